@@ -0,0 +1,100 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// AbortAllResult reports what [Client.AbortAll] stopped.
+type AbortAllResult struct {
+	// SessionIDs are the sessions whose in-flight work the CLI aborted.
+	SessionIDs []string
+	// ToolCalls is how many running handlers of tools registered with the
+	// client's sessions had their ToolInvocation.Context canceled.
+	ToolCalls int
+}
+
+// AbortAll stops every session of the CLI at once: it cancels the contexts of
+// the client's running tool handlers and aborts every in-flight generation and
+// built-in tool call, e.g. for an administrator's "stop the agents now"
+// control. Sessions stay usable, and queued messages are still sent.
+//
+// The CLI aborts all of its sessions in a single sessions.abortAll request,
+// including sessions of other clients connected to it. When the CLI does not
+// implement sessions.abortAll, AbortAll falls back to aborting the client's
+// own sessions concurrently, and returns the errors of the aborts that failed.
+//
+// Example:
+//
+//	http.HandleFunc("/admin/stop", func(w http.ResponseWriter, r *http.Request) {
+//	    result, err := client.AbortAll(r.Context())
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusBadGateway)
+//	        return
+//	    }
+//	    fmt.Fprintf(w, "stopped %d sessions\n", len(result.SessionIDs))
+//	})
+func (c *Client) AbortAll(ctx context.Context) (*AbortAllResult, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	// Cancel tool handlers first, so they stop even if the CLI does not answer.
+	sessions := c.trackedSessions()
+	result := &AbortAllResult{}
+	for _, session := range sessions {
+		result.ToolCalls += session.runningTools.cancel()
+	}
+
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	if capabilities.Supports("sessions.abortAll") {
+		aborted, err := c.RPC.Sessions.AbortAll(ctx, &rpc.SessionsAbortAllParams{})
+		if err != nil {
+			return result, fmt.Errorf("failed to abort sessions: %w", err)
+		}
+		result.SessionIDs = aborted.SessionIDs
+		return result, nil
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for _, session := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := session.Abort(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("session %s: %w", session.SessionID, err))
+				return
+			}
+			result.SessionIDs = append(result.SessionIDs, session.SessionID)
+		}()
+	}
+	wg.Wait()
+	slices.Sort(result.SessionIDs)
+	return result, errors.Join(errs...)
+}
+
+// trackedSessions returns the sessions of the client that are not destroyed.
+func (c *Client) trackedSessions() []*Session {
+	c.sessionsMux.Lock()
+	defer c.sessionsMux.Unlock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		if !session.isDestroyed() {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
@@ -0,0 +1,80 @@
+package copilot
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClient_AbortAll(t *testing.T) {
+	capabilities := func(log *replayLog, methods ...string) {
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"methods":         methods,
+			"features":        map[string]bool{},
+		})
+	}
+	create := func(t *testing.T, client *Client) *Session {
+		t.Helper()
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("aborts every session in one request", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		capabilities(log, "sessions.abortAll")
+		log.call("sessions.abortAll", map[string]any{"sessionIds": []string{"s1", "other-client"}})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session := create(t, client)
+		tool, release := session.newToolContext("t1", "deploy")
+		defer release()
+
+		result, err := client.AbortAll(t.Context())
+		if err != nil {
+			t.Fatalf("AbortAll failed: %v", err)
+		}
+		if !slices.Equal(result.SessionIDs, []string{"s1", "other-client"}) || result.ToolCalls != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		if tool.Context.Err() == nil {
+			t.Error("Expected the running tool's context to be canceled")
+		}
+	})
+
+	t.Run("aborts each session when the CLI lacks sessions.abortAll", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.create", map[string]any{"sessionId": "s2"})
+		capabilities(log, "session.abort")
+		log.call("session.abort", map[string]any{})
+		log.call("session.abort", map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		create(t, client)
+		create(t, client)
+
+		result, err := client.AbortAll(t.Context())
+		if err != nil {
+			t.Fatalf("AbortAll failed: %v", err)
+		}
+		if !slices.Equal(result.SessionIDs, []string{"s1", "s2"}) || result.ToolCalls != 0 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("releases finished tool calls", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		_, release := session.newToolContext("t1", "deploy")
+		release()
+		if n := session.runningTools.cancel(); n != 0 {
+			t.Errorf("Expected no running tool calls, got %d", n)
+		}
+	})
+}
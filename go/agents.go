@@ -0,0 +1,283 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AgentFileError reports a problem with an agent definition file loaded by
+// [LoadAgentsFromDir].
+type AgentFileError struct {
+	// Path is the agent definition file.
+	Path string
+	// Line is the 1-based line the problem was found on, or 0 if it concerns the whole file.
+	Line int
+	// Err describes the problem.
+	Err error
+}
+
+func (e *AgentFileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *AgentFileError) Unwrap() error {
+	return e.Err
+}
+
+// agentNamePattern restricts agent names to those that can be selected by name.
+var agentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// yamlErrorPattern matches yaml syntax errors, which carry a line number.
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// LoadAgentsFromDir parses the custom agent definitions in dir, such as a
+// repository's .github/agents directory, so agents can be versioned with the code
+// they work on. The result is sorted by file name and can be passed as
+// SessionConfig.CustomAgents.
+//
+// Markdown files (.md) hold YAML frontmatter between "---" lines followed by the
+// agent's prompt. YAML files (.yaml, .yml) hold the same keys plus "prompt".
+// The keys are name, displayName, description, tools, mcpServers, infer, and
+// extends. The name defaults to the file name without ".agent.md", ".md", or the YAML
+// extension. Other files and subdirectories are ignored.
+//
+// The first problem in each file is reported as an [*AgentFileError] naming the
+// file and line, and the errors for all files are joined with [errors.Join]. Unknown keys, values of the wrong type,
+// empty prompts, invalid names, and names used by more than one file are errors.
+//
+// Example:
+//
+//	agents, err := copilot.LoadAgentsFromDir(".github/agents")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    CustomAgents:        agents,
+//	})
+func LoadAgentsFromDir(dir string) ([]CustomAgentConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agents directory: %w", err)
+	}
+
+	var agents []CustomAgentConfig
+	var errs []error
+	definedIn := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || agentFileName(entry.Name()) == "" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		agent, nameLine, err := loadAgentFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if first, ok := definedIn[agent.Name]; ok {
+			errs = append(errs, &AgentFileError{Path: path, Line: nameLine, Err: fmt.Errorf("agent %q is already defined in %s", agent.Name, first)})
+			continue
+		}
+		definedIn[agent.Name] = path
+		agents = append(agents, agent)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return agents, nil
+}
+
+// agentFileName returns the default agent name for an agent definition file,
+// or "" if the file is not one.
+func agentFileName(file string) string {
+	for _, ext := range []string{".agent.md", ".md", ".yaml", ".yml"} {
+		if strings.HasSuffix(file, ext) {
+			return strings.TrimSuffix(file, ext)
+		}
+	}
+	return ""
+}
+
+// loadAgentFile parses a single agent definition and returns it with the line
+// its name was taken from.
+func loadAgentFile(path string) (CustomAgentConfig, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CustomAgentConfig{}, 0, &AgentFileError{Path: path, Err: err}
+	}
+	fail := func(line int, format string, args ...any) (CustomAgentConfig, int, error) {
+		return CustomAgentConfig{}, 0, &AgentFileError{Path: path, Line: line, Err: fmt.Errorf(format, args...)}
+	}
+
+	isMarkdown := strings.HasSuffix(path, ".md")
+	header, body := data, ""
+	lineOffset, bodyLine := 0, 1
+	if isMarkdown {
+		header = nil
+		lines := strings.SplitAfter(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\n")
+		if strings.TrimSpace(lines[0]) == "---" {
+			end := slices.IndexFunc(lines[1:], func(line string) bool { return strings.TrimSpace(line) == "---" })
+			if end < 0 {
+				return fail(1, "frontmatter is not closed with ---")
+			}
+			header = []byte(strings.Join(lines[1:end+1], ""))
+			lineOffset, bodyLine = 1, end+3
+			lines = lines[end+2:]
+		}
+		body = strings.TrimSpace(strings.Join(lines, ""))
+	}
+
+	agent := CustomAgentConfig{Name: agentFileName(filepath.Base(path)), Prompt: body}
+	nameLine := 0
+	var doc yaml.Node
+	if err := yaml.Unmarshal(header, &doc); err != nil {
+		// yaml reports "yaml: line N: ..." relative to the frontmatter
+		if m := yamlErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return fail(line+lineOffset, "invalid YAML: %s", m[2])
+		}
+		return fail(0, "invalid YAML: %v", err)
+	}
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return fail(root.Line+lineOffset, "expected a mapping of agent settings")
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], root.Content[i+1]
+			var target any
+			switch key.Value {
+			case "name":
+				target, nameLine = &agent.Name, key.Line+lineOffset
+			case "displayName":
+				target = &agent.DisplayName
+			case "description":
+				target = &agent.Description
+			case "tools":
+				target = &agent.Tools
+			case "mcpServers":
+				target = &agent.MCPServers
+			case "infer":
+				target = &agent.Infer
+			case "extends":
+				target = &agent.Extends
+			case "prompt":
+				if isMarkdown {
+					return fail(key.Line+lineOffset, "prompt must be the Markdown body, not a frontmatter key")
+				}
+				target = &agent.Prompt
+			default:
+				return fail(key.Line+lineOffset, "unknown key %q", key.Value)
+			}
+			if err := value.Decode(target); err != nil {
+				return fail(value.Line+lineOffset, "invalid value for %s", key.Value)
+			}
+		}
+	}
+
+	if !agentNamePattern.MatchString(agent.Name) {
+		return fail(nameLine, "invalid agent name %q: use letters, digits, '.', '_', and '-'", agent.Name)
+	}
+	if strings.TrimSpace(agent.Prompt) == "" && agent.Extends == "" {
+		return fail(bodyLine, "agent %q has no prompt", agent.Name)
+	}
+	return agent, nameLine, nil
+}
+
+// ResolveAgents returns agents with every agent that sets Extends merged with
+// the agent it names, so a team can keep a base agent and thin specializations
+// of it. Sessions resolve SessionConfig.CustomAgents this way automatically;
+// call it directly to inspect the result.
+//
+// A specialization inherits from its base, recursively:
+//   - Prompt: the base prompt followed by a blank line and the agent's own prompt
+//   - Tools: the base allowlist, unless the agent sets its own
+//   - MCPServers: the base servers plus the agent's, which win on name clashes
+//   - DisplayName, Description, and Infer: the base values, unless the agent sets them
+//
+// The input is not modified. Base agents stay in the result and can be selected
+// like any other agent. An Extends naming an agent that is not in the list, or
+// a cycle of Extends, is an error.
+func ResolveAgents(agents []CustomAgentConfig) ([]CustomAgentConfig, error) {
+	if !slices.ContainsFunc(agents, func(a CustomAgentConfig) bool { return a.Extends != "" }) {
+		return agents, nil
+	}
+
+	byName := make(map[string]int, len(agents))
+	for i, agent := range agents {
+		byName[agent.Name] = i
+	}
+	resolved := make([]CustomAgentConfig, len(agents))
+	done := make([]bool, len(agents))
+	var resolve func(i int, chain []string) error
+	resolve = func(i int, chain []string) error {
+		if done[i] {
+			return nil
+		}
+		agent := agents[i]
+		if slices.Contains(chain, agent.Name) {
+			return fmt.Errorf("custom agent %q extends itself: %s", agent.Name, strings.Join(append(chain, agent.Name), " -> "))
+		}
+		if agent.Extends != "" {
+			b, ok := byName[agent.Extends]
+			if !ok {
+				return fmt.Errorf("custom agent %q extends unknown agent %q", agent.Name, agent.Extends)
+			}
+			if err := resolve(b, append(chain, agent.Name)); err != nil {
+				return err
+			}
+			agent = mergeAgent(resolved[b], agent)
+		}
+		resolved[i] = agent
+		done[i] = true
+		return nil
+	}
+	for i := range agents {
+		if err := resolve(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// mergeAgent returns agent with the settings it does not set taken from base.
+func mergeAgent(base, agent CustomAgentConfig) CustomAgentConfig {
+	merged := agent
+	switch {
+	case strings.TrimSpace(base.Prompt) == "":
+	case strings.TrimSpace(agent.Prompt) == "":
+		merged.Prompt = base.Prompt
+	default:
+		merged.Prompt = strings.TrimRight(base.Prompt, "\n") + "\n\n" + agent.Prompt
+	}
+	if merged.Tools == nil {
+		merged.Tools = slices.Clone(base.Tools)
+	}
+	if len(base.MCPServers) > 0 {
+		merged.MCPServers = maps.Clone(base.MCPServers)
+		maps.Copy(merged.MCPServers, agent.MCPServers)
+	}
+	if merged.DisplayName == "" {
+		merged.DisplayName = base.DisplayName
+	}
+	if merged.Description == "" {
+		merged.Description = base.Description
+	}
+	if merged.Infer == nil {
+		merged.Infer = base.Infer
+	}
+	return merged
+}
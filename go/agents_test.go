@@ -0,0 +1,146 @@
+package copilot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAgentsFromDir(t *testing.T) {
+	writeAgents := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+		}
+		return dir
+	}
+
+	t.Run("parses Markdown and YAML definitions", func(t *testing.T) {
+		dir := writeAgents(t, map[string]string{
+			"reviewer.agent.md": "---\ndescription: Reviews diffs\ntools:\n  - view\n  - grep\ninfer: false\n---\n\nYou review code.\n",
+			"planner.yaml":      "name: planner\ndisplayName: Planner\nprompt: |\n  You plan work.\nmcpServers:\n  github:\n    type: http\n    url: https://example.com/mcp\n",
+			"notes.md":          "Plain Markdown is a prompt with no settings.\n",
+			"README.txt":        "not an agent",
+		})
+		if err := os.Mkdir(filepath.Join(dir, "drafts"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		agents, err := LoadAgentsFromDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to load agents: %v", err)
+		}
+		if len(agents) != 3 {
+			t.Fatalf("Expected 3 agents, got %+v", agents)
+		}
+		notes, planner, reviewer := agents[0], agents[1], agents[2]
+		if notes.Name != "notes" || notes.Prompt != "Plain Markdown is a prompt with no settings." {
+			t.Errorf("Unexpected notes agent: %+v", notes)
+		}
+		if planner.Name != "planner" || planner.DisplayName != "Planner" || planner.Prompt != "You plan work.\n" || planner.MCPServers["github"]["url"] != "https://example.com/mcp" {
+			t.Errorf("Unexpected planner agent: %+v", planner)
+		}
+		if reviewer.Name != "reviewer" || reviewer.Description != "Reviews diffs" || reviewer.Prompt != "You review code." {
+			t.Errorf("Unexpected reviewer agent: %+v", reviewer)
+		}
+		if len(reviewer.Tools) != 2 || reviewer.Infer == nil || *reviewer.Infer {
+			t.Errorf("Expected tools and infer=false, got %+v", reviewer)
+		}
+	})
+
+	t.Run("reports errors with file and line", func(t *testing.T) {
+		dir := writeAgents(t, map[string]string{
+			"a.md":        "---\ndescription: ok\ntool: [view]\n---\nPrompt\n",
+			"b.md":        "---\ntools: view\n---\nPrompt\n",
+			"c.md":        "---\ndescription: never closed\nPrompt\n",
+			"d.yaml":      "name: d\n",
+			"e.md":        "---\ndescription: [unbalanced\n---\nPrompt\n",
+			"z.agent.md":  "---\nname: valid\n---\nPrompt\n",
+			"g b.md":      "Prompt\n",
+			"h.md":        "---\nprompt: inline\n---\n",
+			"valid.md":    "Prompt\n",
+			"valid.other": "ignored",
+		})
+
+		_, err := LoadAgentsFromDir(dir)
+		if err == nil {
+			t.Fatal("Expected errors")
+		}
+		var fileErr *AgentFileError
+		if !errors.As(err, &fileErr) {
+			t.Errorf("Expected *AgentFileError, got %T", err)
+		}
+		for _, expected := range []string{
+			"a.md:3: unknown key \"tool\"",
+			"b.md:2: invalid value for tools",
+			"c.md:1: frontmatter is not closed",
+			"d.yaml:1: agent \"d\" has no prompt",
+			"e.md:2: invalid YAML",
+			"z.agent.md:2: agent \"valid\" is already defined in " + filepath.Join(dir, "valid.md"),
+			"g b.md: invalid agent name \"g b\"",
+			"h.md:2: prompt must be the Markdown body",
+		} {
+			if !strings.Contains(err.Error(), filepath.Join(dir, expected)) {
+				t.Errorf("Expected error %q in:\n%v", expected, err)
+			}
+		}
+		if strings.Contains(err.Error(), "valid.md:") {
+			t.Errorf("Expected valid files to load cleanly, got:\n%v", err)
+		}
+	})
+
+	t.Run("fails for a missing directory", func(t *testing.T) {
+		if _, err := LoadAgentsFromDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("Expected error for missing directory")
+		}
+	})
+}
+
+func TestResolveAgents(t *testing.T) {
+	t.Run("merges specializations with their base", func(t *testing.T) {
+		agents := []CustomAgentConfig{
+			{Name: "go-reviewer", Extends: "reviewer", Prompt: "Focus on Go idioms.", MCPServers: map[string]MCPServerConfig{"docs": {"url": "https://go.dev/mcp"}}},
+			{Name: "reviewer", Extends: "base", Description: "Reviews diffs", Tools: []string{"view", "grep"}},
+			{Name: "base", Prompt: "You work for Example Corp.\n", Infer: Bool(false), MCPServers: map[string]MCPServerConfig{
+				"docs":   {"url": "https://example.com/mcp"},
+				"github": {"url": "https://api.github.com/mcp"},
+			}},
+		}
+		resolved, err := ResolveAgents(agents)
+		if err != nil {
+			t.Fatalf("Failed to resolve agents: %v", err)
+		}
+
+		goReviewer, reviewer := resolved[0], resolved[1]
+		if reviewer.Prompt != "You work for Example Corp.\n" || reviewer.Infer == nil || *reviewer.Infer {
+			t.Errorf("Expected the reviewer to inherit the base prompt and infer, got %+v", reviewer)
+		}
+		if goReviewer.Prompt != "You work for Example Corp.\n\nFocus on Go idioms." {
+			t.Errorf("Expected prompts to be concatenated, got %q", goReviewer.Prompt)
+		}
+		if goReviewer.Description != "Reviews diffs" || strings.Join(goReviewer.Tools, ",") != "view,grep" {
+			t.Errorf("Expected the description and tools to be inherited transitively, got %+v", goReviewer)
+		}
+		if goReviewer.MCPServers["docs"]["url"] != "https://go.dev/mcp" || goReviewer.MCPServers["github"] == nil {
+			t.Errorf("Expected MCP servers to be merged with overrides, got %v", goReviewer.MCPServers)
+		}
+		if agents[1].Prompt != "" || agents[0].MCPServers["github"] != nil {
+			t.Error("Expected the input to be unchanged")
+		}
+	})
+
+	t.Run("rejects unknown bases and cycles", func(t *testing.T) {
+		if _, err := ResolveAgents([]CustomAgentConfig{{Name: "a", Extends: "missing"}}); err == nil || !strings.Contains(err.Error(), `unknown agent "missing"`) {
+			t.Errorf("Expected an unknown agent error, got %v", err)
+		}
+		_, err := ResolveAgents([]CustomAgentConfig{{Name: "a", Extends: "b"}, {Name: "b", Extends: "a"}})
+		if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+			t.Errorf("Expected a cycle error, got %v", err)
+		}
+	})
+}
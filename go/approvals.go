@@ -0,0 +1,158 @@
+package copilot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PendingApproval is a permission request waiting in the client's approval queue.
+// See [Client.QueueApproval].
+type PendingApproval struct {
+	// ID identifies the request in calls to [Client.ApprovePermission] and [Client.DenyPermission].
+	ID        string
+	SessionID string
+	Request   PermissionRequest
+	// RequestedAt is when the request entered the queue.
+	RequestedAt time.Time
+}
+
+type approvalDecision struct {
+	result PermissionRequestResult
+	err    error
+}
+
+type pendingApproval struct {
+	approval PendingApproval
+	decision chan approvalDecision
+}
+
+// approvalQueue holds the permission requests of a client that are waiting for a decision.
+type approvalQueue struct {
+	mu      sync.Mutex
+	pending []*pendingApproval
+	nextID  int
+}
+
+func (q *approvalQueue) add(request PermissionRequest, sessionID string) *pendingApproval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.nextID++
+	p := &pendingApproval{
+		approval: PendingApproval{
+			ID:          fmt.Sprintf("approval-%d", q.nextID),
+			SessionID:   sessionID,
+			Request:     request,
+			RequestedAt: time.Now(),
+		},
+		decision: make(chan approvalDecision, 1),
+	}
+	q.pending = append(q.pending, p)
+	return p
+}
+
+// remove takes the request with the given ID out of the queue, reporting
+// whether it was still pending.
+func (q *approvalQueue) remove(id string) (*pendingApproval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, p := range q.pending {
+		if p.approval.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (q *approvalQueue) list() []PendingApproval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	approvals := make([]PendingApproval, len(q.pending))
+	for i, p := range q.pending {
+		approvals[i] = p.approval
+	}
+	return approvals
+}
+
+// cancel fails every pending request with err.
+func (q *approvalQueue) cancel(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range q.pending {
+		p.decision <- approvalDecision{err: err}
+	}
+	q.pending = nil
+}
+
+// QueueApproval is a [PermissionHandlerFunc] that places the request in the client's
+// approval queue and waits for another goroutine, such as an HTTP admin UI, to decide
+// it with [Client.ApprovePermission] or [Client.DenyPermission]. Use it directly as
+// SessionConfig.OnPermissionRequest, or call it from a handler that only defers some
+// requests to a human.
+//
+// The request is denied if it is not decided within ClientOptions.ApprovalTimeout,
+// or if the client is stopped first.
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: client.QueueApproval,
+//	})
+//
+//	// Elsewhere
+//	for _, approval := range client.PendingApprovals() {
+//	    client.ApprovePermission(approval.ID)
+//	}
+func (c *Client) QueueApproval(request PermissionRequest, invocation PermissionInvocation) (PermissionRequestResult, error) {
+	p := c.approvals.add(request, invocation.SessionID)
+	if c.options.OnApprovalQueued != nil {
+		c.options.OnApprovalQueued(p.approval)
+	}
+
+	var timeout <-chan time.Time
+	if c.options.ApprovalTimeout > 0 {
+		timer := time.NewTimer(c.options.ApprovalTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case decision := <-p.decision:
+		return decision.result, decision.err
+	case <-timeout:
+		if _, ok := c.approvals.remove(p.approval.ID); !ok {
+			// Decided at the same moment the timeout fired
+			decision := <-p.decision
+			return decision.result, decision.err
+		}
+		return PermissionRequestResult{}, fmt.Errorf("approval %s timed out after %s", p.approval.ID, c.options.ApprovalTimeout)
+	}
+}
+
+// PendingApprovals returns the permission requests waiting in the approval queue,
+// oldest first. Requests enter the queue through [Client.QueueApproval].
+func (c *Client) PendingApprovals() []PendingApproval {
+	return c.approvals.list()
+}
+
+// ApprovePermission approves the queued permission request with the given ID.
+// Returns an error if no such request is pending.
+func (c *Client) ApprovePermission(id string) error {
+	return c.decideApproval(id, PermissionRequestResult{Kind: "approved"})
+}
+
+// DenyPermission denies the queued permission request with the given ID.
+// Returns an error if no such request is pending.
+func (c *Client) DenyPermission(id string) error {
+	return c.decideApproval(id, PermissionRequestResult{Kind: "denied-interactively-by-user"})
+}
+
+func (c *Client) decideApproval(id string, result PermissionRequestResult) error {
+	p, ok := c.approvals.remove(id)
+	if !ok {
+		return fmt.Errorf("no pending approval with ID %s", id)
+	}
+	p.decision <- approvalDecision{result: result}
+	return nil
+}
@@ -0,0 +1,102 @@
+package copilot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_ApprovalQueue(t *testing.T) {
+	type outcome struct {
+		result PermissionRequestResult
+		err    error
+	}
+	queue := func(client *Client, kind string) chan outcome {
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := client.QueueApproval(PermissionRequest{Kind: kind}, PermissionInvocation{SessionID: "s1"})
+			done <- outcome{result, err}
+		}()
+		return done
+	}
+	await := func(t *testing.T, done chan outcome) outcome {
+		t.Helper()
+		select {
+		case o := <-done:
+			return o
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for approval")
+			return outcome{}
+		}
+	}
+
+	t.Run("approves and denies queued requests", func(t *testing.T) {
+		queued := make(chan PendingApproval, 2)
+		client := NewClient(&ClientOptions{OnApprovalQueued: func(approval PendingApproval) { queued <- approval }})
+
+		shell := queue(client, "shell")
+		first := <-queued
+		write := queue(client, "write")
+		second := <-queued
+
+		pending := client.PendingApprovals()
+		if len(pending) != 2 || pending[0].ID != first.ID || pending[0].SessionID != "s1" || pending[0].Request.Kind != "shell" {
+			t.Fatalf("Unexpected pending approvals: %+v", pending)
+		}
+
+		if err := client.ApprovePermission(first.ID); err != nil {
+			t.Fatalf("Failed to approve: %v", err)
+		}
+		if err := client.DenyPermission(second.ID); err != nil {
+			t.Fatalf("Failed to deny: %v", err)
+		}
+		if o := await(t, shell); o.err != nil || o.result.Kind != "approved" {
+			t.Errorf("Expected approval, got %+v", o)
+		}
+		if o := await(t, write); o.err != nil || o.result.Kind != "denied-interactively-by-user" {
+			t.Errorf("Expected denial, got %+v", o)
+		}
+		if len(client.PendingApprovals()) != 0 {
+			t.Errorf("Expected empty queue, got %+v", client.PendingApprovals())
+		}
+		if err := client.ApprovePermission(first.ID); err == nil {
+			t.Error("Expected error when approving a decided request")
+		}
+	})
+
+	t.Run("denies requests that time out", func(t *testing.T) {
+		client := NewClient(&ClientOptions{ApprovalTimeout: 20 * time.Millisecond})
+		o := await(t, queue(client, "shell"))
+		if o.err == nil || !strings.Contains(o.err.Error(), "timed out") {
+			t.Errorf("Expected timeout error, got %+v", o)
+		}
+		if len(client.PendingApprovals()) != 0 {
+			t.Errorf("Expected timed out request to leave the queue, got %+v", client.PendingApprovals())
+		}
+	})
+
+	t.Run("denies pending requests when the client stops", func(t *testing.T) {
+		queued := make(chan PendingApproval, 1)
+		client := NewClient(&ClientOptions{OnApprovalQueued: func(approval PendingApproval) { queued <- approval }})
+		done := queue(client, "shell")
+		<-queued
+
+		client.ForceStop()
+		if o := await(t, done); o.err == nil {
+			t.Errorf("Expected error after stop, got %+v", o)
+		}
+	})
+
+	t.Run("works as a session permission handler", func(t *testing.T) {
+		client := NewClient(&ClientOptions{ApprovalTimeout: 20 * time.Millisecond})
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(client.QueueApproval)
+
+		if _, err := session.handlePermissionRequest(PermissionRequest{Kind: "shell"}); err == nil {
+			t.Error("Expected timeout error from the session handler")
+		}
+		if log := session.PermissionLog(); len(log) != 1 || !strings.Contains(log[0].Error, "timed out") {
+			t.Errorf("Expected audit record with timeout error, got %+v", log)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// SessionArchiveVersion is the version of the [SessionArchive] format written
+// by [Client.ExportAllSessions].
+const SessionArchiveVersion = 1
+
+// SessionArchive is the JSON document written by [Client.ExportAllSessions] and
+// read by [Client.ImportSessions]:
+//
+//	{
+//	  "version": 1,
+//	  "exportedAt": "2026-01-02T15:04:05Z",
+//	  "cliVersion": "1.2.3",
+//	  "sessions": [
+//	    {
+//	      "sessionId": "...",
+//	      "startTime": "...",
+//	      "modifiedTime": "...",
+//	      "summary": "...",
+//	      "metadata": {"tenant": "acme"},
+//	      "state": {...}
+//	    }
+//	  ]
+//	}
+//
+// Each session carries the fields of [SessionMetadata] for inspection, and its
+// state as exported by the CLI. The state is opaque: it includes the
+// conversation history, checkpoints, and workspace files, and is only meant to
+// be imported by a CLI of the same or a later version.
+type SessionArchive struct {
+	// Version is the archive format version, [SessionArchiveVersion] when written by this SDK.
+	Version int `json:"version"`
+	// ExportedAt is when the archive was written.
+	ExportedAt time.Time `json:"exportedAt"`
+	// CLIVersion is the version of the CLI the sessions were exported from.
+	CLIVersion string `json:"cliVersion,omitempty"`
+	// Sessions holds the exported sessions.
+	Sessions []ArchivedSession `json:"sessions"`
+}
+
+// ArchivedSession is a session in a [SessionArchive].
+type ArchivedSession struct {
+	SessionMetadata
+	// State is the session's state as exported by the CLI.
+	State map[string]any `json:"state"`
+}
+
+// ImportOptions configures [Client.ImportSessions].
+type ImportOptions struct {
+	// Overwrite replaces sessions that already exist with the same ID. By
+	// default the import fails if any session exists.
+	Overwrite bool
+}
+
+// ExportAllSessions writes every session known to the CLI to w as a
+// [SessionArchive], so they can be moved to another host with
+// [Client.ImportSessions], e.g. during a blue/green deployment.
+//
+// Sessions are exported as last persisted by the CLI. Sessions with a message in
+// flight are exported without its response, so wait for them to become idle, or
+// stop sending, before exporting.
+//
+// Example:
+//
+//	// On the old host
+//	f, _ := os.Create("sessions.json")
+//	if err := oldClient.ExportAllSessions(ctx, f); err != nil {
+//	    log.Fatal(err)
+//	}
+//	f.Close()
+//
+//	// On the new host
+//	f, _ = os.Open("sessions.json")
+//	ids, err := newClient.ImportSessions(ctx, f, nil)
+func (c *Client) ExportAllSessions(ctx context.Context, w io.Writer) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	sessions, err := c.ListSessions(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	exported, err := c.RPC.Sessions.Export(ctx, &rpc.SessionsExportParams{})
+	if err != nil {
+		return fmt.Errorf("failed to export sessions: %w", err)
+	}
+
+	metadata := make(map[string]SessionMetadata, len(sessions))
+	for _, session := range sessions {
+		metadata[session.SessionID] = session
+	}
+	archive := SessionArchive{
+		Version:    SessionArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Sessions:   make([]ArchivedSession, 0, len(exported.Sessions)),
+	}
+	if version, err := c.Version(ctx); err == nil {
+		archive.CLIVersion = version
+	}
+	for _, session := range exported.Sessions {
+		info, ok := metadata[session.SessionID]
+		if !ok {
+			info = SessionMetadata{SessionID: session.SessionID}
+		}
+		archive.Sessions = append(archive.Sessions, ArchivedSession{SessionMetadata: info, State: session.Data})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		return fmt.Errorf("failed to write session archive: %w", err)
+	}
+	return nil
+}
+
+// ImportSessions reads a [SessionArchive] written by [Client.ExportAllSessions]
+// and recreates its sessions in the CLI, returning their IDs. Imported sessions
+// are not opened; resume them with [Client.ResumeSession] as usual.
+//
+// The archive is validated before anything is imported. Unless
+// options.Overwrite is set, the import fails if a session with the same ID
+// already exists.
+func (c *Client) ImportSessions(ctx context.Context, r io.Reader, options *ImportOptions) ([]string, error) {
+	var archive SessionArchive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to read session archive: %w", err)
+	}
+	if archive.Version < 1 || archive.Version > SessionArchiveVersion {
+		return nil, fmt.Errorf("unsupported session archive version %d: this SDK reads version %d", archive.Version, SessionArchiveVersion)
+	}
+	params := &rpc.SessionsImportParams{Sessions: make([]rpc.SessionsImportParamsSession, len(archive.Sessions))}
+	seen := make(map[string]bool, len(archive.Sessions))
+	for i, session := range archive.Sessions {
+		if session.SessionID == "" {
+			return nil, fmt.Errorf("session %d in archive has no sessionId", i)
+		}
+		if seen[session.SessionID] {
+			return nil, fmt.Errorf("session %s appears more than once in archive", session.SessionID)
+		}
+		if session.State == nil {
+			return nil, fmt.Errorf("session %s in archive has no state", session.SessionID)
+		}
+		seen[session.SessionID] = true
+		params.Sessions[i] = rpc.SessionsImportParamsSession{SessionID: session.SessionID, Data: session.State}
+	}
+	if len(params.Sessions) == 0 {
+		return nil, nil
+	}
+	if options != nil && options.Overwrite {
+		params.Overwrite = Bool(true)
+	}
+
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	result, err := c.RPC.Sessions.Import(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import sessions: %w", err)
+	}
+	c.options.Logger.Info("sessions imported", "count", len(result.SessionIDs))
+	return result.SessionIDs, nil
+}
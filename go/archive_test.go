@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestClient_ExportAllSessions(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.list", map[string]any{"sessions": []map[string]any{
+		{"sessionId": "s1", "startTime": "2026-01-01T00:00:00Z", "modifiedTime": "2026-01-02T00:00:00Z", "summary": "Fix the build", "metadata": map[string]string{"tenant": "acme"}},
+	}})
+	log.call("sessions.export", map[string]any{"sessions": []map[string]any{
+		{"sessionId": "s1", "data": map[string]any{"events": []any{"e1", "e2"}}},
+		{"sessionId": "s2", "data": map[string]any{"events": []any{}}},
+	}})
+	log.call("capabilities.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion()})
+	client := newPlaybackClientForTest(t, log, nil)
+
+	var out bytes.Buffer
+	if err := client.ExportAllSessions(t.Context(), &out); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	var archive SessionArchive
+	if err := json.Unmarshal(out.Bytes(), &archive); err != nil {
+		t.Fatalf("Failed to parse archive: %v\n%s", err, out.String())
+	}
+	if archive.Version != SessionArchiveVersion || archive.CLIVersion != "1.2.3" || archive.ExportedAt.IsZero() {
+		t.Errorf("Unexpected envelope: %+v", archive)
+	}
+	if len(archive.Sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", archive.Sessions)
+	}
+	first := archive.Sessions[0]
+	if first.SessionID != "s1" || first.Summary == nil || *first.Summary != "Fix the build" || first.Metadata["tenant"] != "acme" {
+		t.Errorf("Expected session metadata in the archive, got %+v", first)
+	}
+	if events, _ := first.State["events"].([]any); len(events) != 2 {
+		t.Errorf("Expected the CLI state to be kept, got %v", first.State)
+	}
+	if !strings.Contains(out.String(), `"sessionId": "s2"`) {
+		t.Errorf("Expected sessions without metadata to be exported, got %s", out.String())
+	}
+}
+
+func TestClient_ImportSessions(t *testing.T) {
+	archive := `{"version":1,"exportedAt":"2026-01-02T00:00:00Z","sessions":[
+		{"sessionId":"s1","summary":"Fix the build","state":{"events":["e1"]}},
+		{"sessionId":"s2","state":{"events":[]}}
+	]}`
+
+	t.Run("imports every session", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("sessions.import", map[string]any{"sessionIds": []string{"s1", "s2"}})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+
+		ids, err := client.ImportSessions(t.Context(), strings.NewReader(archive), &ImportOptions{Overwrite: true})
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+		if !slices.Equal(ids, []string{"s1", "s2"}) {
+			t.Errorf("Unexpected imported sessions: %v", ids)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string                   `json:"method"`
+				Params rpcSessionsImportMessage `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method != "sessions.import" {
+				continue
+			}
+			if !message.Params.Overwrite || len(message.Params.Sessions) != 2 || message.Params.Sessions[0].Data["events"] == nil {
+				t.Errorf("Unexpected import request: %s", record.Message)
+			}
+		}
+	})
+
+	t.Run("validates the archive before importing", func(t *testing.T) {
+		client := NewClient(nil)
+		tests := map[string]string{
+			`{"version":2,"sessions":[]}`:                                                            "unsupported session archive version 2",
+			`{"version":1,"sessions":[{"sessionId":"s1"}]}`:                                          "session s1 in archive has no state",
+			`{"version":1,"sessions":[{"state":{}}]}`:                                                "session 0 in archive has no sessionId",
+			`{"version":1,"sessions":[{"sessionId":"s1","state":{}},{"sessionId":"s1","state":{}}]}`: "appears more than once",
+			`not json`: "failed to read session archive",
+		}
+		for input, expected := range tests {
+			if _, err := client.ImportSessions(t.Context(), strings.NewReader(input), nil); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error containing %q for %s, got %v", expected, input, err)
+			}
+		}
+	})
+}
+
+type rpcSessionsImportMessage struct {
+	Overwrite bool `json:"overwrite"`
+	Sessions  []struct {
+		SessionID string         `json:"sessionId"`
+		Data      map[string]any `json:"data"`
+	} `json:"sessions"`
+}
@@ -0,0 +1,118 @@
+package copilot
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// PermissionAuditRecord describes a single permission request and how it was decided.
+type PermissionAuditRecord struct {
+	// Time is when the CLI asked for permission.
+	Time time.Time
+	// SessionID is the session the request belongs to.
+	SessionID string
+	// Request is the request as received from the CLI. Request.Extra holds the
+	// kind-specific details, such as the command line or tool arguments.
+	Request PermissionRequest
+	// Decision is the result kind sent back to the CLI, e.g. "approved".
+	Decision string
+	// Handler names the function that made the decision, "none" when no
+	// OnPermissionRequest handler was registered and the request was denied by
+	// default, or "remembered" when an earlier [PermissionScope] decided it.
+	Handler string
+	// Error is the handler's error message when it failed. Failed requests are denied.
+	Error string
+	// Duration is how long the handler took to decide.
+	Duration time.Duration
+	// Denial describes the rule that denied the request, or is nil if the
+	// request was approved.
+	Denial *PermissionDeniedError
+}
+
+// permissionAudit holds the permission records of a session.
+type permissionAudit struct {
+	mu      sync.Mutex
+	records []PermissionAuditRecord
+	onAudit func(PermissionAuditRecord)
+}
+
+// PermissionLog returns every permission request this session has handled, oldest first.
+//
+// The returned slice is a copy and may be filtered or modified freely.
+//
+// Example:
+//
+//	for _, record := range session.PermissionLog() {
+//	    if strings.HasPrefix(record.Decision, "denied") {
+//	        fmt.Printf("%s denied %s\n", record.Time.Format(time.RFC3339), record.Request.Kind)
+//	    }
+//	}
+func (s *Session) PermissionLog() []PermissionAuditRecord {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	records := make([]PermissionAuditRecord, len(s.audit.records))
+	copy(records, s.audit.records)
+	return records
+}
+
+// setAuditHook sets the function called with each new audit record.
+func (s *Session) setAuditHook(onAudit func(PermissionAuditRecord)) {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	s.audit.onAudit = onAudit
+}
+
+// getAuditHook returns the function called with each new audit record, or nil.
+func (s *Session) getAuditHook() func(PermissionAuditRecord) {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	return s.audit.onAudit
+}
+
+// recordPermission appends a record for a decided permission request and passes
+// it to the audit hook, if any. A *PermissionDeniedError from the handler is a
+// denial rather than a failure.
+func (s *Session) recordPermission(request PermissionRequest, handler string, result PermissionRequestResult, err error, start time.Time) {
+	record := PermissionAuditRecord{
+		Time:      start,
+		SessionID: s.SessionID,
+		Request:   request,
+		Decision:  result.Kind,
+		Handler:   handler,
+		Duration:  time.Since(start),
+		Denial:    s.permissionDenial(request, handler, result, err),
+	}
+	if _, ok := err.(*PermissionDeniedError); err != nil && !ok {
+		record.Decision = "denied-no-approval-rule-and-could-not-request-from-user"
+		record.Error = err.Error()
+	}
+	if record.Denial != nil {
+		s.toolCalls.deny(record.Denial)
+	}
+
+	s.audit.mu.Lock()
+	s.audit.records = append(s.audit.records, record)
+	onAudit := s.audit.onAudit
+	s.audit.mu.Unlock()
+
+	if onAudit != nil {
+		onAudit(record)
+	}
+}
+
+// permissionHandlerName returns a readable name for handler.
+func permissionHandlerName(handler PermissionHandlerFunc) string {
+	if handler == nil {
+		return "none"
+	}
+	pc := reflect.ValueOf(handler).Pointer()
+	if pc == reflect.ValueOf(PermissionHandler.ApproveAll).Pointer() {
+		return "PermissionHandler.ApproveAll"
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return "unknown"
+}
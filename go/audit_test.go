@@ -0,0 +1,85 @@
+package copilot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSession_PermissionLog(t *testing.T) {
+	request := PermissionRequest{Kind: "shell", ToolCallID: "t1", Extra: map[string]any{"fullCommandText": "rm -rf build"}}
+
+	t.Run("records default denials", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		if _, err := session.handlePermissionRequest(request); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		log := session.PermissionLog()
+		if len(log) != 1 {
+			t.Fatalf("Expected 1 record, got %d", len(log))
+		}
+		if log[0].Handler != "none" {
+			t.Errorf("Expected handler none, got %q", log[0].Handler)
+		}
+		if !strings.HasPrefix(log[0].Decision, "denied") {
+			t.Errorf("Expected denial, got %q", log[0].Decision)
+		}
+		if log[0].SessionID != "s1" || log[0].Request.Extra["fullCommandText"] != "rm -rf build" {
+			t.Errorf("Expected request details to be recorded, got %+v", log[0])
+		}
+		if log[0].Time.IsZero() {
+			t.Error("Expected record time to be set")
+		}
+	})
+
+	t.Run("records handler decisions and errors", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(PermissionHandler.ApproveAll)
+		session.handlePermissionRequest(request)
+
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{}, errors.New("policy service unavailable")
+		})
+		session.handlePermissionRequest(request)
+
+		log := session.PermissionLog()
+		if len(log) != 2 {
+			t.Fatalf("Expected 2 records, got %d", len(log))
+		}
+		if log[0].Decision != "approved" || log[0].Handler != "PermissionHandler.ApproveAll" {
+			t.Errorf("Expected approval by ApproveAll, got %+v", log[0])
+		}
+		if !strings.HasPrefix(log[1].Decision, "denied") || log[1].Error != "policy service unavailable" {
+			t.Errorf("Expected failed handler to be recorded as a denial, got %+v", log[1])
+		}
+		if !strings.Contains(log[1].Handler, "TestSession_PermissionLog") {
+			t.Errorf("Expected handler name to identify the test function, got %q", log[1].Handler)
+		}
+	})
+
+	t.Run("calls the audit hook", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		var audited []PermissionAuditRecord
+		session.setAuditHook(func(record PermissionAuditRecord) {
+			audited = append(audited, record)
+		})
+		session.registerPermissionHandler(PermissionHandler.ApproveAll)
+		session.handlePermissionRequest(request)
+
+		if len(audited) != 1 || audited[0].Decision != "approved" {
+			t.Errorf("Expected hook to receive the approval, got %+v", audited)
+		}
+	})
+
+	t.Run("returns a copy", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.handlePermissionRequest(request)
+
+		log := session.PermissionLog()
+		log[0].Decision = "approved"
+		if session.PermissionLog()[0].Decision == "approved" {
+			t.Error("Expected PermissionLog to return a copy")
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuthChangeType describes how the CLI's authentication state changed.
+type AuthChangeType string
+
+const (
+	// AuthLoggedIn is reported when the CLI becomes authenticated.
+	AuthLoggedIn AuthChangeType = "login"
+	// AuthLoggedOut is reported when the user logs out of the CLI.
+	AuthLoggedOut AuthChangeType = "logout"
+	// AuthTokenExpired is reported when the CLI's token expired or was revoked,
+	// including when the SDK finds out from a request rejected as unauthenticated.
+	AuthTokenExpired AuthChangeType = "tokenExpired"
+	// AuthAccountChanged is reported when the CLI switches to another account,
+	// host, or organization while staying authenticated.
+	AuthAccountChanged AuthChangeType = "accountChanged"
+)
+
+// AuthChangeEvent reports a change of the CLI's authentication state, received
+// by [Client.OnAuthChange] handlers.
+type AuthChangeEvent struct {
+	// Type is how the state changed.
+	Type AuthChangeType `json:"type"`
+	// Status is the new authentication status.
+	Status GetAuthStatusResponse `json:"status"`
+	// Previous is the status before the change, or nil if the client had not
+	// seen one.
+	Previous *GetAuthStatusResponse `json:"-"`
+}
+
+// AuthChangeHandler is a callback for [AuthChangeEvent]s.
+type AuthChangeHandler func(event AuthChangeEvent)
+
+// authWatcher tracks the last known authentication status of a client and the
+// handlers to notify when it changes.
+type authWatcher struct {
+	mu       sync.Mutex
+	status   *GetAuthStatusResponse
+	handlers []authHandler
+	nextID   uint64
+	checking atomic.Bool // set while checkAuth runs
+	dispatch sync.Mutex  // delivers events to handlers one at a time
+}
+
+type authHandler struct {
+	id uint64
+	fn AuthChangeHandler
+}
+
+// OnAuthChange subscribes to changes of the CLI's authentication state: login,
+// logout, token expiry, and switches to another account or organization. Hosts
+// can use it to prompt the user to sign in again instead of seeing requests
+// fail mid-session.
+//
+// The CLI announces changes with auth.statusChanged notifications. In addition,
+// when a request or a session fails as unauthenticated, the client checks
+// [Client.AuthStatus] and reports the change it finds, so token expiry is
+// noticed with CLIs that do not send notifications. Handlers are called on
+// their own goroutine, one event at a time.
+//
+// Returns a function that, when called, unsubscribes the handler.
+//
+// Example:
+//
+//	client.OnAuthChange(func(event copilot.AuthChangeEvent) {
+//	    if !event.Status.IsAuthenticated {
+//	        ui.ShowSignInBanner(event.Type)
+//	    }
+//	})
+func (c *Client) OnAuthChange(handler AuthChangeHandler) func() {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	id := c.auth.nextID
+	c.auth.nextID++
+	c.auth.handlers = append(c.auth.handlers, authHandler{id: id, fn: handler})
+
+	return func() {
+		c.auth.mu.Lock()
+		defer c.auth.mu.Unlock()
+		for i, h := range c.auth.handlers {
+			if h.id == id {
+				c.auth.handlers = append(c.auth.handlers[:i], c.auth.handlers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// AuthStatus returns the CLI's current authentication status. Unlike
+// [Client.GetAuthStatus], it also compares the status with the last one the
+// client saw and notifies [Client.OnAuthChange] handlers of a change, so
+// polling it is enough to follow the state with CLIs that send no
+// notifications.
+func (c *Client) AuthStatus(ctx context.Context) (*GetAuthStatusResponse, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	status, err := c.GetAuthStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.recordAuthStatus(*status, "", false)
+	return status, nil
+}
+
+// handleAuthStatusChanged handles an auth.statusChanged notification.
+func (c *Client) handleAuthStatusChanged(event AuthChangeEvent) {
+	c.recordAuthStatus(event.Status, event.Type, true)
+}
+
+// recordAuthStatus stores status as the last known status and notifies
+// handlers if it differs from the previous one. changeType is used when set;
+// otherwise it is derived from the two statuses. Without a previous status,
+// handlers are only notified when announce is set.
+func (c *Client) recordAuthStatus(status GetAuthStatusResponse, changeType AuthChangeType, announce bool) {
+	c.auth.mu.Lock()
+	previous := c.auth.status
+	c.auth.status = &status
+	if changeType == "" && (previous != nil || announce) {
+		changeType = authChange(previous, status)
+	}
+	handlers := make([]authHandler, len(c.auth.handlers))
+	copy(handlers, c.auth.handlers)
+	c.auth.mu.Unlock()
+
+	if changeType == "" {
+		return
+	}
+	c.options.Logger.Info("auth status changed", "change", string(changeType), "authenticated", status.IsAuthenticated)
+	event := AuthChangeEvent{Type: changeType, Status: status, Previous: previous}
+	c.emitEvent(ClientEvent{Type: ClientAuthChanged, Message: string(changeType), Auth: &event})
+	go func() {
+		// Handlers run off the JSON-RPC read loop so they can call the client
+		c.auth.dispatch.Lock()
+		defer c.auth.dispatch.Unlock()
+		for _, h := range handlers {
+			func() {
+				defer func() { recover() }() // Ignore handler panics
+				h.fn(event)
+			}()
+		}
+	}()
+}
+
+// authChange returns how the status changed from previous, or "" if it did not.
+func authChange(previous *GetAuthStatusResponse, status GetAuthStatusResponse) AuthChangeType {
+	wasAuthenticated := previous != nil && previous.IsAuthenticated
+	switch {
+	case status.IsAuthenticated && !wasAuthenticated:
+		return AuthLoggedIn
+	case !status.IsAuthenticated && wasAuthenticated:
+		return AuthLoggedOut
+	case !status.IsAuthenticated && previous == nil:
+		return AuthLoggedOut
+	case status.IsAuthenticated && (deref(status.Login) != deref(previous.Login) ||
+		deref(status.Host) != deref(previous.Host) ||
+		deref(status.Organization) != deref(previous.Organization)):
+		return AuthAccountChanged
+	}
+	return ""
+}
+
+// checkAuth fetches the auth status after a request failed as unauthenticated
+// and reports the CLI as logged out because its token expired. Concurrent
+// failures share one check.
+func (c *Client) checkAuth() {
+	if !c.auth.checking.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer c.auth.checking.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if c.client == nil {
+			return
+		}
+		status, err := c.GetAuthStatus(ctx)
+		if err != nil {
+			c.options.Logger.Warn("failed to check auth status", "error", err)
+			return
+		}
+		changeType := AuthChangeType("")
+		c.auth.mu.Lock()
+		wasAuthenticated := c.auth.status == nil || c.auth.status.IsAuthenticated
+		c.auth.mu.Unlock()
+		if !status.IsAuthenticated && wasAuthenticated {
+			changeType = AuthTokenExpired
+		}
+		c.recordAuthStatus(*status, changeType, true)
+	}()
+}
+
+// observeAuthError checks the auth status when err from a request to method
+// shows that the CLI is no longer authenticated.
+func (c *Client) observeAuthError(method string, err error) {
+	if err != nil && method != "auth.getStatus" && errors.Is(classifyError(err), ErrUnauthenticated) {
+		c.checkAuth()
+	}
+}
@@ -0,0 +1,101 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthChange(t *testing.T) {
+	str := func(s string) *string { return &s }
+	alice := &GetAuthStatusResponse{IsAuthenticated: true, Login: str("alice")}
+	tests := []struct {
+		name     string
+		previous *GetAuthStatusResponse
+		status   GetAuthStatusResponse
+		want     AuthChangeType
+	}{
+		{"login", &GetAuthStatusResponse{}, *alice, AuthLoggedIn},
+		{"logout", alice, GetAuthStatusResponse{}, AuthLoggedOut},
+		{"account", alice, GetAuthStatusResponse{IsAuthenticated: true, Login: str("bob")}, AuthAccountChanged},
+		{"organization", alice, GetAuthStatusResponse{IsAuthenticated: true, Login: str("alice"), Organization: str("acme")}, AuthAccountChanged},
+		{"unchanged", alice, GetAuthStatusResponse{IsAuthenticated: true, Login: str("alice"), StatusMessage: str("ok")}, ""},
+		{"first logged out", nil, GetAuthStatusResponse{}, AuthLoggedOut},
+	}
+	for _, tt := range tests {
+		if got := authChange(tt.previous, tt.status); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestClient_OnAuthChange(t *testing.T) {
+	startClient := func(t *testing.T, log *replayLog) (*Client, chan AuthChangeEvent) {
+		t.Helper()
+		client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		t.Cleanup(func() { client.ForceStop() })
+		events := make(chan AuthChangeEvent, 4)
+		client.OnAuthChange(func(event AuthChangeEvent) { events <- event })
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start playback client: %v", err)
+		}
+		return client, events
+	}
+	next := func(t *testing.T, events chan AuthChangeEvent) AuthChangeEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for an auth change")
+			return AuthChangeEvent{}
+		}
+	}
+
+	t.Run("reports notifications from the CLI", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.notify("auth.statusChanged", map[string]any{"type": "accountChanged", "status": map[string]any{"isAuthenticated": true, "login": "alice", "organization": "acme"}})
+		_, events := startClient(t, log)
+
+		event := next(t, events)
+		if event.Type != AuthAccountChanged || event.Status.Organization == nil || *event.Status.Organization != "acme" {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	})
+
+	t.Run("reports token expiry after an unauthenticated request", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("auth.getStatus", map[string]any{"isAuthenticated": true, "login": "alice"})
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "models.list", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "Bad credentials", "data": map[string]any{"code": "unauthenticated"}}})
+		log.call("auth.getStatus", map[string]any{"isAuthenticated": false, "statusMessage": "token expired"})
+		client, events := startClient(t, log)
+
+		status, err := client.AuthStatus(t.Context())
+		if err != nil || !status.IsAuthenticated {
+			t.Fatalf("Unexpected status: %+v, %v", status, err)
+		}
+		select {
+		case event := <-events:
+			t.Fatalf("Expected no event for the first status, got %+v", event)
+		default:
+		}
+
+		if _, err := client.ListModels(t.Context()); !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("Expected ErrUnauthenticated, got %v", err)
+		}
+		event := next(t, events)
+		if event.Type != AuthTokenExpired || event.Status.IsAuthenticated || event.Previous == nil || *event.Previous.Login != "alice" {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	})
+}
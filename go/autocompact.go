@@ -0,0 +1,166 @@
+package copilot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AutoCompactSource is the Data.Source of the [SessionCompactionComplete]
+// events dispatched for compactions triggered by [AutoCompactConfig], which
+// tells them apart from the events of the CLI.
+const AutoCompactSource = "sdk.autoCompact"
+
+// autoCompactor triggers compaction for a session according to an AutoCompactConfig.
+type autoCompactor struct {
+	session *Session
+	config  AutoCompactConfig
+	ctx     context.Context // cancelled by stop
+	cancel  context.CancelFunc
+
+	mu            sync.Mutex
+	tokens        int
+	messages      int
+	idleTimer     *time.Timer
+	running       bool
+	compactedIdle bool // set after an idle compaction until the session is active again
+	stopped       bool
+}
+
+func newAutoCompactor(session *Session, config AutoCompactConfig) *autoCompactor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &autoCompactor{session: session, config: config, ctx: ctx, cancel: cancel}
+}
+
+// observe updates compaction state from a session event. It is called on the
+// event dispatch path, so compaction itself runs on a separate goroutine.
+func (a *autoCompactor) observe(event SessionEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return
+	}
+
+	switch event.Type {
+	case SessionUsageInfo:
+		if event.Data.CurrentTokens != nil {
+			a.tokens = int(*event.Data.CurrentTokens)
+		}
+		if event.Data.MessagesLength != nil {
+			a.messages = int(*event.Data.MessagesLength)
+		}
+	case SessionIdle:
+		if reason := a.thresholdReached(); reason != "" {
+			a.startLocked(reason)
+			return
+		}
+		if a.config.IdleTime > 0 && !a.compactedIdle {
+			a.stopIdleTimerLocked()
+			a.idleTimer = time.AfterFunc(a.config.IdleTime, func() {
+				a.mu.Lock()
+				defer a.mu.Unlock()
+				if !a.stopped {
+					a.compactedIdle = true
+					a.startLocked("idle")
+				}
+			})
+		}
+	case SessionCompactionStart, SessionCompactionComplete:
+		// Compaction events are not session activity
+	default:
+		a.stopIdleTimerLocked()
+		a.compactedIdle = false
+	}
+}
+
+// thresholdReached returns the name of the first reached threshold, or "".
+// Must be called with a.mu held.
+func (a *autoCompactor) thresholdReached() string {
+	if a.config.TokenThreshold > 0 && a.tokens >= a.config.TokenThreshold {
+		return "tokens"
+	}
+	if a.config.MessageThreshold > 0 && a.messages >= a.config.MessageThreshold {
+		return "messages"
+	}
+	return ""
+}
+
+// startLocked runs a compaction in the background unless one is already running.
+// Must be called with a.mu held.
+func (a *autoCompactor) startLocked(reason string) {
+	if a.running {
+		return
+	}
+	a.running = true
+	go a.compact(reason)
+}
+
+// compact compacts the session while holding its turn, so that SendAndWait
+// does not start a turn on a history that is being rewritten. A compaction is
+// skipped if a message was sent before the turn became free; the next
+// session.idle checks the thresholds again.
+func (a *autoCompactor) compact(reason string) {
+	defer func() {
+		a.mu.Lock()
+		a.running = false
+		a.mu.Unlock()
+	}()
+
+	select {
+	case a.session.turn <- struct{}{}:
+	case <-a.ctx.Done():
+		return
+	}
+	if a.session.busy.Load() {
+		<-a.session.turn
+		return
+	}
+	result, err := a.session.RPC.Compaction.Compact(a.ctx)
+	<-a.session.turn
+
+	a.mu.Lock()
+	if err == nil {
+		a.tokens = 0
+		a.messages = 0
+	}
+	stopped := a.stopped
+	a.mu.Unlock()
+
+	if stopped {
+		return
+	}
+
+	source := AutoCompactSource
+	data := Data{Reason: &reason, Source: &source, Success: Bool(err == nil)}
+	if err == nil {
+		data.Success = &result.Success
+		data.MessagesRemoved = &result.MessagesRemoved
+		data.TokensRemoved = &result.TokensRemoved
+	} else {
+		message := err.Error()
+		data.Message = &message
+	}
+	a.session.dispatchEvent(SessionEvent{
+		Type:      SessionCompactionComplete,
+		Timestamp: time.Now(),
+		Ephemeral: Bool(true),
+		Data:      data,
+	})
+}
+
+// stop cancels any pending idle compaction and the compaction in progress.
+func (a *autoCompactor) stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopped = true
+	a.stopIdleTimerLocked()
+	a.cancel()
+}
+
+func (a *autoCompactor) stopIdleTimerLocked() {
+	if a.idleTimer != nil {
+		a.idleTimer.Stop()
+		a.idleTimer = nil
+	}
+}
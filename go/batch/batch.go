@@ -0,0 +1,269 @@
+// Package batch sends a list of prompts through a pool of sessions, for
+// offline jobs such as evaluations and data labeling. Each prompt is answered
+// in a fresh session, so prompts do not see each other's history.
+//
+// Example:
+//
+//	report, err := batch.RunBatch(ctx, prompts, batch.Options{
+//	    Client: client,
+//	    Config: &copilot.SessionConfig{
+//	        OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    },
+//	    Concurrency: 8,
+//	    OnProgress: func(p batch.Progress) {
+//	        log.Printf("%d/%d done, %d failed", p.Completed, p.Total, p.Failed)
+//	    },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, result := range report.Results {
+//	    fmt.Printf("%d\t%s\n", result.Index, result.Output)
+//	}
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Options configures [RunBatch].
+type Options struct {
+	// Pool provides the sessions. When nil, RunBatch creates a pool of
+	// Concurrency sessions from Client and Config and closes it when done.
+	Pool *copilot.SessionPool
+	// Client and Config create the pool when Pool is nil. Config must set
+	// OnPermissionRequest and must not set SessionID.
+	Client *copilot.Client
+	Config *copilot.SessionConfig
+	// Concurrency is the number of prompts in flight at once. Default: 4.
+	Concurrency int
+	// MaxAttempts is the number of times a prompt is sent before its error is
+	// recorded, each time in a new session. Default: 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry of a prompt, doubled for each
+	// later retry. A longer [copilot.SDKError.RetryAfter] from the CLI takes
+	// precedence. Default: 1s.
+	Backoff time.Duration
+	// Timeout bounds how long each attempt waits for the reply, as
+	// MessageOptions.Timeout. Default: the SendAndWait default of 60 seconds.
+	Timeout time.Duration
+	// Retryable reports whether a failed attempt should be retried. Default:
+	// [Retryable].
+	Retryable func(err error) bool
+	// OnProgress, when non-nil, is called after each prompt finishes. Calls are
+	// serialized, so it may update shared state without locking.
+	OnProgress func(Progress)
+}
+
+// Result is the outcome of one prompt.
+type Result struct {
+	// Index is the position of the prompt in the batch.
+	Index  int
+	Prompt string
+	// Output is the content of the final assistant message, if the prompt
+	// succeeded.
+	Output string
+	// SessionID is the session of the last attempt.
+	SessionID string
+	// Attempts is the number of times the prompt was sent.
+	Attempts int
+	// Duration is the time spent on the prompt, including retries.
+	Duration time.Duration
+	// Err is the error of the last attempt, if the prompt failed.
+	Err error
+}
+
+// Progress reports a finished prompt to Options.OnProgress.
+type Progress struct {
+	// Result is the prompt that finished.
+	Result Result
+	// Completed is the number of prompts finished so far, including Failed.
+	Completed int
+	// Failed is the number of prompts that failed so far.
+	Failed int
+	// Total is the number of prompts in the batch.
+	Total int
+}
+
+// Report collates the results of [RunBatch].
+type Report struct {
+	// Results holds one result per prompt, in the order of the prompts.
+	Results   []Result
+	Succeeded int
+	Failed    int
+	Duration  time.Duration
+}
+
+// Failures returns the results of the prompts that failed.
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Retryable is the default Options.Retryable. It retries timeouts, rate
+// limits, and an unavailable CLI or open circuit breaker.
+func Retryable(err error) bool {
+	return errors.Is(err, copilot.ErrTimeout) ||
+		errors.Is(err, copilot.ErrRateLimited) ||
+		errors.Is(err, copilot.ErrCLIUnavailable) ||
+		errors.Is(err, copilot.ErrCircuitOpen)
+}
+
+// sendFunc answers one prompt, returning the reply and the session used.
+type sendFunc func(ctx context.Context, prompt string) (output, sessionID string, err error)
+
+// RunBatch answers each prompt in a session from the pool, with up to
+// Concurrency prompts in flight, and retries failed prompts as configured.
+// Prompts that fail are recorded in the report rather than stopping the batch.
+// If ctx is canceled, prompts that had not finished are recorded with its
+// error, and RunBatch returns the report along with ctx.Err().
+func RunBatch(ctx context.Context, prompts []string, options Options) (*Report, error) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	options.Concurrency = max(min(options.Concurrency, len(prompts)), 1)
+
+	pool := options.Pool
+	if pool == nil {
+		if options.Client == nil {
+			return nil, errors.New("batch: Options.Pool or Options.Client is required")
+		}
+		var err error
+		if pool, err = options.Client.NewSessionPool(options.Concurrency, options.Config); err != nil {
+			return nil, fmt.Errorf("batch: %w", err)
+		}
+		defer pool.Close()
+	}
+
+	send := func(ctx context.Context, prompt string) (string, string, error) {
+		session, err := pool.Acquire(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		defer pool.Release(session)
+		response, err := session.SendAndWait(ctx, copilot.MessageOptions{Prompt: prompt, Timeout: options.Timeout})
+		if err != nil {
+			return "", session.SessionID, err
+		}
+		if response == nil || response.Data.Content == nil {
+			return "", session.SessionID, errors.New("no assistant message received")
+		}
+		return *response.Data.Content, session.SessionID, nil
+	}
+	return run(ctx, prompts, options, send)
+}
+
+// run fans the prompts out to Concurrency workers.
+func run(ctx context.Context, prompts []string, options Options, send sendFunc) (*Report, error) {
+	start := time.Now()
+	report := &Report{Results: make([]Result, len(prompts))}
+	finished := make([]bool, len(prompts))
+
+	var mu sync.Mutex // guards report, finished, and OnProgress calls
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range options.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := runPrompt(ctx, i, prompts[i], options, send)
+
+				mu.Lock()
+				report.Results[i] = result
+				finished[i] = true
+				if result.Err != nil {
+					report.Failed++
+				} else {
+					report.Succeeded++
+				}
+				if options.OnProgress != nil {
+					options.OnProgress(Progress{
+						Result:    result,
+						Completed: report.Succeeded + report.Failed,
+						Failed:    report.Failed,
+						Total:     len(prompts),
+					})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range prompts {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, done := range finished {
+		if !done {
+			report.Results[i] = Result{Index: i, Prompt: prompts[i], Err: ctx.Err()}
+			report.Failed++
+		}
+	}
+	report.Duration = time.Since(start)
+	return report, ctx.Err()
+}
+
+// runPrompt sends one prompt until it succeeds, fails with an error that is
+// not retryable, or runs out of attempts.
+func runPrompt(ctx context.Context, index int, prompt string, options Options, send sendFunc) Result {
+	maxAttempts := options.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	backoff := options.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	retryable := options.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	start := time.Now()
+	result := Result{Index: index, Prompt: prompt}
+	for {
+		result.Attempts++
+		var err error
+		result.Output, result.SessionID, err = send(ctx, prompt)
+		result.Err = err
+		if err == nil || result.Attempts >= maxAttempts || ctx.Err() != nil || !retryable(err) {
+			break
+		}
+
+		wait := backoff
+		var sdkErr *copilot.SDKError
+		if errors.As(err, &sdkErr) && sdkErr.RetryAfter > wait {
+			wait = sdkErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			result.Duration = time.Since(start)
+			return result
+		}
+		backoff *= 2
+	}
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("collates results in prompt order", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		send := func(ctx context.Context, prompt string) (string, string, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			if prompt == "bad" {
+				return "", "s-" + prompt, errors.New("model refused")
+			}
+			return strings.ToUpper(prompt), "s-" + prompt, nil
+		}
+
+		var progress []Progress
+		prompts := []string{"a", "b", "bad", "c", "d", "e"}
+		report, err := run(t.Context(), prompts, Options{
+			Concurrency: 2,
+			OnProgress:  func(p Progress) { progress = append(progress, p) },
+		}, send)
+		if err != nil {
+			t.Fatalf("Failed to run batch: %v", err)
+		}
+
+		for i, result := range report.Results {
+			if result.Index != i || result.Prompt != prompts[i] || result.SessionID != "s-"+prompts[i] {
+				t.Errorf("Result %d: unexpected %+v", i, result)
+			}
+		}
+		if report.Results[0].Output != "A" || report.Results[5].Output != "E" {
+			t.Errorf("Unexpected outputs: %+v", report.Results)
+		}
+		if report.Succeeded != 5 || report.Failed != 1 {
+			t.Errorf("Expected 5 succeeded and 1 failed, got %d and %d", report.Succeeded, report.Failed)
+		}
+		failures := report.Failures()
+		if len(failures) != 1 || failures[0].Prompt != "bad" || failures[0].Attempts != 1 {
+			t.Errorf("Expected the failure without retries, got %+v", failures)
+		}
+		if peak.Load() != 2 {
+			t.Errorf("Expected 2 prompts in flight, got %d", peak.Load())
+		}
+		if len(progress) != 6 || progress[5].Completed != 6 || progress[5].Failed != 1 || progress[5].Total != 6 {
+			t.Errorf("Unexpected progress: %+v", progress)
+		}
+	})
+
+	t.Run("retries retryable errors", func(t *testing.T) {
+		var mu sync.Mutex
+		attempts := map[string]int{}
+		send := func(ctx context.Context, prompt string) (string, string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[prompt]++
+			switch {
+			case prompt == "flaky" && attempts[prompt] < 3:
+				return "", "", fmt.Errorf("failed to send message: %w", &copilot.SDKError{Code: copilot.ErrorCodeTimeout})
+			case prompt == "down":
+				return "", "", &copilot.SDKError{Code: copilot.ErrorCodeCLIUnavailable}
+			}
+			return "ok", "", nil
+		}
+
+		report, err := run(t.Context(), []string{"flaky", "down"}, Options{Concurrency: 2, MaxAttempts: 3, Backoff: time.Millisecond}, send)
+		if err != nil {
+			t.Fatalf("Failed to run batch: %v", err)
+		}
+		if result := report.Results[0]; result.Err != nil || result.Attempts != 3 || result.Output != "ok" {
+			t.Errorf("Expected success on the third attempt, got %+v", result)
+		}
+		if result := report.Results[1]; !errors.Is(result.Err, copilot.ErrCLIUnavailable) || result.Attempts != 3 {
+			t.Errorf("Expected failure after 3 attempts, got %+v", result)
+		}
+	})
+
+	t.Run("records unfinished prompts when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		send := func(ctx context.Context, prompt string) (string, string, error) {
+			cancel()
+			<-ctx.Done()
+			return "", "", ctx.Err()
+		}
+
+		report, err := run(ctx, []string{"a", "b", "c"}, Options{Concurrency: 1}, send)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if report.Failed != 3 || report.Succeeded != 0 {
+			t.Errorf("Expected every prompt to fail, got %+v", report)
+		}
+		for _, result := range report.Results {
+			if !errors.Is(result.Err, context.Canceled) {
+				t.Errorf("Expected cancellation for %q, got %v", result.Prompt, result.Err)
+			}
+		}
+	})
+}
+
+func TestRunBatch(t *testing.T) {
+	if _, err := RunBatch(t.Context(), []string{"a"}, Options{}); err == nil {
+		t.Error("Expected an error without a pool or client")
+	}
+	client := copilot.NewClient(nil)
+	if _, err := RunBatch(t.Context(), []string{"a"}, Options{Client: client}); err == nil || !strings.Contains(err.Error(), "OnPermissionRequest") {
+		t.Errorf("Expected the session config to be checked, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Capabilities describes what the connected CLI supports, as reported by [Client.Capabilities].
+type Capabilities struct {
+	// Version is the CLI version.
+	Version string
+	// ProtocolVersion is the SDK protocol version the CLI speaks.
+	ProtocolVersion int
+	// Methods lists the JSON-RPC methods the CLI implements, sorted. It is nil
+	// when the CLI predates capability discovery.
+	Methods []string
+	// Features maps the names of the CLI's feature flags to whether they are enabled.
+	Features map[string]bool
+}
+
+// Supports reports whether the CLI implements the JSON-RPC method, such as
+// "session.summarize". It reports false for every method when the CLI predates
+// capability discovery, so callers fall back to behavior that older CLIs support.
+func (c *Capabilities) Supports(method string) bool {
+	_, found := slices.BinarySearch(c.Methods, method)
+	return found
+}
+
+// HasFeature reports whether the named feature flag is enabled in the CLI.
+func (c *Capabilities) HasFeature(name string) bool {
+	return c.Features[name]
+}
+
+// Capabilities reports the CLI version, the RPC methods it implements, and its
+// feature flags, so that code can degrade gracefully when talking to an older CLI.
+//
+// The result is cached until the client disconnects. When the CLI does not
+// implement capability discovery, the version comes from status.get and
+// Methods and Features are empty.
+//
+// Example:
+//
+//	caps, err := client.Capabilities(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if caps.Supports("session.summarize") {
+//	    summary, err = session.RPC.Summarize(ctx, nil)
+//	} else {
+//	    summary, err = summarizeLocally(ctx, session)
+//	}
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+
+	c.capabilitiesMux.Lock()
+	defer c.capabilitiesMux.Unlock()
+
+	if c.capabilities == nil {
+		capabilities, err := c.fetchCapabilities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.capabilities = capabilities
+	}
+
+	// Return a copy to prevent cache mutation
+	capabilities := *c.capabilities
+	capabilities.Methods = slices.Clone(c.capabilities.Methods)
+	capabilities.Features = maps.Clone(c.capabilities.Features)
+	return &capabilities, nil
+}
+
+// Version returns the version of the connected CLI.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		return "", err
+	}
+	return capabilities.Version, nil
+}
+
+func (c *Client) fetchCapabilities(ctx context.Context) (*Capabilities, error) {
+	result, err := c.RPC.Capabilities.Get(ctx)
+	if err == nil {
+		methods := slices.Clone(result.Methods)
+		slices.Sort(methods)
+		return &Capabilities{
+			Version:         result.Version,
+			ProtocolVersion: int(result.ProtocolVersion),
+			Methods:         methods,
+			Features:        result.Features,
+		}, nil
+	}
+
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.RPCError == nil || sdkErr.RPCError.Code != rpcMethodNotFound {
+		return nil, err
+	}
+	status, err := c.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Capabilities{Version: status.Version, ProtocolVersion: status.ProtocolVersion}, nil
+}
+
+// rpcMethodNotFound is the JSON-RPC error code for a method the server does not implement.
+const rpcMethodNotFound = -32601
@@ -0,0 +1,72 @@
+package copilot
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	t.Run("reports and caches CLI capabilities", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"methods":         []string{"session.send", "session.create", "session.summarize"},
+			"features":        map[string]bool{"memory": true, "sandbox": false},
+		})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		caps, err := client.Capabilities(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get capabilities: %v", err)
+		}
+		if caps.Version != "1.2.3" || caps.ProtocolVersion != GetSdkProtocolVersion() {
+			t.Errorf("Unexpected version info: %+v", caps)
+		}
+		if !caps.Supports("session.summarize") || !caps.Supports("session.create") || caps.Supports("session.rewind") {
+			t.Errorf("Unexpected method support: %v", caps.Methods)
+		}
+		if !caps.HasFeature("memory") || caps.HasFeature("sandbox") || caps.HasFeature("unknown") {
+			t.Errorf("Unexpected features: %v", caps.Features)
+		}
+
+		// Served from the cache; the playback log has no second capabilities.get
+		caps.Features["sandbox"] = true
+		version, err := client.Version(t.Context())
+		if err != nil || version != "1.2.3" {
+			t.Errorf("Expected cached version 1.2.3, got %q (err=%v)", version, err)
+		}
+		if again, _ := client.Capabilities(t.Context()); again.HasFeature("sandbox") {
+			t.Error("Expected cached capabilities to be unaffected by caller mutation")
+		}
+	})
+
+	t.Run("falls back to status for older CLIs", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "capabilities.get", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32601, "message": "Method not found"}})
+		log.call("status.get", map[string]any{"version": "0.9.0", "protocolVersion": GetSdkProtocolVersion()})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		caps, err := client.Capabilities(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get capabilities: %v", err)
+		}
+		if caps.Version != "0.9.0" || caps.Methods != nil || caps.Supports("session.send") {
+			t.Errorf("Expected version-only capabilities, got %+v", caps)
+		}
+	})
+
+	t.Run("requires a connection", func(t *testing.T) {
+		if _, err := NewClient(nil).Capabilities(t.Context()); err == nil {
+			t.Error("Expected error when not connected")
+		}
+	})
+}
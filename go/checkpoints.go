@@ -0,0 +1,91 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// Checkpoint is a named snapshot of a session's conversation history, created
+// with [Session.Checkpoint].
+type Checkpoint struct {
+	// Name identifies the checkpoint within its session.
+	Name string
+	// EventID is the ID of the last event included in the checkpoint.
+	EventID string
+	// EventCount is the number of events in the history at the checkpoint.
+	EventCount int
+	// CreatedAt is when the checkpoint was created.
+	CreatedAt time.Time
+}
+
+func newCheckpoint(name, eventID string, eventCount float64, createdAt string) Checkpoint {
+	created, _ := time.Parse(time.RFC3339, createdAt)
+	return Checkpoint{Name: name, EventID: eventID, EventCount: int(eventCount), CreatedAt: created}
+}
+
+// Checkpoint snapshots the session's conversation history under name, so it can
+// later be restored with [Session.RestoreCheckpoint]. Snapshots are kept by the
+// CLI. An existing checkpoint with the same name is replaced.
+//
+// Example:
+//
+//	session.Checkpoint(ctx, "before-refactor")
+//	session.SendAndWait(ctx, copilot.MessageOptions{Prompt: "Refactor the parser"})
+//	// Undo the refactor turn
+//	session.RestoreCheckpoint(ctx, "before-refactor")
+func (s *Session) Checkpoint(ctx context.Context, name string) (*Checkpoint, error) {
+	if name == "" {
+		return nil, fmt.Errorf("checkpoint name is required")
+	}
+	result, err := s.RPC.Checkpoints.Create(ctx, &rpc.SessionCheckpointsCreateParams{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	c := result.Checkpoint
+	checkpoint := newCheckpoint(c.Name, c.EventID, c.EventCount, c.CreatedAt)
+	return &checkpoint, nil
+}
+
+// RestoreCheckpoint rewinds the session's conversation history to the checkpoint
+// with the given name, discarding the turns after it. Other checkpoints are kept,
+// so restoring a later checkpoint redoes the discarded turns.
+//
+// Returns an error if a message is being processed; wait for the session to become
+// idle, or call [Session.Abort], first.
+func (s *Session) RestoreCheckpoint(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("checkpoint name is required")
+	}
+	if s.busy.Load() {
+		return fmt.Errorf("cannot restore checkpoint %q while a message is being processed", name)
+	}
+	if _, err := s.RPC.Checkpoints.Restore(ctx, &rpc.SessionCheckpointsRestoreParams{Name: name}); err != nil {
+		return fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+	s.logger.Info("checkpoint restored", "checkpoint", name)
+	return nil
+}
+
+// ListCheckpoints returns the session's checkpoints, oldest first.
+func (s *Session) ListCheckpoints(ctx context.Context) ([]Checkpoint, error) {
+	result, err := s.RPC.Checkpoints.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	checkpoints := make([]Checkpoint, len(result.Checkpoints))
+	for i, c := range result.Checkpoints {
+		checkpoints[i] = newCheckpoint(c.Name, c.EventID, c.EventCount, c.CreatedAt)
+	}
+	return checkpoints, nil
+}
+
+// DeleteCheckpoint removes the checkpoint with the given name.
+func (s *Session) DeleteCheckpoint(ctx context.Context, name string) error {
+	if _, err := s.RPC.Checkpoints.Delete(ctx, &rpc.SessionCheckpointsDeleteParams{Name: name}); err != nil {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
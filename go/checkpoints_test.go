@@ -0,0 +1,81 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession_Checkpoints(t *testing.T) {
+	checkpoint := map[string]any{"name": "before-refactor", "eventId": "e7", "eventCount": 7, "createdAt": "2026-01-02T03:04:05Z"}
+
+	t.Run("creates, lists, restores, and deletes checkpoints", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.checkpoints.create", map[string]any{"checkpoint": checkpoint})
+		log.call("session.checkpoints.list", map[string]any{"checkpoints": []any{checkpoint}})
+		log.call("session.checkpoints.restore", map[string]any{"eventsRemoved": 4})
+		log.call("session.checkpoints.delete", map[string]any{})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		created, err := session.Checkpoint(t.Context(), "before-refactor")
+		if err != nil {
+			t.Fatalf("Failed to create checkpoint: %v", err)
+		}
+		expected := Checkpoint{Name: "before-refactor", EventID: "e7", EventCount: 7, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+		if *created != expected {
+			t.Errorf("Expected %+v, got %+v", expected, *created)
+		}
+		checkpoints, err := session.ListCheckpoints(t.Context())
+		if err != nil || len(checkpoints) != 1 || checkpoints[0] != expected {
+			t.Errorf("Expected [%+v], got %+v (err=%v)", expected, checkpoints, err)
+		}
+		if err := session.RestoreCheckpoint(t.Context(), "before-refactor"); err != nil {
+			t.Errorf("Failed to restore checkpoint: %v", err)
+		}
+		if err := session.DeleteCheckpoint(t.Context(), "before-refactor"); err != nil {
+			t.Errorf("Failed to delete checkpoint: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var frame struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &frame)
+			if strings.HasPrefix(frame.Method, "session.checkpoints.") && frame.Method != "session.checkpoints.list" {
+				if frame.Params["sessionId"] != "s1" || frame.Params["name"] != "before-refactor" {
+					t.Errorf("Unexpected params for %s: %v", frame.Method, frame.Params)
+				}
+			}
+		}
+	})
+
+	t.Run("refuses to restore during a turn", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.busy.Store(true)
+		if err := session.RestoreCheckpoint(t.Context(), "before-refactor"); err == nil || !strings.Contains(err.Error(), "being processed") {
+			t.Errorf("Expected busy error, got %v", err)
+		}
+	})
+
+	t.Run("requires a name", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		if _, err := session.Checkpoint(t.Context(), ""); err == nil {
+			t.Error("Expected error for empty checkpoint name")
+		}
+		if err := session.RestoreCheckpoint(t.Context(), ""); err == nil {
+			t.Error("Expected error for empty checkpoint name")
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a client's circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through. This is the normal state.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails requests with [ErrCircuitOpen] without sending them.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through to test whether the
+	// CLI has recovered, and fails other requests until it completes.
+	CircuitHalfOpen CircuitState = "halfOpen"
+)
+
+// CircuitBreakerConfig configures the circuit breaker enabled by
+// [ClientOptions.CircuitBreaker].
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive requests that time out or
+	// find the CLI unavailable before the breaker opens. Default: 5.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting a probe
+	// request through. Default: 30s.
+	OpenTimeout time.Duration
+	// ProbeTimeout is how long the probe request may go unanswered before it
+	// counts as failed and the breaker opens again. Default: 10s.
+	ProbeTimeout time.Duration
+	// OnStateChange, when non-nil, is called when the breaker changes state,
+	// e.g. to mark the service unhealthy. It must not block.
+	OnStateChange func(from, to CircuitState)
+}
+
+// circuitBreaker fails requests fast after repeated timeouts or CLI crashes,
+// and lets a probe through after OpenTimeout to detect recovery.
+type circuitBreaker struct {
+	config   CircuitBreakerConfig
+	logger   *slog.Logger
+	now      func() time.Time
+	onChange func(from, to CircuitState) // reports changes to Client.Events
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the breaker last opened
+	probing  bool      // set while the half-open probe is in flight
+	probe    uint64    // identifies the latest probe, so a late outcome is ignored
+	probedAt time.Time // when the latest probe was let through
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig, logger *slog.Logger) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = 10 * time.Second
+	}
+	return &circuitBreaker{config: config, logger: logger, now: time.Now, state: CircuitClosed}
+}
+
+// allow is the request gate. It returns an [ErrCircuitOpen] error when the
+// request should not be sent, and otherwise a function that records the
+// request's outcome.
+func (b *circuitBreaker) allow(method string) (func(error), error) {
+	b.mu.Lock()
+	from := b.state
+	if b.state == CircuitOpen {
+		if wait := b.openedAt.Add(b.config.OpenTimeout).Sub(b.now()); wait > 0 {
+			b.mu.Unlock()
+			return nil, b.openError(wait)
+		}
+		b.state = CircuitHalfOpen
+	}
+	if b.state == CircuitHalfOpen && b.probing {
+		if b.now().Sub(b.probedAt) < b.config.ProbeTimeout {
+			b.mu.Unlock()
+			return nil, b.openError(0)
+		}
+		// The probe hung, which is as good as a timeout
+		b.open()
+		b.mu.Unlock()
+		b.notify(from, CircuitOpen)
+		return nil, b.openError(b.config.OpenTimeout)
+	}
+	var probe uint64 // zero for requests that are not probes
+	if b.state == CircuitHalfOpen {
+		b.probe++
+		probe = b.probe
+		b.probing = true
+		b.probedAt = b.now()
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return func(err error) { b.record(probe, err) }, nil
+}
+
+// record updates the breaker with the outcome of a request, or of the probe
+// with the given ID if it is not zero.
+func (b *circuitBreaker) record(probe uint64, err error) {
+	failed := errors.Is(err, ErrTimeout) || errors.Is(err, ErrCLIUnavailable)
+
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case probe != 0 && (probe != b.probe || !b.probing):
+		// The probe outlived ProbeTimeout and was counted as failed
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, which says nothing about the CLI; a cancelled
+		// probe lets the next request probe instead
+		if probe != 0 {
+			b.probing = false
+		}
+	case probe != 0:
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.state = CircuitClosed
+		}
+	case b.state != CircuitClosed:
+		// Requests sent before the breaker opened do not decide its state
+	case !failed:
+		b.failures = 0
+	default:
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.open()
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// open opens the breaker. Must be called with b.mu held.
+func (b *circuitBreaker) open() {
+	b.state = CircuitOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.probing = false
+}
+
+// notify logs a change of state from from to to and calls OnStateChange and
+// onChange.
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from == to {
+		return
+	}
+	if to == CircuitOpen {
+		b.logger.Warn("circuit breaker opened", "from", string(from), "openTimeout", b.config.OpenTimeout)
+	} else {
+		b.logger.Info("circuit breaker state changed", "from", string(from), "to", string(to))
+	}
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(from, to)
+	}
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
+
+// openError returns the error for a request rejected by the breaker, which
+// may be retried after wait.
+func (b *circuitBreaker) openError(wait time.Duration) error {
+	return &SDKError{
+		Code:       ErrorCodeCircuitOpen,
+		Message:    fmt.Sprintf("circuit breaker is open after %d consecutive failures", b.config.FailureThreshold),
+		RetryAfter: wait,
+	}
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && !b.now().Before(b.openedAt.Add(b.config.OpenTimeout)) {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// CircuitState returns the state of the client's circuit breaker, or
+// [CircuitClosed] if [ClientOptions.CircuitBreaker] is not set. An open breaker
+// whose OpenTimeout has elapsed is reported as [CircuitHalfOpen], since the
+// next request will probe the CLI.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.currentState()
+}
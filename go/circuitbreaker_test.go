@@ -0,0 +1,175 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	timeout := &SDKError{Code: ErrorCodeTimeout}
+	var changes []CircuitState
+	breaker := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		OnStateChange:    func(from, to CircuitState) { changes = append(changes, to) },
+	}, slog.New(slog.DiscardHandler))
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	call := func(err error) error {
+		done, gateErr := breaker.allow("ping")
+		if gateErr != nil {
+			return gateErr
+		}
+		done(err)
+		return nil
+	}
+
+	call(timeout)
+	call(nil)
+	call(timeout)
+	if state := breaker.currentState(); state != CircuitClosed {
+		t.Fatalf("Expected a success to reset the failure count, got %s", state)
+	}
+	call(&SDKError{Code: ErrorCodeCLIUnavailable})
+	err := call(nil)
+	var sdkErr *SDKError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &sdkErr) || sdkErr.RetryAfter != time.Minute {
+		t.Fatalf("Expected ErrCircuitOpen with RetryAfter, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if state := breaker.currentState(); state != CircuitHalfOpen {
+		t.Fatalf("Expected half-open after OpenTimeout, got %s", state)
+	}
+	probe, err := breaker.allow("ping")
+	if err != nil {
+		t.Fatalf("Expected the probe to be let through, got %v", err)
+	}
+	if _, err := breaker.allow("ping"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected requests during the probe to fail fast, got %v", err)
+	}
+	probe(timeout)
+	if state := breaker.currentState(); state != CircuitOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %s", state)
+	}
+
+	now = now.Add(time.Minute)
+	if err := call(errors.New("tool not found")); err != nil {
+		t.Fatalf("Expected the probe to be let through, got %v", err)
+	}
+	if state := breaker.currentState(); state != CircuitClosed {
+		t.Errorf("Expected a responsive CLI to close the breaker, got %s", state)
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected state changes %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Expected state changes %v, got %v", want, changes)
+			break
+		}
+	}
+}
+
+func TestCircuitBreaker_Probe(t *testing.T) {
+	newOpenBreaker := func() (*circuitBreaker, *time.Time) {
+		breaker := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, ProbeTimeout: 10 * time.Second}, slog.New(slog.DiscardHandler))
+		now := time.Now()
+		breaker.now = func() time.Time { return now }
+		done, _ := breaker.allow("ping")
+		done(ErrTimeout)
+		now = now.Add(time.Minute)
+		return breaker, &now
+	}
+
+	t.Run("reopens after a probe that hangs", func(t *testing.T) {
+		breaker, now := newOpenBreaker()
+		probe, err := breaker.allow("ping")
+		if err != nil {
+			t.Fatalf("Expected the probe to be let through, got %v", err)
+		}
+
+		*now = now.Add(10 * time.Second)
+		var sdkErr *SDKError
+		if _, err := breaker.allow("ping"); !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeCircuitOpen || sdkErr.RetryAfter != time.Minute {
+			t.Fatalf("Expected the hung probe to reopen the breaker, got %v", err)
+		}
+		// The late outcome of the hung probe does not close the breaker
+		probe(nil)
+		if state := breaker.currentState(); state != CircuitOpen {
+			t.Errorf("Expected the breaker to stay open, got %s", state)
+		}
+
+		*now = now.Add(time.Minute)
+		if _, err := breaker.allow("ping"); err != nil {
+			t.Errorf("Expected a new probe after OpenTimeout, got %v", err)
+		}
+	})
+
+	t.Run("does not count a cancelled probe", func(t *testing.T) {
+		breaker, _ := newOpenBreaker()
+		probe, _ := breaker.allow("ping")
+		probe(context.Canceled)
+		if state := breaker.currentState(); state != CircuitHalfOpen {
+			t.Fatalf("Expected a cancelled probe to leave the breaker half-open, got %s", state)
+		}
+		next, err := breaker.allow("ping")
+		if err != nil {
+			t.Fatalf("Expected the next request to probe, got %v", err)
+		}
+		next(nil)
+		if state := breaker.currentState(); state != CircuitClosed {
+			t.Errorf("Expected a successful probe to close the breaker, got %s", state)
+		}
+	})
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	for range 2 {
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "ping", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "timed out", "data": map[string]any{"code": "timeout"}}})
+	}
+
+	var recorded bytes.Buffer
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	client.options.RecordTo = &recorded
+	client.breaker = newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2}, client.options.Logger)
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start playback client: %v", err)
+	}
+
+	for range 2 {
+		if _, err := client.Ping(t.Context(), ""); !errors.Is(err, ErrTimeout) {
+			t.Fatalf("Expected ErrTimeout, got %v", err)
+		}
+	}
+	if state := client.CircuitState(); state != CircuitOpen {
+		t.Fatalf("Expected the breaker to open, got %s", state)
+	}
+
+	sent := recorded.Len()
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	if _, err := client.Ping(ctx, ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if recorded.Len() != sent {
+		t.Error("Expected the request to fail without being sent")
+	}
+}
@@ -34,17 +34,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/github/copilot-sdk/go/internal/embeddedcli"
 	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/pricing"
+	"github.com/github/copilot-sdk/go/redact"
 	"github.com/github/copilot-sdk/go/rpc"
 )
 
@@ -83,11 +91,29 @@ type Client struct {
 	autoRestart            bool     // resolved value from options
 	modelsCache            []ModelInfo
 	modelsCacheMux         sync.Mutex
+	capabilities           *Capabilities // cached by Capabilities until disconnect
+	capabilitiesMux        sync.Mutex
 	lifecycleHandlers      []SessionLifecycleHandler
 	typedLifecycleHandlers map[SessionLifecycleEventType][]SessionLifecycleHandler
 	lifecycleHandlersMux   sync.Mutex
-	processDone            chan struct{} // closed when CLI process exits
-	processError           error         // set before processDone is closed
+	processDone            chan struct{}   // closed when CLI process exits
+	processError           error           // set before processDone is closed
+	playback               []ReplayRecord  // recorded frames served instead of a CLI (see NewPlaybackClient)
+	cliStarts              int             // number of times this client has spawned the CLI
+	limiter                *rateLimiter    // nil unless RateLimit is configured
+	breaker                *circuitBreaker // nil unless CircuitBreaker is configured
+	responseCache          *responseCache  // nil unless ResponseCache is configured
+	secrets                *secretResolver // nil unless SecretsProvider is set
+	connectedAt            time.Time       // when the client last reached StateConnected
+	approvals              approvalQueue   // permission requests waiting in QueueApproval
+	jobs                   jobRegistry     // jobs submitted or looked up through this client
+	diagnostics            diagnosticsRecorder
+	stopping               atomic.Bool        // set by Stop and ForceStop so the CLI exit is not reported as a crash
+	reloadMux              sync.Mutex         // serializes ReloadConfig
+	sharedCLI              *sharedCLI         // CLI this client holds a reference to, with SharedCLI
+	cliOutput              func(CLILogRecord) // receives the CLI's stderr lines instead of emitCLILog when set
+	auth                   authWatcher        // last known auth status and OnAuthChange handlers
+	events                 clientEventBus     // subscribers of Events
 
 	// RPC provides typed server-scoped RPC methods.
 	// This field is nil until the client is connected via Start().
@@ -134,10 +160,58 @@ func NewClient(options *ClientOptions) *Client {
 			panic("CLIUrl is mutually exclusive with UseStdio and CLIPath")
 		}
 
+		if options.SSH != nil {
+			if options.CLIUrl != "" {
+				panic("SSH is mutually exclusive with CLIUrl")
+			}
+			if (options.UseStdio != nil && !*options.UseStdio) || options.Port > 0 {
+				panic("SSH requires stdio transport")
+			}
+			if options.SSH.Host == "" {
+				panic("SSH.Host is required")
+			}
+			if strings.HasPrefix(options.SSH.Host, "-") || strings.HasPrefix(options.SSH.User, "-") {
+				panic("SSH.Host and SSH.User must not start with '-'")
+			}
+		}
+
+		if options.SocketPath != "" {
+			if options.CLIUrl != "" || options.CLIPath != "" || options.UseStdio != nil || options.Port > 0 || options.SSH != nil {
+				panic("SocketPath is mutually exclusive with CLIUrl, CLIPath, UseStdio, Port, and SSH")
+			}
+			if options.GitHubToken != "" || options.UseLoggedInUser != nil {
+				panic("GitHubToken and UseLoggedInUser cannot be used with SocketPath (the server manages its own auth)")
+			}
+		}
+
 		// Validate auth options with external server
 		if options.CLIUrl != "" && (options.GitHubToken != "" || options.UseLoggedInUser != nil) {
 			panic("GitHubToken and UseLoggedInUser cannot be used with CLIUrl (external server manages its own auth)")
 		}
+		if options.GitHubHost != "" {
+			if options.CLIUrl != "" || options.SocketPath != "" {
+				panic("GitHubHost cannot be used with CLIUrl or SocketPath (the server has its own host)")
+			}
+			host, err := normalizeGitHubHost(options.GitHubHost)
+			if err != nil {
+				panic(err.Error())
+			}
+			opts.GitHubHost = host
+		}
+
+		if options.SharedCLI {
+			if options.CLIUrl != "" || options.SocketPath != "" || options.UseStdio != nil || options.SSH != nil {
+				panic("SharedCLI is mutually exclusive with CLIUrl, SocketPath, UseStdio, and SSH")
+			}
+			client.useStdio = false
+			opts.SharedCLI = true
+		}
+
+		if options.SocketPath != "" {
+			client.isExternalServer = true
+			client.useStdio = false
+			opts.SocketPath = options.SocketPath
+		}
 
 		// Parse CLIUrl if provided
 		if options.CLIUrl != "" {
@@ -166,6 +240,7 @@ func NewClient(options *ClientOptions) *Client {
 		if options.LogLevel != "" {
 			opts.LogLevel = options.LogLevel
 		}
+		opts.StrictProtocol = options.StrictProtocol
 		if options.Env != nil {
 			opts.Env = options.Env
 		}
@@ -184,6 +259,101 @@ func NewClient(options *ClientOptions) *Client {
 		if options.UseLoggedInUser != nil {
 			opts.UseLoggedInUser = options.UseLoggedInUser
 		}
+		if options.RecordTo != nil {
+			opts.RecordTo = options.RecordTo
+		}
+		if options.WireDump != nil {
+			opts.WireDump = options.WireDump
+			opts.WireDumpOptions = options.WireDumpOptions
+		}
+		if options.MetricsRegistry != nil {
+			opts.MetricsRegistry = options.MetricsRegistry
+		}
+		if options.SSH != nil {
+			opts.SSH = options.SSH
+		}
+		if options.ResourceLimits != nil {
+			opts.ResourceLimits = options.ResourceLimits
+		}
+		if options.RateLimit != nil {
+			opts.RateLimit = options.RateLimit
+			client.limiter = newRateLimiter(*options.RateLimit)
+			client.limiter.onExceeded = func(event QuotaExceededEvent) {
+				client.emitEvent(ClientEvent{Type: ClientQuotaWarning, SessionID: event.SessionID, Quota: &event})
+			}
+		}
+		if options.CircuitBreaker != nil {
+			opts.CircuitBreaker = options.CircuitBreaker
+		}
+		if options.SessionIdleTimeout > 0 {
+			opts.SessionIdleTimeout = options.SessionIdleTimeout
+		}
+		if options.SessionMemoryLimit > 0 {
+			opts.SessionMemoryLimit = options.SessionMemoryLimit
+		}
+		if options.OnSessionMemoryLimit != nil {
+			opts.OnSessionMemoryLimit = options.OnSessionMemoryLimit
+		}
+		if options.JobStore != nil {
+			opts.JobStore = options.JobStore
+		}
+		if options.MemoryStore != nil {
+			opts.MemoryStore = options.MemoryStore
+		}
+		if options.ResponseCache != nil {
+			if options.ResponseCache.Store == nil {
+				panic("ResponseCache.Store is required")
+			}
+			opts.ResponseCache = options.ResponseCache
+			client.responseCache = &responseCache{config: *options.ResponseCache}
+		}
+		if options.SecretsProvider != nil {
+			opts.SecretsProvider = options.SecretsProvider
+			client.secrets = newSecretResolver(options.SecretsProvider)
+		}
+		if options.ApprovalTimeout > 0 {
+			opts.ApprovalTimeout = options.ApprovalTimeout
+		}
+		if options.OnApprovalQueued != nil {
+			opts.OnApprovalQueued = options.OnApprovalQueued
+		}
+		if options.DiagnosticsDir != "" {
+			opts.DiagnosticsDir = options.DiagnosticsDir
+		}
+		if options.OnCLILog != nil {
+			opts.OnCLILog = options.OnCLILog
+		}
+		if options.Logger != nil {
+			opts.Logger = options.Logger
+		}
+		if options.Redactor != nil {
+			opts.Redactor = options.Redactor
+		}
+		if options.Pricing != nil {
+			opts.Pricing = options.Pricing
+		}
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.DiscardHandler)
+	}
+	if opts.Redactor == nil {
+		opts.Redactor = redact.Default()
+	}
+	if client.secrets != nil {
+		// Keep resolved secrets out of logs, wire dumps, and transcripts
+		opts.Redactor = opts.Redactor.With(redact.Detector("secret", client.secrets.find))
+	}
+	opts.Logger = slog.New(opts.Redactor.Handler(opts.Logger.Handler()))
+	client.diagnostics.redactor = opts.Redactor
+	if opts.CircuitBreaker != nil {
+		client.breaker = newCircuitBreaker(*opts.CircuitBreaker, opts.Logger)
+		client.breaker.onChange = func(from, to CircuitState) {
+			client.emitEvent(ClientEvent{Type: ClientCircuitChanged, CircuitFrom: from, CircuitTo: to})
+		}
+	}
+	if opts.Pricing == nil {
+		opts.Pricing = pricing.Default()
 	}
 
 	// Default Env to current environment if not set
@@ -257,27 +427,58 @@ func (c *Client) Start(ctx context.Context) error {
 
 	c.state = StateConnecting
 
+	// A remote CLI reaches the host from its own network, so only check locally
+	if c.options.GitHubHost != "" && !c.isExternalServer && c.options.SSH == nil {
+		if err := checkGitHubHost(ctx, c.options.GitHubHost); err != nil {
+			c.state = StateError
+			c.options.Logger.Error("GitHub host is unreachable", "host", c.options.GitHubHost, "error", err)
+			return err
+		}
+	}
+
 	// Only start CLI server process if not connecting to external server
-	if !c.isExternalServer {
+	if c.options.SharedCLI {
+		if err := c.acquireSharedCLI(); err != nil {
+			c.state = StateError
+			c.options.Logger.Error("failed to start shared CLI process", "error", err)
+			return newError(ErrorCodeCLIUnavailable, err)
+		}
+	} else if !c.isExternalServer {
 		if err := c.startCLIServer(ctx); err != nil {
 			c.state = StateError
-			return err
+			c.options.Logger.Error("failed to start CLI process", "error", err)
+			return newError(ErrorCodeCLIUnavailable, err)
+		}
+		c.cliStarts++
+		if c.cliStarts > 1 {
+			c.options.Logger.Info("restarted CLI process", "starts", c.cliStarts)
+			c.emitEvent(ClientEvent{Type: ClientCLIRestarted, Message: fmt.Sprintf("CLI started %d times", c.cliStarts)})
+			if c.options.MetricsRegistry != nil {
+				c.options.MetricsRegistry.CLIRestarted()
+			}
 		}
 	}
 
 	// Connect to the server
 	if err := c.connectToServer(ctx); err != nil {
 		c.state = StateError
-		return err
+		c.options.Logger.Error("failed to connect to CLI", "error", err)
+		return newError(ErrorCodeCLIUnavailable, err)
 	}
 
 	// Verify protocol version compatibility
 	if err := c.verifyProtocolVersion(ctx); err != nil {
 		c.state = StateError
+		c.options.Logger.Error("CLI protocol check failed", "error", err)
+		if errors.Is(err, ErrProtocolMismatch) {
+			c.captureDiagnostics(err)
+		}
 		return err
 	}
 
 	c.state = StateConnected
+	c.connectedAt = time.Now()
+	c.options.Logger.Info("connected to CLI", "external", c.isExternalServer)
 	return nil
 }
 
@@ -286,7 +487,8 @@ func (c *Client) Start(ctx context.Context) error {
 // This method performs graceful cleanup:
 //  1. Destroys all active sessions
 //  2. Closes the JSON-RPC connection
-//  3. Terminates the CLI server process (if spawned by this client)
+//  3. Terminates the CLI server process (if spawned by this client), or releases
+//     the shared CLI process with SharedCLI
 //
 // Returns an error that aggregates all errors encountered during cleanup.
 //
@@ -297,6 +499,10 @@ func (c *Client) Start(ctx context.Context) error {
 //	}
 func (c *Client) Stop() error {
 	var errs []error
+	c.options.Logger.Info("stopping client")
+
+	// Deny queued permission requests so their handlers return
+	c.approvals.cancel(fmt.Errorf("client stopped before the request was approved"))
 
 	// Destroy all active sessions
 	c.sessionsMux.Lock()
@@ -317,6 +523,7 @@ func (c *Client) Stop() error {
 	c.sessionsMux.Unlock()
 
 	// Kill CLI process FIRST (this closes stdout and unblocks readLoop) - only if we spawned it
+	c.stopping.Store(true)
 	if c.process != nil && !c.isExternalServer {
 		if err := c.process.Process.Kill(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to kill CLI process: %w", err))
@@ -332,17 +539,32 @@ func (c *Client) Stop() error {
 		c.conn = nil
 	}
 
+	// Disconnect from a shared CLI; the last client using it stops it
+	if c.sharedCLI != nil {
+		if c.conn != nil {
+			if err := c.conn.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close socket: %w", err))
+			}
+			c.conn = nil
+		}
+		c.releaseSharedCLI()
+	}
+
 	// Then close JSON-RPC client (readLoop can now exit)
 	if c.client != nil {
 		c.client.Stop()
 		c.client = nil
 	}
 
-	// Clear models cache
+	// Clear models and capabilities caches
 	c.modelsCacheMux.Lock()
 	c.modelsCache = nil
 	c.modelsCacheMux.Unlock()
 
+	c.capabilitiesMux.Lock()
+	c.capabilities = nil
+	c.capabilitiesMux.Unlock()
+
 	c.state = StateDisconnected
 	if !c.isExternalServer {
 		c.actualPort = 0
@@ -357,7 +579,7 @@ func (c *Client) Stop() error {
 // Use this when [Client.Stop] fails or takes too long. This method:
 //   - Clears all sessions immediately without destroying them
 //   - Force closes the connection
-//   - Kills the CLI process (if spawned by this client)
+//   - Kills the CLI process (if spawned by this client) or releases the shared one
 //
 // Example:
 //
@@ -375,12 +597,16 @@ func (c *Client) Stop() error {
 //	    client.ForceStop()
 //	}
 func (c *Client) ForceStop() {
+	c.options.Logger.Warn("force stopping client")
 	// Clear sessions immediately without trying to destroy them
 	c.sessionsMux.Lock()
 	c.sessions = make(map[string]*Session)
 	c.sessionsMux.Unlock()
 
+	c.approvals.cancel(fmt.Errorf("client stopped before the request was approved"))
+
 	// Kill CLI process (only if we spawned it)
+	c.stopping.Store(true)
 	if c.process != nil && !c.isExternalServer {
 		c.process.Process.Kill() // Ignore errors
 		c.process = nil
@@ -392,17 +618,30 @@ func (c *Client) ForceStop() {
 		c.conn = nil
 	}
 
+	// Disconnect from a shared CLI; the last client using it stops it
+	if c.sharedCLI != nil {
+		if c.conn != nil {
+			_ = c.conn.Close() // Ignore errors
+			c.conn = nil
+		}
+		c.releaseSharedCLI()
+	}
+
 	// Close JSON-RPC client
 	if c.client != nil {
 		c.client.Stop()
 		c.client = nil
 	}
 
-	// Clear models cache
+	// Clear models and capabilities caches
 	c.modelsCacheMux.Lock()
 	c.modelsCache = nil
 	c.modelsCacheMux.Unlock()
 
+	c.capabilitiesMux.Lock()
+	c.capabilities = nil
+	c.capabilitiesMux.Unlock()
+
 	c.state = StateDisconnected
 	if !c.isExternalServer {
 		c.actualPort = 0
@@ -418,7 +657,7 @@ func (c *Client) ensureConnected() error {
 	if c.autoStart {
 		return c.Start(context.Background())
 	}
-	return fmt.Errorf("client not connected. Call Start() first")
+	return newError(ErrorCodeCLIUnavailable, fmt.Errorf("client not connected. Call Start() first"))
 }
 
 // CreateSession creates a new conversation session with the Copilot CLI.
@@ -455,28 +694,69 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		return nil, fmt.Errorf("an OnPermissionRequest handler is required when creating a session. For example, to allow all permissions, use &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll}")
 	}
 
+	if err := validateEnv(config.Env); err != nil {
+		return nil, err
+	}
+	if err := validateSandbox(config.Sandbox); err != nil {
+		return nil, err
+	}
+	if err := validateLocale(config.Locale); err != nil {
+		return nil, err
+	}
+	if err := validateTruncationPolicy(config.TruncationPolicy); err != nil {
+		return nil, err
+	}
+	agents, err := ResolveAgents(config.CustomAgents)
+	if err != nil {
+		return nil, err
+	}
+	model, err := validateProvider(config.Provider, config.Model)
+	if err != nil {
+		return nil, err
+	}
+	remembered, err := loadPermissionScopes(config.PermissionStore)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := c.ensureConnected(); err != nil {
 		return nil, err
 	}
+	sampling, err := c.deterministicSampling(ctx, config.Deterministic)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := c.secrets.resolve(ctx, sessionSecrets{env: config.Env, mcpServers: config.MCPServers, agents: agents})
+	if err != nil {
+		return nil, err
+	}
 
 	req := createSessionRequest{}
-	req.Model = config.Model
+	req.Model = model
 	req.SessionID = config.SessionID
 	req.ClientName = config.ClientName
 	req.ReasoningEffort = config.ReasoningEffort
 	req.ConfigDir = config.ConfigDir
 	req.Tools = config.Tools
-	req.SystemMessage = config.SystemMessage
+	req.SystemMessage = withLocale(config.SystemMessage, config.Locale)
+	req.Locale = config.Locale
 	req.AvailableTools = config.AvailableTools
 	req.ExcludedTools = config.ExcludedTools
 	req.Provider = config.Provider
 	req.WorkingDirectory = config.WorkingDirectory
-	req.MCPServers = config.MCPServers
+	req.MCPServers = secrets.mcpServers
 	req.EnvValueMode = "direct"
-	req.CustomAgents = config.CustomAgents
+	req.CustomAgents = secrets.agents
 	req.SkillDirectories = config.SkillDirectories
 	req.DisabledSkills = config.DisabledSkills
 	req.InfiniteSessions = config.InfiniteSessions
+	req.Metadata = config.Metadata
+	req.Env = secrets.env
+	if config.ClearEnv {
+		req.ClearEnv = Bool(true)
+	}
+	req.Sandbox = config.Sandbox
+	req.Sampling = sampling
 
 	if config.Streaming {
 		req.Streaming = Bool(true)
@@ -492,9 +772,13 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		config.Hooks.OnErrorOccurred != nil) {
 		req.Hooks = Bool(true)
 	}
+	if config.DryRun != nil {
+		// Dry-run mode blocks built-in tools from the preToolUse hook
+		req.Hooks = Bool(true)
+	}
 	req.RequestPermission = Bool(true)
 
-	result, err := c.client.Request("session.create", req)
+	result, err := c.client.RequestContext(ctx, "session.create", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -507,7 +791,43 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 	session := newSession(response.SessionID, c.client, response.WorkspacePath)
 
 	session.registerTools(config.Tools)
+	session.setMaxParallelTools(config.MaxParallelTools)
+	session.toolTimeout = config.ToolTimeout
+	for _, agent := range agents {
+		session.agentNames = append(session.agentNames, agent.Name)
+	}
+	session.metrics = c.options.MetricsRegistry
+	session.limiter = c.limiter
+	if config.AutoCompact != nil {
+		session.autoCompact = newAutoCompactor(session, *config.AutoCompact)
+	}
+	if config.TruncationPolicy != nil {
+		session.truncation = newHistoryTruncator(session, *config.TruncationPolicy, true)
+	}
+	session.modelFallbacks = fallbackModels(config.ModelFallbacks, model)
+	session.locale = config.Locale
+	session.responseCache = c.responseCache.session(req)
+	if config.AutoTitle {
+		session.autoTitle = &autoTitler{session: session}
+	}
+	session.setMaxQueuedMessages(config.MaxQueuedMessages)
+	session.setMetadata(config.Metadata)
+	session.beforeSend = config.OnBeforeSend
+	session.outputFilters = config.OnBeforeDeliver
+	if config.ToolCache != nil {
+		session.toolCache = newToolCache(*config.ToolCache)
+	}
+	if config.DryRun != nil {
+		session.dryRun = newDryRun(*config.DryRun)
+	}
+	if config.ReviewEdits {
+		session.edits = newEditReview(config.OnEditProposed)
+	}
 	session.registerPermissionHandler(config.OnPermissionRequest)
+	session.setAuditHook(config.OnPermissionAudit)
+	session.setPermissionScopes(config.PermissionStore, remembered)
+	session.deterministic = config.Deterministic != nil
+	session.sequence.onGap = config.OnEventGap
 	if config.OnUserInputRequest != nil {
 		session.registerUserInputHandler(config.OnUserInputRequest)
 	}
@@ -515,11 +835,75 @@ func (c *Client) CreateSession(ctx context.Context, config *SessionConfig) (*Ses
 		session.registerHooks(config.Hooks)
 	}
 
+	c.trackSession(session)
+	session.logger.Info("session created")
+	if err := session.recallMemories(ctx); err != nil {
+		session.logger.Warn("failed to recall memories", "error", err)
+	}
+
+	return session, nil
+}
+
+// validateEnv rejects SessionConfig.Env entries that cannot be environment variables.
+func validateEnv(env map[string]string) error {
+	for name := range env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q in Env", name)
+		}
+	}
+	return nil
+}
+
+// validateSandbox rejects empty entries in SessionConfig.Sandbox, which the CLI
+// would otherwise resolve to the working directory or ignore.
+func validateSandbox(sandbox *SandboxConfig) error {
+	if sandbox == nil {
+		return nil
+	}
+	if slices.Contains(sandbox.AllowedPaths, "") {
+		return fmt.Errorf("empty path in Sandbox.AllowedPaths")
+	}
+	if slices.Contains(sandbox.AllowedCommands, "") {
+		return fmt.Errorf("empty command in Sandbox.AllowedCommands")
+	}
+	return nil
+}
+
+// trackSession registers a session so that events and server requests are routed to it.
+func (c *Client) trackSession(session *Session) {
+	session.track = c.trackSession
+	session.onDestroyed = func() { c.emitEvent(ClientEvent{Type: ClientSessionClosed, SessionID: session.SessionID}) }
+	session.capabilities = c.Capabilities
+	session.logger = c.sessionLogger(session)
+	session.memoryLimit = c.options.SessionMemoryLimit
+	session.onMemoryLimit = c.options.OnSessionMemoryLimit
+	session.jobs = &c.jobs
+	session.jobStore = c.options.JobStore
+	session.memoryStore = c.options.MemoryStore
+	session.memoryWorkspace = c.memoryWorkspace()
+	session.redactor = c.options.Redactor
+	session.pricer = c.options.Pricing
+	if c.options.SessionIdleTimeout > 0 {
+		session.expiry = newIdleExpiry(c.options.SessionIdleTimeout, func() { c.expireSession(session) })
+	}
+
 	c.sessionsMux.Lock()
-	c.sessions[response.SessionID] = session
+	c.sessions[session.SessionID] = session
 	c.sessionsMux.Unlock()
+	c.emitEvent(ClientEvent{Type: ClientSessionOpened, SessionID: session.SessionID})
+}
 
-	return session, nil
+// memoryWorkspace returns the workspace that MemoryScopeWorkspace memories in
+// ClientOptions.MemoryStore belong to: the CLI's working directory.
+func (c *Client) memoryWorkspace() string {
+	dir := c.options.Cwd
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
 }
 
 // ResumeSession resumes an existing conversation session by its ID.
@@ -552,16 +936,50 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		return nil, fmt.Errorf("an OnPermissionRequest handler is required when resuming a session. For example, to allow all permissions, use &copilot.ResumeSessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll}")
 	}
 
+	if err := validateEnv(config.Env); err != nil {
+		return nil, err
+	}
+	if err := validateSandbox(config.Sandbox); err != nil {
+		return nil, err
+	}
+	if err := validateLocale(config.Locale); err != nil {
+		return nil, err
+	}
+	if err := validateTruncationPolicy(config.TruncationPolicy); err != nil {
+		return nil, err
+	}
+	agents, err := ResolveAgents(config.CustomAgents)
+	if err != nil {
+		return nil, err
+	}
+	model, err := validateProvider(config.Provider, config.Model)
+	if err != nil {
+		return nil, err
+	}
+	remembered, err := loadPermissionScopes(config.PermissionStore)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := c.ensureConnected(); err != nil {
 		return nil, err
 	}
+	sampling, err := c.deterministicSampling(ctx, config.Deterministic)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := c.secrets.resolve(ctx, sessionSecrets{env: config.Env, mcpServers: config.MCPServers, agents: agents})
+	if err != nil {
+		return nil, err
+	}
 
 	var req resumeSessionRequest
 	req.SessionID = sessionID
 	req.ClientName = config.ClientName
-	req.Model = config.Model
+	req.Model = model
 	req.ReasoningEffort = config.ReasoningEffort
-	req.SystemMessage = config.SystemMessage
+	req.SystemMessage = withLocale(config.SystemMessage, config.Locale)
+	req.Locale = config.Locale
 	req.Tools = config.Tools
 	req.Provider = config.Provider
 	req.AvailableTools = config.AvailableTools
@@ -580,20 +998,31 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		config.Hooks.OnErrorOccurred != nil) {
 		req.Hooks = Bool(true)
 	}
+	if config.DryRun != nil {
+		// Dry-run mode blocks built-in tools from the preToolUse hook
+		req.Hooks = Bool(true)
+	}
 	req.WorkingDirectory = config.WorkingDirectory
 	req.ConfigDir = config.ConfigDir
 	if config.DisableResume {
 		req.DisableResume = Bool(true)
 	}
-	req.MCPServers = config.MCPServers
+	req.MCPServers = secrets.mcpServers
 	req.EnvValueMode = "direct"
-	req.CustomAgents = config.CustomAgents
+	req.CustomAgents = secrets.agents
 	req.SkillDirectories = config.SkillDirectories
 	req.DisabledSkills = config.DisabledSkills
 	req.InfiniteSessions = config.InfiniteSessions
+	req.Metadata = config.Metadata
+	req.Env = secrets.env
+	if config.ClearEnv {
+		req.ClearEnv = Bool(true)
+	}
+	req.Sandbox = config.Sandbox
+	req.Sampling = sampling
 	req.RequestPermission = Bool(true)
 
-	result, err := c.client.Request("session.resume", req)
+	result, err := c.client.RequestContext(ctx, "session.resume", req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resume session: %w", err)
 	}
@@ -605,7 +1034,39 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 
 	session := newSession(response.SessionID, c.client, response.WorkspacePath)
 	session.registerTools(config.Tools)
+	session.setMaxParallelTools(config.MaxParallelTools)
+	session.toolTimeout = config.ToolTimeout
+	for _, agent := range agents {
+		session.agentNames = append(session.agentNames, agent.Name)
+	}
+	session.metrics = c.options.MetricsRegistry
+	session.limiter = c.limiter
+	if config.AutoCompact != nil {
+		session.autoCompact = newAutoCompactor(session, *config.AutoCompact)
+	}
+	if config.TruncationPolicy != nil {
+		session.truncation = newHistoryTruncator(session, *config.TruncationPolicy, false)
+	}
+	session.modelFallbacks = fallbackModels(config.ModelFallbacks, model)
+	session.locale = config.Locale
+	session.setMaxQueuedMessages(config.MaxQueuedMessages)
+	session.setMetadata(config.Metadata)
+	session.beforeSend = config.OnBeforeSend
+	session.outputFilters = config.OnBeforeDeliver
+	if config.ToolCache != nil {
+		session.toolCache = newToolCache(*config.ToolCache)
+	}
+	if config.DryRun != nil {
+		session.dryRun = newDryRun(*config.DryRun)
+	}
+	if config.ReviewEdits {
+		session.edits = newEditReview(config.OnEditProposed)
+	}
 	session.registerPermissionHandler(config.OnPermissionRequest)
+	session.setAuditHook(config.OnPermissionAudit)
+	session.setPermissionScopes(config.PermissionStore, remembered)
+	session.deterministic = config.Deterministic != nil
+	session.sequence.onGap = config.OnEventGap
 	if config.OnUserInputRequest != nil {
 		session.registerUserInputHandler(config.OnUserInputRequest)
 	}
@@ -613,9 +1074,11 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 		session.registerHooks(config.Hooks)
 	}
 
-	c.sessionsMux.Lock()
-	c.sessions[response.SessionID] = session
-	c.sessionsMux.Unlock()
+	c.trackSession(session)
+	session.logger.Info("session resumed")
+	if err := session.recallMemories(ctx); err != nil {
+		session.logger.Warn("failed to recall memories", "error", err)
+	}
 
 	return session, nil
 }
@@ -625,7 +1088,7 @@ func (c *Client) ResumeSessionWithOptions(ctx context.Context, sessionID string,
 // Returns a list of SessionMetadata for all available sessions, including their IDs,
 // timestamps, optional summaries, and context information.
 //
-// An optional filter can be provided to filter sessions by cwd, git root, repository, or branch.
+// An optional filter can be provided to filter sessions by cwd, git root, repository, branch, or metadata.
 //
 // Example:
 //
@@ -649,7 +1112,7 @@ func (c *Client) ListSessions(ctx context.Context, filter *SessionListFilter) ([
 	if filter != nil {
 		params.Filter = filter
 	}
-	result, err := c.client.Request("session.list", params)
+	result, err := c.client.RequestContext(ctx, "session.list", params)
 	if err != nil {
 		return nil, err
 	}
@@ -659,9 +1122,45 @@ func (c *Client) ListSessions(ctx context.Context, filter *SessionListFilter) ([
 		return nil, fmt.Errorf("failed to unmarshal sessions response: %w", err)
 	}
 
+	c.sessionsMux.Lock()
+	for i := range response.Sessions {
+		session, ok := c.sessions[response.Sessions[i].SessionID]
+		if !ok {
+			continue
+		}
+		if response.Sessions[i].Metadata == nil {
+			response.Sessions[i].Metadata = session.Metadata()
+		}
+		if title := session.Title(); response.Sessions[i].Title == nil && title != "" {
+			response.Sessions[i].Title = &title
+		}
+	}
+	c.sessionsMux.Unlock()
+
+	// The CLI may not support metadata filters, so apply them here as well
+	if filter != nil && len(filter.Metadata) > 0 {
+		matched := response.Sessions[:0]
+		for _, session := range response.Sessions {
+			if matchesMetadata(session.Metadata, filter.Metadata) {
+				matched = append(matched, session)
+			}
+		}
+		response.Sessions = matched
+	}
+
 	return response.Sessions, nil
 }
 
+// matchesMetadata reports whether metadata has every key in filter with the same value.
+func matchesMetadata(metadata, filter map[string]string) bool {
+	for key, value := range filter {
+		if v, ok := metadata[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 // DeleteSession permanently deletes a session and all its conversation history.
 //
 // The session cannot be resumed after deletion. If the session is in the local
@@ -677,7 +1176,7 @@ func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 		return err
 	}
 
-	result, err := c.client.Request("session.delete", deleteSessionRequest{SessionID: sessionID})
+	result, err := c.client.RequestContext(ctx, "session.delete", deleteSessionRequest{SessionID: sessionID})
 	if err != nil {
 		return err
 	}
@@ -699,6 +1198,9 @@ func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
 	c.sessionsMux.Lock()
 	delete(c.sessions, sessionID)
 	c.sessionsMux.Unlock()
+	if c.limiter != nil {
+		c.limiter.forget(sessionID)
+	}
 
 	return nil
 }
@@ -728,7 +1230,7 @@ func (c *Client) GetForegroundSessionID(ctx context.Context) (*string, error) {
 		}
 	}
 
-	result, err := c.client.Request("session.getForeground", getForegroundSessionRequest{})
+	result, err := c.client.RequestContext(ctx, "session.getForeground", getForegroundSessionRequest{})
 	if err != nil {
 		return nil, err
 	}
@@ -762,7 +1264,7 @@ func (c *Client) SetForegroundSessionID(ctx context.Context, sessionID string) e
 		}
 	}
 
-	result, err := c.client.Request("session.setForeground", setForegroundSessionRequest{SessionID: sessionID})
+	result, err := c.client.RequestContext(ctx, "session.setForeground", setForegroundSessionRequest{SessionID: sessionID})
 	if err != nil {
 		return err
 	}
@@ -847,6 +1349,12 @@ func (c *Client) OnEventType(eventType SessionLifecycleEventType, handler Sessio
 
 // handleLifecycleEvent dispatches a lifecycle event to all registered handlers
 func (c *Client) handleLifecycleEvent(event SessionLifecycleEvent) {
+	c.sessionsMux.Lock()
+	if session, ok := c.sessions[event.SessionID]; ok {
+		event.SessionMetadata = session.Metadata()
+	}
+	c.sessionsMux.Unlock()
+
 	c.lifecycleHandlersMux.Lock()
 	// Copy handlers to avoid holding lock during callbacks
 	typedHandlers := make([]SessionLifecycleHandler, 0)
@@ -907,7 +1415,7 @@ func (c *Client) Ping(ctx context.Context, message string) (*PingResponse, error
 		return nil, fmt.Errorf("client not connected")
 	}
 
-	result, err := c.client.Request("ping", pingRequest{Message: message})
+	result, err := c.client.RequestContext(ctx, "ping", pingRequest{Message: message})
 	if err != nil {
 		return nil, err
 	}
@@ -925,7 +1433,7 @@ func (c *Client) GetStatus(ctx context.Context) (*GetStatusResponse, error) {
 		return nil, fmt.Errorf("client not connected")
 	}
 
-	result, err := c.client.Request("status.get", getStatusRequest{})
+	result, err := c.client.RequestContext(ctx, "status.get", getStatusRequest{})
 	if err != nil {
 		return nil, err
 	}
@@ -937,13 +1445,65 @@ func (c *Client) GetStatus(ctx context.Context) (*GetStatusResponse, error) {
 	return &response, nil
 }
 
+// Health checks that the CLI is responsive and reports client and session health.
+//
+// The returned status is always non-nil so that it can be reported even when the
+// check fails. An error is returned if the client is not connected or the CLI
+// does not respond, making Health suitable for readiness and liveness probes.
+//
+// Example:
+//
+//	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+//	    if _, err := client.Health(r.Context()); err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    w.WriteHeader(http.StatusOK)
+//	})
+func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
+	health := &HealthStatus{State: c.state}
+
+	c.sessionsMux.Lock()
+	for _, session := range c.sessions {
+		health.Sessions = append(health.Sessions, SessionHealth{
+			SessionID:    session.SessionID,
+			MessagesSent: int(session.messagesSent.Load()),
+			Busy:         session.busy.Load(),
+		})
+	}
+	c.sessionsMux.Unlock()
+	sort.Slice(health.Sessions, func(i, j int) bool {
+		return health.Sessions[i].SessionID < health.Sessions[j].SessionID
+	})
+
+	if c.state != StateConnected {
+		return health, newError(ErrorCodeCLIUnavailable, fmt.Errorf("client not connected (state: %s)", c.state))
+	}
+	health.Uptime = time.Since(c.connectedAt)
+
+	start := time.Now()
+	if _, err := c.Ping(ctx, ""); err != nil {
+		return health, err
+	}
+	health.Latency = time.Since(start)
+
+	status, err := c.GetStatus(ctx)
+	if err != nil {
+		return health, err
+	}
+	health.CLIVersion = status.Version
+	health.ProtocolVersion = status.ProtocolVersion
+
+	return health, nil
+}
+
 // GetAuthStatus returns current authentication status
 func (c *Client) GetAuthStatus(ctx context.Context) (*GetAuthStatusResponse, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("client not connected")
 	}
 
-	result, err := c.client.Request("auth.getStatus", getAuthStatusRequest{})
+	result, err := c.client.RequestContext(ctx, "auth.getStatus", getAuthStatusRequest{})
 	if err != nil {
 		return nil, err
 	}
@@ -977,7 +1537,7 @@ func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
 	}
 
 	// Cache miss - fetch from backend while holding lock
-	result, err := c.client.Request("models.list", listModelsRequest{})
+	result, err := c.client.RequestContext(ctx, "models.list", listModelsRequest{})
 	if err != nil {
 		return nil, err
 	}
@@ -1005,11 +1565,11 @@ func (c *Client) verifyProtocolVersion(ctx context.Context) error {
 	}
 
 	if pingResult.ProtocolVersion == nil {
-		return fmt.Errorf("SDK protocol version mismatch: SDK expects version %d, but server does not report a protocol version. Please update your server to ensure compatibility", expectedVersion)
+		return newError(ErrorCodeProtocolMismatch, fmt.Errorf("SDK protocol version mismatch: SDK expects version %d, but server does not report a protocol version. Please update your server to ensure compatibility", expectedVersion))
 	}
 
 	if *pingResult.ProtocolVersion != expectedVersion {
-		return fmt.Errorf("SDK protocol version mismatch: SDK expects version %d, but server reports version %d. Please update your SDK or server to ensure compatibility", expectedVersion, *pingResult.ProtocolVersion)
+		return newError(ErrorCodeProtocolMismatch, fmt.Errorf("SDK protocol version mismatch: SDK expects version %d, but server reports version %d. Please update your SDK or server to ensure compatibility", expectedVersion, *pingResult.ProtocolVersion))
 	}
 
 	return nil
@@ -1021,7 +1581,7 @@ func (c *Client) verifyProtocolVersion(ctx context.Context) error {
 // mode (stdio or TCP).
 func (c *Client) startCLIServer(ctx context.Context) error {
 	cliPath := c.options.CLIPath
-	if cliPath == "" {
+	if cliPath == "" && c.options.SSH == nil {
 		// If no CLI path is provided, attempt to use the embedded CLI if available
 		cliPath = embeddedcli.Path()
 	}
@@ -1064,13 +1624,33 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		args = append([]string{cliPath}, args...)
 	}
 
+	// When running remotely, wrap the whole command in ssh
+	if c.options.SSH != nil {
+		var forwardEnv []string
+		if c.options.GitHubToken != "" {
+			forwardEnv = append(forwardEnv, "COPILOT_SDK_AUTH_TOKEN")
+		}
+		if c.options.GitHubHost != "" {
+			forwardEnv = append(forwardEnv, gitHubHostEnv)
+		}
+		command, args = sshCommand(c.options.SSH, command, args, c.options.Cwd, forwardEnv)
+	} else {
+		command, args = c.options.ResourceLimits.niceCommand(command, args)
+	}
+
 	c.process = exec.CommandContext(ctx, command, args...)
+	cliLog := &cliLogWriter{emit: c.emitCLILog}
+	if c.cliOutput != nil {
+		cliLog.emit = c.cliOutput
+	}
+	c.process.Stderr = io.MultiWriter(&c.diagnostics, cliLog)
+	c.stopping.Store(false)
 
 	// Configure platform-specific process attributes (e.g., hide window on Windows)
 	configureProcAttr(c.process)
 
-	// Set working directory if specified
-	if c.options.Cwd != "" {
+	// Set working directory if specified (remote working directory is handled by sshCommand)
+	if c.options.Cwd != "" && c.options.SSH == nil {
 		c.process.Dir = c.options.Cwd
 	}
 
@@ -1079,6 +1659,9 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 	if c.options.GitHubToken != "" {
 		c.process.Env = append(c.process.Env, "COPILOT_SDK_AUTH_TOKEN="+c.options.GitHubToken)
 	}
+	if c.options.GitHubHost != "" {
+		c.process.Env = append(c.process.Env, gitHubHostEnv+"="+c.options.GitHubHost)
+	}
 
 	if c.useStdio {
 		// For stdio mode, we need stdin/stdout pipes
@@ -1095,17 +1678,25 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		if err := c.process.Start(); err != nil {
 			return fmt.Errorf("failed to start CLI server: %w", err)
 		}
+		c.options.Logger.Info("started CLI process", "pid", c.process.Process.Pid, "command", command)
+		c.limitResources(c.process.Process)
 
 		// Monitor process exit to signal pending requests
 		c.processDone = make(chan struct{})
+		process := c.process // Stop and ForceStop clear c.process
 		go func() {
-			waitErr := c.process.Wait()
+			waitErr := process.Wait()
+			cliLog.flush()
+			c.options.Logger.Info("CLI process exited", "error", waitErr)
 			if waitErr != nil {
 				c.processError = fmt.Errorf("CLI process exited: %v", waitErr)
 			} else {
 				c.processError = fmt.Errorf("CLI process exited unexpectedly")
 			}
 			close(c.processDone)
+			if !c.stopping.Load() && ctx.Err() == nil {
+				c.captureDiagnostics(c.processError)
+			}
 		}()
 
 		// Create JSON-RPC client immediately
@@ -1113,6 +1704,8 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		c.client.SetProcessDone(c.processDone, &c.processError)
 		c.RPC = rpc.NewServerRpc(c.client)
 		c.setupNotificationHandler()
+		c.setupObservers()
+		c.client.SetErrorMapper(classifyError)
 		c.client.Start()
 
 		return nil
@@ -1126,6 +1719,8 @@ func (c *Client) startCLIServer(ctx context.Context) error {
 		if err := c.process.Start(); err != nil {
 			return fmt.Errorf("failed to start CLI server: %w", err)
 		}
+		c.options.Logger.Info("started CLI process", "pid", c.process.Process.Pid, "command", command)
+		c.limitResources(c.process.Process)
 
 		// Wait for port announcement
 		scanner := bufio.NewScanner(stdout)
@@ -1160,10 +1755,31 @@ func (c *Client) connectToServer(ctx context.Context) error {
 		return nil
 	}
 
+	if c.playback != nil {
+		return c.connectViaPlayback()
+	}
+
+	if c.options.SocketPath != "" {
+		return c.connectViaSocket(ctx)
+	}
+
 	// Connect via TCP
 	return c.connectViaTcp(ctx)
 }
 
+// connectViaSocket connects to a CLI server via its unix domain socket.
+func (c *Client) connectViaSocket(ctx context.Context) error {
+	dialer := net.Dialer{
+		Timeout: 10 * time.Second,
+	}
+	conn, err := dialer.DialContext(ctx, "unix", c.options.SocketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to CLI server at %s: %w", c.options.SocketPath, err)
+	}
+	c.attachConn(conn)
+	return nil
+}
+
 // connectViaTcp connects to the CLI server via TCP socket.
 func (c *Client) connectViaTcp(ctx context.Context) error {
 	if c.actualPort == 0 {
@@ -1180,27 +1796,63 @@ func (c *Client) connectViaTcp(ctx context.Context) error {
 		return fmt.Errorf("failed to connect to CLI server at %s: %w", address, err)
 	}
 
-	c.conn = conn
+	c.attachConn(conn)
+	return nil
+}
 
-	// Create JSON-RPC client with the connection
+// attachConn starts a JSON-RPC client over a connection to the CLI server.
+func (c *Client) attachConn(conn net.Conn) {
+	c.conn = conn
 	c.client = jsonrpc2.NewClient(conn, conn)
+	if c.sharedCLI != nil {
+		c.client.SetProcessDone(c.sharedCLI.exited, &c.sharedCLI.err)
+	}
 	c.RPC = rpc.NewServerRpc(c.client)
 	c.setupNotificationHandler()
+	c.setupObservers()
+	c.client.SetErrorMapper(classifyError)
 	c.client.Start()
-
-	return nil
 }
 
 // setupNotificationHandler configures handlers for session events, tool calls, and permission requests.
 func (c *Client) setupNotificationHandler() {
 	c.client.SetRequestHandler("session.event", jsonrpc2.NotificationHandlerFor(c.handleSessionEvent))
 	c.client.SetRequestHandler("session.lifecycle", jsonrpc2.NotificationHandlerFor(c.handleLifecycleEvent))
+	c.client.SetRequestHandler("auth.statusChanged", jsonrpc2.NotificationHandlerFor(c.handleAuthStatusChanged))
 	c.client.SetRequestHandler("tool.call", jsonrpc2.RequestHandlerFor(c.handleToolCallRequest))
 	c.client.SetRequestHandler("permission.request", jsonrpc2.RequestHandlerFor(c.handlePermissionRequest))
 	c.client.SetRequestHandler("userInput.request", jsonrpc2.RequestHandlerFor(c.handleUserInputRequest))
 	c.client.SetRequestHandler("hooks.invoke", jsonrpc2.RequestHandlerFor(c.handleHooksInvoke))
 }
 
+// setupObservers attaches observers that see JSON-RPC traffic, such as the replay recorder, wire dump, diagnostics, metrics, and logging.
+func (c *Client) setupObservers() {
+	if c.options.RecordTo != nil {
+		recorder := &replayRecorder{w: c.options.RecordTo, redactor: c.options.Redactor}
+		c.client.AddFrameObserver(recorder.observe)
+	}
+	if c.options.WireDump != nil {
+		dumper := &wireDumper{w: c.options.WireDump, options: c.options.WireDumpOptions, redactor: c.options.Redactor}
+		c.client.AddFrameObserver(dumper.observe)
+	}
+	c.client.AddFrameObserver(c.diagnostics.observe)
+	c.client.SetProtocolErrorHandler(c.captureDiagnostics)
+	if c.options.StrictProtocol {
+		c.client.SetValidator(c.validateProtocol)
+	}
+	c.setupLogging()
+	if c.breaker != nil {
+		c.client.SetRequestGate(c.breaker.allow)
+	}
+	c.client.SetRequestObserver(func(method string, duration time.Duration, err error) {
+		c.logRPC(method, duration, err)
+		c.observeAuthError(method, err)
+		if c.options.MetricsRegistry != nil {
+			c.options.MetricsRegistry.ObserveRPC(method, duration, err)
+		}
+	})
+}
+
 func (c *Client) handleSessionEvent(req sessionEventRequest) {
 	if req.SessionID == "" {
 		return
@@ -1210,6 +1862,15 @@ func (c *Client) handleSessionEvent(req sessionEventRequest) {
 	session, ok := c.sessions[req.SessionID]
 	c.sessionsMux.Unlock()
 
+	if c.limiter != nil && req.Event.Type == AssistantUsage {
+		c.limiter.recordTokens(req.Event)
+	}
+	if req.Event.Type == SessionError && req.Event.Data.StatusCode != nil && *req.Event.Data.StatusCode == 401 {
+		c.checkAuth()
+	}
+
+	c.observeSessionWarning(req.SessionID, req.Event)
+
 	if ok {
 		session.dispatchEvent(req.Event)
 	}
@@ -1230,26 +1891,54 @@ func (c *Client) handleToolCallRequest(req toolCallRequest) (*toolCallResponse,
 
 	handler, ok := session.getToolHandler(req.ToolName)
 	if !ok {
-		return &toolCallResponse{Result: buildUnsupportedToolResult(req.ToolName)}, nil
+		result := buildUnsupportedToolResult(req.ToolName)
+		c.recordToolCall(req.ToolName, result)
+		return &toolCallResponse{Result: result}, nil
 	}
+	if session.dryRun != nil && !session.dryRun.allows(req.ToolName) {
+		result, _ := session.dryRun.simulate(DryRunCall{ToolName: req.ToolName, ToolCallID: req.ToolCallID, Arguments: req.Arguments})
+		c.recordToolCall(req.ToolName, result)
+		return &toolCallResponse{Result: result}, nil
+	}
+
+	invocation := ToolInvocation{
+		SessionID:  req.SessionID,
+		ToolCallID: req.ToolCallID,
+		ToolName:   req.ToolName,
+		Arguments:  req.Arguments,
+	}
+	key, cacheable := session.toolCache.key(invocation)
+	if cacheable {
+		if result, ok := session.toolCache.get(key); ok {
+			return &toolCallResponse{Result: result}, nil
+		}
+	}
+
+	// Tool calls arrive concurrently; bound how many handlers run at once if configured
+	release := session.acquireToolSlot()
+	defer release()
 
-	result := c.executeToolCall(req.SessionID, req.ToolCallID, req.ToolName, req.Arguments, handler)
+	toolContext, cancel := session.newToolContext(req.ToolCallID, req.ToolName)
+	defer cancel()
+	invocation.Context = toolContext
+
+	result := c.executeToolCall(invocation, handler)
+	c.recordToolCall(req.ToolName, result)
+	if cacheable {
+		session.toolCache.put(key, result)
+	}
 	return &toolCallResponse{Result: result}, nil
 }
 
-// executeToolCall executes a tool handler and returns the result.
-func (c *Client) executeToolCall(
-	sessionID, toolCallID, toolName string,
-	arguments any,
-	handler ToolHandler,
-) (result ToolResult) {
-	invocation := ToolInvocation{
-		SessionID:  sessionID,
-		ToolCallID: toolCallID,
-		ToolName:   toolName,
-		Arguments:  arguments,
+// recordToolCall reports a handled tool call to the metrics registry, if configured.
+func (c *Client) recordToolCall(toolName string, result ToolResult) {
+	if c.options.MetricsRegistry != nil {
+		c.options.MetricsRegistry.ToolCalled(toolName, result.ResultType)
 	}
+}
 
+// executeToolCall executes a tool handler and returns the result.
+func (c *Client) executeToolCall(invocation ToolInvocation, handler ToolHandler) (result ToolResult) {
 	defer func() {
 		if r := recover(); r != nil {
 			result = buildFailedToolResult(fmt.Sprintf("tool panic: %v", r))
@@ -1283,11 +1972,13 @@ func (c *Client) handlePermissionRequest(req permissionRequestRequest) (*permiss
 	result, err := session.handlePermissionRequest(req.Request)
 	if err != nil {
 		// Return denial on error
-		return &permissionRequestResponse{
-			Result: PermissionRequestResult{
-				Kind: "denied-no-approval-rule-and-could-not-request-from-user",
-			},
-		}, nil
+		result = PermissionRequestResult{
+			Kind: "denied-no-approval-rule-and-could-not-request-from-user",
+		}
+	}
+
+	if c.options.MetricsRegistry != nil && strings.HasPrefix(result.Kind, "denied") {
+		c.options.MetricsRegistry.PermissionDenied(req.Request.Kind)
 	}
 
 	return &permissionRequestResponse{Result: result}, nil
@@ -1,12 +1,20 @@
 package copilot
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // This file is for unit tests. Where relevant, prefer to add e2e tests in e2e/*.test.go instead
@@ -486,3 +494,515 @@ func TestClient_ResumeSession_RequiresPermissionHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestClient_MaxParallelTools(t *testing.T) {
+	runConcurrentToolCalls := func(t *testing.T, limit, calls int) int32 {
+		t.Helper()
+		var running, peak atomic.Int32
+		release := make(chan struct{})
+		tool := Tool{
+			Name: "slow_tool",
+			Handler: func(inv ToolInvocation) (ToolResult, error) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				<-release
+				running.Add(-1)
+				return ToolResult{TextResultForLLM: "ok", ResultType: "success"}, nil
+			},
+		}
+
+		session := newSession("s1", nil, "")
+		session.registerTools([]Tool{tool})
+		session.setMaxParallelTools(limit)
+		client := &Client{sessions: map[string]*Session{"s1": session}}
+
+		var wg sync.WaitGroup
+		for i := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client.handleToolCallRequest(toolCallRequest{
+					SessionID:  "s1",
+					ToolCallID: strconv.Itoa(i),
+					ToolName:   "slow_tool",
+				})
+			}()
+		}
+
+		// Give the calls time to pile up against the limit before letting them finish
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return peak.Load()
+	}
+
+	t.Run("limits concurrent tool handlers", func(t *testing.T) {
+		if peak := runConcurrentToolCalls(t, 2, 6); peak != 2 {
+			t.Errorf("Expected at most 2 concurrent tool handlers, got %d", peak)
+		}
+	})
+
+	t.Run("runs all tool handlers concurrently when unlimited", func(t *testing.T) {
+		if peak := runConcurrentToolCalls(t, 0, 4); peak != 4 {
+			t.Errorf("Expected 4 concurrent tool handlers, got %d", peak)
+		}
+	})
+}
+
+// fakeMetrics records MetricsRegistry callbacks for assertions.
+type fakeMetrics struct {
+	mu           sync.Mutex
+	rpcMethods   []string
+	messagesSent int
+	toolCalls    []string
+	denials      []string
+	restarts     int
+}
+
+func (m *fakeMetrics) ObserveRPC(method string, _ time.Duration, _ error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rpcMethods = append(m.rpcMethods, method)
+}
+
+func (m *fakeMetrics) MessageSent() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesSent++
+}
+
+func (m *fakeMetrics) ToolCalled(toolName string, resultType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.toolCalls = append(m.toolCalls, toolName+":"+resultType)
+}
+
+func (m *fakeMetrics) PermissionDenied(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.denials = append(m.denials, kind)
+}
+
+func (m *fakeMetrics) CLIRestarted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restarts++
+}
+
+func TestClient_MetricsRegistry(t *testing.T) {
+	t.Run("reports rpc latency and messages sent", func(t *testing.T) {
+		metrics := &fakeMetrics{}
+		client, err := NewPlaybackClient(bytes.NewReader(simpleConversationLog().buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		client.options.MetricsRegistry = metrics
+		t.Cleanup(func() { client.ForceStop() })
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is 2+2?"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		if got := strings.Join(metrics.rpcMethods, ","); got != "ping,session.create,session.send" {
+			t.Errorf("Expected observed RPCs 'ping,session.create,session.send', got %q", got)
+		}
+		if metrics.messagesSent != 1 {
+			t.Errorf("Expected 1 message sent, got %d", metrics.messagesSent)
+		}
+	})
+
+	t.Run("reports tool calls and permission denials", func(t *testing.T) {
+		metrics := &fakeMetrics{}
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{Kind: "denied-interactively-by-user"}, nil
+		})
+		client := &Client{
+			sessions: map[string]*Session{"s1": session},
+			options:  ClientOptions{MetricsRegistry: metrics},
+		}
+
+		client.handleToolCallRequest(toolCallRequest{SessionID: "s1", ToolCallID: "1", ToolName: "missing_tool"})
+		client.handlePermissionRequest(permissionRequestRequest{SessionID: "s1", Request: PermissionRequest{Kind: "shell"}})
+
+		if len(metrics.toolCalls) != 1 || metrics.toolCalls[0] != "missing_tool:failure" {
+			t.Errorf("Expected one failed tool call, got %v", metrics.toolCalls)
+		}
+		if len(metrics.denials) != 1 || metrics.denials[0] != "shell" {
+			t.Errorf("Expected one shell denial, got %v", metrics.denials)
+		}
+	})
+}
+
+func TestClient_Health(t *testing.T) {
+	t.Run("reports CLI and session health", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.call("ping", map[string]any{"message": "", "timestamp": 2})
+		log.call("status.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion()})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hello"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		health, err := client.Health(t.Context())
+		if err != nil {
+			t.Fatalf("Expected healthy client, got %v", err)
+		}
+		if health.State != StateConnected || health.CLIVersion != "1.2.3" || health.ProtocolVersion != GetSdkProtocolVersion() {
+			t.Errorf("Unexpected health status: %+v", health)
+		}
+		if health.Uptime <= 0 {
+			t.Errorf("Expected positive uptime, got %s", health.Uptime)
+		}
+		if len(health.Sessions) != 1 || health.Sessions[0].MessagesSent != 1 || !health.Sessions[0].Busy {
+			t.Errorf("Expected one busy session with one message, got %+v", health.Sessions)
+		}
+	})
+
+	t.Run("reports an error when not connected", func(t *testing.T) {
+		client := NewClient(nil)
+
+		health, err := client.Health(t.Context())
+		if !errors.Is(err, ErrCLIUnavailable) {
+			t.Errorf("Expected ErrCLIUnavailable, got %v", err)
+		}
+		if health == nil || health.State != StateDisconnected {
+			t.Errorf("Expected disconnected health status, got %+v", health)
+		}
+	})
+}
+
+func TestClient_SSH(t *testing.T) {
+	t.Run("wraps the CLI command in ssh", func(t *testing.T) {
+		command, args := sshCommand(&SSHConfig{
+			Host:    "devbox",
+			User:    "octocat",
+			Port:    2222,
+			KeyFile: "/home/me/.ssh/id_ed25519",
+			Args:    []string{"-o", "StrictHostKeyChecking=accept-new"},
+		}, "copilot", []string{"--headless", "--log-level", "info", "--stdio"}, "/work/my repo", []string{"COPILOT_SDK_AUTH_TOKEN"})
+
+		if command != "ssh" {
+			t.Errorf("Expected command 'ssh', got %q", command)
+		}
+		expected := []string{
+			"-T", "-o", "BatchMode=yes",
+			"-p", "2222",
+			"-i", "/home/me/.ssh/id_ed25519",
+			"-o", "SendEnv=COPILOT_SDK_AUTH_TOKEN",
+			"-o", "StrictHostKeyChecking=accept-new",
+			"--", "octocat@devbox",
+			"cd '/work/my repo' && exec copilot --headless --log-level info --stdio",
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("Expected args %q, got %q", expected, args)
+		}
+	})
+
+	t.Run("quotes remote arguments", func(t *testing.T) {
+		_, args := sshCommand(&SSHConfig{Host: "devbox"}, "copilot", []string{"--banner", "it's here"}, "", nil)
+
+		remote := args[len(args)-1]
+		if remote != `exec copilot --banner 'it'\''s here'` {
+			t.Errorf("Expected quoted remote command, got %q", remote)
+		}
+		if args[len(args)-2] != "devbox" {
+			t.Errorf("Expected destination 'devbox' without user, got %q", args[len(args)-2])
+		}
+	})
+
+	t.Run("should panic when SSH is combined with TCP", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil || r.(string) != "SSH requires stdio transport" {
+				t.Errorf("Expected panic 'SSH requires stdio transport', got: %v", r)
+			}
+		}()
+
+		NewClient(&ClientOptions{SSH: &SSHConfig{Host: "devbox"}, UseStdio: Bool(false)})
+	})
+
+	t.Run("should panic when SSH host is missing", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil || r.(string) != "SSH.Host is required" {
+				t.Errorf("Expected panic 'SSH.Host is required', got: %v", r)
+			}
+		}()
+
+		NewClient(&ClientOptions{SSH: &SSHConfig{}})
+	})
+
+	t.Run("should panic when SSH host looks like an option", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil || r.(string) != "SSH.Host and SSH.User must not start with '-'" {
+				t.Errorf("Expected panic for an option-like host, got: %v", r)
+			}
+		}()
+
+		NewClient(&ClientOptions{SSH: &SSHConfig{Host: "-oProxyCommand=touch /tmp/pwned"}})
+	})
+}
+
+func TestClient_SessionMetadata(t *testing.T) {
+	t.Run("filters sessions and annotates lifecycle events", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.notify("session.lifecycle", map[string]any{"type": "session.updated", "sessionId": "s1"})
+		log.call("session.list", map[string]any{"sessions": []map[string]any{
+			{"sessionId": "s1", "startTime": "", "modifiedTime": ""},
+			{"sessionId": "s2", "startTime": "", "modifiedTime": "", "metadata": map[string]any{"tenant": "globex"}},
+			{"sessionId": "s3", "startTime": "", "modifiedTime": ""},
+		}})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		var events []SessionLifecycleEvent
+		client.On(func(event SessionLifecycleEvent) { events = append(events, event) })
+
+		metadata := map[string]string{"tenant": "acme", "user": "mona"}
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Metadata:            metadata,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		metadata["tenant"] = "changed"
+		if session.Metadata()["tenant"] != "acme" {
+			t.Errorf("Expected session to keep its own copy of metadata, got %v", session.Metadata())
+		}
+
+		sessions, err := client.ListSessions(t.Context(), &SessionListFilter{Metadata: map[string]string{"tenant": "acme"}})
+		if err != nil {
+			t.Fatalf("Failed to list sessions: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].SessionID != "s1" || sessions[0].Metadata["user"] != "mona" {
+			t.Errorf("Expected only s1 with local metadata, got %+v", sessions)
+		}
+
+		if len(events) != 1 || events[0].SessionMetadata["tenant"] != "acme" {
+			t.Errorf("Expected lifecycle event with session metadata, got %+v", events)
+		}
+	})
+}
+
+func TestClient_ContextPropagation(t *testing.T) {
+	recordedFrames := func(t *testing.T, recorded *bytes.Buffer) []map[string]any {
+		t.Helper()
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to parse recorded log: %v", err)
+		}
+		var frames []map[string]any
+		for _, record := range records {
+			var frame map[string]any
+			json.Unmarshal(record.Message, &frame)
+			frames = append(frames, frame)
+		}
+		return frames
+	}
+
+	t.Run("sends the context deadline with requests", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.list", map[string]any{"sessions": []any{}})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		deadline := time.Now().Add(time.Minute)
+		ctx, cancel := context.WithDeadline(t.Context(), deadline)
+		defer cancel()
+		if _, err := client.ListSessions(ctx, nil); err != nil {
+			t.Fatalf("Failed to list sessions: %v", err)
+		}
+
+		client.ForceStop()
+		for _, frame := range recordedFrames(t, &recorded) {
+			if frame["method"] != "session.list" {
+				continue
+			}
+			meta, _ := frame["params"].(map[string]any)["_meta"].(map[string]any)
+			if meta["deadline"] != deadline.UTC().Format(time.RFC3339Nano) {
+				t.Errorf("Expected deadline %s in _meta, got %v", deadline.UTC().Format(time.RFC3339Nano), frame["params"])
+			}
+			return
+		}
+		t.Error("Expected session.list to be recorded")
+	})
+
+	t.Run("cancels abandoned requests", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		// A request the CLI never answers
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": "99", "method": "session.list", "params": map[string]any{}})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.ListSessions(ctx, nil)
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected timeout error, got %v", err)
+		}
+
+		// Stop reading so that no frames are recorded while they are inspected
+		client.ForceStop()
+		var requestID any
+		for _, frame := range recordedFrames(t, &recorded) {
+			switch frame["method"] {
+			case "session.list":
+				requestID = frame["id"]
+			case "$/cancelRequest":
+				if id := frame["params"].(map[string]any)["id"]; id != requestID {
+					t.Errorf("Expected cancellation of %v, got %v", requestID, id)
+				}
+				return
+			}
+		}
+		t.Error("Expected $/cancelRequest to be sent")
+	})
+}
+
+func TestClient_SessionEnv(t *testing.T) {
+	t.Run("sends the environment with create and resume", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.resume", map[string]any{"sessionId": "s1"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		env := map[string]string{"PATH": "/opt/tenant/bin", "HTTPS_PROXY": "http://proxy:3128"}
+		if _, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Env:                 env,
+			ClearEnv:            true,
+		}); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Env:                 env,
+		}); err != nil {
+			t.Fatalf("Failed to resume session: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		checked := 0
+		for _, record := range records {
+			var frame struct {
+				Method string `json:"method"`
+				Params struct {
+					Env      map[string]string `json:"env"`
+					ClearEnv *bool             `json:"clearEnv"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &frame)
+			if frame.Method != "session.create" && frame.Method != "session.resume" {
+				continue
+			}
+			checked++
+			if frame.Params.Env["PATH"] != "/opt/tenant/bin" || frame.Params.Env["HTTPS_PROXY"] != "http://proxy:3128" {
+				t.Errorf("Expected env in %s, got %v", frame.Method, frame.Params.Env)
+			}
+			if clear := frame.Params.ClearEnv != nil && *frame.Params.ClearEnv; clear != (frame.Method == "session.create") {
+				t.Errorf("Unexpected clearEnv in %s: %v", frame.Method, frame.Params.ClearEnv)
+			}
+		}
+		if checked != 2 {
+			t.Errorf("Expected create and resume requests, found %d", checked)
+		}
+	})
+
+	t.Run("rejects invalid variable names", func(t *testing.T) {
+		client := NewClient(nil)
+		for _, name := range []string{"", "A=B"} {
+			_, err := client.CreateSession(t.Context(), &SessionConfig{
+				OnPermissionRequest: PermissionHandler.ApproveAll,
+				Env:                 map[string]string{name: "value"},
+			})
+			if err == nil || !strings.Contains(err.Error(), "invalid environment variable name") {
+				t.Errorf("Expected error for %q, got %v", name, err)
+			}
+		}
+	})
+}
+
+func TestClient_SessionSandbox(t *testing.T) {
+	t.Run("sends the sandbox with create and resume", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.resume", map[string]any{"sessionId": "s1"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		sandbox := &SandboxConfig{
+			AllowedPaths:    []string{"/srv/repo", "../shared"},
+			Network:         Bool(false),
+			AllowedCommands: []string{"go", "git"},
+		}
+		if _, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Sandbox: sandbox}); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Sandbox: sandbox}); err != nil {
+			t.Fatalf("Failed to resume session: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		checked := 0
+		for _, record := range records {
+			var frame struct {
+				Method string `json:"method"`
+				Params struct {
+					Sandbox map[string]any `json:"sandbox"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &frame)
+			if frame.Method != "session.create" && frame.Method != "session.resume" {
+				continue
+			}
+			checked++
+			expected := map[string]any{
+				"allowedPaths":    []any{"/srv/repo", "../shared"},
+				"network":         false,
+				"allowedCommands": []any{"go", "git"},
+			}
+			if !reflect.DeepEqual(frame.Params.Sandbox, expected) {
+				t.Errorf("Expected sandbox %v in %s, got %v", expected, frame.Method, frame.Params.Sandbox)
+			}
+		}
+		if checked != 2 {
+			t.Errorf("Expected create and resume requests, found %d", checked)
+		}
+	})
+
+	t.Run("rejects empty entries", func(t *testing.T) {
+		client := NewClient(nil)
+		for _, sandbox := range []*SandboxConfig{{AllowedPaths: []string{""}}, {AllowedCommands: []string{"go", ""}}} {
+			_, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Sandbox: sandbox})
+			if err == nil || !strings.Contains(err.Error(), "Sandbox") {
+				t.Errorf("Expected error for %+v, got %v", sandbox, err)
+			}
+		}
+	})
+}
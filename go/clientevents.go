@@ -0,0 +1,172 @@
+package copilot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientEventType identifies a [ClientEvent].
+type ClientEventType string
+
+const (
+	// ClientSessionOpened is emitted when the client creates, resumes, or
+	// forks a session.
+	ClientSessionOpened ClientEventType = "session.opened"
+	// ClientSessionClosed is emitted when a session of the client is
+	// destroyed, including when it expires after SessionIdleTimeout.
+	ClientSessionClosed ClientEventType = "session.closed"
+	// ClientCLIRestarted is emitted when the client spawns the CLI again, e.g.
+	// after a crash with AutoRestart.
+	ClientCLIRestarted ClientEventType = "cli.restarted"
+	// ClientAuthChanged is emitted when the CLI's authentication state changes,
+	// as reported to [Client.OnAuthChange] handlers.
+	ClientAuthChanged ClientEventType = "auth.changed"
+	// ClientQuotaWarning is emitted when a send exceeds a RateLimit quota, and
+	// when the CLI warns a session that it is close to a usage quota.
+	ClientQuotaWarning ClientEventType = "quota.warning"
+	// ClientCircuitChanged is emitted when the circuit breaker configured by
+	// ClientOptions.CircuitBreaker changes state.
+	ClientCircuitChanged ClientEventType = "circuit.changed"
+)
+
+// ClientEvent is a client-scope notification delivered by [Client.Events].
+type ClientEvent struct {
+	Type ClientEventType `json:"type"`
+	// Time is when the SDK emitted the event.
+	Time time.Time `json:"time"`
+	// SessionID is the session the event concerns, if any.
+	SessionID string `json:"sessionId,omitempty"`
+	// Message describes the event, e.g. the CLI's quota warning.
+	Message string `json:"message,omitempty"`
+	// Auth is set for [ClientAuthChanged] events.
+	Auth *AuthChangeEvent `json:"auth,omitempty"`
+	// Quota is set for [ClientQuotaWarning] events about RateLimit quotas.
+	Quota *QuotaExceededEvent `json:"quota,omitempty"`
+	// CircuitFrom and CircuitTo are set for [ClientCircuitChanged] events.
+	CircuitFrom CircuitState `json:"circuitFrom,omitempty"`
+	CircuitTo   CircuitState `json:"circuitTo,omitempty"`
+}
+
+// Events returns a channel that receives the client's client-scope events:
+// sessions opened and closed, CLI restarts, authentication changes, quota
+// warnings, and circuit breaker changes. Dashboards can use it to follow the
+// health of the SDK from one place instead of subscribing to every session.
+//
+// Events are buffered without limit, so a slow reader never blocks the client
+// or loses events. The channel is closed when ctx is done.
+//
+// Example:
+//
+//	for event := range client.Events(ctx) {
+//	    switch event.Type {
+//	    case copilot.ClientCLIRestarted:
+//	        metrics.Restarts.Inc()
+//	    case copilot.ClientAuthChanged:
+//	        if !event.Auth.Status.IsAuthenticated {
+//	            alert("Copilot signed out: " + string(event.Auth.Type))
+//	        }
+//	    }
+//	}
+func (c *Client) Events(ctx context.Context) <-chan ClientEvent {
+	out := make(chan ClientEvent)
+	subscriber := c.events.subscribe()
+
+	go func() {
+		defer close(out)
+		defer c.events.unsubscribe(subscriber)
+		for {
+			next, ok := subscriber.pop()
+			if !ok {
+				select {
+				case <-subscriber.ready:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			select {
+			case out <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// emitEvent delivers a client event to the channels returned by Events.
+func (c *Client) emitEvent(event ClientEvent) {
+	event.Time = time.Now()
+	c.events.publish(event)
+}
+
+// observeSessionWarning emits a quota warning for session.warning events
+// about usage quotas.
+func (c *Client) observeSessionWarning(sessionID string, event SessionEvent) {
+	if event.Type != SessionWarning || !strings.Contains(strings.ToLower(deref(event.Data.WarningType)), "quota") {
+		return
+	}
+	c.emitEvent(ClientEvent{Type: ClientQuotaWarning, SessionID: sessionID, Message: deref(event.Data.Message)})
+}
+
+// clientEventBus fans client events out to the subscribers created by Events.
+type clientEventBus struct {
+	mu          sync.Mutex
+	subscribers map[*clientEventSubscriber]struct{}
+}
+
+// clientEventSubscriber buffers the events of one Events channel.
+type clientEventSubscriber struct {
+	mu      sync.Mutex
+	pending []ClientEvent
+	ready   chan struct{} // signaled when an event is added
+}
+
+func (b *clientEventBus) subscribe() *clientEventSubscriber {
+	subscriber := &clientEventSubscriber{ready: make(chan struct{}, 1)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[*clientEventSubscriber]struct{})
+	}
+	b.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (b *clientEventBus) unsubscribe(subscriber *clientEventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, subscriber)
+}
+
+func (b *clientEventBus) publish(event ClientEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for subscriber := range b.subscribers {
+		subscriber.push(event)
+	}
+}
+
+func (s *clientEventSubscriber) push(event ClientEvent) {
+	s.mu.Lock()
+	s.pending = append(s.pending, event)
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *clientEventSubscriber) pop() (ClientEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return ClientEvent{}, false
+	}
+	event := s.pending[0]
+	s.pending[0] = ClientEvent{}
+	s.pending = s.pending[1:]
+	return event, true
+}
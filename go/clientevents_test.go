@@ -0,0 +1,106 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_Events(t *testing.T) {
+	// next reads the next event, failing the test if none arrives.
+	next := func(t *testing.T, events <-chan ClientEvent) ClientEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for a client event")
+			return ClientEvent{}
+		}
+	}
+
+	t.Run("reports sessions opened, warned, and closed", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.event("s1", SessionWarning, map[string]any{"warningType": "quota", "message": "90% of premium requests used"})
+		log.call("session.destroy", map[string]any{})
+
+		client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		t.Cleanup(func() { client.ForceStop() })
+		events := client.Events(t.Context())
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start: %v", err)
+		}
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		// The warning is read while CreateSession finishes, so it may come first
+		received := map[ClientEventType]ClientEvent{}
+		for range 2 {
+			event := next(t, events)
+			received[event.Type] = event
+		}
+		if event := received[ClientSessionOpened]; event.SessionID != "s1" || event.Time.IsZero() {
+			t.Errorf("Expected session.opened, got %+v", received)
+		}
+		if event := received[ClientQuotaWarning]; event.SessionID != "s1" || event.Message != "90% of premium requests used" {
+			t.Errorf("Expected a quota warning, got %+v", received)
+		}
+		if err := session.Destroy(); err != nil {
+			t.Fatalf("Failed to destroy: %v", err)
+		}
+		if event := next(t, events); event.Type != ClientSessionClosed || event.SessionID != "s1" {
+			t.Errorf("Expected session.closed, got %+v", event)
+		}
+	})
+
+	t.Run("reports rate limits, auth changes, and the circuit breaker", func(t *testing.T) {
+		client := NewClient(&ClientOptions{
+			RateLimit:      &RateLimitConfig{MaxMessagesPerSession: 1},
+			CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 1},
+		})
+		ctx, cancel := context.WithCancel(t.Context())
+		events := client.Events(ctx)
+
+		client.limiter.acquire(t.Context(), "s1")
+		client.limiter.acquire(t.Context(), "s1")
+		if event := next(t, events); event.Type != ClientQuotaWarning || event.Quota == nil || event.Quota.Limit != QuotaSessionMessages {
+			t.Errorf("Expected a rate limit warning, got %+v", event)
+		}
+
+		client.recordAuthStatus(GetAuthStatusResponse{IsAuthenticated: true}, "", false)
+		client.recordAuthStatus(GetAuthStatusResponse{IsAuthenticated: false}, "", false)
+		if event := next(t, events); event.Type != ClientAuthChanged || event.Auth == nil || event.Auth.Status.IsAuthenticated {
+			t.Errorf("Expected an auth change, got %+v", event)
+		}
+
+		done, _ := client.breaker.allow("ping")
+		done(ErrTimeout)
+		if event := next(t, events); event.Type != ClientCircuitChanged || event.CircuitFrom != CircuitClosed || event.CircuitTo != CircuitOpen {
+			t.Errorf("Expected the circuit to open, got %+v", event)
+		}
+
+		cancel()
+		for range events {
+		}
+		if n := len(client.events.subscribers); n != 0 {
+			t.Errorf("Expected the subscriber to be removed, got %d", n)
+		}
+	})
+
+	t.Run("delivers to every subscriber", func(t *testing.T) {
+		client := NewClient(nil)
+		first, second := client.Events(t.Context()), client.Events(t.Context())
+		client.emitEvent(ClientEvent{Type: ClientCLIRestarted})
+		if next(t, first).Type != ClientCLIRestarted || next(t, second).Type != ClientCLIRestarted {
+			t.Error("Expected both subscribers to receive the event")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CLILogRecord is a line the CLI wrote to stderr, parsed into a leveled log record.
+// See ClientOptions.OnCLILog.
+type CLILogRecord struct {
+	// Time is the timestamp on the line, or when the line was read if it has none.
+	Time time.Time
+	// Level is the severity on the line. Lines without one are reported at
+	// [slog.LevelWarn], since unstructured stderr output is usually a warning or
+	// part of a crash trace.
+	Level slog.Level
+	// Message is the line without its timestamp and level.
+	Message string
+	// Attrs holds the remaining fields of JSON log lines, and is nil for text lines.
+	Attrs map[string]any
+	// Raw is the line as written by the CLI.
+	Raw string
+}
+
+// cliLogLevels maps level names used by the CLI to slog levels.
+var cliLogLevels = map[string]slog.Level{
+	"trace":   slog.LevelDebug - 4,
+	"debug":   slog.LevelDebug,
+	"info":    slog.LevelInfo,
+	"warn":    slog.LevelWarn,
+	"warning": slog.LevelWarn,
+	"error":   slog.LevelError,
+	"fatal":   slog.LevelError + 4,
+}
+
+// cliLogLinePattern matches text log lines such as
+// "2025-01-02T03:04:05.678Z [ERROR] message" and "warn: message".
+var cliLogLinePattern = regexp.MustCompile(`(?i)^(?:(\S+)\s+)?\[?(trace|debug|info|warn|warning|error|fatal)\]?:?\s+(.*)$`)
+
+// parseCLILogLine parses a line of CLI stderr output. JSON lines may use
+// "level" or "severity", "msg" or "message", and "time" or "timestamp" fields;
+// numeric levels follow the pino convention (30 is info, 40 warn, 50 error).
+func parseCLILogLine(line string, now time.Time) CLILogRecord {
+	record := CLILogRecord{Time: now, Level: slog.LevelWarn, Message: line, Raw: line}
+
+	var fields map[string]any
+	if strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &fields) == nil {
+		for _, key := range []string{"level", "severity"} {
+			if level, ok := parseCLILogLevel(fields[key]); ok {
+				record.Level = level
+				delete(fields, key)
+				break
+			}
+		}
+		for _, key := range []string{"msg", "message"} {
+			if message, ok := fields[key].(string); ok {
+				record.Message = message
+				delete(fields, key)
+				break
+			}
+		}
+		for _, key := range []string{"time", "timestamp"} {
+			if t, ok := parseCLILogTime(fields[key]); ok {
+				record.Time = t
+				delete(fields, key)
+				break
+			}
+		}
+		if len(fields) > 0 {
+			record.Attrs = fields
+		}
+		return record
+	}
+
+	m := cliLogLinePattern.FindStringSubmatch(line)
+	if m == nil {
+		return record
+	}
+	if m[1] != "" {
+		t, ok := parseCLILogTime(m[1])
+		if !ok {
+			// The first word is not a timestamp, so the level word is part of the message
+			return record
+		}
+		record.Time = t
+	}
+	record.Level = cliLogLevels[strings.ToLower(m[2])]
+	record.Message = m[3]
+	return record
+}
+
+func parseCLILogLevel(value any) (slog.Level, bool) {
+	switch v := value.(type) {
+	case string:
+		level, ok := cliLogLevels[strings.ToLower(v)]
+		return level, ok
+	case float64:
+		switch {
+		case v >= 60:
+			return cliLogLevels["fatal"], true
+		case v >= 50:
+			return slog.LevelError, true
+		case v >= 40:
+			return slog.LevelWarn, true
+		case v >= 30:
+			return slog.LevelInfo, true
+		case v >= 20:
+			return slog.LevelDebug, true
+		default:
+			return cliLogLevels["trace"], true
+		}
+	}
+	return 0, false
+}
+
+func parseCLILogTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case float64:
+		return time.UnixMilli(int64(v)), true
+	}
+	return time.Time{}, false
+}
+
+// cliLogWriter splits the CLI's stderr output into lines and reports each as a
+// CLILogRecord. It implements io.Writer.
+type cliLogWriter struct {
+	mu      sync.Mutex
+	partial []byte
+	emit    func(CLILogRecord)
+}
+
+func (w *cliLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.partial[:i])
+		w.partial = w.partial[i+1:]
+		w.line(line)
+	}
+	return len(p), nil
+}
+
+// flush reports output after the last newline, once the CLI has exited.
+func (w *cliLogWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.line(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *cliLogWriter) line(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	w.emit(parseCLILogLine(line, time.Now()))
+}
+
+// emitCLILog logs a line of CLI stderr through the client logger, with the
+// attribute source=cli, and passes it to ClientOptions.OnCLILog.
+func (c *Client) emitCLILog(record CLILogRecord) {
+	logger := c.options.Logger
+	if logger.Enabled(context.Background(), record.Level) {
+		attrs := make([]slog.Attr, 0, len(record.Attrs)+1)
+		attrs = append(attrs, slog.String("source", "cli"))
+		for _, key := range slices.Sorted(maps.Keys(record.Attrs)) {
+			attrs = append(attrs, slog.Any(key, record.Attrs[key]))
+		}
+		logger.LogAttrs(context.Background(), record.Level, record.Message, attrs...)
+	}
+	if c.options.OnCLILog != nil {
+		c.options.OnCLILog(record)
+	}
+}
@@ -0,0 +1,106 @@
+package copilot
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCLILogLine(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stamp := time.Date(2025, 1, 2, 3, 4, 5, 678000000, time.UTC)
+
+	tests := []struct {
+		line    string
+		level   slog.Level
+		message string
+		time    time.Time
+	}{
+		{"2025-01-02T03:04:05.678Z [ERROR] Failed to load config", slog.LevelError, "Failed to load config", stamp},
+		{"[info] Listening on stdio", slog.LevelInfo, "Listening on stdio", now},
+		{"warn: Token expires soon", slog.LevelWarn, "Token expires soon", now},
+		{"DEBUG tool registry loaded", slog.LevelDebug, "tool registry loaded", now},
+		{"Error: something went wrong", slog.LevelError, "something went wrong", now},
+		{"    at Object.<anonymous> (/cli/index.js:1:1)", slog.LevelWarn, "    at Object.<anonymous> (/cli/index.js:1:1)", now},
+		{"Connection error reported", slog.LevelWarn, "Connection error reported", now},
+		{`{"level":"debug","msg":"starting","time":"2025-01-02T03:04:05.678Z","pid":42}`, slog.LevelDebug, "starting", stamp},
+		{`{"level":50,"message":"crashed","time":1735787045678}`, slog.LevelError, "crashed", stamp},
+	}
+	for _, test := range tests {
+		record := parseCLILogLine(test.line, now)
+		if record.Level != test.level || record.Message != test.message || !record.Time.Equal(test.time) || record.Raw != test.line {
+			t.Errorf("parseCLILogLine(%q) = %+v, expected level %v, message %q, time %v", test.line, record, test.level, test.message, test.time)
+		}
+	}
+
+	record := parseCLILogLine(`{"level":"info","msg":"ready","pid":42}`, now)
+	if len(record.Attrs) != 1 || record.Attrs["pid"] != float64(42) {
+		t.Errorf("Expected remaining JSON fields as attrs, got %v", record.Attrs)
+	}
+}
+
+func TestCLILogWriter(t *testing.T) {
+	var records []CLILogRecord
+	w := &cliLogWriter{emit: func(record CLILogRecord) { records = append(records, record) }}
+
+	w.Write([]byte("[INFO] first\r\n\n[WARN] sec"))
+	w.Write([]byte("ond\n[ERROR] unterminated"))
+	if len(records) != 2 || records[0].Message != "first" || records[1].Message != "second" {
+		t.Fatalf("Expected complete lines only, got %+v", records)
+	}
+	w.flush()
+	if len(records) != 3 || records[2].Level != slog.LevelError || records[2].Message != "unterminated" {
+		t.Errorf("Expected flush to report the last line, got %+v", records)
+	}
+}
+
+func TestClient_CLILog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	cli := filepath.Join(t.TempDir(), "copilot")
+	script := "#!/bin/sh\necho '[WARN] Model list is stale' >&2\necho 'plain output' >&2\nexit 1\n"
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	var mu sync.Mutex
+	var logs bytes.Buffer
+	var records []CLILogRecord
+	done := make(chan struct{})
+	client := NewClient(&ClientOptions{
+		CLIPath:        cli,
+		DiagnosticsDir: t.TempDir(),
+		Logger:         slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		OnCLILog: func(record CLILogRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, record)
+			if len(records) == 2 {
+				close(done)
+			}
+		},
+	})
+	t.Cleanup(client.ForceStop)
+	client.Start(t.Context())
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for CLI log records")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if records[0].Level != slog.LevelWarn || records[0].Message != "Model list is stale" || records[1].Message != "plain output" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+	if !strings.Contains(logs.String(), `msg="Model list is stale" source=cli`) {
+		t.Errorf("Expected CLI output in the client logger, got:\n%s", logs.String())
+	}
+}
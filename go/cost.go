@@ -0,0 +1,80 @@
+package copilot
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/pricing"
+)
+
+// usageLog accumulates the token usage of a session by model, from
+// assistant.usage events.
+type usageLog struct {
+	mu     sync.Mutex
+	models map[string]*pricing.Usage
+}
+
+// observe adds the usage reported by an assistant.usage event.
+func (l *usageLog) observe(event SessionEvent) {
+	data := event.Data
+	model := "unknown"
+	if data.Model != nil && *data.Model != "" {
+		model = *data.Model
+	}
+	tokens := func(n *float64) int64 {
+		if n == nil {
+			return 0
+		}
+		return int64(*n)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.models == nil {
+		l.models = make(map[string]*pricing.Usage)
+	}
+	usage, ok := l.models[model]
+	if !ok {
+		usage = &pricing.Usage{Model: model}
+		l.models[model] = usage
+	}
+	usage.Requests++
+	usage.InputTokens += tokens(data.InputTokens)
+	usage.OutputTokens += tokens(data.OutputTokens)
+	usage.CacheReadTokens += tokens(data.CacheReadTokens)
+	usage.CacheWriteTokens += tokens(data.CacheWriteTokens)
+}
+
+// snapshot returns the usage of each model, sorted by model ID.
+func (l *usageLog) snapshot() []pricing.Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usage := make([]pricing.Usage, 0, len(l.models))
+	for _, model := range slices.Sorted(maps.Keys(l.models)) {
+		usage = append(usage, *l.models[model])
+	}
+	return usage
+}
+
+// Usage returns the tokens consumed by each model, as reported by the
+// assistant.usage events this Session object received. Usage from before the
+// session was resumed is not included.
+func (s *Session) Usage() []pricing.Usage {
+	return s.usage.snapshot()
+}
+
+// EstimatedCost estimates what the session's [Session.Usage] cost, with the
+// rates of ClientOptions.Pricing. Models without a rate are listed in
+// Estimate.Unpriced and not included in the total.
+//
+// Example:
+//
+//	estimate := session.EstimatedCost()
+//	fmt.Printf("Conversation cost: $%.4f\n", estimate.Total)
+//	for _, model := range estimate.Models {
+//	    fmt.Printf("  %s: %d in, %d out, $%.4f\n", model.Model, model.InputTokens, model.OutputTokens, model.Cost)
+//	}
+func (s *Session) EstimatedCost() pricing.Estimate {
+	return pricing.Calculate(s.pricer, s.usage.snapshot()...)
+}
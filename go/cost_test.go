@@ -0,0 +1,42 @@
+package copilot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/pricing"
+)
+
+func TestSession_EstimatedCost(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.send", map[string]any{"messageId": "m1"})
+	log.event("s1", AssistantUsage, map[string]any{"model": "gpt-5", "inputTokens": 10000, "outputTokens": 1000, "cacheReadTokens": 20000})
+	log.event("s1", AssistantUsage, map[string]any{"model": "gpt-5-2025-08-07", "inputTokens": 2000, "outputTokens": 500})
+	log.event("s1", AssistantUsage, map[string]any{"model": "in-house-model", "inputTokens": 5000})
+	log.event("s1", SessionIdle, map[string]any{})
+
+	client := newPlaybackClientForTest(t, log, nil)
+	client.options.Pricing = pricing.Chain(pricing.Table{"gpt-5": {Input: 1, Output: 8, CacheRead: 0.1}}, pricing.Default())
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hi"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	usage := session.Usage()
+	if len(usage) != 3 || usage[0].Model != "gpt-5" || usage[0].Requests != 1 || usage[0].CacheReadTokens != 20000 {
+		t.Fatalf("Unexpected usage: %+v", usage)
+	}
+	estimate := session.EstimatedCost()
+	// gpt-5: 0.01 + 0.008 + 0.002; the dated snapshot at the same custom rate: 0.002 + 0.004
+	if math.Abs(estimate.Total-0.026) > 1e-9 {
+		t.Errorf("Expected a total of 0.026, got %v", estimate.Total)
+	}
+	if len(estimate.Unpriced) != 1 || estimate.Unpriced[0] != "in-house-model" {
+		t.Errorf("Unexpected unpriced models: %v", estimate.Unpriced)
+	}
+}
@@ -13,7 +13,9 @@ import (
 )
 
 // DefineTool creates a Tool with automatic JSON schema generation from a typed handler function.
-// The handler receives typed arguments (automatically unmarshaled from JSON) and the raw ToolInvocation.
+// The handler receives typed arguments (validated against the schema and decoded with
+// [DecodeArguments]) and the raw ToolInvocation. Arguments that do not match the
+// schema fail the call with a [*ToolArgumentsError] without calling the handler.
 // The handler can return any value - strings pass through directly, other types are JSON-serialized.
 //
 // Example:
@@ -42,17 +44,9 @@ func DefineTool[T any, U any](name, description string, handler func(T, ToolInvo
 // createTypedHandler wraps a typed handler function into the standard ToolHandler signature.
 func createTypedHandler[T any, U any](handler func(T, ToolInvocation) (U, error)) ToolHandler {
 	return func(inv ToolInvocation) (ToolResult, error) {
-		var params T
-
-		// Convert arguments to typed struct via JSON round-trip
-		// Arguments is already map[string]any from JSON-RPC parsing
-		jsonBytes, err := json.Marshal(inv.Arguments)
+		params, err := DecodeArguments[T](inv)
 		if err != nil {
-			return ToolResult{}, fmt.Errorf("failed to marshal arguments: %w", err)
-		}
-
-		if err := json.Unmarshal(jsonBytes, &params); err != nil {
-			return ToolResult{}, fmt.Errorf("failed to unmarshal arguments into %T: %w", params, err)
+			return ToolResult{}, err
 		}
 
 		result, err := handler(params, inv)
@@ -0,0 +1,131 @@
+package copilot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rules reported in [PermissionDeniedError.Rule] for denials made by the SDK
+// or the CLI rather than by a rule of the permission handler.
+const (
+	// DenialRuleNoHandler is reported when no OnPermissionRequest handler is registered.
+	DenialRuleNoHandler = "no-handler"
+	// DenialRuleRemembered is reported when an earlier [AlwaysDenyTool] decision applied.
+	DenialRuleRemembered = "remembered"
+	// DenialRuleHandlerError is reported when the handler returned an error.
+	DenialRuleHandlerError = "handler-error"
+	// DenialRuleEditRejected is reported when a file edit held by
+	// SessionConfig.ReviewEdits was rejected.
+	DenialRuleEditRejected = "edit-rejected"
+	// DenialRuleSandbox is reported when the CLI's sandbox (SessionConfig.Sandbox)
+	// blocked the tool call.
+	DenialRuleSandbox = "sandbox"
+)
+
+// PermissionDeniedError describes a tool call blocked by a permission handler or
+// sandbox policy. It is attached to the [PermissionAuditRecord] and
+// [ToolCallRecord] of the call, and matches [ErrPermissionDenied] with
+// [errors.Is].
+//
+// A permission handler can return a *PermissionDeniedError as its error to deny
+// a request under a named rule. The CLI is told the request was denied by
+// rules, and the SDK fills in the fields the handler left empty.
+//
+// Example:
+//
+//	OnPermissionRequest: func(request copilot.PermissionRequest, _ copilot.PermissionInvocation) (copilot.PermissionRequestResult, error) {
+//	    if strings.Contains(fmt.Sprint(request.Extra["fullCommandText"]), "--force") {
+//	        return copilot.PermissionRequestResult{}, &copilot.PermissionDeniedError{
+//	            Rule:   "no-force-push",
+//	            Reason: "force pushes rewrite shared history",
+//	        }
+//	    }
+//	    return copilot.PermissionRequestResult{Kind: "approved"}, nil
+//	},
+type PermissionDeniedError struct {
+	// SessionID is the session the tool call belongs to.
+	SessionID string
+	// ToolCallID identifies the blocked call, when known.
+	ToolCallID string
+	// ToolName names the tool as in [PermissionRequest.ToolKey], e.g.
+	// "shell(git)", or the tool name for sandbox denials.
+	ToolName string
+	// ArgumentsDigest is a SHA-256 digest of the request details, as
+	// "sha256:<hex>", to correlate denials in audit trails without storing
+	// arguments that may hold secrets.
+	ArgumentsDigest string
+	// Rule identifies the rule that denied the call: the rule a handler
+	// returned, one of the DenialRule constants, or "handler:<name>" for a
+	// handler that denied without naming a rule.
+	Rule string
+	// Reason explains the denial, when known.
+	Reason string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	msg := fmt.Sprintf("permission denied for %s by rule %q", e.ToolName, e.Rule)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Is reports whether target is [ErrPermissionDenied].
+func (e *PermissionDeniedError) Is(target error) bool {
+	t, ok := target.(*SDKError)
+	return ok && t.Code == ErrorCodePermissionDenied
+}
+
+// permissionDenial returns the denial of request described by the handler's
+// result and error, or nil if the request was approved. handler is the name
+// recorded in the audit record.
+func (s *Session) permissionDenial(request PermissionRequest, handler string, result PermissionRequestResult, err error) *PermissionDeniedError {
+	denial, ok := err.(*PermissionDeniedError)
+	switch {
+	case ok:
+		copied := *denial
+		denial = &copied
+		if denial.Rule == "" {
+			denial.Rule = "handler:" + handler
+		}
+	case err != nil:
+		denial = &PermissionDeniedError{Rule: DenialRuleHandlerError, Reason: err.Error()}
+	case !strings.HasPrefix(result.Kind, "denied"):
+		return nil
+	case handler == "none":
+		denial = &PermissionDeniedError{Rule: DenialRuleNoHandler}
+	case handler == "remembered":
+		denial = &PermissionDeniedError{Rule: DenialRuleRemembered}
+	case request.Kind == "write" && s.edits != nil:
+		denial = &PermissionDeniedError{Rule: DenialRuleEditRejected}
+	default:
+		denial = &PermissionDeniedError{Rule: "handler:" + handler}
+	}
+	if denial.SessionID == "" {
+		denial.SessionID = s.SessionID
+	}
+	if denial.ToolCallID == "" {
+		denial.ToolCallID = request.ToolCallID
+	}
+	if denial.ToolName == "" {
+		denial.ToolName = request.ToolKey()
+	}
+	if denial.ArgumentsDigest == "" {
+		denial.ArgumentsDigest = argumentsDigest(request.Extra)
+	}
+	return denial
+}
+
+// argumentsDigest returns the SHA-256 digest of the JSON encoding of arguments,
+// whose map keys are sorted so equal arguments have equal digests.
+func argumentsDigest(arguments any) string {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package copilot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPermissionDeniedError(t *testing.T) {
+	request := PermissionRequest{Kind: "shell", ToolCallID: "t1", Extra: map[string]any{
+		"fullCommandText": "git push --force",
+		"commands":        []any{map[string]any{"identifier": "git"}},
+	}}
+
+	t.Run("records the rule a handler returned", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{}, &PermissionDeniedError{Rule: "no-force-push", Reason: "rewrites shared history"}
+		})
+		session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Timestamp: time.Now(), Data: Data{ToolCallID: String("t1"), ToolName: String("bash")}})
+
+		result, err := session.handlePermissionRequest(request)
+		if err != nil || result.Kind != "denied-by-rules" {
+			t.Fatalf("Expected a rules denial, got %+v, %v", result, err)
+		}
+
+		record := session.PermissionLog()[0]
+		denial := record.Denial
+		if record.Error != "" || record.Decision != "denied-by-rules" || denial == nil {
+			t.Fatalf("Unexpected record: %+v", record)
+		}
+		if denial.Rule != "no-force-push" || denial.ToolName != "shell(git)" || denial.SessionID != "s1" || denial.ToolCallID != "t1" {
+			t.Errorf("Unexpected denial: %+v", denial)
+		}
+		if !strings.HasPrefix(denial.ArgumentsDigest, "sha256:") || strings.Contains(denial.ArgumentsDigest, "force") {
+			t.Errorf("Expected a digest of the arguments, got %q", denial.ArgumentsDigest)
+		}
+		if !errors.Is(denial, ErrPermissionDenied) {
+			t.Error("Expected the denial to match ErrPermissionDenied")
+		}
+		if want := `permission denied for shell(git) by rule "no-force-push": rewrites shared history`; denial.Error() != want {
+			t.Errorf("Expected %q, got %q", want, denial.Error())
+		}
+		if calls := session.ToolCalls(); len(calls) != 1 || calls[0].Denial != denial {
+			t.Errorf("Expected the denial on the tool call record, got %+v", calls)
+		}
+
+		other := PermissionRequest{Kind: "shell", Extra: map[string]any{"commands": []any{map[string]any{"identifier": "git"}}, "fullCommandText": "git push --force"}}
+		if session.permissionDenial(other, "h", PermissionRequestResult{Kind: "denied-by-rules"}, nil).ArgumentsDigest != denial.ArgumentsDigest {
+			t.Error("Expected equal arguments to have equal digests")
+		}
+	})
+
+	t.Run("names the rule of other denials", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.handlePermissionRequest(request)
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{Kind: "denied-interactively-by-user", Scope: AlwaysDenyTool}, nil
+		})
+		session.handlePermissionRequest(request)
+		session.handlePermissionRequest(request)
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{}, errors.New("policy service unavailable")
+		})
+		session.ForgetPermissions()
+		session.handlePermissionRequest(request)
+		session.registerPermissionHandler(PermissionHandler.ApproveAll)
+		session.handlePermissionRequest(request)
+
+		log := session.PermissionLog()
+		rules := []string{DenialRuleNoHandler, "handler:", DenialRuleRemembered, DenialRuleHandlerError}
+		for i, rule := range rules {
+			if log[i].Denial == nil || !strings.HasPrefix(log[i].Denial.Rule, rule) {
+				t.Errorf("Record %d: expected rule %q, got %+v", i, rule, log[i].Denial)
+			}
+		}
+		if log[4].Denial != nil {
+			t.Errorf("Expected no denial for an approval, got %+v", log[4].Denial)
+		}
+	})
+
+	t.Run("reports sandbox denials from the CLI", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Timestamp: time.Now(), Data: Data{
+			ToolCallID: String("t2"), ToolName: String("web_fetch"), Arguments: map[string]any{"url": "https://example.com"},
+		}})
+		session.dispatchEvent(SessionEvent{Type: ToolExecutionComplete, Timestamp: time.Now(), Data: Data{
+			ToolCallID: String("t2"),
+			Error:      &ErrorUnion{ErrorClass: &ErrorClass{Code: String("permission_denied"), Message: "network access is disabled"}},
+		}})
+
+		denial := session.ToolCalls()[0].Denial
+		if denial == nil || denial.Rule != DenialRuleSandbox || denial.ToolName != "web_fetch" || denial.Reason != "network access is disabled" || denial.SessionID != "s1" {
+			t.Errorf("Unexpected denial: %+v", denial)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+)
+
+// FeatureDeterministicSampling is the CLI feature flag, reported by
+// [Client.Capabilities], for seeded sampling requested by SessionConfig.Deterministic.
+const FeatureDeterministicSampling = "deterministicSampling"
+
+// DeterministicConfig makes a session's responses repeatable, for tests.
+//
+// When the CLI reports [FeatureDeterministicSampling], the session samples with
+// temperature 0, top_p 1, and Seed. Otherwise the model's output cannot be made
+// repeatable, and the session is only created if the client records its traffic
+// with ClientOptions.RecordTo, so that the run can be replayed exactly with
+// [NewPlaybackClient], or is itself a playback client.
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    Deterministic:       &copilot.DeterministicConfig{Seed: 42},
+//	})
+type DeterministicConfig struct {
+	// Seed is passed to the model's sampler. Runs with the same seed and inputs
+	// produce the same output, as far as the model provider guarantees it.
+	Seed int64
+}
+
+// samplingConfig is the sampling the CLI is asked to use for every request in a session.
+type samplingConfig struct {
+	Temperature float64 `json:"temperature"`
+	TopP        float64 `json:"topP"`
+	Seed        int64   `json:"seed"`
+}
+
+// deterministicSampling returns the sampling settings to create a session with
+// for config, or nil when config is nil or the session's responses are
+// made repeatable by recording instead.
+func (c *Client) deterministicSampling(ctx context.Context, config *DeterministicConfig) (*samplingConfig, error) {
+	if config == nil {
+		return nil, nil
+	}
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	sampling, err := samplingFor(config, capabilities, c.playback != nil || c.options.RecordTo != nil)
+	if err == nil && sampling == nil {
+		c.options.Logger.Info("CLI does not support deterministic sampling; relying on the replay log", "version", capabilities.Version)
+	}
+	return sampling, err
+}
+
+// samplingFor decides how to make a session deterministic given the CLI's
+// capabilities and whether the client's traffic is recorded or replayed.
+func samplingFor(config *DeterministicConfig, capabilities *Capabilities, recorded bool) (*samplingConfig, error) {
+	if capabilities.HasFeature(FeatureDeterministicSampling) {
+		return &samplingConfig{Temperature: 0, TopP: 1, Seed: config.Seed}, nil
+	}
+	if recorded {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("CLI version %s does not support deterministic sampling; set ClientOptions.RecordTo to record the session and replay it with NewPlaybackClient", capabilities.Version)
+}
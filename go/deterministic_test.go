@@ -0,0 +1,80 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSamplingFor(t *testing.T) {
+	config := &DeterministicConfig{Seed: 42}
+
+	t.Run("seeds sampling when the CLI supports it", func(t *testing.T) {
+		capabilities := &Capabilities{Features: map[string]bool{FeatureDeterministicSampling: true}}
+		sampling, err := samplingFor(config, capabilities, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sampling == nil || *sampling != (samplingConfig{Temperature: 0, TopP: 1, Seed: 42}) {
+			t.Errorf("Unexpected sampling: %+v", sampling)
+		}
+	})
+
+	t.Run("relies on recording otherwise", func(t *testing.T) {
+		capabilities := &Capabilities{Version: "1.0.0"}
+		if sampling, err := samplingFor(config, capabilities, true); sampling != nil || err != nil {
+			t.Errorf("Expected no sampling settings, got %+v, %v", sampling, err)
+		}
+		_, err := samplingFor(config, capabilities, false)
+		if err == nil || !strings.Contains(err.Error(), "1.0.0 does not support deterministic sampling") {
+			t.Errorf("Expected an unsupported error, got %v", err)
+		}
+	})
+}
+
+func TestClient_DeterministicSession(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("capabilities.get", map[string]any{
+		"version":         "1.2.3",
+		"protocolVersion": GetSdkProtocolVersion(),
+		"features":        map[string]bool{FeatureDeterministicSampling: true},
+	})
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+
+	var recorded bytes.Buffer
+	client := newPlaybackClientForTest(t, log, &recorded)
+	session, err := client.CreateSession(t.Context(), &SessionConfig{
+		OnPermissionRequest: PermissionHandler.ApproveAll,
+		Deterministic:       &DeterministicConfig{Seed: 7},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+	for _, record := range records {
+		var message struct {
+			Method string `json:"method"`
+			Params struct {
+				Sampling map[string]any `json:"sampling"`
+			} `json:"params"`
+		}
+		json.Unmarshal(record.Message, &message)
+		if message.Method != "session.create" {
+			continue
+		}
+		expected := map[string]any{"temperature": float64(0), "topP": float64(1), "seed": float64(7)}
+		for key, value := range expected {
+			if message.Params.Sampling[key] != value {
+				t.Errorf("Expected sampling %v, got %v", expected, message.Params.Sampling)
+			}
+		}
+	}
+
+	temperature := 0.5
+	if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi", Temperature: &temperature}); err == nil {
+		t.Error("Expected a per-message Temperature to be rejected")
+	}
+}
@@ -0,0 +1,234 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/redact"
+)
+
+const (
+	// diagnosticsFrames is how many recent JSON-RPC frames a diagnostics bundle holds.
+	diagnosticsFrames = 100
+	// diagnosticsStderrLines is how many recent lines of CLI stderr a bundle holds.
+	diagnosticsStderrLines = 100
+	// diagnosticsInterval is the minimum time between bundles, so that a stream of
+	// malformed frames produces one bundle rather than hundreds.
+	diagnosticsInterval = time.Minute
+)
+
+// Diagnostics is a snapshot of the client's recent activity, captured when the
+// CLI crashes or violates the JSON-RPC protocol. Attach the bundle directory to
+// bug reports. See [Client.LastDiagnostics].
+type Diagnostics struct {
+	// Dir is the directory the bundle was written to, or empty if writing failed.
+	// It holds diagnostics.json and frames.jsonl, a replay log of the recent
+	// frames that can be loaded with [NewPlaybackClient].
+	Dir string `json:"-"`
+	// Time is when the problem was detected.
+	Time time.Time `json:"time"`
+	// Reason describes the crash or protocol error.
+	Reason string `json:"reason"`
+	// SDKProtocolVersion is the protocol version this SDK speaks.
+	SDKProtocolVersion int `json:"sdkProtocolVersion"`
+	// CLIVersion is the CLI version, if it was known before the problem.
+	CLIVersion string `json:"cliVersion,omitempty"`
+	// GoVersion, OS, and Arch describe the host program.
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	// Frames are the most recent JSON-RPC frames, oldest first, with credentials redacted
+	// by ClientOptions.Redactor, which also applies to Reason and Stderr.
+	Frames []ReplayRecord `json:"-"`
+	// Stderr holds the last lines the CLI wrote to stderr.
+	Stderr []string `json:"stderr"`
+	// Sessions summarizes the sessions that were open.
+	Sessions []SessionDiagnostics `json:"sessions"`
+}
+
+// SessionDiagnostics summarizes one open session in a [Diagnostics] bundle.
+type SessionDiagnostics struct {
+	SessionID     string `json:"sessionId"`
+	WorkspacePath string `json:"workspacePath,omitempty"`
+	// Busy reports whether a turn was in progress.
+	Busy         bool   `json:"busy"`
+	MessagesSent int64  `json:"messagesSent"`
+	CurrentAgent string `json:"currentAgent,omitempty"`
+}
+
+// diagnosticsRecorder keeps the recent frames and stderr lines of a client.
+type diagnosticsRecorder struct {
+	redactor *redact.Redactor // ClientOptions.Redactor; nil means redact.Default
+
+	mu      sync.Mutex
+	frames  []ReplayRecord
+	stderr  []string
+	partial []byte // stderr written since the last newline
+	// capturedAt is when the last bundle was started, and last is the bundle.
+	capturedAt time.Time
+	last       *Diagnostics
+}
+
+// observe is a jsonrpc2.FrameObserver.
+func (r *diagnosticsRecorder) observe(direction jsonrpc2.FrameDirection, data []byte) {
+	record := ReplayRecord{Time: time.Now(), Direction: "recv", Message: json.RawMessage(redactFrame(r.redactor, data))}
+	if direction == jsonrpc2.FrameSent {
+		record.Direction = "send"
+	}
+	if !json.Valid(record.Message) {
+		record.Message, _ = json.Marshal(string(record.Message))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, record)
+	if len(r.frames) > diagnosticsFrames {
+		r.frames = slices.Delete(r.frames, 0, len(r.frames)-diagnosticsFrames)
+	}
+}
+
+// Write records the CLI's stderr output. It implements io.Writer.
+func (r *diagnosticsRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.partial = append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+		if i < 0 {
+			break
+		}
+		r.stderr = append(r.stderr, strings.TrimRight(string(r.partial[:i]), "\r"))
+		r.partial = r.partial[i+1:]
+	}
+	if len(r.stderr) > diagnosticsStderrLines {
+		r.stderr = slices.Delete(r.stderr, 0, len(r.stderr)-diagnosticsStderrLines)
+	}
+	return len(p), nil
+}
+
+// captureDiagnostics records a diagnostics bundle for reason and writes it to
+// ClientOptions.DiagnosticsDir, unless a bundle was captured within diagnosticsInterval.
+func (c *Client) captureDiagnostics(reason error) {
+	r := &c.diagnostics
+	r.mu.Lock()
+	if time.Since(r.capturedAt) < diagnosticsInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.capturedAt = time.Now()
+	diagnostics := &Diagnostics{
+		Time:               r.capturedAt,
+		Reason:             reason.Error(),
+		SDKProtocolVersion: GetSdkProtocolVersion(),
+		GoVersion:          runtime.Version(),
+		OS:                 runtime.GOOS,
+		Arch:               runtime.GOARCH,
+		Frames:             slices.Clone(r.frames),
+		Stderr:             slices.Clone(r.stderr),
+	}
+	if len(r.partial) > 0 {
+		diagnostics.Stderr = append(diagnostics.Stderr, string(r.partial))
+	}
+	redactor := r.redactor
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+	diagnostics.Reason = redactor.String(diagnostics.Reason)
+	for i, line := range diagnostics.Stderr {
+		diagnostics.Stderr[i] = redactor.String(line)
+	}
+	r.mu.Unlock()
+
+	c.capabilitiesMux.Lock()
+	if c.capabilities != nil {
+		diagnostics.CLIVersion = c.capabilities.Version
+	}
+	c.capabilitiesMux.Unlock()
+
+	c.sessionsMux.Lock()
+	for _, session := range c.sessions {
+		diagnostics.Sessions = append(diagnostics.Sessions, SessionDiagnostics{
+			SessionID:     session.SessionID,
+			WorkspacePath: session.WorkspacePath(),
+			Busy:          session.busy.Load(),
+			MessagesSent:  session.messagesSent.Load(),
+			CurrentAgent:  session.CurrentAgent(),
+		})
+	}
+	c.sessionsMux.Unlock()
+	slices.SortFunc(diagnostics.Sessions, func(a, b SessionDiagnostics) int { return strings.Compare(a.SessionID, b.SessionID) })
+
+	dir, err := writeDiagnostics(c.options.DiagnosticsDir, diagnostics)
+	if err != nil {
+		c.options.Logger.Warn("failed to write diagnostics bundle", "error", err)
+	} else {
+		diagnostics.Dir = dir
+		c.options.Logger.Warn("captured diagnostics bundle", "dir", dir, "reason", diagnostics.Reason)
+	}
+
+	r.mu.Lock()
+	r.last = diagnostics
+	r.mu.Unlock()
+}
+
+// writeDiagnostics writes diagnostics to a new directory in parent, or the
+// system temporary directory if parent is empty, and returns its path.
+func writeDiagnostics(parent string, diagnostics *Diagnostics) (string, error) {
+	if parent == "" {
+		parent = os.TempDir()
+	}
+	dir, err := os.MkdirTemp(parent, "copilot-diagnostics-*")
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "diagnostics.json"), summary, 0600); err != nil {
+		return "", err
+	}
+
+	var frames bytes.Buffer
+	for _, record := range diagnostics.Frames {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return "", err
+		}
+		frames.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "frames.jsonl"), frames.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// LastDiagnostics returns the most recent diagnostics bundle, captured when the
+// CLI process exited unexpectedly, the CLI sent malformed JSON-RPC data, or its
+// protocol version did not match. It returns nil if nothing has gone wrong.
+//
+// Example:
+//
+//	if _, err := session.SendAndWait(ctx, options); errors.Is(err, copilot.ErrCLIUnavailable) {
+//	    if diagnostics := client.LastDiagnostics(); diagnostics != nil {
+//	        log.Printf("CLI crashed; attach %s to the bug report", diagnostics.Dir)
+//	    }
+//	}
+func (c *Client) LastDiagnostics() *Diagnostics {
+	c.diagnostics.mu.Lock()
+	defer c.diagnostics.mu.Unlock()
+	if c.diagnostics.last == nil {
+		return nil
+	}
+	diagnostics := *c.diagnostics.last
+	return &diagnostics
+}
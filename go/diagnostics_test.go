@@ -0,0 +1,113 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestClient_Diagnostics(t *testing.T) {
+	t.Run("captures a bundle on protocol mismatch", func(t *testing.T) {
+		log := &replayLog{}
+		log.call("ping", map[string]any{"message": "pong", "timestamp": 1, "protocolVersion": GetSdkProtocolVersion() + 1})
+
+		client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		client.options.DiagnosticsDir = t.TempDir()
+		t.Cleanup(client.ForceStop)
+		if client.LastDiagnostics() != nil {
+			t.Fatal("Expected no diagnostics before a failure")
+		}
+		if err := client.Start(t.Context()); err == nil {
+			t.Fatal("Expected protocol mismatch error")
+		}
+
+		diagnostics := client.LastDiagnostics()
+		if diagnostics == nil {
+			t.Fatal("Expected diagnostics after a protocol mismatch")
+		}
+		if !strings.Contains(diagnostics.Reason, "protocol version mismatch") || diagnostics.GoVersion != runtime.Version() {
+			t.Errorf("Unexpected diagnostics: %+v", diagnostics)
+		}
+		if len(diagnostics.Frames) != 2 || diagnostics.Frames[0].Direction != "send" || diagnostics.Frames[1].Direction != "recv" {
+			t.Errorf("Expected the ping request and response, got %+v", diagnostics.Frames)
+		}
+
+		frames, err := os.ReadFile(filepath.Join(diagnostics.Dir, "frames.jsonl"))
+		if err != nil {
+			t.Fatalf("Failed to read frames: %v", err)
+		}
+		if records, err := readReplayLog(bytes.NewReader(frames)); err != nil || len(records) != 2 {
+			t.Errorf("Expected frames.jsonl to be a replay log of 2 frames, got %d (err=%v)", len(records), err)
+		}
+		summary, err := os.ReadFile(filepath.Join(diagnostics.Dir, "diagnostics.json"))
+		if err != nil {
+			t.Fatalf("Failed to read summary: %v", err)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal(summary, &decoded); err != nil || decoded["reason"] != diagnostics.Reason {
+			t.Errorf("Unexpected diagnostics.json: %s", summary)
+		}
+	})
+
+	t.Run("captures stderr when the CLI crashes", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses a shell script as the CLI")
+		}
+		cli := filepath.Join(t.TempDir(), "copilot")
+		script := "#!/bin/sh\necho 'starting' >&2\necho 'fatal: out of memory' >&2\nexit 3\n"
+		if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to write fake CLI: %v", err)
+		}
+
+		client := NewClient(&ClientOptions{CLIPath: cli, DiagnosticsDir: t.TempDir()})
+		t.Cleanup(client.ForceStop)
+		if err := client.Start(t.Context()); err == nil {
+			t.Fatal("Expected start to fail when the CLI exits")
+		}
+
+		// The crash is recorded by the goroutine that waits for the process
+		deadline := time.Now().Add(5 * time.Second)
+		for client.LastDiagnostics() == nil && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		diagnostics := client.LastDiagnostics()
+		if diagnostics == nil {
+			t.Fatal("Expected diagnostics after the CLI crashed")
+		}
+		if !slices.Equal(diagnostics.Stderr, []string{"starting", "fatal: out of memory"}) {
+			t.Errorf("Unexpected stderr: %q", diagnostics.Stderr)
+		}
+		if !strings.Contains(diagnostics.Reason, "exit status 3") || diagnostics.Dir == "" {
+			t.Errorf("Unexpected diagnostics: %+v", diagnostics)
+		}
+	})
+
+	t.Run("keeps recent frames with credentials redacted", func(t *testing.T) {
+		var r diagnosticsRecorder
+		for i := 0; i < diagnosticsFrames+5; i++ {
+			r.observe(jsonrpc2.FrameSent, []byte(`{"jsonrpc":"2.0","method":"auth","params":{"githubToken":"ghp_secret"}}`))
+		}
+		r.observe(jsonrpc2.FrameReceived, []byte(`not json`))
+
+		if len(r.frames) != diagnosticsFrames {
+			t.Errorf("Expected %d frames, got %d", diagnosticsFrames, len(r.frames))
+		}
+		if strings.Contains(string(r.frames[0].Message), "ghp_secret") {
+			t.Errorf("Expected token to be redacted, got %s", r.frames[0].Message)
+		}
+		if last := r.frames[len(r.frames)-1]; !json.Valid(last.Message) || last.Direction != "recv" {
+			t.Errorf("Expected malformed frame to be kept as a JSON string, got %+v", last)
+		}
+	})
+}
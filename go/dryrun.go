@@ -0,0 +1,124 @@
+package copilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DryRunConfig configures dry-run mode, enabled by SessionConfig.DryRun.
+//
+// In dry-run mode, tool calls are recorded instead of executed. Built-in tools
+// such as file edits and shell commands are blocked before they run, and the
+// model is told that the call was not executed. Tools registered with the
+// session return a synthesized result without running their handler.
+type DryRunConfig struct {
+	// AllowTools lists tools that still run, such as read-only tools that let
+	// the agent explore the workspace, e.g. "view" or "grep".
+	AllowTools []string
+	// Simulate, when non-nil, returns a synthesized result for a call, or
+	// false to send the default dry-run notice. For built-in tools, the result's
+	// TextResultForLLM is included in the notice the model receives.
+	Simulate func(call DryRunCall) (ToolResult, bool)
+	// OnCall, when non-nil, is called for each call that was not executed.
+	OnCall func(call DryRunCall)
+}
+
+// DryRunCall is a tool call that dry-run mode did not execute.
+type DryRunCall struct {
+	// ToolName is the tool the agent called.
+	ToolName string
+	// ToolCallID identifies the call. It is only set for tools registered with
+	// the session, since the CLI does not report it for built-in tools.
+	ToolCallID string
+	// Arguments are the arguments the tool would have been called with.
+	Arguments any
+	// Cwd is the working directory a built-in tool would have run in.
+	Cwd string
+	// Simulated is set when DryRunConfig.Simulate synthesized the result.
+	Simulated bool
+	// Time is when the call was made.
+	Time time.Time
+}
+
+// dryRunNotice is the result the model receives for a call that was not
+// executed.
+const dryRunNotice = "Dry run: the %s tool was not executed, and nothing was changed. Describe what you would do next as if it had succeeded."
+
+// dryRun records the tool calls of a session in dry-run mode.
+type dryRun struct {
+	config DryRunConfig
+
+	mu    sync.Mutex
+	calls []DryRunCall
+}
+
+func newDryRun(config DryRunConfig) *dryRun {
+	return &dryRun{config: config}
+}
+
+// allows reports whether toolName runs despite dry-run mode.
+func (d *dryRun) allows(toolName string) bool {
+	return slices.Contains(d.config.AllowTools, toolName)
+}
+
+// simulate records call and returns the result to send for it, and whether
+// Simulate synthesized it.
+func (d *dryRun) simulate(call DryRunCall) (ToolResult, bool) {
+	call.Time = time.Now()
+	result := ToolResult{
+		TextResultForLLM: fmt.Sprintf(dryRunNotice, call.ToolName),
+		ResultType:       "success",
+		ToolTelemetry:    map[string]any{},
+	}
+	if d.config.Simulate != nil {
+		if simulated, ok := d.config.Simulate(call); ok {
+			result = simulated
+			call.Simulated = true
+		}
+	}
+
+	d.mu.Lock()
+	d.calls = append(d.calls, call)
+	d.mu.Unlock()
+	if d.config.OnCall != nil {
+		d.config.OnCall(call)
+	}
+	return result, call.Simulated
+}
+
+// preToolUse blocks a built-in tool call. It returns nil for tools that run
+// anyway, including those registered with the session, which are simulated
+// when the CLI calls them.
+func (d *dryRun) preToolUse(s *Session, rawInput json.RawMessage) (*PreToolUseHookOutput, error) {
+	var input PreToolUseHookInput
+	if err := json.Unmarshal(rawInput, &input); err != nil {
+		return nil, fmt.Errorf("invalid hook input: %w", err)
+	}
+	if d.allows(input.ToolName) {
+		return nil, nil
+	}
+	if _, ok := s.getToolHandler(input.ToolName); ok {
+		return nil, nil
+	}
+
+	result, simulated := d.simulate(DryRunCall{ToolName: input.ToolName, Arguments: input.ToolArgs, Cwd: input.Cwd})
+	reason := result.TextResultForLLM
+	if simulated {
+		reason = fmt.Sprintf("Dry run: the %s tool was not executed. Simulated result:\n%s", input.ToolName, reason)
+	}
+	return &PreToolUseHookOutput{PermissionDecision: "deny", PermissionDecisionReason: reason}, nil
+}
+
+// DryRunLog returns the tool calls that dry-run mode did not execute, oldest
+// first, or nil if the session is not in dry-run mode. See SessionConfig.DryRun.
+func (s *Session) DryRunLog() []DryRunCall {
+	if s.dryRun == nil {
+		return nil
+	}
+	s.dryRun.mu.Lock()
+	defer s.dryRun.mu.Unlock()
+	return slices.Clone(s.dryRun.calls)
+}
@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	newDryRunClient := func(config DryRunConfig, handler ToolHandler) (*Client, *Session) {
+		session := newSession("s1", nil, "")
+		session.registerTools([]Tool{
+			{Name: "deploy", Handler: handler},
+			{Name: "lookup", Handler: handler},
+		})
+		session.dryRun = newDryRun(config)
+		return &Client{sessions: map[string]*Session{"s1": session}}, session
+	}
+	preToolUse := func(session *Session, tool string, args map[string]any) any {
+		input, _ := json.Marshal(PreToolUseHookInput{ToolName: tool, ToolArgs: args, Cwd: "/repo"})
+		output, err := session.handleHooksInvoke("preToolUse", input)
+		if err != nil {
+			t.Fatalf("Hook failed: %v", err)
+		}
+		return output
+	}
+
+	t.Run("simulates registered tools without running them", func(t *testing.T) {
+		ran := false
+		var notified []DryRunCall
+		client, session := newDryRunClient(DryRunConfig{
+			AllowTools: []string{"lookup"},
+			OnCall:     func(call DryRunCall) { notified = append(notified, call) },
+		}, func(ToolInvocation) (ToolResult, error) {
+			ran = true
+			return ToolResult{TextResultForLLM: "ran", ResultType: "success"}, nil
+		})
+
+		response, _ := client.handleToolCallRequest(toolCallRequest{SessionID: "s1", ToolCallID: "t1", ToolName: "deploy", Arguments: map[string]any{"env": "prod"}})
+		if ran || response.Result.ResultType != "success" || !strings.HasPrefix(response.Result.TextResultForLLM, "Dry run: the deploy tool was not executed") {
+			t.Errorf("Expected a dry-run notice without running the handler, got %+v", response.Result)
+		}
+		response, _ = client.handleToolCallRequest(toolCallRequest{SessionID: "s1", ToolCallID: "t2", ToolName: "lookup"})
+		if !ran || response.Result.TextResultForLLM != "ran" {
+			t.Errorf("Expected an allowed tool to run, got %+v", response.Result)
+		}
+
+		log := session.DryRunLog()
+		if len(log) != 1 || log[0].ToolName != "deploy" || log[0].ToolCallID != "t1" || log[0].Simulated || log[0].Time.IsZero() {
+			t.Errorf("Unexpected dry-run log: %+v", log)
+		}
+		if len(notified) != 1 || notified[0].ToolCallID != "t1" {
+			t.Errorf("Expected OnCall for the simulated call, got %+v", notified)
+		}
+	})
+
+	t.Run("blocks built-in tools with a notice", func(t *testing.T) {
+		_, session := newDryRunClient(DryRunConfig{
+			AllowTools: []string{"view"},
+			Simulate: func(call DryRunCall) (ToolResult, bool) {
+				if call.ToolName == "bash" {
+					return ToolResult{TextResultForLLM: "exit code 0", ResultType: "success"}, true
+				}
+				return ToolResult{}, false
+			},
+		}, func(ToolInvocation) (ToolResult, error) { return ToolResult{}, nil })
+		var userHookCalls int
+		session.registerHooks(&SessionHooks{OnPreToolUse: func(PreToolUseHookInput, HookInvocation) (*PreToolUseHookOutput, error) {
+			userHookCalls++
+			return nil, nil
+		}})
+
+		edit, _ := preToolUse(session, "edit", map[string]any{"path": "main.go"}).(*PreToolUseHookOutput)
+		if edit == nil || edit.PermissionDecision != "deny" || !strings.Contains(edit.PermissionDecisionReason, "Dry run: the edit tool was not executed") {
+			t.Errorf("Expected the edit to be denied with a notice, got %+v", edit)
+		}
+		shell, _ := preToolUse(session, "bash", map[string]any{"command": "make deploy"}).(*PreToolUseHookOutput)
+		if shell == nil || shell.PermissionDecision != "deny" || !strings.HasSuffix(shell.PermissionDecisionReason, "Simulated result:\nexit code 0") {
+			t.Errorf("Expected the simulated result as the reason, got %+v", shell)
+		}
+		preToolUse(session, "view", nil)
+		preToolUse(session, "deploy", nil)
+		if userHookCalls != 2 {
+			t.Errorf("Expected the host hook for the tools that run, got %d calls", userHookCalls)
+		}
+
+		log := session.DryRunLog()
+		if len(log) != 2 || log[0].ToolName != "edit" || log[0].Cwd != "/repo" || !log[1].Simulated {
+			t.Errorf("Unexpected dry-run log: %+v", log)
+		}
+	})
+}
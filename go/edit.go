@@ -0,0 +1,108 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeatureHistoryTruncate is the CLI feature flag, reported by
+// [Client.Capabilities], for removing the end of a session's history through
+// session.history.truncate, which [Session.EditMessage] and
+// [Session.Regenerate] need.
+const FeatureHistoryTruncate = "historyTruncate"
+
+// EditMessage replaces the user message with the given event ID, like the
+// "edit your prompt" action of chat clients: the message and every event after
+// it are removed from the history, and newContent is sent in its place with
+// the original attachments. Returns the ID of the new message; as with
+// [Session.Send], subscribe to events or use [Session.Events] for the reply.
+//
+// Returns an error if a message is being processed, if id is not a user
+// message in the history, or if the CLI does not support
+// [FeatureHistoryTruncate]. Take a [Session.Checkpoint] first to be able to
+// undo the edit.
+//
+// Example:
+//
+//	events, _ := session.GetMessages(ctx)
+//	// ... the user edits the prompt of event id in the UI
+//	if _, err := session.EditMessage(ctx, id, "Use PostgreSQL instead"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Session) EditMessage(ctx context.Context, id, newContent string) (string, error) {
+	if newContent == "" {
+		return "", fmt.Errorf("message content is required")
+	}
+	return s.rewindAndSend(ctx, func(events []SessionEvent) (*SessionEvent, error) {
+		for i := range events {
+			if events[i].ID != id {
+				continue
+			}
+			if events[i].Type != UserMessage {
+				return nil, fmt.Errorf("event %q is a %s, not a user message", id, events[i].Type)
+			}
+			return &events[i], nil
+		}
+		return nil, fmt.Errorf("message %q not found in the session history", id)
+	}, &newContent)
+}
+
+// Regenerate discards the reply to the last user message and sends the message
+// again, so the model answers it anew. Returns the ID of the new message; as
+// with [Session.Send], subscribe to events or use [Session.Events] for the
+// reply.
+//
+// Returns an error if a message is being processed, if the history has no user
+// message, or if the CLI does not support [FeatureHistoryTruncate].
+func (s *Session) Regenerate(ctx context.Context) (string, error) {
+	return s.rewindAndSend(ctx, func(events []SessionEvent) (*SessionEvent, error) {
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Type == UserMessage {
+				return &events[i], nil
+			}
+		}
+		return nil, fmt.Errorf("the session history has no user message to regenerate")
+	}, nil)
+}
+
+// rewindAndSend removes the user message chosen by find, and everything after
+// it, from the history and sends it again, with content replacing its prompt
+// when not nil.
+func (s *Session) rewindAndSend(ctx context.Context, find func([]SessionEvent) (*SessionEvent, error), content *string) (string, error) {
+	if s.busy.Load() {
+		return "", fmt.Errorf("cannot rewind the history while a message is being processed")
+	}
+	if s.capabilities != nil {
+		capabilities, err := s.capabilities(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to check CLI capabilities: %w", err)
+		}
+		if !capabilities.HasFeature(FeatureHistoryTruncate) {
+			return "", fmt.Errorf("CLI version %s does not support editing the history", capabilities.Version)
+		}
+	}
+
+	events, err := s.GetMessages(ctx)
+	if err != nil {
+		return "", err
+	}
+	message, err := find(events)
+	if err != nil {
+		return "", err
+	}
+	options := MessageOptions{Attachments: message.Data.Attachments}
+	if content != nil {
+		options.Prompt = *content
+	} else if message.Data.Content != nil {
+		options.Prompt = *message.Data.Content
+	}
+
+	result, err := s.RPC.History.Truncate(ctx, &rpc.SessionHistoryTruncateParams{EventID: message.ID})
+	if err != nil {
+		return "", fmt.Errorf("failed to truncate history: %w", err)
+	}
+	s.logger.Info("history truncated", "eventId", message.ID, "removedEvents", int(result.RemovedEvents))
+	return s.Send(ctx, options)
+}
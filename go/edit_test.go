@@ -0,0 +1,139 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func editHistory() []SessionEvent {
+	str := func(s string) *string { return &s }
+	return []SessionEvent{
+		{ID: "u1", Type: UserMessage, Data: Data{Content: str("Use MySQL"), Attachments: []Attachment{{Type: "file", Path: str("schema.sql"), DisplayName: "schema.sql"}}}},
+		{ID: "a1", Type: AssistantMessage, Data: Data{Content: str("Done with MySQL")}},
+		{ID: "u2", Type: UserMessage, Data: Data{Content: str("Add an index")}},
+		{ID: "a2", Type: AssistantMessage, Data: Data{Content: str("Index added")}},
+	}
+}
+
+func TestSession_EditMessage(t *testing.T) {
+	capabilities := map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion(), "features": map[string]bool{FeatureHistoryTruncate: true}}
+
+	// sent returns the params of the requests recorded for method.
+	sent := func(t *testing.T, recorded *bytes.Buffer, method string) []map[string]any {
+		t.Helper()
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to read recording: %v", err)
+		}
+		var params []map[string]any
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == method {
+				params = append(params, message.Params)
+			}
+		}
+		return params
+	}
+
+	t.Run("replaces a message and the turns after it", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", capabilities)
+		log.call("session.getMessages", map[string]any{"events": editHistory()})
+		log.call("session.history.truncate", map[string]any{"removedEvents": 4})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		id, err := session.EditMessage(t.Context(), "u1", "Use PostgreSQL")
+		if err != nil || id != "m2" {
+			t.Fatalf("Unexpected result: %q, %v", id, err)
+		}
+		truncate := sent(t, &recorded, "session.history.truncate")
+		if len(truncate) != 1 || truncate[0]["eventId"] != "u1" {
+			t.Errorf("Expected the history to be truncated at u1, got %v", truncate)
+		}
+		send := sent(t, &recorded, "session.send")
+		if len(send) != 1 || send[0]["prompt"] != "Use PostgreSQL" {
+			t.Fatalf("Expected the edited prompt to be sent, got %v", send)
+		}
+		if attachments, _ := send[0]["attachments"].([]any); len(attachments) != 1 {
+			t.Errorf("Expected the original attachment to be sent again, got %v", send[0]["attachments"])
+		}
+	})
+
+	t.Run("regenerates the last reply", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", capabilities)
+		log.call("session.getMessages", map[string]any{"events": editHistory()})
+		log.call("session.history.truncate", map[string]any{"removedEvents": 2})
+		log.call("session.send", map[string]any{"messageId": "m3"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		if _, err := session.Regenerate(t.Context()); err != nil {
+			t.Fatalf("Failed to regenerate: %v", err)
+		}
+		truncate := sent(t, &recorded, "session.history.truncate")
+		send := sent(t, &recorded, "session.send")
+		if len(truncate) != 1 || truncate[0]["eventId"] != "u2" || len(send) != 1 || send[0]["prompt"] != "Add an index" {
+			t.Errorf("Expected the last user message to be resent, got %v and %v", truncate, send)
+		}
+	})
+
+	t.Run("rejects events that are not user messages", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", capabilities)
+		log.call("session.getMessages", map[string]any{"events": editHistory()})
+		log.call("session.getMessages", map[string]any{"events": editHistory()})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.EditMessage(t.Context(), "a1", "Hi"); err == nil || !strings.Contains(err.Error(), "not a user message") {
+			t.Errorf("Expected an error for an assistant message, got %v", err)
+		}
+		if _, err := session.EditMessage(t.Context(), "missing", "Hi"); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected an error for an unknown message, got %v", err)
+		}
+	})
+
+	t.Run("requires CLI support", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.Regenerate(t.Context()); err == nil || !strings.Contains(err.Error(), "CLI version 1.0.0 does not support") {
+			t.Errorf("Expected an unsupported error, got %v", err)
+		}
+	})
+}
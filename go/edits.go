@@ -0,0 +1,183 @@
+package copilot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PendingEdit is a file write proposed by the agent that is waiting for the host
+// application to apply or reject it. See SessionConfig.ReviewEdits.
+type PendingEdit struct {
+	// ID identifies the edit in calls to [Session.ApplyEdits] and [Session.RejectEdits].
+	ID string
+	// ToolCallID is the tool call that proposed the edit.
+	ToolCallID string
+	// Path is the file the agent wants to write.
+	Path string
+	// Diff is the proposed change as a unified diff.
+	Diff string
+	// NewContents is the full file content after the edit, when the CLI provides it.
+	NewContents string
+	// Intention is the agent's description of the edit.
+	Intention string
+	// ProposedAt is when the edit was received.
+	ProposedAt time.Time
+}
+
+// editReview holds the edits of a session that are waiting for a decision.
+type editReview struct {
+	mu        sync.Mutex
+	pending   []*pendingEdit
+	nextID    int
+	closed    bool
+	onPropose func(PendingEdit)
+}
+
+type pendingEdit struct {
+	edit     PendingEdit
+	decision chan bool // receives true to apply, false to reject
+}
+
+func newEditReview(onPropose func(PendingEdit)) *editReview {
+	return &editReview{onPropose: onPropose}
+}
+
+// await records a write permission request as a pending edit and blocks until it
+// is applied or rejected.
+func (r *editReview) await(request PermissionRequest) PermissionRequestResult {
+	str := func(key string) string {
+		value, _ := request.Extra[key].(string)
+		return value
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return PermissionRequestResult{Kind: "denied-interactively-by-user"}
+	}
+	r.nextID++
+	p := &pendingEdit{
+		edit: PendingEdit{
+			ID:          fmt.Sprintf("edit-%d", r.nextID),
+			ToolCallID:  request.ToolCallID,
+			Path:        str("fileName"),
+			Diff:        str("diff"),
+			NewContents: str("newFileContents"),
+			Intention:   str("intention"),
+			ProposedAt:  time.Now(),
+		},
+		decision: make(chan bool, 1),
+	}
+	r.pending = append(r.pending, p)
+	onPropose := r.onPropose
+	r.mu.Unlock()
+
+	if onPropose != nil {
+		onPropose(p.edit)
+	}
+
+	if <-p.decision {
+		return PermissionRequestResult{Kind: "approved"}
+	}
+	return PermissionRequestResult{Kind: "denied-interactively-by-user"}
+}
+
+// list returns the pending edits in the order they were proposed.
+func (r *editReview) list() []PendingEdit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	edits := make([]PendingEdit, len(r.pending))
+	for i, p := range r.pending {
+		edits[i] = p.edit
+	}
+	return edits
+}
+
+// decide resolves the edits with the given IDs, or all pending edits if ids is empty.
+func (r *editReview) decide(apply bool, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(ids) == 0 {
+		for _, p := range r.pending {
+			p.decision <- apply
+		}
+		r.pending = nil
+		return nil
+	}
+
+	var unknown []string
+	for _, id := range ids {
+		if !r.containsLocked(id) {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("no pending edit with ID %s", strings.Join(unknown, ", "))
+	}
+
+	remaining := r.pending[:0]
+	for _, p := range r.pending {
+		if slices.Contains(ids, p.edit.ID) {
+			p.decision <- apply
+		} else {
+			remaining = append(remaining, p)
+		}
+	}
+	r.pending = remaining
+	return nil
+}
+
+// close rejects all pending edits and any proposed later.
+func (r *editReview) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, p := range r.pending {
+		p.decision <- false
+	}
+	r.pending = nil
+}
+
+func (r *editReview) containsLocked(id string) bool {
+	for _, p := range r.pending {
+		if p.edit.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingEdits returns the file edits proposed by the agent that have not been
+// applied or rejected yet, oldest first. It is empty unless SessionConfig.ReviewEdits is set.
+func (s *Session) PendingEdits() []PendingEdit {
+	if s.edits == nil {
+		return nil
+	}
+	return s.edits.list()
+}
+
+// ApplyEdits approves the pending edits with the given IDs, or all pending edits
+// if no IDs are given. The CLI then writes each file and the agent continues.
+//
+// Returns an error, without applying anything, if an ID does not match a pending edit.
+func (s *Session) ApplyEdits(ids ...string) error {
+	if s.edits == nil {
+		return fmt.Errorf("edit review is not enabled for this session")
+	}
+	return s.edits.decide(true, ids)
+}
+
+// RejectEdits rejects the pending edits with the given IDs, or all pending edits
+// if no IDs are given. The agent is told that the write was denied by the user.
+//
+// Returns an error, without rejecting anything, if an ID does not match a pending edit.
+func (s *Session) RejectEdits(ids ...string) error {
+	if s.edits == nil {
+		return fmt.Errorf("edit review is not enabled for this session")
+	}
+	return s.edits.decide(false, ids)
+}
@@ -0,0 +1,116 @@
+package copilot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_ReviewEdits(t *testing.T) {
+	writeRequest := func(file string) PermissionRequest {
+		return PermissionRequest{Kind: "write", ToolCallID: "t-" + file, Extra: map[string]any{
+			"fileName":  file,
+			"diff":      "--- a/" + file + "\n+++ b/" + file,
+			"intention": "Fix the bug",
+		}}
+	}
+	newReviewSession := func() (*Session, chan PendingEdit) {
+		proposed := make(chan PendingEdit, 10)
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(PermissionHandler.ApproveAll)
+		session.edits = newEditReview(func(edit PendingEdit) { proposed <- edit })
+		return session, proposed
+	}
+	decide := func(session *Session, request PermissionRequest) chan string {
+		result := make(chan string, 1)
+		go func() {
+			decision, _ := session.handlePermissionRequest(request)
+			result <- decision.Kind
+		}()
+		return result
+	}
+	await := func(t *testing.T, ch chan string) string {
+		t.Helper()
+		select {
+		case kind := <-ch:
+			return kind
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for permission decision")
+			return ""
+		}
+	}
+
+	t.Run("holds writes until they are applied or rejected", func(t *testing.T) {
+		session, proposed := newReviewSession()
+		first := decide(session, writeRequest("a.go"))
+		edit := <-proposed
+		second := decide(session, writeRequest("b.go"))
+		<-proposed
+
+		if edit.Path != "a.go" || edit.Intention != "Fix the bug" || edit.ToolCallID != "t-a.go" || edit.Diff == "" {
+			t.Errorf("Unexpected pending edit: %+v", edit)
+		}
+		pending := session.PendingEdits()
+		if len(pending) != 2 || pending[0].ID != edit.ID {
+			t.Fatalf("Expected 2 pending edits, got %+v", pending)
+		}
+
+		if err := session.ApplyEdits(edit.ID); err != nil {
+			t.Fatalf("Failed to apply edit: %v", err)
+		}
+		if kind := await(t, first); kind != "approved" {
+			t.Errorf("Expected applied edit to be approved, got %q", kind)
+		}
+		if err := session.RejectEdits(); err != nil {
+			t.Fatalf("Failed to reject edits: %v", err)
+		}
+		if kind := await(t, second); kind != "denied-interactively-by-user" {
+			t.Errorf("Expected rejected edit to be denied, got %q", kind)
+		}
+		if len(session.PendingEdits()) != 0 {
+			t.Errorf("Expected no pending edits, got %+v", session.PendingEdits())
+		}
+	})
+
+	t.Run("rejects unknown IDs without deciding anything", func(t *testing.T) {
+		session, proposed := newReviewSession()
+		decide(session, writeRequest("a.go"))
+		edit := <-proposed
+
+		if err := session.ApplyEdits(edit.ID, "edit-99"); err == nil {
+			t.Error("Expected error for unknown edit ID")
+		}
+		if len(session.PendingEdits()) != 1 {
+			t.Errorf("Expected edit to remain pending, got %+v", session.PendingEdits())
+		}
+		session.edits.close()
+	})
+
+	t.Run("leaves other permission requests to the handler", func(t *testing.T) {
+		session, _ := newReviewSession()
+		result, _ := session.handlePermissionRequest(PermissionRequest{Kind: "shell"})
+		if result.Kind != "approved" {
+			t.Errorf("Expected shell request to use the permission handler, got %q", result.Kind)
+		}
+	})
+
+	t.Run("rejects pending edits when closed", func(t *testing.T) {
+		session, proposed := newReviewSession()
+		result := decide(session, writeRequest("a.go"))
+		<-proposed
+		session.edits.close()
+
+		if kind := await(t, result); kind != "denied-interactively-by-user" {
+			t.Errorf("Expected edit to be denied on close, got %q", kind)
+		}
+		if kind := await(t, decide(session, writeRequest("b.go"))); kind != "denied-interactively-by-user" {
+			t.Errorf("Expected later edits to be denied, got %q", kind)
+		}
+	})
+
+	t.Run("reports when review is disabled", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		if err := session.ApplyEdits(); err == nil {
+			t.Error("Expected error when ReviewEdits is not enabled")
+		}
+	})
+}
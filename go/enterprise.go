@@ -0,0 +1,87 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// gitHubHostEnv is the environment variable that points the CLI, like the gh
+// CLI, at a GitHub Enterprise Server host.
+const gitHubHostEnv = "GH_HOST"
+
+// gitHubHostTimeout bounds the reachability check of ClientOptions.GitHubHost
+// when the context passed to Start has no earlier deadline.
+const gitHubHostTimeout = 10 * time.Second
+
+// gitHubHostClient is the HTTP client used to check that the GitHub host is
+// reachable. Tests replace it to trust their servers.
+var gitHubHostClient = http.DefaultClient
+
+// GitHubHostError is returned by [Client.Start] when the GitHub Enterprise
+// Server host configured by ClientOptions.GitHubHost cannot be reached, so
+// that the problem is reported before the CLI fails to sign in.
+type GitHubHostError struct {
+	// Host is the configured host, e.g. "github.example.com".
+	Host string
+	// Err is the error from the request to the host's API.
+	Err error
+}
+
+func (e *GitHubHostError) Error() string {
+	return fmt.Sprintf("GitHub host %s is unreachable: %v; check ClientOptions.GitHubHost, DNS, proxy settings, and that the host's TLS certificate is trusted", e.Host, e.Err)
+}
+
+func (e *GitHubHostError) Unwrap() error {
+	return e.Err
+}
+
+// normalizeGitHubHost returns the host, with its port if any, of a
+// ClientOptions.GitHubHost given as a host name or as the URL of the instance,
+// e.g. "github.example.com" or "https://github.example.com/".
+func normalizeGitHubHost(value string) (string, error) {
+	raw := strings.TrimSpace(value)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHubHost %q: %w", value, err)
+	}
+	switch {
+	case parsed.Scheme != "https":
+		return "", fmt.Errorf("invalid GitHubHost %q: scheme must be https", value)
+	case parsed.Hostname() == "":
+		return "", fmt.Errorf("invalid GitHubHost %q: missing host name", value)
+	case parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "":
+		return "", fmt.Errorf("invalid GitHubHost %q: must be a host name or the URL of the instance", value)
+	case parsed.Path != "" && parsed.Path != "/" && strings.TrimSuffix(parsed.Path, "/") != "/api/v3":
+		return "", fmt.Errorf("invalid GitHubHost %q: unexpected path %q", value, parsed.Path)
+	}
+	return strings.ToLower(parsed.Host), nil
+}
+
+// checkGitHubHost reports a [*GitHubHostError] if the API of host does not
+// answer. Any HTTP response, including an error status, counts as reachable.
+func checkGitHubHost(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, gitHubHostTimeout)
+	defer cancel()
+
+	endpoint := "https://" + host + "/api/v3/meta"
+	if host == "github.com" {
+		endpoint = "https://api.github.com/meta"
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return &GitHubHostError{Host: host, Err: err}
+	}
+	response, err := gitHubHostClient.Do(request)
+	if err != nil {
+		return &GitHubHostError{Host: host, Err: err}
+	}
+	response.Body.Close()
+	return nil
+}
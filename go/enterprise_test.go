@@ -0,0 +1,98 @@
+package copilot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeGitHubHost(t *testing.T) {
+	valid := map[string]string{
+		"github.example.com":                     "github.example.com",
+		"GitHub.Example.com":                     "github.example.com",
+		"https://github.example.com":             "github.example.com",
+		"https://github.example.com/":            "github.example.com",
+		"https://github.example.com:8443/api/v3": "github.example.com:8443",
+	}
+	for input, want := range valid {
+		if got, err := normalizeGitHubHost(input); err != nil || got != want {
+			t.Errorf("normalizeGitHubHost(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "http://github.example.com", "https://github.example.com/orgs/acme", "https://user@github.example.com", "https://github.example.com?x=1"} {
+		if _, err := normalizeGitHubHost(input); err == nil {
+			t.Errorf("Expected normalizeGitHubHost(%q) to fail", input)
+		}
+	}
+}
+
+func TestClient_GitHubHost(t *testing.T) {
+	t.Run("checks that the host is reachable", func(t *testing.T) {
+		var path string
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		previous := gitHubHostClient
+		gitHubHostClient = server.Client()
+		t.Cleanup(func() { gitHubHostClient = previous })
+
+		host := server.Listener.Addr().String()
+		if err := checkGitHubHost(t.Context(), host); err != nil {
+			t.Errorf("Expected an error status to count as reachable, got %v", err)
+		}
+		if path != "/api/v3/meta" {
+			t.Errorf("Expected the meta endpoint, got %q", path)
+		}
+
+		server.Close()
+		err := checkGitHubHost(t.Context(), host)
+		var hostErr *GitHubHostError
+		if !errors.As(err, &hostErr) || hostErr.Host != host || !strings.Contains(err.Error(), "is unreachable") {
+			t.Errorf("Expected a GitHubHostError, got %v", err)
+		}
+	})
+
+	t.Run("fails to start when the host is unreachable", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		host := server.Listener.Addr().String()
+		server.Close()
+
+		client := NewClient(&ClientOptions{CLIPath: "/nonexistent/copilot", GitHubHost: "https://" + host + "/"})
+		var hostErr *GitHubHostError
+		if err := client.Start(t.Context()); !errors.As(err, &hostErr) {
+			t.Errorf("Expected a GitHubHostError before spawning the CLI, got %v", err)
+		}
+		if client.options.GitHubHost != host {
+			t.Errorf("Expected the normalized host, got %q", client.options.GitHubHost)
+		}
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, options := range []*ClientOptions{
+			{GitHubHost: "ftp://github.example.com"},
+			{GitHubHost: "github.example.com", CLIUrl: "localhost:8080"},
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("Expected NewClient to panic for %+v", options)
+					}
+				}()
+				NewClient(options)
+			}()
+		}
+	})
+
+	t.Run("loads the host from the environment", func(t *testing.T) {
+		t.Setenv("COPILOT_SDK_GITHUB_HOST", "github.example.com")
+		options, err := LoadOptions("")
+		if err != nil || options.GitHubHost != "github.example.com" {
+			t.Errorf("Expected GitHubHost from the environment, got %+v (err=%v)", options, err)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// ErrorCode classifies an [*SDKError].
+type ErrorCode string
+
+const (
+	// ErrorCodeUnknown is used for errors returned by the CLI that carry no recognized code.
+	ErrorCodeUnknown ErrorCode = "unknown"
+	// ErrorCodeRateLimited indicates that a rate limit or quota was exceeded.
+	// Check [SDKError.RetryAfter] for when to try again.
+	ErrorCodeRateLimited ErrorCode = "rate_limited"
+	// ErrorCodePermissionDenied indicates that the operation was not permitted.
+	ErrorCodePermissionDenied ErrorCode = "permission_denied"
+	// ErrorCodeCLIUnavailable indicates that the CLI could not be started or the connection to it was lost.
+	ErrorCodeCLIUnavailable ErrorCode = "cli_unavailable"
+	// ErrorCodeProtocolMismatch indicates that the CLI speaks a different protocol version than the SDK.
+	ErrorCodeProtocolMismatch ErrorCode = "protocol_mismatch"
+	// ErrorCodeTimeout indicates that the SDK stopped waiting for the CLI before it finished.
+	ErrorCodeTimeout ErrorCode = "timeout"
+	// ErrorCodeQueueFull indicates that a session's message queue has no room for another message.
+	ErrorCodeQueueFull ErrorCode = "queue_full"
+	// ErrorCodeUnauthenticated indicates that the CLI is not signed in, or its token expired.
+	// See [Client.OnAuthChange].
+	ErrorCodeUnauthenticated ErrorCode = "unauthenticated"
+	// ErrorCodeCircuitOpen indicates that the client's circuit breaker failed the
+	// request without sending it. See [ClientOptions.CircuitBreaker].
+	ErrorCodeCircuitOpen ErrorCode = "circuit_open"
+	// ErrorCodeModelUnavailable indicates that the requested model cannot serve
+	// requests right now. See SessionConfig.ModelFallbacks.
+	ErrorCodeModelUnavailable ErrorCode = "model_unavailable"
+	// ErrorCodeCacheDiverged indicates that a message was not sent because
+	// earlier turns of the session were served from the response cache, which
+	// the CLI did not see. See [ResponseCacheConfig].
+	ErrorCodeCacheDiverged ErrorCode = "cache_diverged"
+)
+
+// Sentinel errors for use with [errors.Is]. An [*SDKError] matches a sentinel with the same code.
+//
+// Example:
+//
+//	_, err := session.Send(ctx, copilot.MessageOptions{Prompt: "Hello"})
+//	if errors.Is(err, copilot.ErrRateLimited) {
+//	    var copilotErr *copilot.SDKError
+//	    errors.As(err, &copilotErr)
+//	    time.Sleep(copilotErr.RetryAfter)
+//	}
+var (
+	ErrRateLimited      = &SDKError{Code: ErrorCodeRateLimited}
+	ErrPermissionDenied = &SDKError{Code: ErrorCodePermissionDenied}
+	ErrCLIUnavailable   = &SDKError{Code: ErrorCodeCLIUnavailable}
+	ErrProtocolMismatch = &SDKError{Code: ErrorCodeProtocolMismatch}
+	ErrTimeout          = &SDKError{Code: ErrorCodeTimeout}
+	ErrQueueFull        = &SDKError{Code: ErrorCodeQueueFull}
+	ErrUnauthenticated  = &SDKError{Code: ErrorCodeUnauthenticated}
+	ErrCircuitOpen      = &SDKError{Code: ErrorCodeCircuitOpen}
+	ErrModelUnavailable = &SDKError{Code: ErrorCodeModelUnavailable}
+	ErrCacheDiverged    = &SDKError{Code: ErrorCodeCacheDiverged}
+)
+
+// SDKError is the structured error type returned by the SDK.
+//
+// Use [errors.Is] with the Err* sentinels to check the code, or [errors.As] to
+// access RetryAfter and the raw JSON-RPC error.
+type SDKError struct {
+	// Code classifies the error.
+	Code ErrorCode
+	// Message is a human-readable description of the error.
+	Message string
+	// RetryAfter is how long to wait before retrying, when known. Zero otherwise.
+	RetryAfter time.Duration
+	// RPCError is the JSON-RPC error returned by the CLI, or nil if the error originated in the SDK.
+	RPCError *RPCError
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// RPCError is a JSON-RPC error object returned by the CLI.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    map[string]any
+}
+
+func (e *SDKError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return string(e.Code)
+}
+
+func (e *SDKError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *SDKError with the same code, so that
+// errors.Is(err, ErrRateLimited) matches any rate limit error.
+func (e *SDKError) Is(target error) bool {
+	t, ok := target.(*SDKError)
+	return ok && t.Code == e.Code
+}
+
+// classifyError converts transport errors and expired deadlines into *SDKError
+// values. It is installed as the JSON-RPC client's error mapper so that both
+// Client methods and the typed RPC wrappers return structured errors.
+func classifyError(err error) error {
+	var rpcErr *jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return &SDKError{
+			Code:       rpcErrorCode(rpcErr),
+			Message:    rpcErr.Message,
+			RetryAfter: rpcRetryAfter(rpcErr),
+			RPCError:   &RPCError{Code: rpcErr.Code, Message: rpcErr.Message, Data: rpcErr.Data},
+			Err:        err,
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &SDKError{Code: ErrorCodeTimeout, Message: err.Error(), Err: err}
+	}
+
+	var connErr *jsonrpc2.ConnectionError
+	if errors.As(err, &connErr) {
+		return &SDKError{Code: ErrorCodeCLIUnavailable, Message: connErr.Error(), Err: err}
+	}
+
+	return err
+}
+
+// rpcErrorCode reads the error code from the "code" member of the JSON-RPC error data.
+func rpcErrorCode(rpcErr *jsonrpc2.Error) ErrorCode {
+	if code, ok := rpcErr.Data["code"].(string); ok {
+		switch ErrorCode(code) {
+		case ErrorCodeRateLimited, ErrorCodePermissionDenied, ErrorCodeCLIUnavailable, ErrorCodeProtocolMismatch, ErrorCodeTimeout, ErrorCodeUnauthenticated, ErrorCodeModelUnavailable:
+			return ErrorCode(code)
+		}
+	}
+	return ErrorCodeUnknown
+}
+
+// rpcRetryAfter reads the "retryAfter" member, in seconds, of the JSON-RPC error data.
+func rpcRetryAfter(rpcErr *jsonrpc2.Error) time.Duration {
+	if seconds, ok := rpcErr.Data["retryAfter"].(float64); ok && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return 0
+}
+
+// newError wraps err in an *SDKError with the given code.
+func newError(code ErrorCode, err error) *SDKError {
+	return &SDKError{Code: code, Message: err.Error(), Err: err}
+}
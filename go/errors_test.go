@@ -0,0 +1,119 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestSDKError(t *testing.T) {
+	t.Run("classifies JSON-RPC errors by data code", func(t *testing.T) {
+		err := classifyError(&jsonrpc2.Error{
+			Code:    -32000,
+			Message: "too many requests",
+			Data:    map[string]any{"code": "rate_limited", "retryAfter": 1.5},
+		})
+
+		if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected error to match ErrRateLimited, got %v", err)
+		}
+		var sdkErr *SDKError
+		if !errors.As(err, &sdkErr) {
+			t.Fatalf("Expected *SDKError, got %T", err)
+		}
+		if sdkErr.RetryAfter != 1500*time.Millisecond {
+			t.Errorf("Expected RetryAfter 1.5s, got %s", sdkErr.RetryAfter)
+		}
+		if sdkErr.RPCError == nil || sdkErr.RPCError.Code != -32000 {
+			t.Errorf("Expected raw RPC error with code -32000, got %+v", sdkErr.RPCError)
+		}
+		if err.Error() != "JSON-RPC Error -32000: too many requests" {
+			t.Errorf("Expected original error text, got %q", err.Error())
+		}
+	})
+
+	t.Run("classifies unrecognized JSON-RPC errors as unknown", func(t *testing.T) {
+		err := classifyError(&jsonrpc2.Error{Code: -32603, Message: "internal error"})
+
+		var sdkErr *SDKError
+		if !errors.As(err, &sdkErr) || sdkErr.Code != ErrorCodeUnknown {
+			t.Errorf("Expected unknown SDKError, got %v", err)
+		}
+		if errors.Is(err, ErrRateLimited) {
+			t.Error("Expected unknown error not to match ErrRateLimited")
+		}
+	})
+
+	t.Run("classifies connection errors as CLI unavailable", func(t *testing.T) {
+		err := classifyError(&jsonrpc2.ConnectionError{Err: fmt.Errorf("client stopped")})
+
+		if !errors.Is(err, ErrCLIUnavailable) {
+			t.Errorf("Expected error to match ErrCLIUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("matches through wrapping", func(t *testing.T) {
+		err := fmt.Errorf("failed to send message: %w", classifyError(&jsonrpc2.Error{
+			Code: -32000, Message: "denied", Data: map[string]any{"code": "permission_denied"},
+		}))
+
+		if !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("Expected wrapped error to match ErrPermissionDenied, got %v", err)
+		}
+	})
+
+	t.Run("reports protocol mismatch on start", func(t *testing.T) {
+		log := &replayLog{}
+		log.call("ping", map[string]any{"message": "pong", "timestamp": 1, "protocolVersion": GetSdkProtocolVersion() + 1})
+
+		client, err := NewPlaybackClient(&log.buf)
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		t.Cleanup(func() { client.ForceStop() })
+
+		if err := client.Start(t.Context()); !errors.Is(err, ErrProtocolMismatch) {
+			t.Errorf("Expected ErrProtocolMismatch, got %v", err)
+		}
+	})
+
+	t.Run("reports rate limited sends", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "session.send", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32603, "message": "internal error"}})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		client, err := NewPlaybackClient(&log.buf)
+		if err != nil {
+			t.Fatalf("Failed to create playback client: %v", err)
+		}
+		client.limiter = newRateLimiter(RateLimitConfig{MaxMessagesPerSession: 1})
+		t.Cleanup(func() { client.ForceStop() })
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "one"}); err == nil || errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected the CLI's error, got %v", err)
+		}
+		// The failed send gave its capacity back
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "one"}); err != nil {
+			t.Fatalf("Expected the retried send to succeed, got %v", err)
+		}
+
+		_, err = session.Send(t.Context(), MessageOptions{Prompt: "two"})
+		var quotaErr *QuotaExceededError
+		if !errors.Is(err, ErrRateLimited) || !errors.As(err, &quotaErr) {
+			t.Errorf("Expected rate limited error wrapping QuotaExceededError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package copilot
+
+import (
+	"context"
+)
+
+// Events returns a channel that receives every event of this session, as an
+// alternative to [Session.On] for programs built around select loops.
+//
+// Events are buffered without limit, so a slow reader never blocks event
+// dispatch or loses events, unless ClientOptions.SessionMemoryLimit is set and
+// the buffer grows past it. The channel is closed when ctx is done, or after the
+// remaining buffered events are delivered once the session is destroyed.
+//
+// Example:
+//
+//	events := session.Events(ctx)
+//	for {
+//	    select {
+//	    case event, ok := <-events:
+//	        if !ok {
+//	            return
+//	        }
+//	        if event.Type == copilot.AssistantMessage {
+//	            fmt.Println(*event.Data.Content)
+//	        }
+//	    case job := <-jobs:
+//	        session.Send(ctx, copilot.MessageOptions{Prompt: job.Prompt})
+//	    }
+//	}
+func (s *Session) Events(ctx context.Context) <-chan SessionEvent {
+	out := make(chan SessionEvent)
+	buffer := &eventBuffer{ready: make(chan struct{}, 1)}
+	s.buffers.add(buffer)
+	unsubscribe := s.On(buffer.push)
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+		defer s.buffers.remove(buffer)
+
+		closing := false
+		for {
+			next, ok := buffer.pop()
+			if !ok {
+				if closing {
+					return
+				}
+				select {
+				case <-buffer.ready:
+				case <-s.destroyed:
+					// Deliver events dispatched before the session was destroyed, then close
+					closing = true
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			select {
+			case out <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
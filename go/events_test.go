@@ -0,0 +1,82 @@
+package copilot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSession_Events(t *testing.T) {
+	receive := func(t *testing.T, events <-chan SessionEvent) (SessionEvent, bool) {
+		t.Helper()
+		select {
+		case event, ok := <-events:
+			return event, ok
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for event")
+			return SessionEvent{}, false
+		}
+	}
+
+	t.Run("buffers events in order without blocking dispatch", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		events := session.Events(t.Context())
+
+		for i := 0; i < 100; i++ {
+			session.dispatchEvent(SessionEvent{ID: string(rune('a' + i%26)), Type: AssistantMessageDelta})
+		}
+		session.dispatchEvent(SessionEvent{Type: SessionIdle})
+
+		for i := 0; i < 100; i++ {
+			event, ok := receive(t, events)
+			if !ok || event.ID != string(rune('a'+i%26)) {
+				t.Fatalf("Expected event %d in order, got %+v (ok=%v)", i, event, ok)
+			}
+		}
+		if event, _ := receive(t, events); event.Type != SessionIdle {
+			t.Errorf("Expected session.idle last, got %s", event.Type)
+		}
+	})
+
+	t.Run("closes when the context is done", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		ctx, cancel := context.WithCancel(t.Context())
+		events := session.Events(ctx)
+		cancel()
+
+		if _, ok := receive(t, events); ok {
+			t.Error("Expected channel to be closed")
+		}
+		session.handlerMutex.RLock()
+		handlers := len(session.handlers)
+		session.handlerMutex.RUnlock()
+		if handlers != 0 {
+			t.Errorf("Expected handler to be removed, %d remain", handlers)
+		}
+	})
+
+	t.Run("delivers buffered events and closes when the session is destroyed", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.destroy", map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		events := session.Events(t.Context())
+		session.dispatchEvent(SessionEvent{Type: SessionIdle})
+		if err := session.Destroy(); err != nil {
+			t.Fatalf("Failed to destroy session: %v", err)
+		}
+
+		if event, ok := receive(t, events); !ok || event.Type != SessionIdle {
+			t.Errorf("Expected buffered session.idle, got %+v (ok=%v)", event, ok)
+		}
+		if _, ok := receive(t, events); ok {
+			t.Error("Expected channel to be closed after destroy")
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/redact"
+)
+
+// ExportFormat names a transcript format understood by [Session.Export].
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "markdown"
+	ExportHTML     ExportFormat = "html"
+	ExportJSON     ExportFormat = "json"
+)
+
+// TranscriptRenderer renders session history as a transcript.
+// Register custom renderers with [RegisterTranscriptRenderer].
+type TranscriptRenderer interface {
+	Render(w io.Writer, events []SessionEvent) error
+}
+
+// TranscriptRendererFunc adapts a function to the [TranscriptRenderer] interface.
+type TranscriptRendererFunc func(w io.Writer, events []SessionEvent) error
+
+// Render calls f(w, events).
+func (f TranscriptRendererFunc) Render(w io.Writer, events []SessionEvent) error {
+	return f(w, events)
+}
+
+var (
+	renderers = map[ExportFormat]TranscriptRenderer{
+		ExportMarkdown: TranscriptRendererFunc(renderMarkdown),
+		ExportHTML:     TranscriptRendererFunc(renderHTML),
+		ExportJSON:     TranscriptRendererFunc(renderJSON),
+	}
+	renderersMux sync.RWMutex
+)
+
+// RegisterTranscriptRenderer makes a renderer available to [Session.Export] under
+// the given format name, replacing any existing renderer for that format.
+//
+// Example:
+//
+//	copilot.RegisterTranscriptRenderer("text", copilot.TranscriptRendererFunc(
+//	    func(w io.Writer, events []copilot.SessionEvent) error {
+//	        for _, event := range events {
+//	            if event.Type == copilot.AssistantMessage && event.Data.Content != nil {
+//	                fmt.Fprintln(w, *event.Data.Content)
+//	            }
+//	        }
+//	        return nil
+//	    }))
+func RegisterTranscriptRenderer(format ExportFormat, renderer TranscriptRenderer) {
+	renderersMux.Lock()
+	defer renderersMux.Unlock()
+	renderers[format] = renderer
+}
+
+// Export writes this session's history to w as a transcript in the given format.
+//
+// Built-in formats are [ExportMarkdown], [ExportHTML], and [ExportJSON]. Transcripts
+// include user and assistant messages, tool calls with their arguments and results,
+// agent switches, and errors. Secrets are removed from every event with
+// ClientOptions.Redactor before rendering, including for custom renderers.
+//
+// Example:
+//
+//	f, _ := os.Create("review.md")
+//	defer f.Close()
+//	if err := session.Export(context.Background(), f, copilot.ExportMarkdown); err != nil {
+//	    log.Printf("Failed to export: %v", err)
+//	}
+func (s *Session) Export(ctx context.Context, w io.Writer, format ExportFormat) error {
+	renderersMux.RLock()
+	renderer, ok := renderers[format]
+	renderersMux.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	events, err := s.GetMessages(ctx)
+	if err != nil {
+		return err
+	}
+	events, err = redactEvents(s.redactor, events)
+	if err != nil {
+		return err
+	}
+	if err := renderer.Render(w, events); err != nil {
+		return fmt.Errorf("failed to render %s transcript: %w", format, err)
+	}
+	return nil
+}
+
+// redactEvents returns a copy of events with secrets removed by redactor.
+// A nil redactor means [redact.Default].
+func redactEvents(redactor *redact.Redactor, events []SessionEvent) ([]SessionEvent, error) {
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact transcript: %w", err)
+	}
+	var redacted []SessionEvent
+	if err := json.Unmarshal(redactor.JSON(data), &redacted); err != nil {
+		return nil, fmt.Errorf("failed to redact transcript: %w", err)
+	}
+	return redacted, nil
+}
+
+// transcriptEntry is a format-neutral transcript item shared by the built-in renderers.
+type transcriptEntry struct {
+	Kind      string // "user", "assistant", "tool", "agent", or "error"
+	Title     string
+	Content   string
+	Arguments string
+	Result    string
+}
+
+// buildTranscript converts session events into transcript entries, pairing
+// tool execution start and completion events.
+func buildTranscript(events []SessionEvent) []transcriptEntry {
+	var entries []transcriptEntry
+	toolEntries := make(map[string]int)
+
+	for _, event := range events {
+		data := event.Data
+		switch event.Type {
+		case UserMessage:
+			entries = append(entries, transcriptEntry{Kind: "user", Title: "User", Content: deref(data.Content)})
+		case AssistantMessage:
+			if content := deref(data.Content); content != "" {
+				entries = append(entries, transcriptEntry{Kind: "assistant", Title: "Assistant", Content: content})
+			}
+		case ToolExecutionStart:
+			entry := transcriptEntry{Kind: "tool", Title: deref(data.ToolName)}
+			if data.Arguments != nil {
+				if args, err := json.MarshalIndent(data.Arguments, "", "  "); err == nil {
+					entry.Arguments = string(args)
+				}
+			}
+			entries = append(entries, entry)
+			if data.ToolCallID != nil {
+				toolEntries[*data.ToolCallID] = len(entries) - 1
+			}
+		case ToolExecutionComplete:
+			if data.ToolCallID == nil || data.Result == nil {
+				continue
+			}
+			if i, ok := toolEntries[*data.ToolCallID]; ok {
+				entries[i].Result = data.Result.Content
+			}
+		case SubagentSelected, AgentChanged:
+			name := deref(data.AgentDisplayName)
+			if name == "" {
+				name = deref(data.AgentName)
+			}
+			entries = append(entries, transcriptEntry{Kind: "agent", Title: name})
+		case SessionError:
+			entries = append(entries, transcriptEntry{Kind: "error", Content: deref(data.Message)})
+		}
+	}
+	return entries
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in content.
+func codeFence(content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+func renderMarkdown(w io.Writer, events []SessionEvent) error {
+	var b strings.Builder
+	b.WriteString("# Copilot Session Transcript\n")
+	for _, entry := range buildTranscript(events) {
+		switch entry.Kind {
+		case "user", "assistant":
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n", entry.Title, entry.Content)
+		case "tool":
+			fmt.Fprintf(&b, "\n### Tool: `%s`\n", entry.Title)
+			if entry.Arguments != "" {
+				fence := codeFence(entry.Arguments)
+				fmt.Fprintf(&b, "\n%sjson\n%s\n%s\n", fence, entry.Arguments, fence)
+			}
+			if entry.Result != "" {
+				fence := codeFence(entry.Result)
+				fmt.Fprintf(&b, "\nResult:\n\n%s\n%s\n%s\n", fence, entry.Result, fence)
+			}
+		case "agent":
+			fmt.Fprintf(&b, "\n> Switched to agent **%s**\n", entry.Title)
+		case "error":
+			fmt.Fprintf(&b, "\n> **Error:** %s\n", entry.Content)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTranscript = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Copilot Session Transcript</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+.entry { margin: 1em 0; }
+.content, pre { white-space: pre-wrap; }
+pre { background: #f6f8fa; padding: 0.5em; }
+.agent, .error { font-style: italic; }
+.error { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Copilot Session Transcript</h1>
+{{- range .}}
+<div class="entry {{.Kind}}">
+{{- if eq .Kind "user" "assistant"}}
+<h2>{{.Title}}</h2>
+<div class="content">{{.Content}}</div>
+{{- else if eq .Kind "tool"}}
+<h3>Tool: <code>{{.Title}}</code></h3>
+{{- if .Arguments}}
+<pre>{{.Arguments}}</pre>
+{{- end}}
+{{- if .Result}}
+<p>Result:</p>
+<pre>{{.Result}}</pre>
+{{- end}}
+{{- else if eq .Kind "agent"}}
+<p>Switched to agent <strong>{{.Title}}</strong></p>
+{{- else if eq .Kind "error"}}
+<p><strong>Error:</strong> {{.Content}}</p>
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`))
+
+func renderHTML(w io.Writer, events []SessionEvent) error {
+	return htmlTranscript.Execute(w, buildTranscript(events))
+}
+
+func renderJSON(w io.Writer, events []SessionEvent) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(events)
+}
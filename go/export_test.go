@@ -0,0 +1,127 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func transcriptEvents() []SessionEvent {
+	str := func(s string) *string { return &s }
+	return []SessionEvent{
+		{Type: UserMessage, Data: Data{Content: str("Fix the <bug>")}},
+		{Type: SubagentSelected, Data: Data{AgentName: str("reviewer"), AgentDisplayName: str("Code Reviewer")}},
+		{Type: ToolExecutionStart, Data: Data{ToolCallID: str("t1"), ToolName: str("view"), Arguments: map[string]any{"path": "main.go"}}},
+		{Type: ToolExecutionComplete, Data: Data{ToolCallID: str("t1"), Result: &Result{Content: "package main\n```\n"}}},
+		{Type: AssistantMessage, Data: Data{Content: str("Here is the fix:\n\n```go\nfmt.Println(1)\n```")}},
+		{Type: SessionError, Data: Data{Message: str("rate limited")}},
+	}
+}
+
+func TestSession_Export(t *testing.T) {
+	t.Run("renders markdown transcripts", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderMarkdown(&buf, transcriptEvents()); err != nil {
+			t.Fatalf("Failed to render markdown: %v", err)
+		}
+		out := buf.String()
+
+		for _, expected := range []string{
+			"## User\n\nFix the <bug>\n",
+			"> Switched to agent **Code Reviewer**",
+			"### Tool: `view`",
+			"\"path\": \"main.go\"",
+			"Result:\n\n````\npackage main\n```\n\n````",
+			"## Assistant\n\nHere is the fix:\n\n```go\nfmt.Println(1)\n```\n",
+			"> **Error:** rate limited",
+		} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("Expected markdown to contain %q, got:\n%s", expected, out)
+			}
+		}
+	})
+
+	t.Run("escapes HTML transcripts", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := renderHTML(&buf, transcriptEvents()); err != nil {
+			t.Fatalf("Failed to render HTML: %v", err)
+		}
+		out := buf.String()
+
+		if !strings.Contains(out, "Fix the &lt;bug&gt;") {
+			t.Errorf("Expected user content to be escaped, got:\n%s", out)
+		}
+		if !strings.Contains(out, "Switched to agent <strong>Code Reviewer</strong>") {
+			t.Errorf("Expected agent switch, got:\n%s", out)
+		}
+	})
+
+	t.Run("exports session history via registered renderers", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": transcriptEvents()})
+		log.call("session.getMessages", map[string]any{"events": transcriptEvents()})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := session.Export(t.Context(), &buf, ExportJSON); err != nil {
+			t.Fatalf("Failed to export JSON: %v", err)
+		}
+		var events []SessionEvent
+		if err := json.Unmarshal(buf.Bytes(), &events); err != nil || len(events) != len(transcriptEvents()) {
+			t.Errorf("Expected %d exported events, got %d (err: %v)", len(transcriptEvents()), len(events), err)
+		}
+
+		RegisterTranscriptRenderer("count", TranscriptRendererFunc(func(w io.Writer, events []SessionEvent) error {
+			_, err := io.WriteString(w, strings.Repeat(".", len(events)))
+			return err
+		}))
+		buf.Reset()
+		if err := session.Export(t.Context(), &buf, "count"); err != nil {
+			t.Fatalf("Failed to export with custom renderer: %v", err)
+		}
+		if buf.String() != "......" {
+			t.Errorf("Expected custom renderer output, got %q", buf.String())
+		}
+
+		if err := session.Export(t.Context(), &buf, "pdf"); err == nil || !strings.Contains(err.Error(), `unknown export format "pdf"`) {
+			t.Errorf("Expected unknown format error, got %v", err)
+		}
+	})
+	t.Run("redacts secrets", func(t *testing.T) {
+		token := "ghp_" + strings.Repeat("a", 36)
+		str := func(s string) *string { return &s }
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": []SessionEvent{
+			{Type: UserMessage, Data: Data{Content: str("Use " + token + " to push")}},
+			{Type: ToolExecutionStart, Data: Data{ToolCallID: str("t1"), ToolName: str("fetch"), Arguments: map[string]any{"apiKey": "hunter2"}}},
+		}})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := session.Export(t.Context(), &buf, ExportMarkdown); err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+		out := buf.String()
+		if strings.Contains(out, token) || strings.Contains(out, "hunter2") {
+			t.Errorf("Expected secrets to be redacted, got:\n%s", out)
+		}
+		if !strings.Contains(out, "Use [REDACTED:github-token] to push") {
+			t.Errorf("Expected the message around the token to be kept, got:\n%s", out)
+		}
+	})
+}
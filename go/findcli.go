@@ -0,0 +1,162 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FindCLI locates a Copilot CLI whose protocol version matches the SDK.
+//
+// It checks, in order, the COPILOT_CLI_PATH environment variable, PATH, the npm
+// global install directory, and common install locations such as
+// ~/.local/bin and /usr/local/bin. Each CLI found is started briefly to read its
+// protocol version; the first compatible one is returned.
+//
+// When no CLI is found, the error matches [ErrCLIUnavailable]. When CLIs are
+// found but none is compatible, the error matches [ErrProtocolMismatch] and
+// lists each CLI with the version it reported.
+//
+// Example:
+//
+//	cliPath, err := copilot.FindCLI(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client := copilot.NewClient(&copilot.ClientOptions{CLIPath: cliPath})
+func FindCLI(ctx context.Context) (string, error) {
+	return findCLI(ctx, cliCandidates(ctx), checkCLIProtocol)
+}
+
+// findCLI returns the first candidate that exists and passes check.
+func findCLI(ctx context.Context, candidates []string, check func(context.Context, string) error) (string, error) {
+	var problems []string
+	mismatch := false
+	for _, path := range candidates {
+		if !isCLIFile(path) {
+			continue
+		}
+		err := check(ctx, path)
+		if err == nil {
+			return path, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if errors.Is(err, ErrProtocolMismatch) {
+			mismatch = true
+		}
+		problems = append(problems, fmt.Sprintf("  %s: %v", path, err))
+	}
+
+	if len(problems) == 0 {
+		return "", newError(ErrorCodeCLIUnavailable, fmt.Errorf(
+			"Copilot CLI not found in COPILOT_CLI_PATH, PATH, or the npm global directory. "+
+				"Install it with \"npm install -g @github/copilot\" or set COPILOT_CLI_PATH to its location"))
+	}
+
+	err := fmt.Errorf("no compatible Copilot CLI found (SDK protocol version %d):\n%s", GetSdkProtocolVersion(), strings.Join(problems, "\n"))
+	if mismatch {
+		return "", newError(ErrorCodeProtocolMismatch, fmt.Errorf(
+			"%w\nInstall a compatible CLI with \"npm install -g @github/copilot@latest\", or upgrade the SDK", err))
+	}
+	return "", newError(ErrorCodeCLIUnavailable, err)
+}
+
+// cliCandidates returns the paths FindCLI checks, most specific first, without duplicates.
+func cliCandidates(ctx context.Context) []string {
+	var names []string
+	if runtime.GOOS == "windows" {
+		names = []string{"copilot.exe", "copilot.cmd"}
+	} else {
+		names = []string{"copilot"}
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if !seen[path] {
+			seen[path] = true
+			candidates = append(candidates, path)
+		}
+	}
+
+	add(os.Getenv("COPILOT_CLI_PATH"))
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			add(path)
+		}
+	}
+
+	var dirs []string
+	if prefix := npmGlobalPrefix(ctx); prefix != "" {
+		if runtime.GOOS == "windows" {
+			dirs = append(dirs, prefix)
+			add(filepath.Join(prefix, "node_modules", "@github", "copilot", "index.js"))
+		} else {
+			dirs = append(dirs, filepath.Join(prefix, "bin"))
+			add(filepath.Join(prefix, "lib", "node_modules", "@github", "copilot", "index.js"))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".local", "bin"), filepath.Join(home, ".npm-global", "bin"))
+	}
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			dirs = append(dirs, filepath.Join(appData, "npm"))
+		}
+	} else {
+		dirs = append(dirs, "/usr/local/bin", "/opt/homebrew/bin", "/usr/bin")
+	}
+	for _, dir := range dirs {
+		for _, name := range names {
+			add(filepath.Join(dir, name))
+		}
+	}
+	return candidates
+}
+
+// npmGlobalPrefix returns the npm global install prefix, or "" if npm is unavailable.
+func npmGlobalPrefix(ctx context.Context) string {
+	output, err := exec.CommandContext(ctx, "npm", "prefix", "-g").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// isCLIFile reports whether path is a file that can be run as the CLI.
+func isCLIFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" || strings.HasSuffix(path, ".js") {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
+
+// checkCLIProtocol starts the CLI at path and verifies its protocol version.
+func checkCLIProtocol(ctx context.Context, path string) error {
+	client := NewClient(&ClientOptions{
+		AutoRestart:     Bool(false),
+		UseLoggedInUser: Bool(false),
+		LogLevel:        "error",
+	})
+	// Set after NewClient so that COPILOT_CLI_PATH does not override the candidate
+	client.options.CLIPath = path
+	defer client.ForceStop()
+	return client.Start(ctx)
+}
@@ -0,0 +1,88 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFakeCLI(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	return path
+}
+
+func TestFindCLI(t *testing.T) {
+	t.Run("returns the first compatible CLI", func(t *testing.T) {
+		old := writeFakeCLI(t, "copilot")
+		current := writeFakeCLI(t, "copilot")
+		missing := filepath.Join(t.TempDir(), "copilot")
+
+		var checked []string
+		path, err := findCLI(t.Context(), []string{missing, old, current}, func(_ context.Context, path string) error {
+			checked = append(checked, path)
+			if path == old {
+				return newError(ErrorCodeProtocolMismatch, errors.New("server reports version 1"))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if path != current {
+			t.Errorf("Expected %s, got %s", current, path)
+		}
+		if len(checked) != 2 {
+			t.Errorf("Expected missing candidates to be skipped, checked %v", checked)
+		}
+	})
+
+	t.Run("reports when no CLI is installed", func(t *testing.T) {
+		_, err := findCLI(t.Context(), []string{filepath.Join(t.TempDir(), "copilot")}, func(context.Context, string) error {
+			t.Error("Expected check not to be called")
+			return nil
+		})
+		if !errors.Is(err, ErrCLIUnavailable) {
+			t.Errorf("Expected ErrCLIUnavailable, got %v", err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "npm install -g @github/copilot") {
+			t.Errorf("Expected install instructions, got %v", err)
+		}
+	})
+
+	t.Run("lists incompatible CLIs", func(t *testing.T) {
+		old := writeFakeCLI(t, "copilot")
+		_, err := findCLI(t.Context(), []string{old}, func(context.Context, string) error {
+			return newError(ErrorCodeProtocolMismatch, errors.New("server reports version 1"))
+		})
+		if !errors.Is(err, ErrProtocolMismatch) {
+			t.Errorf("Expected ErrProtocolMismatch, got %v", err)
+		}
+		if err == nil || !strings.Contains(err.Error(), old+": server reports version 1") {
+			t.Errorf("Expected error to list %s, got %v", old, err)
+		}
+		if !strings.Contains(err.Error(), fmt.Sprintf("SDK protocol version %d", GetSdkProtocolVersion())) {
+			t.Errorf("Expected error to name the SDK protocol version, got %v", err)
+		}
+	})
+
+	t.Run("skips files that are not executable", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "copilot")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if isCLIFile(path) != (os.PathSeparator == '\\') {
+			t.Errorf("Expected non-executable file to be skipped")
+		}
+		if !isCLIFile(writeFakeCLI(t, "index.js")) {
+			t.Errorf("Expected .js entry point to be accepted")
+		}
+	})
+}
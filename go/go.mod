@@ -3,6 +3,15 @@ module github.com/github/copilot-sdk/go
 go 1.24
 
 require (
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/google/jsonschema-go v0.4.2
 	github.com/klauspost/compress v1.18.3
+	golang.org/x/sys v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.10.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
@@ -0,0 +1,9 @@
+package copilot
+
+// AgentChanged is emitted when the session's custom agent changes through a
+// handoff made with session.RPC.Agent.Handoff. Data.AgentName and
+// Data.AgentDisplayName name the receiving agent, Data.Content holds the
+// handoff instructions, and Data.Summary the summary from the handoff note.
+// Unlike [SubagentSelected], the conversation continues with its history, so
+// the receiving agent starts from the context the previous agent built up.
+const AgentChanged SessionEventType = "agent.changed"
@@ -0,0 +1,82 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+func TestAgentHandoff(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.agent.handoff", map[string]any{
+		"agent":         map[string]any{"name": "executor", "displayName": "Executor", "description": "Carries out plans"},
+		"previousAgent": "planner",
+	})
+	log.event("s1", AgentChanged, map[string]any{"agentName": "executor", "agentDisplayName": "Executor", "content": "Implement step 1", "summary": "Plan has three steps"})
+
+	var recorded bytes.Buffer
+	client := newPlaybackClientForTest(t, log, &recorded)
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	changed := make(chan SessionEvent, 1)
+	session.On(func(event SessionEvent) {
+		if event.Type == AgentChanged {
+			changed <- event
+		}
+	})
+
+	summary := "Plan has three steps"
+	result, err := session.RPC.Agent.Handoff(t.Context(), &rpc.SessionAgentHandoffParams{
+		Name:         "executor",
+		Instructions: "Implement step 1",
+		Note:         &rpc.SessionAgentHandoffParamsNote{Summary: &summary, Files: []string{"api/users.go"}},
+	})
+	if err != nil {
+		t.Fatalf("Handoff failed: %v", err)
+	}
+	if result.Agent.Name != "executor" || result.PreviousAgent == nil || *result.PreviousAgent != "planner" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	select {
+	case event := <-changed:
+		if deref(event.Data.Content) != "Implement step 1" || deref(event.Data.Summary) != summary {
+			t.Errorf("Unexpected event data: %+v", event.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the agent.changed event")
+	}
+	if agent := session.CurrentAgent(); agent != "executor" {
+		t.Errorf("Expected the current agent to be executor, got %q", agent)
+	}
+
+	records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	var params map[string]any
+	for _, record := range records {
+		var message struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		json.Unmarshal(record.Message, &message)
+		if message.Method == "session.agent.handoff" {
+			params = message.Params
+		}
+	}
+	note, _ := params["note"].(map[string]any)
+	if params["name"] != "executor" || params["instructions"] != "Implement step 1" || note["summary"] != summary {
+		t.Errorf("Unexpected handoff params: %v", params)
+	}
+	if _, ok := note["decisions"]; ok {
+		t.Errorf("Expected empty note fields to be omitted, got %v", note)
+	}
+}
@@ -0,0 +1,60 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// FeatureHistoryWindow is the CLI feature flag, reported by [Client.Capabilities],
+// for MessageOptions.HistoryWindow.
+const FeatureHistoryWindow = "historyWindow"
+
+// HistoryWindow restricts the conversation history the model sees when
+// answering one message, so a long session can ask a question about part of
+// the conversation without compacting it. The rest of the history is kept and
+// used again by later messages, and the message and its reply are added to the
+// history as usual.
+//
+// Set at most one of LastTurns and MessageIDs. A HistoryWindow with neither
+// set sends the message without any prior history. The system message and
+// attached context are always included.
+type HistoryWindow struct {
+	// LastTurns includes only the last N turns: user messages and everything
+	// that followed each of them, such as replies and tool calls.
+	LastTurns int `json:"lastTurns,omitempty"`
+	// MessageIDs includes only these messages, identified by the IDs of their
+	// user.message and assistant.message events, in conversation order.
+	// Tool calls made in the listed assistant messages are included with them.
+	MessageIDs []string `json:"messageIds,omitempty"`
+}
+
+// validateHistoryWindow checks options.HistoryWindow and that the CLI supports
+// it. An older CLI would silently send the full history instead.
+func (s *Session) validateHistoryWindow(ctx context.Context, options MessageOptions) error {
+	window := options.HistoryWindow
+	if window == nil {
+		return nil
+	}
+	if window.LastTurns < 0 {
+		return fmt.Errorf("invalid HistoryWindow.LastTurns %d: must not be negative", window.LastTurns)
+	}
+	if window.LastTurns > 0 && len(window.MessageIDs) > 0 {
+		return fmt.Errorf("HistoryWindow.LastTurns and MessageIDs are mutually exclusive")
+	}
+	if slices.Contains(window.MessageIDs, "") {
+		return fmt.Errorf("HistoryWindow.MessageIDs must not contain empty IDs")
+	}
+
+	if s.capabilities == nil {
+		return nil
+	}
+	capabilities, err := s.capabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	if !capabilities.HasFeature(FeatureHistoryWindow) {
+		return fmt.Errorf("CLI version %s does not support HistoryWindow", capabilities.Version)
+	}
+	return nil
+}
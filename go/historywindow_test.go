@@ -0,0 +1,89 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSession_HistoryWindow(t *testing.T) {
+	newSessionWithFeatures := func(t *testing.T, features map[string]bool, recorded *bytes.Buffer) *Session {
+		t.Helper()
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"features":        features,
+		})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+
+		client := newPlaybackClientForTest(t, log, recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("sends the window when the CLI supports it", func(t *testing.T) {
+		var recorded bytes.Buffer
+		session := newSessionWithFeatures(t, map[string]bool{FeatureHistoryWindow: true}, &recorded)
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "What did we decide?", HistoryWindow: &HistoryWindow{LastTurns: 3}}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Compare these", HistoryWindow: &HistoryWindow{MessageIDs: []string{"u1", "a1"}}}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+
+		var windows []string
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+				Params struct {
+					HistoryWindow json.RawMessage `json:"historyWindow"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.send" {
+				windows = append(windows, string(message.Params.HistoryWindow))
+			}
+		}
+		expected := []string{`{"lastTurns":3}`, `{"messageIds":["u1","a1"]}`}
+		if !slices.Equal(windows, expected) {
+			t.Errorf("Expected windows %v, got %v", expected, windows)
+		}
+	})
+
+	t.Run("rejects a window the CLI does not support", func(t *testing.T) {
+		session := newSessionWithFeatures(t, map[string]bool{}, nil)
+
+		_, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi", HistoryWindow: &HistoryWindow{}})
+		if err == nil || !strings.Contains(err.Error(), "1.2.3 does not support HistoryWindow") {
+			t.Errorf("Expected unsupported error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid windows", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		tests := []*HistoryWindow{
+			{LastTurns: -1},
+			{LastTurns: 2, MessageIDs: []string{"u1"}},
+			{MessageIDs: []string{"u1", ""}},
+		}
+		for _, window := range tests {
+			if err := session.validateHistoryWindow(t.Context(), MessageOptions{HistoryWindow: window}); err == nil {
+				t.Errorf("Expected error for %+v", window)
+			}
+		}
+		if err := session.validateHistoryWindow(t.Context(), MessageOptions{Prompt: "Hi"}); err != nil {
+			t.Errorf("Expected no error without a window, got %v", err)
+		}
+	})
+}
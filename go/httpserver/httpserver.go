@@ -0,0 +1,503 @@
+// Package httpserver exposes a [copilot.Client] over HTTP, with JSON request
+// and response bodies and Server-Sent Events for streaming, so web frontends
+// can talk to the SDK without a custom backend.
+//
+// Routes:
+//
+//	POST   /sessions                   create a session
+//	GET    /sessions                   list sessions
+//	POST   /sessions/{id}/resume       resume a session
+//	DELETE /sessions/{id}              destroy a session (?purge=true also deletes its data)
+//	POST   /sessions/{id}/messages     send a message (with "wait": true, wait for the reply)
+//	GET    /sessions/{id}/messages     get the session's events
+//	POST   /sessions/{id}/abort        abort the current turn
+//	GET    /sessions/{id}/events       stream events as text/event-stream (?type= filters)
+//
+// Errors are returned as {"error": {"code": ..., "message": ...}} with a
+// matching status code, for example 429 with a Retry-After header for
+// [copilot.ErrRateLimited].
+//
+// Every request must be authorized by Options.Authorize; a handler without it
+// rejects all requests unless Options.AllowUnauthenticated is set.
+//
+// Example:
+//
+//	handler := httpserver.New(client, &httpserver.Options{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    Authorize: func(r *http.Request, sessionID string) error {
+//	        if r.Header.Get("Authorization") != "Bearer "+token {
+//	            return httpserver.ErrUnauthorized
+//	        }
+//	        return nil
+//	    },
+//	})
+//	http.Handle("/copilot/", http.StripPrefix("/copilot", handler))
+//	log.Fatal(http.ListenAndServe(":8080", nil))
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/gateway"
+)
+
+// ErrUnauthorized can be returned from Options.Authorize to respond with
+// 401 Unauthorized. Any other error responds with 403 Forbidden.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Options configures a [Handler].
+type Options struct {
+	// Authorize is called before every request. sessionID is the session the
+	// request targets, or empty for requests that create or list sessions.
+	// Returning an error rejects the request. Without Authorize, every request
+	// is rejected with 401 Unauthorized unless AllowUnauthenticated is set.
+	Authorize func(r *http.Request, sessionID string) error
+	// AllowUnauthenticated serves requests without Authorize, e.g. behind a proxy
+	// that authenticates them. Any client that can reach the handler can then
+	// use every session it serves.
+	AllowUnauthenticated bool
+	// MaxBodyBytes limits the size of request bodies; larger requests are
+	// rejected with 413 Request Entity Too Large. Default: 4 MiB, the default
+	// message size limit of the gRPC gateway.
+	MaxBodyBytes int64
+	// AllowedOrigins lists the origins allowed to make cross-origin requests, or
+	// "*" for any origin. Default: none.
+	AllowedOrigins []string
+	// OnPermissionRequest handles permission requests for every session created
+	// or resumed through the handler. Default: deny all requests.
+	OnPermissionRequest copilot.PermissionHandlerFunc
+	// Tools are registered with every session created or resumed through the handler.
+	Tools []copilot.Tool
+	// ConfigureSession, if set, is called with the config of each session created
+	// or resumed through the handler after it has been filled in from the
+	// request, e.g. to add hooks or custom agents, or to check the
+	// WorkingDirectory the client asked for. For a resume, config.SessionID is
+	// the session being resumed. Returning an error fails the request.
+	ConfigureSession func(r *http.Request, config *copilot.SessionConfig) error
+	// KeepAlive is the interval between comment lines sent on idle event streams,
+	// so proxies do not close them. Default: 15 seconds.
+	KeepAlive time.Duration
+}
+
+// Handler serves a [copilot.Client] over HTTP. Create one with [New].
+type Handler struct {
+	client   *copilot.Client
+	options  Options
+	mux      *http.ServeMux
+	sessions gateway.Sessions
+}
+
+// New returns a handler that serves client's API over HTTP. The caller owns
+// client and is responsible for starting and stopping it. options may be nil.
+func New(client *copilot.Client, options *Options) *Handler {
+	h := &Handler{client: client, mux: http.NewServeMux()}
+	if options != nil {
+		h.options = *options
+	}
+	if h.options.OnPermissionRequest == nil {
+		h.options.OnPermissionRequest = gateway.DenyAll
+	}
+	if h.options.KeepAlive <= 0 {
+		h.options.KeepAlive = 15 * time.Second
+	}
+	if h.options.MaxBodyBytes <= 0 {
+		h.options.MaxBodyBytes = 4 << 20
+	}
+
+	h.mux.HandleFunc("POST /sessions", h.createSession)
+	h.mux.HandleFunc("GET /sessions", h.listSessions)
+	h.mux.HandleFunc("POST /sessions/{id}/resume", h.resumeSession)
+	h.mux.HandleFunc("DELETE /sessions/{id}", h.destroySession)
+	h.mux.HandleFunc("POST /sessions/{id}/messages", h.send)
+	h.mux.HandleFunc("GET /sessions/{id}/messages", h.getMessages)
+	h.mux.HandleFunc("POST /sessions/{id}/abort", h.abort)
+	h.mux.HandleFunc("GET /sessions/{id}/events", h.streamEvents)
+	return h
+}
+
+// ServeHTTP implements [http.Handler].
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && h.allowOrigin(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// sessionResponse is the JSON form of an open session.
+type sessionResponse struct {
+	SessionID     string            `json:"sessionId"`
+	WorkspacePath string            `json:"workspacePath,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// sessionRequest is the body of create and resume requests.
+type sessionRequest struct {
+	SessionID        string                       `json:"sessionId"`
+	Model            string                       `json:"model"`
+	ReasoningEffort  string                       `json:"reasoningEffort"`
+	SystemMessage    *copilot.SystemMessageConfig `json:"systemMessage"`
+	AvailableTools   []string                     `json:"availableTools"`
+	ExcludedTools    []string                     `json:"excludedTools"`
+	WorkingDirectory string                       `json:"workingDirectory"`
+	Streaming        bool                         `json:"streaming"`
+	Metadata         map[string]string            `json:"metadata"`
+}
+
+// sendRequest is the body of a send request.
+type sendRequest struct {
+	Prompt         string               `json:"prompt"`
+	Attachments    []copilot.Attachment `json:"attachments"`
+	Mode           string               `json:"mode"`
+	IdempotencyKey string               `json:"idempotencyKey"`
+	// Wait makes the request wait for the session to become idle and return the
+	// final assistant message.
+	Wait      bool  `json:"wait"`
+	TimeoutMs int64 `json:"timeoutMs"`
+}
+
+func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, "") {
+		return
+	}
+	var req sessionRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	config, ok := h.sessionConfig(w, r, req)
+	if !ok {
+		return
+	}
+	session, err := h.client.CreateSession(r.Context(), config)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.sessions.Track(session)
+	writeJSON(w, http.StatusCreated, sessionJSON(session))
+}
+
+// sessionConfig fills in the config of a session from a create or resume
+// request and runs ConfigureSession on it, writing the error response if the
+// hook rejects the request.
+func (h *Handler) sessionConfig(w http.ResponseWriter, r *http.Request, req sessionRequest) (*copilot.SessionConfig, bool) {
+	config := &copilot.SessionConfig{
+		SessionID:           req.SessionID,
+		Model:               req.Model,
+		ReasoningEffort:     req.ReasoningEffort,
+		SystemMessage:       req.SystemMessage,
+		AvailableTools:      req.AvailableTools,
+		ExcludedTools:       req.ExcludedTools,
+		WorkingDirectory:    req.WorkingDirectory,
+		Streaming:           req.Streaming,
+		Metadata:            req.Metadata,
+		Tools:               h.options.Tools,
+		OnPermissionRequest: h.options.OnPermissionRequest,
+	}
+	if h.options.ConfigureSession != nil {
+		if err := h.options.ConfigureSession(r, config); err != nil {
+			writeError(w, err)
+			return nil, false
+		}
+	}
+	return config, true
+}
+
+func (h *Handler) listSessions(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r, "") {
+		return
+	}
+	query := r.URL.Query()
+	filter := &copilot.SessionListFilter{
+		Cwd:        query.Get("cwd"),
+		GitRoot:    query.Get("gitRoot"),
+		Repository: query.Get("repository"),
+		Branch:     query.Get("branch"),
+	}
+	sessions, err := h.client.ListSessions(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []copilot.SessionMetadata{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+func (h *Handler) resumeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if !h.authorize(w, r, sessionID) {
+		return
+	}
+	var req sessionRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	req.SessionID = sessionID
+	config, ok := h.sessionConfig(w, r, req)
+	if !ok {
+		return
+	}
+	session, err := h.client.ResumeSessionWithOptions(r.Context(), sessionID, gateway.ResumeConfig(config))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.sessions.Track(session)
+	writeJSON(w, http.StatusOK, sessionJSON(session))
+}
+
+func (h *Handler) destroySession(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if !h.authorize(w, r, sessionID) {
+		return
+	}
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	found, err := h.sessions.Destroy(sessionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !found && !purge {
+		writeNotFound(w, sessionID)
+		return
+	}
+	if purge {
+		if err := h.client.DeleteSession(r.Context(), sessionID); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) send(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	var req sendRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	options := copilot.MessageOptions{
+		Prompt:         req.Prompt,
+		Attachments:    req.Attachments,
+		Mode:           req.Mode,
+		IdempotencyKey: req.IdempotencyKey,
+		Timeout:        time.Duration(req.TimeoutMs) * time.Millisecond,
+	}
+	if !req.Wait {
+		messageID, err := session.Send(r.Context(), options)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"messageId": messageID})
+		return
+	}
+	event, err := session.SendAndWait(r.Context(), options)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"message": event})
+}
+
+func (h *Handler) getMessages(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	events, err := session.GetMessages(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if events == nil {
+		events = []copilot.SessionEvent{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+func (h *Handler) abort(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	if err := session.Abort(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// streamEvents sends the session's events as Server-Sent Events until the
+// client disconnects. Each event uses the event type as its SSE event name and
+// the JSON-encoded [copilot.SessionEvent] as its data.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "internal", "streaming is not supported by this server")
+		return
+	}
+	types := r.URL.Query()["type"]
+
+	events := session.Events(r.Context())
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.options.KeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if len(types) > 0 && !slices.Contains(types, string(event.Type)) {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	if h.options.Authorize == nil {
+		if h.options.AllowUnauthenticated {
+			return true
+		}
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", "httpserver: Options.Authorize is not set; set it, or AllowUnauthenticated to serve requests without authorization")
+		return false
+	}
+	err := h.options.Authorize(r, sessionID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrUnauthorized):
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized", err.Error())
+	default:
+		writeJSONError(w, http.StatusForbidden, "forbidden", err.Error())
+	}
+	return false
+}
+
+func (h *Handler) allowOrigin(origin string) bool {
+	return slices.Contains(h.options.AllowedOrigins, "*") || slices.Contains(h.options.AllowedOrigins, origin)
+}
+
+// session authorizes the request and returns the open session it targets,
+// writing an error response if either fails.
+func (h *Handler) session(w http.ResponseWriter, r *http.Request) (*copilot.Session, bool) {
+	sessionID := r.PathValue("id")
+	if !h.authorize(w, r, sessionID) {
+		return nil, false
+	}
+	session, ok := h.sessions.Get(sessionID)
+	if !ok {
+		writeNotFound(w, sessionID)
+	}
+	return session, ok
+}
+
+func sessionJSON(session *copilot.Session) sessionResponse {
+	return sessionResponse{
+		SessionID:     session.SessionID,
+		WorkspacePath: session.WorkspacePath(),
+		Metadata:      session.Metadata(),
+	}
+}
+
+// decode reads a JSON request body of at most Options.MaxBodyBytes into v. An
+// empty body leaves v unchanged.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	body := http.MaxBytesReader(w, r.Body, h.options.MaxBodyBytes)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request_too_large", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+			return false
+		}
+		writeJSONError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]string{"code": code, "message": message}})
+}
+
+func writeNotFound(w http.ResponseWriter, sessionID string) {
+	writeJSONError(w, http.StatusNotFound, "not_found", fmt.Sprintf("session %q is not open on this server; create or resume it first", sessionID))
+}
+
+// writeError maps SDK errors to HTTP status codes so callers can branch on them.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	code := string(copilot.ErrorCodeUnknown)
+	var sdkErr *copilot.SDKError
+	if errors.As(err, &sdkErr) {
+		code = string(sdkErr.Code)
+	}
+	switch gateway.Classify(err) {
+	case gateway.KindCanceled:
+		// The client has gone away; nobody will read the response
+		status, code = 499, "canceled"
+	case gateway.KindTimeout:
+		status = http.StatusGatewayTimeout
+	case gateway.KindResourceExhausted:
+		status = http.StatusTooManyRequests
+	case gateway.KindPermissionDenied:
+		status = http.StatusForbidden
+	case gateway.KindUnavailable:
+		status = http.StatusServiceUnavailable
+	}
+	if (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) && sdkErr != nil && sdkErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(sdkErr.RetryAfter.Seconds()))))
+	}
+	writeJSONError(w, status, code, err.Error())
+}
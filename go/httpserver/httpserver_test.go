@@ -0,0 +1,273 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/playbacktest"
+)
+
+func newServer(t *testing.T, log *playbacktest.Log, options *Options) *httptest.Server {
+	t.Helper()
+	client := playbacktest.NewClient(t, log)
+	server := httptest.NewServer(New(client, options))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func request(t *testing.T, method, url string, body any) *http.Response {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequestWithContext(t.Context(), method, url, reader)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeBody(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("creates a session and waits for the reply", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "4", "messageId": "m1"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		log.Call("session.destroy", map[string]any{})
+		server := newServer(t, log, &Options{AllowUnauthenticated: true})
+
+		resp := request(t, "POST", server.URL+"/sessions", map[string]any{"metadata": map[string]string{"team": "infra"}})
+		var session sessionResponse
+		decodeBody(t, resp, &session)
+		if resp.StatusCode != http.StatusCreated || session.SessionID != "s1" || session.Metadata["team"] != "infra" {
+			t.Fatalf("Unexpected create response %d: %+v", resp.StatusCode, session)
+		}
+
+		resp = request(t, "POST", server.URL+"/sessions/s1/messages", map[string]any{"prompt": "What is 2+2?", "wait": true})
+		var reply struct {
+			Message copilot.SessionEvent `json:"message"`
+		}
+		decodeBody(t, resp, &reply)
+		if resp.StatusCode != http.StatusOK || reply.Message.Data.Content == nil || *reply.Message.Data.Content != "4" {
+			t.Errorf("Unexpected reply %d: %+v", resp.StatusCode, reply.Message)
+		}
+
+		if resp := request(t, "DELETE", server.URL+"/sessions/s1", nil); resp.StatusCode != http.StatusNoContent {
+			t.Errorf("Expected 204 from destroy, got %d", resp.StatusCode)
+		}
+		if resp := request(t, "GET", server.URL+"/sessions/s1/messages", nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected 404 after destroy, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("streams filtered events", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "Hi", "messageId": "m1"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		server := newServer(t, log, &Options{AllowUnauthenticated: true})
+
+		request(t, "POST", server.URL+"/sessions", nil)
+		stream := request(t, "GET", server.URL+"/sessions/s1/events?type=assistant.message", nil)
+		if stream.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("Expected an event stream, got %q", stream.Header.Get("Content-Type"))
+		}
+		if resp := request(t, "POST", server.URL+"/sessions/s1/messages", map[string]any{"prompt": "Hello"}); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected 202 from send, got %d", resp.StatusCode)
+		}
+
+		reader := bufio.NewReader(stream.Body)
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read event stream: %v", err)
+			}
+			if line == "\n" {
+				break
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+		if len(lines) != 3 || lines[1] != "event: assistant.message" || !strings.Contains(lines[2], `"content":"Hi"`) {
+			t.Errorf("Unexpected event: %q", lines)
+		}
+	})
+
+	t.Run("rejects unauthorized requests", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		server := newServer(t, log, &Options{
+			Authorize: func(r *http.Request, sessionID string) error {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					return ErrUnauthorized
+				}
+				if sessionID != "" {
+					return errors.New("not your session")
+				}
+				return nil
+			},
+		})
+
+		resp := request(t, "GET", server.URL+"/sessions", nil)
+		var body struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		decodeBody(t, resp, &body)
+		if resp.StatusCode != http.StatusUnauthorized || body.Error.Code != "unauthorized" {
+			t.Errorf("Expected 401 unauthorized, got %d %q", resp.StatusCode, body.Error.Code)
+		}
+	})
+
+	t.Run("rejects requests without Authorize", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		server := newServer(t, log, nil)
+
+		if resp := request(t, "GET", server.URL+"/sessions", nil); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without Authorize or AllowUnauthenticated, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rejects oversized bodies", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		server := newServer(t, log, &Options{AllowUnauthenticated: true, MaxBodyBytes: 64})
+
+		resp := request(t, "POST", server.URL+"/sessions", map[string]any{"model": strings.Repeat("x", 100)})
+		var body struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		decodeBody(t, resp, &body)
+		if resp.StatusCode != http.StatusRequestEntityTooLarge || body.Error.Code != "request_too_large" {
+			t.Errorf("Expected 413 request_too_large, got %d %q", resp.StatusCode, body.Error.Code)
+		}
+	})
+
+	t.Run("keeps serving a session that failed to be destroyed", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.CallError("session.destroy", -32603, "internal error")
+		log.Call("session.destroy", map[string]any{})
+		server := newServer(t, log, &Options{AllowUnauthenticated: true})
+
+		request(t, "POST", server.URL+"/sessions", nil)
+		if resp := request(t, "DELETE", server.URL+"/sessions/s1", nil); resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("Expected the first destroy to fail, got %d", resp.StatusCode)
+		}
+		if resp := request(t, "DELETE", server.URL+"/sessions/s1", nil); resp.StatusCode != http.StatusNoContent {
+			t.Errorf("Expected the session to still be tracked after a failed destroy, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("runs ConfigureSession on resume", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.resume", map[string]any{"sessionId": "s1"})
+		var configured []string
+		server := newServer(t, log, &Options{
+			AllowUnauthenticated: true,
+			ConfigureSession: func(r *http.Request, config *copilot.SessionConfig) error {
+				configured = append(configured, config.SessionID)
+				if !strings.HasPrefix(config.WorkingDirectory, "/srv/") {
+					return errors.New("working directory not allowed")
+				}
+				return nil
+			},
+		})
+
+		if resp := request(t, "POST", server.URL+"/sessions/s1/resume", map[string]any{"workingDirectory": "/etc"}); resp.StatusCode == http.StatusOK {
+			t.Error("Expected ConfigureSession to reject the working directory")
+		}
+		if resp := request(t, "POST", server.URL+"/sessions/s1/resume", map[string]any{"workingDirectory": "/srv/app"}); resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected the resume to succeed, got %d", resp.StatusCode)
+		}
+		if len(configured) != 2 || configured[0] != "s1" {
+			t.Errorf("Expected ConfigureSession to see both resumes of s1, got %v", configured)
+		}
+	})
+
+	t.Run("answers CORS preflight for allowed origins", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		server := newServer(t, log, &Options{AllowedOrigins: []string{"https://app.example.com"}})
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodOptions, server.URL+"/sessions", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Preflight failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+			t.Errorf("Unexpected preflight response %d: %v", resp.StatusCode, resp.Header)
+		}
+
+		req.Header.Set("Origin", "https://evil.example.com")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Preflight failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("Access-Control-Allow-Origin") != "" {
+			t.Error("Expected no CORS headers for a disallowed origin")
+		}
+	})
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{&copilot.SDKError{Code: copilot.ErrorCodeTimeout}, http.StatusGatewayTimeout},
+		{&copilot.SDKError{Code: copilot.ErrorCodeRateLimited, RetryAfter: 1500 * time.Millisecond}, http.StatusTooManyRequests},
+		{&copilot.SDKError{Code: copilot.ErrorCodePermissionDenied}, http.StatusForbidden},
+		{&copilot.SDKError{Code: copilot.ErrorCodeCLIUnavailable}, http.StatusServiceUnavailable},
+		{&copilot.SDKError{Code: copilot.ErrorCodeCircuitOpen, RetryAfter: 10 * time.Second}, http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		writeError(w, test.err)
+		if w.Code != test.status {
+			t.Errorf("writeError(%v): expected %d, got %d", test.err, test.status, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	writeError(w, &copilot.SDKError{Code: copilot.ErrorCodeRateLimited, RetryAfter: 1500 * time.Millisecond})
+	if w.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected Retry-After rounded up to 2, got %q", w.Header().Get("Retry-After"))
+	}
+}
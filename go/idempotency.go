@@ -0,0 +1,150 @@
+package copilot
+
+import (
+	"context"
+	"sync"
+)
+
+// maxIdempotencyKeys bounds how many successful sends a session remembers for
+// deduplication. Older keys are forgotten first.
+const maxIdempotencyKeys = 1000
+
+// sentMessages tracks the outcome of sends made with MessageOptions.IdempotencyKey.
+type sentMessages struct {
+	mu      sync.Mutex
+	entries map[string]*sentMessage
+	order   []string // successful keys, oldest first
+}
+
+type sentMessage struct {
+	done      chan struct{} // closed when the send completes
+	messageID string
+	err       error
+	response  *SessionEvent // final assistant message, when sent with SendAndWait
+}
+
+// begin returns the entry for key, creating it if this is the first send with
+// the key. The caller owns a new entry and must complete it with finish.
+func (m *sentMessages) begin(key string) (entry *sentMessage, owner bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[key]; ok {
+		return entry, false
+	}
+	if m.entries == nil {
+		m.entries = make(map[string]*sentMessage)
+	}
+	entry = &sentMessage{done: make(chan struct{})}
+	m.entries[key] = entry
+	return entry, true
+}
+
+// finish records the outcome of the send for key. Failed sends are forgotten so
+// that a retry with the same key sends again.
+func (m *sentMessages) finish(key string, entry *sentMessage, messageID string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.messageID = messageID
+	entry.err = err
+	close(entry.done)
+
+	if err != nil {
+		delete(m.entries, key)
+		return
+	}
+	m.order = append(m.order, key)
+	if len(m.order) > maxIdempotencyKeys {
+		delete(m.entries, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+// resent records messageID as the outcome of the send for key after the
+// message was resent on a fallback model, unless another send with the key is
+// in progress. A finished entry is replaced rather than updated, since the
+// sends that waited for it read its fields without holding m.mu.
+func (m *sentMessages) resent(key, messageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.entries[key]
+	if ok {
+		select {
+		case <-previous.done:
+		default:
+			return
+		}
+	}
+	entry := &sentMessage{done: make(chan struct{}), messageID: messageID}
+	close(entry.done)
+	if m.entries == nil {
+		m.entries = make(map[string]*sentMessage)
+	}
+	m.entries[key] = entry
+	if ok {
+		// A finished entry in the map succeeded, so its key is already in order
+		entry.response = previous.response
+		return
+	}
+	m.order = append(m.order, key)
+	if len(m.order) > maxIdempotencyKeys {
+		delete(m.entries, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+func (m *sentMessages) setResponse(key string, response *SessionEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[key]; ok {
+		entry.response = response
+	}
+}
+
+func (m *sentMessages) response(key string) *SessionEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.entries[key]; ok {
+		return entry.response
+	}
+	return nil
+}
+
+// send screens and sends a message, deduplicating by options.IdempotencyKey.
+// duplicate reports that an earlier send with the same key succeeded and
+// nothing new was sent; otherwise req is the request prepared for the message,
+// if any.
+func (s *Session) send(ctx context.Context, options MessageOptions) (messageID string, duplicate bool, req *sessionSendRequest, err error) {
+	if err := s.screenMessage(&options); err != nil {
+		return "", false, nil, err
+	}
+	return s.sendScreened(ctx, options)
+}
+
+// sendScreened is send for a message that has passed screenMessage.
+func (s *Session) sendScreened(ctx context.Context, options MessageOptions) (messageID string, duplicate bool, req *sessionSendRequest, err error) {
+	key := options.IdempotencyKey
+	if key == "" {
+		messageID, req, err = s.sendMessage(ctx, options)
+		return messageID, false, req, err
+	}
+
+	for {
+		entry, owner := s.sent.begin(key)
+		if owner {
+			messageID, req, err = s.sendMessage(ctx, options)
+			s.sent.finish(key, entry, messageID, err)
+			return messageID, false, req, err
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return "", false, nil, ctx.Err()
+		}
+		if entry.err == nil {
+			s.logger.Debug("skipped duplicate send", "idempotencyKey", key, "messageId", entry.messageID)
+			return entry.messageID, true, nil, nil
+		}
+		// The earlier send failed, so this one is a genuine retry
+	}
+}
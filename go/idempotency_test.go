@@ -0,0 +1,113 @@
+package copilot
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSession_IdempotencyKey(t *testing.T) {
+	create := func(t *testing.T, log *replayLog) *Session {
+		t.Helper()
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("does not resend a message with a used key", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+
+		session := create(t, log)
+		for i := 0; i < 2; i++ {
+			id, err := session.Send(t.Context(), MessageOptions{Prompt: "Deploy", IdempotencyKey: "deploy-42"})
+			if err != nil || id != "m1" {
+				t.Fatalf("Send %d: expected original message ID m1, got %q (err=%v)", i+1, id, err)
+			}
+		}
+		if id, _ := session.Send(t.Context(), MessageOptions{Prompt: "Deploy", IdempotencyKey: "deploy-43"}); id != "m2" {
+			t.Errorf("Expected a new key to send, got %q", id)
+		}
+	})
+
+	t.Run("retries a key whose send failed", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "session.send", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "transient"}})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+
+		session := create(t, log)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Deploy", IdempotencyKey: "deploy-42"}); err == nil {
+			t.Fatal("Expected first send to fail")
+		}
+		messageID, err := session.Send(t.Context(), MessageOptions{Prompt: "Deploy", IdempotencyKey: "deploy-42"})
+		if err != nil || messageID != "m2" {
+			t.Errorf("Expected retry to send, got %q (err=%v)", messageID, err)
+		}
+	})
+
+	t.Run("SendAndWait returns the original response", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Deployed", "messageId": "m1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		session := create(t, log)
+		for i := 0; i < 2; i++ {
+			response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Deploy", IdempotencyKey: "deploy-42"})
+			if err != nil || response == nil || *response.Data.Content != "Deployed" {
+				t.Fatalf("SendAndWait %d: expected original response, got %+v (err=%v)", i+1, response, err)
+			}
+		}
+	})
+}
+
+func TestSentMessages_Eviction(t *testing.T) {
+	var sent sentMessages
+	for i := 0; i <= maxIdempotencyKeys; i++ {
+		key := strconv.Itoa(i)
+		entry, _ := sent.begin(key)
+		sent.finish(key, entry, "m"+key, nil)
+	}
+	if _, owner := sent.begin("0"); !owner {
+		t.Error("Expected the oldest key to be forgotten")
+	}
+	if _, owner := sent.begin(strconv.Itoa(maxIdempotencyKeys)); owner {
+		t.Error("Expected the newest key to be remembered")
+	}
+}
+
+func TestSentMessages_Resent(t *testing.T) {
+	var sent sentMessages
+	entry, _ := sent.begin("k")
+	sent.finish("k", entry, "m1", nil)
+	sent.setResponse("k", &SessionEvent{ID: "a1"})
+	sent.resent("k", "m2")
+	if entry.messageID != "m1" {
+		t.Errorf("Expected the finished entry to be left alone, got %q", entry.messageID)
+	}
+	replacement, owner := sent.begin("k")
+	if owner || replacement.messageID != "m2" || replacement.response == nil || replacement.response.ID != "a1" {
+		t.Errorf("Expected a finished entry for the resent message, got %+v", replacement)
+	}
+	if len(sent.order) != 1 {
+		t.Errorf("Expected the key to be ordered once, got %v", sent.order)
+	}
+
+	pending, _ := sent.begin("p")
+	sent.resent("p", "m3")
+	if current, _ := sent.begin("p"); current != pending {
+		t.Error("Expected a send in progress to keep its entry")
+	}
+}
@@ -0,0 +1,86 @@
+package copilot
+
+import (
+	"sync"
+	"time"
+)
+
+// idleExpiry destroys a session after a period without activity.
+// See ClientOptions.SessionIdleTimeout.
+type idleExpiry struct {
+	timeout time.Duration
+	expire  func()
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+func newIdleExpiry(timeout time.Duration, expire func()) *idleExpiry {
+	e := &idleExpiry{timeout: timeout, expire: expire}
+	e.touch(false)
+	return e
+}
+
+// touch records activity on the session. While busy the session never expires;
+// otherwise the timeout starts again from now.
+func (e *idleExpiry) touch(busy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopped {
+		return
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if busy {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(e.timeout, func() {
+		e.mu.Lock()
+		if e.stopped || e.timer != timer {
+			e.mu.Unlock()
+			return
+		}
+		e.stopped = true
+		e.timer = nil
+		e.mu.Unlock()
+		e.expire()
+	})
+	e.timer = timer
+}
+
+// stop cancels expiry, e.g. because the session was destroyed.
+func (e *idleExpiry) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stopped = true
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
+
+// expireSession destroys a session that has been idle for
+// ClientOptions.SessionIdleTimeout and emits a SessionLifecycleExpired event.
+func (c *Client) expireSession(session *Session) {
+	session.logger.Info("session expired", "idleTimeout", c.options.SessionIdleTimeout)
+
+	// The CLI may already be gone; the session is dropped either way
+	_ = session.Destroy()
+
+	c.sessionsMux.Lock()
+	if c.sessions[session.SessionID] == session {
+		delete(c.sessions, session.SessionID)
+	}
+	c.sessionsMux.Unlock()
+
+	c.handleLifecycleEvent(SessionLifecycleEvent{
+		Type:            SessionLifecycleExpired,
+		SessionID:       session.SessionID,
+		SessionMetadata: session.Metadata(),
+	})
+}
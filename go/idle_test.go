@@ -0,0 +1,117 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestClient_SessionIdleTimeout(t *testing.T) {
+	newExpiringClient := func(t *testing.T, log *replayLog, recorded *bytes.Buffer) (*Client, chan SessionLifecycleEvent) {
+		client := newPlaybackClientForTest(t, log, recorded)
+		client.options.SessionIdleTimeout = 50 * time.Millisecond
+		expired := make(chan SessionLifecycleEvent, 1)
+		client.OnEventType(SessionLifecycleExpired, func(event SessionLifecycleEvent) {
+			expired <- event
+		})
+		return client, expired
+	}
+
+	t.Run("destroys idle sessions and emits an expired event", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.destroy", map[string]any{})
+
+		var recorded bytes.Buffer
+		client, expired := newExpiringClient(t, log, &recorded)
+		_, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Metadata:            map[string]string{"tenant": "acme"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		select {
+		case event := <-expired:
+			if event.SessionID != "s1" || event.SessionMetadata["tenant"] != "acme" {
+				t.Errorf("Unexpected expired event: %+v", event)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for session to expire")
+		}
+
+		client.sessionsMux.Lock()
+		_, tracked := client.sessions["s1"]
+		client.sessionsMux.Unlock()
+		if tracked {
+			t.Error("Expected expired session to be removed from the client")
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		destroyed := false
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+			}
+			json.Unmarshal(record.Message, &message)
+			destroyed = destroyed || message.Method == "session.destroy"
+		}
+		if !destroyed {
+			t.Error("Expected session.destroy to be sent")
+		}
+	})
+
+	t.Run("does not expire busy sessions", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		client, expired := newExpiringClient(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+
+		select {
+		case event := <-expired:
+			t.Fatalf("Expected busy session not to expire, got %+v", event)
+		case <-time.After(150 * time.Millisecond):
+		}
+
+		session.dispatchEvent(SessionEvent{Type: SessionIdle})
+		select {
+		case <-expired:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for session to expire after becoming idle")
+		}
+	})
+
+	t.Run("destroyed sessions do not expire", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.destroy", map[string]any{})
+
+		client, expired := newExpiringClient(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if err := session.Destroy(); err != nil {
+			t.Fatalf("Failed to destroy session: %v", err)
+		}
+
+		select {
+		case event := <-expired:
+			t.Fatalf("Expected no expired event, got %+v", event)
+		case <-time.After(150 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package copilot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxImageDimension is the longest side, in pixels, of attached images.
+	// Larger images are scaled down, since models downsample them anyway.
+	maxImageDimension = 2048
+	// maxImageBytes is the largest image file the CLI accepts.
+	maxImageBytes = 3_750_000
+)
+
+// ImageAttachment returns an attachment for an image, given either the path of
+// an image file or an [image.Image] such as a screenshot.
+//
+// PNG, JPEG, GIF, and WebP files that are small enough are attached as they
+// are. Other images are scaled down to at most 2048 pixels on their longest side
+// and encoded as PNG, or as JPEG when the source was a JPEG or the PNG would
+// exceed the CLI's size limit, into a new file in [os.TempDir]. When the
+// returned attachment's Path differs from path, the caller may remove that file
+// once the message has been handled. Use MessageOptions.Images to have a
+// session manage the files of in-memory images instead.
+//
+// Example:
+//
+//	attachment, err := copilot.ImageAttachment("screenshot.png")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	session.SendAndWait(ctx, copilot.MessageOptions{
+//	    Prompt:      "What's wrong with this screenshot?",
+//	    Attachments: []copilot.Attachment{attachment},
+//	})
+func ImageAttachment(source any) (Attachment, error) {
+	switch src := source.(type) {
+	case string:
+		return imageFileAttachment(src)
+	case image.Image:
+		path, err := writeImage(src, "png")
+		if err != nil {
+			return Attachment{}, err
+		}
+		return Attachment{Type: File, Path: &path, DisplayName: "image" + filepath.Ext(path)}, nil
+	default:
+		return Attachment{}, fmt.Errorf("unsupported image source %T: use a file path or an image.Image", source)
+	}
+}
+
+// imageFileAttachment attaches an image file, converting it if needed.
+func imageFileAttachment(path string) (Attachment, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	name := filepath.Base(abs)
+	attach := func(p string) Attachment {
+		return Attachment{Type: File, Path: &p, DisplayName: name}
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	defer f.Close()
+	config, format, err := image.DecodeConfig(f)
+	if err != nil {
+		// The standard library cannot decode WebP, which the CLI accepts as is
+		if strings.EqualFold(filepath.Ext(abs), ".webp") && info.Size() <= maxImageBytes {
+			return attach(abs), nil
+		}
+		return Attachment{}, fmt.Errorf("unsupported image %s: %w", name, err)
+	}
+	if info.Size() <= maxImageBytes && max(config.Width, config.Height) <= maxImageDimension {
+		return attach(abs), nil
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return Attachment{}, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to decode image %s: %w", name, err)
+	}
+	converted, err := writeImage(img, format)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return attach(converted), nil
+}
+
+// writeImage scales img to the size limits and writes it to a temporary file
+// in the format of the source ("jpeg" or otherwise PNG), returning its path.
+func writeImage(img image.Image, format string) (string, error) {
+	img = scaleImage(img, maxImageDimension)
+	for {
+		var buf bytes.Buffer
+		ext := ".png"
+		var err error
+		if format == "jpeg" {
+			ext = ".jpg"
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, img)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to encode image: %w", err)
+		}
+
+		if buf.Len() > maxImageBytes {
+			size := img.Bounds().Size()
+			switch {
+			case format != "jpeg":
+				format = "jpeg"
+			case max(size.X, size.Y) > 256:
+				img = scaleImage(img, max(size.X, size.Y)/2)
+			default:
+				return "", fmt.Errorf("image is too large to attach")
+			}
+			continue
+		}
+
+		f, err := os.CreateTemp("", "copilot-image-*"+ext)
+		if err != nil {
+			return "", fmt.Errorf("failed to write image: %w", err)
+		}
+		_, err = f.Write(buf.Bytes())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return "", fmt.Errorf("failed to write image: %w", err)
+		}
+		return f.Name(), nil
+	}
+}
+
+// scaleImage returns img scaled down, keeping its aspect ratio, so that its
+// longest side is at most limit pixels. Each output pixel averages the source
+// pixels it covers.
+func scaleImage(img image.Image, limit int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if max(w, h) <= limit {
+		return img
+	}
+	dw, dh := limit, max(h*limit/w, 1)
+	if h > w {
+		dw, dh = max(w*limit/h, 1), limit
+	}
+
+	src := image.NewNRGBA64(bounds)
+	draw.Draw(src, bounds, img, bounds.Min, draw.Src)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := bounds.Min.Y+y*h/dh, bounds.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := range dw {
+			x0, x1 := bounds.Min.X+x*w/dw, bounds.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.NRGBA64At(sx, sy)
+					r, g, b, a, n = r+uint64(c.R), g+uint64(c.G), b+uint64(c.B), a+uint64(c.A), n+1
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: uint8(a / n >> 8)})
+		}
+	}
+	return dst
+}
+
+// imageFiles holds the temporary files a session wrote for MessageOptions.Images.
+type imageFiles struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// attach writes images to temporary files and returns attachments for them.
+func (f *imageFiles) attach(images []image.Image) ([]Attachment, error) {
+	attachments := make([]Attachment, 0, len(images))
+	for i, img := range images {
+		attachment, err := ImageAttachment(img)
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+		attachment.DisplayName = fmt.Sprintf("image-%d%s", i+1, filepath.Ext(*attachment.Path))
+		f.mu.Lock()
+		f.paths = append(f.paths, *attachment.Path)
+		f.mu.Unlock()
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// removeAll deletes the files written so far.
+func (f *imageFiles) removeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, path := range f.paths {
+		os.Remove(path)
+	}
+	f.paths = nil
+}
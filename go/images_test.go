@@ -0,0 +1,176 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func solidImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	return img
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decodedSize(t *testing.T, path string) image.Point {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return image.Pt(config.Width, config.Height)
+}
+
+func TestImageAttachment(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("attaches small images as they are", func(t *testing.T) {
+		path := filepath.Join(dir, "small.png")
+		writePNG(t, path, solidImage(40, 30))
+		attachment, err := ImageAttachment(path)
+		if err != nil {
+			t.Fatalf("Failed to attach image: %v", err)
+		}
+		if attachment.Type != File || *attachment.Path != path || attachment.DisplayName != "small.png" {
+			t.Errorf("Unexpected attachment: %+v", attachment)
+		}
+	})
+
+	t.Run("scales down large images", func(t *testing.T) {
+		path := filepath.Join(dir, "wide.png")
+		writePNG(t, path, solidImage(4096, 1024))
+		attachment, err := ImageAttachment(path)
+		if err != nil {
+			t.Fatalf("Failed to attach image: %v", err)
+		}
+		defer os.Remove(*attachment.Path)
+		if *attachment.Path == path || attachment.DisplayName != "wide.png" {
+			t.Errorf("Expected a converted copy, got %+v", attachment)
+		}
+		if size := decodedSize(t, *attachment.Path); size != image.Pt(2048, 512) {
+			t.Errorf("Expected 2048x512, got %v", size)
+		}
+	})
+
+	t.Run("encodes in-memory images", func(t *testing.T) {
+		attachment, err := ImageAttachment(solidImage(10, 3000))
+		if err != nil {
+			t.Fatalf("Failed to attach image: %v", err)
+		}
+		defer os.Remove(*attachment.Path)
+		if size := decodedSize(t, *attachment.Path); size != image.Pt(6, 2048) {
+			t.Errorf("Expected 6x2048, got %v", size)
+		}
+	})
+
+	t.Run("rejects unsupported sources", func(t *testing.T) {
+		notImage := filepath.Join(dir, "notes.txt")
+		os.WriteFile(notImage, []byte("hello"), 0644)
+		if _, err := ImageAttachment(notImage); err == nil {
+			t.Error("Expected an error for a non-image file")
+		}
+		if _, err := ImageAttachment(42); err == nil {
+			t.Error("Expected an error for an unsupported source type")
+		}
+	})
+}
+
+func TestScaleImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := range 4 {
+		for y := range 2 {
+			if x < 2 {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			}
+		}
+	}
+	scaled := scaleImage(img, 2).(*image.NRGBA)
+	if scaled.Bounds().Size() != image.Pt(2, 1) {
+		t.Fatalf("Expected 2x1, got %v", scaled.Bounds().Size())
+	}
+	if scaled.NRGBAAt(0, 0).R != 0 || scaled.NRGBAAt(1, 0).R != 255 {
+		t.Errorf("Expected averaged halves, got %v %v", scaled.NRGBAAt(0, 0), scaled.NRGBAAt(1, 0))
+	}
+}
+
+func TestSession_SendImages(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.send", map[string]any{"messageId": "m1"})
+	log.call("session.destroy", map[string]any{})
+	var recorded bytes.Buffer
+	client := newPlaybackClientForTest(t, log, &recorded)
+
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	notes := "/repo/notes.md"
+	_, err = session.Send(t.Context(), MessageOptions{
+		Prompt:      "What's wrong with this screenshot?",
+		Attachments: []Attachment{{Type: File, Path: &notes, DisplayName: "notes.md"}},
+		Images:      []image.Image{solidImage(20, 20)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	var sent struct {
+		Params struct {
+			Attachments []Attachment `json:"attachments"`
+		} `json:"params"`
+	}
+	records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+	for _, record := range records {
+		var frame struct {
+			Method string `json:"method"`
+		}
+		json.Unmarshal(record.Message, &frame)
+		if frame.Method == "session.send" {
+			json.Unmarshal(record.Message, &sent)
+		}
+	}
+	attachments := sent.Params.Attachments
+	if len(attachments) != 2 || attachments[1].DisplayName != "image-1.png" || attachments[1].Path == nil {
+		t.Fatalf("Expected the image after the file attachment, got %+v", attachments)
+	}
+	imagePath := *attachments[1].Path
+	if _, err := os.Stat(imagePath); err != nil {
+		t.Errorf("Expected the image file to exist until the session is destroyed: %v", err)
+	}
+
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("Failed to destroy session: %v", err)
+	}
+	if _, err := os.Stat(imagePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the image file to be removed, got %v", err)
+	}
+}
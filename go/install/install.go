@@ -0,0 +1,259 @@
+// Package install downloads Copilot CLI releases so that applications do not
+// need the CLI to be installed separately.
+//
+// Example:
+//
+//	cliPath, err := install.EnsureCLI(ctx, "0.0.400")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client := copilot.NewClient(&copilot.ClientOptions{CLIPath: cliPath})
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/internal/flock"
+)
+
+// DefaultRegistryURL is the npm registry CLI releases are downloaded from.
+const DefaultRegistryURL = "https://registry.npmjs.org"
+
+// npm package suffix for each supported GOOS/GOARCH
+var npmPlatforms = map[string]string{
+	"linux/amd64":   "linux-x64",
+	"linux/arm64":   "linux-arm64",
+	"darwin/amd64":  "darwin-x64",
+	"darwin/arm64":  "darwin-arm64",
+	"windows/amd64": "win32-x64",
+	"windows/arm64": "win32-arm64",
+}
+
+// Installer downloads and caches CLI releases. The zero value is ready to use.
+type Installer struct {
+	// Dir is the cache directory. Each version is installed in its own
+	// subdirectory. Default: "copilot-sdk/cli" in the user cache directory.
+	Dir string
+	// RegistryURL is the npm registry to download from. Default: [DefaultRegistryURL].
+	RegistryURL string
+	// HTTPClient is used for downloads. Default: [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// EnsureCLI returns the path to the given CLI version for the current platform,
+// downloading it first if it is not already cached. See [Installer.EnsureCLI].
+func EnsureCLI(ctx context.Context, version string) (string, error) {
+	return (&Installer{}).EnsureCLI(ctx, version)
+}
+
+// EnsureCLI returns the path to the given CLI version for the current platform,
+// downloading it first if it is not already cached.
+//
+// The release is downloaded from the @github/copilot-<platform> npm package and
+// verified against the registry's SHA-512 integrity checksum before the binary is
+// extracted. Concurrent calls, including from other processes, install the
+// version only once.
+func (i *Installer) EnsureCLI(ctx context.Context, version string) (string, error) {
+	if version == "" {
+		return "", errors.New("CLI version is required")
+	}
+	if strings.ContainsAny(version, `/\`) || strings.Contains(version, "..") {
+		return "", fmt.Errorf("invalid CLI version %q", version)
+	}
+	platform, ok := npmPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		return "", fmt.Errorf("no CLI release for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	binaryName := "copilot"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	dir, err := i.versionDir(version)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating install directory: %w", err)
+	}
+
+	// Best effort to prevent concurrent installs.
+	if release, _ := flock.Acquire(filepath.Join(dir, ".lock")); release != nil {
+		defer release()
+	}
+
+	path := filepath.Join(dir, binaryName)
+	// The binary is renamed into place only after verification, so its presence means it is complete
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	dist, err := i.fetchDist(ctx, platform, version)
+	if err != nil {
+		return "", err
+	}
+	if err := i.download(ctx, dist, binaryName, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// versionDir returns the cache directory for version.
+func (i *Installer) versionDir(version string) (string, error) {
+	dir := i.Dir
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("locating cache directory: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "copilot-sdk", "cli")
+	}
+	return filepath.Join(dir, version), nil
+}
+
+// packageDist is the "dist" member of npm package version metadata.
+type packageDist struct {
+	Tarball   string `json:"tarball"`
+	Integrity string `json:"integrity"`
+}
+
+// fetchDist reads the tarball URL and checksum of a package version from the registry.
+func (i *Installer) fetchDist(ctx context.Context, platform, version string) (*packageDist, error) {
+	registry := i.RegistryURL
+	if registry == "" {
+		registry = DefaultRegistryURL
+	}
+	url := fmt.Sprintf("%s/@github/copilot-%s/%s", strings.TrimSuffix(registry, "/"), platform, version)
+
+	resp, err := i.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching CLI %s metadata: %w", version, err)
+	}
+	defer resp.Body.Close()
+
+	var metadata struct {
+		Dist packageDist `json:"dist"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("parsing CLI %s metadata: %w", version, err)
+	}
+	if metadata.Dist.Tarball == "" || !strings.HasPrefix(metadata.Dist.Integrity, "sha512-") {
+		return nil, fmt.Errorf("CLI %s metadata has no tarball or SHA-512 checksum", version)
+	}
+	return &metadata.Dist, nil
+}
+
+// download fetches the tarball, verifies its checksum, and extracts binaryName to path.
+func (i *Installer) download(ctx context.Context, dist *packageDist, binaryName, path string) error {
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dist.Integrity, "sha512-"))
+	if err != nil {
+		return fmt.Errorf("decoding checksum: %w", err)
+	}
+
+	resp, err := i.get(ctx, dist.Tarball)
+	if err != nil {
+		return fmt.Errorf("downloading CLI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	tarball, err := os.CreateTemp(filepath.Dir(path), "download-*.tgz")
+	if err != nil {
+		return fmt.Errorf("creating download file: %w", err)
+	}
+	defer os.Remove(tarball.Name())
+	defer tarball.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(io.MultiWriter(tarball, h), resp.Body); err != nil {
+		return fmt.Errorf("downloading CLI: %w", err)
+	}
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected sha512-%s, got sha512-%s",
+			dist.Tarball, base64.StdEncoding.EncodeToString(expected), base64.StdEncoding.EncodeToString(actual))
+	}
+
+	if _, err := tarball.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := extractFile(tarball, "package/"+binaryName, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("installing CLI: %w", err)
+	}
+	return nil
+}
+
+// get issues a GET request and fails on non-200 responses.
+func (i *Installer) get(ctx context.Context, url string) (*http.Response, error) {
+	client := i.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// extractFile writes the tarball entry named name to dest as an executable.
+func extractFile(r io.Reader, name, dest string) error {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading tarball: %w", err)
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in tarball", name)
+		}
+		if err != nil {
+			return fmt.Errorf("reading tarball: %w", err)
+		}
+		if header.Name != name {
+			continue
+		}
+
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return fmt.Errorf("creating binary file: %w", err)
+		}
+		_, err = io.Copy(f, tarReader)
+		if err1 := f.Close(); err1 != nil && err == nil {
+			err = err1
+		}
+		if err != nil {
+			return fmt.Errorf("writing binary file: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeRegistry serves a single CLI release for the current platform.
+type fakeRegistry struct {
+	*httptest.Server
+	tarball   []byte
+	integrity string
+	downloads atomic.Int32
+}
+
+func newFakeRegistry(t *testing.T, binary string) *fakeRegistry {
+	t.Helper()
+	binaryName := "copilot"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "package/" + binaryName, Mode: 0755, Size: int64(len(binary))})
+	tw.Write([]byte(binary))
+	tw.Close()
+	gz.Close()
+
+	sum := sha512.Sum512(buf.Bytes())
+	r := &fakeRegistry{tarball: buf.Bytes(), integrity: "sha512-" + base64.StdEncoding.EncodeToString(sum[:])}
+	platform := npmPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/@github/copilot-" + platform + "/1.2.3":
+			json.NewEncoder(w).Encode(map[string]any{"dist": map[string]any{
+				"tarball":   r.URL + "/copilot.tgz",
+				"integrity": r.integrity,
+			}})
+		case "/copilot.tgz":
+			r.downloads.Add(1)
+			w.Write(r.tarball)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestEnsureCLI(t *testing.T) {
+	if _, ok := npmPlatforms[runtime.GOOS+"/"+runtime.GOARCH]; !ok {
+		t.Skip("no CLI release for this platform")
+	}
+
+	t.Run("downloads and caches a release", func(t *testing.T) {
+		registry := newFakeRegistry(t, "cli binary")
+		installer := &Installer{Dir: t.TempDir(), RegistryURL: registry.URL}
+
+		path, err := installer.EnsureCLI(t.Context(), "1.2.3")
+		if err != nil {
+			t.Fatalf("Failed to install CLI: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "cli binary" {
+			t.Errorf("Expected installed binary, got %q (%v)", data, err)
+		}
+
+		again, err := installer.EnsureCLI(t.Context(), "1.2.3")
+		if err != nil {
+			t.Fatalf("Failed to reuse cached CLI: %v", err)
+		}
+		if again != path {
+			t.Errorf("Expected cached path %s, got %s", path, again)
+		}
+		if registry.downloads.Load() != 1 {
+			t.Errorf("Expected 1 download, got %d", registry.downloads.Load())
+		}
+	})
+
+	t.Run("rejects checksum mismatches", func(t *testing.T) {
+		registry := newFakeRegistry(t, "cli binary")
+		registry.tarball = append(registry.tarball, 0)
+		installer := &Installer{Dir: t.TempDir(), RegistryURL: registry.URL}
+
+		_, err := installer.EnsureCLI(t.Context(), "1.2.3")
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("Expected checksum mismatch, got %v", err)
+		}
+		entries, _ := os.ReadDir(installer.Dir + "/1.2.3")
+		for _, entry := range entries {
+			if entry.Name() != ".lock" {
+				t.Errorf("Expected no files left behind, found %s", entry.Name())
+			}
+		}
+	})
+
+	t.Run("reports unknown versions", func(t *testing.T) {
+		registry := newFakeRegistry(t, "cli binary")
+		installer := &Installer{Dir: t.TempDir(), RegistryURL: registry.URL}
+
+		_, err := installer.EnsureCLI(t.Context(), "9.9.9")
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid versions", func(t *testing.T) {
+		installer := &Installer{Dir: t.TempDir()}
+		for _, version := range []string{"", "../1.0.0"} {
+			if _, err := installer.EnsureCLI(t.Context(), version); err == nil {
+				t.Errorf("Expected error for version %q", version)
+			}
+		}
+	})
+}
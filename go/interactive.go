@@ -0,0 +1,176 @@
+package copilot
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ANSI escape codes for highlighting diffs.
+const (
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+	ansiBold  = "\033[1m"
+	ansiReset = "\033[0m"
+)
+
+// InteractivePermissionOptions configures [NewInteractivePermissionHandler].
+type InteractivePermissionOptions struct {
+	// In is read for answers, one per line. Default: os.Stdin.
+	In io.Reader
+	// Out receives the requests and prompts. Default: os.Stderr, so that
+	// prompts do not mix with output written to stdout.
+	Out io.Writer
+	// Color highlights diffs and prompts with ANSI colors. Default: when Out
+	// is a terminal and the NO_COLOR environment variable is not set.
+	Color *bool
+}
+
+// NewInteractivePermissionHandler returns a permission handler that shows
+// each request on a terminal, with the diff of file edits, and asks the user
+// to answer:
+//   - y: approve this request
+//   - n: deny this request
+//   - a: approve this request and every later request for the same tool in
+//     the session ([AlwaysAllowTool])
+//   - d: deny this request and every later request for the same tool in the
+//     session ([AlwaysDenyTool])
+//
+// Answers are read one line at a time, so the user presses Enter after the
+// key; an empty answer denies the request. Concurrent requests are asked one
+// after another. The handler reads In directly, so do not use it while
+// another reader of the same input is waiting, such as repl.Run.
+//
+// [PermissionHandler].Interactive is a handler with the default options.
+func NewInteractivePermissionHandler(options *InteractivePermissionOptions) PermissionHandlerFunc {
+	var opts InteractivePermissionOptions
+	if options != nil {
+		opts = *options
+	}
+	if opts.In == nil {
+		opts.In = os.Stdin
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stderr
+	}
+	color := isColorTerminal(opts.Out)
+	if opts.Color != nil {
+		color = *opts.Color
+	}
+
+	var mu sync.Mutex
+	return func(request PermissionRequest, _ PermissionInvocation) (PermissionRequestResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		renderPermissionRequest(opts.Out, request, color)
+		key := request.ToolKey()
+		for {
+			fmt.Fprintf(opts.Out, "Allow? [y]es, [n]o, [a]lways allow %s, [d]eny %s always: ", key, key)
+			answer, err := readLine(opts.In)
+			if err != nil && answer == "" {
+				fmt.Fprintln(opts.Out)
+				return PermissionRequestResult{}, fmt.Errorf("no answer to permission request: %w", err)
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				return PermissionRequestResult{Kind: "approved"}, nil
+			case "n", "no", "":
+				return PermissionRequestResult{Kind: "denied-interactively-by-user"}, nil
+			case "a":
+				return PermissionRequestResult{Kind: "approved", Scope: AlwaysAllowTool}, nil
+			case "d":
+				return PermissionRequestResult{Kind: "denied-interactively-by-user", Scope: AlwaysDenyTool}, nil
+			}
+			if err != nil {
+				return PermissionRequestResult{}, fmt.Errorf("no answer to permission request: %w", err)
+			}
+		}
+	}
+}
+
+// renderPermissionRequest writes a description of request to out.
+func renderPermissionRequest(out io.Writer, request PermissionRequest, color bool) {
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	fmt.Fprintf(out, "\n%s %s\n", paint(ansiBold, "Permission requested:"), request.Kind)
+	if edit := request.FileEdit; edit != nil {
+		if edit.Intention != "" {
+			fmt.Fprintf(out, "  %s\n", edit.Intention)
+		}
+		fmt.Fprintf(out, "  file: %s\n", edit.Path)
+		for _, line := range strings.Split(strings.TrimRight(edit.Diff, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				line = paint(ansiBold, line)
+			case strings.HasPrefix(line, "+"):
+				line = paint(ansiGreen, line)
+			case strings.HasPrefix(line, "-"):
+				line = paint(ansiRed, line)
+			case strings.HasPrefix(line, "@@"):
+				line = paint(ansiCyan, line)
+			}
+			if line != "" {
+				fmt.Fprintf(out, "  %s\n", line)
+			}
+		}
+		return
+	}
+
+	if intention, ok := request.Extra["intention"].(string); ok && intention != "" {
+		fmt.Fprintf(out, "  %s\n", intention)
+	}
+	if command, ok := request.Extra["fullCommandText"].(string); ok {
+		fmt.Fprintf(out, "  $ %s\n", command)
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(request.Extra)) {
+		switch value := request.Extra[key].(type) {
+		case string:
+			if key != "intention" && value != "" {
+				fmt.Fprintf(out, "  %s: %s\n", key, value)
+			}
+		case float64, bool:
+			fmt.Fprintf(out, "  %s: %v\n", key, value)
+		}
+	}
+}
+
+// readLine reads up to the next newline one byte at a time, so that input
+// after the line is left for other readers of r.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// isColorTerminal reports whether w is a terminal that should get ANSI colors.
+func isColorTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
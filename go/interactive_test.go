@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInteractivePermissionHandler(t *testing.T) {
+	shell := PermissionRequest{Kind: "shell", Extra: map[string]any{
+		"fullCommandText": "git push --force",
+		"intention":       "Publish the branch",
+		"commands":        []any{map[string]any{"identifier": "git"}},
+	}}
+	ask := func(t *testing.T, request PermissionRequest, input string) (PermissionRequestResult, string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		handler := NewInteractivePermissionHandler(&InteractivePermissionOptions{In: strings.NewReader(input), Out: &out})
+		result, err := handler(request, PermissionInvocation{SessionID: "s1"})
+		return result, out.String(), err
+	}
+
+	t.Run("maps answers to decisions", func(t *testing.T) {
+		tests := map[string]PermissionRequestResult{
+			"y\n":   {Kind: "approved"},
+			"YES\n": {Kind: "approved"},
+			"n\n":   {Kind: "denied-interactively-by-user"},
+			"\n":    {Kind: "denied-interactively-by-user"},
+			"a\r\n": {Kind: "approved", Scope: AlwaysAllowTool},
+			"d":     {Kind: "denied-interactively-by-user", Scope: AlwaysDenyTool},
+		}
+		for input, want := range tests {
+			result, _, err := ask(t, shell, input)
+			if err != nil || result.Kind != want.Kind || result.Scope != want.Scope {
+				t.Errorf("Answer %q: expected %+v, got %+v, %v", input, want, result, err)
+			}
+		}
+	})
+
+	t.Run("shows the request and asks again after an unknown answer", func(t *testing.T) {
+		result, out, err := ask(t, shell, "maybe\ny\nleft over")
+		if err != nil || result.Kind != "approved" {
+			t.Fatalf("Unexpected result: %+v, %v", result, err)
+		}
+		if !strings.Contains(out, "Publish the branch") || !strings.Contains(out, "$ git push --force") {
+			t.Errorf("Expected the command and intention, got:\n%s", out)
+		}
+		if strings.Count(out, "[a]lways allow shell(git)") != 2 {
+			t.Errorf("Expected the prompt twice, got:\n%s", out)
+		}
+	})
+
+	t.Run("shows the diff of file edits", func(t *testing.T) {
+		write := PermissionRequest{Kind: "write", FileEdit: &FileEditPreview{
+			Path:      "main.go",
+			Diff:      "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n",
+			Intention: "Rename the variable",
+		}}
+		_, out, _ := ask(t, write, "y\n")
+		for _, want := range []string{"file: main.go", "Rename the variable", "  -old\n", "  +new\n"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected %q in:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "\033[") {
+			t.Errorf("Expected no colors when Out is not a terminal, got:\n%q", out)
+		}
+
+		var colored bytes.Buffer
+		handler := NewInteractivePermissionHandler(&InteractivePermissionOptions{In: strings.NewReader("y\n"), Out: &colored, Color: Bool(true)})
+		handler(write, PermissionInvocation{})
+		if !strings.Contains(colored.String(), ansiGreen+"+new"+ansiReset) {
+			t.Errorf("Expected added lines in green, got:\n%q", colored.String())
+		}
+	})
+
+	t.Run("denies when the input ends", func(t *testing.T) {
+		if _, _, err := ask(t, shell, ""); err == nil {
+			t.Error("Expected an error without an answer")
+		}
+	})
+}
+
+func TestReadLine(t *testing.T) {
+	in := strings.NewReader("first\nsecond")
+	if line, err := readLine(in); line != "first" || err != nil {
+		t.Errorf("Unexpected first line: %q, %v", line, err)
+	}
+	if rest, _ := readLine(in); rest != "second" {
+		t.Errorf("Expected the rest of the input to be left unread, got %q", rest)
+	}
+}
@@ -246,6 +246,76 @@ func TestAgentSelectionRpc(t *testing.T) {
 			t.Errorf("Expected no errors on stop, got %v", err)
 		}
 	})
+
+	// session.agent.register and session.agent.unregister are defined in schema but not yet implemented in CLI
+	t.Run("should register, update, and unregister agents on a live session", func(t *testing.T) {
+		t.Skip("session.agent.register not yet implemented in CLI")
+
+		client := copilot.NewClient(&copilot.ClientOptions{
+			CLIPath:  cliPath,
+			UseStdio: copilot.Bool(true),
+		})
+		t.Cleanup(func() { client.ForceStop() })
+
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{
+			OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		registered, err := session.RPC.Agent.Register(t.Context(), &rpc.SessionAgentRegisterParams{
+			Agent: rpc.SessionAgentRegisterParamsAgent{
+				Name:        "live-agent",
+				DisplayName: copilot.String("Live Agent"),
+				Prompt:      "You are a live agent.",
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to register agent: %v", err)
+		}
+		if registered.Replaced {
+			t.Error("Expected new agent not to replace an existing one")
+		}
+
+		updated, err := session.RPC.Agent.Register(t.Context(), &rpc.SessionAgentRegisterParams{
+			Agent: rpc.SessionAgentRegisterParamsAgent{
+				Name:        "live-agent",
+				DisplayName: copilot.String("Live Agent v2"),
+				Prompt:      "You are an updated live agent.",
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to update agent: %v", err)
+		}
+		if !updated.Replaced || updated.Agent.DisplayName != "Live Agent v2" {
+			t.Errorf("Expected agent to be replaced with new display name, got %+v", updated)
+		}
+
+		listResult, err := session.RPC.Agent.List(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to list agents: %v", err)
+		}
+		if len(listResult.Agents) != 1 || listResult.Agents[0].Name != "live-agent" {
+			t.Errorf("Expected only live-agent to be listed, got %v", listResult.Agents)
+		}
+
+		removed, err := session.RPC.Agent.Unregister(t.Context(), &rpc.SessionAgentUnregisterParams{Name: "live-agent"})
+		if err != nil {
+			t.Fatalf("Failed to unregister agent: %v", err)
+		}
+		if !removed.Removed {
+			t.Error("Expected agent to be removed")
+		}
+
+		if err := client.Stop(); err != nil {
+			t.Errorf("Expected no errors on stop, got %v", err)
+		}
+	})
 }
 
 func TestSessionCompactionRpc(t *testing.T) {
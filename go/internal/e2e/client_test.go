@@ -230,6 +230,33 @@ func TestClient(t *testing.T) {
 		client.Stop()
 	})
 
+	t.Run("should match the protocol schema in strict mode", func(t *testing.T) {
+		client := copilot.NewClient(&copilot.ClientOptions{
+			CLIPath:        cliPath,
+			UseStdio:       copilot.Bool(true),
+			StrictProtocol: true,
+		})
+		t.Cleanup(func() { client.ForceStop() })
+
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+		if _, err := client.Ping(t.Context(), "strict"); err != nil {
+			t.Errorf("ping failed validation: %v", err)
+		}
+		if _, err := client.GetStatus(t.Context()); err != nil {
+			t.Errorf("status.get failed validation: %v", err)
+		}
+		if _, err := client.GetAuthStatus(t.Context()); err != nil {
+			t.Errorf("auth.getStatus failed validation: %v", err)
+		}
+		if _, err := client.ListSessions(t.Context(), nil); err != nil {
+			t.Errorf("session.list failed validation: %v", err)
+		}
+
+		client.Stop()
+	})
+
 	t.Run("should report error when CLI fails to start", func(t *testing.T) {
 		client := copilot.NewClient(&copilot.ClientOptions{
 			CLIPath:  cliPath,
@@ -0,0 +1,73 @@
+// Matrix runs the E2E suite against several Copilot CLI versions and reports
+// which versions the SDK is compatible with.
+//
+// Usage, from the go directory:
+//
+//	go run ./internal/e2e/cmd/matrix [--versions 0.0.410,0.0.416,local] [--run REGEXP] [--json FILE] [-v]
+//
+//	--versions: CLI versions to test, separated by commas. Defaults to $COPILOT_CLI_VERSIONS,
+//	  or the versions listed in $COPILOT_CLI_VERSIONS_FILE or internal/e2e/cli-versions.txt.
+//	  "local" tests the CLI in $COPILOT_CLI_PATH.
+//	--run: Only run the tests matching the regular expression.
+//	--json: Write the report as JSON to FILE.
+//	-v: Print the test output of each version.
+//
+// Exits with status 1 if any version is incompatible.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/e2e/testharness"
+)
+
+func main() {
+	versions := flag.String("versions", "", "CLI versions to test, separated by commas")
+	run := flag.String("run", "", "Only run the tests matching the regular expression")
+	jsonPath := flag.String("json", "", "Write the report as JSON to this file")
+	verbose := flag.Bool("v", false, "Print the test output of each version")
+	flag.Parse()
+
+	if *versions != "" {
+		os.Setenv(testharness.VersionsEnv, *versions)
+	}
+	matrix, err := testharness.MatrixVersions(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	options := testharness.MatrixOptions{Versions: matrix, Run: *run}
+	if *verbose {
+		options.Output = os.Stdout
+	}
+	fmt.Printf("Testing SDK protocol version %d against CLI versions %v\n", copilot.GetSdkProtocolVersion(), matrix)
+	report, err := testharness.RunMatrix(ctx, options)
+	if report != nil {
+		fmt.Println()
+		report.WriteSummary(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *jsonPath != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*jsonPath, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if !report.Compatible() {
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,8 @@
 package e2e
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -114,6 +116,79 @@ func TestRpc(t *testing.T) {
 			t.Errorf("Expected no errors on stop, got %v", err)
 		}
 	})
+
+	// embeddings.create is defined in schema but not yet implemented in CLI
+	t.Run("should call RPC.Embeddings.Create when authenticated", func(t *testing.T) {
+		t.Skip("embeddings.create not yet implemented in CLI")
+
+		client := copilot.NewClient(&copilot.ClientOptions{
+			CLIPath:  cliPath,
+			UseStdio: copilot.Bool(true),
+		})
+		t.Cleanup(func() { client.ForceStop() })
+
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+
+		authStatus, err := client.GetAuthStatus(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get auth status: %v", err)
+		}
+
+		if !authStatus.IsAuthenticated {
+			t.Skip("Not authenticated - skipping embeddings.create test")
+		}
+
+		result, err := client.RPC.Embeddings.Create(t.Context(), &rpc.EmbeddingsCreateParams{
+			Input: []string{"hello world", "goodbye world"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to call RPC.Embeddings.Create: %v", err)
+		}
+
+		if len(result.Data) != 2 {
+			t.Fatalf("Expected 2 embeddings, got %d", len(result.Data))
+		}
+		if len(result.Data[0].Embedding) == 0 {
+			t.Error("Expected a non-empty embedding vector")
+		}
+
+		if err := client.Stop(); err != nil {
+			t.Errorf("Expected no errors on stop, got %v", err)
+		}
+	})
+
+	t.Run("should report CLI capabilities", func(t *testing.T) {
+		client := copilot.NewClient(&copilot.ClientOptions{
+			CLIPath:  cliPath,
+			UseStdio: copilot.Bool(true),
+		})
+		t.Cleanup(func() { client.ForceStop() })
+
+		if err := client.Start(t.Context()); err != nil {
+			t.Fatalf("Failed to start client: %v", err)
+		}
+
+		// Falls back to status.get until the CLI implements capabilities.get
+		caps, err := client.Capabilities(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get capabilities: %v", err)
+		}
+		if caps.Version == "" {
+			t.Error("Expected a CLI version")
+		}
+		if caps.ProtocolVersion != copilot.GetSdkProtocolVersion() {
+			t.Errorf("Expected protocol version %d, got %d", copilot.GetSdkProtocolVersion(), caps.ProtocolVersion)
+		}
+		if caps.Methods != nil && !caps.Supports("session.create") {
+			t.Errorf("Expected session.create among supported methods, got %v", caps.Methods)
+		}
+
+		if err := client.Stop(); err != nil {
+			t.Errorf("Expected no errors on stop, got %v", err)
+		}
+	})
 }
 
 func TestSessionRpc(t *testing.T) {
@@ -360,6 +435,170 @@ func TestSessionRpc(t *testing.T) {
 			t.Errorf("Expected files to contain 'nested.txt', got %v", afterNested.Files)
 		}
 	})
+
+	// session.files.* is defined in schema but not yet implemented in CLI
+	t.Run("should add, list, and remove context files", func(t *testing.T) {
+		t.Skip("session.files.* not yet implemented in CLI")
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		path := filepath.Join(t.TempDir(), "pinned.go")
+		if err := os.WriteFile(path, []byte("package pinned\n"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		added, err := session.RPC.Files.Add(t.Context(), &rpc.SessionFilesAddParams{Path: path})
+		if err != nil {
+			t.Fatalf("Failed to add file: %v", err)
+		}
+		if !added.Added {
+			t.Error("Expected file to be newly added")
+		}
+
+		listed, err := session.RPC.Files.List(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to list files: %v", err)
+		}
+		if len(listed.Files) != 1 || listed.Files[0].Path != added.Path {
+			t.Errorf("Expected files to contain %q, got %v", added.Path, listed.Files)
+		}
+
+		removed, err := session.RPC.Files.Remove(t.Context(), &rpc.SessionFilesRemoveParams{Path: path})
+		if err != nil {
+			t.Fatalf("Failed to remove file: %v", err)
+		}
+		if !removed.Removed {
+			t.Error("Expected file to be removed")
+		}
+
+		afterRemove, err := session.RPC.Files.List(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to list files after remove: %v", err)
+		}
+		if len(afterRemove.Files) != 0 {
+			t.Errorf("Expected no files after remove, got %v", afterRemove.Files)
+		}
+	})
+
+	// session.slash.* is defined in schema but not yet implemented in CLI
+	t.Run("should list and run slash commands", func(t *testing.T) {
+		t.Skip("session.slash.* not yet implemented in CLI")
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		listed, err := session.RPC.Slash.List(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to list slash commands: %v", err)
+		}
+		var names []string
+		for _, command := range listed.Commands {
+			names = append(names, command.Name)
+		}
+		if !containsString(names, "clear") {
+			t.Errorf("Expected slash commands to include 'clear', got %v", names)
+		}
+
+		result, err := session.RPC.Slash.Run(t.Context(), &rpc.SessionSlashRunParams{Command: "/clear"})
+		if err != nil {
+			t.Fatalf("Failed to run slash command: %v", err)
+		}
+		if !result.Success {
+			t.Errorf("Expected /clear to succeed, got output %q", result.Output)
+		}
+	})
+
+	// session.context.add is defined in schema but not yet implemented in CLI
+	t.Run("should add repository context", func(t *testing.T) {
+		t.Skip("session.context.add not yet implemented in CLI")
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		repo, err := copilot.GatherRepoContext(t.Context(), ".", nil)
+		if err != nil {
+			t.Fatalf("Failed to gather repo context: %v", err)
+		}
+		if err := session.AddRepoContext(t.Context(), repo); err != nil {
+			t.Fatalf("Failed to add repo context: %v", err)
+		}
+	})
+
+	// session.summarize is defined in schema but not yet implemented in CLI
+	t.Run("should summarize conversation without changing history", func(t *testing.T) {
+		t.Skip("session.summarize not yet implemented in CLI")
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.SendAndWait(t.Context(), copilot.MessageOptions{Prompt: "What is 1+1?"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		before, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+
+		result, err := session.RPC.Summarize(t.Context(), &rpc.SessionSummarizeParams{Instructions: copilot.String("One sentence")})
+		if err != nil {
+			t.Fatalf("Failed to summarize: %v", err)
+		}
+		if result.Summary == "" {
+			t.Error("Expected a non-empty summary")
+		}
+
+		after, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		if len(after) != len(before) {
+			t.Errorf("Expected history to be unchanged, had %d events, now %d", len(before), len(after))
+		}
+	})
+
+	// session.checkpoints.* is defined in schema but not yet implemented in CLI
+	t.Run("should create and restore checkpoints", func(t *testing.T) {
+		t.Skip("session.checkpoints.* not yet implemented in CLI")
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		if _, err := session.SendAndWait(t.Context(), copilot.MessageOptions{Prompt: "What is 1+1?"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		before, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		if _, err := session.Checkpoint(t.Context(), "first-answer"); err != nil {
+			t.Fatalf("Failed to create checkpoint: %v", err)
+		}
+
+		if _, err := session.SendAndWait(t.Context(), copilot.MessageOptions{Prompt: "Now double it"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		if err := session.RestoreCheckpoint(t.Context(), "first-answer"); err != nil {
+			t.Fatalf("Failed to restore checkpoint: %v", err)
+		}
+
+		after, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		if len(after) != len(before) {
+			t.Errorf("Expected history restored to %d events, got %d", len(before), len(after))
+		}
+	})
 }
 
 func containsString(slice []string, str string) bool {
@@ -3,6 +3,7 @@ package e2e
 import (
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -58,7 +59,10 @@ func TestSession(t *testing.T) {
 	t.Run("should have stateful conversation", func(t *testing.T) {
 		ctx.ConfigureForTest(t)
 
-		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{
+			OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+			Deterministic:       ctx.Deterministic(t),
+		})
 		if err != nil {
 			t.Fatalf("Failed to create session: %v", err)
 		}
@@ -82,6 +86,49 @@ func TestSession(t *testing.T) {
 		}
 	})
 
+	t.Run("should serialize concurrent SendAndWait calls on a shared session", func(t *testing.T) {
+		ctx.ConfigureForTest(t)
+
+		session, err := client.CreateSession(t.Context(), &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response, err := session.SendAndWait(t.Context(), copilot.MessageOptions{Prompt: "What is 1+1?"})
+				if err != nil {
+					t.Errorf("Failed to send message: %v", err)
+					return
+				}
+				if response == nil || response.Data.Content == nil || !strings.Contains(*response.Data.Content, "2") {
+					t.Errorf("Expected each response to contain '2', got %v", response)
+				}
+			}()
+		}
+		wg.Wait()
+
+		messages, err := session.GetMessages(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to get messages: %v", err)
+		}
+		var userMessages, assistantMessages int
+		for _, message := range messages {
+			switch message.Type {
+			case copilot.UserMessage:
+				userMessages++
+			case copilot.AssistantMessage:
+				assistantMessages++
+			}
+		}
+		if userMessages != 3 || assistantMessages != 3 {
+			t.Errorf("Expected 3 complete turns, got %d user and %d assistant messages", userMessages, assistantMessages)
+		}
+	})
+
 	t.Run("should create a session with appended systemMessage config", func(t *testing.T) {
 		ctx.ConfigureForTest(t)
 
@@ -1,6 +1,8 @@
 package testharness
 
 import (
+	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -43,7 +45,8 @@ type TestContext struct {
 	WorkDir  string
 	ProxyURL string
 
-	proxy *CapiProxy
+	proxy      *CapiProxy
+	recordings []*os.File // JSON-RPC replay logs of the clients created by NewClient
 }
 
 // NewTestContext creates a new test context with isolated directories and a replaying proxy.
@@ -131,6 +134,19 @@ func (c *TestContext) Close(testFailed bool) {
 	if c.proxy != nil {
 		c.proxy.StopWithOptions(testFailed)
 	}
+	for _, recording := range c.recordings {
+		recording.Close()
+		// Keep the replay logs of failed tests so the failure can be reproduced
+		// with copilot.NewPlaybackClient
+		if testFailed {
+			if kept, err := os.CreateTemp("", "copilot-test-replay-*.jsonl"); err == nil {
+				kept.Close()
+				if os.Rename(recording.Name(), kept.Name()) == nil {
+					fmt.Fprintf(os.Stderr, "replay log of the failed test: %s\n", kept.Name())
+				}
+			}
+		}
+	}
 	if c.HomeDir != "" {
 		os.RemoveAll(c.HomeDir)
 	}
@@ -165,6 +181,12 @@ func (c *TestContext) NewClient() *copilot.Client {
 		Env:     c.Env(),
 	}
 
+	name := fmt.Sprintf("replay-%d.jsonl", len(c.recordings)+1)
+	if recording, err := os.Create(filepath.Join(c.HomeDir, name)); err == nil {
+		c.recordings = append(c.recordings, recording)
+		options.RecordTo = recording
+	}
+
 	// Use fake token in CI to allow cached responses without real auth
 	if os.Getenv("CI") == "true" {
 		options.GitHubToken = "fake-token-for-e2e-tests"
@@ -173,6 +195,16 @@ func (c *TestContext) NewClient() *copilot.Client {
 	return copilot.NewClient(options)
 }
 
+// Deterministic returns the deterministic mode settings for t, seeded from the
+// test name so that each test samples the same way on every run. Sessions
+// created with it fall back to the replay log recorded by clients from NewClient
+// when the CLI cannot seed sampling.
+func (c *TestContext) Deterministic(t *testing.T) *copilot.DeterministicConfig {
+	h := fnv.New64a()
+	h.Write([]byte(t.Name()))
+	return &copilot.DeterministicConfig{Seed: int64(h.Sum64() >> 1)}
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
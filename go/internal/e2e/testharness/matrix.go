@@ -0,0 +1,311 @@
+package testharness
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/install"
+)
+
+// VersionsEnv lists the CLI versions to run the E2E suite against, separated
+// by commas or spaces. It takes precedence over the versions file.
+const VersionsEnv = "COPILOT_CLI_VERSIONS"
+
+// VersionsFileEnv names a file listing CLI versions, one per line. Default:
+// [DefaultVersionsFile].
+const VersionsFileEnv = "COPILOT_CLI_VERSIONS_FILE"
+
+// DefaultVersionsFile is the checked-in CLI version matrix, relative to the Go
+// module root.
+const DefaultVersionsFile = "internal/e2e/cli-versions.txt"
+
+// LocalVersion stands for the CLI found by [CLIPath] instead of a released
+// version, such as the one installed in the nodejs directory.
+const LocalVersion = "local"
+
+// MatrixVersions returns the CLI versions to test from [VersionsEnv] or, if
+// it is unset, from the file named by [VersionsFileEnv] or
+// [DefaultVersionsFile] under dir. Blank lines and text after # are ignored.
+func MatrixVersions(dir string) ([]string, error) {
+	if versions := os.Getenv(VersionsEnv); versions != "" {
+		return parseVersions(versions), nil
+	}
+	path := os.Getenv(VersionsFileEnv)
+	if path == "" {
+		path = filepath.Join(dir, DefaultVersionsFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CLI versions: %w", err)
+	}
+	versions := parseVersions(string(data))
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no CLI versions listed in %s", path)
+	}
+	return versions, nil
+}
+
+func parseVersions(text string) []string {
+	var versions []string
+	for _, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		versions = append(versions, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+	return versions
+}
+
+// MatrixOptions configures [RunMatrix].
+type MatrixOptions struct {
+	// Versions are the CLI versions to test, or [LocalVersion].
+	Versions []string
+	// Installer downloads the CLI versions. Default: the zero Installer, which
+	// caches them in the user cache directory.
+	Installer *install.Installer
+	// Dir is the Go module root the tests run in. Default: the current directory.
+	Dir string
+	// Packages are the test packages. Default: ./internal/e2e/...
+	Packages []string
+	// Run, when set, only runs the tests matching the regular expression, as
+	// with go test -run.
+	Run string
+	// Output, when non-nil, receives the go test output of each version.
+	Output io.Writer
+}
+
+// VersionResult is the outcome of the E2E suite against one CLI version.
+type VersionResult struct {
+	Version string `json:"version"`
+	// CLIPath is where the version was installed.
+	CLIPath string `json:"cliPath,omitempty"`
+	// ProtocolVersion is the SDK protocol version the CLI reported, or 0 if
+	// it could not be started.
+	ProtocolVersion int `json:"protocolVersion,omitempty"`
+	// Error describes why the version could not be installed, started, or
+	// tested, if it could not.
+	Error string `json:"error,omitempty"`
+	// Passed, Failed, and Skipped are the names of the tests with each outcome,
+	// including subtests.
+	Passed   []string      `json:"passed"`
+	Failed   []string      `json:"failed"`
+	Skipped  []string      `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Compatible reports whether the SDK works with the version: it was tested
+// and no test failed.
+func (r *VersionResult) Compatible() bool {
+	return r.Error == "" && len(r.Failed) == 0 && len(r.Passed) > 0
+}
+
+// MatrixReport is the outcome of [RunMatrix], with one result per version in
+// the order given.
+type MatrixReport struct {
+	SDKProtocolVersion int             `json:"sdkProtocolVersion"`
+	Results            []VersionResult `json:"results"`
+}
+
+// Compatible reports whether every version is compatible.
+func (r *MatrixReport) Compatible() bool {
+	for i := range r.Results {
+		if !r.Results[i].Compatible() {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteSummary writes a table of the results, followed by the failed tests of
+// each version.
+func (r *MatrixReport) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CLI VERSION\tPROTOCOL\tRESULT\tPASSED\tFAILED\tSKIPPED\tDURATION\n")
+	for i := range r.Results {
+		result := &r.Results[i]
+		status := "compatible"
+		switch {
+		case result.Error != "":
+			status = "error"
+		case !result.Compatible():
+			status = "incompatible"
+		}
+		protocol := "-"
+		if result.ProtocolVersion != 0 {
+			protocol = fmt.Sprint(result.ProtocolVersion)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", result.Version, protocol, status,
+			len(result.Passed), len(result.Failed), len(result.Skipped), result.Duration.Round(time.Second))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for i := range r.Results {
+		result := &r.Results[i]
+		if result.Error != "" {
+			fmt.Fprintf(w, "\n%s: %s\n", result.Version, result.Error)
+		}
+		if len(result.Failed) > 0 {
+			fmt.Fprintf(w, "\n%s failed:\n", result.Version)
+			for _, name := range result.Failed {
+				fmt.Fprintf(w, "  %s\n", name)
+			}
+		}
+	}
+	return nil
+}
+
+// RunMatrix installs each CLI version and runs the E2E suite against it with
+// COPILOT_CLI_PATH pointing at the installed CLI. A version that cannot be
+// installed or started is reported with an Error and the remaining versions
+// are still tested. RunMatrix returns an error only if ctx is done.
+func RunMatrix(ctx context.Context, options MatrixOptions) (*MatrixReport, error) {
+	installer := options.Installer
+	if installer == nil {
+		installer = &install.Installer{}
+	}
+	packages := options.Packages
+	if len(packages) == 0 {
+		packages = []string{"./internal/e2e/..."}
+	}
+
+	report := &MatrixReport{SDKProtocolVersion: copilot.GetSdkProtocolVersion()}
+	for _, version := range options.Versions {
+		start := time.Now()
+		result := VersionResult{Version: version}
+		if err := runVersion(ctx, installer, packages, options, &result); err != nil {
+			result.Error = err.Error()
+		}
+		result.Duration = time.Since(start)
+		report.Results = append(report.Results, result)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func runVersion(ctx context.Context, installer *install.Installer, packages []string, options MatrixOptions, result *VersionResult) error {
+	path := CLIPath()
+	if result.Version != LocalVersion {
+		var err error
+		if path, err = installer.EnsureCLI(ctx, result.Version); err != nil {
+			return fmt.Errorf("installing CLI: %w", err)
+		}
+	} else if path == "" {
+		return errors.New("no local CLI found; set COPILOT_CLI_PATH or run 'npm install' in the nodejs directory")
+	}
+	result.CLIPath = path
+
+	protocolVersion, err := cliProtocolVersion(ctx, path)
+	if err != nil {
+		return fmt.Errorf("starting CLI: %w", err)
+	}
+	result.ProtocolVersion = protocolVersion
+
+	args := []string{"test", "-json", "-count=1"}
+	if options.Run != "" {
+		args = append(args, "-run", options.Run)
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, packages...)...)
+	cmd.Dir = options.Dir
+	cmd.Env = append(os.Environ(), "COPILOT_CLI_PATH="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("running go test: %w", err)
+	}
+	parseErr := parseTestEvents(stdout, options.Output, result)
+	waitErr := cmd.Wait()
+	if parseErr != nil {
+		return fmt.Errorf("reading go test output: %w", parseErr)
+	}
+	// go test exits with an error when tests fail; that is reported through Failed
+	if waitErr != nil && len(result.Failed) == 0 {
+		return fmt.Errorf("go test: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// cliProtocolVersion starts the CLI at path and returns the protocol version
+// it reports. Start fails with ErrProtocolMismatch when it differs from the
+// SDK's.
+func cliProtocolVersion(ctx context.Context, path string) (int, error) {
+	client := copilot.NewClient(&copilot.ClientOptions{CLIPath: path})
+	defer client.ForceStop()
+	if err := client.Start(ctx); err != nil {
+		return 0, err
+	}
+	response, err := client.Ping(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	if response.ProtocolVersion == nil {
+		return 0, nil
+	}
+	return *response.ProtocolVersion, nil
+}
+
+// testEvent is a line of go test -json output.
+type testEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+	Output  string `json:"Output"`
+}
+
+// parseTestEvents reads go test -json output, copying test output to output
+// and recording the outcome of each test in result.
+func parseTestEvents(r io.Reader, output io.Writer, result *VersionResult) error {
+	failedPackages := make(map[string]bool) // packages with a failed test
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// Build errors are printed as plain text
+			if output != nil {
+				fmt.Fprintln(output, scanner.Text())
+			}
+			continue
+		}
+		if output != nil && event.Output != "" {
+			io.WriteString(output, event.Output)
+		}
+		if event.Test == "" {
+			// A package fails without a failed test when it does not build or
+			// its TestMain fails
+			if event.Action == "fail" && !failedPackages[event.Package] {
+				result.Failed = append(result.Failed, event.Package)
+			}
+			continue
+		}
+		switch event.Action {
+		case "pass":
+			result.Passed = append(result.Passed, event.Test)
+		case "fail":
+			failedPackages[event.Package] = true
+			result.Failed = append(result.Failed, event.Test)
+		case "skip":
+			result.Skipped = append(result.Skipped, event.Test)
+		}
+	}
+	sort.Strings(result.Failed)
+	return scanner.Err()
+}
@@ -0,0 +1,61 @@
+package testharness
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatrixVersions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultVersionsFile)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("# tested versions\n0.0.410\n\n0.0.416 # pinned\nlocal\n"), 0644)
+
+	t.Setenv(VersionsEnv, "")
+	t.Setenv(VersionsFileEnv, "")
+	versions, err := MatrixVersions(dir)
+	if err != nil || strings.Join(versions, ",") != "0.0.410,0.0.416,local" {
+		t.Errorf("Unexpected versions from the file: %v, %v", versions, err)
+	}
+
+	t.Setenv(VersionsEnv, "0.0.400, 0.0.405")
+	versions, err = MatrixVersions(dir)
+	if err != nil || strings.Join(versions, ",") != "0.0.400,0.0.405" {
+		t.Errorf("Expected the environment to take precedence, got %v, %v", versions, err)
+	}
+}
+
+func TestParseTestEvents(t *testing.T) {
+	output := `{"Action":"run","Package":"e2e","Test":"TestSession"}
+{"Action":"output","Package":"e2e","Test":"TestSession","Output":"=== RUN   TestSession\n"}
+{"Action":"pass","Package":"e2e","Test":"TestSession/should_create"}
+{"Action":"fail","Package":"e2e","Test":"TestSession/should_resume"}
+{"Action":"fail","Package":"e2e","Test":"TestSession"}
+{"Action":"skip","Package":"e2e","Test":"TestSkills"}
+{"Action":"fail","Package":"e2e"}
+{"Action":"fail","Package":"e2e/broken"}
+`
+	var out strings.Builder
+	result := VersionResult{Version: "0.0.416"}
+	if err := parseTestEvents(strings.NewReader(output), &out, &result); err != nil {
+		t.Fatalf("Failed to parse events: %v", err)
+	}
+	if len(result.Passed) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("Unexpected results: %+v", result)
+	}
+	if strings.Join(result.Failed, ",") != "TestSession,TestSession/should_resume,e2e/broken" {
+		t.Errorf("Expected failed tests and the package that failed on its own, got %v", result.Failed)
+	}
+	if out.String() != "=== RUN   TestSession\n" {
+		t.Errorf("Expected the test output to be copied, got %q", out.String())
+	}
+
+	report := MatrixReport{Results: []VersionResult{result, {Version: "0.0.300", Error: "starting CLI: protocol mismatch"}}}
+	var summary strings.Builder
+	report.WriteSummary(&summary)
+	if report.Compatible() || !strings.Contains(summary.String(), "incompatible") || !strings.Contains(summary.String(), "0.0.300: starting CLI: protocol mismatch") {
+		t.Errorf("Unexpected summary:\n%s", summary.String())
+	}
+}
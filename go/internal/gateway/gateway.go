@@ -0,0 +1,156 @@
+// Package gateway holds the parts of the HTTP and gRPC gateways that do not
+// depend on the transport: the sessions a gateway owns, the permission handler
+// of sessions without one, and the classification of SDK errors into the
+// statuses both transports report.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Sessions tracks the sessions a gateway created or resumed, by ID. The zero
+// value is ready to use.
+type Sessions struct {
+	mu       sync.Mutex
+	sessions map[string]*copilot.Session
+}
+
+// Track adds session, replacing any session with the same ID.
+func (s *Sessions) Track(session *copilot.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = map[string]*copilot.Session{}
+	}
+	s.sessions[session.SessionID] = session
+}
+
+// Untrack removes the session with the given ID.
+func (s *Sessions) Untrack(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// Get returns the tracked session with the given ID.
+func (s *Sessions) Get(sessionID string) (*copilot.Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	return session, ok
+}
+
+// Destroy destroys the tracked session with the given ID and stops tracking it
+// once it is destroyed, so that a failed destroy can be retried. It reports
+// whether the session was tracked.
+func (s *Sessions) Destroy(sessionID string) (bool, error) {
+	session, ok := s.Get(sessionID)
+	if !ok {
+		return false, nil
+	}
+	if err := session.Destroy(); err != nil {
+		return true, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[sessionID] == session {
+		delete(s.sessions, sessionID)
+	}
+	return true, nil
+}
+
+// DenyAll is the permission handler of sessions created by a gateway that was
+// not given one.
+func DenyAll(copilot.PermissionRequest, copilot.PermissionInvocation) (copilot.PermissionRequestResult, error) {
+	return copilot.PermissionRequestResult{Kind: "denied-interactively-by-user"}, nil
+}
+
+// ResumeConfig returns the options of config that apply to resuming a
+// session, so that a gateway can run the same ConfigureSession hook on create
+// and resume requests. Fields of config that a resume does not take, such as
+// SessionID, are dropped.
+func ResumeConfig(config *copilot.SessionConfig) *copilot.ResumeSessionConfig {
+	return &copilot.ResumeSessionConfig{
+		ClientName:          config.ClientName,
+		Model:               config.Model,
+		Locale:              config.Locale,
+		Tools:               config.Tools,
+		SystemMessage:       config.SystemMessage,
+		AvailableTools:      config.AvailableTools,
+		ExcludedTools:       config.ExcludedTools,
+		Provider:            config.Provider,
+		ReasoningEffort:     config.ReasoningEffort,
+		OnPermissionRequest: config.OnPermissionRequest,
+		OnPermissionAudit:   config.OnPermissionAudit,
+		PermissionStore:     config.PermissionStore,
+		OnUserInputRequest:  config.OnUserInputRequest,
+		Hooks:               config.Hooks,
+		WorkingDirectory:    config.WorkingDirectory,
+		ConfigDir:           config.ConfigDir,
+		Streaming:           config.Streaming,
+		MCPServers:          config.MCPServers,
+		CustomAgents:        config.CustomAgents,
+		SkillDirectories:    config.SkillDirectories,
+		DisabledSkills:      config.DisabledSkills,
+		InfiniteSessions:    config.InfiniteSessions,
+		MaxParallelTools:    config.MaxParallelTools,
+		ToolTimeout:         config.ToolTimeout,
+		AutoCompact:         config.AutoCompact,
+		TruncationPolicy:    config.TruncationPolicy,
+		ModelFallbacks:      config.ModelFallbacks,
+		MaxQueuedMessages:   config.MaxQueuedMessages,
+		Env:                 config.Env,
+		ClearEnv:            config.ClearEnv,
+		Sandbox:             config.Sandbox,
+		Deterministic:       config.Deterministic,
+		Metadata:            config.Metadata,
+		OnBeforeSend:        config.OnBeforeSend,
+		OnBeforeDeliver:     config.OnBeforeDeliver,
+		ToolCache:           config.ToolCache,
+		DryRun:              config.DryRun,
+		ReviewEdits:         config.ReviewEdits,
+		OnEditProposed:      config.OnEditProposed,
+		OnEventGap:          config.OnEventGap,
+	}
+}
+
+// ErrorKind is the class of an error, which each transport maps to a status.
+type ErrorKind int
+
+const (
+	// KindUnknown is an error of no other kind.
+	KindUnknown ErrorKind = iota
+	// KindCanceled is a request whose caller went away.
+	KindCanceled
+	// KindTimeout is a request that ran out of time.
+	KindTimeout
+	// KindResourceExhausted is a request over a rate limit or queue bound; it
+	// may be retried after the error's RetryAfter.
+	KindResourceExhausted
+	// KindPermissionDenied is a request a permission handler or policy denied.
+	KindPermissionDenied
+	// KindUnavailable is a request the CLI cannot serve at the moment; it may be
+	// retried after the error's RetryAfter.
+	KindUnavailable
+)
+
+// Classify returns the kind of err.
+func Classify(err error) ErrorKind {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, copilot.ErrTimeout):
+		return KindTimeout
+	case errors.Is(err, copilot.ErrRateLimited), errors.Is(err, copilot.ErrQueueFull):
+		return KindResourceExhausted
+	case errors.Is(err, copilot.ErrPermissionDenied):
+		return KindPermissionDenied
+	case errors.Is(err, copilot.ErrCLIUnavailable), errors.Is(err, copilot.ErrCircuitOpen):
+		return KindUnavailable
+	}
+	return KindUnknown
+}
@@ -0,0 +1,270 @@
+// Package golden records canonical JSON-RPC payloads as golden files and
+// checks that later runs produce the same bytes, so that accidental changes to
+// the SDK's wire format fail tests locally.
+//
+// Golden files are compared by default. Run the tests with
+// COPILOT_UPDATE_GOLDEN=1 to record them again after an intended change, and
+// review the diff of the testdata directory before committing it.
+package golden
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// UpdateEnv, when set to a non-empty value, makes [Check] write golden files
+// instead of comparing against them.
+const UpdateEnv = "COPILOT_UPDATE_GOLDEN"
+
+// Check marshals got as canonical JSON and compares it with the golden file at
+// path, or writes the file when [UpdateEnv] is set. A missing golden file
+// fails the test with a hint to record it.
+func Check(t testing.TB, path string, got any) {
+	t.Helper()
+	if err := check(path, got); err != nil {
+		t.Error(err)
+	}
+}
+
+func check(path string, got any) error {
+	data, err := Canonical(got)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", path, err)
+	}
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create golden directory: %w", err)
+		}
+		return os.WriteFile(path, data, 0o644)
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("golden file %s does not exist; run the test with %s=1 to record it", path, UpdateEnv)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+	if !bytes.Equal(bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n")), data) {
+		return fmt.Errorf("wire format changed for %s; if intended, run the test with %s=1 and review the diff.\ngot:\n%s\nwant:\n%s", path, UpdateEnv, data, want)
+	}
+	return nil
+}
+
+// Canonical marshals v as indented JSON with object keys sorted, so that
+// payloads compare equal regardless of struct field or map order.
+func Canonical(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(generic, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// sampleTime is the time used for every time.Time in samples.
+var sampleTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Sample returns a value of type typ with every field set: strings hold the
+// name of their field, numbers are 1, booleans are true, pointers are
+// non-nil, and slices and maps hold one element. Its JSON covers every member
+// a payload can have, so a renamed or retyped field changes the golden file.
+func Sample(typ reflect.Type) reflect.Value {
+	return sample(typ, "value", map[reflect.Type]bool{})
+}
+
+func sample(typ reflect.Type, name string, visiting map[reflect.Type]bool) reflect.Value {
+	value := reflect.New(typ).Elem()
+	if typ == reflect.TypeOf(time.Time{}) {
+		value.Set(reflect.ValueOf(sampleTime))
+		return value
+	}
+	if typ == reflect.TypeOf(json.RawMessage{}) {
+		value.SetBytes([]byte(`{"` + name + `":true}`))
+		return value
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		value.SetString(name)
+	case reflect.Bool:
+		value.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		value.SetFloat(1)
+	case reflect.Interface:
+		value.Set(reflect.ValueOf(name))
+	case reflect.Pointer:
+		if visiting[typ.Elem()] {
+			return value // break cycles with nil
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(sample(typ.Elem(), name, visiting))
+		value.Set(ptr)
+	case reflect.Slice:
+		if visiting[typ.Elem()] {
+			return value
+		}
+		value.Set(reflect.Append(value, sample(typ.Elem(), name, visiting)))
+	case reflect.Map:
+		value.Set(reflect.MakeMap(typ))
+		value.SetMapIndex(sample(typ.Key(), "key", visiting), sample(typ.Elem(), name, visiting))
+	case reflect.Struct:
+		visiting[typ] = true
+		defer delete(visiting, typ)
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if fieldName == "-" {
+				continue
+			}
+			if fieldName == "" {
+				fieldName = field.Name
+			}
+			value.Field(i).Set(sample(field.Type, fieldName, visiting))
+		}
+	}
+	return value
+}
+
+// Exchange is a request received by a [Server] and the response it sent.
+type Exchange struct {
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Server plays the CLI side of a JSON-RPC connection, answering every request
+// with the result returned by its respond function and recording the
+// exchange.
+type Server struct {
+	Client *jsonrpc2.Client
+
+	respond func(method string, params json.RawMessage) any
+	in      *io.PipeReader // requests from the client
+	out     *io.PipeWriter // responses to the client
+	done    chan struct{}
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewServer starts a server and returns it with a started client connected
+// to it. Stop it with [Server.Close].
+func NewServer(respond func(method string, params json.RawMessage) any) *Server {
+	requestsR, requestsW := io.Pipe()
+	responsesR, responsesW := io.Pipe()
+	s := &Server{
+		Client:  jsonrpc2.NewClient(requestsW, responsesR),
+		respond: respond,
+		in:      requestsR,
+		out:     responsesW,
+		done:    make(chan struct{}),
+	}
+	go s.serve()
+	s.Client.Start()
+	return s
+}
+
+// Last returns the most recent exchange, or false if there was none.
+func (s *Server) Last() (Exchange, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.exchanges) == 0 {
+		return Exchange{}, false
+	}
+	return s.exchanges[len(s.exchanges)-1], true
+}
+
+// Close stops the client and the server.
+func (s *Server) Close() {
+	s.in.Close()
+	s.out.Close()
+	s.Client.Stop()
+	<-s.done
+}
+
+func (s *Server) serve() {
+	defer close(s.done)
+	reader := bufio.NewReader(s.in)
+	for {
+		data, err := readFrame(reader)
+		if err != nil {
+			return
+		}
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(data, &request); err != nil || request.ID == nil {
+			continue
+		}
+		result, err := json.Marshal(s.respond(request.Method, request.Params))
+		if err != nil {
+			result = []byte("null")
+		}
+
+		s.mu.Lock()
+		s.exchanges = append(s.exchanges, Exchange{Method: request.Method, Request: request.Params, Response: result})
+		s.mu.Unlock()
+
+		response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": json.RawMessage(result)})
+		if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(response), response); err != nil {
+			return
+		}
+	}
+}
+
+// readFrame reads a single Content-Length framed message.
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	data := make([]byte, length)
+	_, err := io.ReadFull(reader, data)
+	return data, err
+}
@@ -0,0 +1,97 @@
+package golden
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	type node struct {
+		Name     string          `json:"name"`
+		Count    *int            `json:"count,omitempty"`
+		Tags     []string        `json:"tags,omitempty"`
+		Labels   map[string]bool `json:"labels,omitempty"`
+		At       time.Time       `json:"at"`
+		Raw      json.RawMessage `json:"raw,omitempty"`
+		Children []node          `json:"children,omitempty"`
+		Parent   *node           `json:"parent,omitempty"`
+		Skipped  string          `json:"-"`
+		Extra    map[string]any  `json:"extra,omitempty"`
+	}
+
+	got, err := Canonical(Sample(reflect.TypeOf(node{})).Interface())
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	want := `{
+  "at": "2025-01-02T03:04:05Z",
+  "count": 1,
+  "extra": {
+    "key": "extra"
+  },
+  "labels": {
+    "key": true
+  },
+  "name": "name",
+  "raw": {
+    "raw": true
+  },
+  "tags": [
+    "tags"
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("Unexpected sample:\n%s", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "payload.json")
+	payload := map[string]any{"b": 2, "a": []int{1}}
+
+	t.Setenv(UpdateEnv, "1")
+	Check(t, path, payload)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the golden file to be written: %v", err)
+	}
+	if want := "{\n  \"a\": [\n    1\n  ],\n  \"b\": 2\n}\n"; string(data) != want {
+		t.Errorf("Unexpected golden file:\n%s", data)
+	}
+
+	t.Setenv(UpdateEnv, "")
+	if err := check(path, payload); err != nil {
+		t.Errorf("Expected the recorded payload to match: %v", err)
+	}
+	if err := check(path, map[string]any{"b": 3}); err == nil {
+		t.Error("Expected a changed payload to fail the check")
+	}
+	if err := check(filepath.Join(t.TempDir(), "missing.json"), payload); err == nil {
+		t.Error("Expected a missing golden file to fail the check")
+	}
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer(func(method string, params json.RawMessage) any {
+		return map[string]string{"echo": method}
+	})
+	defer server.Close()
+
+	raw, err := server.Client.RequestContext(context.Background(), "test.echo", map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if string(raw) != `{"echo":"test.echo"}` {
+		t.Errorf("Unexpected result: %s", raw)
+	}
+	exchange, ok := server.Last()
+	if !ok || exchange.Method != "test.echo" || string(exchange.Request) != `{"n":1}` || string(exchange.Response) != string(raw) {
+		t.Errorf("Unexpected exchange: %+v", exchange)
+	}
+}
@@ -2,12 +2,16 @@ package jsonrpc2
 
 import (
 	"bufio"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Error represents a JSON-RPC error response
@@ -21,6 +25,20 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("JSON-RPC Error %d: %s", e.Code, e.Message)
 }
 
+// ConnectionError indicates that a request failed because the connection to the
+// server was lost or could not be used, rather than because the server returned an error.
+type ConnectionError struct {
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
 // Request represents a JSON-RPC 2.0 request
 type Request struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -47,6 +65,45 @@ type NotificationHandler func(method string, params json.RawMessage)
 // RequestHandler handles incoming server requests and returns a result or error
 type RequestHandler func(params json.RawMessage) (json.RawMessage, *Error)
 
+// FrameDirection indicates whether a frame was written to or read from the peer.
+type FrameDirection string
+
+const (
+	FrameSent     FrameDirection = "send"
+	FrameReceived FrameDirection = "recv"
+)
+
+// FrameObserver is called with the raw body of every JSON-RPC message sent or received.
+// Observers run synchronously on the transport goroutines and must not retain data.
+type FrameObserver func(direction FrameDirection, data []byte)
+
+// RequestObserver is called when an outgoing request completes, successfully or not.
+type RequestObserver func(method string, duration time.Duration, err error)
+
+// Payload identifies the part of a message checked by a [Validator].
+type Payload string
+
+const (
+	// RequestParams are the params of a request or notification sent to the peer.
+	RequestParams Payload = "params"
+	// RequestResult is the result of a request sent to the peer.
+	RequestResult Payload = "result"
+	// HandlerParams are the params of a request or notification received from the peer.
+	HandlerParams Payload = "handler params"
+	// HandlerResult is the result of a request received from the peer, before it is sent.
+	HandlerResult Payload = "handler result"
+)
+
+// RequestGate is called before each outgoing request is sent. If it returns an
+// error, the request fails with that error without being sent. Otherwise the
+// returned function, if non-nil, is called with the request's outcome.
+type RequestGate func(method string) (done func(err error), err error)
+
+// Validator checks a message payload. A request whose params or result fail
+// validation returns the error; a received request is answered with an error
+// and reported as a protocol error.
+type Validator func(method string, payload Payload, data json.RawMessage) error
+
 // Client is a minimal JSON-RPC 2.0 client for stdio transport
 type Client struct {
 	stdin           io.WriteCloser
@@ -54,12 +111,19 @@ type Client struct {
 	mu              sync.Mutex
 	pendingRequests map[string]chan *Response
 	requestHandlers map[string]RequestHandler
-	running         bool
+	observers       []FrameObserver
+	requestObserver RequestObserver
+	errorMapper     func(error) error
+	onProtocolError func(error)
+	validator       Validator
+	gate            RequestGate
+	running         atomic.Bool
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	processDone     chan struct{} // closed when the underlying process exits
 	processError    error         // set before processDone is closed
 	processErrorMu  sync.RWMutex  // protects processError
+	logger          *slog.Logger
 }
 
 // NewClient creates a new JSON-RPC client
@@ -70,9 +134,15 @@ func NewClient(stdin io.WriteCloser, stdout io.ReadCloser) *Client {
 		pendingRequests: make(map[string]chan *Response),
 		requestHandlers: make(map[string]RequestHandler),
 		stopChan:        make(chan struct{}),
+		logger:          slog.New(slog.DiscardHandler),
 	}
 }
 
+// SetLogger sets the logger for transport errors. It should be called before Start.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
 // SetProcessDone sets a channel that will be closed when the process exits,
 // and stores the error that should be returned to pending/future requests.
 func (c *Client) SetProcessDone(done chan struct{}, errPtr *error) {
@@ -97,17 +167,16 @@ func (c *Client) getProcessError() error {
 
 // Start begins listening for messages in a background goroutine
 func (c *Client) Start() {
-	c.running = true
+	c.running.Store(true)
 	c.wg.Add(1)
 	go c.readLoop()
 }
 
 // Stop stops the client and cleans up
 func (c *Client) Stop() {
-	if !c.running {
+	if !c.running.CompareAndSwap(true, false) {
 		return
 	}
-	c.running = false
 	close(c.stopChan)
 
 	// Close stdout to unblock the readLoop
@@ -180,8 +249,129 @@ func (c *Client) SetRequestHandler(method string, handler RequestHandler) {
 	c.requestHandlers[method] = handler
 }
 
+// AddFrameObserver registers an observer that sees every raw message body.
+// Observers should be registered before Start is called.
+func (c *Client) AddFrameObserver(observer FrameObserver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observers = append(c.observers, observer)
+}
+
+// notifyObservers passes a raw frame to all registered observers. Callers must hold c.mu
+// so that observers see frames in the order they hit the wire.
+func (c *Client) notifyObservers(direction FrameDirection, data []byte) {
+	for _, observer := range c.observers {
+		observer(direction, data)
+	}
+}
+
+// SetRequestObserver registers an observer that is notified when each request completes.
+func (c *Client) SetRequestObserver(observer RequestObserver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requestObserver = observer
+}
+
+// SetErrorMapper registers a function that converts errors returned by Request,
+// such as *Error and *ConnectionError, into caller-specific error types.
+func (c *Client) SetErrorMapper(mapper func(error) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorMapper = mapper
+}
+
+// SetProtocolErrorHandler registers a function that is called when the peer sends
+// data that is not a valid JSON-RPC message. It should be called before Start.
+func (c *Client) SetProtocolErrorHandler(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onProtocolError = handler
+}
+
+// SetValidator registers a function that checks the params and results of every
+// request and notification. It should be called before Start.
+func (c *Client) SetValidator(validator Validator) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.validator = validator
+}
+
+// SetRequestGate registers a gate that decides whether each request is sent.
+func (c *Client) SetRequestGate(gate RequestGate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gate = gate
+}
+
+// validate checks data with the validator, if any.
+func (c *Client) validate(method string, payload Payload, data json.RawMessage) error {
+	c.mu.Lock()
+	validator := c.validator
+	c.mu.Unlock()
+	if validator == nil {
+		return nil
+	}
+	return validator(method, payload, data)
+}
+
+// protocolError logs err and reports it to the protocol error handler, if any.
+func (c *Client) protocolError(err error) {
+	c.logger.Error("JSON-RPC protocol error", "error", err)
+	c.mu.Lock()
+	handler := c.onProtocolError
+	c.mu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
 // Request sends a JSON-RPC request and waits for the response
 func (c *Client) Request(method string, params any) (json.RawMessage, error) {
+	return c.RequestContext(context.Background(), method, params)
+}
+
+// RequestContext sends a JSON-RPC request and waits for the response or for ctx to be done.
+//
+// If ctx has a deadline, it is sent to the server as params._meta.deadline (RFC 3339)
+// so the server can enforce it. If ctx is done before the response arrives, a
+// $/cancelRequest notification is sent for the request and ctx.Err() is returned.
+func (c *Client) RequestContext(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.mu.Lock()
+	observer := c.requestObserver
+	mapper := c.errorMapper
+	gate := c.gate
+	c.mu.Unlock()
+
+	var done func(error)
+	if gate != nil {
+		var err error
+		if done, err = gate(method); err != nil {
+			if observer != nil {
+				observer(method, 0, err)
+			}
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	result, err := c.request(ctx, method, params)
+	if err != nil && mapper != nil {
+		err = mapper(err)
+	}
+	if done != nil {
+		done(err)
+	}
+	if observer != nil {
+		observer(method, time.Since(start), err)
+	}
+	return result, err
+}
+
+func (c *Client) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	requestID := generateUUID()
 
 	// Create response channel
@@ -202,9 +392,9 @@ func (c *Client) Request(method string, params any) (json.RawMessage, error) {
 		select {
 		case <-c.processDone:
 			if err := c.getProcessError(); err != nil {
-				return nil, err
+				return nil, &ConnectionError{Err: err}
 			}
-			return nil, fmt.Errorf("process exited unexpectedly")
+			return nil, &ConnectionError{Err: fmt.Errorf("process exited unexpectedly")}
 		default:
 			// Process still running, continue
 		}
@@ -214,6 +404,14 @@ func (c *Client) Request(method string, params any) (json.RawMessage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal params: %w", err)
 	}
+	if err := c.validate(method, RequestParams, paramsData); err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if paramsData, err = withDeadline(paramsData, deadline); err != nil {
+			return nil, err
+		}
+	}
 
 	// Send request
 	request := Request{
@@ -224,35 +422,83 @@ func (c *Client) Request(method string, params any) (json.RawMessage, error) {
 	}
 
 	if err := c.sendMessage(request); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, &ConnectionError{Err: fmt.Errorf("failed to send request: %w", err)}
 	}
 
 	// Wait for response, also checking for process exit
 	if c.processDone != nil {
 		select {
 		case response := <-responseChan:
-			if response.Error != nil {
-				return nil, response.Error
-			}
-			return response.Result, nil
+			return c.result(method, response)
 		case <-c.processDone:
 			if err := c.getProcessError(); err != nil {
-				return nil, err
+				return nil, &ConnectionError{Err: err}
 			}
-			return nil, fmt.Errorf("process exited unexpectedly")
+			return nil, &ConnectionError{Err: fmt.Errorf("process exited unexpectedly")}
 		case <-c.stopChan:
-			return nil, fmt.Errorf("client stopped")
+			return nil, &ConnectionError{Err: fmt.Errorf("client stopped")}
+		case <-ctx.Done():
+			c.cancelRequest(request.ID)
+			return nil, ctx.Err()
 		}
 	}
 	select {
 	case response := <-responseChan:
-		if response.Error != nil {
-			return nil, response.Error
-		}
-		return response.Result, nil
+		return c.result(method, response)
 	case <-c.stopChan:
-		return nil, fmt.Errorf("client stopped")
+		return nil, &ConnectionError{Err: fmt.Errorf("client stopped")}
+	case <-ctx.Done():
+		c.cancelRequest(request.ID)
+		return nil, ctx.Err()
+	}
+}
+
+// result returns the result of the response to a request for method.
+func (c *Client) result(method string, response *Response) (json.RawMessage, error) {
+	if response.Error != nil {
+		return nil, response.Error
+	}
+	if err := c.validate(method, RequestResult, response.Result); err != nil {
+		return nil, err
 	}
+	return response.Result, nil
+}
+
+// cancelRequest tells the server that the caller stopped waiting for the request with id.
+// Errors are ignored since the request has already been abandoned.
+func (c *Client) cancelRequest(id json.RawMessage) {
+	c.Notify("$/cancelRequest", map[string]json.RawMessage{"id": id})
+}
+
+// withDeadline adds the deadline to params as _meta.deadline. Params that are not
+// a JSON object or null are returned unchanged.
+func withDeadline(params json.RawMessage, deadline time.Time) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return params, nil
+	}
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+
+	meta := make(map[string]json.RawMessage)
+	if existing, ok := fields["_meta"]; ok {
+		if err := json.Unmarshal(existing, &meta); err != nil || meta == nil {
+			return params, nil
+		}
+	}
+	value, _ := json.Marshal(deadline.UTC().Format(time.RFC3339Nano))
+	meta["deadline"] = value
+
+	var err error
+	if fields["_meta"], err = json.Marshal(meta); err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return data, nil
 }
 
 // Notify sends a JSON-RPC notification (no response expected)
@@ -289,6 +535,7 @@ func (c *Client) sendMessage(message any) error {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
 
+	c.notifyObservers(FrameSent, data)
 	return nil
 }
 
@@ -298,15 +545,15 @@ func (c *Client) readLoop() {
 
 	reader := bufio.NewReader(c.stdout)
 
-	for c.running {
+	for c.running.Load() {
 		// Read Content-Length header
 		var contentLength int
 		for {
 			line, err := reader.ReadString('\n')
 			if err != nil {
 				// Only log unexpected errors (not EOF or closed pipe during shutdown)
-				if err != io.EOF && c.running {
-					fmt.Printf("Error reading header: %v\n", err)
+				if err != io.EOF && c.running.Load() {
+					c.logger.Error("failed to read JSON-RPC header", "error", err)
 				}
 				return
 			}
@@ -330,9 +577,14 @@ func (c *Client) readLoop() {
 		// Read message body
 		body := make([]byte, contentLength)
 		if _, err := io.ReadFull(reader, body); err != nil {
-			fmt.Printf("Error reading body: %v\n", err)
+			if c.running.Load() {
+				c.protocolError(fmt.Errorf("failed to read JSON-RPC body of %d bytes: %w", contentLength, err))
+			}
 			return
 		}
+		c.mu.Lock()
+		c.notifyObservers(FrameReceived, body)
+		c.mu.Unlock()
 
 		// Try to parse as request first (has both ID and Method)
 		var request Request
@@ -347,6 +599,8 @@ func (c *Client) readLoop() {
 			c.handleResponse(&response)
 			continue
 		}
+
+		c.protocolError(fmt.Errorf("received a message that is neither a JSON-RPC request nor a response: %.200s", body))
 	}
 }
 
@@ -380,6 +634,14 @@ func (c *Client) handleRequest(request *Request) {
 		return
 	}
 
+	if err := c.validate(request.Method, HandlerParams, request.Params); err != nil {
+		c.protocolError(err)
+		if request.IsCall() {
+			c.sendErrorResponse(request.ID, -32602, err.Error(), nil)
+		}
+		return
+	}
+
 	// Notifications run synchronously, calls run in a goroutine to avoid blocking
 	if !request.IsCall() {
 		handler(request.Params)
@@ -398,6 +660,11 @@ func (c *Client) handleRequest(request *Request) {
 			c.sendErrorResponse(request.ID, err.Code, err.Message, err.Data)
 			return
 		}
+		if err := c.validate(request.Method, HandlerResult, result); err != nil {
+			c.protocolError(err)
+			c.sendErrorResponse(request.ID, -32603, err.Error(), nil)
+			return
+		}
 		c.sendResponse(request.ID, result)
 	}()
 }
@@ -409,7 +676,7 @@ func (c *Client) sendResponse(id json.RawMessage, result json.RawMessage) {
 		Result:  result,
 	}
 	if err := c.sendMessage(response); err != nil {
-		fmt.Printf("Failed to send JSON-RPC response: %v\n", err)
+		c.logger.Error("failed to send JSON-RPC response", "error", err)
 	}
 }
 
@@ -424,7 +691,7 @@ func (c *Client) sendErrorResponse(id json.RawMessage, code int, message string,
 		},
 	}
 	if err := c.sendMessage(response); err != nil {
-		fmt.Printf("Failed to send JSON-RPC error response: %v\n", err)
+		c.logger.Error("failed to send JSON-RPC error response", "error", err)
 	}
 }
 
@@ -0,0 +1,91 @@
+// Package playbacktest builds replay logs for [copilot.NewPlaybackClient], so
+// that the tests of the SDK's packages can script the CLI's side of a
+// conversation without starting it.
+package playbacktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Log is a replay log under construction.
+type Log struct {
+	buf    bytes.Buffer
+	nextID int
+}
+
+// Write records a JSON-RPC message in direction "send" or "recv".
+func (l *Log) Write(direction string, message map[string]any) {
+	data, _ := json.Marshal(message)
+	line, _ := json.Marshal(copilot.ReplayRecord{Time: time.Now(), Direction: direction, Message: data})
+	l.buf.Write(append(line, '\n'))
+}
+
+// Call records a client request followed by the server's result.
+func (l *Log) Call(method string, result any) {
+	l.nextID++
+	id := strconv.Itoa(l.nextID)
+	l.Write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": map[string]any{}})
+	l.Write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+// CallError records a client request followed by the server's error.
+func (l *Log) CallError(method string, code int, message string) {
+	l.nextID++
+	id := strconv.Itoa(l.nextID)
+	l.Write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": map[string]any{}})
+	l.Write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": code, "message": message}})
+}
+
+// Event records a session.event notification.
+func (l *Log) Event(sessionID string, eventType copilot.SessionEventType, data map[string]any) {
+	l.Write("recv", map[string]any{"jsonrpc": "2.0", "method": "session.event", "params": map[string]any{
+		"sessionId": sessionID,
+		"event": map[string]any{
+			"id":        "evt-" + strconv.Itoa(l.nextID),
+			"timestamp": time.Now().Format(time.RFC3339),
+			"parentId":  nil,
+			"type":      eventType,
+			"data":      data,
+		},
+	}})
+}
+
+// Handshake records the ping issued by [copilot.Client.Start].
+func (l *Log) Handshake() {
+	l.Call("ping", map[string]any{"message": "pong", "timestamp": 1, "protocolVersion": copilot.GetSdkProtocolVersion()})
+}
+
+// NewClient returns a started client that plays back log, and stops it when
+// the test ends.
+func NewClient(t testing.TB, log *Log) *copilot.Client {
+	t.Helper()
+	client, err := copilot.NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start playback client: %v", err)
+	}
+	return client
+}
+
+// NewSession creates a session with config on a client that plays back log.
+// A nil config approves all permission requests.
+func NewSession(t testing.TB, log *Log, config *copilot.SessionConfig) *copilot.Session {
+	t.Helper()
+	if config == nil {
+		config = &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll}
+	}
+	session, err := NewClient(t, log).CreateSession(t.Context(), config)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	return session
+}
@@ -0,0 +1,438 @@
+package copilot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a job submitted with [Session.Submit].
+type JobStatus string
+
+const (
+	// JobRunning means the agent is still working on the job.
+	JobRunning JobStatus = "running"
+	// JobSucceeded means the session became idle after the job's message.
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed means the session reported an error or was destroyed.
+	JobFailed JobStatus = "failed"
+	// JobCanceled means the job was stopped with [JobHandle.Cancel].
+	JobCanceled JobStatus = "canceled"
+)
+
+// ErrJobNotFound is returned by [Client.Job] for an unknown job ID.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobRecord is the state of a job, as saved to ClientOptions.JobStore.
+type JobRecord struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"sessionId"`
+	MessageID string    `json:"messageId,omitempty"`
+	Status    JobStatus `json:"status"`
+	// After is the ID of the last persisted session event before the job's
+	// message, used to find the job's events in the session history.
+	After       string    `json:"after,omitempty"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	FinishedAt  time.Time `json:"finishedAt,omitzero"`
+	// Response is the final assistant message of a succeeded job, if any.
+	Response *SessionEvent `json:"response,omitempty"`
+	// Error describes why a job failed.
+	Error string `json:"error,omitempty"`
+}
+
+// JobStore persists jobs so they can be looked up with [Client.Job] after the
+// client restarts. Implementations must be safe for concurrent use.
+type JobStore interface {
+	// SaveJob creates or replaces the record with the same ID.
+	SaveJob(record JobRecord) error
+	// LoadJob returns the record with the given ID, or an error wrapping
+	// [ErrJobNotFound] if there is none.
+	LoadJob(id string) (JobRecord, error)
+}
+
+// FileJobStore is a [JobStore] that keeps each job in a JSON file in a
+// directory. Create one with [NewFileJobStore].
+type FileJobStore struct {
+	dir string
+}
+
+// NewFileJobStore returns a store that keeps jobs in dir, which is created on
+// the first save.
+func NewFileJobStore(dir string) *FileJobStore {
+	return &FileJobStore{dir: dir}
+}
+
+// SaveJob writes the record to <dir>/<id>.json.
+func (f *FileJobStore) SaveJob(record JobRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create job store directory: %w", err)
+	}
+	path := f.path(record.ID)
+	// Write a temporary file first so that a crash cannot leave a partial record
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write job %s: %w", record.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write job %s: %w", record.ID, err)
+	}
+	return nil
+}
+
+// LoadJob reads the record written by SaveJob.
+func (f *FileJobStore) LoadJob(id string) (JobRecord, error) {
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return JobRecord{}, fmt.Errorf("job %s: %w", id, ErrJobNotFound)
+	}
+	if err != nil {
+		return JobRecord{}, fmt.Errorf("failed to read job %s: %w", id, err)
+	}
+	var record JobRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return JobRecord{}, fmt.Errorf("failed to parse job %s: %w", id, err)
+	}
+	return record, nil
+}
+
+func (f *FileJobStore) path(id string) string {
+	return filepath.Join(f.dir, filepath.Base(id)+".json")
+}
+
+// JobHandle tracks a job submitted with [Session.Submit] or looked up with
+// [Client.Job]. It is safe for concurrent use.
+type JobHandle struct {
+	session  *Session // nil for jobs loaded after they finished
+	store    JobStore
+	registry *jobRegistry // removes the job once it is finished and saved
+	done     chan struct{}
+	saveMu   sync.Mutex // serializes writes to the store
+
+	mu         sync.Mutex
+	record     JobRecord
+	response   *SessionEvent // last assistant message so far
+	cancelling bool
+}
+
+// ID returns the job's ID, which can be passed to [Client.Job].
+func (j *JobHandle) ID() string {
+	return j.record.ID
+}
+
+// SessionID returns the ID of the session running the job.
+func (j *JobHandle) SessionID() string {
+	return j.record.SessionID
+}
+
+// Status returns the job's current status.
+func (j *JobHandle) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.record.Status
+}
+
+// Record returns a copy of the job's current state.
+func (j *JobHandle) Record() JobRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.record
+}
+
+// Wait blocks until the job finishes or ctx is done, and returns the final
+// assistant message. A failed or canceled job returns an error. The job keeps
+// running when ctx is done.
+func (j *JobHandle) Wait(ctx context.Context) (*SessionEvent, error) {
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for job %s: %w", j.record.ID, ctx.Err())
+	}
+	record := j.Record()
+	switch record.Status {
+	case JobFailed:
+		return nil, fmt.Errorf("job %s failed: %s", record.ID, record.Error)
+	case JobCanceled:
+		return nil, fmt.Errorf("job %s was canceled", record.ID)
+	}
+	return record.Response, nil
+}
+
+// Cancel aborts the job's message. The job is canceled once the session stops
+// processing it. Canceling a finished job does nothing.
+func (j *JobHandle) Cancel(ctx context.Context) error {
+	j.mu.Lock()
+	if j.record.Status != JobRunning || j.session == nil {
+		j.mu.Unlock()
+		return nil
+	}
+	j.cancelling = true
+	j.mu.Unlock()
+	return j.session.Abort(ctx)
+}
+
+// observe updates the job with a session event.
+func (j *JobHandle) observe(event SessionEvent) {
+	switch event.Type {
+	case AssistantMessage:
+		eventCopy := event
+		j.mu.Lock()
+		j.response = &eventCopy
+		j.mu.Unlock()
+	case SessionIdle:
+		j.finish(JobSucceeded, "")
+	case SessionError:
+		message := "session error"
+		if event.Data.Message != nil {
+			message = *event.Data.Message
+		}
+		j.finish(JobFailed, message)
+	}
+}
+
+// finish records the outcome of the job, if it has none yet, and saves it.
+func (j *JobHandle) finish(status JobStatus, message string) {
+	j.mu.Lock()
+	if j.record.Status != JobRunning {
+		j.mu.Unlock()
+		return
+	}
+	if j.cancelling && status == JobSucceeded {
+		status = JobCanceled
+	}
+	j.record.Status = status
+	j.record.Error = message
+	j.record.FinishedAt = time.Now().UTC()
+	if status == JobSucceeded {
+		j.record.Response = j.response
+	}
+	j.mu.Unlock()
+	j.save()
+	close(j.done)
+}
+
+// save writes the job to the store, if any.
+func (j *JobHandle) save() {
+	if j.store == nil {
+		return
+	}
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+	record := j.Record()
+	if err := j.store.SaveJob(record); err != nil && j.session != nil {
+		j.session.logger.Warn("failed to save job", "job", record.ID, "error", err)
+	}
+}
+
+// watch finishes the job if the session is destroyed first.
+func (j *JobHandle) watch(unsubscribe func(), release func()) {
+	select {
+	case <-j.done:
+	case <-j.session.destroyed:
+		j.finish(JobFailed, "session was destroyed")
+	}
+	unsubscribe()
+	if release != nil {
+		release()
+	}
+	// Finished jobs can be loaded from the store, so only keep them in memory without one
+	if j.store != nil && j.registry != nil {
+		j.registry.remove(j.record.ID)
+	}
+}
+
+// Submit sends a message as a background job and returns as soon as the CLI has
+// accepted it, so that a worker can run a multi-minute agent task without
+// holding a blocking [Session.SendAndWait] call. Poll [JobHandle.Status] or
+// call [JobHandle.Wait] for the outcome.
+//
+// The job holds the session's turn like SendAndWait: later SendAndWait and
+// Submit calls wait for it to finish. ctx bounds the send only; the job keeps
+// running after it is done. options.Timeout is ignored.
+//
+// When ClientOptions.JobStore is set, the job is saved there and can be looked
+// up by ID with [Client.Job] after the client restarts, provided the CLI kept
+// running, e.g. a shared server connected with CLIUrl.
+//
+// Example:
+//
+//	job, err := session.Submit(ctx, copilot.MessageOptions{Prompt: "Migrate the tests to testify"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	queue.Publish(job.ID())
+//	// ... later, possibly in another process
+//	job, err = client.Job(ctx, id, &copilot.ResumeSessionConfig{OnPermissionRequest: policy})
+//	response, err := job.Wait(ctx)
+func (s *Session) Submit(ctx context.Context, options MessageOptions) (*JobHandle, error) {
+	if err := s.acquireTurn(ctx, newProgressReporter(options.OnProgress)); err != nil {
+		return nil, err
+	}
+	release := func() { <-s.turn }
+
+	id, err := newJobID()
+	if err != nil {
+		release()
+		return nil, err
+	}
+	s.sequence.mu.Lock()
+	after := s.sequence.lastPersisted
+	s.sequence.mu.Unlock()
+
+	job := &JobHandle{
+		session: s,
+		store:   s.jobStore,
+		done:    make(chan struct{}),
+		record: JobRecord{
+			ID:          id,
+			SessionID:   s.SessionID,
+			Status:      JobRunning,
+			After:       after,
+			SubmittedAt: time.Now().UTC(),
+		},
+	}
+	unsubscribe := s.On(job.observe)
+
+	options.Timeout = 0
+	messageID, _, _, err := s.send(ctx, options)
+	if err != nil {
+		unsubscribe()
+		release()
+		return nil, err
+	}
+	job.mu.Lock()
+	job.record.MessageID = messageID
+	job.mu.Unlock()
+
+	if s.jobs != nil {
+		job.registry = s.jobs
+		s.jobs.add(job)
+	}
+	job.save()
+	go job.watch(unsubscribe, release)
+	s.logger.Info("job submitted", "job", id)
+	return job, nil
+}
+
+// Job returns the job with the given ID, submitted by this client or, when
+// ClientOptions.JobStore is set, by an earlier one. A running job from an
+// earlier client is reattached by resuming its session with config, and is
+// finished from the session history if it completed while no client watched it.
+// Returns an error wrapping [ErrJobNotFound] for an unknown ID.
+func (c *Client) Job(ctx context.Context, id string, config *ResumeSessionConfig) (*JobHandle, error) {
+	if job, ok := c.jobs.get(id); ok {
+		return job, nil
+	}
+	if c.options.JobStore == nil {
+		return nil, fmt.Errorf("job %s: %w", id, ErrJobNotFound)
+	}
+	record, err := c.options.JobStore.LoadJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &JobHandle{store: c.options.JobStore, done: make(chan struct{}), record: record}
+	if record.Status != JobRunning {
+		close(job.done)
+		return job, nil
+	}
+
+	c.sessionsMux.Lock()
+	session, ok := c.sessions[record.SessionID]
+	c.sessionsMux.Unlock()
+	if !ok {
+		session, err = c.ResumeSession(ctx, record.SessionID, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resume session of job %s: %w", id, err)
+		}
+	}
+	job.session = session
+
+	// Subscribe before reading the history so that the end of the job cannot be missed
+	unsubscribe := session.On(job.observe)
+	events, err := session.GetMessages(ctx)
+	if err != nil {
+		unsubscribe()
+		return nil, fmt.Errorf("failed to read history of job %s: %w", id, err)
+	}
+	job.replay(events)
+	job.registry = &c.jobs
+	c.jobs.add(job)
+	go job.watch(unsubscribe, nil)
+	return job, nil
+}
+
+// replay finishes the job from the session history if it ended while no
+// client was watching. History only holds persisted events, so a job counts
+// as succeeded when its events end with an assistant.turn_end.
+func (j *JobHandle) replay(events []SessionEvent) {
+	start := 0
+	if j.record.After != "" {
+		for i, event := range events {
+			if event.ID == j.record.After {
+				start = i + 1
+				break
+			}
+		}
+	}
+	events = events[start:]
+	for _, event := range events {
+		if event.Type == AssistantMessage || event.Type == SessionError {
+			j.observe(event)
+		}
+		if event.Type == Abort {
+			j.finish(JobCanceled, "")
+		}
+	}
+	if len(events) > 0 && events[len(events)-1].Type == AssistantTurnEnd {
+		j.finish(JobSucceeded, "")
+	}
+}
+
+// jobRegistry holds the jobs of a client by ID.
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*JobHandle
+}
+
+func (r *jobRegistry) add(job *JobHandle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs == nil {
+		r.jobs = make(map[string]*JobHandle)
+	}
+	r.jobs[job.record.ID] = job
+}
+
+func (r *jobRegistry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, id)
+}
+
+func (r *jobRegistry) get(id string) (*JobHandle, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return "job-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,132 @@
+package copilot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSession_Submit(t *testing.T) {
+	newSession := func(t *testing.T, log *replayLog, store JobStore) (*Client, *Session) {
+		t.Helper()
+		client := newPlaybackClientForTest(t, log, nil)
+		client.options.JobStore = store
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return client, session
+	}
+
+	t.Run("runs the message in the background", func(t *testing.T) {
+		store := NewFileJobStore(t.TempDir())
+		client, session := newSession(t, simpleConversationLog(), store)
+
+		job, err := session.Submit(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+		response, err := job.Wait(t.Context())
+		if err != nil {
+			t.Fatalf("Job failed: %v", err)
+		}
+		if response == nil || *response.Data.Content != "4" || job.Status() != JobSucceeded {
+			t.Errorf("Unexpected outcome: %v, %+v", job.Status(), response)
+		}
+
+		record, err := store.LoadJob(job.ID())
+		if err != nil {
+			t.Fatalf("Failed to load job: %v", err)
+		}
+		if record.Status != JobSucceeded || record.SessionID != "s1" || record.MessageID != "m1" || *record.Response.Data.Content != "4" {
+			t.Errorf("Unexpected saved record: %+v", record)
+		}
+		found, err := client.Job(t.Context(), job.ID(), nil)
+		if err != nil || found.Status() != JobSucceeded {
+			t.Errorf("Expected the finished job to be found, got %v, %v", found, err)
+		}
+	})
+
+	t.Run("cancels the job", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.call("session.abort", map[string]any{})
+		log.event("s1", SessionIdle, map[string]any{})
+		_, session := newSession(t, log, nil)
+
+		job, err := session.Submit(t.Context(), MessageOptions{Prompt: "Refactor everything"})
+		if err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+		if job.Status() != JobRunning {
+			t.Errorf("Expected the job to be running, got %v", job.Status())
+		}
+		if err := job.Cancel(t.Context()); err != nil {
+			t.Fatalf("Failed to cancel: %v", err)
+		}
+		if _, err := job.Wait(t.Context()); err == nil || job.Status() != JobCanceled {
+			t.Errorf("Expected the job to be canceled, got %v, %v", job.Status(), err)
+		}
+	})
+
+	t.Run("releases the turn when finished", func(t *testing.T) {
+		_, session := newSession(t, simpleConversationLog(), nil)
+		job, err := session.Submit(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+		job.Wait(t.Context())
+		deadline := time.Now().Add(time.Second)
+		for len(session.turn) != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if len(session.turn) != 0 {
+			t.Error("Expected the session's turn to be released")
+		}
+	})
+}
+
+func TestClient_Job(t *testing.T) {
+	t.Run("finishes a job from history after a restart", func(t *testing.T) {
+		store := NewFileJobStore(t.TempDir())
+		store.SaveJob(JobRecord{ID: "job-1", SessionID: "s1", Status: JobRunning, After: "e1"})
+
+		str := func(s string) *string { return &s }
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.resume", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": []SessionEvent{
+			{ID: "e1", Type: AssistantMessage, Data: Data{Content: str("Earlier answer")}},
+			{ID: "e2", Type: UserMessage, Data: Data{Content: str("Migrate the tests")}},
+			{ID: "e3", Type: AssistantMessage, Data: Data{Content: str("Migrated 12 tests")}},
+			{ID: "e4", Type: AssistantTurnEnd},
+		}})
+		client := newPlaybackClientForTest(t, log, nil)
+		client.options.JobStore = store
+
+		job, err := client.Job(t.Context(), "job-1", &ResumeSessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to find job: %v", err)
+		}
+		response, err := job.Wait(t.Context())
+		if err != nil || *response.Data.Content != "Migrated 12 tests" {
+			t.Errorf("Unexpected outcome: %+v, %v", response, err)
+		}
+		if record, _ := store.LoadJob("job-1"); record.Status != JobSucceeded {
+			t.Errorf("Expected the outcome to be saved, got %+v", record)
+		}
+	})
+
+	t.Run("reports unknown jobs", func(t *testing.T) {
+		client := NewClient(nil)
+		if _, err := client.Job(t.Context(), "job-x", nil); !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("Expected ErrJobNotFound, got %v", err)
+		}
+		client.options.JobStore = NewFileJobStore(t.TempDir())
+		if _, err := client.Job(t.Context(), "job-x", nil); !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("Expected ErrJobNotFound from the store, got %v", err)
+		}
+	})
+}
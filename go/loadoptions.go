@@ -0,0 +1,215 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the on-disk form of the ClientOptions supported by [LoadOptions].
+type configFile struct {
+	CLIPath            *string   `json:"cliPath" yaml:"cliPath"`
+	CLIArgs            []string  `json:"cliArgs" yaml:"cliArgs"`
+	Cwd                *string   `json:"cwd" yaml:"cwd"`
+	CLIUrl             *string   `json:"cliUrl" yaml:"cliUrl"`
+	SocketPath         *string   `json:"socketPath" yaml:"socketPath"`
+	Port               *int      `json:"port" yaml:"port"`
+	UseStdio           *bool     `json:"useStdio" yaml:"useStdio"`
+	LogLevel           *string   `json:"logLevel" yaml:"logLevel"`
+	AutoStart          *bool     `json:"autoStart" yaml:"autoStart"`
+	AutoRestart        *bool     `json:"autoRestart" yaml:"autoRestart"`
+	GitHubToken        *string   `json:"githubToken" yaml:"githubToken"`
+	UseLoggedInUser    *bool     `json:"useLoggedInUser" yaml:"useLoggedInUser"`
+	GitHubHost         *string   `json:"githubHost" yaml:"githubHost"`
+	SessionIdleTimeout *duration `json:"sessionIdleTimeout" yaml:"sessionIdleTimeout"`
+	ApprovalTimeout    *duration `json:"approvalTimeout" yaml:"approvalTimeout"`
+}
+
+// duration accepts time.ParseDuration strings such as "30s" or "15m".
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// LoadOptions builds ClientOptions from an optional config file and COPILOT_SDK_*
+// environment variables, so deployments can change settings without recompiling.
+// Environment variables take precedence over the file.
+//
+// path names a JSON (.json) or YAML (any other extension) file. If path is empty,
+// the file named by COPILOT_SDK_CONFIG is used, if set. Unknown keys are an error.
+//
+// Supported settings, as file key and environment variable:
+//
+//	cliPath             COPILOT_SDK_CLI_PATH
+//	cliArgs             COPILOT_SDK_CLI_ARGS (space-separated)
+//	cwd                 COPILOT_SDK_CWD
+//	cliUrl              COPILOT_SDK_CLI_URL
+//	socketPath          COPILOT_SDK_SOCKET_PATH
+//	port                COPILOT_SDK_PORT
+//	useStdio            COPILOT_SDK_USE_STDIO
+//	logLevel            COPILOT_SDK_LOG_LEVEL
+//	autoStart           COPILOT_SDK_AUTO_START
+//	autoRestart         COPILOT_SDK_AUTO_RESTART
+//	githubToken         COPILOT_SDK_GITHUB_TOKEN
+//	useLoggedInUser     COPILOT_SDK_USE_LOGGED_IN_USER
+//	githubHost          COPILOT_SDK_GITHUB_HOST
+//	sessionIdleTimeout  COPILOT_SDK_SESSION_IDLE_TIMEOUT (e.g. "30m")
+//	approvalTimeout     COPILOT_SDK_APPROVAL_TIMEOUT
+//
+// Options that hold Go values, such as Logger or MetricsRegistry, can be set on
+// the result before passing it to [NewClient].
+//
+// Example:
+//
+//	options, err := copilot.LoadOptions("copilot.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	options.Logger = slog.Default()
+//	client := copilot.NewClient(options)
+func LoadOptions(path string) (*ClientOptions, error) {
+	var config configFile
+	if path == "" {
+		path = os.Getenv("COPILOT_SDK_CONFIG")
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := parseConfigFile(path, data, &config); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	// NewClient panics on these combinations; report them as configuration errors instead
+	if config.CLIUrl != nil && (config.UseStdio != nil || config.CLIPath != nil) {
+		return nil, fmt.Errorf("cliUrl is mutually exclusive with useStdio and cliPath")
+	}
+	if config.SocketPath != nil && (config.CLIUrl != nil || config.UseStdio != nil || config.CLIPath != nil || config.Port != nil) {
+		return nil, fmt.Errorf("socketPath is mutually exclusive with cliUrl, useStdio, cliPath, and port")
+	}
+
+	options := &ClientOptions{
+		CLIArgs:         config.CLIArgs,
+		Port:            valueOf(config.Port),
+		UseStdio:        config.UseStdio,
+		AutoStart:       config.AutoStart,
+		AutoRestart:     config.AutoRestart,
+		UseLoggedInUser: config.UseLoggedInUser,
+		CLIPath:         valueOf(config.CLIPath),
+		Cwd:             valueOf(config.Cwd),
+		CLIUrl:          valueOf(config.CLIUrl),
+		SocketPath:      valueOf(config.SocketPath),
+		LogLevel:        valueOf(config.LogLevel),
+		GitHubToken:     valueOf(config.GitHubToken),
+		GitHubHost:      valueOf(config.GitHubHost),
+	}
+	if config.SessionIdleTimeout != nil {
+		options.SessionIdleTimeout = time.Duration(*config.SessionIdleTimeout)
+	}
+	if config.ApprovalTimeout != nil {
+		options.ApprovalTimeout = time.Duration(*config.ApprovalTimeout)
+	}
+	return options, nil
+}
+
+func parseConfigFile(path string, data []byte, config *configFile) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(config)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// applyEnv overrides config with COPILOT_SDK_* environment variables.
+func (c *configFile) applyEnv() error {
+	var errs []string
+	str := func(name string, target **string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = &value
+		}
+	}
+	boolean := func(name string, target **bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+			*target = &parsed
+		}
+	}
+	dur := func(name string, target **duration) {
+		if value, ok := os.LookupEnv(name); ok {
+			var parsed duration
+			if err := parsed.UnmarshalText([]byte(value)); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	str("COPILOT_SDK_CLI_PATH", &c.CLIPath)
+	str("COPILOT_SDK_CWD", &c.Cwd)
+	str("COPILOT_SDK_CLI_URL", &c.CLIUrl)
+	str("COPILOT_SDK_SOCKET_PATH", &c.SocketPath)
+	str("COPILOT_SDK_LOG_LEVEL", &c.LogLevel)
+	str("COPILOT_SDK_GITHUB_TOKEN", &c.GitHubToken)
+	str("COPILOT_SDK_GITHUB_HOST", &c.GitHubHost)
+	if value, ok := os.LookupEnv("COPILOT_SDK_CLI_ARGS"); ok {
+		c.CLIArgs = strings.Fields(value)
+	}
+	if value, ok := os.LookupEnv("COPILOT_SDK_PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("COPILOT_SDK_PORT: %v", err))
+		} else {
+			c.Port = &port
+		}
+	}
+	boolean("COPILOT_SDK_USE_STDIO", &c.UseStdio)
+	boolean("COPILOT_SDK_AUTO_START", &c.AutoStart)
+	boolean("COPILOT_SDK_AUTO_RESTART", &c.AutoRestart)
+	boolean("COPILOT_SDK_USE_LOGGED_IN_USER", &c.UseLoggedInUser)
+	dur("COPILOT_SDK_SESSION_IDLE_TIMEOUT", &c.SessionIdleTimeout)
+	dur("COPILOT_SDK_APPROVAL_TIMEOUT", &c.ApprovalTimeout)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func valueOf[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,114 @@
+package copilot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadOptions(t *testing.T) {
+	writeConfig := func(t *testing.T, name, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		return path
+	}
+
+	t.Run("reads a YAML file", func(t *testing.T) {
+		path := writeConfig(t, "copilot.yaml", `
+cliPath: /opt/copilot/bin/copilot
+cliArgs: [--verbose]
+useStdio: false
+port: 9000
+logLevel: debug
+sessionIdleTimeout: 30m
+`)
+		options, err := LoadOptions(path)
+		if err != nil {
+			t.Fatalf("Failed to load options: %v", err)
+		}
+		if options.CLIPath != "/opt/copilot/bin/copilot" || options.Port != 9000 || options.LogLevel != "debug" {
+			t.Errorf("Unexpected options: %+v", options)
+		}
+		if options.UseStdio == nil || *options.UseStdio || !reflect.DeepEqual(options.CLIArgs, []string{"--verbose"}) {
+			t.Errorf("Unexpected transport options: %+v", options)
+		}
+		if options.SessionIdleTimeout != 30*time.Minute {
+			t.Errorf("Expected 30m idle timeout, got %s", options.SessionIdleTimeout)
+		}
+	})
+
+	t.Run("reads a JSON file named by COPILOT_SDK_CONFIG", func(t *testing.T) {
+		path := writeConfig(t, "copilot.json", `{"cliUrl": "localhost:8080", "approvalTimeout": "2m"}`)
+		t.Setenv("COPILOT_SDK_CONFIG", path)
+		options, err := LoadOptions("")
+		if err != nil {
+			t.Fatalf("Failed to load options: %v", err)
+		}
+		if options.CLIUrl != "localhost:8080" || options.ApprovalTimeout != 2*time.Minute {
+			t.Errorf("Unexpected options: %+v", options)
+		}
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		path := writeConfig(t, "copilot.yaml", "logLevel: info\nautoRestart: true\n")
+		t.Setenv("COPILOT_SDK_LOG_LEVEL", "error")
+		t.Setenv("COPILOT_SDK_AUTO_RESTART", "false")
+		t.Setenv("COPILOT_SDK_CLI_ARGS", "--a --b")
+		options, err := LoadOptions(path)
+		if err != nil {
+			t.Fatalf("Failed to load options: %v", err)
+		}
+		if options.LogLevel != "error" || options.AutoRestart == nil || *options.AutoRestart {
+			t.Errorf("Expected environment to win, got %+v", options)
+		}
+		if !reflect.DeepEqual(options.CLIArgs, []string{"--a", "--b"}) {
+			t.Errorf("Expected CLI args from environment, got %v", options.CLIArgs)
+		}
+	})
+
+	t.Run("works without a file", func(t *testing.T) {
+		t.Setenv("COPILOT_SDK_PORT", "7000")
+		options, err := LoadOptions("")
+		if err != nil {
+			t.Fatalf("Failed to load options: %v", err)
+		}
+		if options.Port != 7000 {
+			t.Errorf("Expected port from environment, got %d", options.Port)
+		}
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		cases := map[string]func(t *testing.T) string{
+			"unknown key": func(t *testing.T) string { return writeConfig(t, "c.yaml", "cliPth: copilot\n") },
+			"bad duration": func(t *testing.T) string {
+				return writeConfig(t, "c.json", `{"sessionIdleTimeout": "soon"}`)
+			},
+			"bad env": func(t *testing.T) string {
+				t.Setenv("COPILOT_SDK_USE_STDIO", "maybe")
+				return ""
+			},
+			"conflicting transport": func(t *testing.T) string {
+				return writeConfig(t, "c.yaml", "cliUrl: localhost:8080\ncliPath: copilot\n")
+			},
+			"conflicting socket": func(t *testing.T) string {
+				return writeConfig(t, "c.yaml", "socketPath: /tmp/copilot.sock\nport: 4321\n")
+			},
+			"missing file": func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.yaml") },
+		}
+		for name, setup := range cases {
+			t.Run(name, func(t *testing.T) {
+				if _, err := LoadOptions(setup(t)); err == nil {
+					t.Error("Expected error")
+				} else if name == "bad env" && !strings.Contains(err.Error(), "COPILOT_SDK_USE_STDIO") {
+					t.Errorf("Expected error to name the variable, got %v", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package copilot
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// localePattern matches BCP 47 language tags such as "fr", "pt-BR", or
+// "zh-Hant-TW": a 2 or 3 letter language followed by script, region, or
+// variant subtags.
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// validateLocale rejects a SessionConfig.Locale or MessageOptions.Locale that
+// is not a BCP 47 language tag.
+func validateLocale(locale string) error {
+	if locale == "" || localePattern.MatchString(locale) {
+		return nil
+	}
+	return fmt.Errorf("invalid Locale %q: must be a BCP 47 language tag such as \"en-US\" or \"fr\"", locale)
+}
+
+// localeInstruction is the instruction that asks the model to answer in locale.
+func localeInstruction(locale string) string {
+	return fmt.Sprintf("Respond in the language of the locale %q, using its conventions for dates, numbers, and currency, unless the user explicitly asks for another language. Keep code, identifiers, and command output unchanged.", locale)
+}
+
+// withLocale returns the system message of a session with the instruction for
+// locale appended, leaving systemMessage itself unchanged.
+func withLocale(systemMessage *SystemMessageConfig, locale string) *SystemMessageConfig {
+	if locale == "" {
+		return systemMessage
+	}
+	if systemMessage == nil {
+		return &SystemMessageConfig{Mode: "append", Content: localeInstruction(locale)}
+	}
+	localized := *systemMessage
+	if localized.Content == "" {
+		localized.Content = localeInstruction(locale)
+	} else {
+		localized.Content += "\n\n" + localeInstruction(locale)
+	}
+	return &localized
+}
+
+// Locale returns the locale the session answers in: SessionConfig.Locale, or
+// the Locale of ResumeSessionConfig when the session was resumed. It is empty
+// when no locale was set.
+func (s *Session) Locale() string {
+	return s.locale
+}
+
+// localizePrompt adds the instruction for a MessageOptions.Locale that differs
+// from the session's locale to the prompt, since the system message cannot
+// change for one message.
+func (s *Session) localizePrompt(options *MessageOptions) {
+	if options.Locale == "" || options.Locale == s.locale {
+		return
+	}
+	options.Prompt += "\n\n" + localeInstruction(options.Locale)
+}
@@ -0,0 +1,91 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSession_Locale(t *testing.T) {
+	t.Run("sends the locale with the session and its messages", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		systemMessage := &SystemMessageConfig{Mode: "append", Content: "Be brief."}
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			SystemMessage:       systemMessage,
+			Locale:              "fr-FR",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if session.Locale() != "fr-FR" {
+			t.Errorf("Expected Locale fr-FR, got %q", session.Locale())
+		}
+		if systemMessage.Content != "Be brief." {
+			t.Errorf("Expected the caller's system message to be unchanged, got %q", systemMessage.Content)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Bonjour"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hallo", Locale: "de"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		var sends []map[string]any
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			switch message.Method {
+			case "session.create":
+				content, _ := message.Params["systemMessage"].(map[string]any)["content"].(string)
+				if message.Params["locale"] != "fr-FR" || !strings.HasPrefix(content, "Be brief.\n\n") || !strings.Contains(content, `"fr-FR"`) {
+					t.Errorf("Unexpected create params: %v", message.Params)
+				}
+			case "session.send":
+				sends = append(sends, message.Params)
+			}
+		}
+		if len(sends) != 2 {
+			t.Fatalf("Expected two sends, got %d", len(sends))
+		}
+		if sends[0]["locale"] != "fr-FR" || sends[0]["prompt"] != "Bonjour" {
+			t.Errorf("Expected the session locale without a prompt instruction, got %v", sends[0])
+		}
+		if prompt, _ := sends[1]["prompt"].(string); sends[1]["locale"] != "de" || !strings.HasPrefix(prompt, "Hallo\n\n") || !strings.Contains(prompt, `"de"`) {
+			t.Errorf("Expected the message locale with a prompt instruction, got %v", sends[1])
+		}
+	})
+
+	t.Run("rejects invalid locales", func(t *testing.T) {
+		client := NewClient(nil)
+		if _, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Locale: "French"}); err == nil || !strings.Contains(err.Error(), "invalid Locale") {
+			t.Errorf("Expected an invalid locale error, got %v", err)
+		}
+		session := newSession("s1", nil, "")
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi", Locale: "en_US"}); err == nil || !strings.Contains(err.Error(), "invalid Locale") {
+			t.Errorf("Expected an invalid locale error, got %v", err)
+		}
+	})
+
+	t.Run("adds the instruction without a system message", func(t *testing.T) {
+		systemMessage := withLocale(nil, "pt-BR")
+		if systemMessage.Mode != "append" || !strings.Contains(systemMessage.Content, `"pt-BR"`) {
+			t.Errorf("Unexpected system message: %+v", systemMessage)
+		}
+		if withLocale(nil, "") != nil {
+			t.Error("Expected no system message without a locale")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package copilot
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/redact"
+)
+
+// setupLogging attaches the client logger to the JSON-RPC transport. RPC traffic is
+// only logged, with credentials redacted, when the logger is enabled for debug.
+func (c *Client) setupLogging() {
+	logger := c.options.Logger
+	c.client.SetLogger(logger)
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	c.client.AddFrameObserver(func(direction jsonrpc2.FrameDirection, data []byte) {
+		logger.Debug("rpc frame", "direction", string(direction), "message", redactFrame(c.options.Redactor, data))
+	})
+}
+
+// logRPC logs a completed JSON-RPC request.
+func (c *Client) logRPC(method string, duration time.Duration, err error) {
+	if err != nil {
+		c.options.Logger.Warn("rpc request failed", "method", method, "duration", duration, "error", err)
+		return
+	}
+	c.options.Logger.Debug("rpc request", "method", method, "duration", duration)
+}
+
+// sessionLogger returns the client logger annotated with the session's ID and metadata.
+func (c *Client) sessionLogger(session *Session) *slog.Logger {
+	logger := c.options.Logger.With("sessionId", session.SessionID)
+	if metadata := session.Metadata(); len(metadata) > 0 {
+		attrs := make([]any, 0, len(metadata))
+		for _, key := range slices.Sorted(maps.Keys(metadata)) {
+			attrs = append(attrs, slog.String(key, metadata[key]))
+		}
+		logger = logger.With(slog.Group("metadata", attrs...))
+	}
+	return logger
+}
+
+// redactFrame returns a JSON-RPC message body with credential-like fields and
+// secrets in values replaced. A nil redactor means [redact.Default].
+func redactFrame(redactor *redact.Redactor, data []byte) string {
+	if redactor == nil {
+		redactor = redact.Default()
+	}
+	return string(redactor.JSON(data))
+}
@@ -0,0 +1,88 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestClient_Logger(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.destroy", map[string]any{})
+
+	var output bytes.Buffer
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	client.options.Logger = slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+
+	session, err := client.CreateSession(t.Context(), &SessionConfig{
+		OnPermissionRequest: PermissionHandler.ApproveAll,
+		Metadata:            map[string]string{"tenant": "acme"},
+		Provider:            &ProviderConfig{Type: "openai", BaseURL: "https://example.com", APIKey: "sk-secret"},
+		Model:               "gpt-4",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("Failed to destroy session: %v", err)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	find := func(msg string) map[string]any {
+		for _, record := range records {
+			if record["msg"] == msg {
+				return record
+			}
+		}
+		t.Fatalf("Expected %q to be logged, got %s", msg, output.String())
+		return nil
+	}
+
+	find("connected to CLI")
+	find("rpc request")
+	created := find("session created")
+	metadata, _ := created["metadata"].(map[string]any)
+	if created["sessionId"] != "s1" || metadata["tenant"] != "acme" {
+		t.Errorf("Expected session attributes on session logs, got %v", created)
+	}
+	if destroyed := find("session destroyed"); destroyed["sessionId"] != "s1" {
+		t.Errorf("Expected session ID on destroy log, got %v", destroyed)
+	}
+	if strings.Contains(output.String(), "sk-secret") {
+		t.Error("Expected API key to be redacted from RPC logs")
+	}
+	if !strings.Contains(output.String(), "[REDACTED]") {
+		t.Error("Expected redacted RPC frame to be logged")
+	}
+}
+
+func TestRedactFrame(t *testing.T) {
+	frame := `{"params":{"githubToken":"ghp_x","provider":{"apiKey":"sk"},"headers":[{"Authorization":"Bearer y"}],"outputTokens":12}}`
+	redacted := redactFrame(nil, []byte(frame))
+	for _, secret := range []string{"ghp_x", `"sk"`, "Bearer y"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, `"outputTokens":12`) {
+		t.Errorf("Expected token counts to be kept, got %s", redacted)
+	}
+}
@@ -0,0 +1,354 @@
+package copilot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeatureMemory is the CLI feature flag, reported by [Client.Capabilities], for
+// long-term memory through the session.memory RPCs.
+const FeatureMemory = "memory"
+
+// MemoryScope is who a [Memory] applies to.
+type MemoryScope string
+
+const (
+	// MemoryScopeUser memories apply to the user in every workspace.
+	MemoryScopeUser MemoryScope = "user"
+	// MemoryScopeWorkspace memories apply only to sessions in the same workspace.
+	MemoryScopeWorkspace MemoryScope = "workspace"
+)
+
+// Memory is a durable fact, such as a preference or a project convention, that
+// the agent keeps across sessions.
+type Memory struct {
+	// ID identifies the memory.
+	ID string `json:"id"`
+	// Content is the text of the memory.
+	Content string `json:"content"`
+	// Scope is who the memory applies to.
+	Scope MemoryScope `json:"scope"`
+	// Workspace is the directory a [MemoryScopeWorkspace] memory belongs to.
+	// It is only set for memories kept in a [MemoryStore].
+	Workspace string `json:"workspace,omitempty"`
+	// Tags are labels for organizing memories.
+	Tags []string `json:"tags,omitempty"`
+	// CreatedAt is when the memory was stored.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RememberOptions configures [Session.Remember].
+type RememberOptions struct {
+	// Scope is who the memory applies to (default: [MemoryScopeUser]).
+	Scope MemoryScope
+	// Tags are labels for organizing memories.
+	Tags []string
+}
+
+// MemoryStore keeps memories in the SDK for CLIs that do not support
+// [FeatureMemory]. Set it with ClientOptions.MemoryStore. Implementations must
+// be safe for concurrent use.
+type MemoryStore interface {
+	// LoadMemories returns every stored memory, oldest first.
+	LoadMemories() ([]Memory, error)
+	// SaveMemory adds memory, replacing a memory with the same ID.
+	SaveMemory(memory Memory) error
+	// DeleteMemory removes the memory with the given ID, if any.
+	DeleteMemory(id string) error
+}
+
+// FileMemoryStore is a [MemoryStore] backed by a JSON file.
+type FileMemoryStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileMemoryStore returns a store backed by the JSON file at path.
+// The file and its directory are created on the first save.
+//
+// Example:
+//
+//	dir, _ := os.UserConfigDir()
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    MemoryStore: copilot.NewFileMemoryStore(filepath.Join(dir, "myapp", "memories.json")),
+//	})
+func NewFileMemoryStore(path string) *FileMemoryStore {
+	return &FileMemoryStore{path: path}
+}
+
+// LoadMemories reads the file, returning no memories if it does not exist.
+func (f *FileMemoryStore) LoadMemories() ([]Memory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// SaveMemory adds memory to the file.
+func (f *FileMemoryStore) SaveMemory(memory Memory) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	memories, err := f.load()
+	if err != nil {
+		return err
+	}
+	memories = slices.DeleteFunc(memories, func(m Memory) bool { return m.ID == memory.ID })
+	return f.write(append(memories, memory))
+}
+
+// DeleteMemory removes the memory with the given ID from the file.
+func (f *FileMemoryStore) DeleteMemory(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	memories, err := f.load()
+	if err != nil {
+		return err
+	}
+	return f.write(slices.DeleteFunc(memories, func(m Memory) bool { return m.ID == id }))
+}
+
+func (f *FileMemoryStore) load() ([]Memory, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory store: %w", err)
+	}
+	var memories []Memory
+	if err := json.Unmarshal(data, &memories); err != nil {
+		return nil, fmt.Errorf("failed to parse memory store %s: %w", f.path, err)
+	}
+	return memories, nil
+}
+
+func (f *FileMemoryStore) write(memories []Memory) error {
+	if memories == nil {
+		memories = []Memory{}
+	}
+	data, err := json.MarshalIndent(memories, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create memory store directory: %w", err)
+	}
+	// Write a temporary file first so that a crash cannot leave a partial file
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write memory store: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write memory store: %w", err)
+	}
+	return nil
+}
+
+// Remember stores content as a long-term memory, so the agent knows it in this
+// and future sessions.
+//
+// When the CLI supports [FeatureMemory], the CLI keeps the memory. Otherwise it
+// is saved to ClientOptions.MemoryStore and added to this session's context;
+// new sessions receive the stored memories when they are created or resumed.
+// Without either, Remember returns an error.
+//
+// Example:
+//
+//	session.Remember(ctx, "Use tabs for indentation in this repository", &copilot.RememberOptions{
+//	    Scope: copilot.MemoryScopeWorkspace,
+//	})
+func (s *Session) Remember(ctx context.Context, content string, options *RememberOptions) (*Memory, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("memory content is required")
+	}
+	scope := MemoryScopeUser
+	var tags []string
+	if options != nil {
+		if options.Scope != "" {
+			scope = options.Scope
+		}
+		tags = options.Tags
+	}
+	if scope != MemoryScopeUser && scope != MemoryScopeWorkspace {
+		return nil, fmt.Errorf("invalid memory scope %q", scope)
+	}
+
+	native, err := s.nativeMemory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if native {
+		rpcScope := rpc.MemoryScope(scope)
+		result, err := s.RPC.Memory.Store(ctx, &rpc.SessionMemoryStoreParams{Content: content, Scope: &rpcScope, Tags: tags})
+		if err != nil {
+			return nil, fmt.Errorf("failed to store memory: %w", err)
+		}
+		memory := newMemory(result.Memory)
+		return &memory, nil
+	}
+
+	id, err := newMemoryID()
+	if err != nil {
+		return nil, err
+	}
+	memory := Memory{ID: id, Content: content, Scope: scope, Tags: slices.Clone(tags), CreatedAt: time.Now().UTC()}
+	if scope == MemoryScopeWorkspace {
+		memory.Workspace = s.memoryWorkspace
+	}
+	if err := s.memoryStore.SaveMemory(memory); err != nil {
+		return nil, fmt.Errorf("failed to store memory: %w", err)
+	}
+	if err := s.addMemoryContext(ctx, []Memory{memory}); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// Memories returns the memories visible to the session, oldest first: the
+// user's memories and those of the session's workspace. An empty scope returns
+// both.
+func (s *Session) Memories(ctx context.Context, scope MemoryScope) ([]Memory, error) {
+	native, err := s.nativeMemory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if native {
+		params := &rpc.SessionMemoryListParams{}
+		if scope != "" {
+			rpcScope := rpc.MemoryScope(scope)
+			params.Scope = &rpcScope
+		}
+		result, err := s.RPC.Memory.List(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list memories: %w", err)
+		}
+		memories := make([]Memory, len(result.Memories))
+		for i, m := range result.Memories {
+			memories[i] = newMemory(m)
+		}
+		return memories, nil
+	}
+	return s.storedMemories(scope)
+}
+
+// ForgetMemory deletes the memory with the given ID. It no longer reaches new
+// sessions, but sessions that already received it keep it in their context.
+func (s *Session) ForgetMemory(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("memory ID is required")
+	}
+	native, err := s.nativeMemory(ctx)
+	if err != nil {
+		return err
+	}
+	if native {
+		if _, err := s.RPC.Memory.Delete(ctx, &rpc.SessionMemoryDeleteParams{ID: id}); err != nil {
+			return fmt.Errorf("failed to delete memory: %w", err)
+		}
+		return nil
+	}
+	if err := s.memoryStore.DeleteMemory(id); err != nil {
+		return fmt.Errorf("failed to delete memory: %w", err)
+	}
+	return nil
+}
+
+// nativeMemory reports whether the CLI keeps memories. When it does not, the
+// session falls back to ClientOptions.MemoryStore, and an error is returned if
+// none is set.
+func (s *Session) nativeMemory(ctx context.Context) (bool, error) {
+	version := "unknown"
+	if s.capabilities != nil {
+		capabilities, err := s.capabilities(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to check CLI capabilities: %w", err)
+		}
+		if capabilities.HasFeature(FeatureMemory) {
+			return true, nil
+		}
+		version = capabilities.Version
+	}
+	if s.memoryStore == nil {
+		return false, fmt.Errorf("CLI version %s does not support memory; set ClientOptions.MemoryStore to keep memories in the SDK", version)
+	}
+	return false, nil
+}
+
+// storedMemories returns the memories in the session's MemoryStore that are
+// visible to it.
+func (s *Session) storedMemories(scope MemoryScope) ([]Memory, error) {
+	stored, err := s.memoryStore.LoadMemories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load memories: %w", err)
+	}
+	var memories []Memory
+	for _, memory := range stored {
+		if scope != "" && memory.Scope != scope {
+			continue
+		}
+		if memory.Scope == MemoryScopeWorkspace && memory.Workspace != s.memoryWorkspace {
+			continue
+		}
+		memories = append(memories, memory)
+	}
+	return memories, nil
+}
+
+// recallMemories adds the stored memories visible to the session to its
+// context, when the session uses a MemoryStore.
+func (s *Session) recallMemories(ctx context.Context) error {
+	if s.memoryStore == nil {
+		return nil
+	}
+	if native, err := s.nativeMemory(ctx); err != nil || native {
+		return err
+	}
+	memories, err := s.storedMemories("")
+	if err != nil || len(memories) == 0 {
+		return err
+	}
+	return s.addMemoryContext(ctx, memories)
+}
+
+// addMemoryContext attaches memories to the session as "memory" context.
+func (s *Session) addMemoryContext(ctx context.Context, memories []Memory) error {
+	items := make([]any, len(memories))
+	for i, memory := range memories {
+		items[i] = map[string]any{"id": memory.ID, "content": memory.Content, "scope": string(memory.Scope)}
+	}
+	_, err := s.RPC.Context.Add(ctx, &rpc.SessionContextAddParams{
+		Kind: "memory",
+		Name: "Remembered facts",
+		Data: map[string]any{"memories": items},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add memories to session context: %w", err)
+	}
+	return nil
+}
+
+func newMemory(m rpc.MemoryElement) Memory {
+	created, _ := time.Parse(time.RFC3339, m.CreatedAt)
+	return Memory{ID: m.ID, Content: m.Content, Scope: MemoryScope(m.Scope), Tags: m.Tags, CreatedAt: created}
+}
+
+func newMemoryID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate memory ID: %w", err)
+	}
+	return "mem-" + hex.EncodeToString(b), nil
+}
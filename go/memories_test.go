@@ -0,0 +1,134 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSession_Remember(t *testing.T) {
+	t.Run("uses the CLI when it supports memory", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion(), "features": map[string]bool{FeatureMemory: true}})
+		log.call("session.memory.store", map[string]any{"memory": map[string]any{
+			"id": "m-1", "content": "Prefers tabs", "scope": "workspace", "createdAt": "2026-01-02T00:00:00Z",
+		}})
+		log.call("session.memory.list", map[string]any{"memories": []map[string]any{
+			{"id": "m-1", "content": "Prefers tabs", "scope": "workspace", "createdAt": "2026-01-02T00:00:00Z"},
+		}})
+		log.call("session.memory.delete", map[string]any{})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		memory, err := session.Remember(t.Context(), "Prefers tabs", &RememberOptions{Scope: MemoryScopeWorkspace, Tags: []string{"style"}})
+		if err != nil {
+			t.Fatalf("Failed to remember: %v", err)
+		}
+		if memory.ID != "m-1" || memory.Scope != MemoryScopeWorkspace || memory.CreatedAt.IsZero() {
+			t.Errorf("Unexpected memory: %+v", memory)
+		}
+		memories, err := session.Memories(t.Context(), "")
+		if err != nil || len(memories) != 1 || memories[0].Content != "Prefers tabs" {
+			t.Errorf("Unexpected memories: %+v, %v", memories, err)
+		}
+		if err := session.ForgetMemory(t.Context(), "m-1"); err != nil {
+			t.Errorf("Failed to forget: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.memory.store" && (message.Params["scope"] != "workspace" || message.Params["content"] != "Prefers tabs") {
+				t.Errorf("Unexpected store request: %s", record.Message)
+			}
+		}
+	})
+
+	t.Run("falls back to the memory store", func(t *testing.T) {
+		store := NewFileMemoryStore(filepath.Join(t.TempDir(), "memories.json"))
+		store.SaveMemory(Memory{ID: "old", Content: "Name is Mona", Scope: MemoryScopeUser})
+		store.SaveMemory(Memory{ID: "elsewhere", Content: "Uses Rust", Scope: MemoryScopeWorkspace, Workspace: "/other"})
+
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+		log.call("session.context.add", map[string]any{"id": "c1"})
+		log.call("session.context.add", map[string]any{"id": "c2"})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		client.options.MemoryStore = store
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		memory, err := session.Remember(t.Context(), "Prefers tabs", &RememberOptions{Scope: MemoryScopeWorkspace})
+		if err != nil {
+			t.Fatalf("Failed to remember: %v", err)
+		}
+		if !strings.HasPrefix(memory.ID, "mem-") || memory.Workspace == "" {
+			t.Errorf("Unexpected memory: %+v", memory)
+		}
+		memories, err := session.Memories(t.Context(), "")
+		if err != nil || len(memories) != 2 || memories[0].ID != "old" || memories[1].ID != memory.ID {
+			t.Errorf("Expected memories of this workspace, got %+v, %v", memories, err)
+		}
+		if err := session.ForgetMemory(t.Context(), "old"); err != nil {
+			t.Fatalf("Failed to forget: %v", err)
+		}
+		if stored, _ := store.LoadMemories(); len(stored) != 2 {
+			t.Errorf("Expected the memory to be deleted, got %+v", stored)
+		}
+
+		var contexts []string
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+				Params struct {
+					Kind string         `json:"kind"`
+					Data map[string]any `json:"data"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.context.add" {
+				data, _ := json.Marshal(message.Params.Data)
+				contexts = append(contexts, message.Params.Kind+" "+string(data))
+			}
+		}
+		if len(contexts) != 2 || !strings.Contains(contexts[0], "Name is Mona") || strings.Contains(contexts[0], "Uses Rust") || !strings.Contains(contexts[1], "Prefers tabs") {
+			t.Errorf("Expected stored memories to be added to the session context, got %v", contexts)
+		}
+	})
+
+	t.Run("requires CLI support or a memory store", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.Remember(t.Context(), "Prefers tabs", nil); err == nil || !strings.Contains(err.Error(), "ClientOptions.MemoryStore") {
+			t.Errorf("Expected an error naming MemoryStore, got %v", err)
+		}
+		if _, err := session.Remember(t.Context(), " ", nil); err == nil {
+			t.Error("Expected an error for empty content")
+		}
+	})
+}
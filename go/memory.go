@@ -0,0 +1,271 @@
+package copilot
+
+import (
+	"sort"
+	"sync"
+)
+
+// Estimated sizes, in bytes, of the fixed parts of buffered items. They include
+// struct fields, pointers, and allocation overhead, so that sessions buffering
+// many small items are not reported as nearly free.
+const (
+	eventOverhead            = 512
+	toolCallRecordOverhead   = 256
+	permissionRecordOverhead = 384
+)
+
+// SessionStats reports the memory a session uses in the SDK. See [Session.Stats].
+type SessionStats struct {
+	SessionID string
+	// BufferedEvents is the number of events waiting to be received from
+	// [Session.Events] channels.
+	BufferedEvents int
+	// ToolCalls and PermissionRecords are the number of records kept for
+	// [Session.ToolCalls] and [Session.PermissionLog].
+	ToolCalls         int
+	PermissionRecords int
+	// Bytes is the estimated memory used by the buffered events and records.
+	Bytes int64
+	// Trimmed is the number of events and records discarded because the session
+	// exceeded ClientOptions.SessionMemoryLimit.
+	Trimmed int64
+}
+
+// ClientStats reports the memory used by the sessions of a client. See [Client.Stats].
+type ClientStats struct {
+	// Sessions holds the stats of each active session, sorted by session ID.
+	Sessions []SessionStats
+	// Bytes is the estimated memory used by all sessions.
+	Bytes int64
+}
+
+// Stats reports the memory used by the sessions of this client, e.g. to export
+// as metrics or to find sessions whose events are not being read.
+func (c *Client) Stats() ClientStats {
+	c.sessionsMux.Lock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		sessions = append(sessions, session)
+	}
+	c.sessionsMux.Unlock()
+
+	var stats ClientStats
+	for _, session := range sessions {
+		sessionStats := session.Stats()
+		stats.Sessions = append(stats.Sessions, sessionStats)
+		stats.Bytes += sessionStats.Bytes
+	}
+	sort.Slice(stats.Sessions, func(i, j int) bool {
+		return stats.Sessions[i].SessionID < stats.Sessions[j].SessionID
+	})
+	return stats
+}
+
+// Stats reports the memory this session uses in the SDK for buffered events and
+// records. Sizes are estimates based on the length of their content.
+func (s *Session) Stats() SessionStats {
+	stats := SessionStats{SessionID: s.SessionID, Trimmed: s.trimmed.Load()}
+	events, eventBytes := s.buffers.size()
+	stats.BufferedEvents = events
+	stats.Bytes += eventBytes
+
+	s.toolCalls.mu.Lock()
+	stats.ToolCalls = len(s.toolCalls.records)
+	for _, record := range s.toolCalls.records {
+		stats.Bytes += toolCallRecordSize(record)
+	}
+	s.toolCalls.mu.Unlock()
+
+	s.audit.mu.Lock()
+	stats.PermissionRecords = len(s.audit.records)
+	for _, record := range s.audit.records {
+		stats.Bytes += permissionRecordSize(record)
+	}
+	s.audit.mu.Unlock()
+	return stats
+}
+
+// enforceMemoryLimit discards the oldest buffered events, then the oldest tool
+// call and permission records, once the session exceeds its memory limit,
+// until it is at three quarters of the limit.
+func (s *Session) enforceMemoryLimit() {
+	if s.memoryLimit <= 0 {
+		return
+	}
+	stats := s.Stats()
+	if stats.Bytes <= s.memoryLimit {
+		return
+	}
+	excess := stats.Bytes - s.memoryLimit*3/4
+
+	var trimmed int
+	n, freed := s.buffers.trim(excess)
+	trimmed, excess = trimmed+n, excess-freed
+
+	if excess > 0 {
+		s.toolCalls.mu.Lock()
+		drop := 0
+		for drop < len(s.toolCalls.records) && excess > 0 {
+			excess -= toolCallRecordSize(s.toolCalls.records[drop])
+			drop++
+		}
+		if drop > 0 {
+			s.toolCalls.records = append([]ToolCallRecord(nil), s.toolCalls.records[drop:]...)
+			for id, i := range s.toolCalls.pending {
+				if i < drop {
+					delete(s.toolCalls.pending, id)
+				} else {
+					s.toolCalls.pending[id] = i - drop
+				}
+			}
+		}
+		s.toolCalls.mu.Unlock()
+		trimmed += drop
+	}
+
+	if excess > 0 {
+		s.audit.mu.Lock()
+		drop := 0
+		for drop < len(s.audit.records) && excess > 0 {
+			excess -= permissionRecordSize(s.audit.records[drop])
+			drop++
+		}
+		s.audit.records = append([]PermissionAuditRecord(nil), s.audit.records[drop:]...)
+		s.audit.mu.Unlock()
+		trimmed += drop
+	}
+
+	s.trimmed.Add(int64(trimmed))
+	after := s.Stats()
+	s.logger.Warn("session memory limit exceeded", "limit", s.memoryLimit, "bytes", stats.Bytes, "trimmed", trimmed, "bytesAfter", after.Bytes)
+	if s.onMemoryLimit != nil {
+		go s.onMemoryLimit(s, after)
+	}
+}
+
+func eventSize(event SessionEvent) int64 {
+	size := int64(eventOverhead + len(event.ID) + len(event.Type))
+	data := event.Data
+	for _, text := range []*string{data.Content, data.DeltaContent, data.Message, data.TransformedContent} {
+		if text != nil {
+			size += int64(len(*text))
+		}
+	}
+	if data.Result != nil {
+		size += int64(len(data.Result.Content))
+		if data.Result.DetailedContent != nil {
+			size += int64(len(*data.Result.DetailedContent))
+		}
+	}
+	return size
+}
+
+func toolCallRecordSize(record ToolCallRecord) int64 {
+	return int64(toolCallRecordOverhead + len(record.ToolCallID) + len(record.Name) + len(record.Error))
+}
+
+func permissionRecordSize(record PermissionAuditRecord) int64 {
+	return int64(permissionRecordOverhead + len(record.Decision) + len(record.Handler) + len(record.Error))
+}
+
+// eventBuffer holds the events of a [Session.Events] channel that have not been
+// received yet.
+type eventBuffer struct {
+	mu      sync.Mutex
+	pending []SessionEvent
+	bytes   int64
+	ready   chan struct{} // signaled when an event is added
+}
+
+func (b *eventBuffer) push(event SessionEvent) {
+	b.mu.Lock()
+	b.pending = append(b.pending, event)
+	b.bytes += eventSize(event)
+	b.mu.Unlock()
+	select {
+	case b.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (b *eventBuffer) pop() (SessionEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) == 0 {
+		return SessionEvent{}, false
+	}
+	event := b.pending[0]
+	b.pending[0] = SessionEvent{}
+	b.pending = b.pending[1:]
+	b.bytes -= eventSize(event)
+	return event, true
+}
+
+// eventBuffers is the set of buffers of a session's Events channels.
+type eventBuffers struct {
+	mu      sync.Mutex
+	buffers map[*eventBuffer]struct{}
+}
+
+func (s *eventBuffers) add(b *eventBuffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buffers == nil {
+		s.buffers = make(map[*eventBuffer]struct{})
+	}
+	s.buffers[b] = struct{}{}
+}
+
+func (s *eventBuffers) remove(b *eventBuffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buffers, b)
+}
+
+func (s *eventBuffers) size() (events int, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for b := range s.buffers {
+		b.mu.Lock()
+		events += len(b.pending)
+		bytes += b.bytes
+		b.mu.Unlock()
+	}
+	return events, bytes
+}
+
+// trim discards the oldest events of the largest buffers until at least
+// excess bytes are freed or the buffers are empty, returning the number of
+// events discarded and the bytes freed.
+func (s *eventBuffers) trim(excess int64) (int, int64) {
+	s.mu.Lock()
+	buffers := make([]*eventBuffer, 0, len(s.buffers))
+	for b := range s.buffers {
+		buffers = append(buffers, b)
+	}
+	s.mu.Unlock()
+
+	var dropped int
+	var freed int64
+	for freed < excess {
+		var largest *eventBuffer
+		var largestBytes int64
+		for _, b := range buffers {
+			b.mu.Lock()
+			if b.bytes > largestBytes {
+				largest, largestBytes = b, b.bytes
+			}
+			b.mu.Unlock()
+		}
+		if largest == nil {
+			break
+		}
+		event, ok := largest.pop()
+		if !ok {
+			break
+		}
+		dropped++
+		freed += eventSize(event)
+	}
+	return dropped, freed
+}
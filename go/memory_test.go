@@ -0,0 +1,88 @@
+package copilot
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession_Stats(t *testing.T) {
+	t.Run("reports buffered events and records", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.Events(t.Context())
+		content := strings.Repeat("x", 1000)
+		id, name := "t1", "view"
+		session.dispatchEvent(SessionEvent{ID: "e1", Type: AssistantMessage, Data: Data{Content: &content}})
+		session.dispatchEvent(SessionEvent{ID: "e2", Type: ToolExecutionStart, Data: Data{ToolCallID: &id, ToolName: &name}})
+		session.handlePermissionRequest(PermissionRequest{Kind: "read"})
+
+		stats := session.Stats()
+		if stats.SessionID != "s1" || stats.BufferedEvents != 2 || stats.ToolCalls != 1 || stats.PermissionRecords != 1 {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+		if stats.Bytes < 1000+2*eventOverhead || stats.Trimmed != 0 {
+			t.Errorf("Expected the content to be counted, got %+v", stats)
+		}
+	})
+
+	t.Run("trims the oldest events over the limit", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.memoryLimit = 20 * eventOverhead
+		limited := make(chan SessionStats, 1)
+		session.onMemoryLimit = func(s *Session, stats SessionStats) {
+			select {
+			case limited <- stats:
+			default:
+			}
+		}
+		events := session.Events(t.Context())
+		for i := range 30 {
+			session.dispatchEvent(SessionEvent{ID: "e" + strconv.Itoa(i), Type: AssistantMessageDelta})
+		}
+
+		stats := session.Stats()
+		if stats.Bytes > session.memoryLimit || stats.Trimmed == 0 {
+			t.Errorf("Expected the session to be trimmed under the limit, got %+v", stats)
+		}
+		select {
+		case <-limited:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for OnSessionMemoryLimit")
+		}
+		// The oldest events were discarded; the rest arrive in order
+		var last SessionEvent
+		for range 30 - stats.Trimmed {
+			select {
+			case last = <-events:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for events")
+			}
+		}
+		if last.ID != "e29" {
+			t.Errorf("Expected the newest event last, got %q", last.ID)
+		}
+	})
+
+	t.Run("trims records when no events are buffered", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.memoryLimit = 10 * toolCallRecordOverhead
+		name := "view"
+		for i := range 20 {
+			id := "t" + strconv.Itoa(i)
+			session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Data: Data{ToolCallID: &id, ToolName: &name}})
+		}
+		calls := session.ToolCalls()
+		if len(calls) >= 10 || calls[len(calls)-1].ToolCallID != "t19" {
+			t.Errorf("Expected only the newest records to be kept, got %d ending in %q", len(calls), calls[len(calls)-1].ToolCallID)
+		}
+
+		// Completions still find their start record after trimming
+		id := "t19"
+		session.dispatchEvent(SessionEvent{Type: ToolExecutionComplete, Data: Data{ToolCallID: &id}})
+		calls = session.ToolCalls()
+		if !calls[len(calls)-1].Completed {
+			t.Error("Expected the last call to be completed")
+		}
+	})
+}
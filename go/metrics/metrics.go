@@ -0,0 +1,129 @@
+// Package metrics exports Copilot SDK instrumentation as Prometheus metrics.
+//
+// Example:
+//
+//	collector, err := metrics.Register(prometheus.DefaultRegisterer)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    MetricsRegistry: collector,
+//	})
+package metrics
+
+import (
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "copilot_sdk"
+
+// Collector implements both [prometheus.Collector] and [copilot.MetricsRegistry].
+//
+// The following metrics are exported:
+//   - copilot_sdk_rpc_latency_seconds: histogram of JSON-RPC request latency, by method and status
+//   - copilot_sdk_messages_sent_total: messages sent to sessions
+//   - copilot_sdk_tool_calls_total: tool calls handled, by tool and result
+//   - copilot_sdk_permission_denials_total: permission requests denied, by kind
+//   - copilot_sdk_cli_restarts_total: times the CLI process was spawned again
+type Collector struct {
+	rpcLatency        *prometheus.HistogramVec
+	messagesSent      prometheus.Counter
+	toolCalls         *prometheus.CounterVec
+	permissionDenials *prometheus.CounterVec
+	cliRestarts       prometheus.Counter
+}
+
+var _ copilot.MetricsRegistry = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New creates a Collector. It must be registered with a [prometheus.Registerer]
+// before its metrics are exported; see [Register].
+func New() *Collector {
+	return &Collector{
+		rpcLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_latency_seconds",
+			Help:      "Latency of JSON-RPC requests to the Copilot CLI.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "status"}),
+		messagesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_sent_total",
+			Help:      "Number of messages sent to sessions.",
+		}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of tool calls handled by the SDK.",
+		}, []string{"tool", "result"}),
+		permissionDenials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permission_denials_total",
+			Help:      "Number of permission requests denied.",
+		}, []string{"kind"}),
+		cliRestarts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cli_restarts_total",
+			Help:      "Number of times the Copilot CLI process was restarted.",
+		}),
+	}
+}
+
+// Register creates a Collector and registers it with reg.
+func Register(reg prometheus.Registerer) (*Collector, error) {
+	c := New()
+	if err := reg.Register(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Describe implements [prometheus.Collector].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.rpcLatency.Describe(ch)
+	c.messagesSent.Describe(ch)
+	c.toolCalls.Describe(ch)
+	c.permissionDenials.Describe(ch)
+	c.cliRestarts.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.rpcLatency.Collect(ch)
+	c.messagesSent.Collect(ch)
+	c.toolCalls.Collect(ch)
+	c.permissionDenials.Collect(ch)
+	c.cliRestarts.Collect(ch)
+}
+
+// ObserveRPC implements [copilot.MetricsRegistry].
+func (c *Collector) ObserveRPC(method string, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	c.rpcLatency.WithLabelValues(method, status).Observe(duration.Seconds())
+}
+
+// MessageSent implements [copilot.MetricsRegistry].
+func (c *Collector) MessageSent() {
+	c.messagesSent.Inc()
+}
+
+// ToolCalled implements [copilot.MetricsRegistry].
+func (c *Collector) ToolCalled(toolName string, resultType string) {
+	c.toolCalls.WithLabelValues(toolName, resultType).Inc()
+}
+
+// PermissionDenied implements [copilot.MetricsRegistry].
+func (c *Collector) PermissionDenied(kind string) {
+	c.permissionDenials.WithLabelValues(kind).Inc()
+}
+
+// CLIRestarted implements [copilot.MetricsRegistry].
+func (c *Collector) CLIRestarted() {
+	c.cliRestarts.Inc()
+}
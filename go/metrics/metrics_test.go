@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	t.Run("registers all metrics", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		c, err := Register(reg)
+		if err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+
+		c.ObserveRPC("session.send", 10*time.Millisecond, nil)
+		c.MessageSent()
+		c.ToolCalled("get_weather", "success")
+		c.PermissionDenied("shell")
+		c.CLIRestarted()
+
+		families, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		names := map[string]bool{}
+		for _, f := range families {
+			names[f.GetName()] = true
+		}
+		for _, name := range []string{
+			"copilot_sdk_rpc_latency_seconds",
+			"copilot_sdk_messages_sent_total",
+			"copilot_sdk_tool_calls_total",
+			"copilot_sdk_permission_denials_total",
+			"copilot_sdk_cli_restarts_total",
+		} {
+			if !names[name] {
+				t.Errorf("Expected metric %s to be registered", name)
+			}
+		}
+	})
+
+	t.Run("labels counters", func(t *testing.T) {
+		c := New()
+		c.ToolCalled("get_weather", "success")
+		c.ToolCalled("get_weather", "failure")
+		c.ToolCalled("get_weather", "success")
+		c.PermissionDenied("write")
+
+		if got := testutil.ToFloat64(c.toolCalls.WithLabelValues("get_weather", "success")); got != 2 {
+			t.Errorf("Expected 2 successful tool calls, got %v", got)
+		}
+		if got := testutil.ToFloat64(c.toolCalls.WithLabelValues("get_weather", "failure")); got != 1 {
+			t.Errorf("Expected 1 failed tool call, got %v", got)
+		}
+		if got := testutil.ToFloat64(c.permissionDenials.WithLabelValues("write")); got != 1 {
+			t.Errorf("Expected 1 permission denial, got %v", got)
+		}
+	})
+
+	t.Run("records rpc status", func(t *testing.T) {
+		c := New()
+		c.ObserveRPC("ping", time.Millisecond, nil)
+		c.ObserveRPC("ping", time.Millisecond, errors.New("boom"))
+
+		count := testutil.CollectAndCount(c.rpcLatency)
+		if count != 2 {
+			t.Errorf("Expected 2 rpc latency series (ok and error), got %d", count)
+		}
+	})
+
+	t.Run("rejects duplicate registration", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		if _, err := Register(reg); err != nil {
+			t.Fatalf("Failed to register collector: %v", err)
+		}
+		_, err := Register(reg)
+		if err == nil || !strings.Contains(err.Error(), "duplicate") {
+			t.Errorf("Expected duplicate registration error, got %v", err)
+		}
+	})
+}
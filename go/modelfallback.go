@@ -0,0 +1,175 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// modelChain returns the models to try for a message, in order: the model
+// the message asks for ("" for the session's model), then the session's
+// ModelFallbacks that differ from it.
+func (s *Session) modelChain(model string) []string {
+	chain := []string{model}
+	for _, fallback := range s.modelFallbacks {
+		if fallback != "" && !slices.Contains(chain, fallback) {
+			chain = append(chain, fallback)
+		}
+	}
+	return chain
+}
+
+// fallbackSend sends one message on the models of a chain in turn. The first
+// attempt is sent like any other message; later attempts resend the request
+// prepared for it without running OnBeforeSend or acquiring the rate limit
+// again.
+type fallbackSend struct {
+	session  *Session
+	options  MessageOptions
+	chain    []string            // the first model is the one in use
+	req      *sessionSendRequest // prepared by the first attempt
+	counted  bool                // a delivered attempt counts toward the rate limit
+	screened bool                // options have passed screenMessage
+}
+
+func (s *Session) newFallbackSend(options MessageOptions) *fallbackSend {
+	return &fallbackSend{session: s, options: options, chain: s.modelChain(options.Model)}
+}
+
+// send sends the message on the current model, moving on to the next one
+// while session.send fails because the model is unavailable or rate-limited.
+func (f *fallbackSend) send(ctx context.Context) (messageID string, duplicate bool, err error) {
+	for {
+		switch {
+		case f.req == nil && f.screened:
+			messageID, duplicate, f.req, err = f.session.sendScreened(ctx, f.options)
+		case f.req == nil:
+			messageID, duplicate, f.req, err = f.session.send(ctx, f.options)
+		default:
+			messageID, err = f.session.resend(ctx, f.req, f.chain[0], !f.counted)
+		}
+		if err == nil {
+			f.counted = true
+		}
+		if err == nil || !isModelUnavailable(err) || !f.next(err.Error()) {
+			return messageID, duplicate, err
+		}
+	}
+}
+
+// next moves on to the next model of the chain, reporting false if there is
+// none.
+func (f *fallbackSend) next(reason string) bool {
+	if len(f.chain) < 2 {
+		return false
+	}
+	f.session.logFallback(f.chain[0], f.chain[1], reason)
+	f.chain = f.chain[1:]
+	return true
+}
+
+// model returns the model in use, "" for the session's model.
+func (f *fallbackSend) model() string {
+	return f.chain[0]
+}
+
+// resend sends req again on model after the model it was sent on failed. With
+// count, the message is counted toward the rate limit, since no earlier attempt
+// was delivered. The resend carries its own idempotency key, so that a CLI that
+// honors the key does not drop it as a duplicate of the failed attempt; the
+// session still records its outcome under the caller's key.
+func (s *Session) resend(ctx context.Context, req *sessionSendRequest, model string, count bool) (string, error) {
+	if err := s.validateOverrides(ctx, MessageOptions{Model: model}); err != nil {
+		return "", err
+	}
+	retry := *req
+	retry.Model = model
+	if req.IdempotencyKey != "" {
+		retry.IdempotencyKey = req.IdempotencyKey + "@" + model
+	}
+	messageID, err := s.deliverMessage(ctx, &retry)
+	if err != nil {
+		return "", err
+	}
+	if count && s.limiter != nil {
+		s.limiter.record(s.SessionID)
+	}
+	if req.IdempotencyKey != "" {
+		s.sent.resent(req.IdempotencyKey, messageID)
+	}
+	return messageID, nil
+}
+
+func (s *Session) logFallback(from, to, reason string) {
+	if from == "" {
+		from = "session model"
+	}
+	s.logger.Warn("model unavailable, falling back", "model", from, "fallback", to, "reason", reason)
+}
+
+// isModelUnavailable reports whether err means the requested model cannot
+// serve the message right now, so that another model might. Errors from the
+// SDK's own RateLimit quotas are not model failures.
+func isModelUnavailable(err error) bool {
+	var quotaErr *QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return false
+	}
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrModelUnavailable)
+}
+
+// isModelUnavailableEvent reports whether a session.error event means the
+// model failed the turn because it is unavailable or rate-limited.
+func isModelUnavailableEvent(event SessionEvent) bool {
+	if event.Type != SessionError {
+		return false
+	}
+	if event.Data.StatusCode != nil {
+		switch *event.Data.StatusCode {
+		case 429, 503:
+			return true
+		}
+	}
+	errorType := strings.ToLower(deref(event.Data.ErrorType))
+	for _, kind := range []string{"rate_limit", "ratelimit", "quota", "model_unavailable", "model_not_available", "capacity"} {
+		if strings.Contains(errorType, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateModel records the model that produced response in its Data.Model,
+// preferring the model reported by the turn's assistant.usage event.
+func annotateModel(response *SessionEvent, reported *string, requested string) {
+	if response == nil {
+		return
+	}
+	switch {
+	case reported != nil && *reported != "":
+		model := *reported
+		response.Data.Model = &model
+	case requested != "":
+		response.Data.Model = &requested
+	}
+}
+
+// fallbackModels returns the configured fallbacks without the session's own
+// model, which is always tried first.
+func fallbackModels(fallbacks []string, model string) []string {
+	return slices.DeleteFunc(slices.Clone(fallbacks), func(fallback string) bool {
+		return fallback == "" || fallback == model
+	})
+}
+
+// drain discards the values buffered in ch.
+func drain[T any](ch chan T) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
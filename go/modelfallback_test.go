@@ -0,0 +1,163 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestSession_ModelFallbacks(t *testing.T) {
+	// capabilities records the capabilities.get call made before the first
+	// send with a per-message model.
+	capabilities := func(log *replayLog) {
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"methods":         []string{"session.send"},
+			"features":        map[string]bool{FeatureMessageOverrides: true},
+		})
+	}
+	sendError := func(log *replayLog, code string) {
+		log.nextID++
+		id := strconv.Itoa(log.nextID)
+		log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "session.send", "params": map[string]any{}})
+		log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "model busy", "data": map[string]any{"code": code}}})
+	}
+	create := func(t *testing.T, log *replayLog, recorded *bytes.Buffer) *Session {
+		t.Helper()
+		client := newPlaybackClientForTest(t, log, recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			Model:               "gpt-5",
+			ModelFallbacks:      []string{"gpt-5", "claude-sonnet-4.5", "gpt-4.1"},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+	sentParams := func(t *testing.T, recorded *bytes.Buffer, name string) []any {
+		t.Helper()
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to parse recorded log: %v", err)
+		}
+		var models []any
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.send" {
+				models = append(models, message.Params[name])
+			}
+		}
+		return models
+	}
+
+	t.Run("retries a rejected send on the next model", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		sendError(log, "rate_limited")
+		capabilities(log)
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		var recorded bytes.Buffer
+		session := create(t, log, &recorded)
+		messageID, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi"})
+		if err != nil || messageID != "m1" {
+			t.Fatalf("Expected the fallback to send, got %q (err=%v)", messageID, err)
+		}
+		if models := sentParams(t, &recorded, "model"); len(models) != 2 || models[0] != nil || models[1] != "claude-sonnet-4.5" {
+			t.Errorf("Expected the session model, then the first fallback, got %v", models)
+		}
+	})
+
+	t.Run("retries a failed turn and annotates the response", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", SessionError, map[string]any{"errorType": "model_unavailable", "message": "gpt-5 is unavailable"})
+		capabilities(log)
+		log.call("session.send", map[string]any{"messageId": "m2"})
+		log.event("s1", SessionError, map[string]any{"errorType": "api_error", "statusCode": 429, "message": "Too many requests"})
+		log.call("session.send", map[string]any{"messageId": "m3"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Hello", "messageId": "a1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		var recorded bytes.Buffer
+		session := create(t, log, &recorded)
+		// Fallback attempts resend the same message, so they don't count
+		// against the rate limit again
+		session.limiter = newRateLimiter(RateLimitConfig{MaxMessagesPerSession: 1, Policy: RateLimitReject})
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hi", IdempotencyKey: "greet"})
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if response == nil || *response.Data.Content != "Hello" || response.Data.Model == nil || *response.Data.Model != "gpt-4.1" {
+			t.Errorf("Expected the response annotated with gpt-4.1, got %+v", response)
+		}
+		if models := sentParams(t, &recorded, "model"); len(models) != 3 || models[2] != "gpt-4.1" {
+			t.Errorf("Expected three attempts, got %v", models)
+		}
+		if keys := sentParams(t, &recorded, "idempotencyKey"); len(keys) != 3 || keys[0] != "greet" || keys[1] != "greet@claude-sonnet-4.5" || keys[2] != "greet@gpt-4.1" {
+			t.Errorf("Expected each fallback attempt to have its own idempotency key, got %v", keys)
+		}
+		if id, _, _, err := session.send(t.Context(), MessageOptions{Prompt: "Hi", IdempotencyKey: "greet"}); err != nil || id != "m3" {
+			t.Errorf("Expected the caller's key to map to the delivered attempt, got %q (err=%v)", id, err)
+		}
+	})
+
+	t.Run("does not end the retried turn on the failed turn's session.idle", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", SessionError, map[string]any{"errorType": "model_unavailable", "message": "gpt-5 is unavailable"})
+		log.event("s1", SessionIdle, map[string]any{})
+		capabilities(log)
+		log.call("session.send", map[string]any{"messageId": "m2"})
+		log.event("s1", AssistantTurnStart, map[string]any{"turnId": "t2"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Hello", "messageId": "a1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		session := create(t, log, nil)
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hi"})
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if response == nil || *response.Data.Content != "Hello" {
+			t.Errorf("Expected the retried turn's response, got %+v", response)
+		}
+	})
+
+	t.Run("returns other errors without falling back", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		sendError(log, "permission_denied")
+
+		session := create(t, log, nil)
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi"}); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("Expected the permission error, got %v", err)
+		}
+	})
+}
+
+func TestIsModelUnavailable(t *testing.T) {
+	if isModelUnavailable(&SDKError{Code: ErrorCodeRateLimited, Err: &QuotaExceededError{Limit: QuotaSessionMessages}}) {
+		t.Error("Expected the SDK's own quotas not to trigger a fallback")
+	}
+	if !isModelUnavailable(&SDKError{Code: ErrorCodeModelUnavailable}) {
+		t.Error("Expected model_unavailable to trigger a fallback")
+	}
+	event := SessionEvent{Type: SessionError, Data: Data{ErrorType: String("tool_error")}}
+	if isModelUnavailableEvent(event) {
+		t.Error("Expected tool errors not to trigger a fallback")
+	}
+}
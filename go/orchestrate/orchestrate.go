@@ -0,0 +1,156 @@
+// Package orchestrate runs a task through a sequence of custom agents, such as
+// planner → coder → reviewer, with each agent working in its own session and
+// receiving the output of the agents before it.
+//
+// Example:
+//
+//	router := orchestrate.NewRouter(client, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    CustomAgents:        []copilot.CustomAgentConfig{planner, coder, reviewer},
+//	},
+//	    orchestrate.Step{Agent: "planner"},
+//	    orchestrate.Step{Agent: "coder"},
+//	    orchestrate.Step{Agent: "reviewer"},
+//	)
+//
+//	result, err := router.Run(ctx, "Add pagination to the /users endpoint")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(result.Output)
+package orchestrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// Step is one agent in a [Router] pipeline.
+type Step struct {
+	// Agent is the name of the custom agent that handles this step. It must be
+	// one of the CustomAgents in the router's session config.
+	Agent string
+	// Prompt builds the message sent to the agent from the task and the results
+	// of earlier steps. Default: [DefaultPrompt].
+	Prompt func(task string, previous []StepResult) string
+}
+
+// StepResult is the outcome of a single [Step].
+type StepResult struct {
+	Agent     string
+	SessionID string
+	// Output is the agent's final message for the step.
+	Output   string
+	Duration time.Duration
+}
+
+// Result is the outcome of [Router.Run].
+type Result struct {
+	Task  string
+	Steps []StepResult
+	// Output is the output of the last step.
+	Output string
+}
+
+// Router dispatches a task across custom agents in order. Each step runs in a
+// new session, selected to the step's agent with the session.agent.select RPC.
+type Router struct {
+	client *copilot.Client
+	config copilot.SessionConfig
+	steps  []Step
+
+	// StepTimeout bounds how long each agent may work on its step.
+	// Default: the SendAndWait default of 60 seconds.
+	StepTimeout time.Duration
+	// KeepSessions leaves step sessions open after Run returns, for inspection.
+	// By default each session is destroyed once its step completes.
+	KeepSessions bool
+}
+
+// NewRouter creates a router that runs steps in order using sessions created from config.
+func NewRouter(client *copilot.Client, config *copilot.SessionConfig, steps ...Step) *Router {
+	r := &Router{client: client, steps: steps}
+	if config != nil {
+		r.config = *config
+	}
+	return r
+}
+
+// DefaultPrompt sends the task to the first agent. Later agents receive the task
+// followed by the output of every earlier step.
+func DefaultPrompt(task string, previous []StepResult) string {
+	if len(previous) == 0 {
+		return task
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task:\n%s\n", task)
+	for _, step := range previous {
+		fmt.Fprintf(&b, "\nOutput from %s:\n%s\n", step.Agent, step.Output)
+	}
+	return b.String()
+}
+
+// Run sends task through every step and returns the aggregated result. If a step
+// fails, Run returns the results of the steps that completed along with an error
+// naming the failed step.
+func (r *Router) Run(ctx context.Context, task string) (*Result, error) {
+	if len(r.steps) == 0 {
+		return nil, fmt.Errorf("orchestrate: router has no steps")
+	}
+
+	result := &Result{Task: task}
+	for i, step := range r.steps {
+		stepResult, err := r.runStep(ctx, task, step, result.Steps)
+		if err != nil {
+			return result, fmt.Errorf("orchestrate: step %d (%s): %w", i+1, step.Agent, err)
+		}
+		result.Steps = append(result.Steps, *stepResult)
+		result.Output = stepResult.Output
+	}
+	return result, nil
+}
+
+func (r *Router) runStep(ctx context.Context, task string, step Step, previous []StepResult) (*StepResult, error) {
+	start := time.Now()
+
+	config := r.config
+	session, err := r.client.CreateSession(ctx, &config)
+	if err != nil {
+		return nil, err
+	}
+	if !r.KeepSessions {
+		defer session.Destroy()
+	}
+
+	if _, err := session.RPC.Agent.Select(ctx, &rpc.SessionAgentSelectParams{Name: step.Agent}); err != nil {
+		return nil, fmt.Errorf("selecting agent: %w", err)
+	}
+
+	buildPrompt := step.Prompt
+	if buildPrompt == nil {
+		buildPrompt = DefaultPrompt
+	}
+	response, err := session.SendAndWait(ctx, copilot.MessageOptions{
+		Prompt:  buildPrompt(task, previous),
+		Timeout: r.StepTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := ""
+	if response != nil && response.Data.Content != nil {
+		output = *response.Data.Content
+	}
+	return &StepResult{
+		Agent:     step.Agent,
+		SessionID: session.SessionID,
+		Output:    output,
+		Duration:  time.Since(start),
+	}, nil
+}
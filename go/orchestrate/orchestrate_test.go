@@ -0,0 +1,86 @@
+package orchestrate
+
+import (
+	"strings"
+	"testing"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/playbacktest"
+)
+
+// step records one router step answered with output.
+func step(log *playbacktest.Log, sessionID, agent, output string) {
+	log.Call("session.create", map[string]any{"sessionId": sessionID})
+	log.Call("session.agent.select", map[string]any{"agent": map[string]any{"name": agent, "displayName": agent, "description": ""}})
+	log.Call("session.send", map[string]any{"messageId": "m-" + sessionID})
+	log.Event(sessionID, copilot.AssistantMessage, map[string]any{"content": output, "messageId": "m-" + sessionID})
+	log.Event(sessionID, copilot.SessionIdle, map[string]any{})
+	log.Call("session.destroy", map[string]any{})
+}
+
+func TestRouter(t *testing.T) {
+	config := &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll}
+
+	t.Run("passes each agent's output to the next", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		step(log, "s1", "planner", "1. Add a cursor parameter")
+		step(log, "s2", "coder", "Implemented cursor pagination")
+
+		var coderPrompt string
+		router := NewRouter(playbacktest.NewClient(t, log), config,
+			Step{Agent: "planner"},
+			Step{Agent: "coder", Prompt: func(task string, previous []StepResult) string {
+				coderPrompt = DefaultPrompt(task, previous)
+				return coderPrompt
+			}},
+		)
+
+		result, err := router.Run(t.Context(), "Add pagination")
+		if err != nil {
+			t.Fatalf("Failed to run router: %v", err)
+		}
+		if len(result.Steps) != 2 || result.Steps[0].Agent != "planner" || result.Steps[1].SessionID != "s2" {
+			t.Errorf("Unexpected step results: %+v", result.Steps)
+		}
+		if result.Output != "Implemented cursor pagination" {
+			t.Errorf("Expected last step output, got %q", result.Output)
+		}
+		if !strings.Contains(coderPrompt, "Add pagination") || !strings.Contains(coderPrompt, "Output from planner:\n1. Add a cursor parameter") {
+			t.Errorf("Expected coder prompt to include task and plan, got %q", coderPrompt)
+		}
+	})
+
+	t.Run("reports the failing step with earlier results", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		step(log, "s1", "planner", "A plan")
+		log.Call("session.create", map[string]any{"sessionId": "s2"})
+
+		router := NewRouter(playbacktest.NewClient(t, log), config, Step{Agent: "planner"}, Step{Agent: "missing"})
+		result, err := router.Run(t.Context(), "Add pagination")
+		if err == nil || !strings.Contains(err.Error(), "step 2 (missing)") {
+			t.Fatalf("Expected error naming step 2, got %v", err)
+		}
+		if result == nil || len(result.Steps) != 1 || result.Output != "A plan" {
+			t.Errorf("Expected completed planner step, got %+v", result)
+		}
+	})
+
+	t.Run("requires steps", func(t *testing.T) {
+		if _, err := NewRouter(nil, config).Run(t.Context(), "task"); err == nil {
+			t.Error("Expected error for router without steps")
+		}
+	})
+}
+
+func TestDefaultPrompt(t *testing.T) {
+	if prompt := DefaultPrompt("Fix the bug", nil); prompt != "Fix the bug" {
+		t.Errorf("Expected first step to receive the task, got %q", prompt)
+	}
+	prompt := DefaultPrompt("Fix the bug", []StepResult{{Agent: "planner", Output: "Plan"}, {Agent: "coder", Output: "Diff"}})
+	expected := "Task:\nFix the bug\n\nOutput from planner:\nPlan\n\nOutput from coder:\nDiff\n"
+	if prompt != expected {
+		t.Errorf("Expected %q, got %q", expected, prompt)
+	}
+}
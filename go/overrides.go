@@ -0,0 +1,46 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// FeatureMessageOverrides is the CLI feature flag, reported by [Client.Capabilities],
+// for per-message model settings in [MessageOptions].
+const FeatureMessageOverrides = "messageOverrides"
+
+// reasoningEfforts are the valid values for ReasoningEffort.
+var reasoningEfforts = []string{"low", "medium", "high", "xhigh"}
+
+// validateOverrides checks the per-message model settings in options and that the
+// CLI supports them. Without this check an older CLI would silently ignore them.
+func (s *Session) validateOverrides(ctx context.Context, options MessageOptions) error {
+	if options.Model == "" && options.Temperature == nil && options.MaxOutputTokens == 0 && options.ReasoningEffort == "" {
+		return nil
+	}
+	if options.Temperature != nil && s.deterministic {
+		return fmt.Errorf("Temperature cannot be set for a deterministic session")
+	}
+	if t := options.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("invalid Temperature %v: must be between 0 and 2", *t)
+	}
+	if options.MaxOutputTokens < 0 {
+		return fmt.Errorf("invalid MaxOutputTokens %d: must not be negative", options.MaxOutputTokens)
+	}
+	if options.ReasoningEffort != "" && !slices.Contains(reasoningEfforts, options.ReasoningEffort) {
+		return fmt.Errorf("invalid ReasoningEffort %q: must be one of low, medium, high, xhigh", options.ReasoningEffort)
+	}
+
+	if s.capabilities == nil {
+		return nil
+	}
+	capabilities, err := s.capabilities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	if !capabilities.HasFeature(FeatureMessageOverrides) {
+		return fmt.Errorf("CLI version %s does not support per-message Model, Temperature, MaxOutputTokens, or ReasoningEffort", capabilities.Version)
+	}
+	return nil
+}
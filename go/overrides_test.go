@@ -0,0 +1,94 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSession_MessageOverrides(t *testing.T) {
+	newSessionWithFeatures := func(t *testing.T, features map[string]bool, recorded *bytes.Buffer) *Session {
+		t.Helper()
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"methods":         []string{"session.send"},
+			"features":        features,
+		})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		client := newPlaybackClientForTest(t, log, recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("sends overrides when the CLI supports them", func(t *testing.T) {
+		var recorded bytes.Buffer
+		session := newSessionWithFeatures(t, map[string]bool{FeatureMessageOverrides: true}, &recorded)
+
+		temperature := 0.2
+		_, err := session.Send(t.Context(), MessageOptions{
+			Prompt:          "Review this design",
+			Model:           "claude-opus-4.5",
+			Temperature:     &temperature,
+			MaxOutputTokens: 2000,
+			ReasoningEffort: "high",
+		})
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method != "session.send" {
+				continue
+			}
+			if message.Params["model"] != "claude-opus-4.5" || message.Params["temperature"] != 0.2 ||
+				message.Params["maxOutputTokens"] != float64(2000) || message.Params["reasoningEffort"] != "high" {
+				t.Errorf("Unexpected send params: %v", message.Params)
+			}
+			return
+		}
+		t.Error("Expected session.send to be sent")
+	})
+
+	t.Run("rejects overrides the CLI does not support", func(t *testing.T) {
+		var recorded bytes.Buffer
+		session := newSessionWithFeatures(t, map[string]bool{}, &recorded)
+
+		_, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi", Model: "gpt-5"})
+		if err == nil || !strings.Contains(err.Error(), "1.2.3 does not support") {
+			t.Errorf("Expected unsupported error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		tooHot := 2.5
+		tests := []MessageOptions{
+			{Temperature: &tooHot},
+			{MaxOutputTokens: -1},
+			{ReasoningEffort: "extreme"},
+		}
+		for _, options := range tests {
+			if err := session.validateOverrides(t.Context(), options); err == nil {
+				t.Errorf("Expected error for %+v", options)
+			}
+		}
+		if err := session.validateOverrides(t.Context(), MessageOptions{Prompt: "Hi"}); err != nil {
+			t.Errorf("Expected no error without overrides, got %v", err)
+		}
+	})
+}
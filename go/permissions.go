@@ -4,8 +4,28 @@ package copilot
 var PermissionHandler = struct {
 	// ApproveAll approves all permission requests.
 	ApproveAll PermissionHandlerFunc
+	// Interactive asks the user on the terminal, showing the diff of file edits.
+	// See [NewInteractivePermissionHandler].
+	Interactive PermissionHandlerFunc
 }{
 	ApproveAll: func(_ PermissionRequest, _ PermissionInvocation) (PermissionRequestResult, error) {
 		return PermissionRequestResult{Kind: "approved"}, nil
 	},
+	Interactive: NewInteractivePermissionHandler(nil),
+}
+
+// ApproveWithModifications approves a "write" permission request but has the CLI
+// write contents instead of the content the agent proposed, e.g. after a user
+// edits the change in a review UI. The agent is told the file was written.
+//
+// Example:
+//
+//	OnPermissionRequest: func(request copilot.PermissionRequest, _ copilot.PermissionInvocation) (copilot.PermissionRequestResult, error) {
+//	    if request.FileEdit != nil {
+//	        return copilot.ApproveWithModifications(addLicenseHeader(request.FileEdit.NewContents)), nil
+//	    }
+//	    return copilot.PermissionRequestResult{Kind: "approved"}, nil
+//	},
+func ApproveWithModifications(contents string) PermissionRequestResult {
+	return PermissionRequestResult{Kind: "approved", ModifiedContents: &contents}
 }
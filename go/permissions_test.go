@@ -0,0 +1,54 @@
+package copilot
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPermissionRequest_FileEdit(t *testing.T) {
+	t.Run("populated for write requests", func(t *testing.T) {
+		var request PermissionRequest
+		data := `{"kind":"write","toolCallId":"t1","fileName":"main.go","diff":"@@ -1 +1 @@\n-a\n+b","newFileContents":"b","intention":"Fix typo"}`
+		if err := json.Unmarshal([]byte(data), &request); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		expected := FileEditPreview{Path: "main.go", Diff: "@@ -1 +1 @@\n-a\n+b", NewContents: "b", Intention: "Fix typo"}
+		if request.FileEdit == nil || *request.FileEdit != expected {
+			t.Errorf("Expected file edit %+v, got %+v", expected, request.FileEdit)
+		}
+		if request.Extra["fileName"] != "main.go" {
+			t.Errorf("Expected raw fields to remain in Extra, got %v", request.Extra)
+		}
+	})
+
+	t.Run("nil for other kinds", func(t *testing.T) {
+		var request PermissionRequest
+		if err := json.Unmarshal([]byte(`{"kind":"shell","fullCommandText":"ls"}`), &request); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if request.FileEdit != nil {
+			t.Errorf("Expected no file edit for shell request, got %+v", request.FileEdit)
+		}
+	})
+}
+
+func TestApproveWithModifications(t *testing.T) {
+	data, err := json.Marshal(permissionRequestResponse{Result: ApproveWithModifications("package main\n")})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	expected := `{"result":{"kind":"approved","modifiedContents":"package main\n"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	data, _ = json.Marshal(PermissionRequestResult{Kind: "approved"})
+	if string(data) != `{"kind":"approved"}` {
+		t.Errorf("Expected modifiedContents to be omitted, got %s", data)
+	}
+
+	// An empty file is a valid modification
+	if result := ApproveWithModifications(""); result.ModifiedContents == nil {
+		t.Error("Expected empty contents to be kept")
+	}
+}
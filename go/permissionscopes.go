@@ -0,0 +1,285 @@
+package copilot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PermissionScope says how long a permission decision applies. The zero value
+// applies the decision to the current request only.
+type PermissionScope string
+
+const (
+	// AlwaysAllowTool approves the current request and every later request for
+	// the same tool in the session without calling OnPermissionRequest.
+	AlwaysAllowTool PermissionScope = "always-allow-tool"
+	// AlwaysDenyTool denies the current request and every later request for the
+	// same tool in the session without calling OnPermissionRequest.
+	AlwaysDenyTool PermissionScope = "always-deny-tool"
+)
+
+// rememberedDenial is the result kind sent for requests denied by [AlwaysDenyTool].
+const rememberedDenial = "denied-interactively-by-user"
+
+// ToolKey identifies the tool a permission request is for, and is the key
+// under which [AlwaysAllowTool] and [AlwaysDenyTool] decisions are remembered:
+//   - "shell(git)" for shell commands, naming every command in the request
+//   - "mcp(server/tool)" for MCP tools
+//   - "custom-tool(name)" for tools registered by the SDK
+//   - the kind, e.g. "write", "read", or "url", for the CLI's built-in tools
+//
+// A shell request running several commands, such as "git add . && rm -rf x",
+// has the key "shell(git,rm)" and does not match a decision remembered for "shell(git)".
+func (p PermissionRequest) ToolKey() string {
+	str := func(key string) string {
+		value, _ := p.Extra[key].(string)
+		return value
+	}
+	switch p.Kind {
+	case "shell":
+		var names []string
+		if commands, ok := p.Extra["commands"].([]any); ok {
+			for _, command := range commands {
+				if c, ok := command.(map[string]any); ok {
+					if name, _ := c["identifier"].(string); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+		if len(names) == 0 {
+			if fields := strings.Fields(str("fullCommandText")); len(fields) > 0 {
+				names = append(names, fields[0])
+			}
+		}
+		slices.Sort(names)
+		return "shell(" + strings.Join(slices.Compact(names), ",") + ")"
+	case "mcp":
+		return "mcp(" + str("serverName") + "/" + str("toolName") + ")"
+	case "custom-tool":
+		return "custom-tool(" + str("toolName") + ")"
+	default:
+		return p.Kind
+	}
+}
+
+// PermissionStore persists [AlwaysAllowTool] and [AlwaysDenyTool] decisions
+// across sessions. Set SessionConfig.PermissionStore to load remembered decisions
+// into a new session and save the ones made in it.
+//
+// Implementations must be safe for concurrent use, since sessions sharing a
+// store save from their own goroutines.
+type PermissionStore interface {
+	// LoadPermissions returns the remembered decisions, keyed by [PermissionRequest.ToolKey].
+	LoadPermissions() (map[string]PermissionScope, error)
+	// SavePermission remembers scope for tool, or forgets tool when scope is empty.
+	SavePermission(tool string, scope PermissionScope) error
+}
+
+// FilePermissionStore is a [PermissionStore] that keeps decisions in a JSON
+// file, such as one in the user's config directory. Create one with
+// [NewFilePermissionStore].
+type FilePermissionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFilePermissionStore returns a store backed by the JSON file at path.
+// The file and its directory are created on the first save.
+//
+// Example:
+//
+//	dir, _ := os.UserConfigDir()
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: askUser,
+//	    PermissionStore:     copilot.NewFilePermissionStore(filepath.Join(dir, "myapp", "permissions.json")),
+//	})
+func NewFilePermissionStore(path string) *FilePermissionStore {
+	return &FilePermissionStore{path: path}
+}
+
+// LoadPermissions reads the file, returning no decisions if it does not exist.
+func (f *FilePermissionStore) LoadPermissions() (map[string]PermissionScope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load()
+}
+
+// SavePermission updates the file with the decision for tool.
+func (f *FilePermissionStore) SavePermission(tool string, scope PermissionScope) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	decisions, err := f.load()
+	if err != nil {
+		return err
+	}
+	if scope == "" {
+		delete(decisions, tool)
+	} else {
+		decisions[tool] = scope
+	}
+
+	data, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create permission store directory: %w", err)
+	}
+	// Write a temporary file first so that a crash cannot leave a partial file
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write permission store: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write permission store: %w", err)
+	}
+	return nil
+}
+
+func (f *FilePermissionStore) load() (map[string]PermissionScope, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]PermissionScope{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission store: %w", err)
+	}
+	decisions := map[string]PermissionScope{}
+	if err := json.Unmarshal(data, &decisions); err != nil {
+		return nil, fmt.Errorf("failed to parse permission store %s: %w", f.path, err)
+	}
+	return decisions, nil
+}
+
+// permissionScopes holds the tool decisions remembered by a session.
+type permissionScopes struct {
+	mu        sync.Mutex
+	decisions map[string]PermissionScope
+	store     PermissionStore // nil unless SessionConfig.PermissionStore is set
+}
+
+// loadPermissionScopes returns the decisions remembered by store, if any.
+func loadPermissionScopes(store PermissionStore) (map[string]PermissionScope, error) {
+	if store == nil {
+		return nil, nil
+	}
+	decisions, err := store.LoadPermissions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load remembered permissions: %w", err)
+	}
+	for tool, scope := range decisions {
+		if scope != AlwaysAllowTool && scope != AlwaysDenyTool {
+			return nil, fmt.Errorf("failed to load remembered permissions: invalid scope %q for %s", scope, tool)
+		}
+	}
+	return decisions, nil
+}
+
+// setPermissionScopes initializes the session's remembered decisions.
+func (s *Session) setPermissionScopes(store PermissionStore, decisions map[string]PermissionScope) {
+	s.scopes.mu.Lock()
+	defer s.scopes.mu.Unlock()
+	s.scopes.store = store
+	s.scopes.decisions = maps.Clone(decisions)
+}
+
+// rememberedPermission returns the result for a request whose tool has a
+// remembered decision.
+func (s *Session) rememberedPermission(request PermissionRequest) (PermissionRequestResult, bool) {
+	s.scopes.mu.Lock()
+	scope := s.scopes.decisions[request.ToolKey()]
+	s.scopes.mu.Unlock()
+	switch scope {
+	case AlwaysAllowTool:
+		return PermissionRequestResult{Kind: "approved"}, true
+	case AlwaysDenyTool:
+		return PermissionRequestResult{Kind: rememberedDenial}, true
+	}
+	return PermissionRequestResult{}, false
+}
+
+// rememberPermission records the scope of a handler's decision, if any.
+// A scope that contradicts the decision, such as AlwaysAllowTool on a denial,
+// is ignored.
+func (s *Session) rememberPermission(request PermissionRequest, result PermissionRequestResult) {
+	approved := result.Kind == "approved"
+	switch {
+	case result.Scope == "":
+		return
+	case result.Scope == AlwaysAllowTool && approved, result.Scope == AlwaysDenyTool && !approved:
+	default:
+		s.logger.Warn("ignoring permission scope that does not match the decision", "scope", result.Scope, "decision", result.Kind)
+		return
+	}
+
+	tool := request.ToolKey()
+	s.scopes.mu.Lock()
+	if s.scopes.decisions == nil {
+		s.scopes.decisions = map[string]PermissionScope{}
+	}
+	s.scopes.decisions[tool] = result.Scope
+	store := s.scopes.store
+	s.scopes.mu.Unlock()
+
+	if store != nil {
+		if err := store.SavePermission(tool, result.Scope); err != nil {
+			s.logger.Warn("failed to save remembered permission", "tool", tool, "error", err)
+		}
+	}
+}
+
+// RememberedPermissions returns the tool decisions the session applies without
+// calling OnPermissionRequest, keyed by [PermissionRequest.ToolKey]. They come
+// from handler results with a [PermissionScope] and from SessionConfig.PermissionStore.
+func (s *Session) RememberedPermissions() map[string]PermissionScope {
+	s.scopes.mu.Lock()
+	defer s.scopes.mu.Unlock()
+	decisions := maps.Clone(s.scopes.decisions)
+	if decisions == nil {
+		decisions = map[string]PermissionScope{}
+	}
+	return decisions
+}
+
+// ForgetPermissions removes the remembered decisions for tools, or all of them
+// when no tools are given, so that OnPermissionRequest is asked again. Decisions
+// are also removed from SessionConfig.PermissionStore, if set.
+//
+// Example:
+//
+//	// "Reset permissions" in a settings menu
+//	if err := session.ForgetPermissions(); err != nil {
+//	    log.Printf("failed to reset permissions: %v", err)
+//	}
+func (s *Session) ForgetPermissions(tools ...string) error {
+	s.scopes.mu.Lock()
+	if len(tools) == 0 {
+		tools = slices.Collect(maps.Keys(s.scopes.decisions))
+	}
+	for _, tool := range tools {
+		delete(s.scopes.decisions, tool)
+	}
+	store := s.scopes.store
+	s.scopes.mu.Unlock()
+
+	if store == nil {
+		return nil
+	}
+	var errs []error
+	for _, tool := range tools {
+		if err := store.SavePermission(tool, ""); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
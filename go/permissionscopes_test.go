@@ -0,0 +1,126 @@
+package copilot
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func permissionRequest(t *testing.T, data string) PermissionRequest {
+	t.Helper()
+	var request PermissionRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	return request
+}
+
+func TestPermissionRequest_ToolKey(t *testing.T) {
+	tests := []struct {
+		request string
+		key     string
+	}{
+		{`{"kind":"shell","fullCommandText":"git status","commands":[{"identifier":"git","readOnly":true}]}`, "shell(git)"},
+		{`{"kind":"shell","fullCommandText":"rm x && git add . && rm y","commands":[{"identifier":"rm"},{"identifier":"git"},{"identifier":"rm"}]}`, "shell(git,rm)"},
+		{`{"kind":"shell","fullCommandText":"ls -la"}`, "shell(ls)"},
+		{`{"kind":"mcp","serverName":"github","toolName":"create_issue"}`, "mcp(github/create_issue)"},
+		{`{"kind":"custom-tool","toolName":"encrypt_string"}`, "custom-tool(encrypt_string)"},
+		{`{"kind":"url","url":"https://example.com"}`, "url"},
+	}
+	for _, test := range tests {
+		if key := permissionRequest(t, test.request).ToolKey(); key != test.key {
+			t.Errorf("Expected %q for %s, got %q", test.key, test.request, key)
+		}
+	}
+}
+
+func TestSession_RememberedPermissions(t *testing.T) {
+	git := `{"kind":"shell","fullCommandText":"git status","commands":[{"identifier":"git"}]}`
+	rm := `{"kind":"shell","fullCommandText":"rm -rf x","commands":[{"identifier":"rm"}]}`
+
+	t.Run("applies scoped decisions to later requests for the tool", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		calls := 0
+		session.registerPermissionHandler(func(request PermissionRequest, _ PermissionInvocation) (PermissionRequestResult, error) {
+			calls++
+			if request.ToolKey() == "shell(rm)" {
+				return PermissionRequestResult{Kind: "denied-interactively-by-user", Scope: AlwaysDenyTool}, nil
+			}
+			return PermissionRequestResult{Kind: "approved", Scope: AlwaysAllowTool}, nil
+		})
+
+		for range 2 {
+			if result, _ := session.handlePermissionRequest(permissionRequest(t, git)); result.Kind != "approved" {
+				t.Errorf("Expected approval, got %+v", result)
+			}
+			if result, _ := session.handlePermissionRequest(permissionRequest(t, rm)); result.Kind != "denied-interactively-by-user" {
+				t.Errorf("Expected denial, got %+v", result)
+			}
+		}
+		if calls != 2 {
+			t.Errorf("Expected the handler to be asked once per tool, got %d calls", calls)
+		}
+		expected := map[string]PermissionScope{"shell(git)": AlwaysAllowTool, "shell(rm)": AlwaysDenyTool}
+		if remembered := session.RememberedPermissions(); !maps.Equal(remembered, expected) {
+			t.Errorf("Expected %v, got %v", expected, remembered)
+		}
+		if log := session.PermissionLog(); len(log) != 4 || log[2].Handler != "remembered" {
+			t.Errorf("Expected remembered decisions in the audit log, got %+v", log)
+		}
+
+		if err := session.ForgetPermissions("shell(git)"); err != nil {
+			t.Fatalf("Failed to forget: %v", err)
+		}
+		session.handlePermissionRequest(permissionRequest(t, git))
+		if calls != 3 {
+			t.Errorf("Expected the handler to be asked again after forgetting, got %d calls", calls)
+		}
+	})
+
+	t.Run("ignores scopes that contradict the decision", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{Kind: "denied-by-rules", Scope: AlwaysAllowTool}, nil
+		})
+		session.handlePermissionRequest(permissionRequest(t, git))
+		if remembered := session.RememberedPermissions(); len(remembered) != 0 {
+			t.Errorf("Expected nothing remembered, got %v", remembered)
+		}
+	})
+
+	t.Run("persists decisions to the store", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app", "permissions.json")
+		store := NewFilePermissionStore(path)
+		session := newSession("s1", nil, "")
+		session.setPermissionScopes(store, nil)
+		session.registerPermissionHandler(func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{Kind: "approved", Scope: AlwaysAllowTool}, nil
+		})
+		session.handlePermissionRequest(permissionRequest(t, git))
+
+		remembered, err := loadPermissionScopes(NewFilePermissionStore(path))
+		if err != nil {
+			t.Fatalf("Failed to load: %v", err)
+		}
+		if remembered["shell(git)"] != AlwaysAllowTool {
+			t.Errorf("Expected the decision to be saved, got %v", remembered)
+		}
+
+		if err := session.ForgetPermissions(); err != nil {
+			t.Fatalf("Failed to forget: %v", err)
+		}
+		if remembered, _ := store.LoadPermissions(); len(remembered) != 0 {
+			t.Errorf("Expected the store to be cleared, got %v", remembered)
+		}
+	})
+
+	t.Run("rejects invalid stored scopes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "permissions.json")
+		os.WriteFile(path, []byte(`{"shell(git)":"sometimes"}`), 0o600)
+		if _, err := loadPermissionScopes(NewFilePermissionStore(path)); err == nil {
+			t.Error("Expected an error for an invalid scope")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeaturePins is the CLI feature flag, reported by [Client.Capabilities], for
+// pinned messages through the session.pins RPCs. The CLI keeps pinned
+// messages verbatim when it compacts the session.
+const FeaturePins = "pins"
+
+// Pin marks a user or assistant message, such as a requirement or constraint,
+// to be kept verbatim: compaction, including Compaction.Compact and
+// [AutoCompactConfig], summarizes the messages around it but not the message
+// itself, and every [TruncationPolicy] strategy sends it with each message.
+// messageID is the ID of the message's user.message or assistant.message
+// event. Pinning a pinned message does nothing.
+//
+// Example:
+//
+//	requirements, err := session.SendAndWait(ctx, copilot.MessageOptions{
+//	    Prompt: "The service must stay compatible with Go 1.21 and must not add dependencies.",
+//	})
+//	// ...
+//	if err := session.Pin(ctx, requirements.ID); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Session) Pin(ctx context.Context, messageID string) error {
+	if messageID == "" {
+		return errors.New("failed to pin message: message ID must not be empty")
+	}
+	if err := s.requirePins(ctx); err != nil {
+		return err
+	}
+	result, err := s.RPC.Pins.Add(ctx, &rpc.SessionPinsAddParams{MessageID: messageID})
+	if err != nil {
+		return fmt.Errorf("failed to pin message: %w", err)
+	}
+	s.setPinned(result.MessageIDs)
+	return nil
+}
+
+// Unpin removes a pin added by [Session.Pin], so the message can be compacted
+// and truncated like any other. Unpinning a message that is not pinned does
+// nothing.
+func (s *Session) Unpin(ctx context.Context, messageID string) error {
+	if err := s.requirePins(ctx); err != nil {
+		return err
+	}
+	result, err := s.RPC.Pins.Remove(ctx, &rpc.SessionPinsRemoveParams{MessageID: messageID})
+	if err != nil {
+		return fmt.Errorf("failed to unpin message: %w", err)
+	}
+	s.setPinned(result.MessageIDs)
+	return nil
+}
+
+// PinnedMessages returns the IDs of the session's pinned messages, in
+// conversation order, as recorded by the CLI. Pins persist with the session,
+// so a resumed session returns the pins added before it was resumed.
+func (s *Session) PinnedMessages(ctx context.Context) ([]string, error) {
+	if err := s.requirePins(ctx); err != nil {
+		return nil, err
+	}
+	return s.loadPinned(ctx)
+}
+
+// loadPinned reads the pinned messages from the CLI.
+func (s *Session) loadPinned(ctx context.Context) ([]string, error) {
+	result, err := s.RPC.Pins.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+	s.setPinned(result.MessageIDs)
+	return slices.Clone(result.MessageIDs), nil
+}
+
+// pinsSupported reports whether the CLI supports [FeaturePins], and returns
+// its version. It assumes it does when the session cannot check, as in tests.
+func (s *Session) pinsSupported(ctx context.Context) (bool, string, error) {
+	if s.capabilities == nil {
+		return true, "", nil
+	}
+	capabilities, err := s.capabilities(ctx)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	return capabilities.HasFeature(FeaturePins), capabilities.Version, nil
+}
+
+// requirePins returns an error unless the CLI supports [FeaturePins]. Without
+// it, the CLI would compact pinned messages like any other.
+func (s *Session) requirePins(ctx context.Context) error {
+	supported, version, err := s.pinsSupported(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fmt.Errorf("CLI version %s does not support pinned messages", version)
+	}
+	return nil
+}
+
+func (s *Session) setPinned(ids []string) {
+	s.pinnedMux.Lock()
+	defer s.pinnedMux.Unlock()
+	s.pinned = slices.Clone(ids)
+}
+
+// pinnedIDs returns the pinned message IDs known to the session.
+func (s *Session) pinnedIDs() []string {
+	s.pinnedMux.Lock()
+	defer s.pinnedMux.Unlock()
+	return slices.Clone(s.pinned)
+}
@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSession_Pin(t *testing.T) {
+	newPinsSession := func(t *testing.T, features map[string]bool, recorded *bytes.Buffer, calls func(log *replayLog)) *Session {
+		t.Helper()
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"features":        features,
+		})
+		calls(log)
+
+		client := newPlaybackClientForTest(t, log, recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("pins and unpins through the CLI", func(t *testing.T) {
+		var recorded bytes.Buffer
+		session := newPinsSession(t, map[string]bool{FeaturePins: true}, &recorded, func(log *replayLog) {
+			log.call("session.pins.add", map[string]any{"messageIds": []string{"u1"}})
+			log.call("session.pins.add", map[string]any{"messageIds": []string{"u1", "a2"}})
+			log.call("session.pins.remove", map[string]any{"messageIds": []string{"a2"}})
+			log.call("session.pins.list", map[string]any{"messageIds": []string{"a2"}})
+		})
+
+		if err := session.Pin(t.Context(), "u1"); err != nil {
+			t.Fatalf("Failed to pin: %v", err)
+		}
+		if err := session.Pin(t.Context(), "a2"); err != nil {
+			t.Fatalf("Failed to pin: %v", err)
+		}
+		if ids := session.pinnedIDs(); !slices.Equal(ids, []string{"u1", "a2"}) {
+			t.Errorf("Expected pins [u1 a2], got %v", ids)
+		}
+		if err := session.Unpin(t.Context(), "u1"); err != nil {
+			t.Fatalf("Failed to unpin: %v", err)
+		}
+		ids, err := session.PinnedMessages(t.Context())
+		if err != nil || !slices.Equal(ids, []string{"a2"}) {
+			t.Errorf("Expected pins [a2], got %v, %v", ids, err)
+		}
+
+		var sent []string
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if strings.HasPrefix(message.Method, "session.pins.") {
+				sent = append(sent, message.Method+" "+string(message.Params))
+			}
+		}
+		expected := []string{
+			`session.pins.add {"messageId":"u1","sessionId":"s1"}`,
+			`session.pins.add {"messageId":"a2","sessionId":"s1"}`,
+			`session.pins.remove {"messageId":"u1","sessionId":"s1"}`,
+			`session.pins.list {"sessionId":"s1"}`,
+		}
+		if !slices.Equal(sent, expected) {
+			t.Errorf("Expected requests %v, got %v", expected, sent)
+		}
+	})
+
+	t.Run("rejects pins the CLI does not support", func(t *testing.T) {
+		session := newPinsSession(t, map[string]bool{}, nil, func(*replayLog) {})
+
+		err := session.Pin(t.Context(), "u1")
+		if err == nil || !strings.Contains(err.Error(), "1.2.3 does not support pinned messages") {
+			t.Errorf("Expected unsupported error, got %v", err)
+		}
+		if err := session.Pin(t.Context(), ""); err == nil {
+			t.Error("Expected an error for an empty message ID")
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package copilot
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlanStepStatus is the state of a [PlanStep].
+type PlanStepStatus string
+
+const (
+	// PlanStepPending means the agent has not started the step.
+	PlanStepPending PlanStepStatus = "pending"
+	// PlanStepInProgress means the agent is working on the step.
+	PlanStepInProgress PlanStepStatus = "in_progress"
+	// PlanStepCompleted means the agent finished the step.
+	PlanStepCompleted PlanStepStatus = "completed"
+)
+
+// PlanStep is an item of the agent's plan or todo list.
+type PlanStep struct {
+	// Title describes the step.
+	Title string `json:"title"`
+	// Status is how far the agent got with the step.
+	Status PlanStepStatus `json:"status"`
+}
+
+// PlanUpdate is the agent's plan or todo list for a long-running task, as
+// received by [Session.OnPlanUpdated] handlers.
+type PlanUpdate struct {
+	// Steps are the steps of the plan, in order. Empty when the plan was deleted.
+	Steps []PlanStep `json:"steps"`
+	// Source is where the plan came from: "plan.md" for the session's plan
+	// file, or the name of the todo tool the agent called.
+	Source string `json:"source"`
+	// Time is when the SDK received the update.
+	Time time.Time `json:"time"`
+}
+
+// Completed returns how many steps of the plan are completed.
+func (p PlanUpdate) Completed() int {
+	completed := 0
+	for _, step := range p.Steps {
+		if step.Status == PlanStepCompleted {
+			completed++
+		}
+	}
+	return completed
+}
+
+// Current returns the first step in progress, or the first pending step if
+// none is, and false when every step is completed.
+func (p PlanUpdate) Current() (PlanStep, bool) {
+	for _, status := range []PlanStepStatus{PlanStepInProgress, PlanStepPending} {
+		if i := slices.IndexFunc(p.Steps, func(step PlanStep) bool { return step.Status == status }); i >= 0 {
+			return p.Steps[i], true
+		}
+	}
+	return PlanStep{}, false
+}
+
+// PlanUpdatedHandler is a callback for plan updates.
+type PlanUpdatedHandler func(plan PlanUpdate)
+
+// planSource is the Source of plans read from the session's plan file.
+const planSource = "plan.md"
+
+// todoTools are the names of the tools agents call to record a todo list.
+var todoTools = []string{"update_todo", "todo_write", "write_todos", "TodoWrite"}
+
+// OnPlanUpdated subscribes to the agent's plan, so that hosts can render a
+// progress checklist during long autonomous runs. The handler receives the
+// whole plan each time it changes: when the agent writes the session's plan
+// file (reported by session.plan_changed events) or calls a todo tool such as
+// update_todo.
+//
+// Plans are parsed from Markdown task lists ("- [ ] step", "- [x] done",
+// "- [~] in progress") or from todo items with a status. Handlers for plan
+// file changes are called on their own goroutine, after the plan was read;
+// other updates are delivered from the SDK's event dispatch. Updates are
+// delivered one at a time.
+//
+// Returns a function that, when called, unsubscribes the handler.
+//
+// Example:
+//
+//	session.OnPlanUpdated(func(plan copilot.PlanUpdate) {
+//	    for _, step := range plan.Steps {
+//	        fmt.Printf("[%s] %s\n", step.Status, step.Title)
+//	    }
+//	    fmt.Printf("%d/%d done\n", plan.Completed(), len(plan.Steps))
+//	})
+func (s *Session) OnPlanUpdated(handler PlanUpdatedHandler) func() {
+	return s.plans.subscribe(handler)
+}
+
+// ReadPlan reads the session's plan file and returns its steps. The plan has
+// no steps if the file does not exist or holds no task list.
+func (s *Session) ReadPlan(ctx context.Context) (*PlanUpdate, error) {
+	result, err := s.RPC.Plan.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	return &PlanUpdate{Steps: parsePlanMarkdown(deref(result.Content)), Source: planSource, Time: time.Now()}, nil
+}
+
+// planWatcher delivers plan updates to the handlers of OnPlanUpdated.
+type planWatcher struct {
+	mu       sync.Mutex
+	handlers []planHandler
+	nextID   uint64
+	dispatch sync.Mutex // delivers updates to handlers one at a time
+}
+
+type planHandler struct {
+	id uint64
+	fn PlanUpdatedHandler
+}
+
+func (w *planWatcher) subscribe(handler PlanUpdatedHandler) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextID
+	w.nextID++
+	w.handlers = append(w.handlers, planHandler{id: id, fn: handler})
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.handlers = slices.DeleteFunc(w.handlers, func(h planHandler) bool { return h.id == id })
+	}
+}
+
+func (w *planWatcher) active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.handlers) > 0
+}
+
+func (w *planWatcher) publish(plan PlanUpdate) {
+	w.mu.Lock()
+	handlers := slices.Clone(w.handlers)
+	w.mu.Unlock()
+
+	w.dispatch.Lock()
+	defer w.dispatch.Unlock()
+	for _, h := range handlers {
+		func() {
+			defer func() { recover() }() // Ignore handler panics
+			h.fn(plan)
+		}()
+	}
+}
+
+// observePlan publishes the plans found in session events.
+func (s *Session) observePlan(event SessionEvent) {
+	if !s.plans.active() {
+		return
+	}
+	switch event.Type {
+	case ToolExecutionStart:
+		toolName := deref(event.Data.ToolName)
+		if !slices.Contains(todoTools, toolName) {
+			return
+		}
+		if steps, ok := parseTodoArguments(event.Data.Arguments); ok {
+			s.plans.publish(PlanUpdate{Steps: steps, Source: toolName, Time: time.Now()})
+		}
+	case SessionPlanChanged:
+		if event.Data.Operation != nil && *event.Data.Operation == Delete {
+			s.plans.publish(PlanUpdate{Steps: []PlanStep{}, Source: planSource, Time: time.Now()})
+			return
+		}
+		// Read the plan off the dispatch goroutine, which delivers the response
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			plan, err := s.ReadPlan(ctx)
+			if err != nil {
+				s.logger.Warn("failed to read updated plan", "error", err)
+				return
+			}
+			s.plans.publish(*plan)
+		}()
+	}
+}
+
+// planItemPattern matches a Markdown task list item, capturing its checkbox
+// and title.
+var planItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX~>-])\]\s+(.+?)\s*$`)
+
+// parsePlanMarkdown returns the task list items of a Markdown document as
+// steps. "[x]" marks a completed step, and "[~]", "[>]", or "[-]" a step in
+// progress.
+func parsePlanMarkdown(content string) []PlanStep {
+	steps := []PlanStep{}
+	for _, line := range strings.Split(content, "\n") {
+		match := planItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		status := PlanStepPending
+		switch match[1] {
+		case "x", "X":
+			status = PlanStepCompleted
+		case "~", ">", "-":
+			status = PlanStepInProgress
+		}
+		steps = append(steps, PlanStep{Title: match[2], Status: status})
+	}
+	return steps
+}
+
+// parseTodoArguments returns the steps of a todo tool call, whose todos are
+// either a Markdown task list or a list of items with a title and a status.
+func parseTodoArguments(arguments any) ([]PlanStep, bool) {
+	args, ok := arguments.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	switch todos := args["todos"].(type) {
+	case string:
+		return parsePlanMarkdown(todos), true
+	case []any:
+		data, _ := json.Marshal(todos)
+		var items []struct {
+			Content     string `json:"content"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Status      string `json:"status"`
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, false
+		}
+		steps := make([]PlanStep, 0, len(items))
+		for _, item := range items {
+			title := cmp.Or(item.Content, item.Title, item.Description)
+			if title == "" {
+				continue
+			}
+			steps = append(steps, PlanStep{Title: title, Status: todoStatus(item.Status)})
+		}
+		return steps, true
+	}
+	return nil, false
+}
+
+// todoStatus maps the status of a todo item to a PlanStepStatus.
+func todoStatus(status string) PlanStepStatus {
+	switch strings.ToLower(strings.NewReplacer("-", "_", " ", "_").Replace(status)) {
+	case "completed", "complete", "done":
+		return PlanStepCompleted
+	case "in_progress", "active", "running", "started":
+		return PlanStepInProgress
+	}
+	return PlanStepPending
+}
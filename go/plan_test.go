@@ -0,0 +1,107 @@
+package copilot
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParsePlanMarkdown(t *testing.T) {
+	content := "# Plan\n\n- [x] Read the code\n* [~] Write the fix  \n1. [ ] Run the tests\n- not a step\n- [X] Update docs\n"
+	want := []PlanStep{
+		{Title: "Read the code", Status: PlanStepCompleted},
+		{Title: "Write the fix", Status: PlanStepInProgress},
+		{Title: "Run the tests", Status: PlanStepPending},
+		{Title: "Update docs", Status: PlanStepCompleted},
+	}
+	if got := parsePlanMarkdown(content); !slices.Equal(got, want) {
+		t.Errorf("parsePlanMarkdown = %+v, want %+v", got, want)
+	}
+	if got := parsePlanMarkdown("no task list"); got == nil || len(got) != 0 {
+		t.Errorf("Expected no steps, got %#v", got)
+	}
+}
+
+func TestParseTodoArguments(t *testing.T) {
+	steps, ok := parseTodoArguments(map[string]any{"todos": []any{
+		map[string]any{"content": "Reproduce", "status": "completed"},
+		map[string]any{"title": "Fix", "status": "in-progress"},
+		map[string]any{"description": "Verify"},
+		map[string]any{"status": "pending"},
+	}})
+	want := []PlanStep{
+		{Title: "Reproduce", Status: PlanStepCompleted},
+		{Title: "Fix", Status: PlanStepInProgress},
+		{Title: "Verify", Status: PlanStepPending},
+	}
+	if !ok || !slices.Equal(steps, want) {
+		t.Errorf("parseTodoArguments = %+v, %v; want %+v", steps, ok, want)
+	}
+
+	if _, ok := parseTodoArguments(map[string]any{"other": true}); ok {
+		t.Error("Expected arguments without todos to be ignored")
+	}
+}
+
+func TestPlanUpdate_Current(t *testing.T) {
+	plan := PlanUpdate{Steps: []PlanStep{
+		{Title: "a", Status: PlanStepCompleted},
+		{Title: "b", Status: PlanStepPending},
+		{Title: "c", Status: PlanStepInProgress},
+	}}
+	if step, ok := plan.Current(); !ok || step.Title != "c" {
+		t.Errorf("Expected the step in progress, got %+v, %v", step, ok)
+	}
+	if plan.Completed() != 1 {
+		t.Errorf("Expected 1 completed step, got %d", plan.Completed())
+	}
+	if _, ok := (PlanUpdate{Steps: plan.Steps[:1]}).Current(); ok {
+		t.Error("Expected no current step when every step is completed")
+	}
+}
+
+func TestSession_OnPlanUpdated(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.send", map[string]any{"messageId": "m1"})
+	log.event("s1", ToolExecutionStart, map[string]any{"toolCallId": "t1", "toolName": "update_todo", "arguments": map[string]any{"todos": "- [x] Plan\n- [ ] Build"}})
+	log.event("s1", ToolExecutionStart, map[string]any{"toolCallId": "t2", "toolName": "view", "arguments": map[string]any{"todos": "- [ ] Ignored"}})
+	log.event("s1", SessionPlanChanged, map[string]any{"operation": "update"})
+	log.call("session.plan.read", map[string]any{"exists": true, "content": "- [x] Plan\n- [~] Build\n- [ ] Ship"})
+	log.event("s1", SessionPlanChanged, map[string]any{"operation": "delete"})
+	log.event("s1", SessionIdle, map[string]any{})
+
+	client := newPlaybackClientForTest(t, log, nil)
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	updates := make(chan PlanUpdate, 10)
+	session.OnPlanUpdated(func(plan PlanUpdate) { updates <- plan })
+
+	if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Build it"}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	var got []PlanUpdate
+	for len(got) < 3 {
+		select {
+		case plan := <-updates:
+			got = append(got, plan)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for plan updates, got %+v", got)
+		}
+	}
+	slices.SortStableFunc(got, func(a, b PlanUpdate) int { return len(b.Steps) - len(a.Steps) })
+
+	if got[0].Source != planSource || len(got[0].Steps) != 3 || got[0].Steps[1].Status != PlanStepInProgress {
+		t.Errorf("Unexpected plan file update: %+v", got[0])
+	}
+	if got[1].Source != "update_todo" || len(got[1].Steps) != 2 || got[1].Completed() != 1 {
+		t.Errorf("Unexpected todo tool update: %+v", got[1])
+	}
+	if got[2].Source != planSource || len(got[2].Steps) != 0 {
+		t.Errorf("Expected an empty plan after the plan was deleted, got %+v", got[2])
+	}
+}
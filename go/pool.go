@@ -0,0 +1,214 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sessionPoolCreateTimeout bounds each background session creation.
+const sessionPoolCreateTimeout = time.Minute
+
+// SessionPool keeps sessions created ahead of time so that interactive request
+// paths do not wait for session creation. Create one with [Client.NewSessionPool].
+//
+// A SessionPool is safe for concurrent use.
+type SessionPool struct {
+	client *Client
+	config SessionConfig
+	size   int
+	idle   chan *Session
+	refill chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSessionPool returns a pool that keeps n idle sessions created with config,
+// creating them in the background. config must not set SessionID, since every
+// pooled session needs its own.
+//
+// Pooled sessions all share config, so per-request settings such as Metadata
+// cannot vary. Close the pool before stopping the client, so that it does not
+// start the CLI again to replace sessions.
+//
+// Example:
+//
+//	pool, err := client.NewSessionPool(4, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer pool.Close()
+//
+//	http.HandleFunc("/ask", func(w http.ResponseWriter, r *http.Request) {
+//	    session, err := pool.Acquire(r.Context())
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    defer pool.Release(session)
+//	    // ...
+//	})
+func (c *Client) NewSessionPool(n int, config *SessionConfig) (*SessionPool, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("session pool size must be positive, got %d", n)
+	}
+	if config == nil || config.OnPermissionRequest == nil {
+		return nil, fmt.Errorf("an OnPermissionRequest handler is required when creating a session. For example, to allow all permissions, use &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll}")
+	}
+	if config.SessionID != "" {
+		return nil, fmt.Errorf("SessionID cannot be set for pooled sessions")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &SessionPool{
+		client: c,
+		config: *config,
+		size:   n,
+		idle:   make(chan *Session, n),
+		refill: make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	p.refill <- struct{}{}
+	go p.run(ctx)
+	return p, nil
+}
+
+// Acquire returns an idle session from the pool, or creates one if none is
+// ready. The caller owns the returned session and should pass it to
+// [SessionPool.Release] when done. A replacement is created in the background.
+func (p *SessionPool) Acquire(ctx context.Context) (*Session, error) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, errors.New("session pool is closed")
+	}
+
+	defer p.wake()
+	for {
+		select {
+		case session := <-p.idle:
+			// Sessions can expire or be destroyed by Client.Stop while idle
+			if session.isDestroyed() {
+				continue
+			}
+			return session, nil
+		default:
+			return p.client.CreateSession(ctx, &p.config)
+		}
+	}
+}
+
+// Release hands a session obtained from [SessionPool.Acquire] back to the pool.
+// A session that was never sent a message is kept for reuse if the pool is not
+// full; any other session is destroyed, since its history belongs to the
+// previous request.
+func (p *SessionPool) Release(session *Session) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed && !session.isDestroyed() && session.messagesSent.Load() == 0 {
+		select {
+		case p.idle <- session:
+			return nil
+		default:
+		}
+	}
+	return session.Destroy()
+}
+
+// Idle returns the number of sessions ready to be acquired.
+func (p *SessionPool) Idle() int {
+	return len(p.idle)
+}
+
+// Close stops replacing sessions and destroys the idle ones. Sessions that are
+// acquired remain usable and are destroyed by Release.
+func (p *SessionPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.cancel()
+	<-p.done
+
+	var errs []error
+	for {
+		select {
+		case session := <-p.idle:
+			if err := session.Destroy(); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// wake asks the background loop to top up the pool.
+func (p *SessionPool) wake() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// run creates sessions until the pool is full each time it is woken, backing
+// off while creation fails.
+func (p *SessionPool) run(ctx context.Context) {
+	defer close(p.done)
+	backoff := 100 * time.Millisecond
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.refill:
+		}
+
+		for len(p.idle) < p.size {
+			createCtx, cancel := context.WithTimeout(ctx, sessionPoolCreateTimeout)
+			session, err := p.client.CreateSession(createCtx, &p.config)
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				p.client.options.Logger.Warn("failed to create pooled session", "error", err, "retryIn", backoff)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff = min(backoff*2, 30*time.Second)
+				continue
+			}
+			backoff = 100 * time.Millisecond
+
+			p.mu.Lock()
+			kept := false
+			if !p.closed {
+				select {
+				case p.idle <- session:
+					kept = true
+				default:
+				}
+			}
+			p.mu.Unlock()
+			if !kept {
+				// The pool was closed or filled by Release while creating
+				session.Destroy()
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func waitForIdle(t *testing.T, pool *SessionPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Idle() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d idle sessions, have %d", n, pool.Idle())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSessionPool(t *testing.T) {
+	t.Run("keeps idle sessions ready", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.create", map[string]any{"sessionId": "s2"})
+		log.call("session.create", map[string]any{"sessionId": "s3"})
+		log.call("session.destroy", map[string]any{})
+		log.call("session.destroy", map[string]any{})
+		log.call("session.destroy", map[string]any{})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+
+		pool, err := client.NewSessionPool(2, &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		waitForIdle(t, pool, 2)
+
+		session, err := pool.Acquire(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to acquire session: %v", err)
+		}
+		if session.SessionID != "s1" {
+			t.Errorf("Expected the first pooled session, got %q", session.SessionID)
+		}
+		// The pool creates a replacement, so releasing finds it full
+		waitForIdle(t, pool, 2)
+		if err := pool.Release(session); err != nil {
+			t.Fatalf("Failed to release session: %v", err)
+		}
+		if !session.isDestroyed() {
+			t.Error("Expected the released session to be destroyed when the pool is full")
+		}
+
+		if err := pool.Close(); err != nil {
+			t.Fatalf("Failed to close pool: %v", err)
+		}
+		if pool.Idle() != 0 {
+			t.Errorf("Expected no idle sessions after Close, got %d", pool.Idle())
+		}
+		if _, err := pool.Acquire(t.Context()); err == nil {
+			t.Error("Expected Acquire to fail after Close")
+		}
+
+		var methods []string
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var frame struct {
+				Method string `json:"method"`
+			}
+			json.Unmarshal(record.Message, &frame)
+			if frame.Method != "" {
+				methods = append(methods, frame.Method)
+			}
+		}
+		if len(methods) != 7 || methods[4] != "session.destroy" || methods[6] != "session.destroy" {
+			t.Errorf("Unexpected requests: %v", methods)
+		}
+	})
+
+	t.Run("reuses sessions that were not sent messages", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		client := newPlaybackClientForTest(t, log, nil)
+
+		pool, err := client.NewSessionPool(1, &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		defer pool.cancel()
+		waitForIdle(t, pool, 1)
+
+		// Take the session without waking the refill loop, leaving room for it
+		session := <-pool.idle
+		if err := pool.Release(session); err != nil {
+			t.Fatalf("Failed to release session: %v", err)
+		}
+		if pool.Idle() != 1 || session.isDestroyed() {
+			t.Error("Expected the unused session to return to the pool")
+		}
+	})
+
+	t.Run("validates arguments", func(t *testing.T) {
+		client := NewClient(nil)
+		if _, err := client.NewSessionPool(0, &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll}); err == nil {
+			t.Error("Expected an error for a zero size")
+		}
+		if _, err := client.NewSessionPool(1, &SessionConfig{SessionID: "fixed", OnPermissionRequest: PermissionHandler.ApproveAll}); err == nil {
+			t.Error("Expected an error for a fixed session ID")
+		}
+	})
+}
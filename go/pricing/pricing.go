@@ -0,0 +1,198 @@
+// Package pricing estimates what model usage costs from per-token rates, so
+// applications can show the cost of a conversation.
+//
+// [Default] holds public list prices of common models. Enterprise agreements
+// often differ; put custom rates first with [Chain] and set the result as
+// ClientOptions.Pricing:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    Pricing: pricing.Chain(pricing.Table{
+//	        "gpt-5": {Input: 1.00, Output: 8.00, CacheRead: 0.10},
+//	    }, pricing.Default()),
+//	})
+//	...
+//	estimate := session.EstimatedCost()
+//	fmt.Printf("This conversation cost about $%.4f\n", estimate.Total)
+package pricing
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Rate is the price of a model's tokens, in US dollars per million tokens.
+type Rate struct {
+	// Input is the price of uncached input tokens.
+	Input float64
+	// Output is the price of output tokens, including reasoning tokens.
+	Output float64
+	// CacheRead is the price of input tokens read from the prompt cache.
+	// Zero charges them at the Input rate.
+	CacheRead float64
+	// CacheWrite is the price of input tokens written to the prompt cache.
+	// Zero charges them at the Input rate.
+	CacheWrite float64
+}
+
+// Cost returns the cost in US dollars of usage at this rate.
+func (r Rate) Cost(usage Usage) float64 {
+	cacheRead, cacheWrite := r.CacheRead, r.CacheWrite
+	if cacheRead == 0 {
+		cacheRead = r.Input
+	}
+	if cacheWrite == 0 {
+		cacheWrite = r.Input
+	}
+	return (float64(usage.InputTokens)*r.Input +
+		float64(usage.OutputTokens)*r.Output +
+		float64(usage.CacheReadTokens)*cacheRead +
+		float64(usage.CacheWriteTokens)*cacheWrite) / 1e6
+}
+
+// Usage is the number of tokens a model consumed.
+type Usage struct {
+	// Model is the model ID, e.g. "gpt-5".
+	Model string
+	// Requests is the number of model calls.
+	Requests int
+	// InputTokens excludes tokens read from or written to the prompt cache.
+	InputTokens      int64
+	OutputTokens     int64
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+}
+
+// Pricer looks up the rate of a model.
+type Pricer interface {
+	// Rate returns the rate of model, or false if the model's price is unknown.
+	Rate(model string) (Rate, bool)
+}
+
+// PricerFunc adapts a function to a [Pricer], e.g. to look rates up in a
+// billing service.
+type PricerFunc func(model string) (Rate, bool)
+
+// Rate calls f(model).
+func (f PricerFunc) Rate(model string) (Rate, bool) {
+	return f(model)
+}
+
+// Table is a [Pricer] holding rates by model ID.
+type Table map[string]Rate
+
+// Rate returns the rate of model. A model without its own entry uses the entry
+// of the longest model ID it extends with a "-" suffix, so "gpt-5-2025-08-07"
+// is priced as "gpt-5".
+func (t Table) Rate(model string) (Rate, bool) {
+	if rate, ok := t[model]; ok {
+		return rate, true
+	}
+	var best string
+	for id := range t {
+		if len(id) > len(best) && strings.HasPrefix(model, id+"-") {
+			best = id
+		}
+	}
+	if best == "" {
+		return Rate{}, false
+	}
+	return t[best], true
+}
+
+// Chain returns a [Pricer] that asks each pricer in turn and uses the first
+// rate found.
+func Chain(pricers ...Pricer) Pricer {
+	pricers = slices.Clone(pricers)
+	return PricerFunc(func(model string) (Rate, bool) {
+		for _, p := range pricers {
+			if p == nil {
+				continue
+			}
+			if rate, ok := p.Rate(model); ok {
+				return rate, true
+			}
+		}
+		return Rate{}, false
+	})
+}
+
+// defaultRates are public list prices for direct API use. They are a guide,
+// not a bill: Copilot plans and enterprise agreements are priced differently.
+var defaultRates = Table{
+	"gpt-4o":            {Input: 2.50, Output: 10.00, CacheRead: 1.25},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60, CacheRead: 0.075},
+	"gpt-4.1":           {Input: 2.00, Output: 8.00, CacheRead: 0.50},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+	"gpt-4.1-nano":      {Input: 0.10, Output: 0.40, CacheRead: 0.025},
+	"gpt-5":             {Input: 1.25, Output: 10.00, CacheRead: 0.125},
+	"gpt-5-mini":        {Input: 0.25, Output: 2.00, CacheRead: 0.025},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.40, CacheRead: 0.005},
+	"gpt-5.1":           {Input: 1.25, Output: 10.00, CacheRead: 0.125},
+	"o3":                {Input: 2.00, Output: 8.00, CacheRead: 0.50},
+	"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+	"claude-haiku-4.5":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25},
+	"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-sonnet-4.5": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-opus-4.1":   {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4.5":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10.00, CacheRead: 0.31},
+}
+
+// Default returns the built-in rates: public list prices of common OpenAI,
+// Anthropic, and Google models. The result is a copy that callers may modify.
+func Default() Table {
+	return maps.Clone(defaultRates)
+}
+
+// Estimate is the estimated cost of usage.
+type Estimate struct {
+	// Total is the estimated cost in US dollars of the priced models.
+	Total float64
+	// Models holds the usage and cost of each model, sorted by model ID.
+	Models []ModelCost
+	// Unpriced lists the models without a rate, whose usage is not in Total.
+	Unpriced []string
+}
+
+// ModelCost is the usage and estimated cost of one model.
+type ModelCost struct {
+	Usage
+	// Cost is the estimated cost in US dollars, or zero if the model is unpriced.
+	Cost float64
+}
+
+// Calculate estimates the cost of usage with the rates of pricer. Usage of the
+// same model is combined.
+func Calculate(pricer Pricer, usage ...Usage) Estimate {
+	combined := map[string]*Usage{}
+	for _, u := range usage {
+		total, ok := combined[u.Model]
+		if !ok {
+			total = &Usage{Model: u.Model}
+			combined[u.Model] = total
+		}
+		total.Requests += u.Requests
+		total.InputTokens += u.InputTokens
+		total.OutputTokens += u.OutputTokens
+		total.CacheReadTokens += u.CacheReadTokens
+		total.CacheWriteTokens += u.CacheWriteTokens
+	}
+
+	var estimate Estimate
+	for _, model := range slices.Sorted(maps.Keys(combined)) {
+		cost := ModelCost{Usage: *combined[model]}
+		rate, ok := Rate{}, false
+		if pricer != nil {
+			rate, ok = pricer.Rate(model)
+		}
+		if ok {
+			cost.Cost = rate.Cost(cost.Usage)
+			estimate.Total += cost.Cost
+		} else {
+			estimate.Unpriced = append(estimate.Unpriced, model)
+		}
+		estimate.Models = append(estimate.Models, cost)
+	}
+	return estimate
+}
@@ -0,0 +1,74 @@
+package pricing
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestTable_Rate(t *testing.T) {
+	table := Table{
+		"gpt-5":      {Input: 1},
+		"gpt-5-mini": {Input: 2},
+	}
+	tests := []struct {
+		model string
+		input float64
+		ok    bool
+	}{
+		{"gpt-5", 1, true},
+		{"gpt-5-2025-08-07", 1, true},
+		{"gpt-5-mini", 2, true},
+		{"gpt-5-mini-2025-08-07", 2, true},
+		{"gpt-5.2-codex", 0, false},
+		{"gpt-4.1", 0, false},
+	}
+	for _, test := range tests {
+		rate, ok := table.Rate(test.model)
+		if ok != test.ok || rate.Input != test.input {
+			t.Errorf("Rate(%q) = %+v, %v; want input %v, %v", test.model, rate, ok, test.input, test.ok)
+		}
+	}
+}
+
+func TestRate_Cost(t *testing.T) {
+	rate := Rate{Input: 3, Output: 15, CacheRead: 0.30}
+	cost := rate.Cost(Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 500_000, CacheWriteTokens: 200_000})
+	// 3 + 1.5 + 0.15 + 0.6 (cache writes at the input rate)
+	if math.Abs(cost-5.25) > 1e-9 {
+		t.Errorf("Expected a cost of 5.25, got %v", cost)
+	}
+}
+
+func TestChain(t *testing.T) {
+	custom := PricerFunc(func(model string) (Rate, bool) {
+		return Rate{Input: 0.5}, model == "gpt-5"
+	})
+	pricer := Chain(custom, nil, Default())
+	if rate, _ := pricer.Rate("gpt-5"); rate.Input != 0.5 {
+		t.Errorf("Expected the custom rate to win, got %+v", rate)
+	}
+	if rate, ok := pricer.Rate("claude-sonnet-4.5"); !ok || rate.Input != 3 {
+		t.Errorf("Expected the default rate, got %+v, %v", rate, ok)
+	}
+	if _, ok := pricer.Rate("in-house-model"); ok {
+		t.Error("Expected no rate for an unknown model")
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	estimate := Calculate(Table{"gpt-5": {Input: 1, Output: 10}},
+		Usage{Model: "gpt-5", Requests: 1, InputTokens: 1000, OutputTokens: 100},
+		Usage{Model: "in-house-model", Requests: 1, InputTokens: 500},
+		Usage{Model: "gpt-5", Requests: 1, InputTokens: 1000, OutputTokens: 100},
+	)
+	if math.Abs(estimate.Total-0.004) > 1e-9 {
+		t.Errorf("Expected a total of 0.004, got %v", estimate.Total)
+	}
+	if len(estimate.Models) != 2 || estimate.Models[0].Model != "gpt-5" || estimate.Models[0].Requests != 2 || estimate.Models[0].InputTokens != 2000 {
+		t.Errorf("Unexpected models: %+v", estimate.Models)
+	}
+	if !slices.Equal(estimate.Unpriced, []string{"in-house-model"}) {
+		t.Errorf("Unexpected unpriced models: %v", estimate.Unpriced)
+	}
+}
@@ -0,0 +1,78 @@
+package copilot
+
+import (
+	"sync"
+	"time"
+)
+
+// ProgressPhase is a stage of a [Session.SendAndWait] turn, as reported to
+// MessageOptions.OnProgress.
+type ProgressPhase string
+
+const (
+	// ProgressQueued means the message is waiting for an earlier turn on the session to finish.
+	ProgressQueued ProgressPhase = "queued"
+	// ProgressThinking means the agent is working on the message: planning, reasoning,
+	// or deciding what to do after a tool call.
+	ProgressThinking ProgressPhase = "thinking"
+	// ProgressCallingTool means a tool is running. [Progress.Tool] names it.
+	ProgressCallingTool ProgressPhase = "calling_tool"
+	// ProgressGenerating means the agent is writing its response.
+	ProgressGenerating ProgressPhase = "generating"
+)
+
+// Progress is a phase update for a message sent with [Session.SendAndWait].
+type Progress struct {
+	// Phase is the stage the turn has entered.
+	Phase ProgressPhase
+	// Tool is the name of the running tool when Phase is ProgressCallingTool.
+	Tool string
+	// Time is when the phase started.
+	Time time.Time
+}
+
+// progressReporter derives progress phases from session events and reports each
+// change once.
+type progressReporter struct {
+	mu     sync.Mutex
+	report func(Progress)
+	last   Progress
+}
+
+// newProgressReporter returns a reporter calling report, or nil if report is nil.
+func newProgressReporter(report func(Progress)) *progressReporter {
+	if report == nil {
+		return nil
+	}
+	return &progressReporter{report: report}
+}
+
+// set reports phase unless it is the current phase. It is a no-op on a nil reporter.
+func (p *progressReporter) set(phase ProgressPhase, tool string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last.Phase == phase && p.last.Tool == tool {
+		return
+	}
+	p.last = Progress{Phase: phase, Tool: tool, Time: time.Now()}
+	p.report(p.last)
+}
+
+// observe updates the phase from a session event.
+func (p *progressReporter) observe(event SessionEvent) {
+	switch event.Type {
+	case AssistantTurnStart, AssistantIntent, AssistantReasoning, AssistantReasoningDelta, ToolExecutionComplete:
+		p.set(ProgressThinking, "")
+	case ToolExecutionStart:
+		tool := ""
+		if event.Data.ToolName != nil {
+			tool = *event.Data.ToolName
+		}
+		p.set(ProgressCallingTool, tool)
+	case AssistantMessageDelta, AssistantMessage:
+		p.set(ProgressGenerating, "")
+	}
+}
@@ -0,0 +1,78 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSendAndWait_OnProgress(t *testing.T) {
+	t.Run("reports each phase of a turn once", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", AssistantTurnStart, map[string]any{"turnId": "t1"})
+		log.event("s1", AssistantReasoningDelta, map[string]any{"deltaContent": "Let me", "reasoningId": "r1"})
+		log.event("s1", ToolExecutionStart, map[string]any{"toolCallId": "c1", "toolName": "grep"})
+		log.event("s1", ToolExecutionComplete, map[string]any{"toolCallId": "c1", "success": true})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "Found ", "messageId": "m1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "it", "messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Found it", "messageId": "m1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var updates []Progress
+		start := time.Now()
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{
+			Prompt:     "Find the TODOs",
+			OnProgress: func(p Progress) { updates = append(updates, p) },
+		}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		expected := []Progress{
+			{Phase: ProgressThinking},
+			{Phase: ProgressCallingTool, Tool: "grep"},
+			{Phase: ProgressThinking},
+			{Phase: ProgressGenerating},
+		}
+		if len(updates) != len(expected) {
+			t.Fatalf("Expected %d updates, got %+v", len(expected), updates)
+		}
+		for i, update := range updates {
+			if update.Phase != expected[i].Phase || update.Tool != expected[i].Tool {
+				t.Errorf("Update %d: expected %+v, got %+v", i, expected[i], update)
+			}
+			if update.Time.Before(start) || (i > 0 && update.Time.Before(updates[i-1].Time)) {
+				t.Errorf("Update %d: expected increasing timestamps, got %v", i, update.Time)
+			}
+		}
+	})
+
+	t.Run("reports queued while another turn runs", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.turn <- struct{}{}
+
+		var phases []ProgressPhase
+		_, err := session.SendAndWait(t.Context(), MessageOptions{
+			Prompt:     "Hello",
+			Timeout:    10 * time.Millisecond,
+			OnProgress: func(p Progress) { phases = append(phases, p.Phase) },
+		})
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+		if !slices.Equal(phases, []ProgressPhase{ProgressQueued}) {
+			t.Errorf("Expected only the queued phase, got %v", phases)
+		}
+	})
+}
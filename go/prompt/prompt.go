@@ -0,0 +1,112 @@
+// Package prompt builds [copilot.MessageOptions] from reusable prompt templates.
+//
+// Templates use [text/template] syntax. Besides the usual actions, a template
+// can include partials with {{template "name" .}} and render its few-shot
+// examples with {{examples}}.
+//
+// Example:
+//
+//	review := prompt.Must(prompt.New("review", `
+//	{{template "policy"}}
+//	Review the following {{.Language}} change for bugs.
+//	{{examples}}
+//	{{.Diff}}`))
+//	review.AddPartial("policy", "Never suggest disabling tests.")
+//	review.AddExample("x := 1 / 0", "Division by zero on line 1.")
+//
+//	options, err := review.Compile(map[string]any{"Language": "Go", "Diff": diff})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	response, err := session.SendAndWait(ctx, options)
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Example is a single few-shot exchange rendered by {{examples}}.
+type Example struct {
+	// Input is what the user says.
+	Input string
+	// Output is the response the model should imitate.
+	Output string
+}
+
+// Template is a named prompt template. Templates are safe to compile
+// concurrently once all partials and examples have been added.
+type Template struct {
+	name     string
+	tmpl     *template.Template
+	examples []Example
+}
+
+// New parses text as a prompt template.
+//
+// Compiling fails if the template references a variable that was not provided.
+func New(name, text string) (*Template, error) {
+	t := &Template{name: name}
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"examples": t.renderExamples}).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt template %s: %w", name, err)
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// Must is a helper that wraps a call to [New] and panics if the error is non-nil.
+// It is intended for templates defined in package-level variables.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Name returns the name the template was created with.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// AddPartial defines a named partial that the template can include with
+// {{template "name" .}}. Partials may reference variables and other partials.
+func (t *Template) AddPartial(name, text string) error {
+	if _, err := t.tmpl.New(name).Parse(text); err != nil {
+		return fmt.Errorf("parsing partial %s of prompt template %s: %w", name, t.name, err)
+	}
+	return nil
+}
+
+// AddExample appends a few-shot example rendered by {{examples}}.
+func (t *Template) AddExample(input, output string) {
+	t.examples = append(t.examples, Example{Input: input, Output: output})
+}
+
+// Compile renders the template with vars and returns the resulting message.
+// Surrounding whitespace is trimmed from the rendered prompt.
+func (t *Template) Compile(vars any) (copilot.MessageOptions, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, vars); err != nil {
+		return copilot.MessageOptions{}, fmt.Errorf("rendering prompt template %s: %w", t.name, err)
+	}
+	return copilot.MessageOptions{Prompt: strings.TrimSpace(b.String())}, nil
+}
+
+// renderExamples formats the few-shot examples as tagged user/assistant exchanges.
+func (t *Template) renderExamples() string {
+	var b strings.Builder
+	for i, example := range t.examples {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "<example>\nUser: %s\nAssistant: %s\n</example>\n", example.Input, example.Output)
+	}
+	return b.String()
+}
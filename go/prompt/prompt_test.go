@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Run("renders variables, partials, and examples", func(t *testing.T) {
+		tmpl := Must(New("review", `
+{{template "policy" .}}
+Review this {{.Language}} change.
+{{examples}}
+{{.Diff}}
+`))
+		if err := tmpl.AddPartial("policy", "You work for {{.Team}}."); err != nil {
+			t.Fatalf("Failed to add partial: %v", err)
+		}
+		tmpl.AddExample("x := 1 / 0", "Division by zero.")
+		tmpl.AddExample("return nil, nil", "Ambiguous nil result.")
+
+		options, err := tmpl.Compile(map[string]any{"Team": "payments", "Language": "Go", "Diff": "+ fmt.Println()"})
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		expected := "You work for payments.\nReview this Go change.\n" +
+			"<example>\nUser: x := 1 / 0\nAssistant: Division by zero.\n</example>\n\n" +
+			"<example>\nUser: return nil, nil\nAssistant: Ambiguous nil result.\n</example>\n\n" +
+			"+ fmt.Println()"
+		if options.Prompt != expected {
+			t.Errorf("Expected prompt:\n%q\ngot:\n%q", expected, options.Prompt)
+		}
+	})
+
+	t.Run("accepts structs as variables", func(t *testing.T) {
+		tmpl := Must(New("greet", "Hello {{.Name}}"))
+		options, err := tmpl.Compile(struct{ Name string }{"Mona"})
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+		if options.Prompt != "Hello Mona" {
+			t.Errorf("Expected 'Hello Mona', got %q", options.Prompt)
+		}
+	})
+
+	t.Run("fails on missing variables", func(t *testing.T) {
+		tmpl := Must(New("greet", "Hello {{.Name}}"))
+		_, err := tmpl.Compile(map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "greet") {
+			t.Errorf("Expected error naming the template, got %v", err)
+		}
+	})
+
+	t.Run("fails on missing partials", func(t *testing.T) {
+		tmpl := Must(New("greet", `{{template "signature"}}`))
+		if _, err := tmpl.Compile(nil); err == nil {
+			t.Error("Expected error for undefined partial")
+		}
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		if _, err := New("broken", "{{.Name"); err == nil {
+			t.Error("Expected parse error")
+		}
+		tmpl := Must(New("greet", "Hello"))
+		if err := tmpl.AddPartial("broken", "{{end}}"); err == nil {
+			t.Error("Expected partial parse error")
+		}
+	})
+}
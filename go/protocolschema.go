@@ -0,0 +1,139 @@
+package copilot
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// protocolSchemaFiles holds the JSON schemas of each protocol version's payloads,
+// used by ClientOptions.StrictProtocol.
+//
+//go:embed schemas/protocol-v*.json
+var protocolSchemaFiles embed.FS
+
+// ProtocolSchemaError reports a JSON-RPC payload that does not match the
+// protocol schema, when ClientOptions.StrictProtocol is set. It is returned
+// wrapped in an [*SDKError] with [ErrorCodeProtocolMismatch].
+type ProtocolSchemaError struct {
+	// Method is the JSON-RPC method, e.g. "session.create".
+	Method string
+	// Payload is the part of the message that failed validation: "params" or
+	// "result" of a request sent to the CLI, or "handler params" or "handler
+	// result" of a request the CLI sent.
+	Payload string
+	// ProtocolVersion is the protocol version of the schema.
+	ProtocolVersion int
+	// Err describes the first field that does not match, with its JSON pointer.
+	Err error
+}
+
+func (e *ProtocolSchemaError) Error() string {
+	return fmt.Sprintf("invalid %s %s for protocol version %d: %v", e.Method, e.Payload, e.ProtocolVersion, e.Err)
+}
+
+func (e *ProtocolSchemaError) Unwrap() error {
+	return e.Err
+}
+
+// protocolSchema holds the resolved schemas of one protocol version, keyed by
+// method and then by [jsonrpc2.Payload].
+type protocolSchema struct {
+	version int
+	schemas map[string]map[jsonrpc2.Payload]*jsonschema.Resolved
+}
+
+// protocolSchemaFile is the format of the files in schemas/.
+type protocolSchemaFile struct {
+	ProtocolVersion int                                   `json:"protocolVersion"`
+	Defs            map[string]*jsonschema.Schema         `json:"$defs"`
+	Methods         map[string]map[string]json.RawMessage `json:"methods"`
+	ServerMethods   map[string]map[string]json.RawMessage `json:"serverMethods"`
+}
+
+var loadSDKProtocolSchema = sync.OnceValues(func() (*protocolSchema, error) {
+	return loadProtocolSchema(SdkProtocolVersion)
+})
+
+// loadProtocolSchema reads and resolves the embedded schema of a protocol version.
+func loadProtocolSchema(version int) (*protocolSchema, error) {
+	data, err := protocolSchemaFiles.ReadFile(fmt.Sprintf("schemas/protocol-v%d.json", version))
+	if err != nil {
+		return nil, fmt.Errorf("no schema for protocol version %d: %w", version, err)
+	}
+	var file protocolSchemaFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid schema for protocol version %d: %w", version, err)
+	}
+
+	schema := &protocolSchema{version: version, schemas: map[string]map[jsonrpc2.Payload]*jsonschema.Resolved{}}
+	add := func(methods map[string]map[string]json.RawMessage, params, result jsonrpc2.Payload) error {
+		for method, parts := range methods {
+			for part, raw := range parts {
+				payload := params
+				if part == "result" {
+					payload = result
+				}
+				var s jsonschema.Schema
+				if err := json.Unmarshal(raw, &s); err != nil {
+					return fmt.Errorf("invalid %s %s schema: %w", method, part, err)
+				}
+				// Each payload schema is resolved as its own root, so the shared definitions are attached to it
+				s.Defs = file.Defs
+				resolved, err := s.Resolve(nil)
+				if err != nil {
+					return fmt.Errorf("invalid %s %s schema: %w", method, part, err)
+				}
+				if schema.schemas[method] == nil {
+					schema.schemas[method] = map[jsonrpc2.Payload]*jsonschema.Resolved{}
+				}
+				schema.schemas[method][payload] = resolved
+			}
+		}
+		return nil
+	}
+	if err := add(file.Methods, jsonrpc2.RequestParams, jsonrpc2.RequestResult); err != nil {
+		return nil, err
+	}
+	if err := add(file.ServerMethods, jsonrpc2.HandlerParams, jsonrpc2.HandlerResult); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// validate checks a payload against the schema. Methods and payloads without a
+// schema are not checked.
+func (p *protocolSchema) validate(method string, payload jsonrpc2.Payload, data json.RawMessage) error {
+	resolved := p.schemas[method][payload]
+	if resolved == nil {
+		return nil
+	}
+	var instance any
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &instance); err != nil {
+			return newError(ErrorCodeProtocolMismatch, &ProtocolSchemaError{Method: method, Payload: string(payload), ProtocolVersion: p.version, Err: err})
+		}
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return newError(ErrorCodeProtocolMismatch, &ProtocolSchemaError{Method: method, Payload: string(payload), ProtocolVersion: p.version, Err: err})
+	}
+	return nil
+}
+
+// validateProtocol is the JSON-RPC validator installed when
+// ClientOptions.StrictProtocol is set.
+func (c *Client) validateProtocol(method string, payload jsonrpc2.Payload, data json.RawMessage) error {
+	schema, err := loadSDKProtocolSchema()
+	if err != nil {
+		return newError(ErrorCodeProtocolMismatch, err)
+	}
+	if err := schema.validate(method, payload, data); err != nil {
+		c.options.Logger.Error("protocol schema violation", "method", method, "payload", string(payload), "error", err)
+		return err
+	}
+	return nil
+}
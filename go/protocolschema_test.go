@@ -0,0 +1,107 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+func TestProtocolSchema(t *testing.T) {
+	schema, err := loadProtocolSchema(SdkProtocolVersion)
+	if err != nil {
+		t.Fatalf("Failed to load the schema of the SDK protocol version: %v", err)
+	}
+
+	tests := []struct {
+		method, payload, data string
+		valid                 bool
+	}{
+		{"session.create", "result", `{"sessionId":"s1","workspacePath":"/tmp/ws"}`, true},
+		{"session.create", "result", `{"workspacePath":"/tmp/ws"}`, false},
+		{"session.create", "params", `{"availableTools":null,"reasoningEffort":"extreme"}`, false},
+		{"session.send", "params", `{"sessionId":"s1","prompt":"hi","mode":"enqueue"}`, true},
+		{"session.send", "params", `{"sessionId":"s1","prompt":"hi","temperature":3}`, false},
+		{"session.event", "handler params", `{"sessionId":"s1","event":{"id":"e1","type":"session.idle","timestamp":"now","data":{},"parentId":null}}`, true},
+		{"session.event", "handler params", `{"sessionId":"s1","event":{"id":"e1","timestamp":"now","data":{}}}`, false},
+		{"tool.call", "handler result", `{"result":{"textResultForLlm":"ok","resultType":"succeeded"}}`, false},
+		{"session.unknown", "params", `{"anything":1}`, true},
+	}
+	for _, test := range tests {
+		err := schema.validate(test.method, jsonrpc2.Payload(test.payload), []byte(test.data))
+		if (err == nil) != test.valid {
+			t.Errorf("%s %s %s: expected valid=%v, got %v", test.method, test.payload, test.data, test.valid, err)
+		}
+	}
+}
+
+// newStrictPlaybackClient starts a playback client with StrictProtocol set.
+func newStrictPlaybackClient(t *testing.T, log *replayLog) *Client {
+	t.Helper()
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	client.options.StrictProtocol = true
+	client.options.DiagnosticsDir = t.TempDir()
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start playback client: %v", err)
+	}
+	return client
+}
+
+func TestClient_StrictProtocol(t *testing.T) {
+	t.Run("accepts a valid conversation", func(t *testing.T) {
+		client := newStrictPlaybackClient(t, simpleConversationLog())
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil || *response.Data.Content != "4" {
+			t.Errorf("Unexpected response: %+v, %v", response, err)
+		}
+	})
+
+	t.Run("fails on an invalid result", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": 42})
+		client := newStrictPlaybackClient(t, log)
+
+		_, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		var schemaErr *ProtocolSchemaError
+		if !errors.As(err, &schemaErr) || !errors.Is(err, ErrProtocolMismatch) {
+			t.Fatalf("Expected a ProtocolSchemaError, got %v", err)
+		}
+		if schemaErr.Method != "session.create" || schemaErr.Payload != "result" || !strings.Contains(err.Error(), "sessionId") {
+			t.Errorf("Expected the error to name the method and field, got %v", err)
+		}
+	})
+
+	t.Run("drops invalid events", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.notify("session.event", map[string]any{"sessionId": "s1", "event": map[string]any{"id": "e1", "data": map[string]any{}}})
+		log.event("s1", SessionIdle, map[string]any{})
+		client := newStrictPlaybackClient(t, log)
+
+		events := make(chan SessionEvent, 2)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		session.On(func(event SessionEvent) { events <- event })
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hi"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if event := <-events; event.Type != SessionIdle {
+			t.Errorf("Expected the invalid event to be dropped, got %v", event.Type)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package copilot
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateProvider checks a custom provider configuration and returns the
+// model to request: model itself, or the Azure deployment when model is empty.
+// A nil provider is valid and leaves model unchanged.
+func validateProvider(provider *ProviderConfig, model string) (string, error) {
+	if provider == nil {
+		return model, nil
+	}
+	switch provider.Type {
+	case "", "openai", "azure", "anthropic":
+	default:
+		return "", fmt.Errorf("invalid Provider.Type %q: use \"openai\", \"azure\", or \"anthropic\"", provider.Type)
+	}
+	switch provider.WireApi {
+	case "", "completions", "responses":
+	default:
+		return "", fmt.Errorf("invalid Provider.WireApi %q: use \"completions\" or \"responses\"", provider.WireApi)
+	}
+	if provider.BaseURL == "" {
+		return "", fmt.Errorf("Provider.BaseURL is required")
+	}
+	u, err := url.Parse(provider.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid Provider.BaseURL %q: expected an http or https URL", provider.BaseURL)
+	}
+
+	isAzureHost := strings.HasSuffix(strings.ToLower(u.Hostname()), ".openai.azure.com")
+	if provider.Type == "azure" {
+		if strings.Contains(u.Path, "/openai") {
+			return "", fmt.Errorf("invalid Provider.BaseURL %q: use just the host for Azure endpoints, without /openai", provider.BaseURL)
+		}
+		if model == "" && provider.Azure != nil {
+			model = provider.Azure.Deployment
+		}
+	} else {
+		if isAzureHost {
+			return "", fmt.Errorf("Provider.Type must be \"azure\" for Azure OpenAI endpoints, got %q", provider.Type)
+		}
+		if provider.Azure != nil {
+			return "", fmt.Errorf("Provider.Azure is only valid with Type \"azure\"")
+		}
+	}
+	if model == "" {
+		return "", fmt.Errorf("Model is required when using a custom provider")
+	}
+	return model, nil
+}
@@ -0,0 +1,43 @@
+package copilot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider *ProviderConfig
+		model    string
+		expected string
+		err      string
+	}{
+		{"no provider", nil, "", "", ""},
+		{"ollama", &ProviderConfig{BaseURL: "http://localhost:11434/v1"}, "llama3", "llama3", ""},
+		{"azure deployment", &ProviderConfig{Type: "azure", BaseURL: "https://r.openai.azure.com", Azure: &AzureProviderOptions{Deployment: "gpt4o-prod"}}, "", "gpt4o-prod", ""},
+		{"model wins over deployment", &ProviderConfig{Type: "azure", BaseURL: "https://r.openai.azure.com", Azure: &AzureProviderOptions{Deployment: "gpt4o-prod"}}, "gpt-4o", "gpt-4o", ""},
+		{"missing model", &ProviderConfig{BaseURL: "https://gateway.example.com/v1"}, "", "", "Model is required"},
+		{"missing base URL", &ProviderConfig{}, "gpt-4o", "", "BaseURL is required"},
+		{"relative base URL", &ProviderConfig{BaseURL: "gateway/v1"}, "gpt-4o", "", "expected an http or https URL"},
+		{"unknown type", &ProviderConfig{Type: "gemini", BaseURL: "https://example.com"}, "m", "", "invalid Provider.Type"},
+		{"unknown wire API", &ProviderConfig{WireApi: "chat", BaseURL: "https://example.com"}, "m", "", "invalid Provider.WireApi"},
+		{"azure host as openai", &ProviderConfig{BaseURL: "https://r.openai.azure.com"}, "m", "", `must be "azure"`},
+		{"azure path", &ProviderConfig{Type: "azure", BaseURL: "https://r.openai.azure.com/openai/v1"}, "m", "", "without /openai"},
+		{"azure options without azure type", &ProviderConfig{BaseURL: "https://example.com", Azure: &AzureProviderOptions{}}, "m", "", "only valid with Type"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := validateProvider(test.provider, test.model)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil || model != test.expected {
+				t.Errorf("Expected model %q, got %q, %v", test.expected, model, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultMaxQueuedMessages is the queue capacity used when SessionConfig.MaxQueuedMessages is zero.
+const defaultMaxQueuedMessages = 16
+
+// QueuedMessage is a handle to a message accepted by [Session.Enqueue].
+type QueuedMessage struct {
+	done     chan struct{}
+	response *SessionEvent
+	err      error
+}
+
+// Done returns a channel that is closed once the message has been processed.
+func (m *QueuedMessage) Done() <-chan struct{} {
+	return m.done
+}
+
+// Wait blocks until the message has been processed or ctx is done, and returns
+// the result of [Session.SendAndWait] for the message.
+func (m *QueuedMessage) Wait(ctx context.Context) (*SessionEvent, error) {
+	select {
+	case <-m.done:
+		return m.response, m.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type queuedItem struct {
+	ctx     context.Context
+	options MessageOptions
+	message *QueuedMessage
+}
+
+// messageQueue delivers enqueued messages to a session one at a time, in order.
+type messageQueue struct {
+	session  *Session
+	capacity int
+
+	mu      sync.Mutex
+	items   []queuedItem // waiting messages, oldest first
+	closed  bool
+	running bool // a goroutine is delivering items
+}
+
+func newMessageQueue(session *Session, capacity int) *messageQueue {
+	if capacity <= 0 {
+		capacity = defaultMaxQueuedMessages
+	}
+	return &messageQueue{session: session, capacity: capacity}
+}
+
+func (q *messageQueue) enqueue(ctx context.Context, options MessageOptions) (*QueuedMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, fmt.Errorf("session %s has been destroyed", q.session.SessionID)
+	}
+	if len(q.items) >= q.capacity {
+		return nil, &SDKError{
+			Code:    ErrorCodeQueueFull,
+			Message: fmt.Sprintf("message queue for session %s is full (%d pending)", q.session.SessionID, q.capacity),
+		}
+	}
+
+	message := &QueuedMessage{done: make(chan struct{})}
+	q.items = append(q.items, queuedItem{ctx: ctx, options: options, message: message})
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+	return message, nil
+}
+
+// run delivers items until the queue is empty or closed.
+func (q *messageQueue) run() {
+	for {
+		q.mu.Lock()
+		if q.closed || len(q.items) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		item := q.items[0]
+		q.items = q.items[1:]
+		q.mu.Unlock()
+
+		if err := item.ctx.Err(); err != nil {
+			item.message.err = err
+		} else {
+			item.message.response, item.message.err = q.session.SendAndWait(item.ctx, item.options)
+		}
+		close(item.message.done)
+	}
+}
+
+// close fails all messages still waiting in the queue and rejects new ones.
+func (q *messageQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	pending := q.items
+	q.items = nil
+	q.mu.Unlock()
+
+	for _, item := range pending {
+		item.message.err = fmt.Errorf("session %s was destroyed before the message was sent", q.session.SessionID)
+		close(item.message.done)
+	}
+}
@@ -0,0 +1,184 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QuotaExceededError is returned by [Session.Send] when a message exceeds a limit
+// configured in [ClientOptions.RateLimit].
+type QuotaExceededError struct {
+	SessionID  string
+	Limit      QuotaLimit
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("quota exceeded for session %s: %s (retry after %s)", e.SessionID, e.Limit, e.RetryAfter)
+	}
+	return fmt.Sprintf("quota exceeded for session %s: %s", e.SessionID, e.Limit)
+}
+
+// rateLimiter enforces a RateLimitConfig across all sessions of a client.
+type rateLimiter struct {
+	config     RateLimitConfig
+	now        func() time.Time
+	onExceeded func(QuotaExceededEvent) // reports exceeded quotas to Client.Events
+
+	mu              sync.Mutex
+	requests        []time.Time // send times within the last minute
+	tokens          []tokenUsage
+	sessionMessages map[string]int
+}
+
+type tokenUsage struct {
+	at     time.Time
+	tokens int
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:          config,
+		now:             time.Now,
+		sessionMessages: make(map[string]int),
+	}
+}
+
+// acquire reserves capacity for one message to the given session and returns
+// the time it was counted at, which identifies the reservation to release.
+// Depending on the policy, it either waits for capacity or returns a
+// *QuotaExceededError.
+func (l *rateLimiter) acquire(ctx context.Context, sessionID string) (time.Time, error) {
+	notified := false
+	for {
+		l.mu.Lock()
+		now := l.now()
+		limit, retryAfter := l.check(sessionID, now)
+		if limit == "" {
+			l.requests = append(l.requests, now)
+			l.sessionMessages[sessionID]++
+			l.mu.Unlock()
+			return now, nil
+		}
+		config := l.config
+		l.mu.Unlock()
+
+		if !notified {
+			event := QuotaExceededEvent{SessionID: sessionID, Limit: limit, RetryAfter: retryAfter}
+			if config.OnQuotaExceeded != nil {
+				config.OnQuotaExceeded(event)
+			}
+			if l.onExceeded != nil {
+				l.onExceeded(event)
+			}
+		}
+		notified = true
+
+		if limit == QuotaSessionMessages || config.Policy == RateLimitReject {
+			return time.Time{}, &QuotaExceededError{SessionID: sessionID, Limit: limit, RetryAfter: retryAfter}
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Time{}, fmt.Errorf("waiting for rate limit: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// release gives back the capacity acquired at the given time for a message to
+// the given session that was not sent. Only that request is removed, so sends
+// counted after it by other sessions keep counting.
+func (l *rateLimiter) release(sessionID string, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sessionMessages[sessionID] > 0 {
+		l.sessionMessages[sessionID]--
+	}
+	// The request may already have left the window
+	for i := len(l.requests) - 1; i >= 0; i-- {
+		if l.requests[i].Equal(at) {
+			l.requests = slices.Delete(l.requests, i, i+1)
+			break
+		}
+	}
+}
+
+// record counts a message to the given session that was sent without acquire,
+// such as a fallback attempt after the first attempt was released.
+func (l *rateLimiter) record(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, l.now())
+	l.sessionMessages[sessionID]++
+}
+
+// forget drops the message count of a session that was destroyed or deleted.
+func (l *rateLimiter) forget(sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.sessionMessages, sessionID)
+}
+
+// setConfig replaces the limits. Sends and tokens already counted still count
+// toward the new limits, and waiting sends are checked against them on their
+// next attempt.
+func (l *rateLimiter) setConfig(config RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+}
+
+// check returns the first exceeded limit and how long until it frees up.
+// Must be called with l.mu held.
+func (l *rateLimiter) check(sessionID string, now time.Time) (QuotaLimit, time.Duration) {
+	if maxMessages := l.config.MaxMessagesPerSession; maxMessages > 0 && l.sessionMessages[sessionID] >= maxMessages {
+		return QuotaSessionMessages, 0
+	}
+
+	for len(l.requests) > 0 && !l.requests[0].After(now.Add(-time.Minute)) {
+		l.requests = l.requests[1:]
+	}
+	if rpm := l.config.RequestsPerMinute; rpm > 0 && len(l.requests) >= rpm {
+		return QuotaRequestsPerMinute, l.requests[0].Add(time.Minute).Sub(now)
+	}
+
+	for len(l.tokens) > 0 && !l.tokens[0].at.After(now.Add(-time.Hour)) {
+		l.tokens = l.tokens[1:]
+	}
+	if tph := l.config.TokensPerHour; tph > 0 {
+		used := 0
+		for _, usage := range l.tokens {
+			used += usage.tokens
+		}
+		if used >= tph {
+			return QuotaTokensPerHour, l.tokens[0].at.Add(time.Hour).Sub(now)
+		}
+	}
+
+	return "", 0
+}
+
+// recordTokens counts tokens reported by an assistant.usage event.
+func (l *rateLimiter) recordTokens(event SessionEvent) {
+	tokens := 0
+	if event.Data.InputTokens != nil {
+		tokens += int(*event.Data.InputTokens)
+	}
+	if event.Data.OutputTokens != nil {
+		tokens += int(*event.Data.OutputTokens)
+	}
+	if tokens == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = append(l.tokens, tokenUsage{at: l.now(), tokens: tokens})
+}
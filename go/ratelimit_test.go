@@ -0,0 +1,153 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func usageEvent(input, output float64) SessionEvent {
+	return SessionEvent{Type: AssistantUsage, Data: Data{InputTokens: &input, OutputTokens: &output}}
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("rejects requests over the per-minute limit", func(t *testing.T) {
+		var events []QuotaExceededEvent
+		limiter := newRateLimiter(RateLimitConfig{
+			RequestsPerMinute: 2,
+			Policy:            RateLimitReject,
+			OnQuotaExceeded:   func(event QuotaExceededEvent) { events = append(events, event) },
+		})
+		now := time.Now()
+		limiter.now = func() time.Time { return now }
+
+		for i := range 2 {
+			if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+				t.Fatalf("Expected request %d to be allowed, got %v", i, err)
+			}
+		}
+
+		_, err := limiter.acquire(t.Context(), "s2")
+		var quotaErr *QuotaExceededError
+		if !errors.As(err, &quotaErr) {
+			t.Fatalf("Expected QuotaExceededError, got %v", err)
+		}
+		if quotaErr.Limit != QuotaRequestsPerMinute || quotaErr.RetryAfter != time.Minute {
+			t.Errorf("Expected requests_per_minute with 1m retry, got %s after %s", quotaErr.Limit, quotaErr.RetryAfter)
+		}
+		if len(events) != 1 || events[0].SessionID != "s2" {
+			t.Errorf("Expected one OnQuotaExceeded event for s2, got %v", events)
+		}
+
+		now = now.Add(time.Minute)
+		if _, err := limiter.acquire(t.Context(), "s2"); err != nil {
+			t.Errorf("Expected request to be allowed after the window, got %v", err)
+		}
+	})
+
+	t.Run("rejects requests over the token budget", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimitConfig{TokensPerHour: 100, Policy: RateLimitReject})
+
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected first request to be allowed, got %v", err)
+		}
+		limiter.recordTokens(usageEvent(60, 40))
+
+		var quotaErr *QuotaExceededError
+		if _, err := limiter.acquire(t.Context(), "s1"); !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaTokensPerHour {
+			t.Errorf("Expected tokens_per_hour quota error, got %v", err)
+		}
+	})
+
+	t.Run("always rejects requests over the per-session cap", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimitConfig{MaxMessagesPerSession: 1, Policy: RateLimitQueue})
+
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected first request to be allowed, got %v", err)
+		}
+
+		var quotaErr *QuotaExceededError
+		if _, err := limiter.acquire(t.Context(), "s1"); !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaSessionMessages {
+			t.Errorf("Expected session_messages quota error, got %v", err)
+		}
+		if _, err := limiter.acquire(t.Context(), "s2"); err != nil {
+			t.Errorf("Expected other sessions to be unaffected, got %v", err)
+		}
+	})
+
+	t.Run("does not count released or forgotten messages", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimitConfig{RequestsPerMinute: 1, MaxMessagesPerSession: 1, Policy: RateLimitReject})
+
+		at, err := limiter.acquire(t.Context(), "s1")
+		if err != nil {
+			t.Fatalf("Expected first request to be allowed, got %v", err)
+		}
+		limiter.release("s1", at)
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected a released request not to count, got %v", err)
+		}
+
+		limiter.setConfig(RateLimitConfig{MaxMessagesPerSession: 1, Policy: RateLimitReject})
+		limiter.forget("s1")
+		if _, ok := limiter.sessionMessages["s1"]; ok {
+			t.Error("Expected the session's count to be dropped")
+		}
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Errorf("Expected a forgotten session to start over, got %v", err)
+		}
+	})
+
+	t.Run("releases only the request it was given", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimitConfig{RequestsPerMinute: 2, Policy: RateLimitReject})
+		start := time.Now()
+		limiter.now = func() time.Time { return start }
+		first, _ := limiter.acquire(t.Context(), "s1")
+		limiter.now = func() time.Time { return start.Add(time.Second) }
+		second, _ := limiter.acquire(t.Context(), "s2")
+
+		limiter.release("s1", first)
+		if len(limiter.requests) != 1 || !limiter.requests[0].Equal(second) {
+			t.Errorf("Expected s2's request to keep counting, got %v", limiter.requests)
+		}
+	})
+
+	t.Run("queues requests until capacity is available", func(t *testing.T) {
+		var notified int
+		limiter := newRateLimiter(RateLimitConfig{
+			RequestsPerMinute: 1,
+			OnQuotaExceeded:   func(QuotaExceededEvent) { notified++ },
+		})
+		start := time.Now()
+		var calls int
+		limiter.now = func() time.Time {
+			// Jump past the window after the first blocked check
+			calls++
+			if calls > 2 {
+				return start.Add(time.Minute)
+			}
+			return start.Add(time.Minute - 10*time.Millisecond)
+		}
+		limiter.requests = []time.Time{start}
+
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected queued request to succeed, got %v", err)
+		}
+		if notified != 1 {
+			t.Errorf("Expected OnQuotaExceeded to be called once, got %d", notified)
+		}
+	})
+
+	t.Run("stops queueing when the context is cancelled", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimitConfig{RequestsPerMinute: 1})
+		if _, err := limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected first request to be allowed, got %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := limiter.acquire(ctx, "s1"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+}
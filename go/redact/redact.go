@@ -0,0 +1,283 @@
+// Package redact removes secrets and personal data from text before it leaves
+// the process in logs, wire dumps, transcripts, and diagnostics bundles.
+//
+// A [Redactor] applies a list of [Rule] values to strings, and additionally
+// replaces the values of credential-like fields, such as "apiKey" or
+// "authorization", when redacting JSON. The client applies
+// ClientOptions.Redactor, by default [Default], to everything it logs, dumps,
+// or exports:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    Redactor: redact.New(append(redact.DefaultRules(),
+//	        redact.Regex("ticket", `\bSEC-\d+\b`),
+//	    )...),
+//	})
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Placeholder replaces the values of credential-like JSON fields and log attributes.
+const Placeholder = "[REDACTED]"
+
+// Rule finds text to redact. Matches are replaced with "[REDACTED:<Name>]".
+// Create rules with [Regex] or [Detector].
+type Rule struct {
+	// Name identifies the kind of data, e.g. "github-token".
+	Name string
+	// Pattern matches the text to redact. When it has a capturing group, only
+	// the first group is redacted, so context such as "Bearer " is kept.
+	Pattern *regexp.Regexp
+	// Detect returns the [start, end) byte ranges of s to redact, for data a
+	// regular expression cannot describe. It is used when Pattern is nil.
+	Detect func(s string) [][2]int
+}
+
+// Regex returns a rule redacting matches of pattern. It panics if pattern does
+// not compile, like [regexp.MustCompile].
+func Regex(name, pattern string) Rule {
+	return Rule{Name: name, Pattern: regexp.MustCompile(pattern)}
+}
+
+// Detector returns a rule redacting the byte ranges detect finds.
+func Detector(name string, detect func(s string) [][2]int) Rule {
+	return Rule{Name: name, Detect: detect}
+}
+
+// Built-in rules.
+var (
+	// GitHubToken matches GitHub personal access, OAuth, app, and refresh tokens.
+	GitHubToken = Regex("github-token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)
+	// AWSAccessKey matches AWS access key IDs.
+	AWSAccessKey = Regex("aws-access-key", `\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`)
+	// APIKey matches "sk-" style API keys used by OpenAI, Anthropic, and others.
+	APIKey = Regex("api-key", `\bsk-[A-Za-z0-9_-]{20,}`)
+	// JWT matches JSON Web Tokens.
+	JWT = Regex("jwt", `\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)
+	// BearerToken matches the credential in "Bearer <token>", keeping the scheme.
+	BearerToken = Regex("bearer-token", `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{8,}=*)`)
+	// PrivateKey matches PEM and OpenSSH private key blocks, including ones cut
+	// off before their END line.
+	PrivateKey = Detector("private-key", detectPrivateKeys)
+	// Email matches email addresses.
+	Email = Regex("email", `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)
+)
+
+// DefaultRules returns the rules used by [Default]: tokens, keys, private keys,
+// and email addresses. The result is a new slice that callers may extend.
+func DefaultRules() []Rule {
+	return []Rule{PrivateKey, GitHubToken, AWSAccessKey, APIKey, JWT, BearerToken, Email}
+}
+
+// Redactor applies redaction rules. A Redactor is immutable and safe for
+// concurrent use.
+type Redactor struct {
+	rules []Rule
+}
+
+// New returns a redactor applying rules in order.
+func New(rules ...Rule) *Redactor {
+	return &Redactor{rules: slices.Clone(rules)}
+}
+
+var defaultRedactor = New(DefaultRules()...)
+
+// Default returns the redactor with [DefaultRules].
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// With returns a redactor applying r's rules followed by rules.
+func (r *Redactor) With(rules ...Rule) *Redactor {
+	return &Redactor{rules: append(slices.Clone(r.rules), rules...)}
+}
+
+// String returns s with every rule's matches replaced.
+func (r *Redactor) String(s string) string {
+	for _, rule := range r.rules {
+		s = rule.apply(s)
+	}
+	return s
+}
+
+func (rule Rule) apply(s string) string {
+	var ranges [][2]int
+	if rule.Pattern != nil {
+		for _, m := range rule.Pattern.FindAllStringSubmatchIndex(s, -1) {
+			if len(m) >= 4 && m[2] >= 0 {
+				ranges = append(ranges, [2]int{m[2], m[3]})
+			} else {
+				ranges = append(ranges, [2]int{m[0], m[1]})
+			}
+		}
+	} else if rule.Detect != nil {
+		ranges = rule.Detect(s)
+	}
+	if len(ranges) == 0 {
+		return s
+	}
+
+	replacement := "[REDACTED:" + rule.Name + "]"
+	var b strings.Builder
+	last := 0
+	for _, span := range ranges {
+		if span[0] < last || span[1] > len(s) || span[0] > span[1] {
+			continue
+		}
+		b.WriteString(s[last:span[0]])
+		b.WriteString(replacement)
+		last = span[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// JSON returns data with the values of credential-like fields replaced by
+// [Placeholder] and every string redacted with the rules. Data that is not
+// valid JSON is redacted as text.
+func (r *Redactor) JSON(data []byte) []byte {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []byte(r.String(string(data)))
+	}
+	redacted, err := json.Marshal(r.Value(value))
+	if err != nil {
+		return []byte(r.String(string(data)))
+	}
+	return redacted
+}
+
+// Value redacts a decoded JSON value in place, as [Redactor.JSON] does, and returns it.
+func (r *Redactor) Value(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if SensitiveKey(key) {
+				v[key] = Placeholder
+			} else {
+				v[key] = r.Value(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.Value(item)
+		}
+	case string:
+		return r.String(v)
+	}
+	return value
+}
+
+// sensitiveKeys are substrings of field names whose values are always redacted.
+// Names ending in "token" are redacted too; token counts ("outputTokens") are not.
+var sensitiveKeys = []string{"secret", "password", "authorization", "apikey", "api_key", "credential", "cookie"}
+
+// SensitiveKey reports whether a JSON field or log attribute named key holds a
+// credential, e.g. "githubToken", "apiKey", or "Authorization".
+func SensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "token") {
+		return true
+	}
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler returns a [slog.Handler] that redacts each record's message and
+// attributes before passing it to next. Attributes with credential-like keys
+// are replaced by [Placeholder]; strings, errors, and [fmt.Stringer] values are
+// redacted with the rules.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	return &handler{redactor: r, next: next}
+}
+
+type handler struct {
+	redactor *Redactor
+	next     slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.attr(attr)
+	}
+	return &handler{redactor: h.redactor, next: h.next.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{redactor: h.redactor, next: h.next.WithGroup(name)}
+}
+
+func (h *handler) attr(attr slog.Attr) slog.Attr {
+	if SensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Placeholder)
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		attrs := make([]any, len(group))
+		for i, a := range group {
+			attrs[i] = h.attr(a)
+		}
+		return slog.Group(attr.Key, attrs...)
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, h.redactor.String(v.Error()))
+		case fmt.Stringer:
+			return slog.String(attr.Key, h.redactor.String(v.String()))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// privateKeyBegin matches the first line of a PEM or OpenSSH private key.
+var privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+
+// detectPrivateKeys finds private key blocks, from the BEGIN line through the
+// matching END line, or to the end of s when the block is truncated.
+func detectPrivateKeys(s string) [][2]int {
+	var ranges [][2]int
+	offset := 0
+	for {
+		m := privateKeyBegin.FindStringIndex(s[offset:])
+		if m == nil {
+			return ranges
+		}
+		start := offset + m[0]
+		end := len(s)
+		footer := strings.Replace(s[start:offset+m[1]], "BEGIN", "END", 1)
+		if i := strings.Index(s[offset+m[1]:], footer); i >= 0 {
+			end = offset + m[1] + i + len(footer)
+		}
+		ranges = append(ranges, [2]int{start, end})
+		offset = end
+	}
+}
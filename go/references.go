@@ -0,0 +1,319 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeatureReferences is the CLI feature flag, reported by
+// [Client.Capabilities], for structured references listed by the CLI through
+// session.references.list, which [Session.References] uses when available.
+const FeatureReferences = "references"
+
+// ReferenceKind classifies a [Reference].
+type ReferenceKind string
+
+const (
+	// ReferenceFile is a path to a file in the workspace, optionally with a line
+	// or range of lines.
+	ReferenceFile ReferenceKind = "file"
+	// ReferenceURL is an http or https URL.
+	ReferenceURL ReferenceKind = "url"
+	// ReferenceSymbol is a code symbol such as a function, type, or method.
+	ReferenceSymbol ReferenceKind = "symbol"
+)
+
+// Reference is a file, URL, or code symbol mentioned in a message, for hosts
+// that hyperlink them.
+type Reference struct {
+	// Kind classifies the reference.
+	Kind ReferenceKind `json:"kind"`
+	// Target is the file path, URL, or symbol name referred to, without line
+	// numbers.
+	Target string `json:"target"`
+	// Text is the reference as written in the message, e.g. a link's text.
+	Text string `json:"text,omitempty"`
+	// Line is the line referenced in a file, starting at 1, or 0 if none is.
+	Line int `json:"line,omitempty"`
+	// EndLine is the last line of a referenced range, or 0 for a single line.
+	EndLine int `json:"endLine,omitempty"`
+	// Start and End are the byte offsets of the mention in the message content,
+	// for replacing it with a link: the whole Markdown link for links, and the
+	// text between the backticks for inline code. Both are 0 when the CLI did
+	// not report them.
+	Start int `json:"start,omitempty"`
+	End   int `json:"end,omitempty"`
+}
+
+// References returns the files, URLs, and code symbols mentioned in the
+// event's content, in order of appearance, as found by [ParseReferences]. It
+// returns nil for events without content.
+//
+// Example:
+//
+//	response, err := session.SendAndWait(ctx, copilot.MessageOptions{Prompt: "Where is auth handled?"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, ref := range response.References() {
+//	    fmt.Printf("%s %s:%d\n", ref.Kind, ref.Target, ref.Line)
+//	}
+func (r *SessionEvent) References() []Reference {
+	if r.Data.Content == nil {
+		return nil
+	}
+	return ParseReferences(*r.Data.Content)
+}
+
+// References returns the references in the assistant message with the given
+// message ID or event ID. When the CLI supports [FeatureReferences], the
+// structured references it recorded for the message are returned; otherwise
+// the message is read from the history and parsed with [ParseReferences].
+func (s *Session) References(ctx context.Context, messageID string) ([]Reference, error) {
+	if s.capabilities != nil {
+		capabilities, err := s.capabilities(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check CLI capabilities: %w", err)
+		}
+		if capabilities.HasFeature(FeatureReferences) {
+			result, err := s.RPC.References.List(ctx, &rpc.SessionReferencesListParams{MessageID: messageID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list references: %w", err)
+			}
+			references := make([]Reference, len(result.References))
+			for i, element := range result.References {
+				references[i] = referenceFromRPC(element)
+			}
+			return references, nil
+		}
+	}
+
+	events, err := s.GetMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		event := &events[i]
+		if event.Type == AssistantMessage && (event.ID == messageID || deref(event.Data.MessageID) == messageID) {
+			return event.References(), nil
+		}
+	}
+	return nil, fmt.Errorf("assistant message %q not found in the session history", messageID)
+}
+
+func referenceFromRPC(element rpc.ReferenceElement) Reference {
+	number := func(f *float64) int {
+		if f == nil {
+			return 0
+		}
+		return int(*f)
+	}
+	return Reference{
+		Kind:    ReferenceKind(element.Kind),
+		Target:  element.Target,
+		Text:    deref(element.Text),
+		Line:    number(element.Line),
+		EndLine: number(element.EndLine),
+		Start:   number(element.Start),
+		End:     number(element.End),
+	}
+}
+
+var (
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+	inlineCodePattern   = regexp.MustCompile("`([^`\n]+)`")
+	urlPattern          = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+	filePathPattern     = regexp.MustCompile(`(?:^|[\s(])((?:\.{1,2}/)?(?:[\w.-]+/)+[\w.-]*\.[A-Za-z][A-Za-z0-9]{0,7}(?::\d+(?:-\d+)?)?)`)
+	lineSuffixPattern   = regexp.MustCompile(`^(.+?)(?::(\d+)(?:-(\d+))?|#L(\d+)(?:-L(\d+))?)$`)
+	symbolPattern       = regexp.MustCompile(`^[A-Za-z_$][\w$]*(?:(?:\.|::|#|->)[A-Za-z_$][\w$]*)*(?:\(\))?$`)
+)
+
+// sourceExtensions are file extensions that make a single inline-code word,
+// such as `client.go`, a file reference rather than a symbol.
+var sourceExtensions = map[string]bool{
+	"c": true, "cc": true, "cpp": true, "cs": true, "css": true, "go": true, "h": true,
+	"html": true, "java": true, "js": true, "json": true, "jsx": true, "kt": true,
+	"md": true, "mod": true, "php": true, "proto": true, "py": true, "rb": true,
+	"rs": true, "scss": true, "sh": true, "sql": true, "sum": true, "swift": true,
+	"toml": true, "ts": true, "tsx": true, "txt": true, "xml": true, "yaml": true,
+	"yml": true,
+}
+
+// ParseReferences finds the files, URLs, and code symbols mentioned in
+// Markdown content, in order of appearance:
+//
+//   - Markdown links are URL references when they point to an http or https
+//     URL and file references when they point to a relative path.
+//   - Bare http and https URLs are URL references.
+//   - Paths with a directory and an extension, such as api/users.go, are file
+//     references, in inline code or in text.
+//   - Inline code holding a file name with a known extension, such as
+//     `client.go`, is a file reference.
+//   - Inline code holding an identifier that looks like code, such as
+//     `Client`, `Client.Start()`, or `max_retries`, is a symbol reference.
+//
+// File references may carry a line or range as path:10, path:10-20, or, in
+// links, path#L10-L20. Fenced code blocks are skipped.
+func ParseReferences(content string) []Reference {
+	p := referenceParser{skip: fencedBlocks(content)}
+
+	for _, m := range markdownLinkPattern.FindAllStringSubmatchIndex(content, -1) {
+		text, target := content[m[2]:m[3]], content[m[4]:m[5]]
+		if ref, ok := linkReference(target); ok {
+			ref.Text = text
+			p.add(ref, m[0], m[1])
+		}
+	}
+	for _, m := range inlineCodePattern.FindAllStringSubmatchIndex(content, -1) {
+		code := content[m[2]:m[3]]
+		if ref, ok := codeReference(code); ok {
+			ref.Text = code
+			p.add(ref, m[2], m[3])
+		}
+	}
+	for _, m := range urlPattern.FindAllStringIndex(content, -1) {
+		raw := strings.TrimRight(content[m[0]:m[1]], ".,;:!?")
+		p.add(Reference{Kind: ReferenceURL, Target: raw, Text: raw}, m[0], m[0]+len(raw))
+	}
+	for _, m := range filePathPattern.FindAllStringSubmatchIndex(content, -1) {
+		text := strings.TrimRight(content[m[2]:m[3]], ".")
+		ref := fileReference(text)
+		ref.Text = text
+		p.add(ref, m[2], m[2]+len(text))
+	}
+
+	sort.Slice(p.refs, func(i, j int) bool { return p.refs[i].Start < p.refs[j].Start })
+	return p.refs
+}
+
+// referenceParser collects references without overlaps, in the order of the
+// passes of ParseReferences, so that earlier passes take precedence.
+type referenceParser struct {
+	skip [][2]int // fenced code blocks
+	refs []Reference
+}
+
+func (p *referenceParser) add(ref Reference, start, end int) {
+	for _, r := range p.skip {
+		if start < r[1] && r[0] < end {
+			return
+		}
+	}
+	for _, r := range p.refs {
+		if start < r.End && r.Start < end {
+			return
+		}
+	}
+	ref.Start, ref.End = start, end
+	p.refs = append(p.refs, ref)
+}
+
+// fencedBlocks returns the byte ranges of the fenced code blocks in content.
+// An unterminated block extends to the end.
+func fencedBlocks(content string) [][2]int {
+	var blocks [][2]int
+	start, fence := -1, ""
+	for offset := 0; offset < len(content); {
+		end := strings.IndexByte(content[offset:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += offset + 1
+		}
+		line := strings.TrimSpace(content[offset:end])
+		switch {
+		case start < 0 && (strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")):
+			start, fence = offset, line[:3]
+		case start >= 0 && strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "":
+			blocks = append(blocks, [2]int{start, end})
+			start = -1
+		}
+		offset = end
+	}
+	if start >= 0 {
+		blocks = append(blocks, [2]int{start, len(content)})
+	}
+	return blocks
+}
+
+// linkReference classifies the target of a Markdown link.
+func linkReference(target string) (Reference, bool) {
+	if strings.HasPrefix(target, "#") {
+		return Reference{}, false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return Reference{}, false
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return Reference{Kind: ReferenceURL, Target: target}, true
+	case "file":
+		return fileReference(u.Path + lineFragment(u.Fragment)), true
+	case "":
+		return fileReference(target), true
+	}
+	return Reference{}, false
+}
+
+// lineFragment returns fragment as a #L suffix when it names lines.
+func lineFragment(fragment string) string {
+	if strings.HasPrefix(fragment, "L") {
+		return "#" + fragment
+	}
+	return ""
+}
+
+// codeReference classifies the content of inline code.
+func codeReference(code string) (Reference, bool) {
+	if strings.ContainsAny(code, " \t") {
+		return Reference{}, false
+	}
+	if urlPattern.FindString(code) == code {
+		return Reference{Kind: ReferenceURL, Target: code}, true
+	}
+	ref := fileReference(code)
+	ext := strings.TrimPrefix(path.Ext(ref.Target), ".")
+	if strings.Contains(ref.Target, "/") && ext != "" || sourceExtensions[strings.ToLower(ext)] {
+		return ref, true
+	}
+	if symbolPattern.MatchString(code) && looksLikeCode(code) {
+		return Reference{Kind: ReferenceSymbol, Target: strings.TrimSuffix(code, "()")}, true
+	}
+	return Reference{}, false
+}
+
+// looksLikeCode reports whether an identifier is unlikely to be a plain word,
+// command, or keyword: it is qualified, called, or has capitals, underscores,
+// or digits.
+func looksLikeCode(identifier string) bool {
+	return strings.ContainsAny(identifier, ".:#>(_$0123456789") || strings.ToLower(identifier) != identifier
+}
+
+// fileReference splits a line or range suffix off a path.
+func fileReference(target string) Reference {
+	ref := Reference{Kind: ReferenceFile, Target: target}
+	m := lineSuffixPattern.FindStringSubmatch(target)
+	if m == nil {
+		return ref
+	}
+	line, endLine := m[2], m[3]
+	if m[4] != "" {
+		line, endLine = m[4], m[5]
+	}
+	if line == "" {
+		return ref
+	}
+	ref.Target = m[1]
+	ref.Line, _ = strconv.Atoi(line)
+	ref.EndLine, _ = strconv.Atoi(endLine)
+	return ref
+}
@@ -0,0 +1,82 @@
+package copilot
+
+import (
+	"testing"
+)
+
+func TestParseReferences(t *testing.T) {
+	content := "The handler lives in api/users.go:42-58 and calls `Store.ListUsers()`.\n" +
+		"See [the pagination guide](https://example.com/docs/pagination) and " +
+		"[the config](config/app.yaml#L10), or https://go.dev/doc/effective_go.\n" +
+		"Run `go test` after editing `client.go`; set `max_retries` to `true`.\n" +
+		"```go\nfunc main() { fmt.Println(\"see other/file.go\") }\n```\n" +
+		"Unrelated words: and/or, e.g. v1.2, `Client`."
+
+	want := []Reference{
+		{Kind: ReferenceFile, Target: "api/users.go", Text: "api/users.go:42-58", Line: 42, EndLine: 58},
+		{Kind: ReferenceSymbol, Target: "Store.ListUsers", Text: "Store.ListUsers()"},
+		{Kind: ReferenceURL, Target: "https://example.com/docs/pagination", Text: "the pagination guide"},
+		{Kind: ReferenceFile, Target: "config/app.yaml", Text: "the config", Line: 10},
+		{Kind: ReferenceURL, Target: "https://go.dev/doc/effective_go", Text: "https://go.dev/doc/effective_go"},
+		{Kind: ReferenceFile, Target: "client.go", Text: "client.go"},
+		{Kind: ReferenceSymbol, Target: "max_retries", Text: "max_retries"},
+		{Kind: ReferenceSymbol, Target: "Client", Text: "Client"},
+	}
+	got := ParseReferences(content)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d references, got %d: %+v", len(want), len(got), got)
+	}
+	for i, ref := range got {
+		if ref.Kind != want[i].Kind || ref.Target != want[i].Target || ref.Text != want[i].Text || ref.Line != want[i].Line || ref.EndLine != want[i].EndLine {
+			t.Errorf("Reference %d: expected %+v, got %+v", i, want[i], ref)
+		}
+		if mention := content[ref.Start:ref.End]; mention != ref.Text && mention[0] != '[' {
+			t.Errorf("Reference %d: offsets point at %q", i, mention)
+		}
+	}
+	if link := got[2]; content[link.Start:link.End] != "[the pagination guide](https://example.com/docs/pagination)" {
+		t.Errorf("Expected link offsets to cover the whole link, got %q", content[link.Start:link.End])
+	}
+}
+
+func TestSession_References(t *testing.T) {
+	t.Run("lists references recorded by the CLI", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion(), "features": map[string]bool{FeatureReferences: true}})
+		log.call("session.references.list", map[string]any{"references": []any{
+			map[string]any{"kind": "symbol", "target": "auth.Middleware", "line": 12},
+		}})
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		refs, err := session.References(t.Context(), "m1")
+		if err != nil || len(refs) != 1 || refs[0].Kind != ReferenceSymbol || refs[0].Target != "auth.Middleware" || refs[0].Line != 12 {
+			t.Errorf("Unexpected references: %+v, %v", refs, err)
+		}
+	})
+
+	t.Run("parses the message without CLI support", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+		log.call("session.getMessages", map[string]any{"events": []SessionEvent{
+			{ID: "e1", Type: AssistantMessage, Data: Data{MessageID: String("m1"), Content: String("Fixed in `auth/token.go`.")}},
+		}})
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		refs, err := session.References(t.Context(), "m1")
+		if err != nil || len(refs) != 1 || refs[0].Kind != ReferenceFile || refs[0].Target != "auth/token.go" {
+			t.Errorf("Unexpected references: %+v, %v", refs, err)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ReloadOptions holds the settings [Client.ReloadConfig] applies to a running
+// client. Nil fields leave the current setting unchanged.
+type ReloadOptions struct {
+	// CustomAgents, when non-nil, replaces the custom agents of every active
+	// session: agents are registered or updated by name, and agents the session
+	// had that are not in the list are removed. Agents may use Extends, as in
+	// SessionConfig.CustomAgents.
+	CustomAgents []CustomAgentConfig
+	// OnPermissionRequest, when non-nil, replaces the permission handler of every
+	// active session.
+	OnPermissionRequest PermissionHandlerFunc
+	// RateLimit, when non-nil, replaces the client's rate limits. Messages and
+	// tokens already counted still count toward the new limits. The client must
+	// have been created with ClientOptions.RateLimit; use an empty
+	// RateLimitConfig to start without limits and add them later.
+	RateLimit *RateLimitConfig
+}
+
+// ReloadConfig applies new agent definitions, permission policy, and rate
+// limits to the client and its active sessions without restarting them, e.g.
+// after a configuration file changes. Sessions created afterwards use their
+// own SessionConfig.
+//
+// The options are validated before anything is applied. Failures to update
+// individual sessions are joined into the returned error; the other sessions
+// are still updated.
+//
+// Example:
+//
+//	agents, err := copilot.LoadAgentsFromDir(".github/agents")
+//	if err != nil {
+//	    return err
+//	}
+//	err = client.ReloadConfig(ctx, copilot.ReloadOptions{
+//	    CustomAgents: agents,
+//	    RateLimit:    &copilot.RateLimitConfig{RequestsPerMinute: 30},
+//	})
+func (c *Client) ReloadConfig(ctx context.Context, options ReloadOptions) error {
+	var agents []CustomAgentConfig
+	if options.CustomAgents != nil {
+		var err error
+		if agents, err = ResolveAgents(options.CustomAgents); err != nil {
+			return err
+		}
+		if agents == nil {
+			agents = []CustomAgentConfig{}
+		}
+	}
+	if options.RateLimit != nil && c.limiter == nil {
+		return fmt.Errorf("cannot reload rate limits: the client was created without ClientOptions.RateLimit")
+	}
+
+	c.reloadMux.Lock()
+	defer c.reloadMux.Unlock()
+
+	if options.RateLimit != nil {
+		c.limiter.setConfig(*options.RateLimit)
+	}
+
+	c.sessionsMux.Lock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		sessions = append(sessions, session)
+	}
+	c.sessionsMux.Unlock()
+
+	var errs []error
+	for _, session := range sessions {
+		if options.OnPermissionRequest != nil {
+			session.registerPermissionHandler(options.OnPermissionRequest)
+		}
+		if agents != nil {
+			if err := session.reloadAgents(ctx, agents); err != nil {
+				errs = append(errs, fmt.Errorf("session %s: %w", session.SessionID, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		c.options.Logger.Warn("configuration reload failed", "sessions", len(sessions), "error", err)
+		return err
+	}
+	c.options.Logger.Info("configuration reloaded", "sessions", len(sessions))
+	return nil
+}
+
+// ReloadOnSignal calls load and applies the result with [Client.ReloadConfig]
+// each time the process receives SIGHUP, until the returned stop function is
+// called. Errors from load or ReloadConfig are logged to ClientOptions.Logger
+// and passed to onError, if non-nil. On platforms without SIGHUP, such as
+// Windows, reloads never happen.
+//
+// Example:
+//
+//	stop := client.ReloadOnSignal(func() (copilot.ReloadOptions, error) {
+//	    agents, err := copilot.LoadAgentsFromDir(".github/agents")
+//	    return copilot.ReloadOptions{CustomAgents: agents}, err
+//	}, nil)
+//	defer stop()
+func (c *Client) ReloadOnSignal(load func() (ReloadOptions, error), onError func(error)) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	notifyReload(signals)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+			}
+			c.options.Logger.Info("reloading configuration on signal")
+			options, err := load()
+			if err == nil {
+				err = c.ReloadConfig(context.Background(), options)
+			} else {
+				c.options.Logger.Warn("configuration reload failed", "error", err)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// reloadAgents registers agents with the CLI and removes the session's other
+// custom agents.
+func (s *Session) reloadAgents(ctx context.Context, agents []CustomAgentConfig) error {
+	s.agentNamesMux.Lock()
+	defer s.agentNamesMux.Unlock()
+
+	var errs []error
+	names := make([]string, len(agents))
+	for i, agent := range agents {
+		names[i] = agent.Name
+		params := &rpc.SessionAgentRegisterParams{Agent: rpc.SessionAgentRegisterParamsAgent{
+			Name:   agent.Name,
+			Prompt: agent.Prompt,
+			Tools:  agent.Tools,
+			Infer:  agent.Infer,
+		}}
+		if agent.DisplayName != "" {
+			params.Agent.DisplayName = &agent.DisplayName
+		}
+		if agent.Description != "" {
+			params.Agent.Description = &agent.Description
+		}
+		if len(agent.MCPServers) > 0 {
+			params.Agent.MCPServers = make(map[string]map[string]any, len(agent.MCPServers))
+			for name, server := range agent.MCPServers {
+				params.Agent.MCPServers[name] = server
+			}
+		}
+		if _, err := s.RPC.Agent.Register(ctx, params); err != nil {
+			errs = append(errs, fmt.Errorf("failed to register agent %q: %w", agent.Name, err))
+		}
+	}
+	for _, name := range s.agentNames {
+		if slices.Contains(names, name) {
+			continue
+		}
+		if _, err := s.RPC.Agent.Unregister(ctx, &rpc.SessionAgentUnregisterParams{Name: name}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove agent %q: %w", name, err))
+			// Keep tracking it so the next reload retries the removal
+			names = append(names, name)
+		}
+	}
+	s.agentNames = names
+	return errors.Join(errs...)
+}
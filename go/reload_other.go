@@ -0,0 +1,8 @@
+//go:build !unix
+
+package copilot
+
+import "os"
+
+// notifyReload does nothing: the platform has no SIGHUP.
+func notifyReload(signals chan<- os.Signal) {}
@@ -0,0 +1,103 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestClient_ReloadConfig(t *testing.T) {
+	t.Run("updates agents and permission handlers of active sessions", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.agent.register", map[string]any{"agent": map[string]any{"name": "reviewer"}, "replaced": true})
+		log.call("session.agent.register", map[string]any{"agent": map[string]any{"name": "tester"}, "replaced": false})
+		log.call("session.agent.unregister", map[string]any{"removed": true})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			CustomAgents: []CustomAgentConfig{
+				{Name: "reviewer", Prompt: "Review the code"},
+				{Name: "planner", Prompt: "Plan the work"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		denyAll := func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+			return PermissionRequestResult{Kind: "denied-by-rules"}, nil
+		}
+		err = client.ReloadConfig(t.Context(), ReloadOptions{
+			CustomAgents: []CustomAgentConfig{
+				{Name: "reviewer", Prompt: "Review the code strictly"},
+				{Name: "tester", Extends: "reviewer", Description: "Writes tests"},
+			},
+			OnPermissionRequest: denyAll,
+		})
+		if err != nil {
+			t.Fatalf("Failed to reload: %v", err)
+		}
+
+		var methods []string
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if record.Direction != "send" || !slices.Contains([]string{"session.agent.register", "session.agent.unregister"}, message.Method) {
+				continue
+			}
+			methods = append(methods, message.Method)
+			if agent, ok := message.Params["agent"].(map[string]any); ok && agent["name"] == "tester" && agent["prompt"] != "Review the code strictly" {
+				t.Errorf("Expected tester to inherit the reviewer prompt, got %v", agent)
+			}
+			if message.Method == "session.agent.unregister" && message.Params["name"] != "planner" {
+				t.Errorf("Expected planner to be removed, got %v", message.Params)
+			}
+		}
+		if !slices.Equal(methods, []string{"session.agent.register", "session.agent.register", "session.agent.unregister"}) {
+			t.Errorf("Unexpected agent requests: %v", methods)
+		}
+		if !slices.Equal(session.agentNames, []string{"reviewer", "tester"}) {
+			t.Errorf("Unexpected tracked agents: %v", session.agentNames)
+		}
+
+		result, _ := session.getPermissionHandler()(PermissionRequest{Kind: "shell"}, PermissionInvocation{SessionID: "s1"})
+		if result.Kind != "denied-by-rules" {
+			t.Errorf("Expected the reloaded permission handler, got %+v", result)
+		}
+	})
+
+	t.Run("replaces rate limits", func(t *testing.T) {
+		client := NewClient(&ClientOptions{RateLimit: &RateLimitConfig{}})
+		if err := client.ReloadConfig(t.Context(), ReloadOptions{
+			RateLimit: &RateLimitConfig{RequestsPerMinute: 1, Policy: RateLimitReject},
+		}); err != nil {
+			t.Fatalf("Failed to reload: %v", err)
+		}
+		if _, err := client.limiter.acquire(t.Context(), "s1"); err != nil {
+			t.Fatalf("Expected the first send to be allowed, got %v", err)
+		}
+		var quotaErr *QuotaExceededError
+		if _, err := client.limiter.acquire(t.Context(), "s1"); !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaRequestsPerMinute {
+			t.Errorf("Expected the reloaded limit to reject the second send, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid options before applying them", func(t *testing.T) {
+		client := NewClient(nil)
+		if err := client.ReloadConfig(t.Context(), ReloadOptions{RateLimit: &RateLimitConfig{}}); err == nil {
+			t.Error("Expected an error reloading rate limits on a client without them")
+		}
+		if err := client.ReloadConfig(t.Context(), ReloadOptions{CustomAgents: []CustomAgentConfig{{Name: "a", Extends: "missing"}}}); err == nil {
+			t.Error("Expected an error for an agent extending an unknown agent")
+		}
+	})
+}
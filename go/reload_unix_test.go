@@ -0,0 +1,39 @@
+//go:build unix
+
+package copilot
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClient_ReloadOnSignal(t *testing.T) {
+	client := NewClient(nil)
+	loaded := make(chan struct{}, 1)
+	failed := make(chan error, 1)
+	stop := client.ReloadOnSignal(func() (ReloadOptions, error) {
+		loaded <- struct{}{}
+		return ReloadOptions{}, errors.New("bad config")
+	}, func(err error) { failed <- err })
+	defer stop()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+	select {
+	case <-loaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected SIGHUP to trigger a reload")
+	}
+	select {
+	case err := <-failed:
+		if err.Error() != "bad config" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the load error to be reported")
+	}
+	stop()
+}
@@ -0,0 +1,285 @@
+// Package repl runs an interactive terminal chat on top of a session, so a
+// custom CLI needs little more than a client, a session, and a call to [Run].
+//
+// Assistant replies are printed as they stream in when the session was created
+// with Streaming, and tool calls are shown as they start. Lines starting with
+// "/" are handled by [Options.Commands] when they name one, and are otherwise
+// sent to the session unchanged, so the CLI's own slash commands keep working.
+// Ctrl+C aborts the turn in progress, or ends the chat at the prompt.
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    Streaming:           true,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := repl.Run(ctx, session, nil); err != nil {
+//	    log.Fatal(err)
+//	}
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Command is a slash command handled by the REPL itself. args is the rest of
+// the line after the command name, with surrounding spaces removed. Output
+// written to out appears in the chat. Returning [ErrExit] ends the chat.
+type Command func(ctx context.Context, session *copilot.Session, args string, out io.Writer) error
+
+// ErrExit can be returned by a [Command] to end the chat. Run then returns nil.
+var ErrExit = errors.New("exit")
+
+// Options configures [Run].
+type Options struct {
+	// In is read for user input, one message per line. Default: os.Stdin.
+	In io.Reader
+	// Out receives the prompt and the conversation. Default: os.Stdout.
+	Out io.Writer
+	// Prompt is printed before each line of input. Default: "> ".
+	Prompt string
+	// Commands maps slash command names, without the "/", to their handlers.
+	// "exit" and "quit" end the chat unless overridden, and "help" lists the
+	// commands.
+	Commands map[string]Command
+	// ShowReasoning prints the model's reasoning before its reply.
+	ShowReasoning bool
+}
+
+// notifyInterrupt delivers Ctrl+C presses to ch. Tests replace it.
+var notifyInterrupt = func(ch chan<- os.Signal) func() {
+	signal.Notify(ch, os.Interrupt)
+	return func() { signal.Stop(ch) }
+}
+
+// Run reads messages from the user and sends them to session until the input
+// ends, ctx is done, the user types /exit, or Ctrl+C is pressed at the prompt.
+// It returns nil when the user ends the chat, and ctx.Err() when ctx is done.
+//
+// Errors from individual turns, such as a failed send or a session error event,
+// are printed and the chat continues.
+func Run(ctx context.Context, session *copilot.Session, options *Options) error {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	if opts.In == nil {
+		opts.In = os.Stdin
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	if opts.Prompt == "" {
+		opts.Prompt = "> "
+	}
+	commands := map[string]Command{"exit": exit, "quit": exit}
+	maps.Copy(commands, opts.Commands)
+	if _, ok := commands["help"]; !ok {
+		commands["help"] = help(commands)
+	}
+
+	r := &renderer{out: opts.Out, showReasoning: opts.ShowReasoning, done: make(chan struct{}, 1)}
+	defer session.On(r.handle)()
+
+	interrupts := make(chan os.Signal, 1)
+	defer notifyInterrupt(interrupts)()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(opts.In)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	for {
+		fmt.Fprint(opts.Out, opts.Prompt)
+		var line string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-interrupts:
+			fmt.Fprintln(opts.Out)
+			return nil
+		case err := <-readErr:
+			fmt.Fprintln(opts.Out)
+			return err
+		case line = <-lines:
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if name, args, ok := parseCommand(line); ok {
+			if command, ok := commands[name]; ok {
+				err := command(ctx, session, args, opts.Out)
+				if errors.Is(err, ErrExit) {
+					return nil
+				}
+				if err != nil {
+					fmt.Fprintf(opts.Out, "error: %v\n", err)
+				}
+				continue
+			}
+		}
+
+		if err := r.turn(ctx, session, line, interrupts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(opts.Out, "error: %v\n", err)
+		}
+	}
+}
+
+// parseCommand splits "/name args" into its parts.
+func parseCommand(line string) (name, args string, ok bool) {
+	if !strings.HasPrefix(line, "/") || len(line) == 1 {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(line[1:], " ")
+	return name, strings.TrimSpace(args), true
+}
+
+func exit(context.Context, *copilot.Session, string, io.Writer) error {
+	return ErrExit
+}
+
+func help(commands map[string]Command) Command {
+	return func(_ context.Context, _ *copilot.Session, _ string, out io.Writer) error {
+		for _, name := range slices.Sorted(maps.Keys(commands)) {
+			fmt.Fprintf(out, "/%s\n", name)
+		}
+		fmt.Fprintln(out, "Other /commands are sent to the session. Press Ctrl+C to abort a reply.")
+		return nil
+	}
+}
+
+// renderer prints session events and signals the end of each turn.
+type renderer struct {
+	out           io.Writer
+	showReasoning bool
+	done          chan struct{} // receives when the session becomes idle
+
+	mu        sync.Mutex
+	streaming bool   // a reply is being streamed
+	streamed  string // message ID of the last streamed reply
+}
+
+func (r *renderer) handle(event copilot.SessionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := event.Data
+	switch event.Type {
+	case copilot.AssistantMessageDelta:
+		if data.DeltaContent != nil {
+			fmt.Fprint(r.out, *data.DeltaContent)
+			r.streaming = true
+			if data.MessageID != nil {
+				r.streamed = *data.MessageID
+			}
+		}
+	case copilot.AssistantMessage:
+		if r.streaming && (data.MessageID == nil || *data.MessageID == r.streamed) {
+			fmt.Fprintln(r.out)
+			r.streaming = false
+			return
+		}
+		r.endStreamLocked()
+		if data.Content != nil && *data.Content != "" {
+			fmt.Fprintln(r.out, *data.Content)
+		}
+	case copilot.AssistantReasoning:
+		if r.showReasoning && data.Content != nil {
+			r.endStreamLocked()
+			fmt.Fprintf(r.out, "(thinking) %s\n", *data.Content)
+		}
+	case copilot.ToolExecutionStart:
+		if data.ToolName != nil {
+			r.endStreamLocked()
+			fmt.Fprintf(r.out, "[%s]\n", *data.ToolName)
+		}
+	case copilot.SessionError:
+		r.endStreamLocked()
+		if data.Message != nil {
+			fmt.Fprintf(r.out, "error: %s\n", *data.Message)
+		}
+		r.signalDone()
+	case copilot.SessionIdle:
+		r.endStreamLocked()
+		r.signalDone()
+	}
+}
+
+// endStreamLocked finishes the line of a streamed reply that ended without an
+// assistant message, e.g. because it was aborted.
+func (r *renderer) endStreamLocked() {
+	if r.streaming {
+		fmt.Fprintln(r.out)
+		r.streaming = false
+	}
+}
+
+func (r *renderer) signalDone() {
+	select {
+	case r.done <- struct{}{}:
+	default:
+	}
+}
+
+// turn sends prompt and waits for the session to become idle, aborting the
+// turn if the user presses Ctrl+C.
+func (r *renderer) turn(ctx context.Context, session *copilot.Session, prompt string, interrupts <-chan os.Signal) error {
+	// Drop an idle signal left over from an earlier turn
+	select {
+	case <-r.done:
+	default:
+	}
+	if _, err := session.Send(ctx, copilot.MessageOptions{Prompt: prompt}); err != nil {
+		return err
+	}
+	aborted := false
+	for {
+		select {
+		case <-r.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-interrupts:
+			if aborted {
+				continue
+			}
+			aborted = true
+			r.mu.Lock()
+			r.endStreamLocked()
+			fmt.Fprintln(r.out, "^C aborting...")
+			r.mu.Unlock()
+			if err := session.Abort(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/playbacktest"
+)
+
+// output is a goroutine-safe buffer that can wait for text to appear.
+type output struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+func (o *output) waitFor(t *testing.T, text string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(o.String(), text) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %q in output:\n%s", text, o.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("streams replies and handles commands", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.ToolExecutionStart, map[string]any{"toolCallId": "t1", "toolName": "view"})
+		log.Event("s1", copilot.AssistantMessageDelta, map[string]any{"deltaContent": "It is ", "messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessageDelta, map[string]any{"deltaContent": "a Go module.", "messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "It is a Go module.", "messageId": "m1"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		log.Call("session.send", map[string]any{"messageId": "m2"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "Compacted.", "messageId": "m2"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		session := playbacktest.NewSession(t, log, &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll, Streaming: true})
+
+		var out output
+		var commandArgs string
+		err := Run(t.Context(), session, &Options{
+			In:  strings.NewReader("What is this?\n\n/echo  hello there \n/compact\n/exit\nignored\n"),
+			Out: &out,
+			Commands: map[string]Command{
+				"echo": func(_ context.Context, _ *copilot.Session, args string, w io.Writer) error {
+					commandArgs = args
+					_, err := io.WriteString(w, "echoed\n")
+					return err
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if commandArgs != "hello there" {
+			t.Errorf("Expected command args 'hello there', got %q", commandArgs)
+		}
+		expected := "> [view]\nIt is a Go module.\n> > echoed\n> Compacted.\n> "
+		if out.String() != expected {
+			t.Errorf("Unexpected output:\n%q\nexpected:\n%q", out.String(), expected)
+		}
+	})
+
+	t.Run("aborts the turn on Ctrl+C", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessageDelta, map[string]any{"deltaContent": "Let me think", "messageId": "m1"})
+		log.Call("session.abort", map[string]any{})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		session := playbacktest.NewSession(t, log, &copilot.SessionConfig{OnPermissionRequest: copilot.PermissionHandler.ApproveAll, Streaming: true})
+
+		interrupts := make(chan chan<- os.Signal, 1)
+		original := notifyInterrupt
+		notifyInterrupt = func(ch chan<- os.Signal) func() {
+			interrupts <- ch
+			return func() {}
+		}
+		t.Cleanup(func() { notifyInterrupt = original })
+
+		var out output
+		in, writeIn := io.Pipe()
+		done := make(chan error, 1)
+		go func() { done <- Run(t.Context(), session, &Options{In: in, Out: &out}) }()
+		interrupt := <-interrupts
+
+		writeIn.Write([]byte("Write a novel\n"))
+		out.waitFor(t, "Let me think")
+		interrupt <- os.Interrupt
+		out.waitFor(t, "^C aborting...\n> ")
+
+		// Ctrl+C at the prompt ends the chat
+		interrupt <- os.Interrupt
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for Run to return")
+		}
+	})
+
+	t.Run("parses commands", func(t *testing.T) {
+		for line, expected := range map[string][3]string{
+			"/help":          {"help", "", "true"},
+			"/model gpt-5 ":  {"model", "gpt-5", "true"},
+			"/":              {"", "", "false"},
+			"not a /command": {"", "", "false"},
+		} {
+			name, args, ok := parseCommand(strings.TrimSpace(line))
+			if name != expected[0] || args != expected[1] || strconv.FormatBool(ok) != expected[2] {
+				t.Errorf("parseCommand(%q) = %q, %q, %v", line, name, args, ok)
+			}
+		}
+	})
+}
@@ -0,0 +1,288 @@
+package copilot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/redact"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ReplayRecord is a single line of a replay log written when [ClientOptions.RecordTo] is set.
+//
+// Direction is "send" for frames written by the SDK and "recv" for frames read from the CLI.
+// Message holds the raw JSON-RPC request, response, or notification.
+type ReplayRecord struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// replayRecorder writes JSON-RPC frames to a JSONL replay log, redacted like
+// the SDK's logs.
+type replayRecorder struct {
+	w        io.Writer
+	redactor *redact.Redactor
+}
+
+// observe is a jsonrpc2.FrameObserver. Frames arrive serialized by the JSON-RPC client,
+// so no additional locking is needed here.
+func (r *replayRecorder) observe(direction jsonrpc2.FrameDirection, data []byte) {
+	message := json.RawMessage(redactFrame(r.redactor, data))
+	if !json.Valid(message) {
+		message, _ = json.Marshal(string(message))
+	}
+	line, err := json.Marshal(ReplayRecord{
+		Time:      time.Now().UTC(),
+		Direction: string(direction),
+		Message:   message,
+	})
+	if err != nil {
+		return
+	}
+	r.w.Write(append(line, '\n'))
+}
+
+// readReplayLog parses a JSONL replay log.
+func readReplayLog(r io.Reader) ([]ReplayRecord, error) {
+	var records []ReplayRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record ReplayRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid replay log entry on line %d: %w", line, err)
+		}
+		if record.Direction != string(jsonrpc2.FrameSent) && record.Direction != string(jsonrpc2.FrameReceived) {
+			return nil, fmt.Errorf("invalid replay log entry on line %d: unknown direction %q", line, record.Direction)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay log: %w", err)
+	}
+	return records, nil
+}
+
+// NewPlaybackClient creates a client that replays a log recorded via [ClientOptions.RecordTo]
+// instead of talking to a Copilot CLI.
+//
+// Playback is deterministic: requests made by the SDK are matched, in order, against the
+// requests in the log, and the recorded responses and notifications are delivered back.
+// Tool calls, permission requests, and other server-initiated requests are replayed too,
+// so registered handlers run as they did during recording. A request that does not match
+// the log fails with a JSON-RPC error describing the mismatch.
+//
+// Example:
+//
+//	f, _ := os.Open("testdata/conversation.jsonl")
+//	defer f.Close()
+//	client, err := copilot.NewPlaybackClient(f)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.Stop()
+//	session, _ := client.CreateSession(context.Background(), &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	})
+func NewPlaybackClient(log io.Reader) (*Client, error) {
+	records, err := readReplayLog(log)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(nil)
+	client.isExternalServer = true
+	client.useStdio = false
+	client.playback = records
+	return client, nil
+}
+
+// connectViaPlayback connects the client to an in-process server replaying c.playback.
+func (c *Client) connectViaPlayback() error {
+	local, remote := net.Pipe()
+	server := &playbackServer{
+		records: c.playback,
+		conn:    remote,
+		pending: make(map[string]json.RawMessage),
+	}
+	go server.run()
+
+	c.conn = local
+	c.client = jsonrpc2.NewClient(local, local)
+	c.RPC = rpc.NewServerRpc(c.client)
+	c.setupNotificationHandler()
+	c.setupObservers()
+	c.client.SetErrorMapper(classifyError)
+	c.client.Start()
+	return nil
+}
+
+// playbackServer plays the CLI side of a recorded conversation.
+type playbackServer struct {
+	records []ReplayRecord
+	pos     int
+	conn    net.Conn
+	// pending maps recorded request IDs to the IDs used by the live client.
+	pending map[string]json.RawMessage
+}
+
+type playbackFrame struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+}
+
+func (p *playbackServer) run() {
+	defer p.conn.Close()
+
+	// Read client frames on a separate goroutine so that writes from the client never
+	// block on us while we are delivering recorded frames.
+	frames := make(chan []byte, 1024)
+	go func() {
+		defer close(frames)
+		reader := bufio.NewReader(p.conn)
+		for {
+			body, err := readFrame(reader)
+			if err != nil {
+				return
+			}
+			frames <- body
+		}
+	}()
+
+	for {
+		if err := p.emitReceived(); err != nil {
+			return
+		}
+
+		body, ok := <-frames
+		if !ok {
+			return
+		}
+
+		var frame playbackFrame
+		if err := json.Unmarshal(body, &frame); err != nil {
+			continue
+		}
+
+		recorded, hasRecorded := p.peek()
+
+		// Responses to replayed server requests and client notifications just
+		// advance past the matching recorded frame.
+		if frame.Method == "" || len(frame.ID) == 0 {
+			if hasRecorded && recorded.Method == frame.Method && (len(recorded.ID) == 0) == (len(frame.ID) == 0) {
+				p.pos++
+			}
+			continue
+		}
+
+		if !hasRecorded {
+			p.writeError(frame.ID, fmt.Sprintf("playback: no recorded response for %s", frame.Method))
+			continue
+		}
+		if recorded.Method != frame.Method {
+			p.writeError(frame.ID, fmt.Sprintf("playback: unexpected request %s, log expects %s", frame.Method, recorded.Method))
+			continue
+		}
+		p.pos++
+		p.pending[string(recorded.ID)] = frame.ID
+	}
+}
+
+// peek returns the next recorded client frame, if any.
+func (p *playbackServer) peek() (playbackFrame, bool) {
+	if p.pos >= len(p.records) {
+		return playbackFrame{}, false
+	}
+	var frame playbackFrame
+	json.Unmarshal(p.records[p.pos].Message, &frame)
+	return frame, true
+}
+
+// emitReceived writes recorded server frames up to the next recorded client frame.
+func (p *playbackServer) emitReceived() error {
+	for p.pos < len(p.records) && p.records[p.pos].Direction == string(jsonrpc2.FrameReceived) {
+		message := p.records[p.pos].Message
+		p.pos++
+
+		var frame playbackFrame
+		if err := json.Unmarshal(message, &frame); err == nil && frame.Method == "" && len(frame.ID) > 0 {
+			if liveID, ok := p.pending[string(frame.ID)]; ok {
+				delete(p.pending, string(frame.ID))
+				message = rewriteID(message, liveID)
+			}
+		}
+		if err := writeFrame(p.conn, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *playbackServer) writeError(id json.RawMessage, message string) {
+	data, _ := json.Marshal(jsonrpc2.Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &jsonrpc2.Error{Code: -32603, Message: message},
+	})
+	writeFrame(p.conn, data)
+}
+
+// rewriteID replaces the "id" member of a JSON-RPC message.
+func rewriteID(message json.RawMessage, id json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return message
+	}
+	fields["id"] = id
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return message
+	}
+	return data
+}
+
+// readFrame reads a single Content-Length framed message.
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	contentLength := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == "\r\n" || line == "\n" {
+			if contentLength == 0 {
+				continue
+			}
+			break
+		}
+		var length int
+		if _, err := fmt.Sscanf(line, "Content-Length: %d", &length); err == nil {
+			contentLength = length
+		}
+	}
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// writeFrame writes a single Content-Length framed message.
+func writeFrame(w io.Writer, data []byte) error {
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
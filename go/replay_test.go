@@ -0,0 +1,208 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replayLog builds replay logs by hand so unit tests can drive a playback client.
+type replayLog struct {
+	buf    bytes.Buffer
+	nextID int
+}
+
+func (l *replayLog) write(direction string, message map[string]any) {
+	data, _ := json.Marshal(message)
+	line, _ := json.Marshal(ReplayRecord{Time: time.Now(), Direction: direction, Message: data})
+	l.buf.Write(append(line, '\n'))
+}
+
+// call records a client request followed by the server's result.
+func (l *replayLog) call(method string, result any) {
+	l.nextID++
+	id := strconv.Itoa(l.nextID)
+	l.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": map[string]any{}})
+	l.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+// notify records a server notification.
+func (l *replayLog) notify(method string, params any) {
+	l.write("recv", map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// serverCall records a server-initiated request and the client's response.
+func (l *replayLog) serverCall(method string, params any) {
+	l.nextID++
+	id := "server-" + strconv.Itoa(l.nextID)
+	l.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	l.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
+}
+
+// handshake records the ping issued by Client.Start.
+func (l *replayLog) handshake() {
+	l.call("ping", map[string]any{"message": "pong", "timestamp": 1, "protocolVersion": GetSdkProtocolVersion()})
+}
+
+// event records a session.event notification.
+func (l *replayLog) event(sessionID string, eventType SessionEventType, data map[string]any) {
+	l.notify("session.event", map[string]any{
+		"sessionId": sessionID,
+		"event": map[string]any{
+			"id":        "evt-" + strconv.Itoa(l.nextID),
+			"timestamp": time.Now().Format(time.RFC3339),
+			"parentId":  nil,
+			"type":      eventType,
+			"data":      data,
+		},
+	})
+}
+
+// newPlaybackClientForTest starts a playback client for the given log.
+func newPlaybackClientForTest(t *testing.T, log *replayLog, recordTo *bytes.Buffer) *Client {
+	t.Helper()
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	if recordTo != nil {
+		client.options.RecordTo = recordTo
+	}
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start playback client: %v", err)
+	}
+	return client
+}
+
+func simpleConversationLog() *replayLog {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.send", map[string]any{"messageId": "m1"})
+	log.event("s1", AssistantMessage, map[string]any{"content": "4", "messageId": "m1"})
+	log.event("s1", SessionIdle, map[string]any{})
+	return log
+}
+
+func TestPlaybackClient(t *testing.T) {
+	t.Run("replays a recorded conversation", func(t *testing.T) {
+		client := newPlaybackClientForTest(t, simpleConversationLog(), nil)
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if session.SessionID != "s1" {
+			t.Errorf("Expected session ID 's1', got %q", session.SessionID)
+		}
+
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		if response == nil || response.Data.Content == nil || *response.Data.Content != "4" {
+			t.Errorf("Expected assistant content '4', got %v", response)
+		}
+	})
+
+	t.Run("fails requests that do not match the log", func(t *testing.T) {
+		client := newPlaybackClientForTest(t, simpleConversationLog(), nil)
+
+		_, err := client.ListSessions(t.Context(), nil)
+		if err == nil || !strings.Contains(err.Error(), "log expects session.create") {
+			t.Errorf("Expected playback mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("replays server requests to registered handlers", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.serverCall("permission.request", map[string]any{
+			"sessionId":         "s1",
+			"permissionRequest": map[string]any{"kind": "shell", "toolCallId": "t1"},
+		})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+
+		requests := make(chan PermissionRequest, 1)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: func(request PermissionRequest, _ PermissionInvocation) (PermissionRequestResult, error) {
+				requests <- request
+				return PermissionRequestResult{Kind: "approved"}, nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "run ls"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		select {
+		case request := <-requests:
+			if request.Kind != "shell" {
+				t.Errorf("Expected permission kind 'shell', got %q", request.Kind)
+			}
+		default:
+			t.Error("Expected permission handler to be invoked")
+		}
+	})
+
+	t.Run("records traffic that can be played back again", func(t *testing.T) {
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, simpleConversationLog(), &recorded)
+
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is 2+2?"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		client.ForceStop()
+
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to parse recorded log: %v", err)
+		}
+		// ping, session.create, session.send (request + response each) plus two events
+		if len(records) != 8 {
+			t.Fatalf("Expected 8 recorded frames, got %d", len(records))
+		}
+		if records[0].Direction != "send" || records[1].Direction != "recv" {
+			t.Errorf("Expected send/recv ordering, got %q/%q", records[0].Direction, records[1].Direction)
+		}
+
+		replayed, err := NewPlaybackClient(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to create playback client from recording: %v", err)
+		}
+		t.Cleanup(func() { replayed.ForceStop() })
+		session, err = replayed.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session from recording: %v", err)
+		}
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatalf("Failed to send message from recording: %v", err)
+		}
+		if response == nil || response.Data.Content == nil || *response.Data.Content != "4" {
+			t.Errorf("Expected assistant content '4', got %v", response)
+		}
+	})
+
+	t.Run("rejects malformed logs", func(t *testing.T) {
+		_, err := NewPlaybackClient(strings.NewReader("{\"direction\":\"sideways\",\"message\":{}}\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("Expected error pointing at line 1, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// RepoContext describes the state of a git repository, as gathered by [GatherRepoContext].
+type RepoContext struct {
+	// Path is the repository root.
+	Path string `json:"path"`
+	// Branch is the checked-out branch, or empty when HEAD is detached.
+	Branch string `json:"branch,omitempty"`
+	// Head is the commit SHA of HEAD.
+	Head string `json:"head"`
+	// RemoteURL is the URL of the "origin" remote with any credentials removed,
+	// or empty if there is no such remote.
+	RemoteURL string `json:"remoteUrl,omitempty"`
+	// RecentCommits lists the most recent commits reachable from HEAD, newest first.
+	RecentCommits []RepoCommit `json:"recentCommits"`
+	// Diff is the uncommitted change to tracked files, staged and unstaged, relative to HEAD.
+	Diff string `json:"diff,omitempty"`
+	// DiffTruncated reports whether Diff was cut at RepoContextOptions.MaxDiffBytes.
+	DiffTruncated bool `json:"diffTruncated,omitempty"`
+}
+
+// RepoCommit is a single commit in [RepoContext.RecentCommits].
+type RepoCommit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
+// RepoContextOptions configures [GatherRepoContext].
+type RepoContextOptions struct {
+	// CommitCount is how many recent commits to include. Default: 10.
+	CommitCount int
+	// MaxDiffBytes bounds the size of the diff. Default: 100000.
+	MaxDiffBytes int
+}
+
+// GatherRepoContext collects git metadata for the repository containing path:
+// the current branch and commit, recent commits, the uncommitted diff, and the
+// origin remote URL. It requires git to be installed. options may be nil.
+func GatherRepoContext(ctx context.Context, path string, options *RepoContextOptions) (*RepoContext, error) {
+	commitCount := 10
+	maxDiffBytes := 100000
+	if options != nil {
+		if options.CommitCount > 0 {
+			commitCount = options.CommitCount
+		}
+		if options.MaxDiffBytes > 0 {
+			maxDiffBytes = options.MaxDiffBytes
+		}
+	}
+
+	root, err := git(ctx, path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repo := &RepoContext{Path: filepath.FromSlash(root)}
+
+	// An empty repository has no HEAD; report what is available
+	if repo.Head, err = git(ctx, root, "rev-parse", "HEAD"); err != nil {
+		repo.Head = ""
+	}
+	repo.Branch, _ = git(ctx, root, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if remote, err := git(ctx, root, "remote", "get-url", "origin"); err == nil {
+		repo.RemoteURL = redactRemoteURL(remote)
+	}
+
+	if repo.Head != "" {
+		log, err := git(ctx, root, "log", fmt.Sprintf("-n%d", commitCount), "--format=%H%x1f%an%x1f%aI%x1f%s")
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(log, "\n") {
+			fields := strings.Split(line, "\x1f")
+			if len(fields) != 4 {
+				continue
+			}
+			date, _ := time.Parse(time.RFC3339, fields[2])
+			repo.RecentCommits = append(repo.RecentCommits, RepoCommit{SHA: fields[0], Author: fields[1], Date: date, Subject: fields[3]})
+		}
+
+		diff, err := git(ctx, root, "diff", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if len(diff) > maxDiffBytes {
+			diff = diff[:maxDiffBytes]
+			repo.DiffTruncated = true
+		}
+		repo.Diff = diff
+	}
+	return repo, nil
+}
+
+// AddRepoContext attaches repo to the session as structured "repository" context,
+// so the agent knows which repository, branch, and changes it is working with.
+//
+// Example:
+//
+//	repo, err := copilot.GatherRepoContext(ctx, ".", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := session.AddRepoContext(ctx, repo); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Session) AddRepoContext(ctx context.Context, repo *RepoContext) error {
+	encoded, err := json.Marshal(repo)
+	if err != nil {
+		return err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return err
+	}
+	_, err = s.RPC.Context.Add(ctx, &rpc.SessionContextAddParams{
+		Kind: "repository",
+		Name: filepath.Base(repo.Path),
+		Data: data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add repository context: %w", err)
+	}
+	return nil
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// redactRemoteURL removes credentials from an http(s) remote URL.
+func redactRemoteURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,284 @@
+package copilot
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResourceLimit identifies a limit in [ResourceLimits].
+type ResourceLimit string
+
+const (
+	// ResourceLimitMemory is ResourceLimits.MaxMemory.
+	ResourceLimitMemory ResourceLimit = "memory"
+	// ResourceLimitProcesses is ResourceLimits.MaxProcesses.
+	ResourceLimitProcesses ResourceLimit = "processes"
+)
+
+// ResourceLimits restricts the resources of the CLI process the client spawns,
+// so that a runaway agent cannot starve the application embedding it. Limits
+// do not apply to CLIs the client connects to with CLIUrl or SocketPath, or to
+// CLIs run over SSH.
+//
+// MaxMemory and MaxProcesses are checked every Interval. When one is exceeded,
+// OnLimitExceeded is called and the CLI is killed along with its child
+// processes; with AutoRestart it is started again on the next request.
+// Checking is supported on Linux, macOS, and other Unix systems, and Nice on
+// Unix systems; elsewhere the limits are ignored and a warning is logged.
+//
+// On Linux the kernel also enforces MaxMemory where the client may create
+// cgroups: when this process's cgroup v2 has the memory controller enabled
+// for its children, the CLI is moved to a child cgroup whose memory.max is
+// MaxMemory, so that the process tree cannot outgrow the limit between
+// checks, and the kernel kills the tree if it does not fit. MaxProcesses is
+// only checked: RLIMIT_NPROC counts every process of the user and pids.max
+// counts threads, so neither matches it.
+type ResourceLimits struct {
+	// MaxMemory caps the resident memory, in bytes, of the CLI and the processes
+	// it started. Zero means no limit.
+	MaxMemory int64
+	// MaxAddressSpace caps the virtual memory, in bytes, of the CLI and of each
+	// process it starts, with RLIMIT_AS: allocations past it fail. It applies
+	// to each process rather than to the tree, and Node reserves far more
+	// address space than it uses, so set it well above MaxMemory. Supported on
+	// Linux. Zero means no limit.
+	MaxAddressSpace int64
+	// MaxProcesses caps the number of processes the CLI has running at once,
+	// such as shell commands and MCP servers, not counting the CLI itself.
+	// Zero means no limit.
+	MaxProcesses int
+	// Nice lowers the scheduling priority of the CLI and its children, from 1
+	// (slightly lower) to 19 (lowest). Zero keeps the priority of this process.
+	Nice int
+	// Interval is how often usage is checked. Default: 1 second.
+	Interval time.Duration
+	// OnLimitExceeded, if set, is called when a limit is exceeded, before the
+	// CLI is killed.
+	OnLimitExceeded func(event ResourceLimitEvent)
+}
+
+// ResourceLimitEvent describes a CLI process that exceeded a [ResourceLimits] limit.
+type ResourceLimitEvent struct {
+	// Limit is the exceeded limit.
+	Limit ResourceLimit
+	// Pid is the CLI's process ID.
+	Pid int
+	// Value is the measured usage: bytes for ResourceLimitMemory, processes for
+	// ResourceLimitProcesses. When the kernel killed the CLI for exceeding
+	// MaxMemory, it is the peak memory of its cgroup.
+	Value int64
+	// Max is the configured limit, in the same unit as Value.
+	Max int64
+}
+
+// processUsage is a sample of the resources used by a process tree.
+type processUsage struct {
+	// Memory is the resident memory of the root process and its descendants.
+	Memory int64
+	// Descendants are the process IDs of the root's descendants.
+	Descendants []int
+}
+
+// resourceMonitor enforces the memory and process limits of one CLI process.
+type resourceMonitor struct {
+	limits ResourceLimits
+	pid    int
+	sample func(pid int) (processUsage, error)
+	kill   func(pids []int)
+	logger *slog.Logger
+	// cgroup, if set, is the cgroup the kernel enforces MaxMemory with.
+	cgroup *memoryCgroup
+}
+
+// niceCommand wraps command to run at the niceness in limits, on platforms that
+// support it.
+func (l *ResourceLimits) niceCommand(command string, args []string) (string, []string) {
+	if l == nil || l.Nice == 0 || !supportsNice {
+		return command, args
+	}
+	return "nice", append([]string{"-n", strconv.Itoa(l.Nice), command}, args...)
+}
+
+// limitResources starts enforcing ClientOptions.ResourceLimits on a newly spawned CLI.
+func (c *Client) limitResources(process *os.Process) {
+	limits := c.options.ResourceLimits
+	if limits == nil || c.options.SSH != nil {
+		return
+	}
+	if limits.Nice != 0 && !supportsNice {
+		c.options.Logger.Warn("ResourceLimits.Nice is not supported on this platform")
+	}
+	if limits.MaxAddressSpace > 0 {
+		if err := limitAddressSpace(process.Pid, limits.MaxAddressSpace); err != nil {
+			c.options.Logger.Warn("failed to apply ResourceLimits.MaxAddressSpace", "error", err)
+		}
+	}
+	if limits.MaxMemory <= 0 && limits.MaxProcesses <= 0 {
+		return
+	}
+	if !supportsProcessUsage {
+		c.options.Logger.Warn("ResourceLimits.MaxMemory and MaxProcesses are not supported on this platform")
+		return
+	}
+	m := &resourceMonitor{
+		limits: *limits,
+		pid:    process.Pid,
+		sample: sampleProcessUsage,
+		kill:   killProcesses,
+		logger: c.options.Logger,
+	}
+	if limits.MaxMemory > 0 {
+		cgroup, err := newMemoryCgroup(process.Pid, limits.MaxMemory)
+		if err != nil {
+			c.options.Logger.Debug("the kernel cannot enforce ResourceLimits.MaxMemory; checking it only", "error", err)
+		}
+		m.cgroup = cgroup
+	}
+	go m.run()
+}
+
+// run checks the process until it exits or exceeds a limit.
+func (m *resourceMonitor) run() {
+	interval := m.limits.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer m.cgroup.remove()
+	for range ticker.C {
+		if !m.check() {
+			return
+		}
+	}
+}
+
+// check samples the process once and kills it if it exceeds a limit. It
+// returns false once the process is gone or has been killed.
+func (m *resourceMonitor) check() bool {
+	event := ResourceLimitEvent{Pid: m.pid}
+	if m.cgroup.oomKilled() {
+		event.Limit, event.Value, event.Max = ResourceLimitMemory, m.cgroup.peak(m.limits.MaxMemory), m.limits.MaxMemory
+		m.logger.Error("CLI process exceeded resource limit; the kernel killed it", "limit", event.Limit, "pid", m.pid, "value", event.Value, "max", event.Max)
+		if m.limits.OnLimitExceeded != nil {
+			m.limits.OnLimitExceeded(event)
+		}
+		return false
+	}
+	usage, err := m.sample(m.pid)
+	if err != nil {
+		// The process has exited
+		return false
+	}
+	switch {
+	case m.limits.MaxMemory > 0 && usage.Memory > m.limits.MaxMemory:
+		event.Limit, event.Value, event.Max = ResourceLimitMemory, usage.Memory, m.limits.MaxMemory
+	case m.limits.MaxProcesses > 0 && len(usage.Descendants) > m.limits.MaxProcesses:
+		event.Limit, event.Value, event.Max = ResourceLimitProcesses, int64(len(usage.Descendants)), int64(m.limits.MaxProcesses)
+	default:
+		return true
+	}
+
+	m.logger.Error("CLI process exceeded resource limit; killing it", "limit", event.Limit, "pid", m.pid, "value", event.Value, "max", event.Max)
+	if m.limits.OnLimitExceeded != nil {
+		m.limits.OnLimitExceeded(event)
+	}
+	// Kill the children first so the CLI cannot start new ones
+	m.kill(append(usage.Descendants, m.pid))
+	return false
+}
+
+// killProcesses kills each process, ignoring ones that have already exited.
+func killProcesses(pids []int) {
+	for _, pid := range pids {
+		if process, err := os.FindProcess(pid); err == nil {
+			process.Kill()
+		}
+	}
+}
+
+// memoryCgroup is a cgroup v2 directory holding a CLI process tree, whose
+// memory.max is ResourceLimits.MaxMemory. Its methods do nothing on a nil
+// memoryCgroup.
+type memoryCgroup struct {
+	dir string
+}
+
+// oomKilled reports whether the kernel killed processes of the cgroup for
+// exceeding its memory limit. With memory.oom.group set, it kills the whole
+// tree at once.
+func (g *memoryCgroup) oomKilled() bool {
+	if g == nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(g.dir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			return count != "0"
+		}
+	}
+	return false
+}
+
+// peak returns the highest memory usage of the cgroup, or fallback on kernels
+// that do not report it.
+func (g *memoryCgroup) peak(fallback int64) int64 {
+	data, err := os.ReadFile(filepath.Join(g.dir, "memory.peak"))
+	if err != nil {
+		return fallback
+	}
+	peak, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return peak
+}
+
+// remove deletes the cgroup once the processes in it have exited, giving up
+// after a few seconds.
+func (g *memoryCgroup) remove() {
+	if g == nil {
+		return
+	}
+	for range 50 {
+		if err := os.Remove(g.dir); err == nil || errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// errProcessNotFound is returned by sampleProcessUsage for processes that have exited.
+var errProcessNotFound = errors.New("process not found")
+
+// treeUsage returns the usage of root and its descendants, from the parent and
+// resident memory of every process on the system.
+func treeUsage(root int, parents map[int]int, memory map[int]int64) (processUsage, error) {
+	if _, ok := parents[root]; !ok {
+		return processUsage{}, errProcessNotFound
+	}
+	children := make(map[int][]int)
+	for pid, ppid := range parents {
+		children[ppid] = append(children[ppid], pid)
+	}
+	usage := processUsage{Memory: memory[root]}
+	queue := slices.Clone(children[root])
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		usage.Memory += memory[pid]
+		usage.Descendants = append(usage.Descendants, pid)
+		queue = append(queue, children[pid]...)
+	}
+	return usage, nil
+}
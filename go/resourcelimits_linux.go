@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	supportsProcessUsage = true
+	supportsNice         = true
+)
+
+// sampleProcessUsage reads the process table from /proc.
+func sampleProcessUsage(root int) (processUsage, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return processUsage{}, err
+	}
+	pageSize := int64(os.Getpagesize())
+	parents := make(map[int]int)
+	memory := make(map[int]int64)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile("/proc/" + entry.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		// The command name in parentheses may contain spaces; fields after it are
+		// state, ppid, ... with rss (in pages) as the 22nd
+		end := strings.LastIndexByte(string(stat), ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		rss, _ := strconv.ParseInt(fields[21], 10, 64)
+		parents[pid] = ppid
+		memory[pid] = rss * pageSize
+	}
+	return treeUsage(root, parents, memory)
+}
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// limitAddressSpace sets the RLIMIT_AS of pid, which the processes it starts
+// inherit.
+func limitAddressSpace(pid int, max int64) error {
+	limit := &unix.Rlimit{Cur: uint64(max), Max: uint64(max)}
+	return unix.Prlimit(pid, unix.RLIMIT_AS, limit, nil)
+}
+
+// newMemoryCgroup moves pid to a new cgroup below the one of this process,
+// limited to max bytes of memory. It fails unless the memory controller is
+// enabled for the children of this process's cgroup, or can be: cgroup v2
+// only allows that in delegated cgroups holding no processes themselves.
+func newMemoryCgroup(pid int, max int64) (*memoryCgroup, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return nil, err
+	}
+	var parent string
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			parent = filepath.Join(cgroupRoot, path)
+		}
+	}
+	if parent == "" {
+		return nil, errors.New("cgroup v2 is not available")
+	}
+	subtree := filepath.Join(parent, "cgroup.subtree_control")
+	controllers, err := os.ReadFile(subtree)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(strings.Fields(string(controllers)), "memory") {
+		if err := os.WriteFile(subtree, []byte("+memory"), 0); err != nil {
+			return nil, fmt.Errorf("failed to enable the memory controller: %w", err)
+		}
+	}
+
+	g := &memoryCgroup{dir: filepath.Join(parent, "copilot-cli-"+strconv.Itoa(pid))}
+	if err := os.Mkdir(g.dir, 0o755); err != nil {
+		return nil, err
+	}
+	settings := [][2]string{
+		{"memory.max", strconv.FormatInt(max, 10)},
+		{"memory.oom.group", "1"},
+		{"cgroup.procs", strconv.Itoa(pid)},
+	}
+	for _, setting := range settings {
+		if err := os.WriteFile(filepath.Join(g.dir, setting[0]), []byte(setting[1]), 0); err != nil {
+			os.Remove(g.dir)
+			return nil, fmt.Errorf("failed to set %s: %w", setting[0], err)
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package copilot
+
+import "errors"
+
+const (
+	supportsProcessUsage = false
+	supportsNice         = false
+)
+
+func sampleProcessUsage(root int) (processUsage, error) {
+	return processUsage{}, errProcessNotFound
+}
+
+// limitAddressSpace is not supported on this platform.
+func limitAddressSpace(pid int, max int64) error {
+	return errors.New("not supported on this platform")
+}
+
+// newMemoryCgroup is not supported on this platform.
+func newMemoryCgroup(pid int, max int64) (*memoryCgroup, error) {
+	return nil, errors.New("cgroups require Linux")
+}
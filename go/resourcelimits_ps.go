@@ -0,0 +1,50 @@
+//go:build unix && !linux
+
+package copilot
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	supportsProcessUsage = true
+	supportsNice         = true
+)
+
+// sampleProcessUsage reads the process table with ps.
+func sampleProcessUsage(root int) (processUsage, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=", "-o", "ppid=", "-o", "rss=").Output()
+	if err != nil {
+		return processUsage{}, err
+	}
+	parents := make(map[int]int)
+	memory := make(map[int]int64)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rss, err3 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		parents[pid] = ppid
+		memory[pid] = rss * 1024
+	}
+	return treeUsage(root, parents, memory)
+}
+
+// limitAddressSpace is not supported on this platform.
+func limitAddressSpace(pid int, max int64) error {
+	return errors.New("not supported on this platform")
+}
+
+// newMemoryCgroup is not supported on this platform.
+func newMemoryCgroup(pid int, max int64) (*memoryCgroup, error) {
+	return nil, errors.New("cgroups require Linux")
+}
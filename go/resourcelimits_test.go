@@ -0,0 +1,173 @@
+package copilot
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResourceMonitor(t *testing.T) {
+	newMonitor := func(limits ResourceLimits, usage processUsage) (*resourceMonitor, *[]int, *[]ResourceLimitEvent) {
+		var killed []int
+		var events []ResourceLimitEvent
+		limits.OnLimitExceeded = func(event ResourceLimitEvent) { events = append(events, event) }
+		return &resourceMonitor{
+			limits: limits,
+			pid:    100,
+			sample: func(pid int) (processUsage, error) { return usage, nil },
+			kill:   func(pids []int) { killed = pids },
+			logger: slog.New(slog.DiscardHandler),
+		}, &killed, &events
+	}
+
+	t.Run("allows usage within the limits", func(t *testing.T) {
+		m, killed, events := newMonitor(ResourceLimits{MaxMemory: 1 << 30, MaxProcesses: 2}, processUsage{Memory: 1 << 20, Descendants: []int{101, 102}})
+		if !m.check() || len(*killed) != 0 || len(*events) != 0 {
+			t.Errorf("Expected the process to keep running, killed %v, events %v", *killed, *events)
+		}
+	})
+
+	t.Run("kills the process tree over the memory limit", func(t *testing.T) {
+		m, killed, events := newMonitor(ResourceLimits{MaxMemory: 1 << 20}, processUsage{Memory: 2 << 20, Descendants: []int{101}})
+		if m.check() {
+			t.Error("Expected monitoring to stop")
+		}
+		if !slices.Equal(*killed, []int{101, 100}) {
+			t.Errorf("Expected the child and then the CLI to be killed, got %v", *killed)
+		}
+		want := ResourceLimitEvent{Limit: ResourceLimitMemory, Pid: 100, Value: 2 << 20, Max: 1 << 20}
+		if len(*events) != 1 || (*events)[0] != want {
+			t.Errorf("Unexpected events: %+v", *events)
+		}
+	})
+
+	t.Run("kills the process tree over the process limit", func(t *testing.T) {
+		m, killed, events := newMonitor(ResourceLimits{MaxProcesses: 1}, processUsage{Descendants: []int{101, 102}})
+		m.check()
+		if len(*killed) != 3 || len(*events) != 1 || (*events)[0].Limit != ResourceLimitProcesses || (*events)[0].Value != 2 {
+			t.Errorf("Unexpected outcome: killed %v, events %+v", *killed, *events)
+		}
+	})
+
+	t.Run("reports a tree the kernel killed for its memory", func(t *testing.T) {
+		m, killed, events := newMonitor(ResourceLimits{MaxMemory: 1 << 20}, processUsage{Memory: 1 << 10})
+		m.cgroup = &memoryCgroup{dir: t.TempDir()}
+		os.WriteFile(filepath.Join(m.cgroup.dir, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 0\noom_kill 0\n"), 0o644)
+		if !m.check() || len(*events) != 0 {
+			t.Fatalf("Expected the process to keep running, events %v", *events)
+		}
+
+		os.WriteFile(filepath.Join(m.cgroup.dir, "memory.events"), []byte("low 0\nhigh 0\nmax 9\noom 1\noom_kill 1\n"), 0o644)
+		os.WriteFile(filepath.Join(m.cgroup.dir, "memory.peak"), []byte("1048576\n"), 0o644)
+		if m.check() {
+			t.Error("Expected monitoring to stop")
+		}
+		want := ResourceLimitEvent{Limit: ResourceLimitMemory, Pid: 100, Value: 1 << 20, Max: 1 << 20}
+		if len(*events) != 1 || (*events)[0] != want || len(*killed) != 0 {
+			t.Errorf("Unexpected outcome: killed %v, events %+v", *killed, *events)
+		}
+	})
+}
+
+func TestKernelLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("kernel limits require Linux")
+	}
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start a child process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	t.Run("limits the address space", func(t *testing.T) {
+		if err := limitAddressSpace(cmd.Process.Pid, 64<<30); err != nil {
+			t.Fatalf("Failed to limit the address space: %v", err)
+		}
+		limits, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/limits")
+		if err != nil {
+			t.Fatalf("Failed to read the limits: %v", err)
+		}
+		if !regexp.MustCompile(`Max address space\s+68719476736\s+68719476736`).Match(limits) {
+			t.Errorf("Expected a 64 GiB address space limit, got:\n%s", limits)
+		}
+	})
+
+	t.Run("moves the process to a memory cgroup", func(t *testing.T) {
+		cgroup, err := newMemoryCgroup(cmd.Process.Pid, 1<<30)
+		if err != nil {
+			t.Skipf("Cannot create a memory cgroup here: %v", err)
+		}
+		max, _ := os.ReadFile(filepath.Join(cgroup.dir, "memory.max"))
+		procs, _ := os.ReadFile(filepath.Join(cgroup.dir, "cgroup.procs"))
+		if strings.TrimSpace(string(max)) != "1073741824" || strings.TrimSpace(string(procs)) != strconv.Itoa(cmd.Process.Pid) {
+			t.Errorf("Unexpected cgroup: memory.max %q, cgroup.procs %q", max, procs)
+		}
+		if cgroup.oomKilled() {
+			t.Error("Expected no OOM kills")
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+		cgroup.remove()
+		if _, err := os.Stat(cgroup.dir); err == nil {
+			t.Error("Expected the cgroup to be removed")
+		}
+	})
+}
+
+func TestTreeUsage(t *testing.T) {
+	parents := map[int]int{1: 0, 10: 1, 11: 10, 12: 11, 13: 10, 20: 1}
+	memory := map[int]int64{10: 100, 11: 10, 12: 1, 13: 1000, 20: 5}
+	usage, err := treeUsage(10, parents, memory)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	slices.Sort(usage.Descendants)
+	if usage.Memory != 1111 || !slices.Equal(usage.Descendants, []int{11, 12, 13}) {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if _, err := treeUsage(99, parents, memory); err != errProcessNotFound {
+		t.Errorf("Expected errProcessNotFound, got %v", err)
+	}
+}
+
+func TestSampleProcessUsage(t *testing.T) {
+	if !supportsProcessUsage {
+		t.Skip("process usage is not supported on this platform")
+	}
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot start a child process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	usage, err := sampleProcessUsage(os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to sample usage: %v", err)
+	}
+	if usage.Memory <= 0 || !slices.Contains(usage.Descendants, cmd.Process.Pid) {
+		t.Errorf("Expected this process's memory and the child, got %+v", usage)
+	}
+}
+
+func TestResourceLimits_niceCommand(t *testing.T) {
+	command, args := (&ResourceLimits{Nice: 10}).niceCommand("copilot", []string{"--headless"})
+	if supportsNice && (command != "nice" || !slices.Equal(args, []string{"-n", "10", "copilot", "--headless"})) {
+		t.Errorf("Unexpected command: %s %v", command, args)
+	}
+	if command, _ := (*ResourceLimits)(nil).niceCommand("copilot", nil); command != "copilot" {
+		t.Errorf("Expected no wrapper without limits, got %s", command)
+	}
+}
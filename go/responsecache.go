@@ -0,0 +1,378 @@
+package copilot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResponseCacheConfig enables the client's response cache. With it,
+// [Session.SendAndWait] returns the stored response of an identical prompt
+// sent in an identical context instead of running the turn again, e.g. for
+// documentation pipelines that are rerun often.
+//
+// Two turns share a context when their sessions were created with the same
+// configuration, the earlier prompts and responses of the sessions match, and
+// the same files were attached with the same contents. Resumed sessions, and
+// sessions after a message sent with [Session.Send], with images, or without
+// the cache, are not cached: the SDK cannot tell what the agent saw.
+//
+// A cached response runs no tools, and the agent does not see its turn. A
+// message that is not in the cache, sent after a cached response, would run
+// against a history without the cached turns, so it fails with an error
+// matching [ErrCacheDiverged] instead. Send it in a new session, or set
+// MessageOptions.SkipCache to send it anyway; the session is then no longer
+// cached.
+type ResponseCacheConfig struct {
+	// Store keeps the cached responses. Required. See [NewMemoryResponseCache]
+	// and [NewFileResponseCache].
+	Store ResponseCacheStore
+	// TTL, when positive, is how long a cached response is used. Default: 0
+	// (cached responses do not expire).
+	TTL time.Duration
+	// Context, when non-nil, returns extra context for the cache key, such as
+	// the commit of the repository being documented, so that responses are not
+	// reused after it changes. An error sends the message without the cache.
+	Context func(ctx context.Context) (string, error)
+}
+
+// CachedResponse is a response kept in a [ResponseCacheStore].
+type CachedResponse struct {
+	// Response is the assistant.message event returned by SendAndWait.
+	Response SessionEvent `json:"response"`
+	// CreatedAt is when the response was cached.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ResponseCacheStore keeps the responses of ClientOptions.ResponseCache.
+// Keys are hex-encoded SHA-256 hashes. Implementations must be safe for
+// concurrent use.
+type ResponseCacheStore interface {
+	// LoadResponse returns the response cached under key, or nil if there is
+	// none.
+	LoadResponse(key string) (*CachedResponse, error)
+	// SaveResponse caches response under key, replacing any previous response.
+	SaveResponse(key string, response CachedResponse) error
+}
+
+// ResponseCacheStats counts the lookups of the client's response cache.
+type ResponseCacheStats struct {
+	// Hits is how many SendAndWait calls returned a cached response.
+	Hits int64
+	// Misses is how many SendAndWait calls looked up the cache and ran the turn.
+	Misses int64
+}
+
+// ResponseCacheStats returns the number of hits and misses of
+// ClientOptions.ResponseCache, or zero counts when it is not set.
+func (c *Client) ResponseCacheStats() ResponseCacheStats {
+	if c.responseCache == nil {
+		return ResponseCacheStats{}
+	}
+	return ResponseCacheStats{Hits: c.responseCache.hits.Load(), Misses: c.responseCache.misses.Load()}
+}
+
+// MemoryResponseCache is a [ResponseCacheStore] that keeps responses in memory.
+type MemoryResponseCache struct {
+	maxEntries int
+
+	mu        sync.Mutex
+	responses map[string]CachedResponse
+	order     []string // keys, oldest first
+}
+
+// NewMemoryResponseCache returns a store that keeps responses in memory,
+// discarding the oldest ones beyond maxEntries. A maxEntries of 0 keeps every
+// response.
+func NewMemoryResponseCache(maxEntries int) *MemoryResponseCache {
+	return &MemoryResponseCache{maxEntries: maxEntries, responses: make(map[string]CachedResponse)}
+}
+
+// LoadResponse returns the response cached under key, or nil.
+func (m *MemoryResponseCache) LoadResponse(key string) (*CachedResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	response, ok := m.responses[key]
+	if !ok {
+		return nil, nil
+	}
+	return &response, nil
+}
+
+// SaveResponse caches response under key.
+func (m *MemoryResponseCache) SaveResponse(key string, response CachedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.responses[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.responses[key] = response
+	if m.maxEntries > 0 && len(m.order) > m.maxEntries {
+		for _, oldest := range m.order[:len(m.order)-m.maxEntries] {
+			delete(m.responses, oldest)
+		}
+		m.order = append([]string(nil), m.order[len(m.order)-m.maxEntries:]...)
+	}
+	return nil
+}
+
+// FileResponseCache is a [ResponseCacheStore] that keeps each response in a
+// JSON file in a directory, so that cached responses survive across runs.
+// Create one with [NewFileResponseCache].
+type FileResponseCache struct {
+	dir string
+}
+
+// NewFileResponseCache returns a store that keeps responses in dir, which is
+// created on the first save.
+//
+// Example:
+//
+//	dir, _ := os.UserCacheDir()
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    ResponseCache: &copilot.ResponseCacheConfig{
+//	        Store: copilot.NewFileResponseCache(filepath.Join(dir, "myapp", "responses")),
+//	        TTL:   7 * 24 * time.Hour,
+//	    },
+//	})
+func NewFileResponseCache(dir string) *FileResponseCache {
+	return &FileResponseCache{dir: dir}
+}
+
+// LoadResponse reads <dir>/<key>.json, returning nil if it does not exist.
+func (f *FileResponseCache) LoadResponse(key string) (*CachedResponse, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached response: %w", err)
+	}
+	var response CachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse cached response %s: %w", key, err)
+	}
+	return &response, nil
+}
+
+// SaveResponse writes response to <dir>/<key>.json.
+func (f *FileResponseCache) SaveResponse(key string, response CachedResponse) error {
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create response cache directory: %w", err)
+	}
+	path := f.path(key)
+	// Write a temporary file first so that a crash cannot leave a partial response
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write cached response: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cached response: %w", err)
+	}
+	return nil
+}
+
+func (f *FileResponseCache) path(key string) string {
+	return filepath.Join(f.dir, filepath.Base(key)+".json")
+}
+
+// responseCache is the client's ResponseCacheConfig and its counters.
+type responseCache struct {
+	config ResponseCacheConfig
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// session returns the cache state of a session created with req. It is nil,
+// caching nothing, when c is nil.
+func (c *responseCache) session(req createSessionRequest) *sessionResponseCache {
+	if c == nil {
+		return nil
+	}
+	// The session ID and metadata identify the session, not its context
+	req.SessionID, req.ClientName, req.Metadata = "", "", nil
+	data, _ := json.Marshal(req)
+	return &sessionResponseCache{cache: c, context: cacheDigest(data)}
+}
+
+// sessionResponseCache tracks the context of a session's turns for the
+// response cache. Its methods are safe to call on a nil *sessionResponseCache.
+type sessionResponseCache struct {
+	cache *responseCache
+
+	mu       sync.Mutex
+	context  string // hash of the session's configuration and earlier turns
+	skipped  bool   // a turn was served from the cache, so the CLI did not see it
+	disabled bool   // the CLI's history no longer matches context
+}
+
+// responseCacheKey holds what makes a turn's response reusable.
+type responseCacheKey struct {
+	Context         string            `json:"context"`
+	Extra           string            `json:"extra,omitempty"`
+	Prompt          string            `json:"prompt"`
+	Attachments     []Attachment      `json:"attachments,omitempty"`
+	Files           map[string]string `json:"files,omitempty"` // attached file path to content hash
+	Mode            string            `json:"mode,omitempty"`
+	Model           string            `json:"model,omitempty"`
+	Temperature     *float64          `json:"temperature,omitempty"`
+	MaxOutputTokens int               `json:"maxOutputTokens,omitempty"`
+	ReasoningEffort string            `json:"reasoningEffort,omitempty"`
+	HistoryWindow   *HistoryWindow    `json:"historyWindow,omitempty"`
+	Locale          string            `json:"locale,omitempty"`
+}
+
+// key returns the cache key of a SendAndWait call, or false if its response
+// must not be cached.
+func (c *sessionResponseCache) key(ctx context.Context, options MessageOptions) (string, bool) {
+	if c == nil || options.SkipCache || len(options.Images) > 0 {
+		return "", false
+	}
+	c.mu.Lock()
+	key := responseCacheKey{
+		Context:         c.context,
+		Prompt:          options.Prompt,
+		Attachments:     options.Attachments,
+		Mode:            options.Mode,
+		Model:           options.Model,
+		Temperature:     options.Temperature,
+		MaxOutputTokens: options.MaxOutputTokens,
+		ReasoningEffort: options.ReasoningEffort,
+		HistoryWindow:   options.HistoryWindow,
+		Locale:          options.Locale,
+	}
+	disabled := c.disabled
+	c.mu.Unlock()
+	if disabled {
+		return "", false
+	}
+
+	if c.cache.config.Context != nil {
+		extra, err := c.cache.config.Context(ctx)
+		if err != nil {
+			return "", false
+		}
+		key.Extra = extra
+	}
+	for _, attachment := range options.Attachments {
+		if attachment.Type != File || attachment.Path == nil {
+			continue
+		}
+		data, err := os.ReadFile(*attachment.Path)
+		if err != nil {
+			return "", false
+		}
+		if key.Files == nil {
+			key.Files = make(map[string]string)
+		}
+		key.Files[*attachment.Path] = cacheDigest(data)
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", false
+	}
+	return cacheDigest(data), true
+}
+
+// diverged returns an error matching ErrCacheDiverged if a message sent with
+// options would reach a CLI that did not see the turns served from the cache.
+func (c *sessionResponseCache) diverged(sessionID string, options MessageOptions) error {
+	if c == nil || options.SkipCache {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.skipped || c.disabled {
+		return nil
+	}
+	return &SDKError{
+		Code: ErrorCodeCacheDiverged,
+		Message: fmt.Sprintf("session %s: the message is not cached, but earlier turns were served from the response cache "+
+			"and the CLI did not see them; send it in a new session, or with SkipCache to send it anyway", sessionID),
+	}
+}
+
+// load returns the unexpired response cached under key, or nil.
+func (c *sessionResponseCache) load(key string) (*SessionEvent, error) {
+	cached, err := c.cache.config.Store.LoadResponse(key)
+	if err != nil || cached == nil {
+		c.cache.misses.Add(1)
+		return nil, err
+	}
+	if c.cache.config.TTL > 0 && time.Since(cached.CreatedAt) > c.cache.config.TTL {
+		c.cache.misses.Add(1)
+		return nil, nil
+	}
+	c.cache.hits.Add(1)
+	response := cached.Response
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.context, c.skipped = nextCacheContext(key, &response), true
+	return &response, nil
+}
+
+// save caches the response of a turn that ran with key, unless the CLI ran
+// it without the turns served from the cache earlier.
+func (c *sessionResponseCache) save(key string, response *SessionEvent) error {
+	c.mu.Lock()
+	if c.skipped {
+		c.disabled = true
+	}
+	disabled := c.disabled
+	c.context = nextCacheContext(key, response)
+	c.mu.Unlock()
+	if disabled || response == nil {
+		return nil
+	}
+	return c.cache.config.Store.SaveResponse(key, CachedResponse{Response: *response, CreatedAt: time.Now()})
+}
+
+// disable stops caching for the session, e.g. after a turn the cache did
+// not see.
+func (c *sessionResponseCache) disable() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = true
+}
+
+// fork returns the cache state of a fork of the session.
+func (c *sessionResponseCache) fork() *sessionResponseCache {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &sessionResponseCache{cache: c.cache, context: c.context, skipped: c.skipped, disabled: c.disabled}
+}
+
+// nextCacheContext returns the context following a turn with key and response.
+func nextCacheContext(key string, response *SessionEvent) string {
+	var content string
+	if response != nil {
+		content = deref(response.Data.Content)
+	}
+	return cacheDigest([]byte(key + "\x00" + content))
+}
+
+// cacheDigest returns the hex-encoded SHA-256 digest of data.
+func cacheDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,235 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryResponseCache(t *testing.T) {
+	store := NewMemoryResponseCache(2)
+	for _, key := range []string{"a", "b", "a", "c"} {
+		store.SaveResponse(key, CachedResponse{Response: SessionEvent{Data: Data{Content: String(key)}}})
+	}
+	if response, _ := store.LoadResponse("a"); response != nil {
+		t.Errorf("Expected the oldest response to be discarded, got %+v", response)
+	}
+	for _, key := range []string{"b", "c"} {
+		if response, err := store.LoadResponse(key); err != nil || response == nil || *response.Response.Data.Content != key {
+			t.Errorf("LoadResponse(%q) = %+v, %v", key, response, err)
+		}
+	}
+}
+
+func TestFileResponseCache(t *testing.T) {
+	store := NewFileResponseCache(filepath.Join(t.TempDir(), "responses"))
+	if response, err := store.LoadResponse("missing"); response != nil || err != nil {
+		t.Errorf("Expected no response for a missing key, got %+v, %v", response, err)
+	}
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := store.SaveResponse("k1", CachedResponse{Response: SessionEvent{Type: AssistantMessage, Data: Data{Content: String("Docs")}}, CreatedAt: createdAt})
+	if err != nil {
+		t.Fatalf("SaveResponse failed: %v", err)
+	}
+	response, err := store.LoadResponse("k1")
+	if err != nil || response == nil || response.Response.Type != AssistantMessage || *response.Response.Data.Content != "Docs" || !response.CreatedAt.Equal(createdAt) {
+		t.Errorf("Unexpected response: %+v, %v", response, err)
+	}
+}
+
+func TestSession_ResponseCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api.go")
+	os.WriteFile(file, []byte("package api"), 0o600)
+	prompt := MessageOptions{Prompt: "Document this file", Attachments: []Attachment{{Type: File, Path: String(file), DisplayName: "api.go"}}}
+
+	turn := func(log *replayLog, sessionID, content string) {
+		log.call("session.send", map[string]any{"messageId": "m-" + content})
+		log.event(sessionID, AssistantMessage, map[string]any{"messageId": "m-" + content, "content": content})
+		log.event(sessionID, SessionIdle, map[string]any{})
+	}
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	turn(log, "s1", "First docs")
+	log.call("session.create", map[string]any{"sessionId": "s2"})
+	log.call("session.create", map[string]any{"sessionId": "s5"})
+	turn(log, "s5", "Tests docs")
+	log.call("session.create", map[string]any{"sessionId": "s3"})
+	turn(log, "s3", "Docs for the new file")
+	log.call("session.create", map[string]any{"sessionId": "s4"})
+	turn(log, "s4", "More docs")
+
+	var recorded bytes.Buffer
+	client := newPlaybackClientForTest(t, log, &recorded)
+	store := NewMemoryResponseCache(0)
+	client.responseCache = &responseCache{config: ResponseCacheConfig{Store: store}}
+	create := func() *Session {
+		t.Helper()
+		session, err := client.CreateSession(t.Context(), &SessionConfig{Model: "gpt-5", OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+	send := func(session *Session, options MessageOptions) string {
+		t.Helper()
+		response, err := session.SendAndWait(t.Context(), options)
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		return *response.Data.Content
+	}
+
+	if got := send(create(), prompt); got != "First docs" {
+		t.Fatalf("Unexpected response: %q", got)
+	}
+
+	// An identical prompt in an identical context is served from the cache
+	if got := send(create(), prompt); got != "First docs" {
+		t.Errorf("Expected the cached response, got %q", got)
+	}
+
+	// After a cached turn, an uncached message would run against a history
+	// without it
+	session := create()
+	send(session, prompt)
+	if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "And the tests?"}); !errors.Is(err, ErrCacheDiverged) {
+		t.Errorf("Expected ErrCacheDiverged for an uncached message after a hit, got %v", err)
+	}
+	if _, err := session.Send(t.Context(), MessageOptions{Prompt: "And the tests?"}); !errors.Is(err, ErrCacheDiverged) {
+		t.Errorf("Expected Send to fail with ErrCacheDiverged too, got %v", err)
+	}
+	if got := send(session, MessageOptions{Prompt: "And the tests?", SkipCache: true}); got != "Tests docs" {
+		t.Errorf("Expected SkipCache to send the message, got %q", got)
+	}
+
+	// Changing the attached file changes the context
+	os.WriteFile(file, []byte("package api // v2"), 0o600)
+	session = create()
+	if got := send(session, prompt); got != "Docs for the new file" {
+		t.Errorf("Expected a new response after the file changed, got %q", got)
+	}
+
+	// Skipping the cache stops caching for the rest of the session
+	os.WriteFile(file, []byte("package api"), 0o600)
+	session = create()
+	if got := send(session, MessageOptions{Prompt: prompt.Prompt, Attachments: prompt.Attachments, SkipCache: true}); got != "More docs" {
+		t.Errorf("Expected SkipCache to send the message, got %q", got)
+	}
+	if _, cacheable := session.responseCache.key(t.Context(), prompt); cacheable {
+		t.Error("Expected later messages not to be cached after SkipCache")
+	}
+
+	if stats := client.ResponseCacheStats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(store.responses) != 2 {
+		t.Errorf("Expected 2 cached responses, got %d", len(store.responses))
+	}
+
+	records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+	sends := 0
+	for _, record := range records {
+		var message struct {
+			Method string `json:"method"`
+		}
+		json.Unmarshal(record.Message, &message)
+		if message.Method == "session.send" {
+			sends++
+		}
+	}
+	if sends != 4 {
+		t.Errorf("Expected 4 messages sent to the CLI, got %d", sends)
+	}
+}
+
+func TestSession_ResponseCacheScreening(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.send", map[string]any{"messageId": "m1"})
+	log.event("s1", AssistantMessage, map[string]any{"messageId": "m1", "content": "Docs"})
+	log.event("s1", SessionIdle, map[string]any{})
+	log.call("session.create", map[string]any{"sessionId": "s2"})
+	log.call("session.create", map[string]any{"sessionId": "s3"})
+	log.call("session.destroy", map[string]any{})
+
+	client := newPlaybackClientForTest(t, log, nil)
+	client.responseCache = &responseCache{config: ResponseCacheConfig{Store: NewMemoryResponseCache(0)}}
+	create := func(onBeforeSend func(*MessageOptions) error) *Session {
+		t.Helper()
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, OnBeforeSend: onBeforeSend})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+	redact := func(options *MessageOptions) error {
+		options.Prompt = strings.ReplaceAll(options.Prompt, "hunter2", "[REDACTED]")
+		return nil
+	}
+
+	if response, err := create(redact).SendAndWait(t.Context(), MessageOptions{Prompt: "Document hunter2"}); err != nil || *response.Data.Content != "Docs" {
+		t.Fatalf("Unexpected response: %+v, %v", response, err)
+	}
+
+	// The response is cached under the prompt OnBeforeSend rewrote
+	session := create(nil)
+	if response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Document [REDACTED]"}); err != nil || *response.Data.Content != "Docs" {
+		t.Errorf("Expected a hit for the rewritten prompt, got %+v, %v", response, err)
+	}
+
+	// OnBeforeSend runs on hits too
+	blocked := create(func(*MessageOptions) error { return errors.New("policy violation") })
+	if _, err := blocked.SendAndWait(t.Context(), MessageOptions{Prompt: "Document [REDACTED]"}); err == nil || !strings.Contains(err.Error(), "policy violation") {
+		t.Errorf("Expected OnBeforeSend to block a cached prompt, got %v", err)
+	}
+
+	if err := session.Destroy(); err != nil {
+		t.Fatalf("Failed to destroy session: %v", err)
+	}
+	if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Document [REDACTED]"}); err == nil || !strings.Contains(err.Error(), "destroyed") {
+		t.Errorf("Expected a destroyed session not to be served from the cache, got %v", err)
+	}
+}
+
+func TestSessionResponseCache_SkippedTurns(t *testing.T) {
+	store := NewMemoryResponseCache(0)
+	cache := &responseCache{config: ResponseCacheConfig{Store: store, TTL: time.Hour}}
+	session := cache.session(createSessionRequest{Model: "gpt-5"})
+	other := cache.session(createSessionRequest{Model: "gpt-5", SessionID: "other"})
+
+	key, _ := session.key(t.Context(), MessageOptions{Prompt: "one"})
+	session.save(key, &SessionEvent{Data: Data{Content: String("1")}})
+	if otherKey, _ := other.key(t.Context(), MessageOptions{Prompt: "one"}); otherKey != key {
+		t.Fatal("Expected sessions with the same configuration to share keys")
+	}
+	if response, _ := other.load(key); response == nil {
+		t.Fatal("Expected a cache hit")
+	}
+
+	if err := other.diverged("other", MessageOptions{Prompt: "two"}); !errors.Is(err, ErrCacheDiverged) {
+		t.Errorf("Expected an uncached message after a hit to diverge, got %v", err)
+	}
+	if err := session.diverged("s1", MessageOptions{Prompt: "two"}); err != nil {
+		t.Errorf("Expected a session without hits not to diverge, got %v", err)
+	}
+
+	// The CLI did not see the cached turn, so the next response is not cached
+	next, _ := other.key(t.Context(), MessageOptions{Prompt: "two"})
+	other.save(next, &SessionEvent{Data: Data{Content: String("2")}})
+	if response, _ := store.LoadResponse(next); response != nil {
+		t.Error("Expected the response after a cache hit not to be cached")
+	}
+
+	store.SaveResponse("stale", CachedResponse{CreatedAt: time.Now().Add(-2 * time.Hour)})
+	if response, _ := session.load("stale"); response != nil {
+		t.Error("Expected an expired response to be ignored")
+	}
+}
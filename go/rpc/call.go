@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// Call invokes a server-scoped RPC method by name. Use it for methods that do
+// not have typed bindings yet.
+//
+// params is marshaled to JSON and may be nil. If result is non-nil, the
+// response is unmarshaled into it.
+func (a *ServerRpc) Call(ctx context.Context, method string, params any, result any) error {
+	return call(ctx, a.client, method, params, result)
+}
+
+// Call invokes a session-scoped RPC method by name. Use it for methods that do
+// not have typed bindings yet.
+//
+// params must marshal to a JSON object, or be nil. The session ID is added to
+// it as "sessionId" unless already present. If result is non-nil, the response
+// is unmarshaled into it.
+//
+// Example:
+//
+//	var result struct {
+//	    Items []string `json:"items"`
+//	}
+//	err := session.RPC.Call(ctx, "session.todos.list", map[string]any{"status": "open"}, &result)
+func (a *SessionRpc) Call(ctx context.Context, method string, params any, result any) error {
+	// Keep field values as raw JSON so that numbers are not rounded to float64
+	req := map[string]json.RawMessage{}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("params for %s must be a JSON object: %w", method, err)
+		}
+	}
+	if _, ok := req["sessionId"]; !ok {
+		sessionID, err := json.Marshal(a.sessionID)
+		if err != nil {
+			return err
+		}
+		req["sessionId"] = sessionID
+	}
+	return call(ctx, a.client, method, req, result)
+}
+
+func call(ctx context.Context, client *jsonrpc2.Client, method string, params any, result any) error {
+	raw, err := client.RequestContext(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
@@ -0,0 +1,1128 @@
+// Hand-written bindings for RPC methods that are not described by the
+// api.schema.json of the published @github/copilot package, and so are not
+// emitted into generated_rpc.go by scripts/codegen/go.ts. CLI builds that do
+// not serve a method answer with a JSON-RPC "method not found" error. Move a
+// binding into the schema once the CLI publishes it.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+)
+
+// ServerRpcExtensions holds the server-scoped APIs of this file. Its fields
+// are promoted to [ServerRpc].
+type ServerRpcExtensions struct {
+	Embeddings   *EmbeddingsRpcApi
+	Capabilities *CapabilitiesRpcApi
+	Sessions     *SessionsRpcApi
+}
+
+func newServerRpcExtensions(client *jsonrpc2.Client) *ServerRpcExtensions {
+	return &ServerRpcExtensions{
+		Embeddings:   &EmbeddingsRpcApi{client: client},
+		Capabilities: &CapabilitiesRpcApi{client: client},
+		Sessions:     &SessionsRpcApi{client: client},
+	}
+}
+
+// SessionRpcExtensions holds the session-scoped APIs of this file. Its fields
+// are promoted to [SessionRpc].
+type SessionRpcExtensions struct {
+	Files       *FilesRpcApi
+	Slash       *SlashRpcApi
+	Context     *ContextRpcApi
+	Checkpoints *CheckpointsRpcApi
+	Memory      *MemoryRpcApi
+	Tool        *ToolRpcApi
+	Title       *TitleRpcApi
+	History     *HistoryRpcApi
+	References  *ReferencesRpcApi
+	Feedback    *FeedbackRpcApi
+	Pins        *PinsRpcApi
+}
+
+func newSessionRpcExtensions(client *jsonrpc2.Client, sessionID string) *SessionRpcExtensions {
+	return &SessionRpcExtensions{
+		Files:       &FilesRpcApi{client: client, sessionID: sessionID},
+		Slash:       &SlashRpcApi{client: client, sessionID: sessionID},
+		Context:     &ContextRpcApi{client: client, sessionID: sessionID},
+		Checkpoints: &CheckpointsRpcApi{client: client, sessionID: sessionID},
+		Memory:      &MemoryRpcApi{client: client, sessionID: sessionID},
+		Tool:        &ToolRpcApi{client: client, sessionID: sessionID},
+		Title:       &TitleRpcApi{client: client, sessionID: sessionID},
+		History:     &HistoryRpcApi{client: client, sessionID: sessionID},
+		References:  &ReferencesRpcApi{client: client, sessionID: sessionID},
+		Feedback:    &FeedbackRpcApi{client: client, sessionID: sessionID},
+		Pins:        &PinsRpcApi{client: client, sessionID: sessionID},
+	}
+}
+
+type SessionAgentHandoffResult struct {
+	// The custom agent now handling the session
+	Agent SessionAgentHandoffResultAgent `json:"agent"`
+	// Name of the custom agent that handed off, or null if the default agent did
+	PreviousAgent *string `json:"previousAgent"`
+}
+
+// The custom agent now handling the session
+type SessionAgentHandoffResultAgent struct {
+	// Description of the agent's purpose
+	Description string `json:"description"`
+	// Human-readable display name
+	DisplayName string `json:"displayName"`
+	// Unique identifier of the custom agent
+	Name string `json:"name"`
+}
+
+type SessionAgentHandoffParams struct {
+	// Instructions for the receiving agent, added to the conversation as the handoff message
+	Instructions string `json:"instructions"`
+	// Name of the custom agent to hand the session to
+	Name string `json:"name"`
+	// Structured context passed to the receiving agent along with the conversation history
+	Note *SessionAgentHandoffParamsNote `json:"note,omitempty"`
+}
+
+// Structured context passed to the receiving agent along with the conversation history
+type SessionAgentHandoffParamsNote struct {
+	// Decisions the handing-off agent made that the receiving agent should keep
+	Decisions []string `json:"decisions,omitempty"`
+	// Files relevant to the remaining work
+	Files []string `json:"files,omitempty"`
+	// Questions or tasks left open
+	OpenItems []string `json:"openItems,omitempty"`
+	// Summary of the work done so far
+	Summary *string `json:"summary,omitempty"`
+}
+
+type SessionFilesListResult struct {
+	// Files currently pinned into the session context
+	Files []File `json:"files"`
+}
+
+type File struct {
+	// Human-readable name shown in mentions (defaults to the file name)
+	DisplayName string `json:"displayName"`
+	// Absolute path of the pinned file
+	Path string `json:"path"`
+}
+
+type SessionFilesAddResult struct {
+	// Whether the file was newly pinned (false if it was already in context)
+	Added bool `json:"added"`
+	// Absolute path of the pinned file as resolved by the server
+	Path string `json:"path"`
+}
+
+type SessionFilesAddParams struct {
+	// Optional display name used when the file is mentioned in context
+	DisplayName *string `json:"displayName,omitempty"`
+	// Path of the file to pin, absolute or relative to the session working directory
+	Path string `json:"path"`
+}
+
+type SessionFilesRemoveResult struct {
+	// Whether the file was pinned before removal
+	Removed bool `json:"removed"`
+}
+
+type SessionFilesRemoveParams struct {
+	// Path of the file to unpin, absolute or relative to the session working directory
+	Path string `json:"path"`
+}
+
+type SessionAgentRegisterResult struct {
+	// The registered custom agent
+	Agent SessionAgentRegisterResultAgent `json:"agent"`
+	// Whether an existing agent with the same name was replaced
+	Replaced bool `json:"replaced"`
+}
+
+// The registered custom agent
+type SessionAgentRegisterResultAgent struct {
+	// Description of the agent's purpose
+	Description string `json:"description"`
+	// Human-readable display name
+	DisplayName string `json:"displayName"`
+	// Unique identifier of the custom agent
+	Name string `json:"name"`
+}
+
+type SessionAgentRegisterParams struct {
+	// Custom agent definition to add, or to update if an agent with the same name exists
+	Agent SessionAgentRegisterParamsAgent `json:"agent"`
+}
+
+// Custom agent definition to add, or to update if an agent with the same name exists
+type SessionAgentRegisterParamsAgent struct {
+	// Description of the agent's purpose
+	Description *string `json:"description,omitempty"`
+	// Human-readable display name
+	DisplayName *string `json:"displayName,omitempty"`
+	// Whether the agent should be available for model inference
+	Infer *bool `json:"infer,omitempty"`
+	// MCP servers specific to this agent
+	MCPServers map[string]map[string]interface{} `json:"mcpServers,omitempty"`
+	// Unique identifier of the custom agent
+	Name string `json:"name"`
+	// Prompt content for the agent
+	Prompt string `json:"prompt"`
+	// Tool names the agent can use (omit for all tools)
+	Tools []string `json:"tools,omitempty"`
+}
+
+type SessionAgentUnregisterResult struct {
+	// Whether an agent with the given name was removed
+	Removed bool `json:"removed"`
+}
+
+type SessionAgentUnregisterParams struct {
+	// Name of the custom agent to remove
+	Name string `json:"name"`
+}
+
+type SessionSlashListResult struct {
+	// Slash commands available in this session
+	Commands []SlashCommand `json:"commands"`
+}
+
+type SlashCommand struct {
+	// Usage hint for the command's arguments
+	ArgumentHint *string `json:"argumentHint,omitempty"`
+	// Description of what the command does
+	Description string `json:"description"`
+	// Command name without the leading slash (e.g., "clear", "diff", "review")
+	Name string `json:"name"`
+}
+
+type SessionSlashRunResult struct {
+	// Structured result, when the command produces one
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Text output of the command
+	Output string `json:"output"`
+	// Whether the command completed successfully
+	Success bool `json:"success"`
+}
+
+type SessionSlashRunParams struct {
+	// Arguments passed to the command, as typed after the command name
+	Args *string `json:"args,omitempty"`
+	// Command name, with or without the leading slash (e.g., "clear", "/diff")
+	Command string `json:"command"`
+}
+
+type EmbeddingsCreateResult struct {
+	// Embeddings, one per input, in input order
+	Data []Embedding `json:"data"`
+	// Model that produced the embeddings
+	Model string `json:"model"`
+	// Token usage for the request
+	Usage EmbeddingsCreateResultUsage `json:"usage"`
+}
+
+type Embedding struct {
+	// Embedding vector
+	Embedding []float64 `json:"embedding"`
+	// Index of the input this embedding corresponds to
+	Index float64 `json:"index"`
+}
+
+// Token usage for the request
+type EmbeddingsCreateResultUsage struct {
+	// Number of tokens in the inputs
+	PromptTokens float64 `json:"promptTokens"`
+	// Total number of tokens billed
+	TotalTokens float64 `json:"totalTokens"`
+}
+
+type EmbeddingsCreateParams struct {
+	// Number of dimensions for the output vectors, for models that support it
+	Dimensions *float64 `json:"dimensions,omitempty"`
+	// Texts to embed
+	Input []string `json:"input"`
+	// Embedding model ID (defaults to the CLI's embedding model)
+	Model *string `json:"model,omitempty"`
+}
+
+type SessionContextAddResult struct {
+	// Identifier of the added context item
+	ID string `json:"id"`
+}
+
+type SessionContextAddParams struct {
+	// Structured context payload
+	Data map[string]interface{} `json:"data"`
+	// Kind of context (e.g., "repository")
+	Kind string `json:"kind"`
+	// Short label for the context item
+	Name string `json:"name"`
+}
+
+type SessionSummarizeResult struct {
+	// Number of messages covered by the summary
+	MessagesSummarized float64 `json:"messagesSummarized"`
+	// Summary of the conversation
+	Summary string `json:"summary"`
+}
+
+type SessionSummarizeParams struct {
+	// Event ID of the first message to summarize (default: start of the conversation)
+	FromEventID *string `json:"fromEventId,omitempty"`
+	// Additional instructions for the summary, such as the audience or focus
+	Instructions *string `json:"instructions,omitempty"`
+	// Approximate maximum length of the summary in words
+	MaxWords *float64 `json:"maxWords,omitempty"`
+	// Event ID of the last message to summarize (default: end of the conversation)
+	ToEventID *string `json:"toEventId,omitempty"`
+}
+
+type CapabilitiesGetResult struct {
+	// Feature flags enabled in the CLI, by name
+	Features map[string]bool `json:"features"`
+	// JSON-RPC methods the CLI implements
+	Methods []string `json:"methods"`
+	// Server protocol version number
+	ProtocolVersion float64 `json:"protocolVersion"`
+	// CLI version
+	Version string `json:"version"`
+}
+
+type SessionsExportResult struct {
+	// Exported sessions
+	Sessions []SessionsExportResultSession `json:"sessions"`
+}
+
+type SessionsExportResultSession struct {
+	// Opaque session state, including conversation history, checkpoints, and workspace files
+	Data map[string]interface{} `json:"data"`
+	// Session ID
+	SessionID string `json:"sessionId"`
+}
+
+type SessionsExportParams struct {
+	// IDs of the sessions to export (default: all sessions)
+	SessionIDs []string `json:"sessionIds,omitempty"`
+}
+
+type SessionsImportResult struct {
+	// IDs of the imported sessions
+	SessionIDs []string `json:"sessionIds"`
+}
+
+type SessionsImportParams struct {
+	// Replace sessions that already exist with the same ID instead of failing
+	Overwrite *bool `json:"overwrite,omitempty"`
+	// Sessions to import, as returned by sessions.export
+	Sessions []SessionsImportParamsSession `json:"sessions"`
+}
+
+type SessionsImportParamsSession struct {
+	// Opaque session state, as returned by sessions.export
+	Data map[string]interface{} `json:"data"`
+	// Session ID
+	SessionID string `json:"sessionId"`
+}
+
+type SessionsAbortAllResult struct {
+	// IDs of the sessions whose in-flight work was aborted
+	SessionIDs []string `json:"sessionIds"`
+}
+
+type SessionsAbortAllParams struct {
+	// Reason for aborting, recorded in each aborted session's history
+	Reason *string `json:"reason,omitempty"`
+}
+
+type SessionCheckpointsCreateResult struct {
+	// The created checkpoint
+	Checkpoint SessionCheckpointsCreateResultCheckpoint `json:"checkpoint"`
+}
+
+// The created checkpoint
+type SessionCheckpointsCreateResultCheckpoint struct {
+	// When the checkpoint was created (ISO 8601)
+	CreatedAt string `json:"createdAt"`
+	// Number of events in the conversation history at the checkpoint
+	EventCount float64 `json:"eventCount"`
+	// ID of the last event included in the checkpoint
+	EventID string `json:"eventId"`
+	// Name of the checkpoint
+	Name string `json:"name"`
+}
+
+type SessionCheckpointsCreateParams struct {
+	// Name of the checkpoint. An existing checkpoint with the same name is replaced.
+	Name string `json:"name"`
+}
+
+type SessionCheckpointsRestoreResult struct {
+	// Number of events removed from the conversation history
+	EventsRemoved float64 `json:"eventsRemoved"`
+}
+
+type SessionCheckpointsRestoreParams struct {
+	// Name of the checkpoint to restore
+	Name string `json:"name"`
+}
+
+type SessionCheckpointsListResult struct {
+	// Checkpoints of the session, oldest first
+	Checkpoints []CheckpointElement `json:"checkpoints"`
+}
+
+type CheckpointElement struct {
+	// When the checkpoint was created (ISO 8601)
+	CreatedAt string `json:"createdAt"`
+	// Number of events in the conversation history at the checkpoint
+	EventCount float64 `json:"eventCount"`
+	// ID of the last event included in the checkpoint
+	EventID string `json:"eventId"`
+	// Name of the checkpoint
+	Name string `json:"name"`
+}
+
+type SessionCheckpointsDeleteResult struct {
+}
+
+type SessionCheckpointsDeleteParams struct {
+	// Name of the checkpoint to delete
+	Name string `json:"name"`
+}
+
+type SessionMemoryStoreResult struct {
+	// The stored memory
+	Memory MemoryElement `json:"memory"`
+}
+
+type MemoryElement struct {
+	// Text of the memory
+	Content string `json:"content"`
+	// When the memory was stored (ISO 8601)
+	CreatedAt string `json:"createdAt"`
+	// Unique identifier of the memory
+	ID string `json:"id"`
+	// Who the memory applies to: "user" or "workspace"
+	Scope MemoryScope `json:"scope"`
+	// Labels for filtering memories
+	Tags []string `json:"tags,omitempty"`
+}
+
+type SessionMemoryStoreParams struct {
+	// Text of the memory
+	Content string `json:"content"`
+	// Who the memory applies to (default: "user")
+	Scope *MemoryScope `json:"scope,omitempty"`
+	// Labels for filtering memories
+	Tags []string `json:"tags,omitempty"`
+}
+
+type SessionMemoryListResult struct {
+	// Memories visible to the session, oldest first
+	Memories []MemoryElement `json:"memories"`
+}
+
+type SessionMemoryListParams struct {
+	// Only return memories with this scope (default: all)
+	Scope *MemoryScope `json:"scope,omitempty"`
+}
+
+type SessionMemoryDeleteResult struct {
+}
+
+type SessionMemoryDeleteParams struct {
+	// ID of the memory to delete
+	ID string `json:"id"`
+}
+
+type SessionToolProgressResult struct {
+}
+
+type SessionToolProgressParams struct {
+	// ID of the running tool call
+	ToolCallID string `json:"toolCallId"`
+	// Status of the tool call to show to the agent and the user
+	Message string `json:"message"`
+}
+
+type SessionTitleSetResult struct {
+}
+
+type SessionTitleSetParams struct {
+	// New title of the conversation
+	Title string `json:"title"`
+}
+
+type SessionTitleGenerateResult struct {
+	// Title the model chose for the conversation, now set as the session's title
+	Title string `json:"title"`
+}
+
+type SessionTitleGenerateParams struct {
+	// Additional instructions for the title, such as the language or style
+	Instructions *string `json:"instructions,omitempty"`
+	// Approximate maximum length of the title in words
+	MaxWords *float64 `json:"maxWords,omitempty"`
+}
+
+type SessionHistoryTruncateResult struct {
+	// Number of events removed from the history
+	RemovedEvents float64 `json:"removedEvents"`
+}
+
+type SessionHistoryTruncateParams struct {
+	// ID of the first event to remove; it and every later event are removed
+	EventID string `json:"eventId"`
+}
+
+type SessionReferencesListResult struct {
+	// References the message makes to files, URLs, and code symbols, in order of appearance
+	References []ReferenceElement `json:"references"`
+}
+
+type ReferenceElement struct {
+	// Byte offset just past the end of the reference in the message content
+	End *float64 `json:"end,omitempty"`
+	// Last line of the referenced range, for file references to several lines
+	EndLine *float64 `json:"endLine,omitempty"`
+	// Kind of reference: "file", "url", or "symbol"
+	Kind string `json:"kind"`
+	// Line referenced in the file, starting at 1
+	Line *float64 `json:"line,omitempty"`
+	// Byte offset of the reference in the message content
+	Start *float64 `json:"start,omitempty"`
+	// File path, URL, or symbol name referred to
+	Target string `json:"target"`
+	// The reference as written in the message
+	Text *string `json:"text,omitempty"`
+}
+
+type SessionReferencesListParams struct {
+	// ID of the assistant message whose references to list
+	MessageID string `json:"messageId"`
+}
+
+type SessionFeedbackSubmitResult struct {
+	// Whether the feedback was recorded; false if telemetry is disabled
+	Recorded bool `json:"recorded"`
+}
+
+type SessionFeedbackSubmitParams struct {
+	// Optional free-form comment explaining the rating
+	Comment *string `json:"comment,omitempty"`
+	// ID of the assistant message the feedback is about
+	MessageID string `json:"messageId"`
+	// Rating of the response: "positive" or "negative"
+	Rating Rating `json:"rating"`
+}
+
+type SessionPinsListResult struct {
+	// IDs of the pinned messages, in conversation order
+	MessageIDs []string `json:"messageIds"`
+}
+
+type SessionPinsAddResult struct {
+	// IDs of the pinned messages after pinning, in conversation order
+	MessageIDs []string `json:"messageIds"`
+}
+
+type SessionPinsAddParams struct {
+	// ID of the user or assistant message to keep verbatim through compaction
+	MessageID string `json:"messageId"`
+}
+
+type SessionPinsRemoveResult struct {
+	// IDs of the pinned messages after unpinning, in conversation order
+	MessageIDs []string `json:"messageIds"`
+}
+
+type SessionPinsRemoveParams struct {
+	// ID of the message to unpin
+	MessageID string `json:"messageId"`
+}
+
+// Who a memory applies to: "user" for the signed-in user across workspaces, or
+// "workspace" for the session's workspace only.
+type MemoryScope string
+
+const (
+	MemoryScopeUser      MemoryScope = "user"
+	MemoryScopeWorkspace MemoryScope = "workspace"
+)
+
+// Rating of a response: "positive" (thumbs up) or "negative" (thumbs down).
+type Rating string
+
+const (
+	RatingNegative Rating = "negative"
+	RatingPositive Rating = "positive"
+)
+
+type FilesRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type SlashRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type ContextRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type CheckpointsRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type MemoryRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type ToolRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type TitleRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type HistoryRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type ReferencesRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type FeedbackRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type PinsRpcApi struct {
+	client    *jsonrpc2.Client
+	sessionID string
+}
+
+type EmbeddingsRpcApi struct{ client *jsonrpc2.Client }
+
+func (a *EmbeddingsRpcApi) Create(ctx context.Context, params *EmbeddingsCreateParams) (*EmbeddingsCreateResult, error) {
+	raw, err := a.client.RequestContext(ctx, "embeddings.create", params)
+	if err != nil {
+		return nil, err
+	}
+	var result EmbeddingsCreateResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type CapabilitiesRpcApi struct{ client *jsonrpc2.Client }
+
+func (a *CapabilitiesRpcApi) Get(ctx context.Context) (*CapabilitiesGetResult, error) {
+	raw, err := a.client.RequestContext(ctx, "capabilities.get", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	var result CapabilitiesGetResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type SessionsRpcApi struct{ client *jsonrpc2.Client }
+
+func (a *SessionsRpcApi) Export(ctx context.Context, params *SessionsExportParams) (*SessionsExportResult, error) {
+	raw, err := a.client.RequestContext(ctx, "sessions.export", params)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionsExportResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *SessionsRpcApi) Import(ctx context.Context, params *SessionsImportParams) (*SessionsImportResult, error) {
+	raw, err := a.client.RequestContext(ctx, "sessions.import", params)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionsImportResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *SessionsRpcApi) AbortAll(ctx context.Context, params *SessionsAbortAllParams) (*SessionsAbortAllResult, error) {
+	raw, err := a.client.RequestContext(ctx, "sessions.abortAll", params)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionsAbortAllResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *AgentRpcApi) Register(ctx context.Context, params *SessionAgentRegisterParams) (*SessionAgentRegisterResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["agent"] = params.Agent
+	}
+	raw, err := a.client.RequestContext(ctx, "session.agent.register", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionAgentRegisterResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *AgentRpcApi) Unregister(ctx context.Context, params *SessionAgentUnregisterParams) (*SessionAgentUnregisterResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["name"] = params.Name
+	}
+	raw, err := a.client.RequestContext(ctx, "session.agent.unregister", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionAgentUnregisterResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *AgentRpcApi) Handoff(ctx context.Context, params *SessionAgentHandoffParams) (*SessionAgentHandoffResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["name"] = params.Name
+		req["instructions"] = params.Instructions
+		if params.Note != nil {
+			req["note"] = *params.Note
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.agent.handoff", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionAgentHandoffResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *FilesRpcApi) List(ctx context.Context) (*SessionFilesListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	raw, err := a.client.RequestContext(ctx, "session.files.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionFilesListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *FilesRpcApi) Add(ctx context.Context, params *SessionFilesAddParams) (*SessionFilesAddResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["path"] = params.Path
+		if params.DisplayName != nil {
+			req["displayName"] = *params.DisplayName
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.files.add", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionFilesAddResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *FilesRpcApi) Remove(ctx context.Context, params *SessionFilesRemoveParams) (*SessionFilesRemoveResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["path"] = params.Path
+	}
+	raw, err := a.client.RequestContext(ctx, "session.files.remove", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionFilesRemoveResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *SlashRpcApi) List(ctx context.Context) (*SessionSlashListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	raw, err := a.client.RequestContext(ctx, "session.slash.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionSlashListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *SlashRpcApi) Run(ctx context.Context, params *SessionSlashRunParams) (*SessionSlashRunResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["command"] = params.Command
+		if params.Args != nil {
+			req["args"] = *params.Args
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.slash.run", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionSlashRunResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *ContextRpcApi) Add(ctx context.Context, params *SessionContextAddParams) (*SessionContextAddResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["data"] = params.Data
+		req["kind"] = params.Kind
+		req["name"] = params.Name
+	}
+	raw, err := a.client.RequestContext(ctx, "session.context.add", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionContextAddResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *CheckpointsRpcApi) Create(ctx context.Context, params *SessionCheckpointsCreateParams) (*SessionCheckpointsCreateResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["name"] = params.Name
+	}
+	raw, err := a.client.RequestContext(ctx, "session.checkpoints.create", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionCheckpointsCreateResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *CheckpointsRpcApi) Restore(ctx context.Context, params *SessionCheckpointsRestoreParams) (*SessionCheckpointsRestoreResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["name"] = params.Name
+	}
+	raw, err := a.client.RequestContext(ctx, "session.checkpoints.restore", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionCheckpointsRestoreResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *CheckpointsRpcApi) List(ctx context.Context) (*SessionCheckpointsListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	raw, err := a.client.RequestContext(ctx, "session.checkpoints.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionCheckpointsListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *CheckpointsRpcApi) Delete(ctx context.Context, params *SessionCheckpointsDeleteParams) (*SessionCheckpointsDeleteResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["name"] = params.Name
+	}
+	raw, err := a.client.RequestContext(ctx, "session.checkpoints.delete", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionCheckpointsDeleteResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *MemoryRpcApi) Store(ctx context.Context, params *SessionMemoryStoreParams) (*SessionMemoryStoreResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["content"] = params.Content
+		if params.Scope != nil {
+			req["scope"] = *params.Scope
+		}
+		if params.Tags != nil {
+			req["tags"] = params.Tags
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.memory.store", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionMemoryStoreResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *MemoryRpcApi) List(ctx context.Context, params *SessionMemoryListParams) (*SessionMemoryListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		if params.Scope != nil {
+			req["scope"] = *params.Scope
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.memory.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionMemoryListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *MemoryRpcApi) Delete(ctx context.Context, params *SessionMemoryDeleteParams) (*SessionMemoryDeleteResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["id"] = params.ID
+	}
+	raw, err := a.client.RequestContext(ctx, "session.memory.delete", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionMemoryDeleteResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *ToolRpcApi) Progress(ctx context.Context, params *SessionToolProgressParams) (*SessionToolProgressResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["toolCallId"] = params.ToolCallID
+		req["message"] = params.Message
+	}
+	raw, err := a.client.RequestContext(ctx, "session.tool.progress", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionToolProgressResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *TitleRpcApi) Set(ctx context.Context, params *SessionTitleSetParams) (*SessionTitleSetResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["title"] = params.Title
+	}
+	raw, err := a.client.RequestContext(ctx, "session.title.set", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionTitleSetResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *TitleRpcApi) Generate(ctx context.Context, params *SessionTitleGenerateParams) (*SessionTitleGenerateResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		if params.Instructions != nil {
+			req["instructions"] = *params.Instructions
+		}
+		if params.MaxWords != nil {
+			req["maxWords"] = *params.MaxWords
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.title.generate", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionTitleGenerateResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *HistoryRpcApi) Truncate(ctx context.Context, params *SessionHistoryTruncateParams) (*SessionHistoryTruncateResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["eventId"] = params.EventID
+	}
+	raw, err := a.client.RequestContext(ctx, "session.history.truncate", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionHistoryTruncateResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *ReferencesRpcApi) List(ctx context.Context, params *SessionReferencesListParams) (*SessionReferencesListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["messageId"] = params.MessageID
+	}
+	raw, err := a.client.RequestContext(ctx, "session.references.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionReferencesListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *FeedbackRpcApi) Submit(ctx context.Context, params *SessionFeedbackSubmitParams) (*SessionFeedbackSubmitResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["messageId"] = params.MessageID
+		req["rating"] = params.Rating
+		if params.Comment != nil {
+			req["comment"] = *params.Comment
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.feedback.submit", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionFeedbackSubmitResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *PinsRpcApi) List(ctx context.Context) (*SessionPinsListResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	raw, err := a.client.RequestContext(ctx, "session.pins.list", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionPinsListResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *PinsRpcApi) Add(ctx context.Context, params *SessionPinsAddParams) (*SessionPinsAddResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["messageId"] = params.MessageID
+	}
+	raw, err := a.client.RequestContext(ctx, "session.pins.add", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionPinsAddResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *PinsRpcApi) Remove(ctx context.Context, params *SessionPinsRemoveParams) (*SessionPinsRemoveResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		req["messageId"] = params.MessageID
+	}
+	raw, err := a.client.RequestContext(ctx, "session.pins.remove", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionPinsRemoveResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *SessionRpc) Summarize(ctx context.Context, params *SessionSummarizeParams) (*SessionSummarizeResult, error) {
+	req := map[string]interface{}{"sessionId": a.sessionID}
+	if params != nil {
+		if params.FromEventID != nil {
+			req["fromEventId"] = *params.FromEventID
+		}
+		if params.Instructions != nil {
+			req["instructions"] = *params.Instructions
+		}
+		if params.MaxWords != nil {
+			req["maxWords"] = *params.MaxWords
+		}
+		if params.ToEventID != nil {
+			req["toEventId"] = *params.ToEventID
+		}
+	}
+	raw, err := a.client.RequestContext(ctx, "session.summarize", req)
+	if err != nil {
+		return nil, err
+	}
+	var result SessionSummarizeResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -276,7 +276,7 @@ const (
 type ModelsRpcApi struct{ client *jsonrpc2.Client }
 
 func (a *ModelsRpcApi) List(ctx context.Context) (*ModelsListResult, error) {
-	raw, err := a.client.Request("models.list", map[string]interface{}{})
+	raw, err := a.client.RequestContext(ctx, "models.list", map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -290,7 +290,7 @@ func (a *ModelsRpcApi) List(ctx context.Context) (*ModelsListResult, error) {
 type ToolsRpcApi struct{ client *jsonrpc2.Client }
 
 func (a *ToolsRpcApi) List(ctx context.Context, params *ToolsListParams) (*ToolsListResult, error) {
-	raw, err := a.client.Request("tools.list", params)
+	raw, err := a.client.RequestContext(ctx, "tools.list", params)
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +304,7 @@ func (a *ToolsRpcApi) List(ctx context.Context, params *ToolsListParams) (*Tools
 type AccountRpcApi struct{ client *jsonrpc2.Client }
 
 func (a *AccountRpcApi) GetQuota(ctx context.Context) (*AccountGetQuotaResult, error) {
-	raw, err := a.client.Request("account.getQuota", map[string]interface{}{})
+	raw, err := a.client.RequestContext(ctx, "account.getQuota", map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -317,14 +317,15 @@ func (a *AccountRpcApi) GetQuota(ctx context.Context) (*AccountGetQuotaResult, e
 
 // ServerRpc provides typed server-scoped RPC methods.
 type ServerRpc struct {
-	client  *jsonrpc2.Client
+	client *jsonrpc2.Client
+	*ServerRpcExtensions
 	Models  *ModelsRpcApi
 	Tools   *ToolsRpcApi
 	Account *AccountRpcApi
 }
 
 func (a *ServerRpc) Ping(ctx context.Context, params *PingParams) (*PingResult, error) {
-	raw, err := a.client.Request("ping", params)
+	raw, err := a.client.RequestContext(ctx, "ping", params)
 	if err != nil {
 		return nil, err
 	}
@@ -337,9 +338,10 @@ func (a *ServerRpc) Ping(ctx context.Context, params *PingParams) (*PingResult,
 
 func NewServerRpc(client *jsonrpc2.Client) *ServerRpc {
 	return &ServerRpc{client: client,
-		Models:  &ModelsRpcApi{client: client},
-		Tools:   &ToolsRpcApi{client: client},
-		Account: &AccountRpcApi{client: client},
+		ServerRpcExtensions: newServerRpcExtensions(client),
+		Models:              &ModelsRpcApi{client: client},
+		Tools:               &ToolsRpcApi{client: client},
+		Account:             &AccountRpcApi{client: client},
 	}
 }
 
@@ -350,7 +352,7 @@ type ModelRpcApi struct {
 
 func (a *ModelRpcApi) GetCurrent(ctx context.Context) (*SessionModelGetCurrentResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.model.getCurrent", req)
+	raw, err := a.client.RequestContext(ctx, "session.model.getCurrent", req)
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +368,7 @@ func (a *ModelRpcApi) SwitchTo(ctx context.Context, params *SessionModelSwitchTo
 	if params != nil {
 		req["modelId"] = params.ModelID
 	}
-	raw, err := a.client.Request("session.model.switchTo", req)
+	raw, err := a.client.RequestContext(ctx, "session.model.switchTo", req)
 	if err != nil {
 		return nil, err
 	}
@@ -384,7 +386,7 @@ type ModeRpcApi struct {
 
 func (a *ModeRpcApi) Get(ctx context.Context) (*SessionModeGetResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.mode.get", req)
+	raw, err := a.client.RequestContext(ctx, "session.mode.get", req)
 	if err != nil {
 		return nil, err
 	}
@@ -400,7 +402,7 @@ func (a *ModeRpcApi) Set(ctx context.Context, params *SessionModeSetParams) (*Se
 	if params != nil {
 		req["mode"] = params.Mode
 	}
-	raw, err := a.client.Request("session.mode.set", req)
+	raw, err := a.client.RequestContext(ctx, "session.mode.set", req)
 	if err != nil {
 		return nil, err
 	}
@@ -418,7 +420,7 @@ type PlanRpcApi struct {
 
 func (a *PlanRpcApi) Read(ctx context.Context) (*SessionPlanReadResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.plan.read", req)
+	raw, err := a.client.RequestContext(ctx, "session.plan.read", req)
 	if err != nil {
 		return nil, err
 	}
@@ -434,7 +436,7 @@ func (a *PlanRpcApi) Update(ctx context.Context, params *SessionPlanUpdateParams
 	if params != nil {
 		req["content"] = params.Content
 	}
-	raw, err := a.client.Request("session.plan.update", req)
+	raw, err := a.client.RequestContext(ctx, "session.plan.update", req)
 	if err != nil {
 		return nil, err
 	}
@@ -447,7 +449,7 @@ func (a *PlanRpcApi) Update(ctx context.Context, params *SessionPlanUpdateParams
 
 func (a *PlanRpcApi) Delete(ctx context.Context) (*SessionPlanDeleteResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.plan.delete", req)
+	raw, err := a.client.RequestContext(ctx, "session.plan.delete", req)
 	if err != nil {
 		return nil, err
 	}
@@ -465,7 +467,7 @@ type WorkspaceRpcApi struct {
 
 func (a *WorkspaceRpcApi) ListFiles(ctx context.Context) (*SessionWorkspaceListFilesResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.workspace.listFiles", req)
+	raw, err := a.client.RequestContext(ctx, "session.workspace.listFiles", req)
 	if err != nil {
 		return nil, err
 	}
@@ -481,7 +483,7 @@ func (a *WorkspaceRpcApi) ReadFile(ctx context.Context, params *SessionWorkspace
 	if params != nil {
 		req["path"] = params.Path
 	}
-	raw, err := a.client.Request("session.workspace.readFile", req)
+	raw, err := a.client.RequestContext(ctx, "session.workspace.readFile", req)
 	if err != nil {
 		return nil, err
 	}
@@ -498,7 +500,7 @@ func (a *WorkspaceRpcApi) CreateFile(ctx context.Context, params *SessionWorkspa
 		req["path"] = params.Path
 		req["content"] = params.Content
 	}
-	raw, err := a.client.Request("session.workspace.createFile", req)
+	raw, err := a.client.RequestContext(ctx, "session.workspace.createFile", req)
 	if err != nil {
 		return nil, err
 	}
@@ -521,7 +523,7 @@ func (a *FleetRpcApi) Start(ctx context.Context, params *SessionFleetStartParams
 			req["prompt"] = *params.Prompt
 		}
 	}
-	raw, err := a.client.Request("session.fleet.start", req)
+	raw, err := a.client.RequestContext(ctx, "session.fleet.start", req)
 	if err != nil {
 		return nil, err
 	}
@@ -539,7 +541,7 @@ type AgentRpcApi struct {
 
 func (a *AgentRpcApi) List(ctx context.Context) (*SessionAgentListResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.agent.list", req)
+	raw, err := a.client.RequestContext(ctx, "session.agent.list", req)
 	if err != nil {
 		return nil, err
 	}
@@ -552,7 +554,7 @@ func (a *AgentRpcApi) List(ctx context.Context) (*SessionAgentListResult, error)
 
 func (a *AgentRpcApi) GetCurrent(ctx context.Context) (*SessionAgentGetCurrentResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.agent.getCurrent", req)
+	raw, err := a.client.RequestContext(ctx, "session.agent.getCurrent", req)
 	if err != nil {
 		return nil, err
 	}
@@ -568,7 +570,7 @@ func (a *AgentRpcApi) Select(ctx context.Context, params *SessionAgentSelectPara
 	if params != nil {
 		req["name"] = params.Name
 	}
-	raw, err := a.client.Request("session.agent.select", req)
+	raw, err := a.client.RequestContext(ctx, "session.agent.select", req)
 	if err != nil {
 		return nil, err
 	}
@@ -581,7 +583,7 @@ func (a *AgentRpcApi) Select(ctx context.Context, params *SessionAgentSelectPara
 
 func (a *AgentRpcApi) Deselect(ctx context.Context) (*SessionAgentDeselectResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.agent.deselect", req)
+	raw, err := a.client.RequestContext(ctx, "session.agent.deselect", req)
 	if err != nil {
 		return nil, err
 	}
@@ -599,7 +601,7 @@ type CompactionRpcApi struct {
 
 func (a *CompactionRpcApi) Compact(ctx context.Context) (*SessionCompactionCompactResult, error) {
 	req := map[string]interface{}{"sessionId": a.sessionID}
-	raw, err := a.client.Request("session.compaction.compact", req)
+	raw, err := a.client.RequestContext(ctx, "session.compaction.compact", req)
 	if err != nil {
 		return nil, err
 	}
@@ -612,8 +614,9 @@ func (a *CompactionRpcApi) Compact(ctx context.Context) (*SessionCompactionCompa
 
 // SessionRpc provides typed session-scoped RPC methods.
 type SessionRpc struct {
-	client     *jsonrpc2.Client
-	sessionID  string
+	client    *jsonrpc2.Client
+	sessionID string
+	*SessionRpcExtensions
 	Model      *ModelRpcApi
 	Mode       *ModeRpcApi
 	Plan       *PlanRpcApi
@@ -625,12 +628,13 @@ type SessionRpc struct {
 
 func NewSessionRpc(client *jsonrpc2.Client, sessionID string) *SessionRpc {
 	return &SessionRpc{client: client, sessionID: sessionID,
-		Model:      &ModelRpcApi{client: client, sessionID: sessionID},
-		Mode:       &ModeRpcApi{client: client, sessionID: sessionID},
-		Plan:       &PlanRpcApi{client: client, sessionID: sessionID},
-		Workspace:  &WorkspaceRpcApi{client: client, sessionID: sessionID},
-		Fleet:      &FleetRpcApi{client: client, sessionID: sessionID},
-		Agent:      &AgentRpcApi{client: client, sessionID: sessionID},
-		Compaction: &CompactionRpcApi{client: client, sessionID: sessionID},
+		SessionRpcExtensions: newSessionRpcExtensions(client, sessionID),
+		Model:                &ModelRpcApi{client: client, sessionID: sessionID},
+		Mode:                 &ModeRpcApi{client: client, sessionID: sessionID},
+		Plan:                 &PlanRpcApi{client: client, sessionID: sessionID},
+		Workspace:            &WorkspaceRpcApi{client: client, sessionID: sessionID},
+		Fleet:                &FleetRpcApi{client: client, sessionID: sessionID},
+		Agent:                &AgentRpcApi{client: client, sessionID: sessionID},
+		Compaction:           &CompactionRpcApi{client: client, sessionID: sessionID},
 	}
 }
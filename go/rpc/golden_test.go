@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/internal/golden"
+)
+
+// TestGolden calls every typed binding with fully populated params against a
+// stub server that answers with a fully populated result, and compares the
+// request and response payloads with the golden files in testdata/golden.
+// After an intended wire-format change, record them again with:
+//
+//	COPILOT_UPDATE_GOLDEN=1 go test ./rpc -run TestGolden
+func TestGolden(t *testing.T) {
+	var next reflect.Type // result type the stub server answers with
+	server := golden.NewServer(func(string, json.RawMessage) any {
+		return golden.Sample(next).Interface()
+	})
+	t.Cleanup(server.Close)
+
+	seen := map[string]string{}
+	check := func(t *testing.T, name string, method reflect.Value) {
+		methodType := method.Type()
+		if !isBinding(methodType) {
+			return
+		}
+		t.Run(name, func(t *testing.T) {
+			next = methodType.Out(0).Elem()
+			args := []reflect.Value{reflect.ValueOf(context.Background())}
+			if methodType.NumIn() == 2 {
+				args = append(args, golden.Sample(methodType.In(1)))
+			}
+			out := method.Call(args)
+			if err, _ := out[1].Interface().(error); err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			exchange, ok := server.Last()
+			if !ok {
+				t.Fatal("Expected the binding to send a request")
+			}
+			if other, ok := seen[exchange.Method]; ok {
+				t.Fatalf("%s and %s both call %s", other, name, exchange.Method)
+			}
+			seen[exchange.Method] = name
+
+			// Decoding the response and encoding it again must be lossless,
+			// or a field of the result type is not round-tripped.
+			decoded, _ := golden.Canonical(out[0].Interface())
+			sent, _ := golden.Canonical(exchange.Response)
+			if string(decoded) != string(sent) {
+				t.Errorf("Result does not round-trip:\nsent:\n%s\ndecoded:\n%s", sent, decoded)
+			}
+			golden.Check(t, filepath.Join("testdata", "golden", exchange.Method+".json"), exchange)
+		})
+	}
+
+	apis := []struct {
+		name string
+		api  reflect.Value
+	}{
+		{"Server", reflect.ValueOf(NewServerRpc(server.Client))},
+		{"Session", reflect.ValueOf(NewSessionRpc(server.Client, "session-1"))},
+	}
+	for _, api := range apis {
+		checkMethods(api.name, api.api, check, t)
+		// Visit the APIs promoted from the hand-written extensions as well
+		for _, field := range reflect.VisibleFields(api.api.Elem().Type()) {
+			if field.IsExported() && !field.Anonymous {
+				checkMethods(api.name+"."+field.Name, api.api.Elem().FieldByIndex(field.Index), check, t)
+			}
+		}
+	}
+}
+
+func checkMethods(prefix string, api reflect.Value, check func(*testing.T, string, reflect.Value), t *testing.T) {
+	for i := range api.NumMethod() {
+		check(t, prefix+"."+api.Type().Method(i).Name, api.Method(i))
+	}
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// isBinding reports whether a method has the shape of a typed binding:
+// func(ctx[, *Params]) (*Result, error).
+func isBinding(method reflect.Type) bool {
+	if method.NumIn() < 1 || method.NumIn() > 2 || method.In(0) != contextType {
+		return false
+	}
+	if method.NumIn() == 2 && method.In(1).Kind() != reflect.Pointer {
+		return false
+	}
+	return method.NumOut() == 2 && method.Out(0).Kind() == reflect.Pointer && method.Out(1) == errorType
+}
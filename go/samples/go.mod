@@ -4,6 +4,11 @@ go 1.24
 
 require github.com/github/copilot-sdk/go v0.0.0
 
-require github.com/google/jsonschema-go v0.4.2 // indirect
+require (
+	github.com/google/jsonschema-go v0.4.2 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
 
 replace github.com/github/copilot-sdk/go => ../
@@ -0,0 +1,358 @@
+package copilot
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// defaultSearchLimit is the default of SearchOptions.Limit.
+const defaultSearchLimit = 20
+
+// maxEmbeddedChars caps the length of each message embedded for semantic search.
+const maxEmbeddedChars = 8000
+
+// snippetChars is the approximate length of SearchHit.Snippet.
+const snippetChars = 160
+
+// SearchOptions configures [Session.Search] and [Client.SearchSessions].
+type SearchOptions struct {
+	// Limit caps the number of hits returned by Session.Search, and the number
+	// of sessions and the hits per session returned by Client.SearchSessions.
+	// Default: 20.
+	Limit int
+	// Semantic ranks messages by meaning instead of by keyword, using
+	// embeddings from the CLI (see rpc.EmbeddingsRpcApi), so that "auth bug"
+	// finds a conversation about "login failures". Every searched message is
+	// embedded, which costs tokens and time on long histories.
+	Semantic bool
+	// EmbeddingModel is the embedding model for Semantic search. Default: the
+	// CLI's embedding model.
+	EmbeddingModel string
+	// MinScore drops Semantic hits whose cosine similarity to the query is
+	// below it. Default: 0, which keeps the best Limit hits.
+	MinScore float64
+	// Filter selects the sessions searched by Client.SearchSessions. Default:
+	// all sessions.
+	Filter *SessionListFilter
+}
+
+// SearchHit is a message that matched a search.
+type SearchHit struct {
+	// SessionID is the session the message belongs to.
+	SessionID string
+	// EventID is the ID of the user.message or assistant.message event.
+	EventID string
+	// Type is [UserMessage] or [AssistantMessage].
+	Type SessionEventType
+	// Timestamp is when the message was sent.
+	Timestamp time.Time
+	// Content is the full content of the message.
+	Content string
+	// Snippet is the part of Content around the first matching keyword, for
+	// showing in a list of results.
+	Snippet string
+	// Score ranks the hit: the number of keyword occurrences, or the cosine
+	// similarity to the query for Semantic search.
+	Score float64
+}
+
+// SessionSearchResult is a session that matched [Client.SearchSessions].
+type SessionSearchResult struct {
+	// Session describes the session, as returned by [Client.ListSessions].
+	Session SessionMetadata
+	// Hits are the session's matching messages, best first.
+	Hits []SearchHit
+	// Score is the score of the best hit.
+	Score float64
+}
+
+// Search finds the messages of the conversation that match query, best first.
+// By default a message matches when it contains every word of query,
+// ignoring case; set SearchOptions.Semantic to rank messages by meaning.
+// User and assistant messages are searched, not tool output.
+//
+// Example:
+//
+//	hits, err := session.Search(ctx, "database migration", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, hit := range hits {
+//	    fmt.Printf("%s %s: %s\n", hit.Timestamp.Format(time.Kitchen), hit.Type, hit.Snippet)
+//	}
+func (s *Session) Search(ctx context.Context, query string, options *SearchOptions) ([]SearchHit, error) {
+	searcher, err := newSearcher(ctx, rpc.NewServerRpc(s.client), query, options)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.GetMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return searcher.search(ctx, s.SessionID, events)
+}
+
+// SearchSessions finds the sessions with messages matching query, such as
+// for a "find the conversation where we discussed X" feature, best match
+// first. Matching works as in [Session.Search]. The history of each session
+// selected by SearchOptions.Filter is read from the CLI; sessions whose
+// history cannot be read are logged and skipped.
+//
+// Example:
+//
+//	results, err := client.SearchSessions(ctx, "rate limiter", &copilot.SearchOptions{Limit: 5})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, result := range results {
+//	    fmt.Printf("%s: %s\n", result.Session.SessionID, result.Hits[0].Snippet)
+//	}
+func (c *Client) SearchSessions(ctx context.Context, query string, options *SearchOptions) ([]SessionSearchResult, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	searcher, err := newSearcher(ctx, c.RPC, query, options)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := c.ListSessions(ctx, searcher.options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SessionSearchResult
+	for _, metadata := range sessions {
+		events, err := c.sessionMessages(ctx, metadata.SessionID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.options.Logger.Warn("skipping session in search", "sessionId", metadata.SessionID, "error", err)
+			continue
+		}
+		hits, err := searcher.search(ctx, metadata.SessionID, events)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) > 0 {
+			results = append(results, SessionSearchResult{Session: metadata, Hits: hits, Score: hits[0].Score})
+		}
+	}
+	slices.SortStableFunc(results, func(a, b SessionSearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if len(results) > searcher.options.Limit {
+		results = results[:searcher.options.Limit]
+	}
+	return results, nil
+}
+
+// sessionMessages returns the history of a session, through the Session when
+// it is open in this client so that its output filters apply.
+func (c *Client) sessionMessages(ctx context.Context, sessionID string) ([]SessionEvent, error) {
+	c.sessionsMux.Lock()
+	session, ok := c.sessions[sessionID]
+	c.sessionsMux.Unlock()
+	if ok {
+		return session.GetMessages(ctx)
+	}
+
+	result, err := c.client.RequestContext(ctx, "session.getMessages", sessionGetMessagesRequest{SessionID: sessionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	var response sessionGetMessagesResponse
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal get messages response: %w", err)
+	}
+	return response.Events, nil
+}
+
+// searcher matches messages against one query.
+type searcher struct {
+	options SearchOptions
+	terms   []string  // lowercase query words, for keyword search
+	vector  []float64 // query embedding, for semantic search
+	server  *rpc.ServerRpc
+}
+
+func newSearcher(ctx context.Context, server *rpc.ServerRpc, query string, options *SearchOptions) (*searcher, error) {
+	s := &searcher{server: server}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.Limit <= 0 {
+		s.options.Limit = defaultSearchLimit
+	}
+	s.terms = strings.Fields(strings.ToLower(query))
+	if len(s.terms) == 0 {
+		return nil, fmt.Errorf("search query is required")
+	}
+	if s.options.Semantic {
+		vectors, err := s.embed(ctx, []string{query})
+		if err != nil {
+			return nil, err
+		}
+		s.vector = vectors[0]
+	}
+	return s, nil
+}
+
+// search returns the best hits among the messages in events.
+func (s *searcher) search(ctx context.Context, sessionID string, events []SessionEvent) ([]SearchHit, error) {
+	var hits []SearchHit
+	for _, event := range events {
+		if event.Type != UserMessage && event.Type != AssistantMessage {
+			continue
+		}
+		if event.Data.Content == nil || strings.TrimSpace(*event.Data.Content) == "" {
+			continue
+		}
+		hits = append(hits, SearchHit{
+			SessionID: sessionID,
+			EventID:   event.ID,
+			Type:      event.Type,
+			Timestamp: event.Timestamp,
+			Content:   *event.Data.Content,
+		})
+	}
+
+	if s.options.Semantic {
+		if err := s.scoreSemantic(ctx, hits); err != nil {
+			return nil, err
+		}
+	} else {
+		s.scoreKeywords(hits)
+	}
+	hits = slices.DeleteFunc(hits, func(hit SearchHit) bool {
+		return hit.Score <= 0 || hit.Score < s.options.MinScore
+	})
+
+	for i := range hits {
+		hits[i].Snippet = s.snippet(hits[i].Content)
+	}
+	slices.SortStableFunc(hits, func(a, b SearchHit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	if len(hits) > s.options.Limit {
+		hits = hits[:s.options.Limit]
+	}
+	return hits, nil
+}
+
+// scoreKeywords scores each hit by the occurrences of the query terms, or
+// zero unless it contains all of them.
+func (s *searcher) scoreKeywords(hits []SearchHit) {
+	for i := range hits {
+		content := strings.ToLower(hits[i].Content)
+		score := 0
+		for _, term := range s.terms {
+			n := strings.Count(content, term)
+			if n == 0 {
+				score = 0
+				break
+			}
+			score += n
+		}
+		hits[i].Score = float64(score)
+	}
+}
+
+// scoreSemantic scores each hit by the cosine similarity of its embedding to
+// the query's.
+func (s *searcher) scoreSemantic(ctx context.Context, hits []SearchHit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+	texts := make([]string, len(hits))
+	for i, hit := range hits {
+		texts[i] = truncateRunes(hit.Content, maxEmbeddedChars)
+	}
+	vectors, err := s.embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	for i := range hits {
+		hits[i].Score = cosineSimilarity(s.vector, vectors[i])
+	}
+	return nil
+}
+
+// embed returns the embeddings of texts, in order.
+func (s *searcher) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	params := &rpc.EmbeddingsCreateParams{Input: texts}
+	if s.options.EmbeddingModel != "" {
+		params.Model = &s.options.EmbeddingModel
+	}
+	result, err := s.server.Embeddings.Create(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	vectors := make([][]float64, len(texts))
+	for _, embedding := range result.Data {
+		if i := int(embedding.Index); i >= 0 && i < len(vectors) {
+			vectors[i] = embedding.Embedding
+		}
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("failed to create embeddings: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// snippet returns the part of content around the first query term, on one line.
+func (s *searcher) snippet(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	lower := strings.ToLower(content)
+	start := -1
+	for _, term := range s.terms {
+		if i := strings.Index(lower, term); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	if start <= snippetChars/4 || len(lower) != len(content) {
+		// Lowercasing changed byte offsets, or the match is near the start
+		return truncateRunes(content, snippetChars)
+	}
+	start -= snippetChars / 4
+	for start < len(content) && !utf8.RuneStart(content[start]) {
+		start++
+	}
+	return "…" + truncateRunes(content[start:], snippetChars)
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b.
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
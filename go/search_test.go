@@ -0,0 +1,151 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func searchEvents() []SessionEvent {
+	str := func(s string) *string { return &s }
+	at := func(minute int) time.Time { return time.Date(2026, 1, 1, 12, minute, 0, 0, time.UTC) }
+	return []SessionEvent{
+		{ID: "e1", Timestamp: at(1), Type: UserMessage, Data: Data{Content: str("The database migration fails on Postgres")}},
+		{ID: "e2", Timestamp: at(2), Type: ToolExecutionComplete, Data: Data{Content: str("database migration log")}},
+		{ID: "e3", Timestamp: at(3), Type: AssistantMessage, Data: Data{Content: str("The migration drops a column the database still indexes. Rerun the migration after dropping the index.")}},
+		{ID: "e4", Timestamp: at(4), Type: UserMessage, Data: Data{Content: str("Thanks, the migration works now")}},
+	}
+}
+
+func TestSession_Search(t *testing.T) {
+	t.Run("ranks messages containing every keyword", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": searchEvents()})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		hits, err := session.Search(t.Context(), "Database MIGRATION", nil)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(hits) != 2 || hits[0].EventID != "e3" || hits[1].EventID != "e1" {
+			t.Fatalf("Expected the assistant and user messages mentioning both words, got %+v", hits)
+		}
+		if hits[0].Score != 3 || hits[0].Type != AssistantMessage || hits[0].SessionID != "s1" {
+			t.Errorf("Unexpected best hit: %+v", hits[0])
+		}
+
+		if _, err := session.Search(t.Context(), "  ", nil); err == nil {
+			t.Error("Expected an error for an empty query")
+		}
+	})
+
+	t.Run("ranks messages by embedding similarity", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("embeddings.create", map[string]any{"data": []map[string]any{{"embedding": []float64{1, 0}, "index": 0}}})
+		log.call("session.getMessages", map[string]any{"events": searchEvents()})
+		log.call("embeddings.create", map[string]any{"data": []map[string]any{
+			{"embedding": []float64{0, 1}, "index": 0},
+			{"embedding": []float64{1, 1}, "index": 1},
+			{"embedding": []float64{1, 0.1}, "index": 2},
+		}})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		hits, err := session.Search(t.Context(), "schema change", &SearchOptions{Semantic: true, EmbeddingModel: "small", MinScore: 0.5})
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(hits) != 2 || hits[0].EventID != "e4" || hits[1].EventID != "e3" {
+			t.Fatalf("Expected the two similar messages, best first, got %+v", hits)
+		}
+
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to read recording: %v", err)
+		}
+		var inputs []int
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+				Params struct {
+					Input []string `json:"input"`
+					Model string   `json:"model"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "embeddings.create" {
+				if message.Params.Model != "small" {
+					t.Errorf("Expected the embedding model to be sent, got %+v", message.Params)
+				}
+				inputs = append(inputs, len(message.Params.Input))
+			}
+		}
+		if len(inputs) != 2 || inputs[0] != 1 || inputs[1] != 3 {
+			t.Errorf("Expected the query and then the 3 messages to be embedded, got %v", inputs)
+		}
+	})
+}
+
+func TestClient_SearchSessions(t *testing.T) {
+	str := func(s string) *string { return &s }
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.list", map[string]any{"sessions": []map[string]any{
+		{"sessionId": "s1", "startTime": "2026-01-01T00:00:00Z", "modifiedTime": "2026-01-01T00:00:00Z"},
+		{"sessionId": "s2", "startTime": "2026-01-02T00:00:00Z", "modifiedTime": "2026-01-02T00:00:00Z"},
+		{"sessionId": "s3", "startTime": "2026-01-03T00:00:00Z", "modifiedTime": "2026-01-03T00:00:00Z"},
+		{"sessionId": "s4", "startTime": "2026-01-04T00:00:00Z", "modifiedTime": "2026-01-04T00:00:00Z"},
+	}})
+	log.call("session.getMessages", map[string]any{"events": []SessionEvent{
+		{ID: "a1", Type: UserMessage, Data: Data{Content: str("Add a rate limiter")}},
+	}})
+	log.nextID++
+	id := strconv.Itoa(log.nextID)
+	log.write("send", map[string]any{"jsonrpc": "2.0", "id": id, "method": "session.getMessages", "params": map[string]any{}})
+	log.write("recv", map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "session not found"}})
+	log.call("session.getMessages", map[string]any{"events": []SessionEvent{
+		{ID: "c1", Type: UserMessage, Data: Data{Content: str("Unrelated")}},
+	}})
+	log.call("session.getMessages", map[string]any{"events": searchEvents()})
+
+	client := newPlaybackClientForTest(t, log, nil)
+	results, err := client.SearchSessions(t.Context(), "migration", &SearchOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].Session.SessionID != "s4" {
+		t.Fatalf("Expected only the best matching session, got %+v", results)
+	}
+	if len(results[0].Hits) != 1 || results[0].Hits[0].EventID != "e3" || results[0].Score != 2 {
+		t.Errorf("Expected the best hit of the session, got %+v", results[0].Hits)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	s := &searcher{terms: []string{"needle"}}
+	content := strings.Repeat("hay ", 100) + "needle\n\n" + strings.Repeat("hay ", 100)
+	snippet := s.snippet(content)
+	if !strings.HasPrefix(snippet, "…") || !strings.HasSuffix(snippet, "…") || !strings.Contains(snippet, "needle hay") {
+		t.Errorf("Expected a one-line excerpt around the match, got %q", snippet)
+	}
+	if got := s.snippet("a needle"); got != "a needle" {
+		t.Errorf("Expected short content unchanged, got %q", got)
+	}
+}
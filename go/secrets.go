@@ -0,0 +1,235 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SecretsProvider resolves secret references in SessionConfig.Env and in the
+// configurations of MCP servers when a session is created or resumed, so that
+// credentials stay out of static configuration. Set it with
+// ClientOptions.SecretsProvider. See [EnvSecretsProvider] and, for Vault and
+// AWS Secrets Manager, the secretstores package. Implementations must be safe
+// for concurrent use.
+//
+// A reference is written "${secret:<ref>}" and may be part of a longer value,
+// e.g. "Bearer ${secret:github/token}". The format of ref depends on the
+// provider.
+type SecretsProvider interface {
+	// ResolveSecret returns the value of the secret ref refers to, or an
+	// error wrapping [ErrSecretNotFound] if there is none.
+	ResolveSecret(ctx context.Context, ref string) (string, error)
+}
+
+// ErrSecretNotFound is wrapped by the errors of [SecretsProvider]
+// implementations for references to secrets that do not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// secretRefPattern matches a secret reference, capturing the reference.
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+// minRedactedSecret is the length below which resolved secrets are not
+// redacted from logs, since they would match ordinary text.
+const minRedactedSecret = 4
+
+// EnvSecretsProvider resolves references from the environment of the SDK's
+// process, e.g. in CI where secrets are injected as variables: "${secret:NAME}"
+// is the value of the variable Prefix+NAME.
+//
+// Example:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    SecretsProvider: copilot.EnvSecretsProvider{Prefix: "MYAPP_"},
+//	})
+type EnvSecretsProvider struct {
+	// Prefix is prepended to references to form variable names.
+	Prefix string
+}
+
+// ResolveSecret returns the value of the environment variable Prefix+ref.
+func (p EnvSecretsProvider) ResolveSecret(_ context.Context, ref string) (string, error) {
+	name := p.Prefix + ref
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s: %w", name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+// secretResolver resolves the secret references of session configurations
+// with ClientOptions.SecretsProvider, and remembers the values it resolved so
+// that they can be redacted.
+type secretResolver struct {
+	provider SecretsProvider
+
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+func newSecretResolver(provider SecretsProvider) *secretResolver {
+	return &secretResolver{provider: provider, values: make(map[string]struct{})}
+}
+
+// sessionSecrets holds the parts of a session configuration that may hold
+// secret references.
+type sessionSecrets struct {
+	env        map[string]string
+	mcpServers map[string]MCPServerConfig
+	agents     []CustomAgentConfig
+}
+
+// resolve returns copies of env, mcpServers, and the MCP servers of agents
+// with their secret references replaced. Without a provider, config is
+// returned unchanged, as it was before SecretsProvider existed.
+func (r *secretResolver) resolve(ctx context.Context, config sessionSecrets) (sessionSecrets, error) {
+	var refs []string
+	collect := func(s string) string {
+		for _, match := range secretRefPattern.FindAllStringSubmatch(s, -1) {
+			if !slices.Contains(refs, match[1]) {
+				refs = append(refs, match[1])
+			}
+		}
+		return s
+	}
+	if r == nil {
+		return config, nil
+	}
+	config.walk(collect)
+	if len(refs) == 0 {
+		return config, nil
+	}
+
+	values := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		value, err := r.provider.ResolveSecret(ctx, ref)
+		if err != nil {
+			return config, fmt.Errorf("failed to resolve secret %q: %w", ref, err)
+		}
+		values[ref] = value
+		r.remember(value)
+	}
+	return config.walk(func(s string) string {
+		return secretRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+			return values[secretRefPattern.FindStringSubmatch(match)[1]]
+		})
+	}), nil
+}
+
+// walk returns a copy of c with f applied to every string value, leaving the
+// caller's maps and slices unchanged.
+func (c sessionSecrets) walk(f func(string) string) sessionSecrets {
+	result := sessionSecrets{env: walkStrings(c.env, f).(map[string]string)}
+	if c.mcpServers != nil {
+		result.mcpServers = walkMCPServers(c.mcpServers, f)
+	}
+	if c.agents != nil {
+		result.agents = slices.Clone(c.agents)
+		for i, agent := range result.agents {
+			if agent.MCPServers != nil {
+				result.agents[i].MCPServers = walkMCPServers(agent.MCPServers, f)
+			}
+		}
+	}
+	return result
+}
+
+func walkMCPServers(servers map[string]MCPServerConfig, f func(string) string) map[string]MCPServerConfig {
+	result := make(map[string]MCPServerConfig, len(servers))
+	for name, server := range servers {
+		result[name] = MCPServerConfig(walkStrings(map[string]any(server), f).(map[string]any))
+	}
+	return result
+}
+
+// walkStrings returns a copy of value, a configuration decoded from JSON or
+// built from maps and slices, with f applied to every string.
+func walkStrings(value any, f func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return f(v)
+	case []string:
+		if v == nil {
+			return v
+		}
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = f(item)
+		}
+		return result
+	case map[string]string:
+		if v == nil {
+			return v
+		}
+		result := make(map[string]string, len(v))
+		for key, item := range v {
+			result[key] = f(item)
+		}
+		return result
+	case []any:
+		if v == nil {
+			return v
+		}
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = walkStrings(item, f)
+		}
+		return result
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = walkStrings(item, f)
+		}
+		return result
+	}
+	return value
+}
+
+func (r *secretResolver) remember(value string) {
+	if len(value) < minRedactedSecret {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[value] = struct{}{}
+}
+
+// find returns the byte ranges of s holding resolved secrets, for the
+// redaction rule of the client's Redactor.
+func (r *secretResolver) find(s string) [][2]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ranges [][2]int
+	for value := range r.values {
+		for offset := 0; ; {
+			i := strings.Index(s[offset:], value)
+			if i < 0 {
+				break
+			}
+			ranges = append(ranges, [2]int{offset + i, offset + i + len(value)})
+			offset += i + len(value)
+		}
+	}
+	// Keep the longest of overlapping ranges, in order
+	slices.SortFunc(ranges, func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return b[1] - a[1]
+	})
+	var result [][2]int
+	for _, span := range ranges {
+		if len(result) == 0 || span[0] >= result[len(result)-1][1] {
+			result = append(result, span)
+		}
+	}
+	return result
+}
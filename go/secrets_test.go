@@ -0,0 +1,111 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/github/copilot-sdk/go/redact"
+)
+
+func TestSecretResolver(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "hunter2-long")
+	t.Setenv("TEST_GH_TOKEN", "gh-secret-value")
+	resolver := newSecretResolver(EnvSecretsProvider{Prefix: "TEST_"})
+
+	env := map[string]string{"DB_PASSWORD": "${secret:DB_PASSWORD}", "PLAIN": "value"}
+	servers := map[string]MCPServerConfig{
+		"github": {
+			"type":    "http",
+			"url":     "https://api.example.com/mcp",
+			"headers": map[string]string{"Authorization": "Bearer ${secret:GH_TOKEN}"},
+		},
+		"db": {
+			"command": "db-mcp",
+			"args":    []string{"--password", "${secret:DB_PASSWORD}"},
+			"env":     map[string]any{"NESTED": []any{"${secret:GH_TOKEN}"}},
+		},
+	}
+	agents := []CustomAgentConfig{{Name: "ops", MCPServers: map[string]MCPServerConfig{"gh": {"token": "${secret:GH_TOKEN}"}}}}
+
+	resolved, err := resolver.resolve(t.Context(), sessionSecrets{env: env, mcpServers: servers, agents: agents})
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if resolved.env["DB_PASSWORD"] != "hunter2-long" || resolved.env["PLAIN"] != "value" {
+		t.Errorf("Unexpected env: %v", resolved.env)
+	}
+	if got := resolved.mcpServers["github"]["headers"].(map[string]string)["Authorization"]; got != "Bearer gh-secret-value" {
+		t.Errorf("Unexpected header: %q", got)
+	}
+	if got := resolved.mcpServers["db"]["args"].([]string)[1]; got != "hunter2-long" {
+		t.Errorf("Unexpected argument: %q", got)
+	}
+	if got := resolved.mcpServers["db"]["env"].(map[string]any)["NESTED"].([]any)[0]; got != "gh-secret-value" {
+		t.Errorf("Unexpected nested value: %v", got)
+	}
+	if got := resolved.agents[0].MCPServers["gh"]["token"]; got != "gh-secret-value" {
+		t.Errorf("Unexpected agent MCP server value: %v", got)
+	}
+	if env["DB_PASSWORD"] != "${secret:DB_PASSWORD}" || agents[0].MCPServers["gh"]["token"] != "${secret:GH_TOKEN}" {
+		t.Error("Expected the caller's configuration to be unchanged")
+	}
+
+	if got := resolver.find("password=hunter2-long token=gh-secret-value"); len(got) != 2 || got[0] != [2]int{9, 21} {
+		t.Errorf("Unexpected secret ranges: %v", got)
+	}
+
+	_, err = resolver.resolve(t.Context(), sessionSecrets{env: map[string]string{"X": "${secret:MISSING}"}})
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+
+	var none *secretResolver
+	if resolved, err := none.resolve(t.Context(), sessionSecrets{env: map[string]string{"X": "${secret:DB_PASSWORD}"}}); err != nil || resolved.env["X"] != "${secret:DB_PASSWORD}" {
+		t.Errorf("Expected references to pass through without a provider, got %+v, %v", resolved, err)
+	}
+}
+
+func TestClient_SecretsProvider(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "resolved-api-key")
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	client.secrets = newSecretResolver(EnvSecretsProvider{Prefix: "TEST_"})
+	client.options.Redactor = client.options.Redactor.With(redact.Detector("secret", client.secrets.find))
+	var wire, recorded bytes.Buffer
+	client.options.WireDump = &wire
+	client.options.RecordTo = &recorded
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start playback client: %v", err)
+	}
+
+	_, err = client.CreateSession(t.Context(), &SessionConfig{
+		OnPermissionRequest: PermissionHandler.ApproveAll,
+		Env:                 map[string]string{"DEPLOY_TARGET": "${secret:API_KEY}"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if !strings.Contains(wire.String(), `"DEPLOY_TARGET":"resolved-api-key"`) {
+		t.Errorf("Expected session.create to carry the resolved secret, got:\n%s", wire.String())
+	}
+	if strings.Contains(recorded.String(), "resolved-api-key") || !strings.Contains(recorded.String(), "[REDACTED:secret]") {
+		t.Errorf("Expected the replay log to redact the resolved secret, got:\n%s", recorded.String())
+	}
+	if _, err := readReplayLog(bytes.NewReader(recorded.Bytes())); err != nil {
+		t.Errorf("Expected a valid replay log: %v", err)
+	}
+
+	redacting := NewClient(&ClientOptions{SecretsProvider: EnvSecretsProvider{Prefix: "TEST_"}})
+	redacting.secrets.remember("resolved-api-key")
+	if got := redacting.options.Redactor.String("key=resolved-api-key"); got != "key=[REDACTED:secret]" {
+		t.Errorf("Expected resolved secrets to be redacted, got %q", got)
+	}
+}
@@ -0,0 +1,289 @@
+// Package secretstores provides [copilot.SecretsProvider] implementations for
+// external secret stores, so that "${secret:<ref>}" references in session
+// configurations can be resolved from HashiCorp Vault or AWS Secrets Manager:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    // Address and Token default to $VAULT_ADDR and $VAULT_TOKEN
+//	    SecretsProvider: &secretstores.Vault{Mount: "kv"},
+//	})
+package secretstores
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Vault resolves references from a HashiCorp Vault KV version 2 secrets
+// engine. A reference is a secret path with an optional field,
+// "<path>#<field>", e.g. "${secret:ci/github#token}"; the field defaults to
+// "value".
+type Vault struct {
+	// Address is the URL of the Vault server. Default: $VAULT_ADDR.
+	Address string
+	// Token authenticates to Vault. Default: $VAULT_TOKEN.
+	Token string
+	// Namespace is the Vault Enterprise namespace. Default: $VAULT_NAMESPACE.
+	Namespace string
+	// Mount is the path the KV engine is mounted at. Default: "secret".
+	Mount string
+	// HTTPClient sends the requests. Default: [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// ResolveSecret reads the field of the secret at the reference's path.
+func (p *Vault) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	if field == "" {
+		field = "value"
+	}
+	address := strings.TrimSuffix(cmp.Or(p.Address, os.Getenv("VAULT_ADDR")), "/")
+	if address == "" {
+		return "", fmt.Errorf("vault: Address or VAULT_ADDR is required")
+	}
+	mount := strings.Trim(cmp.Or(p.Mount, "secret"), "/")
+	endpoint := address + "/v1/" + mount + "/data/" + strings.Trim(path, "/")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	request.Header.Set("X-Vault-Token", cmp.Or(p.Token, os.Getenv("VAULT_TOKEN")))
+	if namespace := cmp.Or(p.Namespace, os.Getenv("VAULT_NAMESPACE")); namespace != "" {
+		request.Header.Set("X-Vault-Namespace", namespace)
+	}
+	response, err := httpClientOrDefault(p.HTTPClient).Do(request)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("vault: %s: %w", path, copilot.ErrSecretNotFound)
+	case response.StatusCode != http.StatusOK:
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		json.Unmarshal(body, &failure)
+		return "", fmt.Errorf("vault: reading %s failed with status %d: %s", path, response.StatusCode, strings.Join(failure.Errors, "; "))
+	}
+	var secret struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("vault: failed to parse secret %s: %w", path, err)
+	}
+	value, ok := secret.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no field %q: %w", path, field, copilot.ErrSecretNotFound)
+	}
+	return secretString(value), nil
+}
+
+// AWSSecretsManager resolves references from AWS Secrets Manager. A
+// reference is a secret name or ARN with an optional key, "<id>#<key>", where
+// the key selects a field of a secret stored as a JSON object, e.g.
+// "${secret:prod/db#password}".
+//
+// Requests are signed with the static credentials of the provider or of the
+// standard AWS environment variables; use a custom [copilot.SecretsProvider]
+// wrapping the AWS SDK for other credential sources.
+//
+// Example:
+//
+//	client := copilot.NewClient(&copilot.ClientOptions{
+//	    // Credentials default to $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY, and $AWS_SESSION_TOKEN
+//	    SecretsProvider: &secretstores.AWSSecretsManager{Region: "us-east-1"},
+//	})
+type AWSSecretsManager struct {
+	// Region is the AWS region. Default: $AWS_REGION or $AWS_DEFAULT_REGION.
+	Region string
+	// AccessKeyID is the access key. Default: $AWS_ACCESS_KEY_ID.
+	AccessKeyID string
+	// SecretAccessKey is the secret key. Default: $AWS_SECRET_ACCESS_KEY.
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials. Default:
+	// $AWS_SESSION_TOKEN when AccessKeyID is not set.
+	SessionToken string
+	// Endpoint overrides the Secrets Manager endpoint, e.g. for a VPC
+	// endpoint. Default: https://secretsmanager.<region>.amazonaws.com.
+	Endpoint string
+	// HTTPClient sends the requests. Default: [http.DefaultClient].
+	HTTPClient *http.Client
+}
+
+// ResolveSecret fetches the current value of the referenced secret.
+func (p *AWSSecretsManager) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	id, key, _ := strings.Cut(ref, "#")
+	region := cmp.Or(p.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return "", fmt.Errorf("aws secrets manager: Region or AWS_REGION is required")
+	}
+	credentials := awsCredentials{AccessKeyID: p.AccessKeyID, SecretAccessKey: p.SecretAccessKey, SessionToken: p.SessionToken}
+	if credentials.AccessKeyID == "" {
+		credentials = awsCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return "", fmt.Errorf("aws secrets manager: credentials are required")
+	}
+	endpoint := cmp.Or(p.Endpoint, "https://secretsmanager."+region+".amazonaws.com")
+
+	body, _ := json.Marshal(map[string]string{"SecretId": id})
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(request, body, credentials, region, "secretsmanager", time.Now())
+
+	response, err := httpClientOrDefault(p.HTTPClient).Do(request)
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("aws secrets manager: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("aws secrets manager: %s: %w", id, copilot.ErrSecretNotFound)
+		}
+		return "", fmt.Errorf("aws secrets manager: reading %s failed with status %d: %s %s", id, response.StatusCode, failure.Type, failure.Message)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return "", fmt.Errorf("aws secrets manager: failed to parse secret %s: %w", id, err)
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("aws secrets manager: %s is a binary secret", id)
+	}
+	if key == "" {
+		return *secret.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("aws secrets manager: %s is not a JSON object: %w", id, err)
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("aws secrets manager: %s has no key %q: %w", id, key, copilot.ErrSecretNotFound)
+	}
+	return secretString(value), nil
+}
+
+// awsCredentials are static AWS credentials.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers to request, signing its
+// host, its headers, and body.
+func signAWSRequest(request *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		path,
+		strings.ReplaceAll(request.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// secretString returns a field of a secret as a string, encoding values that
+// are not strings as JSON.
+func secretString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
@@ -0,0 +1,94 @@
+package secretstores
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/ci/github" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"value":"v","token":"ghs-token","port":5432}}}`))
+	}))
+	defer server.Close()
+	provider := &Vault{Address: server.URL + "/", Token: "vault-token", Namespace: "team", Mount: "kv"}
+
+	for ref, want := range map[string]string{"ci/github": "v", "ci/github#token": "ghs-token", "ci/github#port": "5432"} {
+		if got, err := provider.ResolveSecret(t.Context(), ref); err != nil || got != want {
+			t.Errorf("ResolveSecret(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	for _, ref := range []string{"ci/other", "ci/github#missing"} {
+		if _, err := provider.ResolveSecret(t.Context(), ref); !errors.Is(err, copilot.ErrSecretNotFound) {
+			t.Errorf("ResolveSecret(%q): expected ErrSecretNotFound, got %v", ref, err)
+		}
+	}
+	provider.Token = "wrong"
+	if _, err := provider.ResolveSecret(t.Context(), "ci/github"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected Vault's error, got %v", err)
+	}
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	var target, authorization, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, authorization, token = r.Header.Get("X-Amz-Target"), r.Header.Get("Authorization"), r.Header.Get("X-Amz-Security-Token")
+		var body struct{ SecretId string }
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		switch body.SecretId {
+		case "prod/db":
+			w.Write([]byte(`{"Name":"prod/db","SecretString":"{\"password\":\"s3cret\"}"}`))
+		case "plain":
+			w.Write([]byte(`{"Name":"plain","SecretString":"plain-value"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+	provider := &AWSSecretsManager{Region: "eu-west-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "session", Endpoint: server.URL}
+
+	if got, err := provider.ResolveSecret(t.Context(), "prod/db#password"); err != nil || got != "s3cret" {
+		t.Errorf("Unexpected JSON key value: %q, %v", got, err)
+	}
+	if got, err := provider.ResolveSecret(t.Context(), "plain"); err != nil || got != "plain-value" {
+		t.Errorf("Unexpected value: %q, %v", got, err)
+	}
+	if target != "secretsmanager.GetSecretValue" || token != "session" ||
+		!strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(authorization, "/eu-west-1/secretsmanager/aws4_request") {
+		t.Errorf("Unexpected request: target=%q authorization=%q token=%q", target, authorization, token)
+	}
+	for _, ref := range []string{"missing", "prod/db#username"} {
+		if _, err := provider.ResolveSecret(t.Context(), ref); !errors.Is(err, copilot.ErrSecretNotFound) {
+			t.Errorf("ResolveSecret(%q): expected ErrSecretNotFound, got %v", ref, err)
+		}
+	}
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	request, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signAWSRequest(request, nil, awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		"us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := request.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+package copilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// SendTo sends a message and writes the assistant's reply to w as it is
+// generated, returning once the session is idle. Each assistant.message_delta
+// is written as it arrives, so the reply is never held in memory by the SDK;
+// enable SessionConfig.Streaming to receive deltas, otherwise each complete
+// assistant message is written when it arrives. Consecutive assistant messages
+// in one turn, such as text before and after a tool call, are separated by a
+// blank line. Reasoning is not written.
+//
+// When w has a Flush() or Flush() error method, such as an
+// http.ResponseWriter or a *bufio.Writer, it is flushed after each write and
+// when the turn ends; options.FlushInterval batches flushes instead.
+//
+// Writes are made from the SDK's event dispatch, so a slow writer delays other
+// event handlers of the session. If a write fails, e.g. because an HTTP client
+// disconnected, the turn is aborted and the write error is returned.
+// options.Timeout applies as in [Session.SendAndWait].
+//
+// Returns the number of bytes written.
+//
+// Example:
+//
+//	http.HandleFunc("/ask", func(w http.ResponseWriter, r *http.Request) {
+//	    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+//	    if _, err := session.SendTo(r.Context(), copilot.MessageOptions{Prompt: r.FormValue("q")}, w); err != nil {
+//	        log.Printf("Failed: %v", err)
+//	    }
+//	})
+func (s *Session) SendTo(ctx context.Context, options MessageOptions, w io.Writer) (int64, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	} else if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
+		defer cancel()
+	}
+
+	progress := newProgressReporter(options.OnProgress)
+	if err := s.acquireTurn(ctx, progress); err != nil {
+		return 0, err
+	}
+	defer func() { <-s.turn }()
+
+	sw := newStreamWriter(w, options.FlushInterval)
+	defer sw.stop()
+
+	idleCh := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	unsubscribe := s.On(func(event SessionEvent) {
+		progress.observe(event)
+		var err error
+		switch event.Type {
+		case AssistantMessageDelta:
+			err = sw.delta(event)
+		case AssistantMessage:
+			err = sw.message(event)
+		case SessionIdle:
+			select {
+			case idleCh <- struct{}{}:
+			default:
+			}
+		case SessionError:
+			errMsg := "session error"
+			if event.Data.Message != nil {
+				errMsg = *event.Data.Message
+			}
+			err = fmt.Errorf("session error: %s", errMsg)
+		}
+		if err != nil {
+			select {
+			case errCh <- err:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+
+	progress.set(ProgressThinking, "")
+	_, duplicate, _, err := s.send(ctx, options)
+	if err != nil {
+		return 0, err
+	}
+	if duplicate && !s.busy.Load() {
+		// The original turn already finished; write its reply instead of waiting
+		if event := s.sent.response(options.IdempotencyKey); event != nil {
+			if err := sw.message(*event); err != nil {
+				return sw.written(), err
+			}
+		}
+		return sw.written(), sw.flush()
+	}
+
+	select {
+	case <-idleCh:
+		if options.IdempotencyKey != "" {
+			s.sent.setResponse(options.IdempotencyKey, sw.last())
+		}
+		return sw.written(), sw.flush()
+	case err := <-errCh:
+		var writeErr *streamWriteError
+		if errors.As(err, &writeErr) {
+			if abortErr := s.Abort(context.WithoutCancel(ctx)); abortErr != nil {
+				s.logger.Warn("failed to abort turn after write error", "error", abortErr)
+			}
+			return sw.written(), writeErr
+		}
+		sw.flush()
+		return sw.written(), err
+	case <-ctx.Done():
+		sw.flush()
+		err := fmt.Errorf("waiting for session.idle: %w", ctx.Err())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return sw.written(), &SDKError{Code: ErrorCodeTimeout, Message: err.Error(), Err: err}
+		}
+		return sw.written(), err
+	}
+}
+
+// streamWriteError marks a failed write to the writer of [Session.SendTo].
+type streamWriteError struct {
+	err error
+}
+
+func (e *streamWriteError) Error() string {
+	return fmt.Sprintf("failed to write response: %v", e.err)
+}
+
+func (e *streamWriteError) Unwrap() error {
+	return e.err
+}
+
+// streamWriter writes assistant output to the writer of [Session.SendTo].
+type streamWriter struct {
+	w        io.Writer
+	interval time.Duration
+	flushFn  func() error
+
+	mu         sync.Mutex
+	n          int64
+	err        error
+	messageID  string // ID of the message whose deltas are being written
+	streamed   bool   // whether deltas of messageID were written
+	wroteAny   bool   // whether any message content was written
+	lastEvent  *SessionEvent
+	flushTimer *time.Timer
+}
+
+func newStreamWriter(w io.Writer, interval time.Duration) *streamWriter {
+	sw := &streamWriter{w: w, interval: interval}
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		sw.flushFn = f.Flush
+	case interface{ Flush() }:
+		sw.flushFn = func() error { f.Flush(); return nil }
+	}
+	return sw
+}
+
+// delta writes the content of an assistant.message_delta event.
+func (sw *streamWriter) delta(event SessionEvent) error {
+	if event.Data.DeltaContent == nil || *event.Data.DeltaContent == "" {
+		return nil
+	}
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	messageID := ""
+	if event.Data.MessageID != nil {
+		messageID = *event.Data.MessageID
+	}
+	if !sw.streamed || messageID != sw.messageID {
+		sw.messageID, sw.streamed = messageID, true
+		if err := sw.separate(); err != nil {
+			return err
+		}
+	}
+	return sw.write(*event.Data.DeltaContent)
+}
+
+// message writes the content of an assistant.message event, unless it was
+// already written from its deltas.
+func (sw *streamWriter) message(event SessionEvent) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	eventCopy := event
+	sw.lastEvent = &eventCopy
+	streamed := sw.streamed && (event.Data.MessageID == nil || *event.Data.MessageID == sw.messageID)
+	sw.messageID, sw.streamed = "", false
+	if streamed || event.Data.Content == nil || *event.Data.Content == "" {
+		return nil
+	}
+	if err := sw.separate(); err != nil {
+		return err
+	}
+	return sw.write(*event.Data.Content)
+}
+
+// separate writes a blank line before the content of a message after the first.
+func (sw *streamWriter) separate() error {
+	if !sw.wroteAny {
+		sw.wroteAny = true
+		return nil
+	}
+	return sw.write("\n\n")
+}
+
+// write writes s and flushes according to the flush interval. Callers hold mu.
+func (sw *streamWriter) write(s string) error {
+	if sw.err != nil {
+		// A timed flush failed, or SendTo returned
+		return &streamWriteError{err: sw.err}
+	}
+	n, err := io.WriteString(sw.w, s)
+	sw.n += int64(n)
+	if err != nil {
+		sw.err = err
+		return &streamWriteError{err: err}
+	}
+	switch {
+	case sw.flushFn == nil || sw.interval < 0:
+	case sw.interval == 0:
+		if err := sw.flushFn(); err != nil {
+			sw.err = err
+			return &streamWriteError{err: err}
+		}
+	case sw.flushTimer == nil:
+		sw.flushTimer = time.AfterFunc(sw.interval, func() {
+			sw.mu.Lock()
+			defer sw.mu.Unlock()
+			sw.flushTimer = nil
+			if sw.err == nil {
+				sw.err = sw.flushFn()
+			}
+		})
+	}
+	return nil
+}
+
+// flush flushes pending output, unless flushing is left to the caller.
+func (sw *streamWriter) flush() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.flushTimer != nil {
+		sw.flushTimer.Stop()
+		sw.flushTimer = nil
+	}
+	if sw.err == nil && sw.flushFn != nil && sw.interval >= 0 {
+		sw.err = sw.flushFn()
+	}
+	if sw.err != nil {
+		return &streamWriteError{err: sw.err}
+	}
+	return nil
+}
+
+// stop cancels a pending timed flush.
+func (sw *streamWriter) stop() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.flushTimer != nil {
+		sw.flushTimer.Stop()
+		sw.flushTimer = nil
+	}
+	// Stop writing from handlers still running after SendTo returns
+	if sw.err == nil {
+		sw.err = io.ErrClosedPipe
+	}
+}
+
+func (sw *streamWriter) written() int64 {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.n
+}
+
+func (sw *streamWriter) last() *SessionEvent {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.lastEvent
+}
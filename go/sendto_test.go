@@ -0,0 +1,110 @@
+package copilot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// flushRecorder is a writer with a Flush method, like http.ResponseWriter.
+type flushRecorder struct {
+	buf     bytes.Buffer
+	flushed []string
+	err     error
+}
+
+func (r *flushRecorder) Write(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.buf.Write(p)
+}
+
+func (r *flushRecorder) String() string {
+	return r.buf.String()
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, r.String())
+}
+
+func TestSession_SendTo(t *testing.T) {
+	t.Run("writes deltas and whole messages as they arrive", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "u1"})
+		log.event("s1", AssistantReasoningDelta, map[string]any{"deltaContent": "Hmm", "reasoningId": "r1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "Let me ", "messageId": "m1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "look.", "messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Let me look.", "messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Found it.", "messageId": "m2"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Streaming: true})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var w flushRecorder
+		n, err := session.SendTo(t.Context(), MessageOptions{Prompt: "Find the bug"}, &w)
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if w.String() != "Let me look.\n\nFound it." || n != int64(w.buf.Len()) {
+			t.Errorf("Unexpected output (%d bytes): %q", n, w.String())
+		}
+		if len(w.flushed) < 4 || w.flushed[0] != "Let me " {
+			t.Errorf("Expected a flush after every write, got %q", w.flushed)
+		}
+	})
+
+	t.Run("leaves flushing to the caller with a negative interval", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "u1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Done", "messageId": "m1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var w flushRecorder
+		if _, err := session.SendTo(t.Context(), MessageOptions{Prompt: "Go", FlushInterval: -1}, &w); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		if w.String() != "Done" || len(w.flushed) != 0 {
+			t.Errorf("Expected unflushed output, got %q flushed %q", w.String(), w.flushed)
+		}
+	})
+
+	t.Run("aborts the turn when a write fails", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "u1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "Hello", "messageId": "m1"})
+		log.call("session.abort", map[string]any{})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Streaming: true})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		disconnected := errors.New("client disconnected")
+		_, err = session.SendTo(t.Context(), MessageOptions{Prompt: "Hi"}, &flushRecorder{err: disconnected})
+		if !errors.Is(err, disconnected) {
+			t.Fatalf("Expected the write error, got %v", err)
+		}
+		if !bytes.Contains(recorded.Bytes(), []byte(`session.abort`)) {
+			t.Error("Expected the turn to be aborted")
+		}
+	})
+}
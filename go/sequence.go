@@ -0,0 +1,110 @@
+package copilot
+
+import (
+	"context"
+	"sync"
+)
+
+// SequencedEventHandler handles session events along with their sequence number.
+// See [Session.OnSequenced].
+type SequencedEventHandler func(seq uint64, event SessionEvent)
+
+// EventGap reports that events were missed before a session event, for example
+// because a notification was dropped by a flaky transport. See
+// SessionConfig.OnEventGap.
+type EventGap struct {
+	// SessionID is the session the events belong to.
+	SessionID string
+	// Seq is the sequence number of Event.
+	Seq uint64
+	// Event is the first event received after the gap.
+	Event SessionEvent
+	// LastEventID is the ID of the last event received before the gap.
+	LastEventID string
+}
+
+// eventSequence numbers the events of a session and detects gaps in them.
+//
+// Each event from the CLI names the event before it in ParentID. An event whose
+// parent is neither the last event received nor the last persisted one means
+// events in between were lost. Ephemeral events, such as streaming deltas, are
+// not persisted, so the events after them may point past them.
+type eventSequence struct {
+	mu            sync.Mutex
+	seq           uint64
+	lastID        string
+	lastPersisted string
+	onGap         func(*Session, EventGap)
+}
+
+// next assigns the next sequence number to event and reports a gap before it,
+// if any. Events synthesized by the SDK have no ID and are only numbered.
+func (q *eventSequence) next(event SessionEvent) (uint64, *EventGap) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	if event.ID == "" {
+		return q.seq, nil
+	}
+
+	var gap *EventGap
+	if event.ParentID != nil && q.lastID != "" && *event.ParentID != q.lastID && *event.ParentID != q.lastPersisted {
+		gap = &EventGap{Seq: q.seq, Event: event, LastEventID: q.lastID}
+	}
+	q.lastID = event.ID
+	if event.Ephemeral == nil || !*event.Ephemeral {
+		q.lastPersisted = event.ID
+	}
+	return q.seq, gap
+}
+
+// reset makes the event with the given ID the last one received.
+func (q *eventSequence) reset(lastID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lastID = lastID
+	q.lastPersisted = lastID
+}
+
+// OnSequenced subscribes to events from this session like [Session.On], passing
+// each event's sequence number along with it. Sequence numbers start at 1 and
+// increase by one for every event the session delivers, so all handlers see the
+// same number for the same event. Returns a function that unsubscribes.
+//
+// Sequence numbers are assigned by the SDK in the order events arrive. Events
+// lost before reaching the SDK are not numbered; use SessionConfig.OnEventGap to
+// detect them.
+func (s *Session) OnSequenced(handler SequencedEventHandler) func() {
+	return s.subscribe(handler)
+}
+
+// Resync fetches the session's history from the CLI, so that a consumer that
+// missed events can rebuild its state, and resumes gap detection from the last
+// event in it. It returns the history like [Session.GetMessages].
+//
+// Example:
+//
+//	session, err := client.CreateSession(ctx, &copilot.SessionConfig{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	    OnEventGap: func(session *copilot.Session, gap copilot.EventGap) {
+//	        events, err := session.Resync(context.Background())
+//	        if err != nil {
+//	            log.Printf("resync failed: %v", err)
+//	            return
+//	        }
+//	        view.Rebuild(events)
+//	    },
+//	})
+func (s *Session) Resync(ctx context.Context) ([]SessionEvent, error) {
+	events, err := s.GetMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ID != "" {
+			s.sequence.reset(events[i].ID)
+			break
+		}
+	}
+	return events, nil
+}
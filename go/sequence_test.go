@@ -0,0 +1,98 @@
+package copilot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func chainedEvent(id, parentID string, ephemeral bool) SessionEvent {
+	event := SessionEvent{ID: id, Type: AssistantMessageDelta}
+	if parentID != "" {
+		event.ParentID = &parentID
+	}
+	if ephemeral {
+		event.Ephemeral = Bool(true)
+	}
+	return event
+}
+
+func TestSession_EventSequence(t *testing.T) {
+	t.Run("numbers events and detects gaps", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		var mu sync.Mutex
+		var seqs []uint64
+		var gaps []EventGap
+		gapReported := make(chan struct{}, 1)
+		session.sequence.onGap = func(s *Session, gap EventGap) {
+			mu.Lock()
+			defer mu.Unlock()
+			gaps = append(gaps, gap)
+			gapReported <- struct{}{}
+		}
+		session.OnSequenced(func(seq uint64, event SessionEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			seqs = append(seqs, seq)
+		})
+
+		session.dispatchEvent(chainedEvent("e1", "", false))
+		session.dispatchEvent(chainedEvent("e2", "e1", true))
+		session.dispatchEvent(chainedEvent("e3", "e2", true))
+		// Persisted events may point past the ephemeral ones
+		session.dispatchEvent(chainedEvent("e4", "e1", false))
+		// Synthesized events carry no ID and do not break the chain
+		session.dispatchEvent(SessionEvent{Type: SessionCompactionComplete})
+		session.dispatchEvent(chainedEvent("e5", "e4", false))
+		// e6 was lost
+		session.dispatchEvent(chainedEvent("e7", "e6", false))
+
+		select {
+		case <-gapReported:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for OnEventGap")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(seqs) != 7 || seqs[0] != 1 || seqs[6] != 7 {
+			t.Errorf("Expected sequence numbers 1 to 7, got %v", seqs)
+		}
+		if len(gaps) != 1 || gaps[0].SessionID != "s1" || gaps[0].Seq != 7 || gaps[0].Event.ID != "e7" || gaps[0].LastEventID != "e5" {
+			t.Errorf("Unexpected gaps: %+v", gaps)
+		}
+	})
+
+	t.Run("resync resumes from the history", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": []map[string]any{
+			{"id": "e1", "timestamp": "2025-01-01T00:00:00Z", "parentId": nil, "type": "user.message", "data": map[string]any{"content": "hi"}},
+			{"id": "e2", "timestamp": "2025-01-01T00:00:01Z", "parentId": "e1", "type": "assistant.message", "data": map[string]any{"content": "hello"}},
+		}})
+		client := newPlaybackClientForTest(t, log, nil)
+		gapReported := make(chan EventGap, 1)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			OnEventGap:          func(s *Session, gap EventGap) { gapReported <- gap },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		session.dispatchEvent(chainedEvent("x1", "", false))
+		events, err := session.Resync(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to resync: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("Expected the history, got %+v", events)
+		}
+		session.dispatchEvent(chainedEvent("e3", "e2", false))
+		select {
+		case gap := <-gapReported:
+			t.Errorf("Expected no gap after resync, got %+v", gap)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
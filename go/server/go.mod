@@ -0,0 +1,20 @@
+module github.com/github/copilot-sdk/go/server
+
+go 1.24
+
+require (
+	github.com/github/copilot-sdk/go v0.0.0
+	google.golang.org/grpc v1.72.2
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/google/jsonschema-go v0.4.2 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/github/copilot-sdk/go => ../
@@ -0,0 +1,412 @@
+// Package server exposes a [copilot.Client] as a gRPC service, so services
+// written in other languages can share one Copilot CLI sidecar managed by the
+// Go SDK. The service is defined in gatewaypb/gateway.proto; generate clients
+// for other languages from that file.
+//
+// Sessions created over gRPC are owned by the gateway: tools, permission
+// handling, and other Go-only configuration come from [Options], and the
+// gRPC caller refers to sessions by ID.
+//
+// Example:
+//
+//	client := copilot.NewClient(nil)
+//	if err := client.Start(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.Stop()
+//
+//	lis, err := net.Listen("tcp", ":50051")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	grpcServer := grpc.NewServer()
+//	server.New(client, &server.Options{
+//	    OnPermissionRequest: copilot.PermissionHandler.ApproveAll,
+//	}).Register(grpcServer)
+//	log.Fatal(grpcServer.Serve(lis))
+package server
+
+//go:generate protoc -I gatewaypb --go_out=gatewaypb --go_opt=paths=source_relative --go-grpc_out=gatewaypb --go-grpc_opt=paths=source_relative gatewaypb/gateway.proto
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/gateway"
+	"github.com/github/copilot-sdk/go/server/gatewaypb"
+)
+
+// Options configures a [Server].
+type Options struct {
+	// OnPermissionRequest handles permission requests for every session created
+	// or resumed through the gateway. Default: deny all requests.
+	OnPermissionRequest copilot.PermissionHandlerFunc
+	// Tools are registered with every session created or resumed through the gateway.
+	Tools []copilot.Tool
+	// ConfigureSession, if set, is called with the config of each created or
+	// resumed session after it has been filled in from the gRPC request, e.g. to
+	// add hooks or custom agents, or to reject a WorkingDirectory chosen by the
+	// caller. On resume, config.SessionID is the ID of the resumed session and
+	// fields that ResumeSessionConfig lacks are ignored. Returning an error fails
+	// the CreateSession or ResumeSession call.
+	ConfigureSession func(ctx context.Context, config *copilot.SessionConfig) error
+}
+
+// Server implements the Copilot gRPC service on top of a [copilot.Client].
+// Create one with [New].
+type Server struct {
+	gatewaypb.UnimplementedCopilotServer
+
+	client   *copilot.Client
+	options  Options
+	sessions gateway.Sessions
+}
+
+// New returns a gateway that serves client's API over gRPC. The caller owns
+// client and is responsible for starting and stopping it. options may be nil.
+func New(client *copilot.Client, options *Options) *Server {
+	s := &Server{client: client}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.OnPermissionRequest == nil {
+		s.options.OnPermissionRequest = gateway.DenyAll
+	}
+	return s
+}
+
+// Register registers the Copilot service with registrar, typically a [*grpc.Server].
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	gatewaypb.RegisterCopilotServer(registrar, s)
+}
+
+func (s *Server) Ping(ctx context.Context, req *gatewaypb.PingRequest) (*gatewaypb.PingResponse, error) {
+	resp, err := s.client.Ping(ctx, req.GetMessage())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &gatewaypb.PingResponse{
+		Message:   resp.Message,
+		Timestamp: timestamppb.New(time.UnixMilli(resp.Timestamp)),
+	}
+	if resp.ProtocolVersion != nil {
+		out.ProtocolVersion = int32(*resp.ProtocolVersion)
+	}
+	return out, nil
+}
+
+func (s *Server) CreateSession(ctx context.Context, req *gatewaypb.CreateSessionRequest) (*gatewaypb.Session, error) {
+	config := &copilot.SessionConfig{
+		SessionID:           req.GetSessionId(),
+		Model:               req.GetModel(),
+		ReasoningEffort:     req.GetReasoningEffort(),
+		SystemMessage:       systemMessage(req.GetSystemMessage()),
+		AvailableTools:      req.GetAvailableTools(),
+		ExcludedTools:       req.GetExcludedTools(),
+		WorkingDirectory:    req.GetWorkingDirectory(),
+		Streaming:           req.GetStreaming(),
+		Metadata:            req.GetMetadata(),
+		Tools:               s.options.Tools,
+		OnPermissionRequest: s.options.OnPermissionRequest,
+	}
+	if err := s.configureSession(ctx, config); err != nil {
+		return nil, err
+	}
+	session, err := s.client.CreateSession(ctx, config)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.sessions.Track(session)
+	return sessionProto(session), nil
+}
+
+func (s *Server) ResumeSession(ctx context.Context, req *gatewaypb.ResumeSessionRequest) (*gatewaypb.Session, error) {
+	if req.GetSessionId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+	config := &copilot.SessionConfig{
+		SessionID:           req.GetSessionId(),
+		Model:               req.GetModel(),
+		ReasoningEffort:     req.GetReasoningEffort(),
+		SystemMessage:       systemMessage(req.GetSystemMessage()),
+		AvailableTools:      req.GetAvailableTools(),
+		ExcludedTools:       req.GetExcludedTools(),
+		WorkingDirectory:    req.GetWorkingDirectory(),
+		Streaming:           req.GetStreaming(),
+		Tools:               s.options.Tools,
+		OnPermissionRequest: s.options.OnPermissionRequest,
+	}
+	if err := s.configureSession(ctx, config); err != nil {
+		return nil, err
+	}
+	session, err := s.client.ResumeSessionWithOptions(ctx, req.GetSessionId(), gateway.ResumeConfig(config))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.sessions.Track(session)
+	return sessionProto(session), nil
+}
+
+// configureSession runs Options.ConfigureSession on config, if set.
+func (s *Server) configureSession(ctx context.Context, config *copilot.SessionConfig) error {
+	if s.options.ConfigureSession == nil {
+		return nil
+	}
+	if err := s.options.ConfigureSession(ctx, config); err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (s *Server) ListSessions(ctx context.Context, req *gatewaypb.ListSessionsRequest) (*gatewaypb.ListSessionsResponse, error) {
+	sessions, err := s.client.ListSessions(ctx, &copilot.SessionListFilter{
+		Cwd:        req.GetCwd(),
+		GitRoot:    req.GetGitRoot(),
+		Repository: req.GetRepository(),
+		Branch:     req.GetBranch(),
+		Metadata:   req.GetMetadata(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gatewaypb.ListSessionsResponse{}
+	for _, session := range sessions {
+		summary := &gatewaypb.SessionSummary{
+			SessionId:    session.SessionID,
+			StartTime:    timestampProto(session.StartTime),
+			ModifiedTime: timestampProto(session.ModifiedTime),
+			IsRemote:     session.IsRemote,
+			Metadata:     session.Metadata,
+		}
+		if session.Summary != nil {
+			summary.Summary = *session.Summary
+		}
+		resp.Sessions = append(resp.Sessions, summary)
+	}
+	return resp, nil
+}
+
+func (s *Server) DestroySession(ctx context.Context, req *gatewaypb.DestroySessionRequest) (*gatewaypb.DestroySessionResponse, error) {
+	found, err := s.sessions.Destroy(req.GetSessionId())
+	if !found {
+		return nil, notFound(req.GetSessionId())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &gatewaypb.DestroySessionResponse{}, nil
+}
+
+func (s *Server) DeleteSession(ctx context.Context, req *gatewaypb.DeleteSessionRequest) (*gatewaypb.DeleteSessionResponse, error) {
+	if req.GetSessionId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+	if err := s.client.DeleteSession(ctx, req.GetSessionId()); err != nil {
+		return nil, toStatus(err)
+	}
+	s.sessions.Untrack(req.GetSessionId())
+	return &gatewaypb.DeleteSessionResponse{}, nil
+}
+
+func (s *Server) Send(ctx context.Context, req *gatewaypb.SendRequest) (*gatewaypb.SendResponse, error) {
+	session, err := s.session(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	messageID, err := session.Send(ctx, messageOptions(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &gatewaypb.SendResponse{MessageId: messageID}, nil
+}
+
+func (s *Server) SendAndWait(ctx context.Context, req *gatewaypb.SendRequest) (*gatewaypb.SendAndWaitResponse, error) {
+	session, err := s.session(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	event, err := session.SendAndWait(ctx, messageOptions(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gatewaypb.SendAndWaitResponse{}
+	if event != nil {
+		if resp.Message, err = eventProto(*event); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) Abort(ctx context.Context, req *gatewaypb.AbortRequest) (*gatewaypb.AbortResponse, error) {
+	session, err := s.session(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	if err := session.Abort(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &gatewaypb.AbortResponse{}, nil
+}
+
+func (s *Server) GetMessages(ctx context.Context, req *gatewaypb.GetMessagesRequest) (*gatewaypb.GetMessagesResponse, error) {
+	session, err := s.session(req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	events, err := session.GetMessages(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gatewaypb.GetMessagesResponse{}
+	for _, event := range events {
+		message, err := eventProto(event)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		resp.Events = append(resp.Events, message)
+	}
+	return resp, nil
+}
+
+// StreamEvents sends the session's events until the client cancels the call.
+// Response headers are sent once the subscription is in place, so a caller that
+// waits for them will not miss events caused by its later requests.
+func (s *Server) StreamEvents(req *gatewaypb.StreamEventsRequest, stream grpc.ServerStreamingServer[gatewaypb.SessionEvent]) error {
+	session, err := s.session(req.GetSessionId())
+	if err != nil {
+		return err
+	}
+	events := session.Events(stream.Context())
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for event := range events {
+		if len(req.GetTypes()) > 0 && !slices.Contains(req.GetTypes(), string(event.Type)) {
+			continue
+		}
+		message, err := eventProto(event)
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := stream.Send(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// session returns the open session with the given ID, or a NotFound status.
+func (s *Server) session(sessionID string) (*copilot.Session, error) {
+	session, ok := s.sessions.Get(sessionID)
+	if !ok {
+		return nil, notFound(sessionID)
+	}
+	return session, nil
+}
+
+func notFound(sessionID string) error {
+	return status.Errorf(codes.NotFound, "session %q is not open on this gateway; create or resume it first", sessionID)
+}
+
+func systemMessage(message *gatewaypb.SystemMessage) *copilot.SystemMessageConfig {
+	if message == nil {
+		return nil
+	}
+	return &copilot.SystemMessageConfig{Mode: message.GetMode(), Content: message.GetContent()}
+}
+
+func messageOptions(req *gatewaypb.SendRequest) copilot.MessageOptions {
+	options := copilot.MessageOptions{
+		Prompt:         req.GetPrompt(),
+		Mode:           req.GetMode(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Timeout:        time.Duration(req.GetTimeoutMs()) * time.Millisecond,
+	}
+	for _, attachment := range req.GetAttachments() {
+		path := attachment.GetPath()
+		options.Attachments = append(options.Attachments, copilot.Attachment{
+			Type:        copilot.AttachmentType(attachment.GetType()),
+			Path:        &path,
+			DisplayName: attachment.GetDisplayName(),
+		})
+	}
+	return options
+}
+
+func sessionProto(session *copilot.Session) *gatewaypb.Session {
+	return &gatewaypb.Session{
+		SessionId:     session.SessionID,
+		WorkspacePath: session.WorkspacePath(),
+		Metadata:      session.Metadata(),
+	}
+}
+
+// eventProto converts event to its protobuf form. Data is carried as a Struct
+// holding the event's JSON data.
+func eventProto(event copilot.SessionEvent) (*gatewaypb.SessionEvent, error) {
+	encoded, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	data, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	message := &gatewaypb.SessionEvent{
+		Id:        event.ID,
+		Type:      string(event.Type),
+		Timestamp: timestamppb.New(event.Timestamp),
+		Data:      data,
+	}
+	if event.ParentID != nil {
+		message.ParentId = *event.ParentID
+	}
+	if event.Ephemeral != nil {
+		message.Ephemeral = *event.Ephemeral
+	}
+	return message, nil
+}
+
+func timestampProto(value string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// toStatus maps SDK errors to gRPC status codes so callers can branch on them.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Unknown
+	switch gateway.Classify(err) {
+	case gateway.KindCanceled:
+		code = codes.Canceled
+	case gateway.KindTimeout:
+		code = codes.DeadlineExceeded
+	case gateway.KindResourceExhausted:
+		code = codes.ResourceExhausted
+	case gateway.KindPermissionDenied:
+		code = codes.PermissionDenied
+	case gateway.KindUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/internal/playbacktest"
+	"github.com/github/copilot-sdk/go/server/gatewaypb"
+)
+
+// newGateway serves a playback client through a gateway with options on an
+// in-memory connection and returns a gRPC client for it.
+func newGateway(t *testing.T, log *playbacktest.Log, options *Options) gatewaypb.CopilotClient {
+	t.Helper()
+	client := playbacktest.NewClient(t, log)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	New(client, options).Register(grpcServer)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial gateway: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gatewaypb.NewCopilotClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	t.Run("creates a session and waits for the reply", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1", "workspacePath": "/tmp/s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "4", "messageId": "m1"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		log.Call("session.destroy", map[string]any{})
+		gateway := newGateway(t, log, nil)
+
+		session, err := gateway.CreateSession(t.Context(), &gatewaypb.CreateSessionRequest{Metadata: map[string]string{"team": "infra"}})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if session.GetSessionId() != "s1" || session.GetWorkspacePath() != "/tmp/s1" || session.GetMetadata()["team"] != "infra" {
+			t.Errorf("Unexpected session: %v", session)
+		}
+
+		resp, err := gateway.SendAndWait(t.Context(), &gatewaypb.SendRequest{SessionId: "s1", Prompt: "What is 2+2?"})
+		if err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		message := resp.GetMessage()
+		if message.GetType() != string(copilot.AssistantMessage) || message.GetData().GetFields()["content"].GetStringValue() != "4" {
+			t.Errorf("Unexpected reply: %v", message)
+		}
+
+		if _, err := gateway.DestroySession(t.Context(), &gatewaypb.DestroySessionRequest{SessionId: "s1"}); err != nil {
+			t.Fatalf("Failed to destroy session: %v", err)
+		}
+		_, err = gateway.Send(t.Context(), &gatewaypb.SendRequest{SessionId: "s1", Prompt: "Again"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound after destroy, got %v", err)
+		}
+	})
+
+	t.Run("streams filtered events", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.Call("session.send", map[string]any{"messageId": "m1"})
+		log.Event("s1", copilot.AssistantMessage, map[string]any{"content": "Hi", "messageId": "m1"})
+		log.Event("s1", copilot.SessionIdle, map[string]any{})
+		gateway := newGateway(t, log, nil)
+
+		if _, err := gateway.CreateSession(t.Context(), &gatewaypb.CreateSessionRequest{}); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		stream, err := gateway.StreamEvents(t.Context(), &gatewaypb.StreamEventsRequest{SessionId: "s1", Types: []string{string(copilot.SessionIdle)}})
+		if err != nil {
+			t.Fatalf("Failed to stream events: %v", err)
+		}
+		// Wait for the subscription to be registered before any events are dispatched
+		if _, err := stream.Header(); err != nil {
+			t.Fatalf("Failed to read stream header: %v", err)
+		}
+		if _, err := gateway.Send(t.Context(), &gatewaypb.SendRequest{SessionId: "s1", Prompt: "Hello"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Failed to receive event: %v", err)
+		}
+		if event.GetType() != string(copilot.SessionIdle) {
+			t.Errorf("Expected only session.idle events, got %q", event.GetType())
+		}
+	})
+
+	t.Run("keeps serving a session that failed to be destroyed", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.create", map[string]any{"sessionId": "s1"})
+		log.CallError("session.destroy", -32603, "internal error")
+		log.Call("session.destroy", map[string]any{})
+		gateway := newGateway(t, log, nil)
+
+		if _, err := gateway.CreateSession(t.Context(), &gatewaypb.CreateSessionRequest{}); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := gateway.DestroySession(t.Context(), &gatewaypb.DestroySessionRequest{SessionId: "s1"}); err == nil {
+			t.Fatal("Expected the first destroy to fail")
+		}
+		if _, err := gateway.DestroySession(t.Context(), &gatewaypb.DestroySessionRequest{SessionId: "s1"}); err != nil {
+			t.Errorf("Expected the session to still be tracked after a failed destroy, got %v", err)
+		}
+	})
+
+	t.Run("runs ConfigureSession on resume", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		log.Call("session.resume", map[string]any{"sessionId": "s1"})
+		var configured []string
+		gateway := newGateway(t, log, &Options{
+			ConfigureSession: func(ctx context.Context, config *copilot.SessionConfig) error {
+				configured = append(configured, config.SessionID)
+				if !strings.HasPrefix(config.WorkingDirectory, "/srv/") {
+					return status.Error(codes.PermissionDenied, "working directory not allowed")
+				}
+				return nil
+			},
+		})
+
+		_, err := gateway.ResumeSession(t.Context(), &gatewaypb.ResumeSessionRequest{SessionId: "s1", WorkingDirectory: "/etc"})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected ConfigureSession to reject the working directory, got %v", err)
+		}
+		if _, err := gateway.ResumeSession(t.Context(), &gatewaypb.ResumeSessionRequest{SessionId: "s1", WorkingDirectory: "/srv/app"}); err != nil {
+			t.Errorf("Expected the resume to succeed, got %v", err)
+		}
+		if len(configured) != 2 || configured[0] != "s1" {
+			t.Errorf("Expected ConfigureSession to see both resumes of s1, got %v", configured)
+		}
+	})
+
+	t.Run("rejects unknown sessions", func(t *testing.T) {
+		log := &playbacktest.Log{}
+		log.Handshake()
+		gateway := newGateway(t, log, nil)
+
+		_, err := gateway.GetMessages(t.Context(), &gatewaypb.GetMessagesRequest{SessionId: "missing"})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound, got %v", err)
+		}
+	})
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{&copilot.SDKError{Code: copilot.ErrorCodeTimeout}, codes.DeadlineExceeded},
+		{&copilot.SDKError{Code: copilot.ErrorCodeRateLimited}, codes.ResourceExhausted},
+		{&copilot.SDKError{Code: copilot.ErrorCodeQueueFull}, codes.ResourceExhausted},
+		{&copilot.SDKError{Code: copilot.ErrorCodePermissionDenied}, codes.PermissionDenied},
+		{&copilot.SDKError{Code: copilot.ErrorCodeCLIUnavailable}, codes.Unavailable},
+		{context.Canceled, codes.Canceled},
+		{errors.New("boom"), codes.Unknown},
+		{status.Error(codes.NotFound, "missing"), codes.NotFound},
+	}
+	for _, test := range tests {
+		if code := status.Code(toStatus(test.err)); code != test.code {
+			t.Errorf("toStatus(%v): expected %v, got %v", test.err, test.code, code)
+		}
+	}
+}
@@ -4,17 +4,25 @@ package copilot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/pricing"
+	"github.com/github/copilot-sdk/go/redact"
 	"github.com/github/copilot-sdk/go/rpc"
 )
 
 type sessionHandler struct {
 	id uint64
-	fn SessionEventHandler
+	fn SequencedEventHandler
 }
 
 // Session represents a single conversation session with the Copilot CLI.
@@ -24,7 +32,9 @@ type sessionHandler struct {
 //
 // The session provides methods to send messages, subscribe to events, retrieve
 // conversation history, and manage the session lifecycle. All methods are safe
-// for concurrent use.
+// for concurrent use, so a session can be shared by, for example, several HTTP
+// handlers. Concurrent [Session.SendAndWait] calls are serialized: each waits
+// for the previous turn to finish and returns the response to its own message.
 //
 // Example usage:
 //
@@ -58,17 +68,81 @@ type Session struct {
 	handlerMutex      sync.RWMutex
 	toolHandlers      map[string]ToolHandler
 	toolHandlersM     sync.RWMutex
+	toolSlots         chan struct{} // nil when tool concurrency is unlimited
+	toolTimeout       time.Duration // SessionConfig.ToolTimeout
 	permissionHandler PermissionHandlerFunc
 	permissionMux     sync.RWMutex
+	audit             permissionAudit
+	scopes            permissionScopes
+	toolCalls         toolCallLog
+	usage             usageLog
+	pricer            pricing.Pricer // ClientOptions.Pricing
+	sequence          eventSequence
+	images            imageFiles // temporary files for MessageOptions.Images
+	buffers           eventBuffers
+	memoryLimit       int64 // ClientOptions.SessionMemoryLimit
+	onMemoryLimit     func(*Session, SessionStats)
+	jobs              *jobRegistry // the owning client's jobs
+	jobStore          JobStore
+	memoryStore       MemoryStore      // ClientOptions.MemoryStore
+	memoryWorkspace   string           // workspace of MemoryScopeWorkspace memories in memoryStore
+	redactor          *redact.Redactor // ClientOptions.Redactor
+	trimmed           atomic.Int64
 	userInputHandler  UserInputHandler
 	userInputMux      sync.RWMutex
 	hooks             *SessionHooks
 	hooksMux          sync.RWMutex
+	metrics           MetricsRegistry
+	limiter           *rateLimiter
+	autoCompact       *autoCompactor    // nil unless AutoCompact is configured
+	autoTitle         *autoTitler       // nil unless AutoTitle is set
+	truncation        *historyTruncator // nil unless TruncationPolicy is set
+	modelFallbacks    []string          // SessionConfig.ModelFallbacks
+	locale            string            // SessionConfig.Locale
+	title             atomic.Pointer[string]
+	queue             *messageQueue
+	messagesSent      atomic.Int64
+	busy              atomic.Bool   // true from Send until session.idle or session.error
+	turn              chan struct{} // held by SendAndWait for the duration of a turn
+	currentAgent      atomic.Pointer[string]
+	agentNames        []string               // custom agents registered by the SDK
+	agentNamesMux     sync.Mutex             // guards agentNames
+	pinned            []string               // IDs of pinned messages
+	pinnedMux         sync.Mutex             // guards pinned
+	currentMessage    atomic.Pointer[string] // ID of the last message sent
+	runningTools      runningToolCalls       // contexts of tool calls in progress
+	plans             planWatcher            // handlers of OnPlanUpdated
+	responseCache     *sessionResponseCache  // nil unless ClientOptions.ResponseCache is set
+	track             func(*Session)         // registers forked sessions with the owning client
+	onDestroyed       func()                 // reports the destroyed session to Client.Events
+	capabilities      func(context.Context) (*Capabilities, error)
+	metadata          map[string]string
+	beforeSend        func(*MessageOptions) error
+	outputFilters     []OutputFilter
+	toolCache         *toolCache  // nil unless ToolCache is configured
+	dryRun            *dryRun     // nil unless DryRun is configured
+	edits             *editReview // nil unless ReviewEdits is set
+	deterministic     bool        // SessionConfig.Deterministic is set
+	expiry            *idleExpiry // nil unless ClientOptions.SessionIdleTimeout is set
+	logger            *slog.Logger
+	destroyed         chan struct{} // closed by Destroy
+	destroyMux        sync.Mutex    // serializes Destroy
+	sent              sentMessages  // outcomes of sends with an IdempotencyKey
 
 	// RPC provides typed session-scoped RPC methods.
 	RPC *rpc.SessionRpc
 }
 
+// Metadata returns a copy of the metadata the session was created with.
+func (s *Session) Metadata() map[string]string {
+	return maps.Clone(s.metadata)
+}
+
+// setMetadata stores a copy of metadata. It must be called before the session is shared.
+func (s *Session) setMetadata(metadata map[string]string) {
+	s.metadata = maps.Clone(metadata)
+}
+
 // WorkspacePath returns the path to the session workspace directory when infinite
 // sessions are enabled. Contains checkpoints/, plan.md, and files/ subdirectories.
 // Returns empty string if infinite sessions are disabled.
@@ -78,14 +152,20 @@ func (s *Session) WorkspacePath() string {
 
 // newSession creates a new session wrapper with the given session ID and client.
 func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string) *Session {
-	return &Session{
+	s := &Session{
 		SessionID:     sessionID,
 		workspacePath: workspacePath,
 		client:        client,
 		handlers:      make([]sessionHandler, 0),
 		toolHandlers:  make(map[string]ToolHandler),
 		RPC:           rpc.NewSessionRpc(client, sessionID),
+		logger:        slog.New(slog.DiscardHandler),
+		destroyed:     make(chan struct{}),
+		turn:          make(chan struct{}, 1),
 	}
+	s.queue = newMessageQueue(s, 0)
+	s.toolCalls.sessionID = sessionID
+	return s
 }
 
 // Send sends a message to this session and waits for the response.
@@ -98,6 +178,10 @@ func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string)
 //
 // Returns the message ID of the response, which can be used to correlate events,
 // or an error if the session has been destroyed or the connection fails.
+// When [ClientOptions.RateLimit] is set, Send may wait for capacity or fail
+// with an error matching [ErrRateLimited] that wraps a [*QuotaExceededError].
+// When SessionConfig.OnBeforeSend returns an error, the message is not sent
+// and Send returns an error wrapping it.
 //
 // Example:
 //
@@ -111,15 +195,121 @@ func newSession(sessionID string, client *jsonrpc2.Client, workspacePath string)
 //	    log.Printf("Failed to send message: %v", err)
 //	}
 func (s *Session) Send(ctx context.Context, options MessageOptions) (string, error) {
-	req := sessionSendRequest{
-		SessionID:   s.SessionID,
-		Prompt:      options.Prompt,
-		Attachments: options.Attachments,
-		Mode:        options.Mode,
+	if err := s.responseCache.diverged(s.SessionID, options); err != nil {
+		return "", err
+	}
+	// The response cache only follows turns run by SendAndWait
+	s.responseCache.disable()
+	messageID, _, err := s.newFallbackSend(options).send(ctx)
+	return messageID, err
+}
+
+// sendMessage sends a message without deduplication. It returns the prepared
+// request, if the message got that far, so that it can be resent on a
+// fallback model.
+func (s *Session) sendMessage(ctx context.Context, options MessageOptions) (string, *sessionSendRequest, error) {
+	req, err := s.prepareMessage(ctx, options)
+	if err != nil {
+		return "", nil, err
+	}
+	messageID, err := s.deliverMessage(ctx, req)
+	if err != nil && s.limiter != nil {
+		// The message was not sent, so it does not count toward the limits
+		s.limiter.release(s.SessionID, req.limited)
+	}
+	return messageID, req, err
+}
+
+// screenMessage checks that the session can still send and runs OnBeforeSend,
+// which may rewrite options.
+func (s *Session) screenMessage(options *MessageOptions) error {
+	if s.isDestroyed() {
+		return fmt.Errorf("failed to send message: session %s has been destroyed", s.SessionID)
+	}
+	if s.beforeSend != nil {
+		if err := s.beforeSend(options); err != nil {
+			return fmt.Errorf("message blocked by OnBeforeSend: %w", err)
+		}
+	}
+	return nil
+}
+
+// prepareMessage validates options, which have passed screenMessage, and
+// acquires the rate limit, returning the session.send request for the message.
+func (s *Session) prepareMessage(ctx context.Context, options MessageOptions) (*sessionSendRequest, error) {
+	if err := s.validateOverrides(ctx, options); err != nil {
+		return nil, err
+	}
+	if err := validateLocale(options.Locale); err != nil {
+		return nil, err
+	}
+	locale := options.Locale
+	if locale == "" {
+		locale = s.locale
+	}
+	s.localizePrompt(&options)
+	if options.HistoryWindow == nil && s.truncation != nil {
+		window, err := s.truncation.window(ctx)
+		if err != nil {
+			return nil, err
+		}
+		options.HistoryWindow = window
+	}
+	if err := s.validateHistoryWindow(ctx, options); err != nil {
+		return nil, err
+	}
+
+	attachments := options.Attachments
+	if len(options.Images) > 0 {
+		images, err := s.images.attach(options.Images)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach images: %w", err)
+		}
+		attachments = append(slices.Clone(attachments), images...)
 	}
 
-	result, err := s.client.Request("session.send", req)
+	// Acquired last, since sendMessage gives it back only if delivery fails
+	var limited time.Time
+	if s.limiter != nil {
+		var err error
+		if limited, err = s.limiter.acquire(ctx, s.SessionID); err != nil {
+			var quotaErr *QuotaExceededError
+			if errors.As(err, &quotaErr) {
+				return nil, &SDKError{Code: ErrorCodeRateLimited, Message: quotaErr.Error(), RetryAfter: quotaErr.RetryAfter, Err: quotaErr}
+			}
+			return nil, err
+		}
+	}
+
+	return &sessionSendRequest{
+		SessionID:       s.SessionID,
+		Prompt:          options.Prompt,
+		Attachments:     attachments,
+		Mode:            options.Mode,
+		IdempotencyKey:  options.IdempotencyKey,
+		Model:           options.Model,
+		Temperature:     options.Temperature,
+		MaxOutputTokens: options.MaxOutputTokens,
+		ReasoningEffort: options.ReasoningEffort,
+		HistoryWindow:   options.HistoryWindow,
+		Locale:          locale,
+		limited:         limited,
+	}, nil
+}
+
+// deliverMessage sends req to the CLI.
+func (s *Session) deliverMessage(ctx context.Context, req *sessionSendRequest) (string, error) {
+	// Mark the session busy before sending: session.idle for this message may be
+	// dispatched before the response to session.send is handled here.
+	wasBusy := s.busy.Swap(true)
+	if s.expiry != nil {
+		s.expiry.touch(true)
+	}
+	result, err := s.client.RequestContext(ctx, "session.send", req)
 	if err != nil {
+		if !wasBusy {
+			s.busy.Store(false)
+		}
 		return "", fmt.Errorf("failed to send message: %w", err)
 	}
 
@@ -127,6 +317,12 @@ func (s *Session) Send(ctx context.Context, options MessageOptions) (string, err
 	if err := json.Unmarshal(result, &response); err != nil {
 		return "", fmt.Errorf("failed to unmarshal send response: %w", err)
 	}
+	s.messagesSent.Add(1)
+	s.currentMessage.Store(&response.MessageID)
+	s.logger.Debug("message sent", "messageId", response.MessageID)
+	if s.metrics != nil {
+		s.metrics.MessageSent()
+	}
 	return response.MessageID, nil
 }
 
@@ -137,14 +333,18 @@ func (s *Session) Send(ctx context.Context, options MessageOptions) (string, err
 // has finished processing the message.
 //
 // Events are still delivered to handlers registered via [Session.On] while waiting.
+// If another SendAndWait call on the session is in progress, the message is not
+// sent until that turn finishes.
 //
 // Parameters:
 //   - options: The message options including the prompt and optional attachments.
-//   - timeout: How long to wait for completion. Defaults to 60 seconds if zero.
-//     Controls how long to wait; does not abort in-flight agent work.
+//     options.Timeout controls how long to wait, including for a previous turn,
+//     and defaults to 60 seconds if zero. It does not abort in-flight agent work.
 //
 // Returns the final assistant message event, or nil if none was received.
-// Returns an error if the timeout is reached or the connection fails.
+// Returns an error if the timeout is reached or the connection fails. Timeouts
+// match [ErrTimeout]; with options.PartialOnTimeout, the content received so far
+// is returned alongside the error.
 //
 // Example:
 //
@@ -158,58 +358,245 @@ func (s *Session) Send(ctx context.Context, options MessageOptions) (string, err
 //	    fmt.Println(*response.Data.Content)
 //	}
 func (s *Session) SendAndWait(ctx context.Context, options MessageOptions) (*SessionEvent, error) {
-	if _, ok := ctx.Deadline(); !ok {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	} else if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
 		defer cancel()
 	}
 
+	progress := newProgressReporter(options.OnProgress)
+	if err := s.acquireTurn(ctx, progress); err != nil {
+		return nil, err
+	}
+	defer func() { <-s.turn }()
+
+	// Screen the message before the cache lookup, so that a cached response is
+	// subject to OnBeforeSend too and keyed by the prompt it would have sent
+	if err := s.screenMessage(&options); err != nil {
+		return nil, err
+	}
+	cacheKey, cacheable := s.responseCache.key(ctx, options)
+	if cacheable {
+		response, err := s.responseCache.load(cacheKey)
+		if err != nil {
+			s.logger.Warn("failed to load cached response", "error", err)
+		}
+		if response != nil {
+			s.logger.Debug("response served from cache", "messageId", deref(response.Data.MessageID))
+			return response, nil
+		}
+	}
+	if err := s.responseCache.diverged(s.SessionID, options); err != nil {
+		return nil, err
+	}
+	cached := false
+	defer func() {
+		if !cached {
+			// The turn changed the agent's history in a way the cache did not see
+			s.responseCache.disable()
+		}
+	}()
+
 	idleCh := make(chan struct{}, 1)
-	errCh := make(chan error, 1)
+	errCh := make(chan SessionEvent, 1)
 	var lastAssistantMessage *SessionEvent
+	var reportedModel *string // from assistant.usage
+	var partial partialMessage
+	// failed is set when a session.error may send the message to a fallback
+	// model, until an event of the resent message's turn arrives: a
+	// session.idle in between ends the failed turn, not the retried one
+	var failed bool
 	var mu sync.Mutex
 
 	unsubscribe := s.On(func(event SessionEvent) {
+		progress.observe(event)
+		mu.Lock()
+		defer mu.Unlock()
 		switch event.Type {
+		case UserMessage, AssistantTurnStart:
+			failed = false
+		case AssistantMessageDelta:
+			failed = false
+			partial.append(event)
 		case AssistantMessage:
-			mu.Lock()
+			failed = false
 			eventCopy := event
 			lastAssistantMessage = &eventCopy
-			mu.Unlock()
+			partial.reset()
+		case AssistantUsage:
+			reportedModel = event.Data.Model
 		case SessionIdle:
+			if failed {
+				return
+			}
 			select {
 			case idleCh <- struct{}{}:
 			default:
 			}
 		case SessionError:
-			errMsg := "session error"
-			if event.Data.Message != nil {
-				errMsg = *event.Data.Message
+			if len(s.modelFallbacks) > 0 && isModelUnavailableEvent(event) {
+				failed = true
 			}
 			select {
-			case errCh <- fmt.Errorf("session error: %s", errMsg):
+			case errCh <- event:
 			default:
 			}
 		}
 	})
 	defer unsubscribe()
 
-	_, err := s.Send(ctx, options)
-	if err != nil {
-		return nil, err
+	progress.set(ProgressThinking, "")
+	send := s.newFallbackSend(options)
+	send.screened = true
+	for {
+		_, duplicate, err := send.send(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate && !s.busy.Load() {
+			// The original turn already finished; don't wait for another session.idle
+			return s.sent.response(options.IdempotencyKey), nil
+		}
+
+		select {
+		case <-idleCh:
+			mu.Lock()
+			result := lastAssistantMessage
+			if len(s.modelFallbacks) > 0 {
+				annotateModel(result, reportedModel, send.model())
+			}
+			mu.Unlock()
+			if options.IdempotencyKey != "" {
+				s.sent.setResponse(options.IdempotencyKey, result)
+			}
+			if cacheable {
+				cached = true
+				if err := s.responseCache.save(cacheKey, result); err != nil {
+					s.logger.Warn("failed to cache response", "error", err)
+				}
+			}
+			return result, nil
+		case event := <-errCh:
+			if isModelUnavailableEvent(event) && send.next(deref(event.Data.Message)) {
+				mu.Lock()
+				lastAssistantMessage, reportedModel = nil, nil
+				partial.reset()
+				mu.Unlock()
+				// Don't let a second session.error of the failed turn end the
+				// retried one; its session.idle is ignored by the handler
+				drain(errCh)
+				continue
+			}
+			errMsg := "session error"
+			if event.Data.Message != nil {
+				errMsg = *event.Data.Message
+			}
+			return nil, fmt.Errorf("session error: %s", errMsg)
+		case <-ctx.Done():
+			err := fmt.Errorf("waiting for session.idle: %w", ctx.Err())
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, err
+			}
+			timeoutErr := &SDKError{Code: ErrorCodeTimeout, Message: err.Error(), Err: err}
+			if !options.PartialOnTimeout {
+				return nil, timeoutErr
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if event := partial.event(); event != nil {
+				return event, timeoutErr
+			}
+			return lastAssistantMessage, timeoutErr
+		}
 	}
+}
 
+// acquireTurn waits until no other SendAndWait call is in progress on the session.
+// The caller releases the turn by receiving from s.turn.
+func (s *Session) acquireTurn(ctx context.Context, progress *progressReporter) error {
 	select {
-	case <-idleCh:
-		mu.Lock()
-		result := lastAssistantMessage
-		mu.Unlock()
-		return result, nil
-	case err := <-errCh:
-		return nil, err
-	case <-ctx.Done(): // TODO: remove once session.Send honors the context
-		return nil, fmt.Errorf("waiting for session.idle: %w", ctx.Err())
+	case s.turn <- struct{}{}:
+		return nil
+	default:
+	}
+
+	progress.set(ProgressQueued, "")
+	select {
+	case s.turn <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		err := fmt.Errorf("waiting for the previous turn: %w", ctx.Err())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &SDKError{Code: ErrorCodeTimeout, Message: err.Error(), Err: err}
+		}
+		return err
+	}
+}
+
+// partialMessage accumulates streamed assistant.message_delta content for the
+// message currently being generated.
+type partialMessage struct {
+	messageID string
+	content   strings.Builder
+}
+
+func (p *partialMessage) append(event SessionEvent) {
+	if event.Data.DeltaContent == nil {
+		return
+	}
+	if event.Data.MessageID != nil && *event.Data.MessageID != p.messageID {
+		p.reset()
+		p.messageID = *event.Data.MessageID
+	}
+	p.content.WriteString(*event.Data.DeltaContent)
+}
+
+func (p *partialMessage) reset() {
+	p.messageID = ""
+	p.content.Reset()
+}
+
+// event returns the accumulated content as an assistant.message event, or nil
+// if no content has been streamed.
+func (p *partialMessage) event() *SessionEvent {
+	if p.content.Len() == 0 {
+		return nil
+	}
+	content := p.content.String()
+	event := &SessionEvent{
+		Type:      AssistantMessage,
+		Timestamp: time.Now(),
+		Data:      Data{Content: &content},
 	}
+	if p.messageID != "" {
+		messageID := p.messageID
+		event.Data.MessageID = &messageID
+	}
+	return event
+}
+
+// Enqueue adds a message to this session's queue and returns immediately.
+//
+// Queued messages are sent one at a time, in the order they were enqueued, each
+// waiting for the session to become idle before the next is sent (as with
+// [Session.SendAndWait]). ctx governs both waiting in the queue and the send itself.
+// Use the returned [QueuedMessage] to wait for the result.
+//
+// Returns an error matching [ErrQueueFull] if SessionConfig.MaxQueuedMessages
+// messages are already waiting.
+//
+// Example:
+//
+//	first, _ := session.Enqueue(ctx, copilot.MessageOptions{Prompt: "Summarize README.md"})
+//	second, _ := session.Enqueue(ctx, copilot.MessageOptions{Prompt: "Now list open TODOs"})
+//	summary, err := first.Wait(ctx)
+//	todos, err := second.Wait(ctx)
+func (s *Session) Enqueue(ctx context.Context, options MessageOptions) (*QueuedMessage, error) {
+	return s.queue.enqueue(ctx, options)
 }
 
 // On subscribes to events from this session.
@@ -235,6 +622,11 @@ func (s *Session) SendAndWait(ctx context.Context, options MessageOptions) (*Ses
 //	// Later, to stop receiving events:
 //	unsubscribe()
 func (s *Session) On(handler SessionEventHandler) func() {
+	return s.subscribe(func(_ uint64, event SessionEvent) { handler(event) })
+}
+
+// subscribe registers handler and returns a function that unregisters it.
+func (s *Session) subscribe(handler SequencedEventHandler) func() {
 	s.handlerMutex.Lock()
 	defer s.handlerMutex.Unlock()
 
@@ -275,6 +667,29 @@ func (s *Session) registerTools(tools []Tool) {
 	}
 }
 
+// setMaxParallelTools bounds the number of tool handlers that may run at once.
+// A limit of zero or less removes the bound.
+func (s *Session) setMaxParallelTools(limit int) {
+	if limit > 0 {
+		s.toolSlots = make(chan struct{}, limit)
+	}
+}
+
+// setMaxQueuedMessages sets the capacity of the Enqueue queue. It must be called
+// before the session is used.
+func (s *Session) setMaxQueuedMessages(capacity int) {
+	s.queue = newMessageQueue(s, capacity)
+}
+
+// acquireToolSlot blocks until a tool handler may run and returns the release function.
+func (s *Session) acquireToolSlot() func() {
+	if s.toolSlots == nil {
+		return func() {}
+	}
+	s.toolSlots <- struct{}{}
+	return func() { <-s.toolSlots }
+}
+
 // getToolHandler retrieves a registered tool handler by name.
 // Returns the handler and true if found, or nil and false if not registered.
 func (s *Session) getToolHandler(name string) (ToolHandler, bool) {
@@ -305,8 +720,36 @@ func (s *Session) getPermissionHandler() PermissionHandlerFunc {
 
 // handlePermissionRequest handles a permission request from the Copilot CLI.
 // This is an internal method called by the SDK when the CLI requests permission.
+// Every request is recorded in the session's [Session.PermissionLog].
 func (s *Session) handlePermissionRequest(request PermissionRequest) (PermissionRequestResult, error) {
+	start := time.Now()
+	if request.Kind != "write" || s.edits == nil {
+		if result, ok := s.rememberedPermission(request); ok {
+			s.recordPermission(request, "remembered", result, nil, start)
+			return result, nil
+		}
+	}
+
 	handler := s.getPermissionHandler()
+	result, err := s.decidePermission(handler, request)
+	if _, ok := err.(*PermissionDeniedError); ok {
+		// The handler denied the request under a named rule
+		s.recordPermission(request, permissionHandlerName(handler), PermissionRequestResult{Kind: "denied-by-rules"}, err, start)
+		return PermissionRequestResult{Kind: "denied-by-rules"}, nil
+	}
+	if err == nil && handler != nil {
+		s.rememberPermission(request, result)
+	}
+	s.recordPermission(request, permissionHandlerName(handler), result, err, start)
+	return result, err
+}
+
+// decidePermission runs handler, denying the request when no handler is registered.
+// File writes are held for review instead when ReviewEdits is set.
+func (s *Session) decidePermission(handler PermissionHandlerFunc, request PermissionRequest) (PermissionRequestResult, error) {
+	if request.Kind == "write" && s.edits != nil {
+		return s.edits.await(request), nil
+	}
 
 	if handler == nil {
 		return PermissionRequestResult{
@@ -378,6 +821,12 @@ func (s *Session) getHooks() *SessionHooks {
 // handleHooksInvoke handles a hook invocation from the Copilot CLI.
 // This is an internal method called by the SDK when the CLI invokes a hook.
 func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (any, error) {
+	if hookType == "preToolUse" && s.dryRun != nil {
+		if output, err := s.dryRun.preToolUse(s, rawInput); output != nil || err != nil {
+			return output, err
+		}
+	}
+
 	hooks := s.getHooks()
 
 	if hooks == nil {
@@ -457,8 +906,57 @@ func (s *Session) handleHooksInvoke(hookType string, rawInput json.RawMessage) (
 // This is an internal method; handlers are called synchronously and any panics
 // are recovered to prevent crashing the event dispatcher.
 func (s *Session) dispatchEvent(event SessionEvent) {
+	s.filterOutput(&event)
+	switch event.Type {
+	case SessionIdle:
+		s.busy.Store(false)
+		s.logger.Debug("session idle")
+	case SessionError:
+		s.busy.Store(false)
+		if event.Data.Message != nil {
+			s.logger.Warn("session error", "message", *event.Data.Message)
+		} else {
+			s.logger.Warn("session error")
+		}
+	case SubagentSelected, AgentChanged:
+		if event.Data.AgentName != nil {
+			s.currentAgent.Store(event.Data.AgentName)
+		}
+	case ToolExecutionStart, ToolExecutionComplete:
+		s.toolCalls.observe(event)
+	case AssistantUsage:
+		s.usage.observe(event)
+	case SessionTitleChanged:
+		if event.Data.Title != nil {
+			s.title.Store(event.Data.Title)
+		}
+	}
+	if s.autoCompact != nil {
+		s.autoCompact.observe(event)
+	}
+	if s.autoTitle != nil {
+		s.autoTitle.observe(event)
+	}
+	if s.truncation != nil {
+		s.truncation.observe(event)
+	}
+	s.observePlan(event)
+	if s.expiry != nil {
+		s.expiry.touch(s.busy.Load())
+	}
+	seq, gap := s.sequence.next(event)
+	if gap != nil {
+		gap.SessionID = s.SessionID
+		s.logger.Warn("session events missed", "seq", seq, "eventId", event.ID, "lastEventId", gap.LastEventID)
+		if s.sequence.onGap != nil {
+			// Run on its own goroutine so the handler can call Resync, whose
+			// response arrives on the goroutine delivering this event
+			go s.sequence.onGap(s, *gap)
+		}
+	}
+
 	s.handlerMutex.RLock()
-	handlers := make([]SessionEventHandler, 0, len(s.handlers))
+	handlers := make([]SequencedEventHandler, 0, len(s.handlers))
 	for _, h := range s.handlers {
 		handlers = append(handlers, h.fn)
 	}
@@ -469,12 +967,37 @@ func (s *Session) dispatchEvent(event SessionEvent) {
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
-					fmt.Printf("Error in session event handler: %v\n", r)
+					s.logger.Error("session event handler panicked", "eventType", event.Type, "panic", r)
 				}
 			}()
-			handler(event)
+			handler(seq, event)
 		}()
 	}
+	s.enforceMemoryLimit()
+}
+
+// filterOutput runs the session's output filters over the assistant content of event.
+func (s *Session) filterOutput(event *SessionEvent) {
+	if len(s.outputFilters) == 0 {
+		return
+	}
+	var content **string
+	switch event.Type {
+	case AssistantMessage, AssistantReasoning:
+		content = &event.Data.Content
+	case AssistantMessageDelta, AssistantReasoningDelta:
+		content = &event.Data.DeltaContent
+	default:
+		return
+	}
+	if *content == nil {
+		return
+	}
+	filtered := **content
+	for _, filter := range s.outputFilters {
+		filtered = filter(event.Type, filtered)
+	}
+	*content = &filtered
 }
 
 // GetMessages retrieves all events and messages from this session's history.
@@ -499,7 +1022,7 @@ func (s *Session) dispatchEvent(event SessionEvent) {
 //	}
 func (s *Session) GetMessages(ctx context.Context) ([]SessionEvent, error) {
 
-	result, err := s.client.Request("session.getMessages", sessionGetMessagesRequest{SessionID: s.SessionID})
+	result, err := s.client.RequestContext(ctx, "session.getMessages", sessionGetMessagesRequest{SessionID: s.SessionID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
@@ -508,6 +1031,9 @@ func (s *Session) GetMessages(ctx context.Context) ([]SessionEvent, error) {
 	if err := json.Unmarshal(result, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal get messages response: %w", err)
 	}
+	for i := range response.Events {
+		s.filterOutput(&response.Events[i])
+	}
 	return response.Events, nil
 }
 
@@ -517,7 +1043,8 @@ func (s *Session) GetMessages(ctx context.Context) ([]SessionEvent, error) {
 // handlers and tool handlers are cleared. To continue the conversation,
 // use [Client.ResumeSession] with the session ID.
 //
-// Returns an error if the connection fails.
+// Returns an error if the connection fails. Calling Destroy again after it has
+// succeeded does nothing.
 //
 // Example:
 //
@@ -526,11 +1053,40 @@ func (s *Session) GetMessages(ctx context.Context) ([]SessionEvent, error) {
 //	    log.Printf("Failed to destroy session: %v", err)
 //	}
 func (s *Session) Destroy() error {
+	s.destroyMux.Lock()
+	defer s.destroyMux.Unlock()
+	if s.isDestroyed() {
+		return nil
+	}
+
 	_, err := s.client.Request("session.destroy", sessionDestroyRequest{SessionID: s.SessionID})
 	if err != nil {
 		return fmt.Errorf("failed to destroy session: %w", err)
 	}
 
+	s.logger.Info("session destroyed")
+	if s.limiter != nil {
+		s.limiter.forget(s.SessionID)
+	}
+	if s.onDestroyed != nil {
+		s.onDestroyed()
+	}
+
+	if s.autoCompact != nil {
+		s.autoCompact.stop()
+	}
+	if s.autoTitle != nil {
+		s.autoTitle.stop()
+	}
+	if s.expiry != nil {
+		s.expiry.stop()
+	}
+	s.queue.close()
+	if s.edits != nil {
+		s.edits.close()
+	}
+	s.images.removeAll()
+
 	// Clear handlers
 	s.handlerMutex.Lock()
 	s.handlers = nil
@@ -544,9 +1100,122 @@ func (s *Session) Destroy() error {
 	s.permissionHandler = nil
 	s.permissionMux.Unlock()
 
+	close(s.destroyed)
 	return nil
 }
 
+// isDestroyed reports whether Destroy has succeeded.
+func (s *Session) isDestroyed() bool {
+	select {
+	case <-s.destroyed:
+		return true
+	default:
+		return false
+	}
+}
+
+// CurrentAgent returns the name of the custom agent most recently selected in
+// this session, as reported by subagent.selected and agent.changed events, or
+// "" if none has been.
+func (s *Session) CurrentAgent() string {
+	if name := s.currentAgent.Load(); name != nil {
+		return *name
+	}
+	return ""
+}
+
+// Fork creates a new session containing a copy of this session's conversation history.
+//
+// The forked session can be used to explore an alternative continuation without
+// modifying the original conversation. It inherits this session's tools, permission
+// handler, user input handler, and hooks. Event handlers registered via [Session.On]
+// are not copied; subscribe to the forked session separately.
+//
+// Returns an error if the session has been destroyed or the connection fails.
+//
+// Example:
+//
+//	fork, err := session.Fork(context.Background())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	// Try a different prompt without affecting the original session
+//	fork.SendAndWait(context.Background(), copilot.MessageOptions{
+//	    Prompt: "Try a different approach",
+//	})
+func (s *Session) Fork(ctx context.Context) (*Session, error) {
+	result, err := s.client.RequestContext(ctx, "session.fork", sessionForkRequest{SessionID: s.SessionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork session: %w", err)
+	}
+
+	var response sessionForkResponse
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fork response: %w", err)
+	}
+
+	fork := newSession(response.SessionID, s.client, response.WorkspacePath)
+
+	s.toolHandlersM.RLock()
+	for name, handler := range s.toolHandlers {
+		fork.toolHandlers[name] = handler
+	}
+	s.toolHandlersM.RUnlock()
+	if s.toolSlots != nil {
+		fork.setMaxParallelTools(cap(s.toolSlots))
+	}
+	fork.toolTimeout = s.toolTimeout
+	fork.metrics = s.metrics
+	fork.pricer = s.pricer
+	fork.limiter = s.limiter
+	if s.autoCompact != nil {
+		fork.autoCompact = newAutoCompactor(fork, s.autoCompact.config)
+	}
+	if s.truncation != nil {
+		fork.truncation = newHistoryTruncator(fork, s.truncation.policy, false)
+	}
+	fork.setMaxQueuedMessages(s.queue.capacity)
+	fork.setMetadata(s.metadata)
+	fork.setPinned(s.pinnedIDs())
+	fork.currentAgent.Store(s.currentAgent.Load())
+	s.agentNamesMux.Lock()
+	fork.agentNames = slices.Clone(s.agentNames)
+	s.agentNamesMux.Unlock()
+	fork.beforeSend = s.beforeSend
+	fork.outputFilters = s.outputFilters
+	fork.modelFallbacks = s.modelFallbacks
+	fork.locale = s.locale
+	fork.responseCache = s.responseCache.fork()
+	if s.toolCache != nil {
+		fork.toolCache = newToolCache(s.toolCache.config)
+	}
+	if s.edits != nil {
+		fork.edits = newEditReview(s.edits.onPropose)
+	}
+	if s.dryRun != nil {
+		fork.dryRun = newDryRun(s.dryRun.config)
+	}
+	fork.deterministic = s.deterministic
+
+	if handler := s.getPermissionHandler(); handler != nil {
+		fork.registerPermissionHandler(handler)
+	}
+	fork.setAuditHook(s.getAuditHook())
+	fork.setPermissionScopes(s.scopes.store, s.RememberedPermissions())
+	if handler := s.getUserInputHandler(); handler != nil {
+		fork.registerUserInputHandler(handler)
+	}
+	if hooks := s.getHooks(); hooks != nil {
+		fork.registerHooks(hooks)
+	}
+
+	if s.track != nil {
+		s.track(fork)
+	}
+	fork.logger.Info("session forked", "parentSessionId", s.SessionID)
+	return fork, nil
+}
+
 // Abort aborts the currently processing message in this session.
 //
 // Use this to cancel a long-running request. The session remains valid
@@ -569,7 +1238,7 @@ func (s *Session) Destroy() error {
 //	    log.Printf("Failed to abort: %v", err)
 //	}
 func (s *Session) Abort(ctx context.Context) error {
-	_, err := s.client.Request("session.abort", sessionAbortRequest{SessionID: s.SessionID})
+	_, err := s.client.RequestContext(ctx, "session.abort", sessionAbortRequest{SessionID: s.SessionID})
 	if err != nil {
 		return fmt.Errorf("failed to abort session: %w", err)
 	}
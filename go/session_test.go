@@ -1,8 +1,18 @@
 package copilot
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/rpc"
 )
 
 func TestSession_On(t *testing.T) {
@@ -119,3 +129,715 @@ func TestSession_On(t *testing.T) {
 		}
 	})
 }
+
+func TestSession_Fork(t *testing.T) {
+	t.Run("forks into a new session with inherited handlers", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.fork", map[string]any{"sessionId": "s2"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.serverCall("permission.request", map[string]any{
+			"sessionId":         "s2",
+			"permissionRequest": map[string]any{"kind": "shell", "toolCallId": "t1"},
+		})
+		log.event("s2", AssistantMessage, map[string]any{"content": "forked", "messageId": "m1"})
+		log.event("s2", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+
+		var permissionCalls atomic.Int32
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: func(PermissionRequest, PermissionInvocation) (PermissionRequestResult, error) {
+				permissionCalls.Add(1)
+				return PermissionRequestResult{Kind: "approved"}, nil
+			},
+			Tools: []Tool{{Name: "echo", Handler: func(ToolInvocation) (ToolResult, error) { return ToolResult{}, nil }}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		fork, err := session.Fork(t.Context())
+		if err != nil {
+			t.Fatalf("Failed to fork session: %v", err)
+		}
+		if fork.SessionID != "s2" {
+			t.Errorf("Expected forked session ID 's2', got %q", fork.SessionID)
+		}
+		if _, ok := fork.getToolHandler("echo"); !ok {
+			t.Error("Expected forked session to inherit tool handlers")
+		}
+
+		response, err := fork.SendAndWait(t.Context(), MessageOptions{Prompt: "Try again"})
+		if err != nil {
+			t.Fatalf("Failed to send to forked session: %v", err)
+		}
+		if response == nil || response.Data.Content == nil || *response.Data.Content != "forked" {
+			t.Errorf("Expected forked response content, got %v", response)
+		}
+		if permissionCalls.Load() != 1 {
+			t.Errorf("Expected inherited permission handler to be called once, got %d", permissionCalls.Load())
+		}
+	})
+}
+
+func TestSession_AutoCompact(t *testing.T) {
+	runAutoCompact := func(t *testing.T, config AutoCompactConfig) SessionEvent {
+		t.Helper()
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", SessionUsageInfo, map[string]any{"currentTokens": 5000, "messagesLength": 12, "tokenLimit": 8000})
+		log.event("s1", SessionIdle, map[string]any{})
+		log.call("session.compaction.compact", map[string]any{"success": true, "messagesRemoved": 8, "tokensRemoved": 3000})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			AutoCompact:         &config,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		completed := make(chan SessionEvent, 1)
+		session.On(func(event SessionEvent) {
+			if event.Type == SessionCompactionComplete && event.Data.Source != nil && *event.Data.Source == AutoCompactSource {
+				completed <- event
+			}
+		})
+
+		if _, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hello"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		select {
+		case event := <-completed:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for compaction to complete")
+			return SessionEvent{}
+		}
+	}
+
+	t.Run("compacts when the token threshold is reached", func(t *testing.T) {
+		event := runAutoCompact(t, AutoCompactConfig{TokenThreshold: 4000})
+		if event.Data.Reason == nil || *event.Data.Reason != "tokens" {
+			t.Errorf("Expected reason 'tokens', got %v", event.Data.Reason)
+		}
+		if event.Data.Success == nil || !*event.Data.Success {
+			t.Error("Expected successful compaction")
+		}
+		if event.Data.MessagesRemoved == nil || *event.Data.MessagesRemoved != 8 {
+			t.Errorf("Expected 8 messages removed, got %v", event.Data.MessagesRemoved)
+		}
+	})
+
+	t.Run("compacts when the message threshold is reached", func(t *testing.T) {
+		event := runAutoCompact(t, AutoCompactConfig{TokenThreshold: 10000, MessageThreshold: 10})
+		if event.Data.Reason == nil || *event.Data.Reason != "messages" {
+			t.Errorf("Expected reason 'messages', got %v", event.Data.Reason)
+		}
+	})
+
+	t.Run("compacts after the idle time elapses", func(t *testing.T) {
+		event := runAutoCompact(t, AutoCompactConfig{IdleTime: 10 * time.Millisecond})
+		if event.Data.Reason == nil || *event.Data.Reason != "idle" {
+			t.Errorf("Expected reason 'idle', got %v", event.Data.Reason)
+		}
+	})
+
+	t.Run("waits for the turn and stops waiting when stopped", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.turn <- struct{}{}
+		compactor := newAutoCompactor(session, AutoCompactConfig{TokenThreshold: 10000})
+		tokens := float64(20000)
+		compactor.observe(SessionEvent{Type: SessionUsageInfo, Data: Data{CurrentTokens: &tokens}})
+		compactor.observe(SessionEvent{Type: SessionIdle})
+
+		time.Sleep(10 * time.Millisecond)
+		compactor.mu.Lock()
+		running := compactor.running
+		compactor.mu.Unlock()
+		if !running {
+			t.Fatal("Expected compaction to wait for the turn")
+		}
+
+		compactor.stop()
+		deadline := time.Now().Add(5 * time.Second)
+		for running && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			compactor.mu.Lock()
+			running = compactor.running
+			compactor.mu.Unlock()
+		}
+		if running {
+			t.Error("Expected stop to cancel the waiting compaction")
+		}
+		if len(session.turn) != 1 {
+			t.Error("Expected the turn to be left to its holder")
+		}
+	})
+
+	t.Run("does not compact below thresholds", func(t *testing.T) {
+		compactor := newAutoCompactor(newSession("s1", nil, ""), AutoCompactConfig{TokenThreshold: 10000})
+		tokens := float64(5000)
+		compactor.observe(SessionEvent{Type: SessionUsageInfo, Data: Data{CurrentTokens: &tokens}})
+		compactor.observe(SessionEvent{Type: SessionIdle})
+
+		compactor.mu.Lock()
+		defer compactor.mu.Unlock()
+		if compactor.running {
+			t.Error("Expected no compaction below the token threshold")
+		}
+	})
+}
+
+func TestSession_SendAndWaitTimeout(t *testing.T) {
+	streamingLog := func() *replayLog {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "Once upon ", "messageId": "m1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "a time", "messageId": "m1"})
+		return log
+	}
+
+	createSession := func(t *testing.T) *Session {
+		t.Helper()
+		client := newPlaybackClientForTest(t, streamingLog(), nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, Streaming: true})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	t.Run("returns partial content with ErrTimeout", func(t *testing.T) {
+		session := createSession(t)
+
+		response, err := session.SendAndWait(t.Context(), MessageOptions{
+			Prompt:           "Tell me a story",
+			Timeout:          100 * time.Millisecond,
+			PartialOnTimeout: true,
+		})
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected ErrTimeout wrapping deadline exceeded, got %v", err)
+		}
+		if response == nil || response.Data.Content == nil || *response.Data.Content != "Once upon a time" {
+			t.Fatalf("Expected partial content 'Once upon a time', got %v", response)
+		}
+		if response.Data.MessageID == nil || *response.Data.MessageID != "m1" {
+			t.Errorf("Expected partial message ID 'm1', got %v", response.Data.MessageID)
+		}
+	})
+
+	t.Run("discards partial content by default", func(t *testing.T) {
+		session := createSession(t)
+
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Tell me a story", Timeout: 100 * time.Millisecond})
+		if !errors.Is(err, ErrTimeout) {
+			t.Fatalf("Expected ErrTimeout, got %v", err)
+		}
+		if response != nil {
+			t.Errorf("Expected no response, got %v", response)
+		}
+	})
+}
+
+func TestSession_Enqueue(t *testing.T) {
+	t.Run("delivers queued messages in order", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "first", "messageId": "m1"})
+		log.event("s1", SessionIdle, map[string]any{})
+		log.call("session.send", map[string]any{"messageId": "m2"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "second", "messageId": "m2"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		first, err := session.Enqueue(t.Context(), MessageOptions{Prompt: "one"})
+		if err != nil {
+			t.Fatalf("Failed to enqueue first message: %v", err)
+		}
+		second, err := session.Enqueue(t.Context(), MessageOptions{Prompt: "two"})
+		if err != nil {
+			t.Fatalf("Failed to enqueue second message: %v", err)
+		}
+
+		for i, tc := range []struct {
+			message  *QueuedMessage
+			expected string
+		}{{first, "first"}, {second, "second"}} {
+			response, err := tc.message.Wait(t.Context())
+			if err != nil {
+				t.Fatalf("Message %d failed: %v", i, err)
+			}
+			if response == nil || response.Data.Content == nil || *response.Data.Content != tc.expected {
+				t.Errorf("Expected message %d response %q, got %v", i, tc.expected, response)
+			}
+		}
+	})
+
+	t.Run("rejects messages when the queue is full", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			MaxQueuedMessages:   1,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		// The first message never goes idle, so it stays in flight
+		inFlight, err := session.Enqueue(ctx, MessageOptions{Prompt: "one"})
+		if err != nil {
+			t.Fatalf("Failed to enqueue first message: %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for session.messagesSent.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		if _, err := session.Enqueue(ctx, MessageOptions{Prompt: "two"}); err != nil {
+			t.Fatalf("Expected second message to fit in the queue, got %v", err)
+		}
+		if _, err := session.Enqueue(ctx, MessageOptions{Prompt: "three"}); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("Expected ErrQueueFull, got %v", err)
+		}
+
+		cancel()
+		if _, err := inFlight.Wait(t.Context()); err == nil {
+			t.Error("Expected in-flight message to fail after cancellation")
+		}
+	})
+
+	t.Run("completes every message enqueued concurrently with Destroy", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.destroy", map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			MaxQueuedMessages:   1000,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		// Messages delivered before Destroy fail on their cancelled context
+		// without being sent. Each goroutine enqueues until the session is
+		// destroyed, so that some calls race with Destroy.
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		var mu sync.Mutex
+		var accepted []*QueuedMessage
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					message, err := session.Enqueue(ctx, MessageOptions{Prompt: "hi"})
+					if errors.Is(err, ErrQueueFull) {
+						continue
+					}
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					accepted = append(accepted, message)
+					mu.Unlock()
+				}
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := session.Destroy(); err != nil {
+			t.Fatalf("Failed to destroy session: %v", err)
+		}
+		wg.Wait()
+
+		timeout := time.After(5 * time.Second)
+		for _, message := range accepted {
+			select {
+			case <-message.Done():
+			case <-timeout:
+				t.Fatal("Expected every accepted message to complete")
+			}
+		}
+		if _, err := session.Enqueue(t.Context(), MessageOptions{Prompt: "late"}); err == nil {
+			t.Error("Expected Enqueue to fail after Destroy")
+		}
+	})
+}
+
+func TestSessionRpc_Call(t *testing.T) {
+	t.Run("calls methods without typed bindings", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.todos.list", map[string]any{"items": []string{"write tests"}})
+		log.call("session.todos.clear", map[string]any{})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var wire []string
+		client.client.AddFrameObserver(func(direction jsonrpc2.FrameDirection, data []byte) {
+			if direction == jsonrpc2.FrameSent && strings.Contains(string(data), "session.todos.list") {
+				wire = append(wire, string(data))
+			}
+		})
+
+		var result struct {
+			Items []string `json:"items"`
+		}
+		filter := map[string]any{"status": "open", "after": uint64(1<<63 + 1)}
+		if err := session.RPC.Call(t.Context(), "session.todos.list", filter, &result); err != nil {
+			t.Fatalf("Failed to call method: %v", err)
+		}
+		if len(result.Items) != 1 || result.Items[0] != "write tests" {
+			t.Errorf("Expected result to be decoded, got %+v", result)
+		}
+		if len(wire) != 1 || !strings.Contains(wire[0], `"after":9223372036854775809`) {
+			t.Errorf("Expected large integers to be sent exactly, got %v", wire)
+		}
+		if err := session.RPC.Call(t.Context(), "session.todos.clear", nil, nil); err != nil {
+			t.Fatalf("Failed to call method without params: %v", err)
+		}
+
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to parse recorded log: %v", err)
+		}
+		var params []map[string]any
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if strings.HasPrefix(message.Method, "session.todos.") {
+				params = append(params, message.Params)
+			}
+		}
+		if len(params) != 2 {
+			t.Fatalf("Expected 2 raw calls, got %d", len(params))
+		}
+		if params[0]["sessionId"] != "s1" || params[0]["status"] != "open" {
+			t.Errorf("Expected session ID to be added to params, got %v", params[0])
+		}
+		if params[1]["sessionId"] != "s1" {
+			t.Errorf("Expected session ID without params, got %v", params[1])
+		}
+	})
+
+	t.Run("rejects params that are not objects", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		err := session.RPC.Call(t.Context(), "session.todos.list", []string{"open"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "JSON object") {
+			t.Errorf("Expected params error, got %v", err)
+		}
+	})
+}
+
+func TestSessionRpc_FeedbackSubmit(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("session.feedback.submit", map[string]any{"recorded": true})
+	log.call("session.feedback.submit", map[string]any{"recorded": true})
+
+	var recorded bytes.Buffer
+	client := newPlaybackClientForTest(t, log, &recorded)
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	comment := "Cited the wrong file"
+	result, err := session.RPC.Feedback.Submit(t.Context(), &rpc.SessionFeedbackSubmitParams{MessageID: "m1", Rating: rpc.RatingNegative, Comment: &comment})
+	if err != nil || !result.Recorded {
+		t.Fatalf("Unexpected result: %+v, %v", result, err)
+	}
+	if _, err := session.RPC.Feedback.Submit(t.Context(), &rpc.SessionFeedbackSubmitParams{MessageID: "m2", Rating: rpc.RatingPositive}); err != nil {
+		t.Fatalf("Failed to submit feedback: %v", err)
+	}
+
+	records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to parse recorded log: %v", err)
+	}
+	var params []map[string]any
+	for _, record := range records {
+		var message struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		json.Unmarshal(record.Message, &message)
+		if message.Method == "session.feedback.submit" {
+			params = append(params, message.Params)
+		}
+	}
+	if len(params) != 2 {
+		t.Fatalf("Expected 2 feedback calls, got %d", len(params))
+	}
+	if params[0]["messageId"] != "m1" || params[0]["rating"] != "negative" || params[0]["comment"] != comment {
+		t.Errorf("Unexpected params: %v", params[0])
+	}
+	if _, ok := params[1]["comment"]; ok || params[1]["rating"] != "positive" {
+		t.Errorf("Expected no comment for a bare rating, got %v", params[1])
+	}
+}
+
+func TestSession_OnBeforeSend(t *testing.T) {
+	t.Run("rewrites and blocks outgoing messages", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		errBlocked := errors.New("prompt mentions a disallowed project")
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			OnBeforeSend: func(options *MessageOptions) error {
+				if strings.Contains(options.Prompt, "project-x") {
+					return errBlocked
+				}
+				options.Prompt = strings.ReplaceAll(options.Prompt, "hunter2", "[REDACTED]") + "\nFollow the company policy."
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Tell me about project-x"}); !errors.Is(err, errBlocked) {
+			t.Errorf("Expected blocked message error, got %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "My password is hunter2"}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+
+		records, err := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to parse recorded log: %v", err)
+		}
+		var prompts []string
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+				Params struct {
+					Prompt string `json:"prompt"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.send" {
+				prompts = append(prompts, message.Params.Prompt)
+			}
+		}
+		if len(prompts) != 1 || prompts[0] != "My password is [REDACTED]\nFollow the company policy." {
+			t.Errorf("Expected only the rewritten prompt to be sent, got %q", prompts)
+		}
+	})
+}
+
+func TestSession_OnBeforeDeliver(t *testing.T) {
+	redact := func(_ SessionEventType, content string) string {
+		return strings.ReplaceAll(content, "ghp_secret", "[REDACTED]")
+	}
+
+	t.Run("filters SendAndWait results and streamed deltas", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+		log.event("s1", AssistantMessageDelta, map[string]any{"deltaContent": "token ghp_secret", "messageId": "m1"})
+		log.event("s1", AssistantMessage, map[string]any{"content": "Your token is ghp_secret", "messageId": "m1"})
+		log.event("s1", SessionIdle, map[string]any{})
+
+		client := newPlaybackClientForTest(t, log, nil)
+		var seen []SessionEventType
+		session, err := client.CreateSession(t.Context(), &SessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			OnBeforeDeliver: []OutputFilter{
+				func(eventType SessionEventType, content string) string {
+					seen = append(seen, eventType)
+					return content
+				},
+				redact,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		var deltas []string
+		session.On(func(event SessionEvent) {
+			if event.Type == AssistantMessageDelta {
+				deltas = append(deltas, *event.Data.DeltaContent)
+			}
+		})
+
+		response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "What is my token?"})
+		if err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		if response == nil || *response.Data.Content != "Your token is [REDACTED]" {
+			t.Errorf("Expected redacted response, got %v", response)
+		}
+		if len(deltas) != 1 || deltas[0] != "token [REDACTED]" {
+			t.Errorf("Expected redacted delta, got %q", deltas)
+		}
+		if len(seen) != 2 || seen[0] != AssistantMessageDelta || seen[1] != AssistantMessage {
+			t.Errorf("Expected filters to see the delta then the message, got %v", seen)
+		}
+	})
+
+	t.Run("leaves other events alone", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.outputFilters = []OutputFilter{redact}
+		content := "ghp_secret"
+		event := SessionEvent{Type: UserMessage, Data: Data{Content: &content}}
+		session.filterOutput(&event)
+		if *event.Data.Content != "ghp_secret" {
+			t.Errorf("Expected user message to be unchanged, got %q", *event.Data.Content)
+		}
+	})
+}
+
+func TestSession_Concurrency(t *testing.T) {
+	t.Run("serializes concurrent SendAndWait calls", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		for _, reply := range []string{"first", "second", "third"} {
+			log.call("session.send", map[string]any{"messageId": reply})
+			log.event("s1", AssistantMessage, map[string]any{"content": reply, "messageId": reply})
+			log.event("s1", SessionIdle, map[string]any{})
+		}
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		responses := make(chan string, 3)
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hello"})
+				if err != nil || response == nil {
+					t.Errorf("SendAndWait failed: %v", err)
+					return
+				}
+				responses <- *response.Data.Content
+			}()
+		}
+		wg.Wait()
+		close(responses)
+
+		seen := map[string]bool{}
+		for response := range responses {
+			seen[response] = true
+		}
+		if len(seen) != 3 {
+			t.Errorf("Expected each call to receive its own response, got %v", seen)
+		}
+		if session.busy.Load() {
+			t.Error("Expected session to be idle after all turns")
+		}
+	})
+
+	t.Run("times out waiting for the previous turn", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		session.turn <- struct{}{}
+		_, err := session.SendAndWait(t.Context(), MessageOptions{Prompt: "Hello", Timeout: 10 * time.Millisecond})
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+	})
+
+	t.Run("destroy is idempotent and blocks later sends", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("session.destroy", map[string]any{})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := session.Destroy(); err != nil {
+					t.Errorf("Destroy failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "Hello"}); err == nil || !strings.Contains(err.Error(), "destroyed") {
+			t.Errorf("Expected send to a destroyed session to fail, got %v", err)
+		}
+	})
+
+	t.Run("tracks the current agent", func(t *testing.T) {
+		session := newSession("s1", nil, "")
+		if agent := session.CurrentAgent(); agent != "" {
+			t.Errorf("Expected no agent, got %q", agent)
+		}
+
+		var wg sync.WaitGroup
+		for _, name := range []string{"reviewer", "planner"} {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				session.dispatchEvent(SessionEvent{Type: SubagentSelected, Data: Data{AgentName: &name}})
+			}()
+			go func() {
+				defer wg.Done()
+				session.CurrentAgent()
+			}()
+		}
+		wg.Wait()
+		if agent := session.CurrentAgent(); agent != "reviewer" && agent != "planner" {
+			t.Errorf("Expected a selected agent, got %q", agent)
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sharedCLIs holds the CLI processes shared by clients with ClientOptions.SharedCLI.
+var sharedCLIs = &cliManager{spawn: spawnSharedCLI}
+
+// cliManager reference-counts CLI processes shared by the clients of this
+// process, keyed by the options that affect how the CLI is launched.
+type cliManager struct {
+	spawn func(c *Client) (*sharedCLI, error)
+
+	mu       sync.Mutex
+	entries  map[string]*sharedCLI
+	spawning map[string]*cliSpawn // spawns in progress, by key
+}
+
+// cliSpawn is a spawn in progress that clients with the same key wait for.
+type cliSpawn struct {
+	done chan struct{} // closed once the spawn finished
+	err  error         // why the spawn failed, set before done is closed
+}
+
+// sharedCLI is a CLI process listening on TCP that one or more clients connect to.
+type sharedCLI struct {
+	key    string
+	port   int
+	pid    int
+	refs   int           // guarded by cliManager.mu
+	exited chan struct{} // closed when the process exits
+	err    error         // why the process exited, set before exited is closed
+	stop   func()        // kills the process
+
+	mu      sync.Mutex
+	clients []*Client // clients holding a reference, which receive its output and exit
+}
+
+// alive reports whether the shared process is still running.
+func (s *sharedCLI) alive() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// attach makes c receive the stderr output and the exit of the process.
+func (s *sharedCLI) attach(c *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = append(s.clients, c)
+}
+
+// detach stops reporting the process to c.
+func (s *sharedCLI) detach(c *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = slices.DeleteFunc(s.clients, func(client *Client) bool { return client == c })
+}
+
+func (s *sharedCLI) attached() []*Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.clients)
+}
+
+// log passes a line of the process's stderr to every attached client, as if
+// the client had spawned the process itself.
+func (s *sharedCLI) log(record CLILogRecord) {
+	for _, c := range s.attached() {
+		c.diagnostics.Write([]byte(record.Raw + "\n"))
+		c.emitCLILog(record)
+	}
+}
+
+// limitExceeded passes a ResourceLimits violation to every attached client
+// with an OnLimitExceeded callback.
+func (s *sharedCLI) limitExceeded(event ResourceLimitEvent) {
+	for _, c := range s.attached() {
+		if c.options.ResourceLimits != nil && c.options.ResourceLimits.OnLimitExceeded != nil {
+			c.options.ResourceLimits.OnLimitExceeded(event)
+		}
+	}
+}
+
+// exit records why the process exited and reports it to every attached
+// client, capturing diagnostics for clients that are not stopping.
+func (s *sharedCLI) exit(waitErr error) {
+	if waitErr != nil {
+		s.err = fmt.Errorf("CLI process exited: %v", waitErr)
+	} else {
+		s.err = fmt.Errorf("CLI process exited unexpectedly")
+	}
+	close(s.exited)
+	for _, c := range s.attached() {
+		c.options.Logger.Info("shared CLI process exited", "pid", s.pid, "error", waitErr)
+		if !c.stopping.Load() {
+			c.captureDiagnostics(s.err)
+		}
+	}
+}
+
+// sharedCLIKey identifies the CLIs that clients with these options can share.
+func sharedCLIKey(options ClientOptions) string {
+	var limits *sharedCLILimits
+	if l := options.ResourceLimits; l != nil {
+		limits = &sharedCLILimits{l.MaxMemory, l.MaxAddressSpace, l.MaxProcesses, l.Nice, l.Interval}
+	}
+	key, _ := json.Marshal(struct {
+		CLIPath         string
+		CLIArgs         []string
+		Cwd             string
+		Env             []string
+		LogLevel        string
+		Port            int
+		GitHubToken     string
+		UseLoggedInUser *bool
+		GitHubHost      string
+		ResourceLimits  *sharedCLILimits
+	}{
+		options.CLIPath,
+		options.CLIArgs,
+		options.Cwd,
+		options.Env,
+		options.LogLevel,
+		options.Port,
+		options.GitHubToken,
+		options.UseLoggedInUser,
+		options.GitHubHost,
+		limits,
+	})
+	return string(key)
+}
+
+// sharedCLILimits are the fields of ResourceLimits that sharedCLIKey covers;
+// OnLimitExceeded is called for every attached client instead.
+type sharedCLILimits struct {
+	MaxMemory       int64
+	MaxAddressSpace int64
+	MaxProcesses    int
+	Nice            int
+	Interval        time.Duration
+}
+
+// acquire returns a running CLI for c's options, spawning one if no client
+// holds one or the previous one exited. Clients that start while a CLI with
+// their key is being spawned wait for it and share it, without holding the
+// lock, so spawns for other keys are not blocked.
+func (m *cliManager) acquire(c *Client) (*sharedCLI, error) {
+	key := sharedCLIKey(c.options)
+
+	m.mu.Lock()
+	for {
+		if cli, ok := m.entries[key]; ok && cli.alive() {
+			cli.refs++
+			cli.attach(c)
+			refs := cli.refs
+			m.mu.Unlock()
+			c.options.Logger.Info("using shared CLI process", "pid", cli.pid, "clients", refs)
+			return cli, nil
+		}
+		spawn, ok := m.spawning[key]
+		if !ok {
+			break
+		}
+		m.mu.Unlock()
+		<-spawn.done
+		if spawn.err != nil {
+			return nil, spawn.err
+		}
+		m.mu.Lock()
+	}
+	spawn := &cliSpawn{done: make(chan struct{})}
+	if m.spawning == nil {
+		m.spawning = make(map[string]*cliSpawn)
+	}
+	m.spawning[key] = spawn
+	m.mu.Unlock()
+
+	cli, err := m.spawn(c)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.spawning, key)
+	spawn.err = err
+	close(spawn.done)
+	if err != nil {
+		return nil, err
+	}
+	cli.key = key
+	cli.refs = 1
+	cli.attach(c)
+	if m.entries == nil {
+		m.entries = make(map[string]*sharedCLI)
+	}
+	m.entries[key] = cli
+	return cli, nil
+}
+
+// release drops c's reference to cli and stops it once no client holds one.
+func (m *cliManager) release(cli *sharedCLI, c *Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cli.detach(c)
+	cli.refs--
+	if cli.refs > 0 {
+		return
+	}
+	// A replacement may have been spawned after this process exited
+	if m.entries[cli.key] == cli {
+		delete(m.entries, cli.key)
+	}
+	cli.stop()
+}
+
+// spawnSharedCLI starts a CLI in TCP mode with c's options. The process is not
+// tied to the context or the lifetime of any one client: it is started by a
+// client of its own with the options that sharedCLIKey covers, and its output
+// and exit are reported to the clients attached to it.
+func spawnSharedCLI(c *Client) (*sharedCLI, error) {
+	shared := &sharedCLI{exited: make(chan struct{})}
+	var limits *ResourceLimits
+	if c.options.ResourceLimits != nil {
+		copied := *c.options.ResourceLimits
+		copied.OnLimitExceeded = shared.limitExceeded
+		limits = &copied
+	}
+	spawner := NewClient(&ClientOptions{
+		CLIPath:         c.options.CLIPath,
+		CLIArgs:         c.options.CLIArgs,
+		Cwd:             c.options.Cwd,
+		Env:             c.options.Env,
+		LogLevel:        c.options.LogLevel,
+		Port:            c.options.Port,
+		GitHubToken:     c.options.GitHubToken,
+		UseLoggedInUser: c.options.UseLoggedInUser,
+		GitHubHost:      c.options.GitHubHost,
+		SharedCLI:       true,
+		ResourceLimits:  limits,
+		Logger:          c.options.Logger,
+		Redactor:        c.options.Redactor,
+	})
+	spawner.cliOutput = shared.log
+	if err := spawner.startCLIServer(context.Background()); err != nil {
+		if spawner.process != nil && spawner.process.Process != nil {
+			spawner.process.Process.Kill()
+		}
+		return nil, err
+	}
+
+	process := spawner.process
+	shared.port = spawner.actualPort
+	shared.pid = process.Process.Pid
+	shared.stop = func() {
+		spawner.stopping.Store(true)
+		process.Process.Kill()
+	}
+	go func() {
+		shared.exit(process.Wait())
+	}()
+	return shared, nil
+}
+
+// acquireSharedCLI takes a reference to the shared CLI for the client's
+// options, keeping the one it holds if that is still running.
+func (c *Client) acquireSharedCLI() error {
+	if c.sharedCLI != nil {
+		if c.sharedCLI.alive() {
+			c.actualPort = c.sharedCLI.port
+			return nil
+		}
+		c.releaseSharedCLI()
+	}
+	cli, err := sharedCLIs.acquire(c)
+	if err != nil {
+		return err
+	}
+	c.sharedCLI = cli
+	c.actualPort = cli.port
+	return nil
+}
+
+// releaseSharedCLI drops the client's reference to its shared CLI.
+func (c *Client) releaseSharedCLI() {
+	sharedCLIs.release(c.sharedCLI, c)
+	c.sharedCLI = nil
+}
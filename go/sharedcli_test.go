@@ -0,0 +1,281 @@
+package copilot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCLIs returns a cliManager whose spawned CLIs are recorded instead of started.
+func fakeCLIs() (*cliManager, *[]*sharedCLI, *int) {
+	var spawned []*sharedCLI
+	stopped := 0
+	m := &cliManager{spawn: func(c *Client) (*sharedCLI, error) {
+		cli := &sharedCLI{
+			port:   4000 + len(spawned),
+			exited: make(chan struct{}),
+			stop:   func() { stopped++ },
+		}
+		spawned = append(spawned, cli)
+		return cli, nil
+	}}
+	return m, &spawned, &stopped
+}
+
+func TestCLIManager(t *testing.T) {
+	t.Run("shares one CLI among clients with the same options", func(t *testing.T) {
+		m, spawned, stopped := fakeCLIs()
+		clientA, clientB := NewClient(&ClientOptions{SharedCLI: true}), NewClient(&ClientOptions{SharedCLI: true})
+		a, err := m.acquire(clientA)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		b, _ := m.acquire(clientB)
+		if a != b || len(*spawned) != 1 || a.refs != 2 || len(a.attached()) != 2 {
+			t.Fatalf("Expected one CLI with two references, spawned %d, refs %d", len(*spawned), a.refs)
+		}
+
+		m.release(a, clientA)
+		if *stopped != 0 || !slices.Equal(a.attached(), []*Client{clientB}) {
+			t.Error("Expected the CLI to keep running for the remaining client")
+		}
+		m.release(b, clientB)
+		if *stopped != 1 || len(m.entries) != 0 {
+			t.Errorf("Expected the last release to stop the CLI, stopped %d, entries %d", *stopped, len(m.entries))
+		}
+	})
+
+	t.Run("spawns separate CLIs for different options", func(t *testing.T) {
+		m, spawned, _ := fakeCLIs()
+		m.acquire(NewClient(&ClientOptions{SharedCLI: true, GitHubToken: "a"}))
+		m.acquire(NewClient(&ClientOptions{SharedCLI: true, GitHubToken: "b"}))
+		if len(*spawned) != 2 {
+			t.Errorf("Expected two CLIs, got %d", len(*spawned))
+		}
+	})
+
+	t.Run("spawns separate CLIs for different resource limits", func(t *testing.T) {
+		m, spawned, _ := fakeCLIs()
+		m.acquire(NewClient(&ClientOptions{SharedCLI: true, ResourceLimits: &ResourceLimits{MaxMemory: 1 << 30}}))
+		m.acquire(NewClient(&ClientOptions{SharedCLI: true, ResourceLimits: &ResourceLimits{MaxMemory: 2 << 30}}))
+		m.acquire(NewClient(&ClientOptions{SharedCLI: true}))
+		if len(*spawned) != 3 {
+			t.Errorf("Expected three CLIs, got %d", len(*spawned))
+		}
+	})
+
+	t.Run("spawns other keys while a spawn is in progress", func(t *testing.T) {
+		release := make(chan struct{})
+		var mu sync.Mutex
+		spawns := map[string]int{}
+		m := &cliManager{spawn: func(c *Client) (*sharedCLI, error) {
+			mu.Lock()
+			spawns[c.options.GitHubToken]++
+			mu.Unlock()
+			if c.options.GitHubToken == "slow" {
+				<-release
+			}
+			return &sharedCLI{exited: make(chan struct{}), stop: func() {}}, nil
+		}}
+
+		results := make(chan *sharedCLI, 2)
+		for range 2 {
+			go func() {
+				cli, _ := m.acquire(NewClient(&ClientOptions{SharedCLI: true, GitHubToken: "slow"}))
+				results <- cli
+			}()
+		}
+		// A client with another key is not blocked by the slow spawn
+		if _, err := m.acquire(NewClient(&ClientOptions{SharedCLI: true, GitHubToken: "fast"})); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		close(release)
+		a, b := <-results, <-results
+		if a != b || a.refs != 2 {
+			t.Errorf("Expected the clients of the slow key to share one CLI, refs %d", a.refs)
+		}
+		if spawns["slow"] != 1 || spawns["fast"] != 1 {
+			t.Errorf("Expected one spawn per key, got %v", spawns)
+		}
+	})
+
+	t.Run("fails the clients waiting for a spawn that failed", func(t *testing.T) {
+		release := make(chan struct{})
+		m := &cliManager{spawn: func(c *Client) (*sharedCLI, error) {
+			<-release
+			return nil, errors.New("spawn failed")
+		}}
+		errs := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := m.acquire(NewClient(&ClientOptions{SharedCLI: true}))
+				errs <- err
+			}()
+		}
+		close(release)
+		for range 2 {
+			if err := <-errs; err == nil || err.Error() != "spawn failed" {
+				t.Errorf("Expected the spawn error, got %v", err)
+			}
+		}
+	})
+
+	t.Run("replaces a CLI that exited", func(t *testing.T) {
+		m, spawned, _ := fakeCLIs()
+		client := NewClient(&ClientOptions{SharedCLI: true})
+		old, _ := m.acquire(client)
+		close(old.exited)
+		replacement, _ := m.acquire(NewClient(&ClientOptions{SharedCLI: true}))
+		if replacement == old || len(*spawned) != 2 {
+			t.Fatalf("Expected a new CLI, spawned %d", len(*spawned))
+		}
+
+		m.release(old, client)
+		if m.entries[replacement.key] != replacement {
+			t.Error("Expected releasing the exited CLI to keep its replacement")
+		}
+	})
+}
+
+func TestClient_SharedCLI(t *testing.T) {
+	m, spawned, stopped := fakeCLIs()
+	original := sharedCLIs
+	sharedCLIs = m
+	t.Cleanup(func() { sharedCLIs = original })
+
+	client := NewClient(&ClientOptions{SharedCLI: true})
+	if client.useStdio {
+		t.Error("Expected SharedCLI to use TCP")
+	}
+	if err := client.acquireSharedCLI(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Acquiring again, as a restart does, keeps the same reference
+	if err := client.acquireSharedCLI(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(*spawned) != 1 || client.sharedCLI.refs != 1 || client.actualPort != 4000 {
+		t.Fatalf("Unexpected state: spawned %d, refs %d, port %d", len(*spawned), client.sharedCLI.refs, client.actualPort)
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *stopped != 1 || client.sharedCLI != nil {
+		t.Errorf("Expected Stop to release the CLI, stopped %d", *stopped)
+	}
+}
+
+func TestSharedCLI_LimitExceeded(t *testing.T) {
+	var got []string
+	callback := func(name string) *ResourceLimits {
+		return &ResourceLimits{MaxMemory: 1 << 30, OnLimitExceeded: func(ResourceLimitEvent) { got = append(got, name) }}
+	}
+	shared := &sharedCLI{}
+	shared.attach(NewClient(&ClientOptions{SharedCLI: true, ResourceLimits: callback("a")}))
+	shared.attach(NewClient(&ClientOptions{SharedCLI: true, ResourceLimits: callback("b")}))
+
+	shared.limitExceeded(ResourceLimitEvent{Limit: ResourceLimitMemory})
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected every attached client to be told, got %v", got)
+	}
+}
+
+func TestNewClient_SharedCLIExclusive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for SharedCLI with CLIUrl")
+		}
+	}()
+	NewClient(&ClientOptions{SharedCLI: true, CLIUrl: "localhost:8080"})
+}
+
+func TestSpawnSharedCLI(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	cli := filepath.Join(t.TempDir(), "copilot")
+	script := "#!/bin/sh\necho 'listening on port 4321'\nexec sleep 30\n"
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	shared, err := spawnSharedCLI(NewClient(&ClientOptions{CLIPath: cli, SharedCLI: true}))
+	if err != nil {
+		t.Fatalf("Failed to spawn: %v", err)
+	}
+	if shared.port != 4321 || !shared.alive() {
+		t.Errorf("Expected a running CLI on port 4321, got port %d", shared.port)
+	}
+
+	shared.stop()
+	select {
+	case <-shared.exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the CLI to exit after stop")
+	}
+}
+
+func TestSharedCLI_FansOut(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the CLI")
+	}
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "copilot")
+	// Report the port, then fail once the test has attached its clients
+	script := "#!/bin/sh\necho 'listening on port 4321'\nwhile [ ! -f \"$0.crash\" ]; do sleep 0.05; done\necho 'error: out of memory' >&2\nexit 3\n"
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	var mu sync.Mutex
+	logged := map[string][]string{}
+	newClient := func(name string) *Client {
+		return NewClient(&ClientOptions{CLIPath: cli, SharedCLI: true, DiagnosticsDir: dir, OnCLILog: func(record CLILogRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			logged[name] = append(logged[name], record.Message)
+		}})
+	}
+	a, b := newClient("a"), newClient("b")
+	m := &cliManager{spawn: spawnSharedCLI}
+	shared, err := m.acquire(a)
+	if err != nil {
+		t.Fatalf("Failed to spawn: %v", err)
+	}
+	t.Cleanup(shared.stop)
+	if again, _ := m.acquire(b); again != shared {
+		t.Fatal("Expected the second client to share the CLI")
+	}
+
+	os.WriteFile(cli+".crash", nil, 0644)
+	select {
+	case <-shared.exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the CLI to exit")
+	}
+	if shared.err == nil || !strings.Contains(shared.err.Error(), "exit status 3") {
+		t.Errorf("Expected the exit status, got %v", shared.err)
+	}
+	for name, client := range map[string]*Client{"a": a, "b": b} {
+		mu.Lock()
+		got := logged[name]
+		mu.Unlock()
+		if !slices.Equal(got, []string{"out of memory"}) {
+			t.Errorf("Expected client %s to receive the CLI's stderr, got %v", name, got)
+		}
+		// Diagnostics are captured after the exit is signalled
+		for deadline := time.Now().Add(5 * time.Second); client.LastDiagnostics() == nil && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if d := client.LastDiagnostics(); d == nil || !slices.Contains(d.Stderr, "error: out of memory") {
+			t.Errorf("Expected client %s to capture diagnostics with the CLI's stderr, got %+v", name, d)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package copilot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// socketPath returns a short socket path, since unix socket paths are limited
+// to about 100 bytes on some platforms.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "copilot")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "cli.sock")
+}
+
+// serveFakeCLI answers ping requests on every connection to listener.
+func serveFakeCLI(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				var length int
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				fmt.Sscanf(line, "Content-Length: %d", &length)
+				reader.ReadString('\n')
+				body := make([]byte, length)
+				if _, err := io.ReadFull(reader, body); err != nil {
+					return
+				}
+				var request struct {
+					ID     json.RawMessage `json:"id"`
+					Method string          `json:"method"`
+				}
+				json.Unmarshal(body, &request)
+				if request.Method != "ping" {
+					continue
+				}
+				response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": request.ID, "result": map[string]any{
+					"message": "pong", "timestamp": 1, "protocolVersion": GetSdkProtocolVersion(),
+				}})
+				fmt.Fprintf(conn, "Content-Length: %d\r\n\r\n%s", len(response), response)
+			}
+		}()
+	}
+}
+
+func TestClient_SocketPath(t *testing.T) {
+	t.Run("connects to a server over a unix socket", func(t *testing.T) {
+		path := socketPath(t)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			t.Skipf("Unix sockets not supported: %v", err)
+		}
+		defer listener.Close()
+		go serveFakeCLI(listener)
+
+		for range 2 {
+			client := NewClient(&ClientOptions{SocketPath: path})
+			if err := client.Start(t.Context()); err != nil {
+				t.Fatalf("Failed to connect: %v", err)
+			}
+			if _, err := client.Ping(t.Context(), "hi"); err != nil {
+				t.Errorf("Failed to ping: %v", err)
+			}
+			client.Stop()
+		}
+	})
+
+	t.Run("rejects other transports", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "SocketPath is mutually exclusive") {
+				t.Errorf("Expected a panic, got %v", r)
+			}
+		}()
+		NewClient(&ClientOptions{SocketPath: "/tmp/cli.sock", CLIUrl: "localhost:8080"})
+	})
+}
@@ -0,0 +1,61 @@
+package copilot
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sshCommand builds the ssh invocation that runs the CLI with the given
+// arguments on the host described by config.
+//
+// The GitHub token, when set, is forwarded with SendEnv rather than placed on the
+// remote command line, so the remote sshd must accept COPILOT_SDK_AUTH_TOKEN
+// (AcceptEnv) for token authentication to work.
+func sshCommand(config *SSHConfig, cliPath string, cliArgs []string, cwd string, forwardEnv []string) (string, []string) {
+	command := config.Command
+	if command == "" {
+		command = "ssh"
+	}
+
+	// -T: no pseudo-terminal, so the stdio protocol passes through untouched
+	args := []string{"-T", "-o", "BatchMode=yes"}
+	if config.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(config.Port))
+	}
+	if config.KeyFile != "" {
+		args = append(args, "-i", config.KeyFile)
+	}
+	for _, name := range forwardEnv {
+		args = append(args, "-o", "SendEnv="+name)
+	}
+	args = append(args, config.Args...)
+
+	destination := config.Host
+	if config.User != "" {
+		destination = config.User + "@" + config.Host
+	}
+	// "--" ends ssh's options, so the destination cannot be parsed as one
+	args = append(args, "--", destination)
+
+	remote := make([]string, 0, len(cliArgs)+1)
+	remote = append(remote, shellQuote(cliPath))
+	for _, arg := range cliArgs {
+		remote = append(remote, shellQuote(arg))
+	}
+	remoteCommand := "exec " + strings.Join(remote, " ")
+	if cwd != "" {
+		remoteCommand = "cd " + shellQuote(cwd) + " && " + remoteCommand
+	}
+
+	return command, append(args, remoteCommand)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@,+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
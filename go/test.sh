@@ -45,5 +45,10 @@ echo
 
 go test -v ./...
 
+# The gRPC gateway and the Prometheus collector are separate modules
+for module in server metrics; do
+    (cd "$module" && go test -v ./...)
+done
+
 echo
 echo "✅ All tests passed!"
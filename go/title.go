@@ -0,0 +1,197 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeatureSessionTitle is the CLI feature flag, reported by [Client.Capabilities],
+// for conversation titles through the session.title RPCs.
+const FeatureSessionTitle = "sessionTitle"
+
+// maxTitleLength caps the length, in characters, of generated titles.
+const maxTitleLength = 80
+
+// titleInstructions asks the model for a title when the CLI cannot generate one.
+const titleInstructions = "Reply with only a short title for this conversation, " +
+	"like the name of a chat in a list of chats: no quotes, no trailing punctuation."
+
+// Title returns the conversation title, or "" if it has none. It reflects
+// [Session.SetTitle] and the session.title_changed events the session received.
+func (s *Session) Title() string {
+	if title := s.title.Load(); title != nil {
+		return *title
+	}
+	return ""
+}
+
+// SetTitle renames the conversation, e.g. when the user edits its name in a
+// list of chats. A [SessionTitleChanged] event is emitted with the new title.
+//
+// When the CLI does not support [FeatureSessionTitle], the title is kept in
+// this Session object only and the event is dispatched locally.
+func (s *Session) SetTitle(ctx context.Context, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fmt.Errorf("title is required")
+	}
+	supported, err := s.supportsTitle(ctx)
+	if err != nil {
+		return err
+	}
+	if supported {
+		if _, err := s.RPC.Title.Set(ctx, &rpc.SessionTitleSetParams{Title: title}); err != nil {
+			return fmt.Errorf("failed to set title: %w", err)
+		}
+		s.title.Store(&title)
+		return nil
+	}
+	s.title.Store(&title)
+	s.dispatchEvent(SessionEvent{
+		Type:      SessionTitleChanged,
+		Timestamp: time.Now(),
+		Ephemeral: Bool(true),
+		Data:      Data{Title: &title},
+	})
+	return nil
+}
+
+// GenerateTitle asks the model to name the conversation from its history so
+// far, sets the result as the session's title, and returns it.
+//
+// When the CLI does not support [FeatureSessionTitle], the title is requested
+// through session.summarize, which does not add to the conversation, and set
+// with [Session.SetTitle].
+//
+// Example:
+//
+//	if _, err := session.SendAndWait(ctx, copilot.MessageOptions{Prompt: prompt}); err != nil {
+//	    return err
+//	}
+//	title, err := session.GenerateTitle(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	chats.Rename(session.SessionID, title)
+func (s *Session) GenerateTitle(ctx context.Context) (string, error) {
+	supported, err := s.supportsTitle(ctx)
+	if err != nil {
+		return "", err
+	}
+	if supported {
+		result, err := s.RPC.Title.Generate(ctx, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate title: %w", err)
+		}
+		s.title.Store(&result.Title)
+		return result.Title, nil
+	}
+
+	result, err := s.RPC.Summarize(ctx, &rpc.SessionSummarizeParams{
+		Instructions: String(titleInstructions),
+		MaxWords:     Float64(8),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+	title := cleanTitle(result.Summary)
+	if title == "" {
+		return "", fmt.Errorf("failed to generate title: the model returned an empty title")
+	}
+	if err := s.SetTitle(ctx, title); err != nil {
+		return "", err
+	}
+	return title, nil
+}
+
+// supportsTitle reports whether the CLI implements the session.title RPCs.
+func (s *Session) supportsTitle(ctx context.Context) (bool, error) {
+	if s.capabilities == nil {
+		return false, nil
+	}
+	capabilities, err := s.capabilities(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check CLI capabilities: %w", err)
+	}
+	return capabilities.HasFeature(FeatureSessionTitle), nil
+}
+
+// cleanTitle turns a model reply into a title: the first line, without a
+// "Title:" label, surrounding quotes, or a trailing period, and at most
+// maxTitleLength characters.
+func cleanTitle(reply string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+	title = strings.TrimSpace(title)
+	if label, rest, ok := strings.Cut(title, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "title") {
+		title = strings.TrimSpace(rest)
+	}
+	title = strings.Trim(title, "\"'`*“”")
+	title = strings.TrimRight(strings.TrimSpace(title), ".")
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = strings.TrimSpace(string([]rune(title)[:maxTitleLength-1])) + "…"
+	}
+	return title
+}
+
+// autoTitler names a session after its first exchange, for SessionConfig.AutoTitle.
+type autoTitler struct {
+	session *Session
+
+	mu      sync.Mutex
+	replied bool // an assistant message arrived
+	done    bool // a title was set or generation started
+	stopped bool
+}
+
+// observe starts title generation once the first exchange is complete. It is
+// called on the event dispatch path, so generation runs on a separate goroutine.
+func (a *autoTitler) observe(event SessionEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.done || a.stopped {
+		return
+	}
+	switch event.Type {
+	case SessionTitleChanged:
+		a.done = true
+	case AssistantMessage:
+		a.replied = true
+	case SessionIdle:
+		if a.replied {
+			a.done = true
+			go a.generate()
+		}
+	}
+}
+
+func (a *autoTitler) generate() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	title, err := a.session.GenerateTitle(ctx)
+
+	a.mu.Lock()
+	stopped := a.stopped
+	a.mu.Unlock()
+	if stopped {
+		return
+	}
+	if err != nil {
+		a.session.logger.Warn("automatic title generation failed", "error", err)
+		return
+	}
+	a.session.logger.Debug("conversation titled", "title", title)
+}
+
+// stop prevents further title generation.
+func (a *autoTitler) stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopped = true
+}
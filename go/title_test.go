@@ -0,0 +1,137 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanTitle(t *testing.T) {
+	tests := map[string]string{
+		"Fixing the login bug":               "Fixing the login bug",
+		"  \"Fixing the login bug.\"\n":      "Fixing the login bug",
+		"Title: Refactor parser\nBecause...": "Refactor parser",
+		"**Go module setup**":                "Go module setup",
+		strings.Repeat("word ", 30):          strings.TrimSpace(strings.Repeat("word ", 16)) + "…",
+	}
+	for reply, want := range tests {
+		if got := cleanTitle(reply); got != want {
+			t.Errorf("cleanTitle(%q) = %q, want %q", reply, got, want)
+		}
+	}
+}
+
+func TestSession_Title(t *testing.T) {
+	t.Run("uses the CLI when it supports titles", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion(), "features": map[string]bool{FeatureSessionTitle: true}})
+		log.call("session.title.generate", map[string]any{"title": "Login bug"})
+		log.call("session.title.set", map[string]any{})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		title, err := session.GenerateTitle(t.Context())
+		if err != nil || title != "Login bug" || session.Title() != "Login bug" {
+			t.Errorf("Unexpected title %q (%q), %v", title, session.Title(), err)
+		}
+		if err := session.SetTitle(t.Context(), " Auth fixes "); err != nil {
+			t.Fatalf("Failed to set title: %v", err)
+		}
+		if session.Title() != "Auth fixes" {
+			t.Errorf("Expected the new title, got %q", session.Title())
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.title.set" && message.Params["title"] != "Auth fixes" {
+				t.Errorf("Unexpected set request: %s", record.Message)
+			}
+		}
+	})
+
+	t.Run("falls back to summarize and local events", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+		log.call("session.summarize", map[string]any{"summary": "Title: \"Fixing the login bug.\"", "messagesSummarized": 2})
+		client := newPlaybackClientForTest(t, log, nil)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		var events []string
+		session.On(func(event SessionEvent) {
+			if event.Type == SessionTitleChanged {
+				events = append(events, *event.Data.Title)
+			}
+		})
+
+		title, err := session.GenerateTitle(t.Context())
+		if err != nil || title != "Fixing the login bug" {
+			t.Fatalf("Unexpected title %q, %v", title, err)
+		}
+		if len(events) != 1 || events[0] != title || session.Title() != title {
+			t.Errorf("Expected a local title event, got %v", events)
+		}
+		if err := session.SetTitle(t.Context(), "  "); err == nil {
+			t.Error("Expected an empty title to be rejected")
+		}
+	})
+}
+
+func TestSession_AutoTitle(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+	log.call("session.create", map[string]any{"sessionId": "s1"})
+	log.call("capabilities.get", map[string]any{"version": "1.0.0", "protocolVersion": GetSdkProtocolVersion()})
+	log.call("session.summarize", map[string]any{"summary": "Weather in Paris", "messagesSummarized": 2})
+	client := newPlaybackClientForTest(t, log, nil)
+	session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll, AutoTitle: true})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	titled := make(chan string, 2)
+	session.On(func(event SessionEvent) {
+		if event.Type == SessionTitleChanged {
+			titled <- *event.Data.Title
+		}
+	})
+
+	// An idle without a reply does not complete an exchange
+	session.dispatchEvent(SessionEvent{Type: SessionIdle})
+	content := "It is sunny."
+	session.dispatchEvent(SessionEvent{Type: AssistantMessage, Data: Data{Content: &content}})
+	session.dispatchEvent(SessionEvent{Type: SessionIdle})
+
+	select {
+	case title := <-titled:
+		if title != "Weather in Paris" {
+			t.Errorf("Unexpected title %q", title)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the session to be titled after the first exchange")
+	}
+
+	// Later exchanges keep the title
+	session.dispatchEvent(SessionEvent{Type: AssistantMessage, Data: Data{Content: &content}})
+	session.dispatchEvent(SessionEvent{Type: SessionIdle})
+	select {
+	case title := <-titled:
+		t.Errorf("Expected a single title, got another: %q", title)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
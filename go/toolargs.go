@@ -0,0 +1,121 @@
+package copilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// ToolArgumentsError is returned by [DecodeArguments] when the arguments of a
+// tool call do not match the handler's argument type. Returned from a handler,
+// its message tells the model which argument was wrong so it can retry.
+type ToolArgumentsError struct {
+	// ToolName is the name of the called tool.
+	ToolName string
+	// Err describes the mismatch.
+	Err error
+}
+
+func (e *ToolArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for tool %q: %v", e.ToolName, e.Err)
+}
+
+func (e *ToolArgumentsError) Unwrap() error {
+	return e.Err
+}
+
+// argumentSchemas caches the resolved schema of each argument type, keyed by reflect.Type.
+var argumentSchemas sync.Map
+
+// SchemaFor returns the JSON schema of T, for the Parameters of a [Tool] whose
+// handler decodes its arguments with [DecodeArguments]. It is the schema
+// [DefineTool] generates: properties are named by json tags, fields without
+// omitempty or omitzero are required, and a jsonschema tag is used as the
+// property's description. Panics if T cannot be described by a JSON schema.
+func SchemaFor[T any]() map[string]any {
+	return generateSchemaForType(reflect.TypeFor[T]())
+}
+
+// DecodeArguments validates the arguments of a tool call against the schema of
+// T and decodes them into a T. Missing required properties, unknown
+// properties, and values of the wrong type are reported as a
+// [*ToolArgumentsError]. [DefineTool] handlers receive arguments decoded this
+// way; use DecodeArguments in handlers of tools defined with [Tool] directly.
+//
+// Example:
+//
+//	type SearchArgs struct {
+//	    Query string `json:"query" jsonschema:"text to search for"`
+//	    Limit int    `json:"limit,omitempty" jsonschema:"maximum number of results"`
+//	}
+//
+//	tool := copilot.Tool{
+//	    Name:       "search",
+//	    Parameters: copilot.SchemaFor[SearchArgs](),
+//	    Handler: func(inv copilot.ToolInvocation) (copilot.ToolResult, error) {
+//	        args, err := copilot.DecodeArguments[SearchArgs](inv)
+//	        if err != nil {
+//	            return copilot.ToolResult{}, err
+//	        }
+//	        ...
+//	    },
+//	}
+func DecodeArguments[T any](inv ToolInvocation) (T, error) {
+	var args T
+
+	// Normalize the arguments to plain JSON values for validation
+	raw, err := json.Marshal(inv.Arguments)
+	if err != nil {
+		return args, fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	var instance any
+	if err := json.Unmarshal(raw, &instance); err != nil {
+		return args, fmt.Errorf("failed to unmarshal arguments: %w", err)
+	}
+
+	resolved, err := resolvedSchemaFor(reflect.TypeFor[T]())
+	if err != nil {
+		return args, err
+	}
+	if resolved != nil {
+		if instance == nil && resolved.Schema().Type == "object" {
+			// Tools called without arguments get an empty object
+			instance, raw = map[string]any{}, []byte("{}")
+		}
+		if err := resolved.Validate(instance); err != nil {
+			return args, &ToolArgumentsError{ToolName: inv.ToolName, Err: err}
+		}
+	}
+
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return args, &ToolArgumentsError{ToolName: inv.ToolName, Err: err}
+	}
+	return args, nil
+}
+
+// resolvedSchemaFor returns the resolved schema of t, or nil for types without
+// a schema such as any.
+func resolvedSchemaFor(t reflect.Type) (*jsonschema.Resolved, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return nil, nil
+	}
+	if resolved, ok := argumentSchemas.Load(t); ok {
+		return resolved.(*jsonschema.Resolved), nil
+	}
+	schema, err := jsonschema.ForType(t, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema for type %v: %w", t, err)
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve schema for type %v: %w", t, err)
+	}
+	argumentSchemas.Store(t, resolved)
+	return resolved, nil
+}
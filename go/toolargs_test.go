@@ -0,0 +1,82 @@
+package copilot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type searchArgs struct {
+	Query   string   `json:"query" jsonschema:"text to search for"`
+	Limit   int      `json:"limit,omitempty"`
+	Filters []string `json:"filters,omitempty"`
+}
+
+func TestDecodeArguments(t *testing.T) {
+	t.Run("decodes valid arguments", func(t *testing.T) {
+		inv := ToolInvocation{ToolName: "search", Arguments: map[string]any{"query": "race", "limit": float64(5), "filters": []any{"go"}}}
+		args, err := DecodeArguments[searchArgs](inv)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if args.Query != "race" || args.Limit != 5 || len(args.Filters) != 1 {
+			t.Errorf("Unexpected arguments: %+v", args)
+		}
+	})
+
+	t.Run("rejects arguments that do not match the schema", func(t *testing.T) {
+		tests := map[string]any{
+			"missing required":   map[string]any{"limit": float64(5)},
+			"wrong type":         map[string]any{"query": float64(1)},
+			"non-integer":        map[string]any{"query": "race", "limit": 2.5},
+			"unknown property":   map[string]any{"query": "race", "page": float64(2)},
+			"missing arguments":  nil,
+			"not an object":      "race",
+			"wrong element type": map[string]any{"query": "race", "filters": []any{float64(1)}},
+		}
+		for name, arguments := range tests {
+			_, err := DecodeArguments[searchArgs](ToolInvocation{ToolName: "search", Arguments: arguments})
+			var argsErr *ToolArgumentsError
+			if !errors.As(err, &argsErr) || argsErr.ToolName != "search" {
+				t.Errorf("%s: expected a ToolArgumentsError, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("accepts any arguments for untyped handlers", func(t *testing.T) {
+		args, err := DecodeArguments[map[string]any](ToolInvocation{Arguments: map[string]any{"anything": true}})
+		if err != nil || args["anything"] != true {
+			t.Errorf("Unexpected result: %v, %v", args, err)
+		}
+	})
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor[searchArgs]()
+	properties, _ := schema["properties"].(map[string]any)
+	query, _ := properties["query"].(map[string]any)
+	if query["description"] != "text to search for" {
+		t.Errorf("Expected the jsonschema tag as the description, got %v", query)
+	}
+	required, _ := schema["required"].([]any)
+	if len(required) != 1 || required[0] != "query" {
+		t.Errorf("Expected only query to be required, got %v", required)
+	}
+}
+
+func TestDefineTool_InvalidArguments(t *testing.T) {
+	called := false
+	tool := DefineTool("search", "Search the code", func(args searchArgs, inv ToolInvocation) (string, error) {
+		called = true
+		return "", nil
+	})
+
+	client := &Client{}
+	result := client.executeToolCall(ToolInvocation{ToolName: "search", Arguments: map[string]any{"limit": float64(5)}}, tool.Handler)
+	if called {
+		t.Error("Expected the handler not to be called")
+	}
+	if result.ResultType != "failure" || !strings.Contains(result.TextResultForLLM+result.Error, "query") {
+		t.Errorf("Expected a failure naming the missing argument, got %+v", result)
+	}
+}
@@ -0,0 +1,74 @@
+package copilot
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// toolCache holds tool results for a session according to a ToolCacheConfig.
+// Its methods are safe to call on a nil *toolCache, which caches nothing.
+type toolCache struct {
+	config ToolCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]toolCacheEntry
+}
+
+type toolCacheEntry struct {
+	result  ToolResult
+	expires time.Time // zero if the entry does not expire
+}
+
+func newToolCache(config ToolCacheConfig) *toolCache {
+	return &toolCache{config: config, entries: make(map[string]toolCacheEntry)}
+}
+
+// key returns the cache key for invocation, or false if it must not be cached.
+func (c *toolCache) key(invocation ToolInvocation) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	if len(c.config.Tools) > 0 && !slices.Contains(c.config.Tools, invocation.ToolName) {
+		return "", false
+	}
+	if c.config.Key != nil {
+		return c.config.Key(invocation)
+	}
+	// Maps are encoded with sorted keys, so equal arguments produce equal keys
+	arguments, err := json.Marshal(invocation.Arguments)
+	if err != nil {
+		return "", false
+	}
+	return invocation.ToolName + "\x00" + string(arguments), true
+}
+
+// get returns the cached result for key, if present and not expired.
+func (c *toolCache) get(key string) (ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return ToolResult{}, false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return ToolResult{}, false
+	}
+	return entry.result, true
+}
+
+// put caches result under key if the tool call succeeded.
+func (c *toolCache) put(key string, result ToolResult) {
+	if (result.ResultType != "" && result.ResultType != "success") || result.Error != "" {
+		return
+	}
+	entry := toolCacheEntry{result: result}
+	if c.config.TTL > 0 {
+		entry.expires = time.Now().Add(c.config.TTL)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
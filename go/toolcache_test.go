@@ -0,0 +1,115 @@
+package copilot
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestToolCache(t *testing.T) {
+	newCachedClient := func(config ToolCacheConfig, handler ToolHandler) *Client {
+		session := newSession("s1", nil, "")
+		session.registerTools([]Tool{
+			{Name: "read_file", Handler: handler},
+			{Name: "write_file", Handler: handler},
+		})
+		session.toolCache = newToolCache(config)
+		return &Client{sessions: map[string]*Session{"s1": session}}
+	}
+	call := func(client *Client, tool string, args map[string]any, id int) ToolResult {
+		response, _ := client.handleToolCallRequest(toolCallRequest{
+			SessionID:  "s1",
+			ToolCallID: strconv.Itoa(id),
+			ToolName:   tool,
+			Arguments:  args,
+		})
+		return response.Result
+	}
+
+	t.Run("reuses results for identical calls", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newCachedClient(ToolCacheConfig{}, func(inv ToolInvocation) (ToolResult, error) {
+			n := calls.Add(1)
+			return ToolResult{TextResultForLLM: "contents " + strconv.Itoa(int(n)), ResultType: "success"}, nil
+		})
+
+		first := call(client, "read_file", map[string]any{"path": "main.go", "limit": 10}, 1)
+		second := call(client, "read_file", map[string]any{"limit": 10, "path": "main.go"}, 2)
+		other := call(client, "read_file", map[string]any{"path": "go.mod"}, 3)
+
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 handler calls, got %d", calls.Load())
+		}
+		if second.TextResultForLLM != first.TextResultForLLM {
+			t.Errorf("Expected cached result %q, got %q", first.TextResultForLLM, second.TextResultForLLM)
+		}
+		if other.TextResultForLLM == first.TextResultForLLM {
+			t.Error("Expected different arguments to miss the cache")
+		}
+	})
+
+	t.Run("expires entries after the TTL", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newCachedClient(ToolCacheConfig{TTL: 20 * time.Millisecond}, func(inv ToolInvocation) (ToolResult, error) {
+			calls.Add(1)
+			return ToolResult{ResultType: "success"}, nil
+		})
+
+		call(client, "read_file", nil, 1)
+		call(client, "read_file", nil, 2)
+		time.Sleep(30 * time.Millisecond)
+		call(client, "read_file", nil, 3)
+
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 handler calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("only caches listed tools and successful results", func(t *testing.T) {
+		var calls atomic.Int32
+		fail := true
+		client := newCachedClient(ToolCacheConfig{Tools: []string{"read_file"}}, func(inv ToolInvocation) (ToolResult, error) {
+			calls.Add(1)
+			if inv.ToolName == "read_file" && fail {
+				fail = false
+				return ToolResult{}, errors.New("disk busy")
+			}
+			return ToolResult{ResultType: "success"}, nil
+		})
+
+		call(client, "write_file", nil, 1)
+		call(client, "write_file", nil, 2)
+		call(client, "read_file", nil, 3)
+		call(client, "read_file", nil, 4)
+		call(client, "read_file", nil, 5)
+
+		// write_file twice, read_file once failing and once succeeding
+		if calls.Load() != 4 {
+			t.Errorf("Expected 4 handler calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("uses a custom key function", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newCachedClient(ToolCacheConfig{
+			Key: func(inv ToolInvocation) (string, bool) {
+				path, _ := inv.Arguments.(map[string]any)["path"].(string)
+				return path, path != ""
+			},
+		}, func(inv ToolInvocation) (ToolResult, error) {
+			calls.Add(1)
+			return ToolResult{ResultType: "success"}, nil
+		})
+
+		call(client, "read_file", map[string]any{"path": "a.go", "offset": 1}, 1)
+		call(client, "read_file", map[string]any{"path": "a.go", "offset": 2}, 2)
+		call(client, "read_file", map[string]any{}, 3)
+		call(client, "read_file", map[string]any{}, 4)
+
+		if calls.Load() != 3 {
+			t.Errorf("Expected 3 handler calls, got %d", calls.Load())
+		}
+	})
+}
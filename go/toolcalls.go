@@ -0,0 +1,157 @@
+package copilot
+
+import (
+	"sync"
+	"time"
+)
+
+// ToolCallRecord describes a tool call made during a session, built from the
+// session's tool execution events. See [Session.ToolCalls].
+type ToolCallRecord struct {
+	// ToolCallID identifies the call within the session.
+	ToolCallID string
+	// Name is the tool name. For MCP tools, MCPServer names the server.
+	Name      string
+	MCPServer string
+	// Arguments are the arguments the agent passed, decoded from JSON.
+	Arguments any
+	// Start is when the call started.
+	Start time.Time
+	// Duration is how long the call took, or zero if it has not completed.
+	Duration time.Duration
+	// Completed reports whether a completion event was received for the call.
+	Completed bool
+	// Success reports whether the call completed without error.
+	Success bool
+	// Error is the failure message of an unsuccessful call.
+	Error string
+	// ExitCode is the exit status of shell commands, or nil for other tools.
+	ExitCode *int
+	// OutputSize is the length in bytes of the result content returned to the agent.
+	OutputSize int
+	// Denial describes the permission handler rule or sandbox policy that
+	// blocked the call, or is nil if the call was not blocked.
+	Denial *PermissionDeniedError
+}
+
+// toolCallLog holds the tool call records of a session.
+type toolCallLog struct {
+	sessionID string
+
+	mu      sync.Mutex
+	records []ToolCallRecord
+	pending map[string]int                    // tool call ID to index in records
+	denials map[string]*PermissionDeniedError // denials of calls not started yet
+}
+
+// deny attaches a permission denial to the record of its tool call, or keeps
+// it for the call's start event.
+func (l *toolCallLog) deny(denial *PermissionDeniedError) {
+	if denial.ToolCallID == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i, ok := l.pending[denial.ToolCallID]; ok {
+		l.records[i].Denial = denial
+		return
+	}
+	if l.denials == nil {
+		l.denials = make(map[string]*PermissionDeniedError)
+	}
+	l.denials[denial.ToolCallID] = denial
+}
+
+// observe updates the records from a tool execution event.
+func (l *toolCallLog) observe(event SessionEvent) {
+	data := event.Data
+	if data.ToolCallID == nil {
+		return
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch event.Type {
+	case ToolExecutionStart:
+		record := ToolCallRecord{ToolCallID: *data.ToolCallID, Arguments: data.Arguments, Start: at}
+		if data.ToolName != nil {
+			record.Name = *data.ToolName
+		}
+		if data.MCPServerName != nil {
+			record.MCPServer = *data.MCPServerName
+		}
+		if denial, ok := l.denials[record.ToolCallID]; ok {
+			record.Denial = denial
+			delete(l.denials, record.ToolCallID)
+		}
+		if l.pending == nil {
+			l.pending = make(map[string]int)
+		}
+		l.pending[record.ToolCallID] = len(l.records)
+		l.records = append(l.records, record)
+	case ToolExecutionComplete:
+		i, ok := l.pending[*data.ToolCallID]
+		if !ok {
+			return
+		}
+		delete(l.pending, *data.ToolCallID)
+		record := &l.records[i]
+		record.Completed = true
+		record.Duration = max(at.Sub(record.Start), 0)
+		record.Success = data.Error == nil
+		if data.Success != nil {
+			record.Success = *data.Success
+		}
+		if data.Error != nil {
+			if data.Error.ErrorClass != nil {
+				record.Error = data.Error.ErrorClass.Message
+			} else if data.Error.String != nil {
+				record.Error = *data.Error.String
+			}
+			if class := data.Error.ErrorClass; record.Denial == nil && class != nil && class.Code != nil && *class.Code == string(ErrorCodePermissionDenied) {
+				record.Denial = &PermissionDeniedError{
+					SessionID:       l.sessionID,
+					ToolCallID:      record.ToolCallID,
+					ToolName:        record.Name,
+					ArgumentsDigest: argumentsDigest(record.Arguments),
+					Rule:            DenialRuleSandbox,
+					Reason:          class.Message,
+				}
+			}
+		}
+		if data.Result != nil {
+			record.OutputSize = len(data.Result.Content)
+			for _, content := range data.Result.Contents {
+				if content.ExitCode != nil {
+					code := int(*content.ExitCode)
+					record.ExitCode = &code
+					break
+				}
+			}
+		}
+	}
+}
+
+// ToolCalls returns a record of every tool call in this session, in the order
+// the calls started. Calls still running have Completed set to false.
+//
+// Only calls made while this Session value was receiving events are included;
+// use [Session.GetMessages] for the calls of earlier runs of a resumed session.
+// The returned slice is a copy and may be modified freely.
+//
+// Example:
+//
+//	for _, call := range session.ToolCalls() {
+//	    fmt.Printf("%s took %v (success=%v, %d bytes)\n", call.Name, call.Duration, call.Success, call.OutputSize)
+//	}
+func (s *Session) ToolCalls() []ToolCallRecord {
+	s.toolCalls.mu.Lock()
+	defer s.toolCalls.mu.Unlock()
+	records := make([]ToolCallRecord, len(s.toolCalls.records))
+	copy(records, s.toolCalls.records)
+	return records
+}
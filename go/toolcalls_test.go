@@ -0,0 +1,51 @@
+package copilot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_ToolCalls(t *testing.T) {
+	session := newSession("s1", nil, "")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	id1, id2, id3 := "t1", "t2", "t3"
+	shell, fetch, search := "shell", "fetch", "search"
+	exitCode := 2.0
+	failed := false
+	message := "connection refused"
+
+	session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Timestamp: start, Data: Data{ToolCallID: &id1, ToolName: &shell, Arguments: map[string]any{"command": "make"}}})
+	session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Timestamp: start, Data: Data{ToolCallID: &id2, ToolName: &fetch}})
+	session.dispatchEvent(SessionEvent{Type: ToolExecutionStart, Timestamp: start, Data: Data{ToolCallID: &id3, ToolName: &search}})
+	session.dispatchEvent(SessionEvent{Type: ToolExecutionComplete, Timestamp: start.Add(1500 * time.Millisecond), Data: Data{
+		ToolCallID: &id1,
+		Result:     &Result{Content: "make: *** Error 2", Contents: []Content{{Type: "terminal", ExitCode: &exitCode}}},
+	}})
+	session.dispatchEvent(SessionEvent{Type: ToolExecutionComplete, Timestamp: start.Add(time.Second), Data: Data{
+		ToolCallID: &id2,
+		Success:    &failed,
+		Error:      &ErrorUnion{ErrorClass: &ErrorClass{Message: message}},
+	}})
+
+	calls := session.ToolCalls()
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 tool calls, got %+v", calls)
+	}
+	if c := calls[0]; c.Name != "shell" || !c.Completed || !c.Success || c.Duration != 1500*time.Millisecond || c.OutputSize != 17 || c.ExitCode == nil || *c.ExitCode != 2 {
+		t.Errorf("Unexpected shell record: %+v", c)
+	}
+	if args, _ := calls[0].Arguments.(map[string]any); args["command"] != "make" {
+		t.Errorf("Expected arguments to be recorded, got %v", calls[0].Arguments)
+	}
+	if c := calls[1]; !c.Completed || c.Success || c.Error != message || c.Duration != time.Second {
+		t.Errorf("Unexpected failed record: %+v", c)
+	}
+	if c := calls[2]; c.Completed || c.Duration != 0 {
+		t.Errorf("Expected the running call to be incomplete, got %+v", c)
+	}
+
+	calls[0].Name = "changed"
+	if session.ToolCalls()[0].Name != "shell" {
+		t.Error("Expected ToolCalls to return a copy")
+	}
+}
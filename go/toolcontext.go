@@ -0,0 +1,154 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// FeatureToolProgress is the CLI feature flag, reported by [Client.Capabilities],
+// for relaying tool progress to the agent through session.tool.progress.
+const FeatureToolProgress = "toolProgress"
+
+// ToolContext describes the circumstances of a tool call and is passed to tool
+// handlers as ToolInvocation.Context. It is a [context.Context] that is canceled
+// when the session is destroyed or SessionConfig.ToolTimeout elapses, so
+// handlers can pass it to the operations they run and read the timeout from
+// its Deadline method:
+//
+//	func(params FetchParams, inv copilot.ToolInvocation) (any, error) {
+//	    inv.Context.ReportProgress("Downloading " + params.URL)
+//	    req, err := http.NewRequestWithContext(inv.Context, http.MethodGet, params.URL, nil)
+//	    ...
+//	}
+type ToolContext struct {
+	context.Context
+
+	// SessionID is the session that made the call.
+	SessionID string
+	// ToolCallID identifies the call within the session.
+	ToolCallID string
+	// ToolName is the name of the called tool.
+	ToolName string
+	// AgentName is the custom agent selected when the call was made, or "" for
+	// the default agent.
+	AgentName string
+	// MessageID is the ID of the message most recently sent on the session,
+	// whose turn made the call, or "" if none was sent by this client.
+	MessageID string
+	// Logger logs with the session's logger, with the tool name and tool call
+	// ID attached.
+	Logger *slog.Logger
+
+	session *Session
+}
+
+// newToolContext returns the context of a tool call on the session and the
+// function that releases it once the handler returns.
+func (s *Session) newToolContext(toolCallID, toolName string) (*ToolContext, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if s.toolTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), s.toolTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	go func() {
+		select {
+		case <-s.destroyed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	tc := &ToolContext{
+		Context:    ctx,
+		SessionID:  s.SessionID,
+		ToolCallID: toolCallID,
+		ToolName:   toolName,
+		AgentName:  s.CurrentAgent(),
+		Logger:     s.logger.With("tool", toolName, "toolCallId", toolCallID),
+		session:    s,
+	}
+	if id := s.currentMessage.Load(); id != nil {
+		tc.MessageID = *id
+	}
+
+	return tc, s.runningTools.add(toolCallID, cancel)
+}
+
+// runningToolCalls tracks the tool calls of a session whose handlers are
+// running, so that [Client.AbortAll] can cancel them.
+type runningToolCalls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // by tool call ID
+}
+
+// add tracks a running call and returns the function that releases it.
+func (r *runningToolCalls) add(toolCallID string, cancel context.CancelFunc) context.CancelFunc {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancels == nil {
+		r.cancels = make(map[string]context.CancelFunc)
+	}
+	r.cancels[toolCallID] = cancel
+	return func() {
+		r.mu.Lock()
+		delete(r.cancels, toolCallID)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the contexts of the running calls and returns how many
+// there were.
+func (r *runningToolCalls) cancel() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.cancels {
+		cancel()
+	}
+	return len(r.cancels)
+}
+
+// ReportProgress shows an intermediate status of the running tool, such as
+// "Indexed 120 of 300 files", to the user and the agent before the handler
+// returns.
+//
+// When the CLI supports [FeatureToolProgress], the message is relayed to the
+// CLI, which shows it to the agent and emits a [ToolExecutionProgress] event.
+// Otherwise the event is only dispatched to the session's event handlers.
+func (tc *ToolContext) ReportProgress(message string) error {
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("progress message is required")
+	}
+	s := tc.session
+	if s.capabilities != nil {
+		capabilities, err := s.capabilities(tc)
+		if err != nil {
+			return fmt.Errorf("failed to check CLI capabilities: %w", err)
+		}
+		if capabilities.HasFeature(FeatureToolProgress) {
+			if _, err := s.RPC.Tool.Progress(tc, &rpc.SessionToolProgressParams{ToolCallID: tc.ToolCallID, Message: message}); err != nil {
+				return fmt.Errorf("failed to report tool progress: %w", err)
+			}
+			return nil
+		}
+	}
+	s.dispatchEvent(SessionEvent{
+		Type:      ToolExecutionProgress,
+		Timestamp: time.Now(),
+		Ephemeral: Bool(true),
+		Data: Data{
+			ToolCallID:      &tc.ToolCallID,
+			ToolName:        &tc.ToolName,
+			ProgressMessage: &message,
+		},
+	})
+	return nil
+}
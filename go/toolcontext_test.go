@@ -0,0 +1,141 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToolContext(t *testing.T) {
+	newToolClient := func(handler ToolHandler) (*Client, *Session) {
+		session := newSession("s1", nil, "")
+		session.registerTools([]Tool{{Name: "index", Handler: handler}})
+		return &Client{sessions: map[string]*Session{"s1": session}}, session
+	}
+	call := func(client *Client) ToolResult {
+		response, _ := client.handleToolCallRequest(toolCallRequest{SessionID: "s1", ToolCallID: "tc-1", ToolName: "index"})
+		return response.Result
+	}
+
+	t.Run("describes the call", func(t *testing.T) {
+		var tc *ToolContext
+		var errDuringCall error
+		client, session := newToolClient(func(inv ToolInvocation) (ToolResult, error) {
+			tc = inv.Context
+			errDuringCall = tc.Err()
+			return ToolResult{ResultType: "success"}, nil
+		})
+		agent, message := "reviewer", "m-7"
+		session.currentAgent.Store(&agent)
+		session.currentMessage.Store(&message)
+
+		call(client)
+		if tc == nil {
+			t.Fatal("Expected the invocation to carry a ToolContext")
+		}
+		if tc.SessionID != "s1" || tc.ToolCallID != "tc-1" || tc.ToolName != "index" || tc.AgentName != "reviewer" || tc.MessageID != "m-7" || tc.Logger == nil {
+			t.Errorf("Unexpected tool context: %+v", tc)
+		}
+		if _, ok := tc.Deadline(); ok {
+			t.Error("Expected no deadline without ToolTimeout")
+		}
+		if errDuringCall != nil || tc.Err() == nil {
+			t.Errorf("Expected the context to be live during the call and canceled after, got %v, %v", errDuringCall, tc.Err())
+		}
+	})
+
+	t.Run("times out after ToolTimeout", func(t *testing.T) {
+		client, session := newToolClient(func(inv ToolInvocation) (ToolResult, error) {
+			if _, ok := inv.Context.Deadline(); !ok {
+				return ToolResult{}, errors.New("no deadline")
+			}
+			<-inv.Context.Done()
+			return ToolResult{}, inv.Context.Err()
+		})
+		session.toolTimeout = 20 * time.Millisecond
+
+		result := call(client)
+		if result.ResultType != "failure" || result.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("Expected the handler to time out, got %+v", result)
+		}
+	})
+
+	t.Run("is canceled when the session is destroyed", func(t *testing.T) {
+		started := make(chan struct{})
+		client, session := newToolClient(func(inv ToolInvocation) (ToolResult, error) {
+			close(started)
+			<-inv.Context.Done()
+			return ToolResult{}, inv.Context.Err()
+		})
+		go func() {
+			<-started
+			close(session.destroyed)
+		}()
+
+		if result := call(client); result.Error != context.Canceled.Error() {
+			t.Errorf("Expected the handler to be canceled, got %+v", result)
+		}
+	})
+
+	t.Run("reports progress to event handlers", func(t *testing.T) {
+		var progress []string
+		client, session := newToolClient(func(inv ToolInvocation) (ToolResult, error) {
+			if err := inv.Context.ReportProgress("Indexed 120 of 300 files"); err != nil {
+				return ToolResult{}, err
+			}
+			return ToolResult{ResultType: "success"}, inv.Context.ReportProgress(" ")
+		})
+		session.On(func(event SessionEvent) {
+			if event.Type == ToolExecutionProgress && *event.Data.ToolCallID == "tc-1" {
+				progress = append(progress, *event.Data.ProgressMessage)
+			}
+		})
+
+		result := call(client)
+		if len(progress) != 1 || progress[0] != "Indexed 120 of 300 files" {
+			t.Errorf("Unexpected progress events: %q", progress)
+		}
+		if result.ResultType != "failure" {
+			t.Errorf("Expected an empty progress message to be rejected, got %+v", result)
+		}
+	})
+
+	t.Run("relays progress to the CLI when supported", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.create", map[string]any{"sessionId": "s1"})
+		log.call("capabilities.get", map[string]any{"version": "1.2.3", "protocolVersion": GetSdkProtocolVersion(), "features": map[string]bool{FeatureToolProgress: true}})
+		log.call("session.tool.progress", map[string]any{})
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.CreateSession(t.Context(), &SessionConfig{OnPermissionRequest: PermissionHandler.ApproveAll})
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		tc, cancel := session.newToolContext("tc-1", "index")
+		defer cancel()
+		if err := tc.ReportProgress("Indexing"); err != nil {
+			t.Fatalf("Failed to report progress: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		relayed := false
+		for _, record := range records {
+			var message struct {
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.tool.progress" {
+				relayed = message.Params["toolCallId"] == "tc-1" && message.Params["message"] == "Indexing"
+			}
+		}
+		if !relayed {
+			t.Errorf("Expected a session.tool.progress request, got %s", recorded.String())
+		}
+	})
+}
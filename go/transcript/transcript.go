@@ -0,0 +1,242 @@
+// Package transcript compares session histories turn by turn, for example to
+// check what compaction kept or how two forks of a session diverged.
+//
+// Example:
+//
+//	before, _ := session.GetMessages(ctx)
+//	session.RPC.Compaction.Compact(ctx)
+//	after, _ := session.GetMessages(ctx)
+//	for _, change := range transcript.Diff(before, after) {
+//	    fmt.Println(change)
+//	}
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+// Turn is a user message and the agent's work in response to it.
+type Turn struct {
+	// User is the user message that started the turn. It is empty for a turn made
+	// of events that came before the first user message.
+	User string
+	// Assistant is the content of the turn's assistant messages, separated by blank lines.
+	Assistant string
+	// Tools are the tool calls made during the turn, in the order they started.
+	Tools []ToolCall
+}
+
+// ToolCall is a tool call within a [Turn].
+type ToolCall struct {
+	Name string
+	// Arguments is the JSON-encoded arguments.
+	Arguments string
+	// Result is the content returned to the agent, or empty if the call did not complete.
+	Result string
+}
+
+// Turns groups session events into turns, starting a new turn at each user
+// message. Events other than user and assistant messages and tool executions
+// are ignored.
+func Turns(events []copilot.SessionEvent) []Turn {
+	var turns []Turn
+	var current *Turn
+	toolIndex := map[string]int{}
+	turn := func() *Turn {
+		if current == nil {
+			turns = append(turns, Turn{})
+			current = &turns[len(turns)-1]
+		}
+		return current
+	}
+
+	for _, event := range events {
+		data := event.Data
+		switch event.Type {
+		case copilot.UserMessage:
+			turns = append(turns, Turn{User: deref(data.Content)})
+			current = &turns[len(turns)-1]
+			clear(toolIndex)
+		case copilot.AssistantMessage:
+			content := deref(data.Content)
+			if content == "" {
+				continue
+			}
+			t := turn()
+			if t.Assistant != "" {
+				t.Assistant += "\n\n"
+			}
+			t.Assistant += content
+		case copilot.ToolExecutionStart:
+			t := turn()
+			call := ToolCall{Name: deref(data.ToolName)}
+			if data.Arguments != nil {
+				if args, err := json.Marshal(data.Arguments); err == nil {
+					call.Arguments = string(args)
+				}
+			}
+			t.Tools = append(t.Tools, call)
+			if data.ToolCallID != nil {
+				toolIndex[*data.ToolCallID] = len(t.Tools) - 1
+			}
+		case copilot.ToolExecutionComplete:
+			if current == nil || data.ToolCallID == nil || data.Result == nil {
+				continue
+			}
+			if i, ok := toolIndex[*data.ToolCallID]; ok {
+				current.Tools[i].Result = data.Result.Content
+			}
+		}
+	}
+	return turns
+}
+
+// ChangeKind classifies a [Change].
+type ChangeKind string
+
+const (
+	// Added is a turn that is only in the second history.
+	Added ChangeKind = "added"
+	// Removed is a turn that is only in the first history.
+	Removed ChangeKind = "removed"
+	// Changed is a turn that is in both histories with different content.
+	Changed ChangeKind = "changed"
+)
+
+// Change is a difference between two session histories, as reported by [Diff].
+type Change struct {
+	Kind ChangeKind
+	// A is the turn in the first history, or nil if Kind is Added.
+	A *Turn
+	// B is the turn in the second history, or nil if Kind is Removed.
+	B *Turn
+	// AIndex and BIndex are the positions of A and B in their histories, or -1.
+	AIndex, BIndex int
+	// Fields lists the parts of a changed turn that differ: "user", "assistant",
+	// and "tools". It is nil for added and removed turns.
+	Fields []string
+}
+
+// String describes the change in one line, e.g. for test failure messages.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added turn %d: %s", c.BIndex+1, summarize(c.B))
+	case Removed:
+		return fmt.Sprintf("removed turn %d: %s", c.AIndex+1, summarize(c.A))
+	default:
+		return fmt.Sprintf("changed turn %d -> %d (%s): %s", c.AIndex+1, c.BIndex+1, strings.Join(c.Fields, ", "), summarize(c.B))
+	}
+}
+
+// Diff compares two session histories turn by turn and returns their
+// differences in history order. It returns nil when the histories have the same
+// turns.
+//
+// Turns are matched by their user message, keeping their order. A turn that
+// matches but has a different reply or different tool calls is Changed. Where
+// turns were removed and others added in the same place, they are paired up as
+// Changed turns whose user message differs.
+func Diff(a, b []copilot.SessionEvent) []Change {
+	return DiffTurns(Turns(a), Turns(b))
+}
+
+// DiffTurns is like [Diff] for histories already grouped with [Turns].
+func DiffTurns(a, b []Turn) []Change {
+	var changes []Change
+	i, j := 0, 0
+	flush := func(endA, endB int) {
+		// Pair up the unmatched turns between two matches
+		for i < endA && j < endB {
+			changes = append(changes, compare(a, b, i, j))
+			i, j = i+1, j+1
+		}
+		for ; i < endA; i++ {
+			changes = append(changes, Change{Kind: Removed, A: &a[i], AIndex: i, BIndex: -1})
+		}
+		for ; j < endB; j++ {
+			changes = append(changes, Change{Kind: Added, B: &b[j], AIndex: -1, BIndex: j})
+		}
+	}
+	for _, match := range matchTurns(a, b) {
+		flush(match[0], match[1])
+		if change := compare(a, b, i, j); change.Fields != nil {
+			changes = append(changes, change)
+		}
+		i, j = i+1, j+1
+	}
+	flush(len(a), len(b))
+	return changes
+}
+
+// matchTurns returns the index pairs of the longest common subsequence of turns
+// with equal user messages.
+func matchTurns(a, b []Turn) [][2]int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].User == b[j].User {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].User == b[j].User:
+			matches = append(matches, [2]int{i, j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// compare returns a Changed change for a[i] and b[j], with nil Fields if they are equal.
+func compare(a, b []Turn, i, j int) Change {
+	change := Change{Kind: Changed, A: &a[i], B: &b[j], AIndex: i, BIndex: j}
+	if a[i].User != b[j].User {
+		change.Fields = append(change.Fields, "user")
+	}
+	if a[i].Assistant != b[j].Assistant {
+		change.Fields = append(change.Fields, "assistant")
+	}
+	if !slices.Equal(a[i].Tools, b[j].Tools) {
+		change.Fields = append(change.Fields, "tools")
+	}
+	return change
+}
+
+// summarize returns the start of a turn's user message, or of its reply if it has none.
+func summarize(turn *Turn) string {
+	text := turn.User
+	if text == "" {
+		text = turn.Assistant
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > 60 {
+		text = text[:57] + "..."
+	}
+	return fmt.Sprintf("%q", text)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,123 @@
+package transcript
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	copilot "github.com/github/copilot-sdk/go"
+)
+
+func user(content string) copilot.SessionEvent {
+	return copilot.SessionEvent{Type: copilot.UserMessage, Data: copilot.Data{Content: &content}}
+}
+
+func assistant(content string) copilot.SessionEvent {
+	return copilot.SessionEvent{Type: copilot.AssistantMessage, Data: copilot.Data{Content: &content}}
+}
+
+func tool(id, name string, args map[string]any, result string) []copilot.SessionEvent {
+	return []copilot.SessionEvent{
+		{Type: copilot.ToolExecutionStart, Data: copilot.Data{ToolCallID: &id, ToolName: &name, Arguments: args}},
+		{Type: copilot.ToolExecutionComplete, Data: copilot.Data{ToolCallID: &id, Result: &copilot.Result{Content: result}}},
+	}
+}
+
+func history(events ...any) []copilot.SessionEvent {
+	var out []copilot.SessionEvent
+	for _, event := range events {
+		switch e := event.(type) {
+		case copilot.SessionEvent:
+			out = append(out, e)
+		case []copilot.SessionEvent:
+			out = append(out, e...)
+		}
+	}
+	return out
+}
+
+func TestTurns(t *testing.T) {
+	turns := Turns(history(
+		assistant("Welcome"),
+		user("List files"),
+		tool("t1", "ls", map[string]any{"path": "."}, "a.go"),
+		assistant("Found a.go"),
+		assistant("Anything else?"),
+		user("Thanks"),
+	))
+	if len(turns) != 3 {
+		t.Fatalf("Expected 3 turns, got %+v", turns)
+	}
+	if turns[0].User != "" || turns[0].Assistant != "Welcome" {
+		t.Errorf("Expected a leading turn without a user message, got %+v", turns[0])
+	}
+	expectedTools := []ToolCall{{Name: "ls", Arguments: `{"path":"."}`, Result: "a.go"}}
+	if turns[1].Assistant != "Found a.go\n\nAnything else?" || !slices.Equal(turns[1].Tools, expectedTools) {
+		t.Errorf("Unexpected turn: %+v", turns[1])
+	}
+}
+
+func TestDiff(t *testing.T) {
+	base := history(
+		user("Plan the migration"), assistant("1. Add column"),
+		user("Write the SQL"), tool("t1", "edit", map[string]any{"file": "up.sql"}, "ok"), assistant("Done"),
+		user("Run the tests"), assistant("All passing"),
+	)
+
+	t.Run("reports no changes for equal histories", func(t *testing.T) {
+		if changes := Diff(base, base); changes != nil {
+			t.Errorf("Expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("reports removed turns after compaction", func(t *testing.T) {
+		compacted := history(
+			user("Write the SQL"), tool("t1", "edit", map[string]any{"file": "up.sql"}, "ok"), assistant("Done"),
+			user("Run the tests"), assistant("All passing"),
+		)
+		changes := Diff(base, compacted)
+		if len(changes) != 1 || changes[0].Kind != Removed || changes[0].AIndex != 0 || changes[0].BIndex != -1 {
+			t.Fatalf("Expected the first turn to be removed, got %v", changes)
+		}
+		if s := changes[0].String(); s != `removed turn 1: "Plan the migration"` {
+			t.Errorf("Unexpected description: %s", s)
+		}
+	})
+
+	t.Run("reports changed replies and tool calls", func(t *testing.T) {
+		fork := history(
+			user("Plan the migration"), assistant("1. Add column"),
+			user("Write the SQL"), tool("t1", "edit", map[string]any{"file": "down.sql"}, "ok"), assistant("Done"),
+			user("Run the tests"), assistant("2 failures"),
+			user("Fix them"), assistant("Fixed"),
+		)
+		changes := Diff(base, fork)
+		if len(changes) != 3 {
+			t.Fatalf("Expected 3 changes, got %v", changes)
+		}
+		if changes[0].Kind != Changed || !slices.Equal(changes[0].Fields, []string{"tools"}) {
+			t.Errorf("Expected changed tool call, got %v", changes[0])
+		}
+		if changes[1].Kind != Changed || !slices.Equal(changes[1].Fields, []string{"assistant"}) || changes[1].A.Assistant != "All passing" {
+			t.Errorf("Expected changed reply, got %v", changes[1])
+		}
+		if changes[2].Kind != Added || changes[2].BIndex != 3 || changes[2].A != nil {
+			t.Errorf("Expected added turn, got %v", changes[2])
+		}
+	})
+
+	t.Run("pairs replaced turns as changed", func(t *testing.T) {
+		edited := history(
+			user("Plan the rollout"), assistant("1. Canary"),
+			user("Write the SQL"), tool("t1", "edit", map[string]any{"file": "up.sql"}, "ok"), assistant("Done"),
+			user("Run the tests"), assistant("All passing"),
+		)
+		changes := Diff(base, edited)
+		if len(changes) != 1 || changes[0].Kind != Changed || !slices.Equal(changes[0].Fields, []string{"user", "assistant"}) {
+			t.Fatalf("Expected one changed turn, got %v", changes)
+		}
+		if s := changes[0].String(); !strings.HasPrefix(s, "changed turn 1 -> 1 (user, assistant)") {
+			t.Errorf("Unexpected description: %s", s)
+		}
+	})
+}
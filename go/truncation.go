@@ -0,0 +1,224 @@
+package copilot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// TruncationStrategy selects how a [TruncationPolicy] chooses the history
+// sent with each message.
+type TruncationStrategy string
+
+const (
+	// TruncateDropOldest sends only the last MaxTurns turns.
+	TruncateDropOldest TruncationStrategy = "drop-oldest"
+	// TruncateKeepPinned sends the last MaxTurns turns plus the messages
+	// listed in Pinned, wherever they are in the conversation.
+	TruncateKeepPinned TruncationStrategy = "keep-pinned"
+	// TruncateSlidingWindow sends the most recent turns that fit in
+	// MaxTokens, as estimated by the SDK.
+	TruncateSlidingWindow TruncationStrategy = "sliding-window-by-tokens"
+)
+
+// TruncationPolicy limits the conversation history the model sees with each
+// message, as an alternative to compaction: older turns are left out of the
+// request rather than replaced with a model-generated summary, and remain in
+// the session history. The SDK tracks the session's turns and sets
+// MessageOptions.HistoryWindow on every message that does not set its own, so
+// the CLI must support [FeatureHistoryWindow]. Messages pinned with
+// [Session.Pin] are sent by every strategy.
+type TruncationPolicy struct {
+	// Strategy selects which history is sent.
+	Strategy TruncationStrategy
+	// MaxTurns is the number of recent turns sent by [TruncateDropOldest] and
+	// [TruncateKeepPinned]. A turn is a user message and everything that
+	// followed it, such as replies and tool calls.
+	MaxTurns int
+	// MaxTokens is the estimated token budget of the recent turns sent by
+	// [TruncateSlidingWindow]. The most recent turn is always sent, and pinned
+	// messages are sent in addition.
+	MaxTokens int
+	// Pinned lists the IDs of user.message and assistant.message events that
+	// [TruncateKeepPinned] always sends, in addition to those pinned with
+	// [Session.Pin]. Unlike [Session.Pin], it does not affect compaction.
+	Pinned []string
+}
+
+// validateTruncationPolicy checks that p, if set, has the limit its strategy
+// needs.
+func validateTruncationPolicy(p *TruncationPolicy) error {
+	if p == nil {
+		return nil
+	}
+	switch p.Strategy {
+	case TruncateDropOldest, TruncateKeepPinned:
+		if p.MaxTurns <= 0 {
+			return fmt.Errorf("invalid TruncationPolicy: %s requires a positive MaxTurns", p.Strategy)
+		}
+	case TruncateSlidingWindow:
+		if p.MaxTokens <= 0 {
+			return fmt.Errorf("invalid TruncationPolicy: %s requires a positive MaxTokens", p.Strategy)
+		}
+	default:
+		return fmt.Errorf("invalid TruncationPolicy: unknown strategy %q", p.Strategy)
+	}
+	return nil
+}
+
+// historyTurn is a user message and the assistant messages that followed it.
+type historyTurn struct {
+	messageIDs []string // user.message and assistant.message event IDs
+	tokens     int      // estimated
+}
+
+// historyTruncator applies a TruncationPolicy to a session. It follows the
+// session's turns from its events, and reads the history on the first message
+// when the session already had one, e.g. after a resume or fork.
+type historyTruncator struct {
+	session *Session
+	policy  TruncationPolicy
+
+	mu     sync.Mutex
+	turns  []historyTurn
+	loaded bool // turns reflects the session history
+}
+
+func newHistoryTruncator(session *Session, policy TruncationPolicy, loaded bool) *historyTruncator {
+	policy.Pinned = slices.Clone(policy.Pinned)
+	return &historyTruncator{session: session, policy: policy, loaded: loaded}
+}
+
+// observe adds a session event to the tracked turns.
+func (h *historyTruncator) observe(event SessionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch event.Type {
+	case SessionCompactionComplete, SessionTruncation, SessionSnapshotRewind:
+		// The history was rewritten; read it again before the next message
+		h.turns, h.loaded = nil, false
+	default:
+		h.addLocked(event)
+	}
+}
+
+// addLocked adds a message or tool event to the turns. Must be called with
+// h.mu held.
+func (h *historyTruncator) addLocked(event SessionEvent) {
+	switch event.Type {
+	case UserMessage:
+		h.turns = append(h.turns, historyTurn{})
+	case AssistantMessage, ToolExecutionComplete:
+	default:
+		return
+	}
+	if len(h.turns) == 0 {
+		// Messages before the first user message, e.g. a greeting
+		h.turns = append(h.turns, historyTurn{})
+	}
+	turn := &h.turns[len(h.turns)-1]
+	if event.Type != ToolExecutionComplete && event.ID != "" {
+		turn.messageIDs = append(turn.messageIDs, event.ID)
+	}
+	turn.tokens += estimateEventTokens(event)
+}
+
+// window returns the history window for the next message, or nil to send the
+// full history.
+func (h *historyTruncator) window(ctx context.Context) (*HistoryWindow, error) {
+	h.mu.Lock()
+	loaded := h.loaded
+	h.mu.Unlock()
+	if !loaded {
+		if err := h.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	pinned := h.session.pinnedIDs()
+	if h.policy.Strategy == TruncateKeepPinned {
+		pinned = append(pinned, h.policy.Pinned...)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	turns := h.turns
+	recent := len(turns) // the number of recent turns to send
+	switch h.policy.Strategy {
+	case TruncateDropOldest, TruncateKeepPinned:
+		recent = min(recent, h.policy.MaxTurns)
+	case TruncateSlidingWindow:
+		tokens := 0
+		for i := len(turns) - 1; i >= 0; i-- {
+			tokens += turns[i].tokens
+			if tokens > h.policy.MaxTokens {
+				recent = max(len(turns)-1-i, 1)
+				break
+			}
+		}
+	}
+	if recent == len(turns) {
+		return nil, nil
+	}
+
+	// Older turns are left out unless they hold pinned messages, which need
+	// the window to list the messages to send
+	var ids []string
+	pinnedOlder := false
+	for i, turn := range turns {
+		older := i < len(turns)-recent
+		for _, id := range turn.messageIDs {
+			isPinned := slices.Contains(pinned, id)
+			if !older || isPinned {
+				ids = append(ids, id)
+			}
+			pinnedOlder = pinnedOlder || older && isPinned
+		}
+	}
+	if pinnedOlder {
+		return &HistoryWindow{MessageIDs: ids}, nil
+	}
+	return &HistoryWindow{LastTurns: recent}, nil
+}
+
+// load reads the turns, and the pinned messages if the CLI supports them, from
+// the session history.
+func (h *historyTruncator) load(ctx context.Context) error {
+	events, err := h.session.GetMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read history for TruncationPolicy: %w", err)
+	}
+	if supported, _, err := h.session.pinsSupported(ctx); err != nil {
+		return err
+	} else if supported {
+		if _, err := h.session.loadPinned(ctx); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.turns = nil
+	for _, event := range events {
+		h.addLocked(event)
+	}
+	h.loaded = true
+	return nil
+}
+
+// estimateEventTokens estimates the tokens an event adds to the context, at
+// roughly four characters per token.
+func estimateEventTokens(event SessionEvent) int {
+	chars := len(deref(event.Data.Content))
+	for _, request := range event.Data.ToolRequests {
+		if arguments, err := json.Marshal(request.Arguments); err == nil {
+			chars += len(arguments)
+		}
+	}
+	if event.Data.Result != nil {
+		chars += len(event.Data.Result.Content)
+	}
+	return (chars + 3) / 4
+}
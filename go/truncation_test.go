@@ -0,0 +1,136 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTruncationPolicy(t *testing.T) {
+	str := func(s string) *string { return &s }
+	// converse dispatches turns of a user message and a reply with content of
+	// the given lengths.
+	converse := func(session *Session, lengths ...int) {
+		for i, length := range lengths {
+			n := string(rune('1' + i))
+			session.dispatchEvent(SessionEvent{ID: "u" + n, Type: UserMessage, Timestamp: time.Now(), Data: Data{Content: str("q")}})
+			session.dispatchEvent(SessionEvent{ID: "a" + n, Type: AssistantMessage, Timestamp: time.Now(), Data: Data{Content: str(strings.Repeat("x", length))}})
+		}
+	}
+	newTruncatedSession := func(policy TruncationPolicy) *Session {
+		session := newSession("s1", nil, "")
+		session.truncation = newHistoryTruncator(session, policy, true)
+		return session
+	}
+
+	t.Run("drop-oldest sends the last turns", func(t *testing.T) {
+		session := newTruncatedSession(TruncationPolicy{Strategy: TruncateDropOldest, MaxTurns: 2})
+		converse(session, 10, 10)
+		if window, _ := session.truncation.window(t.Context()); window != nil {
+			t.Errorf("Expected the full history within MaxTurns, got %+v", window)
+		}
+		converse(session, 10)
+		if window, _ := session.truncation.window(t.Context()); window == nil || window.LastTurns != 2 {
+			t.Errorf("Expected the last 2 turns, got %+v", window)
+		}
+	})
+
+	t.Run("keep-pinned sends pinned messages and the last turns", func(t *testing.T) {
+		session := newTruncatedSession(TruncationPolicy{Strategy: TruncateKeepPinned, MaxTurns: 1, Pinned: []string{"u1"}})
+		converse(session, 10, 10, 10)
+		window, _ := session.truncation.window(t.Context())
+		if window == nil || !slices.Equal(window.MessageIDs, []string{"u1", "u3", "a3"}) {
+			t.Errorf("Expected the pinned message and the last turn, got %+v", window)
+		}
+	})
+
+	t.Run("sliding window fits the token budget", func(t *testing.T) {
+		session := newTruncatedSession(TruncationPolicy{Strategy: TruncateSlidingWindow, MaxTokens: 100})
+		converse(session, 160, 160)
+		if window, _ := session.truncation.window(t.Context()); window != nil {
+			t.Errorf("Expected the full history within budget, got %+v", window)
+		}
+		converse(session, 160)
+		if window, _ := session.truncation.window(t.Context()); window == nil || window.LastTurns != 2 {
+			t.Errorf("Expected the last 2 turns, got %+v", window)
+		}
+		converse(session, 1000)
+		if window, _ := session.truncation.window(t.Context()); window == nil || window.LastTurns != 1 {
+			t.Errorf("Expected the last turn even over budget, got %+v", window)
+		}
+	})
+
+	t.Run("sends pinned messages with every strategy", func(t *testing.T) {
+		policies := []TruncationPolicy{
+			{Strategy: TruncateDropOldest, MaxTurns: 1},
+			{Strategy: TruncateKeepPinned, MaxTurns: 1},
+			{Strategy: TruncateSlidingWindow, MaxTokens: 50},
+		}
+		for _, policy := range policies {
+			session := newTruncatedSession(policy)
+			session.setPinned([]string{"a2"})
+			converse(session, 160, 160, 160)
+			window, _ := session.truncation.window(t.Context())
+			if window == nil || !slices.Equal(window.MessageIDs, []string{"a2", "u3", "a3"}) {
+				t.Errorf("%s: expected the pinned message and the last turn, got %+v", policy.Strategy, window)
+			}
+		}
+	})
+
+	t.Run("rejects invalid policies", func(t *testing.T) {
+		tests := []TruncationPolicy{
+			{Strategy: TruncateDropOldest},
+			{Strategy: TruncateKeepPinned, MaxTokens: 100},
+			{Strategy: TruncateSlidingWindow, MaxTurns: 3},
+			{Strategy: "newest-first", MaxTurns: 3},
+		}
+		for _, policy := range tests {
+			if err := validateTruncationPolicy(&policy); err == nil {
+				t.Errorf("Expected error for %+v", policy)
+			}
+		}
+	})
+
+	t.Run("reads the history of a resumed session", func(t *testing.T) {
+		log := &replayLog{}
+		log.handshake()
+		log.call("session.resume", map[string]any{"sessionId": "s1"})
+		log.call("session.getMessages", map[string]any{"events": editHistory()})
+		log.call("capabilities.get", map[string]any{
+			"version":         "1.2.3",
+			"protocolVersion": GetSdkProtocolVersion(),
+			"features":        map[string]bool{FeatureHistoryWindow: true},
+		})
+		log.call("session.send", map[string]any{"messageId": "m1"})
+
+		var recorded bytes.Buffer
+		client := newPlaybackClientForTest(t, log, &recorded)
+		session, err := client.ResumeSession(t.Context(), "s1", &ResumeSessionConfig{
+			OnPermissionRequest: PermissionHandler.ApproveAll,
+			TruncationPolicy:    &TruncationPolicy{Strategy: TruncateDropOldest, MaxTurns: 1},
+		})
+		if err != nil {
+			t.Fatalf("Failed to resume session: %v", err)
+		}
+		if _, err := session.Send(t.Context(), MessageOptions{Prompt: "And a foreign key?"}); err != nil {
+			t.Fatalf("Failed to send: %v", err)
+		}
+
+		records, _ := readReplayLog(bytes.NewReader(recorded.Bytes()))
+		for _, record := range records {
+			var message struct {
+				Method string `json:"method"`
+				Params struct {
+					HistoryWindow json.RawMessage `json:"historyWindow"`
+				} `json:"params"`
+			}
+			json.Unmarshal(record.Message, &message)
+			if message.Method == "session.send" && string(message.Params.HistoryWindow) != `{"lastTurns":1}` {
+				t.Errorf("Expected the last turn, got %s", message.Params.HistoryWindow)
+			}
+		}
+	})
+}
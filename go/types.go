@@ -1,6 +1,15 @@
 package copilot
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/github/copilot-sdk/go/pricing"
+	"github.com/github/copilot-sdk/go/redact"
+)
 
 // ConnectionState represents the client connection state
 type ConnectionState string
@@ -30,8 +39,33 @@ type ClientOptions struct {
 	// Examples: "localhost:8080", "http://127.0.0.1:9000", "8080"
 	// Mutually exclusive with CLIPath, UseStdio
 	CLIUrl string
+	// SocketPath is the path of a unix domain socket that an externally managed
+	// CLI server listens on. Several processes can connect to the same server
+	// and share its sessions. Like CLIUrl, the client does not spawn or stop
+	// the CLI, and there is no helper to launch one, since released CLIs only
+	// listen on TCP. Mutually exclusive with CLIUrl, CLIPath, UseStdio, Port,
+	// and SSH.
+	SocketPath string
+	// SharedCLI shares one CLI process among all clients in this process that
+	// set it with the same CLIPath, CLIArgs, Cwd, Env, LogLevel, Port,
+	// GitHubToken, UseLoggedInUser, GitHubHost, and ResourceLimits, instead of
+	// each spawning its own. The first client to start spawns the CLI over
+	// TCP, and the last one to stop shuts it down. Every client using the CLI
+	// receives its logs, its ResourceLimits.OnLimitExceeded calls, and captures
+	// diagnostics when it crashes; the SDK's own messages about spawning the
+	// process go to the Logger of the client that spawned it. Mutually
+	// exclusive with CLIUrl, SocketPath, UseStdio, and SSH.
+	SharedCLI bool
 	// LogLevel for the CLI server
 	LogLevel string
+	// StrictProtocol validates the params and result of every JSON-RPC message
+	// against the embedded schema of the SDK's protocol version, so that drift
+	// between the SDK and the CLI fails fast with the offending field instead of
+	// as a zero value deep inside a handler. Requests with an invalid payload
+	// return a [*ProtocolSchemaError]; invalid messages from the CLI are
+	// rejected and reported like other protocol errors. Intended for tests and
+	// CI, as validation adds overhead to every message.
+	StrictProtocol bool
 	// AutoStart automatically starts the CLI server on first use (default: true).
 	// Use Bool(false) to disable.
 	AutoStart *bool
@@ -54,6 +88,177 @@ type ClientOptions struct {
 	// Default: true (but defaults to false when GitHubToken is provided).
 	// Use Bool(false) to explicitly disable.
 	UseLoggedInUser *bool
+	// GitHubHost points the CLI at a GitHub Enterprise Server instance instead
+	// of github.com, given as its host name or URL, e.g. "github.example.com" or
+	// "https://github.example.com". The host is passed to the CLI as GH_HOST, so
+	// sign-in, GitHubToken, and API requests use it. [Client.Start] checks that
+	// the host is reachable first and fails with a [*GitHubHostError] if not.
+	// Not supported with CLIUrl or SocketPath, whose server has its own host.
+	GitHubHost string
+	// RecordTo, when non-nil, receives every JSON-RPC request, response, and notification
+	// exchanged with the CLI as a JSONL replay log (one [ReplayRecord] per line).
+	// Credentials and resolved secrets are replaced using Redactor, as in the
+	// SDK's logs. Recorded logs can be replayed without a CLI via [NewPlaybackClient].
+	RecordTo io.Writer
+	// WireDump, when non-nil, receives a human-readable copy of every JSON-RPC frame
+	// exchanged with the CLI, with its direction, timestamp, and Content-Length, for
+	// debugging protocol mismatches. See WireDumpOptions for formatting and redaction.
+	WireDump io.Writer
+	// WireDumpOptions controls the formatting of WireDump output. Default: compact
+	// JSON without redaction.
+	WireDumpOptions WireDumpOptions
+	// MetricsRegistry, when non-nil, receives instrumentation for RPC latency, messages,
+	// tool calls, permission denials, and CLI restarts.
+	// See the metrics subpackage for a Prometheus implementation.
+	MetricsRegistry MetricsRegistry
+	// ResourceLimits, when non-nil, caps the memory, child processes, and
+	// scheduling priority of the CLI process the client spawns.
+	ResourceLimits *ResourceLimits
+	// RateLimit, when non-nil, enforces request and token quotas on messages sent
+	// through sessions created by this client.
+	RateLimit *RateLimitConfig
+	// CircuitBreaker, when non-nil, fails requests fast with [ErrCircuitOpen]
+	// after repeated timeouts or CLI crashes instead of letting callers wait on
+	// an unresponsive CLI, and probes for recovery. See [Client.CircuitState].
+	CircuitBreaker *CircuitBreakerConfig
+	// SSH, when non-nil, launches the CLI on a remote machine over SSH and tunnels
+	// the stdio protocol through the connection. CLIPath, CLIArgs, and Cwd then refer
+	// to the remote machine. Requires stdio transport and an ssh client in PATH.
+	SSH *SSHConfig
+	// SessionIdleTimeout, when positive, destroys sessions that have had no activity
+	// (messages sent, events received, or turns in progress) for this long, freeing
+	// their CLI-side resources. Expired sessions are reported to lifecycle handlers
+	// as [SessionLifecycleExpired] events. Default: 0 (sessions never expire).
+	SessionIdleTimeout time.Duration
+	// SessionMemoryLimit, when positive, caps the estimated bytes each session
+	// holds in the SDK for undelivered [Session.Events] events and for
+	// [Session.ToolCalls] and [Session.PermissionLog] records. When a session
+	// exceeds it, the oldest buffered events and then the oldest records are
+	// discarded until the session is at three quarters of the limit. See
+	// [Session.Stats]. Default: 0 (no limit).
+	SessionMemoryLimit int64
+	// OnSessionMemoryLimit, when non-nil, is called on its own goroutine after a
+	// session was trimmed to SessionMemoryLimit, e.g. to compact the session's
+	// history in the CLI as well.
+	OnSessionMemoryLimit func(session *Session, stats SessionStats)
+	// JobStore, when non-nil, persists jobs submitted with [Session.Submit] so
+	// that [Client.Job] can find them after the client restarts.
+	JobStore JobStore
+	// MemoryStore, when non-nil, keeps long-term memories in the SDK for CLIs
+	// that do not support [FeatureMemory]. See [Session.Remember].
+	MemoryStore MemoryStore
+	// ResponseCache, when non-nil, reuses the responses of identical prompts sent
+	// with [Session.SendAndWait] in identical contexts. See [ResponseCacheConfig].
+	ResponseCache *ResponseCacheConfig
+	// SecretsProvider, when non-nil, resolves "${secret:<ref>}" references in
+	// SessionConfig.Env and in MCP server configurations when sessions are
+	// created or resumed. Resolved values are redacted by Redactor. When nil,
+	// references are passed to the CLI as written. See [SecretsProvider].
+	SecretsProvider SecretsProvider
+	// ApprovalTimeout bounds how long [Client.QueueApproval] waits for a queued
+	// permission request to be decided before denying it. Default: 0 (wait until
+	// decided or the client is stopped).
+	ApprovalTimeout time.Duration
+	// OnApprovalQueued, when non-nil, is called when a permission request enters
+	// the approval queue, e.g. to notify a reviewer.
+	OnApprovalQueued func(approval PendingApproval)
+	// DiagnosticsDir is where diagnostics bundles are written when the CLI crashes
+	// or violates the protocol. See [Client.LastDiagnostics]. Default: os.TempDir().
+	DiagnosticsDir string
+	// OnCLILog, when non-nil, is called with each line the CLI writes to stderr,
+	// parsed into a leveled record. The lines are also logged to Logger with the
+	// attribute source=cli. It is called from the goroutine reading stderr.
+	OnCLILog func(record CLILogRecord)
+	// Logger, when non-nil, receives structured logs for CLI process lifecycle,
+	// session transitions, failed RPCs, and, at debug level, all JSON-RPC traffic
+	// with credentials redacted. Default: logs are discarded.
+	Logger *slog.Logger
+	// Redactor removes secrets and personal data from logs, redacted wire dumps,
+	// diagnostics bundles, and [Session.Export] transcripts. Default: [redact.Default],
+	// which redacts tokens, API keys, private keys, and email addresses.
+	Redactor *redact.Redactor
+	// Pricing supplies the per-token rates used by [Session.EstimatedCost].
+	// Default: [pricing.Default], public list prices of common models.
+	Pricing pricing.Pricer
+}
+
+// SSHConfig configures running the Copilot CLI on a remote machine over SSH.
+type SSHConfig struct {
+	// Host is the remote host name or address (required; must not start with "-")
+	Host string
+	// User is the remote user name (default: ssh's default; must not start with "-")
+	User string
+	// Port is the remote SSH port (default: 22)
+	Port int
+	// KeyFile is the path to a private key file for authentication
+	// (default: ssh's configured identities and agent)
+	KeyFile string
+	// Args are extra arguments passed to ssh before the destination,
+	// for example []string{"-o", "StrictHostKeyChecking=accept-new"}
+	Args []string
+	// Command is the ssh executable to run (default: "ssh")
+	Command string
+}
+
+// RateLimitPolicy controls what happens when a message would exceed a rate limit.
+type RateLimitPolicy string
+
+const (
+	// RateLimitQueue waits until the message fits within the limits (default).
+	RateLimitQueue RateLimitPolicy = "queue"
+	// RateLimitReject fails the send immediately with a [*QuotaExceededError].
+	RateLimitReject RateLimitPolicy = "reject"
+)
+
+// QuotaLimit identifies which limit in a [RateLimitConfig] was exceeded.
+type QuotaLimit string
+
+const (
+	QuotaRequestsPerMinute QuotaLimit = "requests_per_minute"
+	QuotaTokensPerHour     QuotaLimit = "tokens_per_hour"
+	QuotaSessionMessages   QuotaLimit = "session_messages"
+)
+
+// RateLimitConfig configures client-level rate limiting and quota enforcement.
+// A zero value for any limit disables that limit.
+type RateLimitConfig struct {
+	// RequestsPerMinute caps messages sent across all sessions in any one-minute window.
+	RequestsPerMinute int
+	// TokensPerHour caps input plus output tokens, as reported by assistant.usage
+	// events, across all sessions in any one-hour window.
+	TokensPerHour int
+	// MaxMessagesPerSession caps the total number of messages sent to a single session.
+	// This limit does not reset while the session is in use, so sends that exceed it
+	// are always rejected. Sends the CLI fails do not count.
+	MaxMessagesPerSession int
+	// Policy selects whether to queue or reject sends that exceed a limit. Default: RateLimitQueue.
+	Policy RateLimitPolicy
+	// OnQuotaExceeded is called when a send exceeds a limit, before it is queued or rejected.
+	OnQuotaExceeded func(event QuotaExceededEvent)
+}
+
+// QuotaExceededEvent describes a send that exceeded a rate limit.
+type QuotaExceededEvent struct {
+	SessionID string
+	Limit     QuotaLimit
+	// RetryAfter is how long until the limit is expected to allow the send.
+	// Zero for limits that never reset.
+	RetryAfter time.Duration
+}
+
+// MetricsRegistry receives instrumentation callbacks from a [Client] and its sessions.
+// Implementations must be safe for concurrent use.
+type MetricsRegistry interface {
+	// ObserveRPC is called when a JSON-RPC request to the CLI completes.
+	ObserveRPC(method string, duration time.Duration, err error)
+	// MessageSent is called when a message is successfully sent to a session.
+	MessageSent()
+	// ToolCalled is called after a tool call is handled, with the result type ("success" or "failure").
+	ToolCalled(toolName string, resultType string)
+	// PermissionDenied is called when a permission request is denied, with the request kind.
+	PermissionDenied(kind string)
+	// CLIRestarted is called when the client spawns the CLI again after a previous run.
+	CLIRestarted()
 }
 
 // Bool returns a pointer to the given bool value.
@@ -104,6 +309,20 @@ type PermissionRequest struct {
 	Kind       string         `json:"kind"`
 	ToolCallID string         `json:"toolCallId,omitempty"`
 	Extra      map[string]any `json:"-"` // Additional fields vary by kind
+	// FileEdit describes the proposed change for "write" requests, and is nil otherwise.
+	FileEdit *FileEditPreview `json:"-"`
+}
+
+// FileEditPreview is the change a "write" permission request would make.
+type FileEditPreview struct {
+	// Path is the file to be written.
+	Path string
+	// Diff is the change as a unified diff.
+	Diff string
+	// NewContents is the full file content after the edit, when the CLI provides it.
+	NewContents string
+	// Intention is the agent's description of the edit.
+	Intention string
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for PermissionRequest
@@ -129,6 +348,19 @@ func (p *PermissionRequest) UnmarshalJSON(data []byte) error {
 	if len(raw) > 0 {
 		p.Extra = raw
 	}
+
+	if p.Kind == "write" {
+		str := func(key string) string {
+			value, _ := raw[key].(string)
+			return value
+		}
+		p.FileEdit = &FileEditPreview{
+			Path:        str("fileName"),
+			Diff:        str("diff"),
+			NewContents: str("newFileContents"),
+			Intention:   str("intention"),
+		}
+	}
 	return nil
 }
 
@@ -136,10 +368,17 @@ func (p *PermissionRequest) UnmarshalJSON(data []byte) error {
 type PermissionRequestResult struct {
 	Kind  string `json:"kind"`
 	Rules []any  `json:"rules,omitempty"`
+	// ModifiedContents, when set on an approved "write" request, is written to the
+	// file instead of the agent's proposed content. See [ApproveWithModifications].
+	ModifiedContents *string `json:"modifiedContents,omitempty"`
+	// Scope, when set, applies the decision to later requests for the same tool
+	// in the session, so that the user is not asked again. See [AlwaysAllowTool].
+	Scope PermissionScope `json:"-"`
 }
 
 // PermissionHandlerFunc executes a permission request
-// The handler should return a PermissionRequestResult. Returning an error denies the permission.
+// The handler should return a PermissionRequestResult. Returning an error denies the permission;
+// return a [*PermissionDeniedError] to deny it under a named rule.
 type PermissionHandlerFunc func(request PermissionRequest, invocation PermissionInvocation) (PermissionRequestResult, error)
 
 // PermissionInvocation provides context about a permission request
@@ -336,6 +575,22 @@ type CustomAgentConfig struct {
 	MCPServers map[string]MCPServerConfig `json:"mcpServers,omitempty"`
 	// Infer indicates whether the agent should be available for model inference
 	Infer *bool `json:"infer,omitempty"`
+	// Extends names another agent in the same list whose settings this agent
+	// inherits. See [ResolveAgents] for how they are merged.
+	Extends string `json:"-"`
+}
+
+// SandboxConfig configures the CLI's execution sandbox for the tool calls of a session.
+type SandboxConfig struct {
+	// AllowedPaths lists the files and directories tools may read and write, in
+	// addition to the session's working directory. Relative paths are resolved
+	// against the working directory. Empty allows only the working directory.
+	AllowedPaths []string `json:"allowedPaths,omitempty"`
+	// Network controls whether tools may make network connections (default: true).
+	Network *bool `json:"network,omitempty"`
+	// AllowedCommands lists the programs shell commands may run, by name or path,
+	// e.g. "go" or "/usr/bin/git". Empty allows all commands.
+	AllowedCommands []string `json:"allowedCommands,omitempty"`
 }
 
 // InfiniteSessionConfig configures infinite sessions with automatic context compaction
@@ -352,6 +607,39 @@ type InfiniteSessionConfig struct {
 	BufferExhaustionThreshold *float64 `json:"bufferExhaustionThreshold,omitempty"`
 }
 
+// AutoCompactConfig configures SDK-driven compaction of a session's history.
+//
+// When the session becomes idle and a threshold has been reached, the SDK calls
+// session.compaction.compact and dispatches a [SessionCompactionComplete] event
+// to the session's handlers with the result and Data.Source set to
+// [AutoCompactSource]; the CLI may report the same compaction with an event of
+// its own. Compaction holds the session's turn, so [Session.SendAndWait] waits
+// for it to finish. A zero value for any threshold disables that trigger.
+type AutoCompactConfig struct {
+	// TokenThreshold compacts once the context holds at least this many tokens,
+	// as reported by session.usage_info events.
+	TokenThreshold int
+	// MessageThreshold compacts once the context holds at least this many messages,
+	// as reported by session.usage_info events.
+	MessageThreshold int
+	// IdleTime compacts once the session has been idle for this long.
+	IdleTime time.Duration
+}
+
+// ToolCacheConfig enables caching of tool results within a session. Repeated calls
+// to a cached tool with the same key, such as reading the same file twice, return
+// the earlier result without running the handler again. Only successful results
+// are cached.
+type ToolCacheConfig struct {
+	// TTL is how long a result is reused. Zero means results do not expire.
+	TTL time.Duration
+	// Tools lists the tools whose results may be cached. If empty, all tools are cached.
+	Tools []string
+	// Key returns the cache key for an invocation, or false to bypass the cache.
+	// Default: the tool name and its JSON-encoded arguments.
+	Key func(invocation ToolInvocation) (string, bool)
+}
+
 // SessionConfig configures a new session
 type SessionConfig struct {
 	// SessionID is an optional custom session ID
@@ -361,6 +649,10 @@ type SessionConfig struct {
 	ClientName string
 	// Model to use for this session
 	Model string
+	// Locale is the BCP 47 language tag of the user, such as "fr-FR" or "ja",
+	// so that responses come back in their language. It is sent to the CLI and
+	// added as an instruction to the system message. See also MessageOptions.Locale.
+	Locale string
 	// ReasoningEffort level for models that support it.
 	// Valid values: "low", "medium", "high", "xhigh"
 	// Only applies to models where capabilities.supports.reasoningEffort is true.
@@ -382,6 +674,15 @@ type SessionConfig struct {
 	// If nil, all permission requests are denied by default.
 	// Provide a handler to approve operations (file writes, shell commands, URL fetches, etc.).
 	OnPermissionRequest PermissionHandlerFunc
+	// OnPermissionAudit, if set, is called with a record of every permission request
+	// after it is decided, e.g. to forward it to a SIEM. It runs on the goroutine that
+	// handles the request, so slow hooks delay the response to the CLI.
+	// Records are also available from [Session.PermissionLog].
+	OnPermissionAudit func(PermissionAuditRecord)
+	// PermissionStore, if set, persists the tool decisions made with a
+	// [PermissionScope] across sessions. Its decisions are loaded when the session
+	// starts and apply without calling OnPermissionRequest.
+	PermissionStore PermissionStore
 	// OnUserInputRequest is a handler for user input requests from the agent (enables ask_user tool)
 	OnUserInputRequest UserInputHandler
 	// Hooks configures hook handlers for session lifecycle events
@@ -406,6 +707,68 @@ type SessionConfig struct {
 	// InfiniteSessions configures infinite sessions for persistent workspaces and automatic compaction.
 	// When enabled (default), sessions automatically manage context limits and persist state.
 	InfiniteSessions *InfiniteSessionConfig
+	// MaxParallelTools limits how many tool handlers run concurrently when the model
+	// issues several tool calls at once. Zero means no limit.
+	MaxParallelTools int
+	// ToolTimeout bounds how long each tool handler may run. When it elapses, the
+	// handler's ToolInvocation.Context is canceled. Zero means no timeout.
+	ToolTimeout time.Duration
+	// AutoCompact, when non-nil, makes the SDK compact the session automatically.
+	AutoCompact *AutoCompactConfig
+	// TruncationPolicy, when non-nil, limits the history sent with each message
+	// without compacting it. See [TruncationPolicy].
+	TruncationPolicy *TruncationPolicy
+	// ModelFallbacks lists models to try, in order, when the session's model is
+	// unavailable or rate-limited. The SDK sends the turn again on the next model
+	// and sets Data.Model of the response returned by [Session.SendAndWait] to
+	// the model that answered.
+	ModelFallbacks []string
+	// AutoTitle names the conversation after its first exchange by asking the
+	// model for a short title, unless a title was already set. See
+	// [Session.GenerateTitle].
+	AutoTitle bool
+	// MaxQueuedMessages bounds how many messages [Session.Enqueue] holds while
+	// another message is in flight. Default: 16.
+	MaxQueuedMessages int
+	// Env sets environment variables for shell commands and other tool processes the
+	// CLI runs in this session, e.g. PATH, proxy settings, or credentials scoped to
+	// one tenant. Entries are added to, or replace, the CLI process environment.
+	Env map[string]string
+	// ClearEnv starts tool processes with only the variables in Env instead of
+	// inheriting the CLI process environment.
+	ClearEnv bool
+	// Sandbox restricts the files, network, and commands that tool calls in this
+	// session can use. Nil applies the CLI's default sandbox settings.
+	Sandbox *SandboxConfig
+	// Deterministic, when non-nil, makes the session's responses repeatable for
+	// tests. See [DeterministicConfig].
+	Deterministic *DeterministicConfig
+	// Metadata holds caller-defined key/value pairs, such as the tenant or user that owns
+	// the session. It is sent to the CLI, returned by [Session.Metadata], included in
+	// lifecycle events, and can be used to filter [Client.ListSessions].
+	Metadata map[string]string
+	// OnBeforeSend, if set, is called with every outgoing message before it is sent,
+	// including messages sent by SendAndWait and Enqueue. It may modify the message,
+	// e.g. to redact secrets or append policy text, or return an error to block it.
+	OnBeforeSend func(options *MessageOptions) error
+	// OnBeforeDeliver filters assistant output before it reaches event handlers,
+	// SendAndWait, and GetMessages. Filters run in order, each receiving the
+	// previous one's output. See [OutputFilter].
+	OnBeforeDeliver []OutputFilter
+	// ToolCache, when non-nil, caches tool results within the session.
+	ToolCache *ToolCacheConfig
+	// DryRun, when non-nil, records tool calls instead of executing them, so a
+	// host can preview what the agent would do. See [DryRunConfig].
+	DryRun *DryRunConfig
+	// ReviewEdits holds file writes proposed by the agent for the host application
+	// to review instead of sending them to OnPermissionRequest. Each write waits as a
+	// [PendingEdit] until [Session.ApplyEdits] or [Session.RejectEdits] is called.
+	ReviewEdits bool
+	// OnEditProposed, if set with ReviewEdits, is called with each new pending edit.
+	OnEditProposed func(edit PendingEdit)
+	// OnEventGap, if set, is called on its own goroutine when the session detects
+	// that events from the CLI were missed. It typically calls [Session.Resync].
+	OnEventGap func(session *Session, gap EventGap)
 }
 
 // Tool describes a caller-implemented tool that can be invoked by Copilot
@@ -422,6 +785,9 @@ type ToolInvocation struct {
 	ToolCallID string
 	ToolName   string
 	Arguments  any
+	// Context carries the call's cancellation, agent, message, and logger, and
+	// reports progress. It is set for every call the client passes to a handler.
+	Context *ToolContext
 }
 
 // ToolHandler executes a tool invocation.
@@ -445,6 +811,10 @@ type ResumeSessionConfig struct {
 	ClientName string
 	// Model to use for this session. Can change the model when resuming.
 	Model string
+	// Locale is the BCP 47 language tag of the user, such as "fr-FR" or "ja",
+	// so that responses come back in their language. It is sent to the CLI and
+	// added as an instruction to the system message. See also MessageOptions.Locale.
+	Locale string
 	// Tools exposes caller-implemented tools to the CLI
 	Tools []Tool
 	// SystemMessage configures system message customization
@@ -464,6 +834,15 @@ type ResumeSessionConfig struct {
 	// If nil, all permission requests are denied by default.
 	// Provide a handler to approve operations (file writes, shell commands, URL fetches, etc.).
 	OnPermissionRequest PermissionHandlerFunc
+	// OnPermissionAudit, if set, is called with a record of every permission request
+	// after it is decided, e.g. to forward it to a SIEM. It runs on the goroutine that
+	// handles the request, so slow hooks delay the response to the CLI.
+	// Records are also available from [Session.PermissionLog].
+	OnPermissionAudit func(PermissionAuditRecord)
+	// PermissionStore, if set, persists the tool decisions made with a
+	// [PermissionScope] across sessions. Its decisions are loaded when the session
+	// starts and apply without calling OnPermissionRequest.
+	PermissionStore PermissionStore
 	// OnUserInputRequest is a handler for user input requests from the agent (enables ask_user tool)
 	OnUserInputRequest UserInputHandler
 	// Hooks configures hook handlers for session lifecycle events
@@ -490,6 +869,64 @@ type ResumeSessionConfig struct {
 	// DisableResume, when true, skips emitting the session.resume event.
 	// Useful for reconnecting to a session without triggering resume-related side effects.
 	DisableResume bool
+	// MaxParallelTools limits how many tool handlers run concurrently when the model
+	// issues several tool calls at once. Zero means no limit.
+	MaxParallelTools int
+	// ToolTimeout bounds how long each tool handler may run. When it elapses, the
+	// handler's ToolInvocation.Context is canceled. Zero means no timeout.
+	ToolTimeout time.Duration
+	// AutoCompact, when non-nil, makes the SDK compact the session automatically.
+	AutoCompact *AutoCompactConfig
+	// TruncationPolicy, when non-nil, limits the history sent with each message
+	// without compacting it. See [TruncationPolicy].
+	TruncationPolicy *TruncationPolicy
+	// ModelFallbacks lists models to try, in order, when the session's model is
+	// unavailable or rate-limited. The SDK sends the turn again on the next model
+	// and sets Data.Model of the response returned by [Session.SendAndWait] to
+	// the model that answered.
+	ModelFallbacks []string
+	// MaxQueuedMessages bounds how many messages [Session.Enqueue] holds while
+	// another message is in flight. Default: 16.
+	MaxQueuedMessages int
+	// Env sets environment variables for shell commands and other tool processes the
+	// CLI runs in this session, e.g. PATH, proxy settings, or credentials scoped to
+	// one tenant. Entries are added to, or replace, the CLI process environment.
+	Env map[string]string
+	// ClearEnv starts tool processes with only the variables in Env instead of
+	// inheriting the CLI process environment.
+	ClearEnv bool
+	// Sandbox restricts the files, network, and commands that tool calls in this
+	// session can use. Nil applies the CLI's default sandbox settings.
+	Sandbox *SandboxConfig
+	// Deterministic, when non-nil, makes the session's responses repeatable for
+	// tests. See [DeterministicConfig].
+	Deterministic *DeterministicConfig
+	// Metadata holds caller-defined key/value pairs, such as the tenant or user that owns
+	// the session. It is sent to the CLI, returned by [Session.Metadata], included in
+	// lifecycle events, and can be used to filter [Client.ListSessions].
+	Metadata map[string]string
+	// OnBeforeSend, if set, is called with every outgoing message before it is sent,
+	// including messages sent by SendAndWait and Enqueue. It may modify the message,
+	// e.g. to redact secrets or append policy text, or return an error to block it.
+	OnBeforeSend func(options *MessageOptions) error
+	// OnBeforeDeliver filters assistant output before it reaches event handlers,
+	// SendAndWait, and GetMessages. Filters run in order, each receiving the
+	// previous one's output. See [OutputFilter].
+	OnBeforeDeliver []OutputFilter
+	// ToolCache, when non-nil, caches tool results within the session.
+	ToolCache *ToolCacheConfig
+	// DryRun, when non-nil, records tool calls instead of executing them, so a
+	// host can preview what the agent would do. See [DryRunConfig].
+	DryRun *DryRunConfig
+	// ReviewEdits holds file writes proposed by the agent for the host application
+	// to review instead of sending them to OnPermissionRequest. Each write waits as a
+	// [PendingEdit] until [Session.ApplyEdits] or [Session.RejectEdits] is called.
+	ReviewEdits bool
+	// OnEditProposed, if set with ReviewEdits, is called with each new pending edit.
+	OnEditProposed func(edit PendingEdit)
+	// OnEventGap, if set, is called on its own goroutine when the session detects
+	// that events from the CLI were missed. It typically calls [Session.Resync].
+	OnEventGap func(session *Session, gap EventGap)
 }
 
 // ProviderConfig configures a custom model provider
@@ -514,6 +951,9 @@ type ProviderConfig struct {
 type AzureProviderOptions struct {
 	// APIVersion is the Azure API version. Defaults to "2024-10-21".
 	APIVersion string `json:"apiVersion,omitempty"`
+	// Deployment is the Azure OpenAI deployment to use. It is sent as the
+	// session's Model when Model is empty.
+	Deployment string `json:"-"`
 }
 
 // ToolBinaryResult represents binary payloads returned by tools.
@@ -530,9 +970,64 @@ type MessageOptions struct {
 	Prompt string
 	// Attachments are file or directory attachments
 	Attachments []Attachment
+	// Images are in-memory images, such as screenshots, to attach to the message.
+	// They are scaled and encoded like [ImageAttachment] into temporary files
+	// that are removed when the session is destroyed.
+	Images []image.Image
 	// Mode is the message delivery mode (default: "enqueue")
 	Mode string
-}
+	// Timeout bounds how long [Session.SendAndWait] waits for the session to become idle.
+	// Defaults to 60 seconds when zero and the context has no deadline.
+	Timeout time.Duration
+	// PartialOnTimeout makes [Session.SendAndWait] return the content received so far
+	// together with an error matching [ErrTimeout] when the wait times out, instead of
+	// only an error. Partial content is assembled from assistant.message_delta events,
+	// so enable SessionConfig.Streaming to receive it mid-message.
+	PartialOnTimeout bool
+	// IdempotencyKey, when set, makes retries of this message safe. The session
+	// remembers keys of successful sends, and a later send with the same key returns
+	// the original message ID instead of starting another turn. Concurrent sends with
+	// the same key wait for the first. The key is also passed to the CLI.
+	IdempotencyKey string
+	// OnProgress, if set, is called by [Session.SendAndWait] each time the turn enters
+	// a new phase (queued, thinking, calling a tool, generating), e.g. to update a
+	// spinner. It is called from the SDK's event dispatch and should return quickly.
+	OnProgress func(Progress)
+	// FlushInterval controls how often [Session.SendTo] flushes a writer with a
+	// Flush method: zero flushes after every write, a positive interval flushes
+	// at most that often, and a negative one leaves flushing to the caller.
+	FlushInterval time.Duration
+	// Model overrides the session's model for this message only, e.g. to route a
+	// hard question to a larger model. Use [Client.ListModels] for valid IDs.
+	Model string
+	// Temperature overrides the sampling temperature for this message, from 0 to 2.
+	// Nil uses the model default.
+	Temperature *float64
+	// MaxOutputTokens bounds the length of the reply to this message. Zero uses the model default.
+	MaxOutputTokens int
+	// ReasoningEffort overrides the session's reasoning effort for this message.
+	// Valid values: "low", "medium", "high", "xhigh"
+	ReasoningEffort string
+	// HistoryWindow, when non-nil, restricts the history the model sees for this
+	// message to the last turns or to chosen messages. Requires a CLI that
+	// supports [FeatureHistoryWindow].
+	HistoryWindow *HistoryWindow
+	// Locale overrides SessionConfig.Locale for this message. A locale that
+	// differs from the session's is added as an instruction to the prompt.
+	Locale string
+	// SkipCache sends the message even if ClientOptions.ResponseCache holds a
+	// response for it, or earlier turns of the session were served from the
+	// cache. Later messages of the session are not cached either, since the
+	// cache cannot tell what the agent saw.
+	SkipCache bool
+}
+
+// OutputFilter scans or rewrites assistant output, e.g. to redact credentials or PII,
+// and returns the content to deliver. It is called with the content of
+// assistant.message and assistant.reasoning events and the delta content of their
+// streaming _delta variants; eventType tells them apart. Deltas are filtered one at
+// a time, so a filter only sees text split across deltas in the final message.
+type OutputFilter func(eventType SessionEventType, content string) string
 
 // SessionEventHandler is a callback for session events
 type SessionEventHandler func(event SessionEvent)
@@ -607,6 +1102,9 @@ type SessionListFilter struct {
 	Repository string `json:"repository,omitempty"`
 	// Branch filters by branch
 	Branch string `json:"branch,omitempty"`
+	// Metadata filters by session metadata. A session matches when it has every
+	// key with the given value.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // SessionMetadata contains metadata about a session
@@ -617,6 +1115,12 @@ type SessionMetadata struct {
 	Summary      *string         `json:"summary,omitempty"`
 	IsRemote     bool            `json:"isRemote"`
 	Context      *SessionContext `json:"context,omitempty"`
+	// Title is the conversation title. For sessions open in this client it is
+	// filled in locally when the CLI does not report it.
+	Title *string `json:"title,omitempty"`
+	// Metadata is the metadata the session was created with. For sessions open in
+	// this client it is filled in locally when the CLI does not report it.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // SessionLifecycleEventType represents the type of session lifecycle event
@@ -628,6 +1132,9 @@ const (
 	SessionLifecycleUpdated    SessionLifecycleEventType = "session.updated"
 	SessionLifecycleForeground SessionLifecycleEventType = "session.foreground"
 	SessionLifecycleBackground SessionLifecycleEventType = "session.background"
+	// SessionLifecycleExpired is emitted by the SDK, not the CLI, when a session is
+	// destroyed after ClientOptions.SessionIdleTimeout without activity.
+	SessionLifecycleExpired SessionLifecycleEventType = "session.expired"
 )
 
 // SessionLifecycleEvent represents a session lifecycle notification
@@ -635,6 +1142,9 @@ type SessionLifecycleEvent struct {
 	Type      SessionLifecycleEventType      `json:"type"`
 	SessionID string                         `json:"sessionId"`
 	Metadata  *SessionLifecycleEventMetadata `json:"metadata,omitempty"`
+	// SessionMetadata is the session's [SessionConfig.Metadata] when the session is
+	// open in this client.
+	SessionMetadata map[string]string `json:"-"`
 }
 
 // SessionLifecycleEventMetadata contains optional metadata for lifecycle events
@@ -681,6 +1191,12 @@ type createSessionRequest struct {
 	SkillDirectories  []string                   `json:"skillDirectories,omitempty"`
 	DisabledSkills    []string                   `json:"disabledSkills,omitempty"`
 	InfiniteSessions  *InfiniteSessionConfig     `json:"infiniteSessions,omitempty"`
+	Metadata          map[string]string          `json:"metadata,omitempty"`
+	Env               map[string]string          `json:"env,omitempty"`
+	ClearEnv          *bool                      `json:"clearEnv,omitempty"`
+	Sandbox           *SandboxConfig             `json:"sandbox,omitempty"`
+	Sampling          *samplingConfig            `json:"sampling,omitempty"`
+	Locale            string                     `json:"locale,omitempty"`
 }
 
 // createSessionResponse is the response from session.create
@@ -713,6 +1229,12 @@ type resumeSessionRequest struct {
 	SkillDirectories  []string                   `json:"skillDirectories,omitempty"`
 	DisabledSkills    []string                   `json:"disabledSkills,omitempty"`
 	InfiniteSessions  *InfiniteSessionConfig     `json:"infiniteSessions,omitempty"`
+	Metadata          map[string]string          `json:"metadata,omitempty"`
+	Env               map[string]string          `json:"env,omitempty"`
+	ClearEnv          *bool                      `json:"clearEnv,omitempty"`
+	Sandbox           *SandboxConfig             `json:"sandbox,omitempty"`
+	Sampling          *samplingConfig            `json:"sampling,omitempty"`
+	Locale            string                     `json:"locale,omitempty"`
 }
 
 // resumeSessionResponse is the response from session.resume
@@ -788,6 +1310,32 @@ type GetStatusResponse struct {
 	ProtocolVersion int    `json:"protocolVersion"`
 }
 
+// HealthStatus reports the health of a [Client] and its sessions.
+// It is returned by [Client.Health] for use in readiness and liveness probes.
+type HealthStatus struct {
+	// State is the client's connection state
+	State ConnectionState
+	// CLIVersion is the version reported by the CLI (empty if unreachable)
+	CLIVersion string
+	// ProtocolVersion is the protocol version reported by the CLI (zero if unreachable)
+	ProtocolVersion int
+	// Uptime is how long the client has been connected
+	Uptime time.Duration
+	// Latency is the round-trip time of the health check ping
+	Latency time.Duration
+	// Sessions reports each session tracked by the client
+	Sessions []SessionHealth
+}
+
+// SessionHealth reports activity counts for a single session.
+type SessionHealth struct {
+	SessionID string
+	// MessagesSent is the number of messages sent to the session by this client
+	MessagesSent int
+	// Busy reports whether the session is processing a message
+	Busy bool
+}
+
 // getAuthStatusRequest is the request for auth.getStatus
 type getAuthStatusRequest struct{}
 
@@ -798,6 +1346,9 @@ type GetAuthStatusResponse struct {
 	Host            *string `json:"host,omitempty"`
 	Login           *string `json:"login,omitempty"`
 	StatusMessage   *string `json:"statusMessage,omitempty"`
+	// Organization is the organization selected for Copilot access, when the
+	// account belongs to several.
+	Organization *string `json:"organization,omitempty"`
 }
 
 // listModelsRequest is the request for models.list
@@ -828,11 +1379,33 @@ type sessionAbortRequest struct {
 	SessionID string `json:"sessionId"`
 }
 
+// sessionForkRequest is the request for session.fork
+type sessionForkRequest struct {
+	SessionID string `json:"sessionId"`
+}
+
+// sessionForkResponse is the response from session.fork
+type sessionForkResponse struct {
+	SessionID     string `json:"sessionId"`
+	WorkspacePath string `json:"workspacePath"`
+}
+
 type sessionSendRequest struct {
-	SessionID   string       `json:"sessionId"`
-	Prompt      string       `json:"prompt"`
-	Attachments []Attachment `json:"attachments,omitempty"`
-	Mode        string       `json:"mode,omitempty"`
+	SessionID      string       `json:"sessionId"`
+	Prompt         string       `json:"prompt"`
+	Attachments    []Attachment `json:"attachments,omitempty"`
+	Mode           string       `json:"mode,omitempty"`
+	IdempotencyKey string       `json:"idempotencyKey,omitempty"`
+	// Per-message overrides of the session's model settings
+	Model           string   `json:"model,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	ReasoningEffort string   `json:"reasoningEffort,omitempty"`
+	// Slice of the history the model sees for this message
+	HistoryWindow *HistoryWindow `json:"historyWindow,omitempty"`
+	Locale        string         `json:"locale,omitempty"`
+	// When the rate limiter counted the message, to release it if not sent
+	limited time.Time
 }
 
 // sessionSendResponse is the response from session.send
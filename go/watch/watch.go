@@ -0,0 +1,339 @@
+// Package watch keeps a long-running session up to date with changes to
+// workspace files. It watches files and directories for changes and pushes each
+// batch of changes, with the new contents of small text files, into the
+// session's context, so the agent sees current file contents without being told
+// to re-read them.
+//
+// Example:
+//
+//	w, err := watch.Start(ctx, session, &watch.Options{Paths: []string{"."}})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer w.Close()
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+
+	copilot "github.com/github/copilot-sdk/go"
+	"github.com/github/copilot-sdk/go/rpc"
+)
+
+// ContextKind is the kind of the context items pushed to the session.
+const ContextKind = "fileChanges"
+
+// Op describes how a file changed.
+type Op string
+
+const (
+	Created  Op = "created"
+	Modified Op = "modified"
+	Removed  Op = "removed"
+)
+
+// Change is a change to a watched file.
+type Change struct {
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+	Op   Op     `json:"op"`
+	// Content is the file's new content for created and modified text files no
+	// larger than Options.MaxContentSize, and empty otherwise.
+	Content string `json:"content,omitempty"`
+	// Truncated is set when the file was too large or not text, so Content was omitted.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Options configures [Start].
+type Options struct {
+	// Paths are the files and directories to watch. Directories are watched
+	// recursively, including directories created later. Default: the current
+	// working directory.
+	Paths []string
+	// Ignore reports whether a path should not be watched or reported. Ignored
+	// directories are not descended into. Default: [DefaultIgnore].
+	Ignore func(path string) bool
+	// Debounce is how long to wait after a change for further changes before
+	// pushing them to the session together. Default: 500ms.
+	Debounce time.Duration
+	// MaxContentSize is the largest file whose content is included in a change.
+	// Default: 64 KiB. Negative values never include content.
+	MaxContentSize int64
+	// OnChange, if set, is called with each batch of changes after it was pushed.
+	OnChange func([]Change)
+	// OnError, if set, is called with errors from the file watcher and from
+	// pushing changes. Watching continues after errors.
+	OnError func(error)
+}
+
+// DefaultIgnore ignores version control directories, dependency directories,
+// and editor swap and backup files.
+func DefaultIgnore(path string) bool {
+	base := filepath.Base(path)
+	switch base {
+	case ".git", ".hg", ".svn", "node_modules", "vendor", ".idea", ".vscode":
+		return true
+	}
+	if ext := filepath.Ext(base); ext == ".swp" || ext == ".swx" || ext == ".tmp" {
+		return true
+	}
+	return base[len(base)-1] == '~' || (len(base) > 1 && base[0] == '#' && base[len(base)-1] == '#')
+}
+
+// Watcher pushes file changes to a session until closed. Create one with [Start].
+type Watcher struct {
+	session *copilot.Session
+	opts    Options
+	fs      *fsnotify.Watcher
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]Op
+}
+
+// Start watches the configured paths and pushes changes to session until ctx is
+// done or the watcher is closed.
+func Start(ctx context.Context, session *copilot.Session, options *Options) (*Watcher, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{"."}
+	}
+	if opts.Ignore == nil {
+		opts.Ignore = DefaultIgnore
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = 500 * time.Millisecond
+	}
+	if opts.MaxContentSize == 0 {
+		opts.MaxContentSize = 64 << 10
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watcher{
+		session: session,
+		opts:    opts,
+		fs:      fsw,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		pending: make(map[string]Op),
+	}
+	for _, path := range opts.Paths {
+		abs, err := filepath.Abs(path)
+		if err == nil {
+			err = w.add(abs)
+		}
+		if err != nil {
+			cancel()
+			fsw.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching. Changes not yet pushed are discarded.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+// add watches path, and everything below it if it is a directory.
+func (w *Watcher) add(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return w.fs.Add(path)
+	}
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != path && w.opts.Ignore(p) {
+			return filepath.SkipDir
+		}
+		return w.fs.Add(p)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	defer w.fs.Close()
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	for {
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+			return
+		case err := <-w.fs.Errors:
+			w.reportError(err)
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if w.record(event) {
+				timer.Reset(w.opts.Debounce)
+			}
+		case <-timer.C:
+			w.flush()
+		}
+	}
+}
+
+// record adds a file system event to the pending changes and reports whether
+// it was relevant.
+func (w *Watcher) record(event fsnotify.Event) bool {
+	if w.opts.Ignore(event.Name) {
+		return false
+	}
+	var op Op
+	switch {
+	case event.Has(fsnotify.Create):
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			// Watch new directories, and report files created in them before the watch started
+			if err := w.add(event.Name); err != nil {
+				w.reportError(err)
+			}
+			filepath.WalkDir(event.Name, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && !w.opts.Ignore(p) {
+					w.setPending(p, Created)
+				}
+				return nil
+			})
+			return true
+		}
+		op = Created
+	case event.Has(fsnotify.Write):
+		op = Modified
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		op = Removed
+	default:
+		return false
+	}
+	w.setPending(event.Name, op)
+	return true
+}
+
+func (w *Watcher) setPending(path string, op Op) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	previous, ok := w.pending[path]
+	switch {
+	case !ok:
+		w.pending[path] = op
+	case previous == Created && op == Modified:
+		// Still new to the agent
+	case previous == Created && op == Removed:
+		delete(w.pending, path)
+	case previous == Removed && op == Created:
+		w.pending[path] = Modified
+	default:
+		w.pending[path] = op
+	}
+}
+
+// flush pushes the pending changes to the session.
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]Op)
+	w.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	changes := make([]Change, 0, len(pending))
+	for _, path := range slices.Sorted(maps.Keys(pending)) {
+		change := Change{Path: path, Op: pending[path]}
+		if change.Op != Removed {
+			change.Content, change.Truncated = w.readContent(path)
+		}
+		changes = append(changes, change)
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	var items []any
+	if err := json.Unmarshal(encoded, &items); err != nil {
+		w.reportError(err)
+		return
+	}
+	name := fmt.Sprintf("%d files changed", len(changes))
+	if len(changes) == 1 {
+		name = fmt.Sprintf("%s %s", filepath.Base(changes[0].Path), changes[0].Op)
+	}
+	_, err = w.session.RPC.Context.Add(w.ctx, &rpc.SessionContextAddParams{
+		Kind: ContextKind,
+		Name: name,
+		Data: map[string]any{"changes": items},
+	})
+	if err != nil {
+		if w.ctx.Err() == nil {
+			w.reportError(fmt.Errorf("failed to push file changes: %w", err))
+		}
+		return
+	}
+	if w.opts.OnChange != nil {
+		w.opts.OnChange(changes)
+	}
+}
+
+// readContent returns the content of a small text file, or reports that it was
+// left out.
+func (w *Watcher) readContent(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	if w.opts.MaxContentSize < 0 || info.Size() > w.opts.MaxContentSize {
+		return "", true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	if !utf8.Valid(data) || slices.Contains(data, 0) {
+		return "", true
+	}
+	return string(data), false
+}
+
+func (w *Watcher) reportError(err error) {
+	if err != nil && w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
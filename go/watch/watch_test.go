@@ -0,0 +1,111 @@
+package watch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/playbacktest"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	log := &playbacktest.Log{}
+	log.Handshake()
+	log.Call("session.create", map[string]any{"sessionId": "s1"})
+	log.Call("session.context.add", map[string]any{"id": "c1"})
+	log.Call("session.context.add", map[string]any{"id": "c2"})
+	session := playbacktest.NewSession(t, log, nil)
+
+	batches := make(chan []Change, 2)
+	errs := make(chan error, 10)
+	w, err := Start(t.Context(), session, &Options{
+		Paths:          []string{dir},
+		Debounce:       200 * time.Millisecond,
+		MaxContentSize: 32,
+		OnChange:       func(changes []Change) { batches <- changes },
+		OnError:        func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	defer w.Close()
+	next := func() []Change {
+		t.Helper()
+		select {
+		case changes := <-batches:
+			return changes
+		case err := <-errs:
+			t.Fatalf("Watcher error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for changes")
+		}
+		return nil
+	}
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "util.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, ".git", "index"), "ignored")
+	changes := next()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Path != filepath.Join(dir, "main.go") || changes[0].Op != Modified || changes[0].Content != "package main\n\nfunc main() {}\n" {
+		t.Errorf("Unexpected change: %+v", changes[0])
+	}
+	if changes[1].Path != filepath.Join(dir, "util.go") || changes[1].Op != Created {
+		t.Errorf("Unexpected change: %+v", changes[1])
+	}
+
+	// New directories are watched, and large files are reported without content
+	if err := os.Mkdir(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "pkg", "big.go"), string(bytes.Repeat([]byte("x"), 100)))
+	if err := os.Remove(filepath.Join(dir, "util.go")); err != nil {
+		t.Fatal(err)
+	}
+	changes = next()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Path != filepath.Join(dir, "pkg", "big.go") || changes[0].Op != Created || !changes[0].Truncated || changes[0].Content != "" {
+		t.Errorf("Unexpected change: %+v", changes[0])
+	}
+	if changes[1].Path != filepath.Join(dir, "util.go") || changes[1].Op != Removed {
+		t.Errorf("Unexpected change: %+v", changes[1])
+	}
+}
+
+func TestDefaultIgnore(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/repo/.git":              true,
+		"/repo/web/node_modules":  true,
+		"/repo/main.go.swp":       true,
+		"/repo/main.go~":          true,
+		"/repo/#main.go#":         true,
+		"/repo/main.go":           false,
+		"/repo/internal/vendorer": false,
+	} {
+		if DefaultIgnore(path) != expected {
+			t.Errorf("DefaultIgnore(%q) = %v", path, !expected)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package copilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/github/copilot-sdk/go/internal/jsonrpc2"
+	"github.com/github/copilot-sdk/go/redact"
+)
+
+// WireDumpOptions controls how frames are written to [ClientOptions.WireDump].
+type WireDumpOptions struct {
+	// Pretty indents each JSON-RPC message over multiple lines.
+	Pretty bool
+	// Redact replaces tokens, API keys, and other credential-like fields with "[REDACTED]",
+	// and secrets in other values as configured by ClientOptions.Redactor.
+	Redact bool
+}
+
+// wireDumper writes JSON-RPC frames in a human-readable form for protocol debugging.
+type wireDumper struct {
+	w        io.Writer
+	options  WireDumpOptions
+	redactor *redact.Redactor // ClientOptions.Redactor; nil means redact.Default
+}
+
+// observe is a jsonrpc2.FrameObserver. Frames arrive serialized by the JSON-RPC client,
+// so no additional locking is needed here.
+func (d *wireDumper) observe(direction jsonrpc2.FrameDirection, data []byte) {
+	arrow := "<--"
+	if direction == jsonrpc2.FrameSent {
+		arrow = "-->"
+	}
+
+	body := data
+	if d.options.Redact {
+		body = []byte(redactFrame(d.redactor, data))
+	}
+	if d.options.Pretty {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			body = indented.Bytes()
+		}
+	}
+
+	fmt.Fprintf(d.w, "%s %s Content-Length: %d\n%s\n\n", time.Now().UTC().Format(time.RFC3339Nano), arrow, len(data), body)
+}
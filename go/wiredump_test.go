@@ -0,0 +1,57 @@
+package copilot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestClient_WireDump(t *testing.T) {
+	log := &replayLog{}
+	log.handshake()
+
+	var dump bytes.Buffer
+	client, err := NewPlaybackClient(bytes.NewReader(log.buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create playback client: %v", err)
+	}
+	client.options.WireDump = &dump
+	client.options.WireDumpOptions = WireDumpOptions{Pretty: true}
+	t.Cleanup(func() { client.ForceStop() })
+	if err := client.Start(t.Context()); err != nil {
+		t.Fatalf("Failed to start client: %v", err)
+	}
+
+	output := dump.String()
+	sent := strings.Index(output, "--> Content-Length: ")
+	received := strings.Index(output, "<-- Content-Length: ")
+	if sent < 0 || received < sent {
+		t.Fatalf("Expected a sent frame followed by a received frame, got:\n%s", output)
+	}
+	if !strings.Contains(output, "\n  \"method\": \"ping\"") {
+		t.Errorf("Expected pretty-printed ping request, got:\n%s", output)
+	}
+}
+
+func TestWireDumper(t *testing.T) {
+	frame := []byte(`{"jsonrpc":"2.0","id":"1","method":"session.create","params":{"provider":{"apiKey":"sk-secret"}}}`)
+
+	t.Run("writes frames verbatim by default", func(t *testing.T) {
+		var dump bytes.Buffer
+		(&wireDumper{w: &dump}).observe("send", frame)
+		if !strings.Contains(dump.String(), string(frame)) {
+			t.Errorf("Expected raw frame, got:\n%s", dump.String())
+		}
+	})
+
+	t.Run("redacts credentials", func(t *testing.T) {
+		var dump bytes.Buffer
+		(&wireDumper{w: &dump, options: WireDumpOptions{Redact: true}}).observe("recv", frame)
+		if strings.Contains(dump.String(), "sk-secret") || !strings.Contains(dump.String(), "[REDACTED]") {
+			t.Errorf("Expected API key to be redacted, got:\n%s", dump.String())
+		}
+		if !strings.Contains(dump.String(), "<-- Content-Length: ") {
+			t.Errorf("Expected received frame header, got:\n%s", dump.String())
+		}
+	})
+}
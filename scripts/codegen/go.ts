@@ -213,6 +213,8 @@ function emitRpcWrapper(lines: string[], node: Record<string, unknown>, isSessio
     lines.push(`type ${wrapperName} struct {`);
     lines.push(`    client *jsonrpc2.Client`);
     if (isSession) lines.push(`    sessionID string`);
+    // Hand-written bindings for methods missing from the schema (go/rpc/extra_rpc.go)
+    lines.push(`    *${wrapperName}Extensions`);
     for (const [groupName] of groups) {
         lines.push(`    ${toPascalCase(groupName)} *${toPascalCase(groupName)}${apiSuffix}`);
     }
@@ -230,6 +232,8 @@ function emitRpcWrapper(lines: string[], node: Record<string, unknown>, isSessio
     const ctorFields = isSession ? "client: client, sessionID: sessionID," : "client: client,";
     lines.push(`func New${wrapperName}(${ctorParams}) *${wrapperName} {`);
     lines.push(`    return &${wrapperName}{${ctorFields}`);
+    const extArgs = isSession ? "client, sessionID" : "client";
+    lines.push(`        ${wrapperName}Extensions: new${wrapperName}Extensions(${extArgs}),`);
     for (const [groupName] of groups) {
         const apiInit = isSession
             ? `&${toPascalCase(groupName)}${apiSuffix}{client: client, sessionID: sessionID}`
@@ -275,10 +279,10 @@ function emitMethod(lines: string[], receiver: string, name: string, method: Rpc
             }
             lines.push(`    }`);
         }
-        lines.push(`    raw, err := a.client.Request("${method.rpcMethod}", req)`);
+        lines.push(`    raw, err := a.client.RequestContext(ctx, "${method.rpcMethod}", req)`);
     } else {
         const arg = hasParams ? "params" : "map[string]interface{}{}";
-        lines.push(`    raw, err := a.client.Request("${method.rpcMethod}", ${arg})`);
+        lines.push(`    raw, err := a.client.RequestContext(ctx, "${method.rpcMethod}", ${arg})`);
     }
 
     lines.push(`    if err != nil { return nil, err }`);
@@ -0,0 +1,99 @@
+/*---------------------------------------------------------------------------------------------
+ *  Copyright (c) Microsoft Corporation. All rights reserved.
+ *--------------------------------------------------------------------------------------------*/
+
+/**
+ * Protocol schema generator for the Go SDK's StrictProtocol option.
+ *
+ * Merges the SDK protocol methods described in sdk-protocol.schema.json with
+ * the RPC methods of api.schema.json into go/schemas/protocol-v<N>.json.
+ */
+
+import fs from "fs/promises";
+import path from "path";
+import type { JSONSchema7, JSONSchema7Definition } from "json-schema";
+import { REPO_ROOT, getApiSchemaPath, isRpcMethod, writeGeneratedFile, type ApiSchema, type RpcMethod } from "./utils.js";
+
+interface ProtocolSchemaFile {
+    $comment?: string;
+    protocolVersion: number;
+    $defs: Record<string, JSONSchema7Definition>;
+    methods: Record<string, { params?: JSONSchema7; result?: JSONSchema7 }>;
+    serverMethods: Record<string, { params?: JSONSchema7; result?: JSONSchema7 }>;
+}
+
+function collectRpcMethods(node: Record<string, unknown>): RpcMethod[] {
+    const results: RpcMethod[] = [];
+    for (const value of Object.values(node)) {
+        if (isRpcMethod(value)) {
+            results.push(value);
+        } else if (typeof value === "object" && value !== null) {
+            results.push(...collectRpcMethods(value as Record<string, unknown>));
+        }
+    }
+    return results;
+}
+
+/**
+ * Drop `additionalProperties: false`, so that new optional fields in the CLI
+ * are not reported as violations.
+ */
+function allowAdditionalProperties(schema: unknown): unknown {
+    if (Array.isArray(schema)) return schema.map(allowAdditionalProperties);
+    if (typeof schema !== "object" || schema === null) return schema;
+    const result: Record<string, unknown> = {};
+    for (const [key, value] of Object.entries(schema)) {
+        if (key === "additionalProperties" && value === false) continue;
+        result[key] = allowAdditionalProperties(value);
+    }
+    return result;
+}
+
+function sortKeys<T>(record: Record<string, T>): Record<string, T> {
+    return Object.fromEntries(Object.entries(record).sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0)));
+}
+
+async function generateProtocolSchema(apiSchemaPath?: string): Promise<void> {
+    console.log("Protocol: generating schema...");
+
+    const sdkSchemaPath = path.join(REPO_ROOT, "scripts/codegen/sdk-protocol.schema.json");
+    const sdk = JSON.parse(await fs.readFile(sdkSchemaPath, "utf-8")) as ProtocolSchemaFile;
+    const methods = { ...sdk.methods };
+
+    try {
+        const resolvedPath = await getApiSchemaPath(apiSchemaPath);
+        const api = JSON.parse(await fs.readFile(resolvedPath, "utf-8")) as ApiSchema;
+        for (const method of [...collectRpcMethods(api.server || {}), ...collectRpcMethods(api.session || {})]) {
+            methods[method.rpcMethod] = allowAdditionalProperties({
+                ...(method.params ? { params: method.params } : {}),
+                result: method.result,
+            }) as { params?: JSONSchema7; result?: JSONSchema7 };
+        }
+    } catch (err) {
+        if ((err as NodeJS.ErrnoException).code === "ENOENT" && !apiSchemaPath) {
+            console.log("Protocol: api.schema.json not found, writing the SDK protocol methods only");
+        } else {
+            throw err;
+        }
+    }
+
+    const output: ProtocolSchemaFile = {
+        $comment:
+            "AUTO-GENERATED FILE - DO NOT EDIT. Generated by scripts/codegen/protocol.ts from sdk-protocol.schema.json and api.schema.json.",
+        protocolVersion: sdk.protocolVersion,
+        $defs: sdk.$defs,
+        methods: sortKeys(methods),
+        serverMethods: sortKeys(sdk.serverMethods),
+    };
+    const outPath = await writeGeneratedFile(
+        `go/schemas/protocol-v${sdk.protocolVersion}.json`,
+        JSON.stringify(output, null, 2) + "\n"
+    );
+    console.log(`  ✓ ${outPath}`);
+}
+
+const apiArg = process.argv[2] || undefined;
+generateProtocolSchema(apiArg).catch((err) => {
+    console.error("Protocol schema generation failed:", err);
+    process.exit(1);
+});